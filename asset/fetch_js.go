@@ -0,0 +1,120 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package asset
+
+import (
+	"fmt"
+	"strconv"
+	"syscall/js"
+
+	"github.com/hajimehoshi/ebiten/v2/internal/web"
+)
+
+var ignoreError = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+	return nil
+})
+
+func openCache(cacheName string) js.Value {
+	if cacheName == "" {
+		return js.Undefined()
+	}
+	caches := js.Global().Get("caches")
+	if !caches.Truthy() {
+		// Cache Storage is not available e.g. in an insecure context.
+		return js.Undefined()
+	}
+	cache, err := web.AwaitPromise(caches.Call("open", cacheName))
+	if err != nil {
+		return js.Undefined()
+	}
+	return cache
+}
+
+func fetch(path string, cacheName string, progress func(loaded, total int64)) ([]byte, error) {
+	cache := openCache(cacheName)
+
+	var res js.Value
+	if cache.Truthy() {
+		if r, err := web.AwaitPromise(cache.Call("match", path)); err == nil && r.Truthy() {
+			res = r
+		}
+	}
+
+	if !res.Truthy() {
+		r, err := web.AwaitPromise(js.Global().Call("fetch", path))
+		if err != nil {
+			return nil, fmt.Errorf("asset: fetching %s failed: %w", path, err)
+		}
+		if !r.Get("ok").Bool() {
+			return nil, fmt.Errorf("asset: fetching %s failed: status %d", path, r.Get("status").Int())
+		}
+		if cache.Truthy() {
+			// Put a clone since the body of a response can be read only once.
+			// An error in putting is ignored as caching is optional.
+			cache.Call("put", path, r.Call("clone")).Call("catch", ignoreError)
+		}
+		res = r
+	}
+
+	// The size is unknown when Content-Length is not given. If the response is compressed, Content-Length is the
+	// compressed size and the actual size might be larger.
+	total := int64(-1)
+	if l := res.Get("headers").Call("get", "Content-Length"); l.Type() == js.TypeString {
+		if n, err := strconv.ParseInt(l.String(), 10, 64); err == nil {
+			total = n
+		}
+	}
+	progress(0, total)
+
+	body := res.Get("body")
+	if !body.Truthy() {
+		// Streaming is not supported. Read the whole body at once.
+		buf, err := web.AwaitPromise(res.Call("arrayBuffer"))
+		if err != nil {
+			return nil, fmt.Errorf("asset: reading %s failed: %w", path, err)
+		}
+		a := js.Global().Get("Uint8Array").New(buf)
+		data := make([]byte, a.Length())
+		js.CopyBytesToGo(data, a)
+		return data, nil
+	}
+
+	var data []byte
+	if total > 0 {
+		data = make([]byte, 0, total)
+	}
+	reader := body.Call("getReader")
+	for {
+		r, err := web.AwaitPromise(reader.Call("read"))
+		if err != nil {
+			return nil, fmt.Errorf("asset: reading %s failed: %w", path, err)
+		}
+		if r.Get("done").Bool() {
+			break
+		}
+		chunk := r.Get("value")
+		n := chunk.Length()
+		data = append(data, make([]byte, n)...)
+		js.CopyBytesToGo(data[len(data)-n:], chunk)
+
+		loaded := int64(len(data))
+		t := total
+		if t >= 0 && loaded > t {
+			t = loaded
+		}
+		progress(loaded, t)
+	}
+	return data, nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !js
+// +build !js
+
+package asset
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// progressReader reports the progress of reading.
+type progressReader struct {
+	r        io.Reader
+	loaded   int64
+	total    int64
+	progress func(loaded, total int64)
+}
+
+func (p *progressReader) Read(buf []byte) (int, error) {
+	n, err := p.r.Read(buf)
+	p.loaded += int64(n)
+	if p.total >= 0 && p.loaded > p.total {
+		p.total = p.loaded
+	}
+	if n > 0 {
+		p.progress(p.loaded, p.total)
+	}
+	return n, err
+}
+
+func fetch(path string, cacheName string, progress func(loaded, total int64)) ([]byte, error) {
+	// cacheName is ignored as Cache Storage is available only on browsers.
+
+	var r io.Reader
+	total := int64(-1)
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		res, err := http.Get(path)
+		if err != nil {
+			return nil, fmt.Errorf("asset: fetching %s failed: %w", path, err)
+		}
+		defer res.Body.Close()
+		if res.StatusCode < 200 || res.StatusCode >= 300 {
+			return nil, fmt.Errorf("asset: fetching %s failed: status %d", path, res.StatusCode)
+		}
+		r = res.Body
+		total = res.ContentLength
+	} else {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		if s, err := f.Stat(); err == nil {
+			total = s.Size()
+		}
+		r = f
+	}
+
+	progress(0, total)
+	var buf bytes.Buffer
+	if total > 0 {
+		buf.Grow(int(total))
+	}
+	if _, err := buf.ReadFrom(&progressReader{r: r, total: total, progress: progress}); err != nil {
+		return nil, fmt.Errorf("asset: reading %s failed: %w", path, err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,30 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package hotreload provides a loader that reloads assets when the source files change, for development.
+//
+// A Reloader loads images, shaders and audio from an fs.FS, and polls the modification of the files. When a file
+// changes, the Reloader reloads the file into the existing handle, i.e. the same *ebiten.Image, *ebiten.Shader or
+// *hotreload.AudioSource, so the game can keep using the handles without restarting:
+//
+//     r := hotreload.New(os.DirFS("assets"), nil)
+//     player, _ := r.Image("player.png")
+//
+//     // In Update
+//     r.Update()
+//
+// As polling files costs, a Reloader is intended to be used only in development.
+//
+// hotreload requires Go 1.16 or later.
+package hotreload
@@ -0,0 +1,434 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.16
+// +build go1.16
+
+package hotreload
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/audio/mp3"
+	"github.com/hajimehoshi/ebiten/v2/audio/vorbis"
+	"github.com/hajimehoshi/ebiten/v2/audio/wav"
+	"github.com/hajimehoshi/ebiten/v2/internal/hotswap"
+)
+
+// DefaultInterval is the default interval to check the modification of the files.
+const DefaultInterval = 500 * time.Millisecond
+
+// Options represents options for a Reloader.
+type Options struct {
+	// Interval is the interval to check the modification of the files.
+	// If Interval is 0, DefaultInterval is used.
+	Interval time.Duration
+
+	// OnReload is called in Update when a file is reloaded.
+	// If reloading fails, err is not nil and the handle keeps the old content.
+	OnReload func(name string, err error)
+}
+
+type assetKind int
+
+const (
+	assetKindImage assetKind = iota
+	assetKindShader
+	assetKindAudio
+)
+
+func (k assetKind) String() string {
+	switch k {
+	case assetKindImage:
+		return "image"
+	case assetKindShader:
+		return "shader"
+	case assetKindAudio:
+		return "audio"
+	}
+	return fmt.Sprintf("assetKind(%d)", int(k))
+}
+
+type entry struct {
+	name    string
+	kind    assetKind
+	modTime time.Time
+	size    int64
+
+	image      *ebiten.Image
+	shader     *ebiten.Shader
+	audio      *AudioSource
+	sampleRate int
+}
+
+// reloaded is the result of reloading a file in background.
+type reloaded struct {
+	entry *entry
+	image *image.RGBA
+	data  []byte
+	err   error
+}
+
+// Reloader loads assets and reloads them when the source files change.
+type Reloader struct {
+	fsys    fs.FS
+	options Options
+
+	entries map[string]*entry
+	results []*reloaded
+
+	done      chan struct{}
+	closeOnce sync.Once
+
+	m sync.Mutex
+}
+
+// New creates a new Reloader to load files from fsys, and starts watching the files.
+//
+// If options is nil, the default options are used.
+func New(fsys fs.FS, options *Options) *Reloader {
+	r := &Reloader{
+		fsys:    fsys,
+		entries: map[string]*entry{},
+		done:    make(chan struct{}),
+	}
+	if options != nil {
+		r.options = *options
+	}
+	if r.options.Interval <= 0 {
+		r.options.Interval = DefaultInterval
+	}
+	go r.loop()
+	return r
+}
+
+// Close stops watching the files. The loaded handles are still available.
+//
+// Close is concurrent-safe.
+func (r *Reloader) Close() {
+	r.closeOnce.Do(func() {
+		close(r.done)
+	})
+}
+
+// Image loads an image file and returns the image.
+// If the image is already loaded, Image returns the same image.
+//
+// When the file changes, the image's pixels are replaced. If the size of the image changes, the content of the
+// image is swapped, and the sub-images of the image created before reloading still refer to the old content.
+//
+// Image is concurrent-safe.
+func (r *Reloader) Image(name string) (*ebiten.Image, error) {
+	e, err := r.load(name, assetKindImage, 0)
+	if err != nil {
+		return nil, err
+	}
+	return e.image, nil
+}
+
+// Shader loads a Kage shader file and returns the shader.
+// If the shader is already loaded, Shader returns the same shader.
+//
+// When the file changes, the shader is recompiled and swapped. If the compilation fails, the shader keeps the old
+// program.
+//
+// Shader is concurrent-safe.
+func (r *Reloader) Shader(name string) (*ebiten.Shader, error) {
+	e, err := r.load(name, assetKindShader, 0)
+	if err != nil {
+		return nil, err
+	}
+	return e.shader, nil
+}
+
+// Audio loads an audio file and returns the decoded stream.
+// The format is determined by the extension: .wav, .ogg or .mp3.
+// If the audio is already loaded with the same sample rate, Audio returns the same stream.
+//
+// When the file changes, the decoded data of the stream is replaced. A player playing the stream continues to
+// play the new data from the same position.
+//
+// Audio is concurrent-safe.
+func (r *Reloader) Audio(name string, sampleRate int) (*AudioSource, error) {
+	e, err := r.load(name, assetKindAudio, sampleRate)
+	if err != nil {
+		return nil, err
+	}
+	return e.audio, nil
+}
+
+func (r *Reloader) load(name string, kind assetKind, sampleRate int) (*entry, error) {
+	r.m.Lock()
+	e, ok := r.entries[name]
+	r.m.Unlock()
+
+	if ok {
+		if e.kind != kind {
+			return nil, fmt.Errorf("hotreload: %s is already loaded as %s", name, e.kind)
+		}
+		if kind == assetKindAudio && e.sampleRate != sampleRate {
+			return nil, fmt.Errorf("hotreload: %s is already loaded with the sample rate %d", name, e.sampleRate)
+		}
+		return e, nil
+	}
+
+	st, err := fs.Stat(r.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	e = &entry{
+		name:       name,
+		kind:       kind,
+		modTime:    st.ModTime(),
+		size:       st.Size(),
+		sampleRate: sampleRate,
+	}
+	res := r.read(e)
+	if res.err != nil {
+		return nil, res.err
+	}
+
+	switch kind {
+	case assetKindImage:
+		e.image = ebiten.NewImageFromImage(res.image)
+	case assetKindShader:
+		s, err := ebiten.NewShader(res.data)
+		if err != nil {
+			return nil, err
+		}
+		e.shader = s
+	case assetKindAudio:
+		e.audio = &AudioSource{
+			data: res.data,
+		}
+	}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+	// Another goroutine might load the same file in the meantime.
+	if e, ok := r.entries[name]; ok {
+		return e, nil
+	}
+	r.entries[name] = e
+	return e, nil
+}
+
+// read reads and decodes the file of the entry.
+func (r *Reloader) read(e *entry) *reloaded {
+	res := &reloaded{
+		entry: e,
+	}
+	data, err := fs.ReadFile(r.fsys, e.name)
+	if err != nil {
+		res.err = err
+		return res
+	}
+
+	switch e.kind {
+	case assetKindImage:
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			res.err = err
+			return res
+		}
+		b := img.Bounds()
+		rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+		draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+		res.image = rgba
+	case assetKindShader:
+		res.data = data
+	case assetKindAudio:
+		res.data, res.err = decodeAudio(e.name, data, e.sampleRate)
+	}
+	return res
+}
+
+func decodeAudio(name string, data []byte, sampleRate int) ([]byte, error) {
+	var s io.Reader
+	var err error
+	switch ext := strings.ToLower(path.Ext(name)); ext {
+	case ".wav":
+		s, err = wav.DecodeWithSampleRate(sampleRate, bytes.NewReader(data))
+	case ".ogg":
+		s, err = vorbis.DecodeWithSampleRate(sampleRate, bytes.NewReader(data))
+	case ".mp3":
+		s, err = mp3.DecodeWithSampleRate(sampleRate, bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("hotreload: unsupported audio format: %s", ext)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(s)
+}
+
+func (r *Reloader) loop() {
+	t := time.NewTicker(r.options.Interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+		case <-r.done:
+			return
+		}
+
+		r.m.Lock()
+		entries := make([]*entry, 0, len(r.entries))
+		for _, e := range r.entries {
+			entries = append(entries, e)
+		}
+		r.m.Unlock()
+
+		for _, e := range entries {
+			st, err := fs.Stat(r.fsys, e.name)
+			if err != nil {
+				// The file might be being written. Try again later.
+				continue
+			}
+
+			r.m.Lock()
+			changed := !st.ModTime().Equal(e.modTime) || st.Size() != e.size
+			e.modTime = st.ModTime()
+			e.size = st.Size()
+			r.m.Unlock()
+
+			if !changed {
+				continue
+			}
+
+			res := r.read(e)
+			r.m.Lock()
+			r.results = append(r.results, res)
+			r.m.Unlock()
+		}
+	}
+}
+
+// Update applies the reloaded files to the handles.
+//
+// Update must be called from the game's Update.
+func (r *Reloader) Update() {
+	r.m.Lock()
+	results := r.results
+	r.results = nil
+	r.m.Unlock()
+
+	for _, res := range results {
+		err := res.err
+		if err == nil {
+			err = res.apply()
+		}
+		if r.options.OnReload != nil {
+			r.options.OnReload(res.entry.name, err)
+		}
+	}
+}
+
+func (res *reloaded) apply() error {
+	e := res.entry
+	switch e.kind {
+	case assetKindImage:
+		w, h := e.image.Size()
+		if b := res.image.Bounds(); b.Dx() == w && b.Dy() == h {
+			e.image.ReplacePixels(res.image.Pix)
+			return nil
+		}
+		hotswap.Image(e.image, ebiten.NewImageFromImage(res.image))
+	case assetKindShader:
+		s, err := ebiten.NewShader(res.data)
+		if err != nil {
+			return err
+		}
+		hotswap.Shader(e.shader, s)
+	case assetKindAudio:
+		e.audio.replace(res.data)
+	}
+	return nil
+}
+
+// AudioSource is a decoded audio stream that can be reloaded.
+//
+// AudioSource implements io.ReadSeeker, and can be passed to audio.NewPlayer.
+// The format is 16bit little endian and 2 channels (stereo), like the other audio decoders.
+type AudioSource struct {
+	data []byte
+	pos  int64
+
+	m sync.Mutex
+}
+
+// Read is implementation of io.Reader's Read.
+func (a *AudioSource) Read(buf []byte) (int, error) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	if a.pos >= int64(len(a.data)) {
+		return 0, io.EOF
+	}
+	n := copy(buf, a.data[a.pos:])
+	a.pos += int64(n)
+	return n, nil
+}
+
+// Seek is implementation of io.Seeker's Seek.
+func (a *AudioSource) Seek(offset int64, whence int) (int64, error) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	next := int64(0)
+	switch whence {
+	case io.SeekStart:
+		next = offset
+	case io.SeekCurrent:
+		next = a.pos + offset
+	case io.SeekEnd:
+		next = int64(len(a.data)) + offset
+	default:
+		return 0, fmt.Errorf("hotreload: invalid whence: %d", whence)
+	}
+	if next < 0 {
+		return 0, fmt.Errorf("hotreload: position must be >= 0")
+	}
+	a.pos = next
+	return next, nil
+}
+
+// Length returns the size of the decoded data in bytes.
+func (a *AudioSource) Length() int64 {
+	a.m.Lock()
+	defer a.m.Unlock()
+	return int64(len(a.data))
+}
+
+func (a *AudioSource) replace(data []byte) {
+	a.m.Lock()
+	defer a.m.Unlock()
+	a.data = data
+	if a.pos > int64(len(data)) {
+		a.pos = int64(len(data))
+	}
+}
@@ -0,0 +1,337 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.16
+// +build go1.16
+
+package hotreload_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/fs"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2/asset/hotreload"
+)
+
+// testFS is a file system whose files can be rewritten while a Reloader watches them.
+type testFS struct {
+	files fstest.MapFS
+	m     sync.Mutex
+}
+
+func newTestFS() *testFS {
+	return &testFS{
+		files: fstest.MapFS{},
+	}
+}
+
+func (f *testFS) Open(name string) (fs.File, error) {
+	f.m.Lock()
+	defer f.m.Unlock()
+	return f.files.Open(name)
+}
+
+func (f *testFS) write(name string, data []byte, modTime time.Time) {
+	f.m.Lock()
+	defer f.m.Unlock()
+	f.files[name] = &fstest.MapFile{
+		Data:    data,
+		ModTime: modTime,
+	}
+}
+
+// wavData returns a 16bit stereo WAV file with the given PCM data.
+func wavData(sampleRate int, pcm []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(36+len(pcm)))
+	buf.WriteString("WAVE")
+	buf.WriteString("fmt ")
+	_ = binary.Write(&buf, binary.LittleEndian, struct {
+		Size          uint32
+		Format        uint16
+		ChannelNum    uint16
+		SampleRate    uint32
+		BytesPerSec   uint32
+		BlockAlign    uint16
+		BitsPerSample uint16
+	}{
+		Size:          16,
+		Format:        1, // PCM
+		ChannelNum:    2,
+		SampleRate:    uint32(sampleRate),
+		BytesPerSec:   uint32(sampleRate * 4),
+		BlockAlign:    4,
+		BitsPerSample: 16,
+	})
+	buf.WriteString("data")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(len(pcm)))
+	buf.Write(pcm)
+	return buf.Bytes()
+}
+
+func readAll(t *testing.T, s *hotreload.AudioSource) []byte {
+	t.Helper()
+	if _, err := s.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	bs, err := io.ReadAll(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bs
+}
+
+type reloadResult struct {
+	name string
+	err  error
+}
+
+// waitForReload calls Update until OnReload is called.
+func waitForReload(t *testing.T, r *hotreload.Reloader, results <-chan reloadResult) reloadResult {
+	t.Helper()
+	timeout := time.After(10 * time.Second)
+	for {
+		r.Update()
+		select {
+		case res := <-results:
+			return res
+		case <-timeout:
+			t.Fatal("timeout")
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+func newTestReloader(fsys fs.FS) (*hotreload.Reloader, <-chan reloadResult) {
+	ch := make(chan reloadResult, 16)
+	r := hotreload.New(fsys, &hotreload.Options{
+		Interval: 5 * time.Millisecond,
+		OnReload: func(name string, err error) {
+			ch <- reloadResult{
+				name: name,
+				err:  err,
+			}
+		},
+	})
+	return r, ch
+}
+
+const sampleRate = 44100
+
+func TestAudio(t *testing.T) {
+	fsys := newTestFS()
+	pcm := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	fsys.write("sound.wav", wavData(sampleRate, pcm), time.Unix(1, 0))
+
+	r, _ := newTestReloader(fsys)
+	defer r.Close()
+
+	s, err := r.Audio("sound.wav", sampleRate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readAll(t, s); !bytes.Equal(got, pcm) {
+		t.Errorf("got: %v, want: %v", got, pcm)
+	}
+	if got, want := s.Length(), int64(len(pcm)); got != want {
+		t.Errorf("Length(): got: %d, want: %d", got, want)
+	}
+
+	// The same handle is returned for the same file.
+	s2, err := r.Audio("sound.wav", sampleRate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s2 != s {
+		t.Errorf("Audio returned a different handle for the same file")
+	}
+}
+
+func TestLoadError(t *testing.T) {
+	fsys := newTestFS()
+	fsys.write("sound.wav", wavData(sampleRate, []byte{1, 2, 3, 4}), time.Unix(1, 0))
+	fsys.write("sound.flac", []byte("flac"), time.Unix(1, 0))
+	fsys.write("broken.wav", []byte("not a wav file"), time.Unix(1, 0))
+
+	r, _ := newTestReloader(fsys)
+	defer r.Close()
+
+	if _, err := r.Audio("sound.wav", sampleRate); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		Name string
+		Load func() error
+	}{
+		{
+			Name: "missing file",
+			Load: func() error {
+				_, err := r.Audio("missing.wav", sampleRate)
+				return err
+			},
+		},
+		{
+			Name: "unsupported format",
+			Load: func() error {
+				_, err := r.Audio("sound.flac", sampleRate)
+				return err
+			},
+		},
+		{
+			Name: "broken file",
+			Load: func() error {
+				_, err := r.Audio("broken.wav", sampleRate)
+				return err
+			},
+		},
+		{
+			Name: "different kind",
+			Load: func() error {
+				_, err := r.Image("sound.wav")
+				return err
+			},
+		},
+		{
+			Name: "different sample rate",
+			Load: func() error {
+				_, err := r.Audio("sound.wav", 48000)
+				return err
+			},
+		},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			if err := c.Load(); err == nil {
+				t.Errorf("got: nil, want: an error")
+			}
+		})
+	}
+}
+
+func TestReloadOnChange(t *testing.T) {
+	fsys := newTestFS()
+	fsys.write("sound.wav", wavData(sampleRate, []byte{1, 2, 3, 4}), time.Unix(1, 0))
+
+	r, results := newTestReloader(fsys)
+	defer r.Close()
+
+	s, err := r.Audio("sound.wav", sampleRate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Changing the modification time is detected.
+	pcm := []byte{5, 6, 7, 8}
+	fsys.write("sound.wav", wavData(sampleRate, pcm), time.Unix(2, 0))
+	res := waitForReload(t, r, results)
+	if res.name != "sound.wav" || res.err != nil {
+		t.Fatalf("OnReload: got: (%q, %v), want: (%q, nil)", res.name, res.err, "sound.wav")
+	}
+	if got := readAll(t, s); !bytes.Equal(got, pcm) {
+		t.Errorf("got: %v, want: %v", got, pcm)
+	}
+
+	// Changing the size is detected even if the modification time is the same.
+	pcm = []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	fsys.write("sound.wav", wavData(sampleRate, pcm), time.Unix(2, 0))
+	res = waitForReload(t, r, results)
+	if res.name != "sound.wav" || res.err != nil {
+		t.Fatalf("OnReload: got: (%q, %v), want: (%q, nil)", res.name, res.err, "sound.wav")
+	}
+	if got := readAll(t, s); !bytes.Equal(got, pcm) {
+		t.Errorf("got: %v, want: %v", got, pcm)
+	}
+}
+
+func TestReloadNotChanged(t *testing.T) {
+	fsys := newTestFS()
+	fsys.write("sound.wav", wavData(sampleRate, []byte{1, 2, 3, 4}), time.Unix(1, 0))
+
+	r, results := newTestReloader(fsys)
+	defer r.Close()
+
+	if _, err := r.Audio("sound.wav", sampleRate); err != nil {
+		t.Fatal(err)
+	}
+
+	// Rewriting the same size with the same modification time is not a change.
+	fsys.write("sound.wav", wavData(sampleRate, []byte{5, 6, 7, 8}), time.Unix(1, 0))
+	time.Sleep(50 * time.Millisecond)
+	r.Update()
+	select {
+	case res := <-results:
+		t.Errorf("OnReload is called for an unchanged file: %q", res.name)
+	default:
+	}
+}
+
+func TestReloadError(t *testing.T) {
+	fsys := newTestFS()
+	pcm := []byte{1, 2, 3, 4}
+	fsys.write("sound.wav", wavData(sampleRate, pcm), time.Unix(1, 0))
+
+	r, results := newTestReloader(fsys)
+	defer r.Close()
+
+	s, err := r.Audio("sound.wav", sampleRate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fsys.write("sound.wav", []byte("not a wav file"), time.Unix(2, 0))
+	res := waitForReload(t, r, results)
+	if res.name != "sound.wav" || res.err == nil {
+		t.Fatalf("OnReload: got: (%q, %v), want: (%q, an error)", res.name, res.err, "sound.wav")
+	}
+	// The handle keeps the old content.
+	if got := readAll(t, s); !bytes.Equal(got, pcm) {
+		t.Errorf("got: %v, want: %v", got, pcm)
+	}
+}
+
+func TestClose(t *testing.T) {
+	fsys := newTestFS()
+	fsys.write("sound.wav", wavData(sampleRate, []byte{1, 2, 3, 4}), time.Unix(1, 0))
+
+	r, results := newTestReloader(fsys)
+	s, err := r.Audio("sound.wav", sampleRate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+	// Close can be called multiple times.
+	r.Close()
+
+	fsys.write("sound.wav", wavData(sampleRate, []byte{5, 6, 7, 8}), time.Unix(2, 0))
+	time.Sleep(50 * time.Millisecond)
+	r.Update()
+	select {
+	case res := <-results:
+		t.Errorf("OnReload is called after Close: %q", res.name)
+	default:
+	}
+	// The handle is still available.
+	if got, want := readAll(t, s), []byte{1, 2, 3, 4}; !bytes.Equal(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
@@ -0,0 +1,291 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package asset provides a loader to fetch asset files with progress.
+//
+// On browsers, files are fetched from URLs with the Fetch API and streamed so that the progress is known while
+// downloading. The files can be cached in Cache Storage. On the other platforms, files are read from the file system,
+// or from the network if the path is an HTTP(S) URL.
+//
+// A typical usage is to show a loading bar while loading assets:
+//
+//     l := asset.NewLoader(&asset.LoaderOptions{CacheName: "mygame-v1"})
+//     bg := l.Add("bg.png", 1)
+//     bgm := l.Add("bgm.ogg", 0)
+//     l.Start()
+//
+//     // In Update and Draw
+//     loaded, total := l.Progress()
+//     if l.IsDone() { ... }
+package asset
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// DefaultConcurrency is the default number of files fetched concurrently.
+const DefaultConcurrency = 4
+
+// LoaderOptions represents options for a Loader.
+type LoaderOptions struct {
+	// Concurrency is the maximum number of files fetched concurrently.
+	// If Concurrency is 0, DefaultConcurrency is used.
+	Concurrency int
+
+	// CacheName is the name of the cache in Cache Storage on browsers.
+	// If CacheName is empty, files are not cached.
+	//
+	// Files in the cache are used without checking the server. Change the name when the files are updated.
+	CacheName string
+
+	// OnProgress is called when the progress of a file is updated.
+	// OnProgress is called from the loader's goroutines. OnProgress must be concurrent-safe.
+	OnProgress func(file *File)
+}
+
+// Loader fetches files in priority order.
+type Loader struct {
+	options LoaderOptions
+
+	files   []*File
+	queue   []*File
+	started bool
+	workers int
+
+	m sync.Mutex
+}
+
+// NewLoader creates a new Loader.
+//
+// If options is nil, the default options are used.
+func NewLoader(options *LoaderOptions) *Loader {
+	l := &Loader{}
+	if options != nil {
+		l.options = *options
+	}
+	if l.options.Concurrency <= 0 {
+		l.options.Concurrency = DefaultConcurrency
+	}
+	return l
+}
+
+// File represents a file to be fetched.
+type File struct {
+	path     string
+	priority int
+
+	loaded int64
+	total  int64
+	data   []byte
+	err    error
+	done   bool
+	doneCh chan struct{}
+
+	m sync.Mutex
+}
+
+// Add adds a file to fetch and returns the File.
+//
+// path is a URL on browsers, and a file path or an HTTP(S) URL on the other platforms.
+// Files with higher priorities are fetched earlier. Files with the same priority are fetched in the added order.
+//
+// Add can be called even after Start.
+//
+// Add is concurrent-safe.
+func (l *Loader) Add(path string, priority int) *File {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	f := &File{
+		path:     path,
+		priority: priority,
+		total:    -1,
+		doneCh:   make(chan struct{}),
+	}
+	l.files = append(l.files, f)
+	l.queue = append(l.queue, f)
+	sort.SliceStable(l.queue, func(i, j int) bool {
+		return l.queue[i].priority > l.queue[j].priority
+	})
+	if l.started {
+		l.startWorkers()
+	}
+	return f
+}
+
+// Start starts fetching the files in background. Start does nothing if the loader has already started.
+//
+// Start is concurrent-safe.
+func (l *Loader) Start() {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	if l.started {
+		return
+	}
+	l.started = true
+	l.startWorkers()
+}
+
+// startWorkers starts goroutines to fetch the queued files.
+// startWorkers must be called with l.m locked.
+func (l *Loader) startWorkers() {
+	for l.workers < l.options.Concurrency && l.workers < len(l.queue) {
+		l.workers++
+		go l.loop()
+	}
+}
+
+// next returns the file with the highest priority in the queue.
+// If the queue is empty, next returns nil and the calling worker must end.
+func (l *Loader) next() *File {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	if len(l.queue) == 0 {
+		l.workers--
+		return nil
+	}
+	f := l.queue[0]
+	l.queue = l.queue[1:]
+	return f
+}
+
+func (l *Loader) loop() {
+	for {
+		f := l.next()
+		if f == nil {
+			return
+		}
+		data, err := fetch(f.path, l.options.CacheName, func(loaded, total int64) {
+			f.setProgress(loaded, total)
+			if l.options.OnProgress != nil {
+				l.options.OnProgress(f)
+			}
+		})
+		f.finish(data, err)
+		if l.options.OnProgress != nil {
+			l.options.OnProgress(f)
+		}
+		// Unblock Wait after the last OnProgress call.
+		close(f.doneCh)
+	}
+}
+
+// Progress returns the sum of the fetched bytes and the sum of the total bytes of all the files.
+//
+// The total is an estimation until all the files are done, as the size of a file is unknown until the file starts
+// being fetched. When a file's size is unknown, the file is counted as done with the fetched bytes.
+//
+// Progress is concurrent-safe.
+func (l *Loader) Progress() (loaded, total int64) {
+	l.m.Lock()
+	files := append([]*File(nil), l.files...)
+	l.m.Unlock()
+
+	for _, f := range files {
+		fl, ft := f.Progress()
+		loaded += fl
+		if ft >= 0 {
+			total += ft
+		} else {
+			total += fl
+		}
+	}
+	return
+}
+
+// IsDone reports whether all the files are done regardless of errors.
+//
+// IsDone is concurrent-safe.
+func (l *Loader) IsDone() bool {
+	l.m.Lock()
+	files := append([]*File(nil), l.files...)
+	l.m.Unlock()
+
+	for _, f := range files {
+		if !f.IsDone() {
+			return false
+		}
+	}
+	return true
+}
+
+// Path returns the path of the file.
+func (f *File) Path() string {
+	return f.path
+}
+
+func (f *File) setProgress(loaded, total int64) {
+	f.m.Lock()
+	defer f.m.Unlock()
+	f.loaded = loaded
+	f.total = total
+}
+
+func (f *File) finish(data []byte, err error) {
+	f.m.Lock()
+	defer f.m.Unlock()
+	f.data = data
+	f.err = err
+	if err == nil {
+		f.loaded = int64(len(data))
+		f.total = int64(len(data))
+	}
+	f.done = true
+}
+
+// Progress returns the fetched bytes and the total bytes. total is -1 if the size is unknown.
+//
+// Progress is concurrent-safe.
+func (f *File) Progress() (loaded, total int64) {
+	f.m.Lock()
+	defer f.m.Unlock()
+	return f.loaded, f.total
+}
+
+// IsDone reports whether fetching the file is done regardless of an error.
+//
+// IsDone is concurrent-safe.
+func (f *File) IsDone() bool {
+	f.m.Lock()
+	defer f.m.Unlock()
+	return f.done
+}
+
+// ErrNotDone is returned by Data when the file is not fetched yet.
+var ErrNotDone = errors.New("asset: the file is not fetched yet")
+
+// Data returns the content of the file. Data returns ErrNotDone if the file is not fetched yet.
+//
+// Data is concurrent-safe.
+func (f *File) Data() ([]byte, error) {
+	f.m.Lock()
+	defer f.m.Unlock()
+	if !f.done {
+		return nil, ErrNotDone
+	}
+	return f.data, f.err
+}
+
+// Wait blocks until the file is done, and returns the content of the file.
+//
+// Wait must not be called in Update or Draw, or the game freezes while fetching. Wait can be used in another
+// goroutine e.g. to decode the file in background.
+func (f *File) Wait() ([]byte, error) {
+	<-f.doneCh
+	return f.Data()
+}
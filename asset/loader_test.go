@@ -0,0 +1,108 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !js
+// +build !js
+
+package asset_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2/asset"
+)
+
+func TestLoaderPriority(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ebiten-asset")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	contents := map[string][]byte{
+		"a": bytes.Repeat([]byte{'a'}, 100),
+		"b": bytes.Repeat([]byte{'b'}, 200),
+		"c": bytes.Repeat([]byte{'c'}, 300),
+	}
+	for name, c := range contents {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), c, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var m sync.Mutex
+	var order []string
+	l := asset.NewLoader(&asset.LoaderOptions{
+		Concurrency: 1,
+		OnProgress: func(f *asset.File) {
+			if !f.IsDone() {
+				return
+			}
+			m.Lock()
+			defer m.Unlock()
+			order = append(order, filepath.Base(f.Path()))
+		},
+	})
+	fa := l.Add(filepath.Join(dir, "a"), 0)
+	fb := l.Add(filepath.Join(dir, "b"), 2)
+	fc := l.Add(filepath.Join(dir, "c"), 1)
+	l.Start()
+
+	for _, f := range []*asset.File{fa, fb, fc} {
+		got, err := f.Wait()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := contents[filepath.Base(f.Path())]; !bytes.Equal(got, want) {
+			t.Errorf("%s: got %d bytes, want %d bytes", f.Path(), len(got), len(want))
+		}
+	}
+
+	if !l.IsDone() {
+		t.Errorf("l.IsDone(): got: false, want: true")
+	}
+	if loaded, total := l.Progress(); loaded != 600 || total != 600 {
+		t.Errorf("l.Progress(): got: (%d, %d), want: (600, 600)", loaded, total)
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	want := []string{"b", "c", "a"}
+	if len(order) != len(want) {
+		t.Fatalf("order: got: %v, want: %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Errorf("order: got: %v, want: %v", order, want)
+			break
+		}
+	}
+}
+
+func TestLoaderNotExist(t *testing.T) {
+	l := asset.NewLoader(nil)
+	f := l.Add(filepath.Join(os.TempDir(), "ebiten-asset-not-exist"), 0)
+	if _, err := f.Data(); err != asset.ErrNotDone {
+		t.Errorf("f.Data(): got: %v, want: %v", err, asset.ErrNotDone)
+	}
+	l.Start()
+	if _, err := f.Wait(); !os.IsNotExist(err) {
+		t.Errorf("f.Wait(): got: %v, want: a not-exist error", err)
+	}
+}
@@ -19,9 +19,13 @@
 //   [sample *]  = [channel 1] ...
 //   [channel *] = [byte 1] [byte 2] ...
 //
+// Streams of 32-bit little endian floats are also available with NewPlayerF32.
+// The samples are processed and mixed as 32-bit floats internally regardless of the stream format.
+//
 // An audio context (audio.Context object) has a sample rate you can specify and all streams you want to play must have the same
 // sample rate. However, decoders in e.g. audio/mp3 package adjust sample rate automatically,
 // and you don't have to care about it as long as you use those decoders.
+// A stream that reports its own sample rate by a method SampleRate() int is resampled automatically by a player.
 //
 // An audio context can generate 'players' (audio.Player objects),
 // and you can play sound by calling Play function of players.
@@ -36,6 +40,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"runtime"
 	"sync"
 	"time"
@@ -48,10 +53,14 @@ const (
 	channelNum      = 2
 	bitDepthInBytes = 2
 	bytesPerSample  = bitDepthInBytes * channelNum
+
+	// The samples are processed as float32 internally.
+	bitDepthInBytesF32 = 4
+	bytesPerSampleF32  = bitDepthInBytesF32 * channelNum
 )
 
 type newPlayerImpler interface {
-	newPlayerImpl(context *Context, src io.Reader) (playerImpl, error)
+	newPlayerImpl(context *Context, src io.Reader, format sampleFormat, sampleRate int) (playerImpl, error)
 }
 
 // A Context represents a current state of audio.
@@ -74,6 +83,17 @@ type Context struct {
 
 	players map[playerImpl]struct{}
 
+	masterBus *Bus
+	listener  *Listener
+
+	// offline is the driver for an offline context, or nil for a usual context.
+	offline *offlineDriver
+
+	maxVoices     int
+	voiceStealing VoiceStealing
+	voiceSeq      uint64
+	voiceM        sync.Mutex
+
 	m         sync.Mutex
 	semaphore chan struct{}
 }
@@ -119,6 +139,8 @@ func NewContext(sampleRate int) *Context {
 		sampleRate: sampleRate,
 		np:         np,
 		players:    map[playerImpl]struct{}{},
+		masterBus:  newMasterBus(),
+		listener:   newListener(),
 		inited:     make(chan struct{}),
 		semaphore:  make(chan struct{}, 1),
 	}
@@ -163,6 +185,9 @@ func NewContext(sampleRate int) *Context {
 		return nil
 	})
 
+	// The players are closed when the game is restarted by ebiten.RestartGame.
+	h.AppendHookOnReset(c.closePlayers)
+
 	return c
 }
 
@@ -216,6 +241,25 @@ func (c *Context) removePlayer(p playerImpl) {
 	c.m.Unlock()
 }
 
+// closePlayers closes all the players that are playing.
+func (c *Context) closePlayers() error {
+	c.m.Lock()
+	ps := make([]playerImpl, 0, len(c.players))
+	for p := range c.players {
+		ps = append(ps, p)
+	}
+	c.m.Unlock()
+
+	// Close can lock c.m.
+	var firstErr error
+	for _, p := range ps {
+		if err := p.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
 func (c *Context) gcPlayers() error {
 	c.m.Lock()
 	defer c.m.Unlock()
@@ -273,6 +317,16 @@ func (c *Context) SampleRate() int {
 	return c.sampleRate
 }
 
+// OutputLatency returns the estimated time from when the samples leave the players' buffers to when they are heard.
+//
+// OutputLatency returns 0 if the audio driver doesn't provide the latency, or before any player starts playing.
+func (c *Context) OutputLatency() time.Duration {
+	if l, ok := c.np.(interface{ outputLatency() time.Duration }); ok {
+		return l.outputLatency()
+	}
+	return 0
+}
+
 func (c *Context) acquireSemaphore() {
 	c.semaphore <- struct{}{}
 }
@@ -292,7 +346,8 @@ func (c *Context) waitUntilInited() {
 // This means that if a Player plays an infinite stream,
 // the object is never GCed unless Close is called.
 type Player struct {
-	p playerImpl
+	p       playerImpl
+	context *Context
 }
 
 type playerImpl interface {
@@ -303,18 +358,34 @@ type playerImpl interface {
 	Pause()
 	Volume() float64
 	SetVolume(volume float64)
+	FadeTo(volume float64, duration time.Duration)
+	Rate() float64
+	SetRate(rate float64)
+	Bus() *Bus
+	SetBus(bus *Bus)
+	Position() (x, y, z float64)
+	SetPosition(x, y, z float64)
+	SetSpatialOptions(options *SpatialOptions)
+	Tap() *Tap
+	SetTap(tap *Tap)
+	SetOnEnd(f func())
 	Current() time.Duration
+	AudiblePosition() time.Duration
 	Rewind() error
 	Seek(offset time.Duration) error
 
 	source() io.Reader
+	voice() *voice
 }
 
 // NewPlayer creates a new player with the given stream.
 //
 // src's format must be linear PCM (16bits little endian, 2 channel stereo)
 // without a header (e.g. RIFF header).
-// The sample rate must be same as that of the audio context.
+//
+// If src has a method SampleRate() int, e.g. streams decoded by DecodeWithoutResampling in audio/wav,
+// audio/mp3 and audio/vorbis, src is treated as a stream at the sample rate and resampled to the audio context's
+// sample rate internally. Otherwise, the sample rate must be same as that of the audio context.
 //
 // The player is seekable when src is io.Seeker.
 // Attempt to seek the player that is not io.Seeker causes panic.
@@ -327,12 +398,57 @@ type playerImpl interface {
 // A Player doesn't close src even if src implements io.Closer.
 // Closing the source is src owner's responsibility.
 func NewPlayer(context *Context, src io.Reader) (*Player, error) {
-	pi, err := context.np.newPlayerImpl(context, src)
+	return newPlayer(context, src, sampleFormatInt16)
+}
+
+// NewPlayerF32 creates a new player with the given stream of 32-bit floats.
+//
+// src's format must be linear PCM (32bit float little endian, 2 channel stereo) without a header. The values
+// should be in the range of [-1, 1].
+//
+// The other behaviors are same as NewPlayer.
+func NewPlayerF32(context *Context, src io.Reader) (*Player, error) {
+	return newPlayer(context, src, sampleFormatFloat32)
+}
+
+// NewPlayerF32FromBytes creates a new player with the given bytes of 32-bit floats.
+//
+// As opposed to NewPlayerF32, you don't have to care if src is already used by another player or not.
+// src can be shared by multiple players.
+//
+// The format of src should be same as noted at NewPlayerF32.
+func NewPlayerF32FromBytes(context *Context, src []byte) *Player {
+	b := bytes.NewReader(src)
+	p, err := NewPlayerF32(context, b)
+	if err != nil {
+		// Errors should never happen.
+		panic(fmt.Sprintf("audio: %v at NewPlayerF32FromBytes", err))
+	}
+	return p
+}
+
+// sampleRater is implemented by a source that knows its own sample rate.
+type sampleRater interface {
+	SampleRate() int
+}
+
+func newPlayer(context *Context, src io.Reader, format sampleFormat) (*Player, error) {
+	sampleRate := context.SampleRate()
+	if s, ok := src.(sampleRater); ok {
+		if r := s.SampleRate(); r > 0 {
+			sampleRate = r
+		}
+	}
+
+	pi, err := context.np.newPlayerImpl(context, src, format, sampleRate)
 	if err != nil {
 		return nil, err
 	}
 
-	p := &Player{pi}
+	p := &Player{
+		p:       pi,
+		context: context,
+	}
 
 	runtime.SetFinalizer(p, (*Player).finalize)
 
@@ -373,8 +489,11 @@ func (p *Player) Close() error {
 }
 
 // Play plays the stream.
+//
+// If the number of the playing players reaches the limit, another player might be paused, or this player might
+// not start playing. See Context.SetMaxVoices.
 func (p *Player) Play() {
-	p.p.Play()
+	p.context.playWithVoiceLimit(p.p)
 }
 
 // IsPlaying returns boolean indicating whether the player is playing.
@@ -413,21 +532,141 @@ func (p *Player) Current() time.Duration {
 	return p.p.Current()
 }
 
+// AudiblePosition returns the position in time that is being heard now.
+//
+// While Current is based on the samples sent to the audio driver, AudiblePosition also subtracts the output
+// latency (see Context.OutputLatency), and interpolates the position with the wall clock between the updates by
+// the audio driver. AudiblePosition is useful to synchronize a game with music, e.g. in rhythm games.
+//
+// The accuracy depends on the audio driver.
+func (p *Player) AudiblePosition() time.Duration {
+	return p.p.AudiblePosition()
+}
+
 // Volume returns the current volume of this player [0-1].
+//
+// While the player is fading by FadeTo, Volume returns the volume in the middle of the fade.
 func (p *Player) Volume() float64 {
 	return p.p.Volume()
 }
 
 // SetVolume sets the volume of this player.
 // volume must be in between 0 and 1. SetVolume panics otherwise.
+//
+// SetVolume cancels the fade by FadeTo.
 func (p *Player) SetVolume(volume float64) {
 	p.p.SetVolume(volume)
 }
 
+// FadeTo changes the volume of this player to volume linearly in duration.
+// volume must be in between 0 and 1. FadeTo panics otherwise.
+//
+// The volume is changed for each sample when the player reads its next samples from the stream, so the fade is
+// smooth even when the game's tick is delayed. The duration is measured in the played samples, so the fade
+// doesn't progress while the player is paused.
+//
+// FadeTo starts from the current volume, so calling FadeTo in the middle of a fade continues smoothly.
+// If duration is not positive, FadeTo is the same as SetVolume.
+func (p *Player) FadeTo(volume float64, duration time.Duration) {
+	p.p.FadeTo(volume, duration)
+}
+
+// Rate returns the current playback rate of this player. The default value is 1.
+func (p *Player) Rate() float64 {
+	return p.p.Rate()
+}
+
+// SetRate sets the playback rate of this player.
+//
+// For example, 2 plays the stream twice as fast, and 0.5 plays the stream at half speed. The pitch changes with
+// the rate. rate must be positive. SetRate panics otherwise.
+//
+// The rate can be changed while the player is playing, e.g. for engine sounds or slow-motion effects.
+// Current reports the position in the source stream regardless of the rate.
+func (p *Player) SetRate(rate float64) {
+	// The condition must be true when rate is NaN.
+	if !(rate > 0) || math.IsInf(rate, 0) {
+		panic("audio: rate must be positive")
+	}
+	p.p.SetRate(rate)
+}
+
+// Bus returns the bus that the player belongs to.
+func (p *Player) Bus() *Bus {
+	return p.p.Bus()
+}
+
+// SetBus sets the bus that the player belongs to.
+// If bus is nil, the player belongs to the master bus.
+func (p *Player) SetBus(bus *Bus) {
+	p.p.SetBus(bus)
+}
+
+// Position returns the position of the player's sound source.
+func (p *Player) Position() (x, y, z float64) {
+	return p.p.Position()
+}
+
+// SetPosition sets the position of the player's sound source.
+//
+// The position takes effect only when the player is spatialized by SetSpatialOptions.
+// See Listener for the coordinate system.
+func (p *Player) SetPosition(x, y, z float64) {
+	p.p.SetPosition(x, y, z)
+}
+
+// SetSpatialOptions spatializes the player with the options. If options is nil, the player is not spatialized.
+// A player is not spatialized by default.
+//
+// A spatialized player's sound is panned, attenuated and filtered by the positions of the player and the context's
+// listener. The stream's channels are mixed into one channel before spatialization.
+//
+// The parameters are updated when the player reads its next samples from the stream, so you can move the player
+// and the listener every frame.
+func (p *Player) SetSpatialOptions(options *SpatialOptions) {
+	p.p.SetSpatialOptions(options)
+}
+
+// Priority returns the priority of the player for the voice limiting. The default value is 0.
+func (p *Player) Priority() int {
+	return p.p.voice().Priority()
+}
+
+// SetPriority sets the priority of the player for the voice limiting.
+//
+// When the number of the playing players reaches the limit, a player with a higher priority can stop a playing
+// player with a lower or equal priority. See Context.SetMaxVoices.
+func (p *Player) SetPriority(priority int) {
+	p.p.voice().SetPriority(priority)
+}
+
+// Tap returns the tap attached to the player, or nil if there is no tap.
+func (p *Player) Tap() *Tap {
+	return p.p.Tap()
+}
+
+// SetTap attaches the tap to the player. If tap is nil, the tap is detached.
+func (p *Player) SetTap(tap *Tap) {
+	p.p.SetTap(tap)
+}
+
+// SetOnEnd sets a function that is called when the player reaches the end of the stream.
+//
+// f is called on a different goroutine after the source returns io.EOF and the buffered samples are consumed by
+// the audio device, so f can start the next player for gapless sequencing. The accuracy depends on the audio
+// driver's buffer.
+//
+// f is not called when the player is paused, seeked or closed before the end. For an infinite stream like
+// InfiniteLoop, f is never called. If f is nil, no function is called.
+func (p *Player) SetOnEnd(f func()) {
+	p.p.SetOnEnd(f)
+}
+
 type hook interface {
 	OnSuspendAudio(f func() error)
 	OnResumeAudio(f func() error)
 	AppendHookOnBeforeUpdate(f func() error)
+	AppendHookOnReset(f func() error)
 }
 
 var hookForTesting hook
@@ -452,3 +691,7 @@ func (h *hookImpl) OnResumeAudio(f func() error) {
 func (h *hookImpl) AppendHookOnBeforeUpdate(f func() error) {
 	hooks.AppendHookOnBeforeUpdate(f)
 }
+
+func (h *hookImpl) AppendHookOnReset(f func() error) {
+	hooks.AppendHookOnReset(f)
+}
@@ -116,3 +116,88 @@ func TestPauseBeforeInit(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestPlayerOnEnd(t *testing.T) {
+	setup()
+	defer teardown()
+
+	p := NewPlayerFromBytes(context, make([]byte, 4096))
+	ch := make(chan struct{})
+	p.SetOnEnd(func() {
+		close(ch)
+	})
+	p.Play()
+
+	select {
+	case <-ch:
+	case <-time.After(5 * time.Second):
+		t.Errorf("the end callback must be called")
+	}
+}
+
+func TestPlayerF32(t *testing.T) {
+	setup()
+	defer teardown()
+
+	// 1 second of stereo float32 samples.
+	p := NewPlayerF32FromBytes(context, make([]byte, 44100*2*4))
+	ch := make(chan struct{})
+	p.SetOnEnd(func() {
+		close(ch)
+	})
+	p.Play()
+
+	select {
+	case <-ch:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("the end callback must be called")
+	}
+	if got, want := p.Current(), time.Second; got != want {
+		t.Errorf("p.Current(): got: %v, want: %v", got, want)
+	}
+}
+
+type sampleRateReader struct {
+	*bytes.Reader
+	sampleRate int
+}
+
+func (r *sampleRateReader) SampleRate() int {
+	return r.sampleRate
+}
+
+func TestPlayerSampleRate(t *testing.T) {
+	setup()
+	defer teardown()
+
+	// 1 second of stereo 16bit samples at 22050 [Hz], which is different from the context's sample rate.
+	src := &sampleRateReader{
+		Reader:     bytes.NewReader(make([]byte, 22050*2*2)),
+		sampleRate: 22050,
+	}
+	p, err := NewPlayer(context, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch := make(chan struct{})
+	p.SetOnEnd(func() {
+		close(ch)
+	})
+	p.Play()
+
+	select {
+	case <-ch:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("the end callback must be called")
+	}
+	if got, want := p.Current(), time.Second; got != want {
+		t.Errorf("p.Current(): got: %v, want: %v", got, want)
+	}
+
+	if err := p.Seek(500 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := p.Current(), 500*time.Millisecond; got != want {
+		t.Errorf("p.Current(): got: %v, want: %v", got, want)
+	}
+}
@@ -0,0 +1,287 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// EffectSlotNum is the number of the effect slots of a Bus.
+const EffectSlotNum = 4
+
+// Effect is an audio effect in a Bus.
+type Effect interface {
+	// Process processes the samples in place.
+	//
+	// buf is interleaved 2-channel samples in the range of [-1, 1]. The sample rate is the audio context's.
+	Process(buf []float32)
+}
+
+// Bus is a mixer group of players, e.g. for music, sound effects or voices.
+//
+// A Bus has its own volume, mute state and effect slots. The buses are rolled up into the master bus, so the
+// master bus's volume and effects are applied to all the players.
+//
+// A player belongs to the master bus unless Player.SetBus is called.
+//
+// Changes of a Bus are applied when the players read their next samples from the streams. Volume changes are
+// ramped over a buffer to avoid clicking noises.
+//
+// Bus is concurrent-safe.
+type Bus struct {
+	name   string
+	parent *Bus
+
+	volume  float64
+	muted   bool
+	effects [EffectSlotNum]func() Effect
+
+	// versions are incremented every time the effect slots are changed.
+	versions [EffectSlotNum]int
+
+	tap *Tap
+
+	m sync.Mutex
+}
+
+// MasterBus returns the master bus of the context.
+func (c *Context) MasterBus() *Bus {
+	return c.masterBus
+}
+
+// NewBus creates a new Bus under the master bus.
+//
+// name is for identification, e.g. "music" or "sfx".
+func (c *Context) NewBus(name string) *Bus {
+	return &Bus{
+		name:   name,
+		parent: c.masterBus,
+		volume: 1,
+	}
+}
+
+func newMasterBus() *Bus {
+	return &Bus{
+		name:   "master",
+		volume: 1,
+	}
+}
+
+// Name returns the bus's name.
+func (b *Bus) Name() string {
+	return b.name
+}
+
+// Volume returns the bus's volume [0-1]. The default value is 1.
+func (b *Bus) Volume() float64 {
+	b.m.Lock()
+	defer b.m.Unlock()
+	return b.volume
+}
+
+// SetVolume sets the bus's volume.
+// volume must be in between 0 and 1. SetVolume panics otherwise.
+func (b *Bus) SetVolume(volume float64) {
+	// The condition must be true when volume is NaN.
+	if !(0 <= volume && volume <= 1) {
+		panic("audio: volume must be in between 0 and 1")
+	}
+
+	b.m.Lock()
+	defer b.m.Unlock()
+	b.volume = volume
+}
+
+// IsMuted reports whether the bus is muted.
+func (b *Bus) IsMuted() bool {
+	b.m.Lock()
+	defer b.m.Unlock()
+	return b.muted
+}
+
+// SetMuted sets whether the bus is muted. Muting a bus keeps its volume.
+func (b *Bus) SetMuted(muted bool) {
+	b.m.Lock()
+	defer b.m.Unlock()
+	b.muted = muted
+}
+
+// SetEffect sets an effect to the slot. If newEffect is nil, the slot is cleared.
+//
+// newEffect is called to create an Effect instance for each player in the bus, so an Effect can have a state
+// for a stream, e.g. a filter's history. The effects are applied to each stream before the streams are mixed,
+// in the order of the slots, from the player's bus up to the master bus.
+//
+// slot must be in between 0 and EffectSlotNum-1. SetEffect panics otherwise.
+func (b *Bus) SetEffect(slot int, newEffect func() Effect) {
+	if slot < 0 || slot >= EffectSlotNum {
+		panic(fmt.Sprintf("audio: slot must be in between 0 and %d but %d", EffectSlotNum-1, slot))
+	}
+
+	b.m.Lock()
+	defer b.m.Unlock()
+	b.effects[slot] = newEffect
+	b.versions[slot]++
+}
+
+// Tap returns the tap attached to the bus, or nil if there is no tap.
+func (b *Bus) Tap() *Tap {
+	b.m.Lock()
+	defer b.m.Unlock()
+	return b.tap
+}
+
+// SetTap attaches the tap to the bus. If tap is nil, the tap is detached.
+//
+// The tap gets the mix of the players in the bus and its descendant buses.
+func (b *Bus) SetTap(tap *Tap) {
+	b.m.Lock()
+	defer b.m.Unlock()
+	b.tap = tap
+}
+
+type busEffect struct {
+	bus     *Bus
+	slot    int
+	version int
+	effect  Effect
+}
+
+// busReader applies the volumes and the effects of a bus and its ancestors to float32 stereo samples.
+type busReader struct {
+	src io.Reader
+	bus *Bus
+
+	gain    float64
+	effects []busEffect
+	fbuf    []float32
+
+	// pending is the bytes of an incomplete frame that are held until the rest of the frame is read.
+	pending []byte
+
+	m sync.Mutex
+}
+
+func newBusReader(bus *Bus) *busReader {
+	return &busReader{
+		bus:  bus,
+		gain: 1,
+	}
+}
+
+// reset discards the pending bytes. reset should be called when the source is seeked.
+func (r *busReader) reset() {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.pending = r.pending[:0]
+}
+
+func (r *busReader) Bus() *Bus {
+	r.m.Lock()
+	defer r.m.Unlock()
+	return r.bus
+}
+
+func (r *busReader) SetBus(bus *Bus) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.bus = bus
+}
+
+// update updates the effect instances and returns the current gain.
+func (r *busReader) update() float64 {
+	gain := 1.0
+	var effects []busEffect
+	for b := r.bus; b != nil; b = b.parent {
+		b.m.Lock()
+		if b.muted {
+			gain = 0
+		} else {
+			gain *= b.volume
+		}
+		for i, f := range b.effects {
+			if f == nil {
+				continue
+			}
+			e := busEffect{
+				bus:     b,
+				slot:    i,
+				version: b.versions[i],
+			}
+			for _, old := range r.effects {
+				if old.bus == e.bus && old.slot == e.slot && old.version == e.version {
+					e.effect = old.effect
+					break
+				}
+			}
+			if e.effect == nil {
+				e.effect = f()
+			}
+			effects = append(effects, e)
+		}
+		b.m.Unlock()
+	}
+	r.effects = effects
+	return gain
+}
+
+func (r *busReader) Read(buf []byte) (int, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	// Process complete frames only.
+	p := copy(buf, r.pending)
+	r.pending = r.pending[:copy(r.pending, r.pending[p:])]
+	n, err := r.src.Read(buf[p:])
+	n += p
+	r.pending = append(r.pending, buf[n-n%bytesPerSampleF32:n]...)
+	n -= n % bytesPerSampleF32
+
+	prevGain := r.gain
+	gain := r.update()
+	r.gain = gain
+
+	if n == 0 || (len(r.effects) == 0 && gain == 1 && prevGain == 1) {
+		return n, err
+	}
+
+	samples := n / bitDepthInBytesF32
+	if cap(r.fbuf) < samples {
+		r.fbuf = make([]float32, samples)
+	}
+	fbuf := r.fbuf[:samples]
+	for i := range fbuf {
+		fbuf[i] = float32At(buf[4*i:])
+	}
+
+	for _, e := range r.effects {
+		e.effect.Process(fbuf)
+	}
+
+	// Ramp the gain linearly to avoid clicking noises.
+	// The values are not clipped here. The audio driver clips the mixed values.
+	frames := samples / channelNum
+	for i := range fbuf {
+		g := gain
+		if prevGain != gain {
+			t := float64(i/channelNum+1) / float64(frames)
+			g = prevGain + (gain-prevGain)*t
+		}
+		putFloat32(buf[4*i:], float32(float64(fbuf[i])*g))
+	}
+	return n, err
+}
@@ -0,0 +1,90 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	. "github.com/hajimehoshi/ebiten/v2/audio"
+)
+
+type invertEffect struct{}
+
+func (invertEffect) Process(buf []float32) {
+	for i := range buf {
+		buf[i] = -buf[i]
+	}
+}
+
+func TestBus(t *testing.T) {
+	setup()
+	defer teardown()
+
+	sfx := context.NewBus("sfx")
+	sfx.SetVolume(0.5)
+	context.MasterBus().SetEffect(0, func() Effect {
+		return invertEffect{}
+	})
+
+	in := stereo16(10000, 10000, 10000, 10000)
+	r := NewBusReaderForTesting(bytes.NewReader(bytes.Repeat(in, 4)), sfx)
+
+	// The first read ramps the volume from 1 to 0.5.
+	buf := make([]byte, len(in))
+	if _, err := io.ReadFull(r, buf); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadFull(r, buf); err != nil {
+		t.Fatal(err)
+	}
+	if want := stereo16(-5000, -5000, -5000, -5000); !bytes.Equal(buf, want) {
+		t.Errorf("got: %v, want: %v", buf, want)
+	}
+
+	// The volume is ramped again after muting.
+	sfx.SetMuted(true)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadFull(r, buf); err != nil {
+		t.Fatal(err)
+	}
+	if want := make([]byte, len(in)); !bytes.Equal(buf, want) {
+		t.Errorf("got: %v, want: %v", buf, want)
+	}
+}
+
+func TestPlayerBus(t *testing.T) {
+	setup()
+	defer teardown()
+
+	p := NewPlayerFromBytes(context, make([]byte, 16))
+	if got, want := p.Bus(), context.MasterBus(); got != want {
+		t.Errorf("got: %s, want: %s", got.Name(), want.Name())
+	}
+
+	music := context.NewBus("music")
+	p.SetBus(music)
+	if got, want := p.Bus(), music; got != want {
+		t.Errorf("got: %s, want: %s", got.Name(), want.Name())
+	}
+
+	p.SetBus(nil)
+	if got, want := p.Bus(), context.MasterBus(); got != want {
+		t.Errorf("got: %s, want: %s", got.Name(), want.Name())
+	}
+}
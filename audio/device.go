@@ -0,0 +1,77 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+import (
+	"errors"
+)
+
+var errOutputDeviceNotSupported = errors.New("audio: selecting an output device is not supported on this environment")
+
+// OutputDevice represents an audio output device.
+type OutputDevice struct {
+	// ID is the identifier of the device to pass to SetOutputDevice.
+	//
+	// The format of ID depends on the platform. An ID might become invalid when devices are connected or
+	// disconnected.
+	ID string
+
+	// Name is the human readable name of the device.
+	//
+	// On browsers, Name is empty unless the user permits the page to access the media devices.
+	Name string
+
+	// IsDefault reports whether the device is the system default device.
+	IsDefault bool
+}
+
+// OutputDevices returns the available audio output devices.
+//
+// OutputDevices returns an error when the environment doesn't support selecting an output device, e.g. on
+// Android, iOS and an offline context.
+//
+// On browsers, OutputDevices blocks until the browser responds. Don't call OutputDevices on a JavaScript callback.
+//
+// OutputDevices is concurrent-safe.
+func (c *Context) OutputDevices() ([]OutputDevice, error) {
+	if d, ok := c.np.(interface {
+		outputDevices(context *Context) ([]OutputDevice, error)
+	}); ok {
+		return d.outputDevices(c)
+	}
+	return nil, errOutputDeviceNotSupported
+}
+
+// SetOutputDevice switches the output device to the device with the given ID.
+// The empty ID represents the system default device.
+//
+// The playing players are moved to the new device. Some samples might be skipped when the players are moved.
+// If SetOutputDevice is called before playing any players, the players output to the device from the beginning.
+//
+// SetOutputDevice returns an error when the ID is invalid, or when the environment doesn't support selecting an
+// output device, e.g. on Android, iOS and an offline context.
+//
+// On browsers, SetOutputDevice blocks until the browser responds. Don't call SetOutputDevice on a JavaScript
+// callback.
+//
+// SetOutputDevice is concurrent-safe.
+func (c *Context) SetOutputDevice(id string) error {
+	if d, ok := c.np.(interface {
+		setOutputDevice(context *Context, id string) error
+	}); ok {
+		return d.setOutputDevice(c, id)
+	}
+	return errOutputDeviceNotSupported
+}
@@ -0,0 +1,75 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/hajimehoshi/ebiten/v2/audio"
+)
+
+func TestOutputDevices(t *testing.T) {
+	setup()
+	defer teardown()
+
+	got, err := context.OutputDevices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []OutputDevice{
+		{ID: "speaker", Name: "Speaker", IsDefault: true},
+		{ID: "headphones", Name: "Headphones"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
+
+func TestSetOutputDevice(t *testing.T) {
+	setup()
+	defer teardown()
+
+	if err := context.SetOutputDevice("headphones"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := OutputDeviceIDForTesting(), "headphones"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+
+	if err := context.SetOutputDevice("invalid"); err == nil {
+		t.Errorf("SetOutputDevice with an invalid ID must return an error")
+	}
+	if got, want := OutputDeviceIDForTesting(), "headphones"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+
+	if err := context.SetOutputDevice(""); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := OutputDeviceIDForTesting(), ""; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+}
+
+func TestOutputDeviceOffline(t *testing.T) {
+	c := NewOfflineContext(44100)
+	if _, err := c.OutputDevices(); err == nil {
+		t.Errorf("OutputDevices on an offline context must return an error")
+	}
+	if err := c.SetOutputDevice(""); err == nil {
+		t.Errorf("SetOutputDevice on an offline context must return an error")
+	}
+}
@@ -15,9 +15,11 @@
 package audio
 
 import (
+	"fmt"
 	"io"
 	"io/ioutil"
 	"sync"
+	"time"
 
 	"github.com/hajimehoshi/ebiten/v2/audio/internal/readerdriver"
 )
@@ -48,8 +50,11 @@ func init() {
 }
 
 type (
-	dummyReaderContext struct{}
-	dummyReaderPlayer  struct {
+	dummyReaderContext struct {
+		deviceID string
+		m        sync.Mutex
+	}
+	dummyReaderPlayer struct {
 		r       io.Reader
 		playing bool
 		volume  float64
@@ -76,6 +81,36 @@ func (c *dummyReaderContext) Resume() error {
 	return nil
 }
 
+func (c *dummyReaderContext) OutputLatency() time.Duration {
+	return 0
+}
+
+func (c *dummyReaderContext) OutputDevices() ([]readerdriver.Device, error) {
+	return []readerdriver.Device{
+		{ID: "speaker", Name: "Speaker", IsDefault: true},
+		{ID: "headphones", Name: "Headphones"},
+	}, nil
+}
+
+func (c *dummyReaderContext) SetOutputDevice(id string) error {
+	switch id {
+	case "", "speaker", "headphones":
+	default:
+		return fmt.Errorf("audio: invalid device ID: %q", id)
+	}
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.deviceID = id
+	return nil
+}
+
+func OutputDeviceIDForTesting() string {
+	c := readerDriverForTesting.(*dummyReaderContext)
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.deviceID
+}
+
 func (p *dummyReaderPlayer) Pause() {
 	p.m.Lock()
 	p.playing = false
@@ -149,6 +184,9 @@ func (h *dummyHook) AppendHookOnBeforeUpdate(f func() error) {
 	h.updates = append(h.updates, f)
 }
 
+func (h *dummyHook) AppendHookOnReset(f func() error) {
+}
+
 func init() {
 	hookForTesting = &dummyHook{}
 }
@@ -173,3 +211,40 @@ func PlayersNumForTesting() int {
 func ResetContextForTesting() {
 	theContext = nil
 }
+
+// int16ReaderForTesting converts float32 samples to 16bit samples so that tests can treat 16bit samples.
+type int16ReaderForTesting struct {
+	src io.Reader
+	buf []byte
+}
+
+func (r *int16ReaderForTesting) Read(buf []byte) (int, error) {
+	size := len(buf) / bitDepthInBytes * bitDepthInBytesF32
+	if cap(r.buf) < size {
+		r.buf = make([]byte, size)
+	}
+	// The source readers always return whole float32 values.
+	n, err := r.src.Read(r.buf[:size])
+	float32ToInt16(buf, r.buf[:n], 1)
+	return n / bitDepthInBytesF32 * bitDepthInBytes, err
+}
+
+func NewRateReaderForTesting(src io.Reader, rate float64) io.Reader {
+	r := newRateReader(newFloat32Reader(src), 1, 1)
+	r.SetRate(rate)
+	return &int16ReaderForTesting{src: r}
+}
+
+func NewBusReaderForTesting(src io.Reader, bus *Bus) io.Reader {
+	r := newBusReader(bus)
+	r.src = newFloat32Reader(src)
+	return &int16ReaderForTesting{src: r}
+}
+
+func NewSpatialReaderForTesting(src io.Reader, listener *Listener, x, y, z float64, options *SpatialOptions) io.Reader {
+	r := newSpatialReader(listener, 44100)
+	r.src = newFloat32Reader(src)
+	r.SetPosition(x, y, z)
+	r.SetOptions(options)
+	return &int16ReaderForTesting{src: r}
+}
@@ -0,0 +1,145 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// fadeReader applies a player's volume to float32 stereo samples, and changes the volume gradually for fades.
+//
+// As the volume is applied when the samples are read on the audio thread, a fade is smooth even when the game's
+// tick is delayed.
+type fadeReader struct {
+	src        io.Reader
+	sampleRate int
+
+	volume float64
+	target float64
+
+	// rest is the number of the frames until the volume reaches the target.
+	rest int64
+
+	// jump reports whether the volume reaches the target in the next Read.
+	jump bool
+
+	// started reports whether any samples have been read.
+	started bool
+
+	m sync.Mutex
+}
+
+func newFadeReader(src io.Reader, sampleRate int) *fadeReader {
+	return &fadeReader{
+		src:        src,
+		sampleRate: sampleRate,
+		volume:     1,
+		target:     1,
+	}
+}
+
+// Volume returns the current volume. While fading, Volume returns the volume in the middle of the fade.
+func (r *fadeReader) Volume() float64 {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if r.jump {
+		return r.target
+	}
+	return r.volume
+}
+
+// SetVolume sets the volume and cancels the current fade.
+//
+// Once samples are read, the volume is ramped within the next Read to avoid clicking noises.
+func (r *fadeReader) SetVolume(volume float64) {
+	// The condition must be true when volume is NaN.
+	if !(0 <= volume && volume <= 1) {
+		panic("audio: volume must be in between 0 and 1")
+	}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.target = volume
+	r.rest = 0
+	if r.started {
+		r.jump = true
+	} else {
+		r.volume = volume
+		r.jump = false
+	}
+}
+
+// FadeTo changes the volume to the given volume linearly in the duration.
+func (r *fadeReader) FadeTo(volume float64, duration time.Duration) {
+	if duration <= 0 {
+		r.SetVolume(volume)
+		return
+	}
+
+	// The condition must be true when volume is NaN.
+	if !(0 <= volume && volume <= 1) {
+		panic("audio: volume must be in between 0 and 1")
+	}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+	if r.jump {
+		r.volume = r.target
+		r.jump = false
+	}
+	r.target = volume
+	r.rest = int64(duration) * int64(r.sampleRate) / int64(time.Second)
+	if r.rest == 0 {
+		r.rest = 1
+	}
+}
+
+func (r *fadeReader) Read(buf []byte) (int, error) {
+	n, err := r.src.Read(buf)
+
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if n > 0 {
+		r.started = true
+	}
+	if r.volume == 1 && !r.jump && r.rest == 0 {
+		return n, err
+	}
+
+	// busReader returns only complete frames.
+	frames := n / bytesPerSampleF32
+	from := r.volume
+	for i := 0; i < frames; i++ {
+		switch {
+		case r.jump:
+			r.volume = from + (r.target-from)*float64(i+1)/float64(frames)
+		case r.rest > 0:
+			r.volume += (r.target - r.volume) / float64(r.rest)
+			r.rest--
+		}
+		for ch := 0; ch < channelNum; ch++ {
+			idx := bytesPerSampleF32*i + bitDepthInBytesF32*ch
+			putFloat32(buf[idx:], float32(float64(float32At(buf[idx:]))*r.volume))
+		}
+	}
+	if frames > 0 && r.jump {
+		r.volume = r.target
+		r.jump = false
+	}
+	return n, err
+}
@@ -0,0 +1,56 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	. "github.com/hajimehoshi/ebiten/v2/audio"
+)
+
+func TestFadeTo(t *testing.T) {
+	c := NewOfflineContext(1000)
+
+	p := NewPlayerFromBytes(c, stereo16(repeatInt16(10000, 20)...))
+	p.FadeTo(0, 10*time.Millisecond)
+	p.Play()
+
+	var buf bytes.Buffer
+	if err := c.Render(&buf, 20*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	got := readInt16s(t, &buf, 40)
+	for i := 0; i < 20; i++ {
+		want := 0
+		if i < 10 {
+			want = 10000 - 1000*(i+1)
+		}
+		if diff := int(got[2*i]) - want; diff < -1 || diff > 1 {
+			t.Errorf("frame %d: got: %d, want: %d", i, got[2*i], want)
+		}
+	}
+	if got, want := p.Volume(), 0.0; got != want {
+		t.Errorf("p.Volume(): got: %f, want: %f", got, want)
+	}
+
+	// SetVolume cancels the fade.
+	p.FadeTo(1, time.Second)
+	p.SetVolume(0.5)
+	if got, want := p.Volume(), 0.5; got != want {
+		t.Errorf("p.Volume(): got: %f, want: %f", got, want)
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flac
+
+import (
+	"bufio"
+	"io"
+)
+
+// bitReader reads bits from a byte stream in the big endian order.
+type bitReader struct {
+	r *bufio.Reader
+
+	// bits is the buffered bits. The valid bits are the lowest n bits.
+	bits uint64
+	n    uint
+}
+
+func newBitReader(r io.Reader) *bitReader {
+	return &bitReader{
+		r: bufio.NewReader(r),
+	}
+}
+
+// readBits reads n bits as an unsigned integer. n must be <= 32.
+func (b *bitReader) readBits(n uint) (uint32, error) {
+	for b.n < n {
+		c, err := b.r.ReadByte()
+		if err != nil {
+			if err == io.EOF {
+				return 0, io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		b.bits = b.bits<<8 | uint64(c)
+		b.n += 8
+	}
+	b.n -= n
+	v := uint32(b.bits >> b.n)
+	if n < 32 {
+		v &= 1<<n - 1
+	}
+	b.bits &= 1<<b.n - 1
+	return v, nil
+}
+
+// readSigned reads n bits as a two's complement signed integer. n must be <= 32.
+func (b *bitReader) readSigned(n uint) (int32, error) {
+	if n == 0 {
+		return 0, nil
+	}
+	v, err := b.readBits(n)
+	if err != nil {
+		return 0, err
+	}
+	return int32(v<<(32-n)) >> (32 - n), nil
+}
+
+// readUnary reads a unary coded value: the number of 0 bits before a 1 bit.
+func (b *bitReader) readUnary() (uint32, error) {
+	var v uint32
+	for {
+		if b.n == 0 {
+			c, err := b.r.ReadByte()
+			if err != nil {
+				if err == io.EOF {
+					return 0, io.ErrUnexpectedEOF
+				}
+				return 0, err
+			}
+			// Skip zero bytes quickly.
+			if c == 0 {
+				v += 8
+				continue
+			}
+			b.bits = uint64(c)
+			b.n = 8
+		}
+		b.n--
+		if b.bits>>b.n&1 == 1 {
+			b.bits &= 1<<b.n - 1
+			return v, nil
+		}
+		v++
+	}
+}
+
+// align discards the bits until the next byte boundary.
+func (b *bitReader) align() {
+	b.n -= b.n % 8
+	b.bits &= 1<<b.n - 1
+}
+
+// readByte reads a byte at a byte boundary. readByte returns io.EOF at the end of the stream.
+func (b *bitReader) readByte() (byte, error) {
+	if b.n >= 8 {
+		v, err := b.readBits(8)
+		return byte(v), err
+	}
+	return b.r.ReadByte()
+}
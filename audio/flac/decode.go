@@ -0,0 +1,410 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package flac provides FLAC decoder.
+package flac
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/hajimehoshi/ebiten/v2/audio"
+	"github.com/hajimehoshi/ebiten/v2/audio/internal/convert"
+)
+
+// Stream is a decoded audio stream.
+type Stream struct {
+	inner      io.ReadSeeker
+	size       int64
+	sampleRate int
+}
+
+// Read is implementation of io.Reader's Read.
+func (s *Stream) Read(p []byte) (int, error) {
+	return s.inner.Read(p)
+}
+
+// Seek is implementation of io.Seeker's Seek.
+//
+// Note that Seek can take long since decoding is a relatively heavy task. Seek uses the seek table of the FLAC
+// data if exists.
+//
+// If the underlying source is not an io.Seeker, Seek panics.
+func (s *Stream) Seek(offset int64, whence int) (int64, error) {
+	return s.inner.Seek(offset, whence)
+}
+
+// Length returns the size of decoded stream in bytes.
+//
+// Length returns 0 if the FLAC data doesn't have the total number of the samples.
+func (s *Stream) Length() int64 {
+	return s.size
+}
+
+// SampleRate returns the sample rate of the decoded stream.
+//
+// audio.NewPlayer uses SampleRate to resample the stream to the audio context's sample rate.
+func (s *Stream) SampleRate() int {
+	return s.sampleRate
+}
+
+// streamInfo is the STREAMINFO metadata block.
+type streamInfo struct {
+	minBlockSize  int
+	maxBlockSize  int
+	sampleRate    int
+	channelNum    int
+	bitsPerSample int
+	totalSamples  int64
+}
+
+// seekPoint is a point in the SEEKTABLE metadata block.
+type seekPoint struct {
+	sample int64
+
+	// offset is the offset in bytes from the first frame.
+	offset int64
+}
+
+// stream decodes FLAC frames into 16bit stereo samples.
+type stream struct {
+	src        io.Reader
+	br         *bitReader
+	info       streamInfo
+	seekPoints []seekPoint
+
+	// dataOffset is the offset of the first frame in src.
+	dataOffset int64
+
+	// buf is the decoded bytes that are not read yet.
+	buf []byte
+
+	// pos is the position in bytes.
+	pos int64
+	eof bool
+}
+
+// Read is implementation of io.Reader's Read.
+func (s *stream) Read(p []byte) (int, error) {
+	for len(s.buf) == 0 {
+		if s.eof {
+			return 0, io.EOF
+		}
+		if err := s.decodeNextFrame(-1); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, s.buf)
+	s.buf = s.buf[n:]
+	s.pos += int64(n)
+	return n, nil
+}
+
+// decodeNextFrame decodes the next frame into s.buf. If target is not negative, the samples before target are
+// skipped.
+func (s *stream) decodeNextFrame(target int64) error {
+	if s.info.totalSamples > 0 && s.pos/4 >= s.info.totalSamples {
+		// There might be trailing data like ID3v1 tags.
+		s.eof = true
+		return nil
+	}
+
+	f, err := decodeFrame(s.br, &s.info)
+	if err == io.EOF {
+		s.eof = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	samples := f.samples
+	n := len(samples[0])
+	start := 0
+	if target >= 0 {
+		if f.first+int64(n) <= target {
+			// Skip this frame.
+			s.pos = (f.first + int64(n)) * 4
+			return nil
+		}
+		if target > f.first {
+			start = int(target - f.first)
+		}
+		s.pos = (f.first + int64(start)) * 4
+	}
+
+	shift := s.info.bitsPerSample - 16
+	conv := func(v int32) int16 {
+		if shift > 0 {
+			return int16(v >> uint(shift))
+		}
+		return int16(v << uint(-shift))
+	}
+
+	s.buf = s.buf[:0]
+	for i := start; i < n; i++ {
+		l := conv(samples[0][i])
+		r := l
+		if len(samples) > 1 {
+			r = conv(samples[1][i])
+		}
+		s.buf = append(s.buf, byte(l), byte(l>>8), byte(r), byte(r>>8))
+	}
+	return nil
+}
+
+// Seek is implementation of io.Seeker's Seek.
+//
+// If the underlying source is not an io.Seeker, Seek panics.
+func (s *stream) Seek(offset int64, whence int) (int64, error) {
+	seeker, ok := s.src.(io.Seeker)
+	if !ok {
+		panic("flac: s.src must be io.Seeker but not")
+	}
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += s.pos
+	case io.SeekEnd:
+		if s.info.totalSamples == 0 {
+			return 0, fmt.Errorf("flac: io.SeekEnd is not available when the total number of the samples is unknown")
+		}
+		offset += s.info.totalSamples * 4
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("flac: invalid offset")
+	}
+
+	// Find the last seek point before the target.
+	target := offset / 4
+	var point seekPoint
+	for _, p := range s.seekPoints {
+		if p.sample > target {
+			break
+		}
+		point = p
+	}
+
+	if _, err := seeker.Seek(s.dataOffset+point.offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	s.br = newBitReader(s.src)
+	s.buf = s.buf[:0]
+	s.pos = point.sample * 4
+	s.eof = false
+
+	for len(s.buf) == 0 && !s.eof {
+		if err := s.decodeNextFrame(target); err != nil {
+			return 0, err
+		}
+	}
+
+	// Skip the bytes in the middle of a sample.
+	if r := int(offset % 4); r > 0 && len(s.buf) > 0 {
+		s.buf = s.buf[r:]
+		s.pos += int64(r)
+	}
+	return s.pos, nil
+}
+
+// readMetadata reads the metadata blocks and returns the number of the read bytes.
+func (s *stream) readMetadata() (int64, error) {
+	var read int64
+	readFull := func(buf []byte) error {
+		n, err := io.ReadFull(s.src, buf)
+		read += int64(n)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return fmt.Errorf("flac: invalid header")
+		}
+		return err
+	}
+
+	buf := make([]byte, 4)
+	if err := readFull(buf); err != nil {
+		return 0, err
+	}
+
+	// Skip an ID3v2 tag. The tag header is 'ID3', the version (2 bytes), the flags (1 byte) and the size of the rest
+	// (4 bytes).
+	if bytes.Equal(buf[:3], []byte("ID3")) {
+		header := make([]byte, 6)
+		if err := readFull(header); err != nil {
+			return 0, err
+		}
+		size := int64(header[2]&0x7f)<<21 | int64(header[3]&0x7f)<<14 | int64(header[4]&0x7f)<<7 | int64(header[5]&0x7f)
+		if buf[3] >= 4 && header[1]&0x10 != 0 {
+			// The footer.
+			size += 10
+		}
+		if err := readFull(make([]byte, size)); err != nil {
+			return 0, err
+		}
+		if err := readFull(buf); err != nil {
+			return 0, err
+		}
+	}
+
+	if !bytes.Equal(buf, []byte("fLaC")) {
+		return 0, fmt.Errorf("flac: invalid header: 'fLaC' not found")
+	}
+
+	var streamInfoFound bool
+	for {
+		header := make([]byte, 4)
+		if err := readFull(header); err != nil {
+			return 0, err
+		}
+		last := header[0]&0x80 != 0
+		typ := header[0] & 0x7f
+		size := int(header[1])<<16 | int(header[2])<<8 | int(header[3])
+		data := make([]byte, size)
+		if err := readFull(data); err != nil {
+			return 0, err
+		}
+
+		switch typ {
+		case 0:
+			// STREAMINFO
+			if size < 34 {
+				return 0, fmt.Errorf("flac: invalid STREAMINFO")
+			}
+			s.info = streamInfo{
+				minBlockSize:  int(data[0])<<8 | int(data[1]),
+				maxBlockSize:  int(data[2])<<8 | int(data[3]),
+				sampleRate:    int(data[10])<<12 | int(data[11])<<4 | int(data[12])>>4,
+				channelNum:    int(data[12]>>1&0x7) + 1,
+				bitsPerSample: (int(data[12]&1)<<4 | int(data[13])>>4) + 1,
+				totalSamples:  int64(data[13]&0xf)<<32 | int64(data[14])<<24 | int64(data[15])<<16 | int64(data[16])<<8 | int64(data[17]),
+			}
+			streamInfoFound = true
+		case 3:
+			// SEEKTABLE
+			for i := 0; i+18 <= size; i += 18 {
+				var sample, offset int64
+				for j := 0; j < 8; j++ {
+					sample = sample<<8 | int64(data[i+j])
+					offset = offset<<8 | int64(data[i+8+j])
+				}
+				// Skip placeholders.
+				if sample == -1 {
+					continue
+				}
+				s.seekPoints = append(s.seekPoints, seekPoint{
+					sample: sample,
+					offset: offset,
+				})
+			}
+		}
+
+		if last {
+			break
+		}
+	}
+
+	if !streamInfoFound {
+		return 0, fmt.Errorf("flac: STREAMINFO not found")
+	}
+	return read, nil
+}
+
+// DecodeWithSampleRate decodes FLAC data to playable stream.
+//
+// The format must be 1 or 2 channels, 4 to 32 bits per sample.
+// The format is converted into 2 channels and 16bit.
+//
+// DecodeWithSampleRate returns error when decoding fails or IO error happens.
+//
+// DecodeWithSampleRate automatically resamples the stream to fit with sampleRate if necessary.
+// Resampling requires the total number of the samples in the FLAC data.
+//
+// The returned Stream's Seek is available only when src is an io.Seeker.
+//
+// A Stream doesn't close src even if src implements io.Closer.
+// Closing the source is src owner's responsibility.
+func DecodeWithSampleRate(sampleRate int, src io.Reader) (*Stream, error) {
+	return decode(src, sampleRate)
+}
+
+// DecodeWithoutResampling decodes FLAC data to playable stream.
+//
+// The format must be 1 or 2 channels, 4 to 32 bits per sample.
+// The format is converted into 2 channels and 16bit.
+//
+// DecodeWithoutResampling returns error when decoding fails or IO error happens.
+//
+// DecodeWithoutResampling doesn't resample the stream. The returned Stream's SampleRate returns the original
+// sample rate, and audio.NewPlayer resamples the stream to the audio context's sample rate.
+//
+// The returned Stream's Seek is available only when src is an io.Seeker.
+//
+// A Stream doesn't close src even if src implements io.Closer.
+// Closing the source is src owner's responsibility.
+func DecodeWithoutResampling(src io.Reader) (*Stream, error) {
+	return decode(src, 0)
+}
+
+// Decode decodes FLAC data to playable stream.
+//
+// Decode is the same as DecodeWithSampleRate with the audio context's sample rate.
+func Decode(context *audio.Context, src io.Reader) (*Stream, error) {
+	return DecodeWithSampleRate(context.SampleRate(), src)
+}
+
+// decode decodes FLAC data. If sampleRate is 0, decode doesn't resample the stream.
+func decode(src io.Reader, sampleRate int) (*Stream, error) {
+	s := &stream{
+		src: src,
+	}
+	offset, err := s.readMetadata()
+	if err != nil {
+		return nil, err
+	}
+	if s.info.channelNum != 1 && s.info.channelNum != 2 {
+		return nil, fmt.Errorf("flac: channel num must be 1 or 2 but was %d", s.info.channelNum)
+	}
+	if s.info.bitsPerSample < 4 {
+		return nil, fmt.Errorf("flac: bits per sample must be >= 4 but was %d", s.info.bitsPerSample)
+	}
+	if s.info.sampleRate == 0 {
+		return nil, fmt.Errorf("flac: invalid sample rate")
+	}
+
+	if seeker, ok := src.(io.Seeker); ok {
+		// Get the actual position of the first frame, as src might not be at the beginning.
+		pos, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, err
+		}
+		offset = pos
+	}
+	s.dataOffset = offset
+	s.br = newBitReader(src)
+
+	var r io.ReadSeeker = s
+	size := s.info.totalSamples * 4
+	if sampleRate == 0 {
+		sampleRate = s.info.sampleRate
+	}
+	if sampleRate != s.info.sampleRate {
+		if size == 0 {
+			return nil, fmt.Errorf("flac: resampling requires the total number of the samples")
+		}
+		res := convert.NewResampling(r, size, s.info.sampleRate, sampleRate)
+		r = res
+		size = res.Length()
+	}
+	return &Stream{inner: r, size: size, sampleRate: sampleRate}, nil
+}
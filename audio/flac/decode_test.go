@@ -0,0 +1,409 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flac_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2/audio/flac"
+)
+
+// bitWriter writes bits in the big endian order to make FLAC data for testing.
+type bitWriter struct {
+	buf  []byte
+	bits uint64
+	n    uint
+}
+
+func (w *bitWriter) write(v uint64, n uint) {
+	for i := int(n) - 1; i >= 0; i-- {
+		w.bits = w.bits<<1 | (v >> uint(i) & 1)
+		w.n++
+		if w.n == 8 {
+			w.buf = append(w.buf, byte(w.bits))
+			w.bits = 0
+			w.n = 0
+		}
+	}
+}
+
+func (w *bitWriter) writeSigned(v int32, n uint) {
+	w.write(uint64(uint32(v))&(1<<n-1), n)
+}
+
+func (w *bitWriter) align() {
+	for w.n != 0 {
+		w.write(0, 1)
+	}
+}
+
+func (w *bitWriter) writeResidual(residual []int32) {
+	const k = 4
+	// The coding method 0 and the partition order 0.
+	w.write(0, 2)
+	w.write(0, 4)
+	w.write(k, 4)
+	for _, r := range residual {
+		u := uint32(r<<1) ^ uint32(r>>31)
+		for i := uint32(0); i < u>>k; i++ {
+			w.write(0, 1)
+		}
+		w.write(1, 1)
+		w.write(uint64(u&(1<<k-1)), k)
+	}
+}
+
+type subframe struct {
+	// typ is "constant", "verbatim", "fixed" or "lpc".
+	typ       string
+	order     int
+	coefs     []int32
+	precision uint
+	shift     uint
+	wasted    uint
+}
+
+func (w *bitWriter) writeSubframe(samples []int32, bps uint, s subframe) {
+	var typ uint64
+	switch s.typ {
+	case "constant":
+		typ = 0
+	case "verbatim":
+		typ = 1
+	case "fixed":
+		typ = 8 + uint64(s.order)
+	case "lpc":
+		typ = 31 + uint64(len(s.coefs))
+	}
+	w.write(0, 1)
+	w.write(typ, 6)
+	if s.wasted > 0 {
+		w.write(1, 1)
+		for i := uint(0); i < s.wasted-1; i++ {
+			w.write(0, 1)
+		}
+		w.write(1, 1)
+		bps -= s.wasted
+		shifted := make([]int32, len(samples))
+		for i := range samples {
+			shifted[i] = samples[i] >> s.wasted
+		}
+		samples = shifted
+	} else {
+		w.write(0, 1)
+	}
+
+	switch s.typ {
+	case "constant":
+		w.writeSigned(samples[0], bps)
+	case "verbatim":
+		for _, v := range samples {
+			w.writeSigned(v, bps)
+		}
+	case "fixed":
+		for _, v := range samples[:s.order] {
+			w.writeSigned(v, bps)
+		}
+		var residual []int32
+		for i := s.order; i < len(samples); i++ {
+			var p int32
+			switch s.order {
+			case 1:
+				p = samples[i-1]
+			case 2:
+				p = 2*samples[i-1] - samples[i-2]
+			case 3:
+				p = 3*samples[i-1] - 3*samples[i-2] + samples[i-3]
+			case 4:
+				p = 4*samples[i-1] - 6*samples[i-2] + 4*samples[i-3] - samples[i-4]
+			}
+			residual = append(residual, samples[i]-p)
+		}
+		w.writeResidual(residual)
+	case "lpc":
+		order := len(s.coefs)
+		for _, v := range samples[:order] {
+			w.writeSigned(v, bps)
+		}
+		w.write(uint64(s.precision-1), 4)
+		w.write(uint64(s.shift), 5)
+		for _, c := range s.coefs {
+			w.writeSigned(c, s.precision)
+		}
+		var residual []int32
+		for i := order; i < len(samples); i++ {
+			var sum int64
+			for j, c := range s.coefs {
+				sum += int64(c) * int64(samples[i-j-1])
+			}
+			residual = append(residual, samples[i]-int32(sum>>s.shift))
+		}
+		w.writeResidual(residual)
+	}
+}
+
+const blockSize = 16
+
+type testFrame struct {
+	channelCode uint64
+	subframes   []subframe
+}
+
+// encodeForTesting encodes the samples to FLAC data. samples are for each channel. Each frame has blockSize samples.
+func encodeForTesting(samples [][]int32, bps uint, frames []testFrame, seekTable bool) []byte {
+	var w bitWriter
+	w.buf = append(w.buf, []byte("fLaC")...)
+
+	// STREAMINFO
+	if seekTable {
+		w.write(0, 1)
+	} else {
+		w.write(1, 1)
+	}
+	w.write(0, 7)
+	w.write(34, 24)
+	w.write(blockSize, 16)
+	w.write(blockSize, 16)
+	w.write(0, 24)
+	w.write(0, 24)
+	w.write(22050, 20)
+	w.write(uint64(len(samples)-1), 3)
+	w.write(uint64(bps-1), 5)
+	w.write(uint64(len(samples[0])), 36)
+	w.buf = append(w.buf, make([]byte, 16)...)
+
+	var frameData [][]byte
+	for i, f := range frames {
+		var fw bitWriter
+		fw.write(0xfff8, 16)
+		// The block size is 8bit at the end of the header, and the sample rate is from STREAMINFO.
+		fw.write(6, 4)
+		fw.write(0, 4)
+		fw.write(f.channelCode, 4)
+		fw.write(0, 3)
+		fw.write(0, 1)
+		fw.write(uint64(i), 8)
+		fw.write(blockSize-1, 8)
+		// CRC-8
+		fw.write(0, 8)
+
+		for ch, s := range f.subframes {
+			vs := samples[ch][i*blockSize : (i+1)*blockSize]
+			sbps := bps
+			l, r := samples[0][i*blockSize:(i+1)*blockSize], []int32(nil)
+			if len(samples) > 1 {
+				r = samples[1][i*blockSize : (i+1)*blockSize]
+			}
+			side := func() []int32 {
+				vs := make([]int32, blockSize)
+				for i := range vs {
+					vs[i] = l[i] - r[i]
+				}
+				return vs
+			}
+			switch {
+			case f.channelCode == 8 && ch == 1, f.channelCode == 9 && ch == 0:
+				vs = side()
+				sbps++
+			case f.channelCode == 10 && ch == 0:
+				vs = make([]int32, blockSize)
+				for i := range vs {
+					vs[i] = (l[i] + r[i]) >> 1
+				}
+			case f.channelCode == 10 && ch == 1:
+				vs = side()
+				sbps++
+			}
+			fw.writeSubframe(vs, sbps, s)
+		}
+		fw.align()
+		// CRC-16
+		fw.write(0, 16)
+		frameData = append(frameData, fw.buf)
+	}
+
+	if seekTable {
+		// SEEKTABLE with a point for each frame and a placeholder.
+		w.write(1, 1)
+		w.write(3, 7)
+		w.write(uint64(18*(len(frames)+1)), 24)
+		var offset int
+		for i, f := range frameData {
+			w.write(uint64(i*blockSize), 64)
+			w.write(uint64(offset), 64)
+			w.write(blockSize, 16)
+			offset += len(f)
+		}
+		w.write(math.MaxUint64, 64)
+		w.write(0, 64)
+		w.write(0, 16)
+	}
+
+	for _, f := range frameData {
+		w.buf = append(w.buf, f...)
+	}
+	return w.buf
+}
+
+func testSamples(channelNum int, n int, amplitude float64) [][]int32 {
+	samples := make([][]int32, channelNum)
+	for ch := range samples {
+		samples[ch] = make([]int32, n)
+		for i := range samples[ch] {
+			samples[ch][i] = int32(amplitude * math.Sin(float64(i)*0.3+float64(ch)))
+		}
+	}
+	return samples
+}
+
+func stereo16(samples [][]int32, shift int) []byte {
+	var b []byte
+	for i := range samples[0] {
+		for ch := 0; ch < 2; ch++ {
+			v := samples[0][i]
+			if len(samples) > 1 {
+				v = samples[ch][i]
+			}
+			if shift > 0 {
+				v >>= uint(shift)
+			} else {
+				v <<= uint(-shift)
+			}
+			b = append(b, byte(v), byte(v>>8))
+		}
+	}
+	return b
+}
+
+func TestDecode(t *testing.T) {
+	samples := testSamples(2, blockSize*5, 10000)
+	// The fourth frame is a constant with wasted bits.
+	for ch := range samples {
+		for i := blockSize * 3; i < blockSize*4; i++ {
+			samples[ch][i] = 0x1230
+		}
+	}
+
+	frames := []testFrame{
+		{
+			channelCode: 1,
+			subframes:   []subframe{{typ: "verbatim"}, {typ: "verbatim"}},
+		},
+		{
+			channelCode: 8,
+			subframes:   []subframe{{typ: "fixed", order: 2}, {typ: "fixed", order: 1}},
+		},
+		{
+			channelCode: 10,
+			subframes: []subframe{
+				{typ: "lpc", coefs: []int32{7, -4}, precision: 5, shift: 2},
+				{typ: "fixed", order: 4},
+			},
+		},
+		{
+			channelCode: 1,
+			subframes:   []subframe{{typ: "constant", wasted: 4}, {typ: "constant"}},
+		},
+		{
+			channelCode: 9,
+			subframes:   []subframe{{typ: "fixed", order: 3}, {typ: "lpc", coefs: []int32{3, -2, 1}, precision: 4, shift: 1}},
+		},
+	}
+
+	for _, seekTable := range []bool{false, true} {
+		data := encodeForTesting(samples, 16, frames, seekTable)
+		s, err := flac.DecodeWithoutResampling(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := s.SampleRate(), 22050; got != want {
+			t.Errorf("s.SampleRate(): got: %d, want: %d", got, want)
+		}
+		want := stereo16(samples, 0)
+		if got, want := s.Length(), int64(len(want)); got != want {
+			t.Errorf("s.Length(): got: %d, want: %d", got, want)
+		}
+		got, err := ioutil.ReadAll(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("seekTable: %v, got: %v, want: %v", seekTable, got, want)
+		}
+
+		// Seek to the middle of a frame.
+		for _, offset := range []int64{0, 40 * 4, 40*4 + 2, 79 * 4, 80 * 4} {
+			pos, err := s.Seek(offset, io.SeekStart)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pos != offset {
+				t.Errorf("seekTable: %v, Seek: got: %d, want: %d", seekTable, pos, offset)
+			}
+			got, err := ioutil.ReadAll(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want[offset:]) {
+				t.Errorf("seekTable: %v, offset: %d, got: %v, want: %v", seekTable, offset, got, want[offset:])
+			}
+		}
+	}
+}
+
+func TestDecodeMono24(t *testing.T) {
+	samples := testSamples(1, blockSize*2, 1<<22)
+	frames := []testFrame{
+		{
+			channelCode: 0,
+			subframes:   []subframe{{typ: "verbatim"}},
+		},
+		{
+			channelCode: 0,
+			subframes:   []subframe{{typ: "fixed", order: 1}},
+		},
+	}
+	data := encodeForTesting(samples, 24, frames, false)
+
+	s, err := flac.DecodeWithSampleRate(44100, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.SampleRate(), 44100; got != want {
+		t.Errorf("s.SampleRate(): got: %d, want: %d", got, want)
+	}
+
+	s, err = flac.DecodeWithoutResampling(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadAll(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := stereo16(samples, 8); !bytes.Equal(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	if _, err := flac.DecodeWithoutResampling(bytes.NewReader([]byte("RIFF0000WAVE"))); err == nil {
+		t.Errorf("DecodeWithoutResampling must return an error for non-FLAC data")
+	}
+}
@@ -0,0 +1,482 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flac
+
+import (
+	"fmt"
+	"io"
+)
+
+// frame is a decoded FLAC frame.
+type frame struct {
+	// first is the index of the first sample in the stream.
+	first int64
+
+	// samples is the decoded samples for each channel.
+	samples [][]int32
+}
+
+const (
+	channelsIndependent = iota
+	channelsLeftSide
+	channelsSideRight
+	channelsMidSide
+)
+
+// decodeFrame decodes a frame. decodeFrame returns io.EOF if there are no more frames.
+//
+// See https://xiph.org/flac/format.html#frame for the format.
+func decodeFrame(b *bitReader, info *streamInfo) (*frame, error) {
+	c0, err := b.readByte()
+	if err != nil {
+		return nil, err
+	}
+	c1, err := b.readByte()
+	if err != nil {
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	if c0 != 0xff || c1&0xfe != 0xf8 {
+		return nil, fmt.Errorf("flac: invalid frame sync code")
+	}
+	variableBlockSize := c1&1 == 1
+
+	v, err := b.readBits(16)
+	if err != nil {
+		return nil, err
+	}
+	blockSizeCode := v >> 12
+	sampleRateCode := v >> 8 & 0xf
+	channelCode := v >> 4 & 0xf
+	sampleSizeCode := v >> 1 & 0x7
+
+	num, err := readUTF8Number(b)
+	if err != nil {
+		return nil, err
+	}
+
+	var blockSize int
+	switch {
+	case blockSizeCode == 0:
+		return nil, fmt.Errorf("flac: reserved block size")
+	case blockSizeCode == 1:
+		blockSize = 192
+	case blockSizeCode <= 5:
+		blockSize = 576 << (blockSizeCode - 2)
+	case blockSizeCode == 6:
+		v, err := b.readBits(8)
+		if err != nil {
+			return nil, err
+		}
+		blockSize = int(v) + 1
+	case blockSizeCode == 7:
+		v, err := b.readBits(16)
+		if err != nil {
+			return nil, err
+		}
+		blockSize = int(v) + 1
+	default:
+		blockSize = 256 << (blockSizeCode - 8)
+	}
+
+	// The sample rate is always taken from STREAMINFO. Skip the bits for the sample rate.
+	switch sampleRateCode {
+	case 12:
+		if _, err := b.readBits(8); err != nil {
+			return nil, err
+		}
+	case 13, 14:
+		if _, err := b.readBits(16); err != nil {
+			return nil, err
+		}
+	case 15:
+		return nil, fmt.Errorf("flac: invalid sample rate")
+	}
+
+	var bps int
+	switch sampleSizeCode {
+	case 0:
+		bps = info.bitsPerSample
+	case 1:
+		bps = 8
+	case 2:
+		bps = 12
+	case 4:
+		bps = 16
+	case 5:
+		bps = 20
+	case 6:
+		bps = 24
+	case 7:
+		bps = 32
+	default:
+		return nil, fmt.Errorf("flac: reserved sample size")
+	}
+
+	var channelNum int
+	assignment := channelsIndependent
+	switch {
+	case channelCode < 8:
+		channelNum = int(channelCode) + 1
+	case channelCode == 8:
+		channelNum = 2
+		assignment = channelsLeftSide
+	case channelCode == 9:
+		channelNum = 2
+		assignment = channelsSideRight
+	case channelCode == 10:
+		channelNum = 2
+		assignment = channelsMidSide
+	default:
+		return nil, fmt.Errorf("flac: reserved channel assignment")
+	}
+	if channelNum != info.channelNum {
+		return nil, fmt.Errorf("flac: the channel num of a frame doesn't match with STREAMINFO")
+	}
+
+	// CRC-8 of the header.
+	if _, err := b.readBits(8); err != nil {
+		return nil, err
+	}
+
+	f := &frame{
+		samples: make([][]int32, channelNum),
+	}
+	if variableBlockSize {
+		f.first = int64(num)
+	} else {
+		// For a fixed block size stream, all the frames but the last one have the same block size.
+		f.first = int64(num) * int64(info.maxBlockSize)
+	}
+
+	for ch := range f.samples {
+		sbps := bps
+		// The side channel has one more bit.
+		if (assignment == channelsLeftSide && ch == 1) ||
+			(assignment == channelsSideRight && ch == 0) ||
+			(assignment == channelsMidSide && ch == 1) {
+			sbps++
+		}
+		if sbps > 32 {
+			return nil, fmt.Errorf("flac: bits per sample must be <= 32 but was %d", sbps)
+		}
+		s, err := decodeSubframe(b, blockSize, sbps)
+		if err != nil {
+			return nil, err
+		}
+		f.samples[ch] = s
+	}
+
+	switch assignment {
+	case channelsLeftSide:
+		l, s := f.samples[0], f.samples[1]
+		for i := range s {
+			s[i] = l[i] - s[i]
+		}
+	case channelsSideRight:
+		s, r := f.samples[0], f.samples[1]
+		for i := range s {
+			s[i] += r[i]
+		}
+	case channelsMidSide:
+		m, s := f.samples[0], f.samples[1]
+		for i := range m {
+			mid := m[i]<<1 | s[i]&1
+			m[i] = (mid + s[i]) >> 1
+			s[i] = (mid - s[i]) >> 1
+		}
+	}
+
+	// The footer is CRC-16 of the frame.
+	b.align()
+	if _, err := b.readBits(16); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// readUTF8Number reads a number coded in the way like UTF-8, up to 36 bits.
+func readUTF8Number(b *bitReader) (uint64, error) {
+	c, err := b.readBits(8)
+	if err != nil {
+		return 0, err
+	}
+
+	var n int
+	switch {
+	case c&0x80 == 0:
+		return uint64(c), nil
+	case c&0xe0 == 0xc0:
+		n = 1
+		c &= 0x1f
+	case c&0xf0 == 0xe0:
+		n = 2
+		c &= 0x0f
+	case c&0xf8 == 0xf0:
+		n = 3
+		c &= 0x07
+	case c&0xfc == 0xf8:
+		n = 4
+		c &= 0x03
+	case c&0xfe == 0xfc:
+		n = 5
+		c &= 0x01
+	case c == 0xfe:
+		n = 6
+		c = 0
+	default:
+		return 0, fmt.Errorf("flac: invalid UTF-8 coded number")
+	}
+
+	v := uint64(c)
+	for i := 0; i < n; i++ {
+		c, err := b.readBits(8)
+		if err != nil {
+			return 0, err
+		}
+		if c&0xc0 != 0x80 {
+			return 0, fmt.Errorf("flac: invalid UTF-8 coded number")
+		}
+		v = v<<6 | uint64(c&0x3f)
+	}
+	return v, nil
+}
+
+// decodeSubframe decodes a subframe of one channel.
+func decodeSubframe(b *bitReader, blockSize int, bps int) ([]int32, error) {
+	v, err := b.readBits(8)
+	if err != nil {
+		return nil, err
+	}
+	if v&0x80 != 0 {
+		return nil, fmt.Errorf("flac: invalid subframe padding")
+	}
+	typ := v >> 1 & 0x3f
+
+	var wasted int
+	if v&1 == 1 {
+		k, err := b.readUnary()
+		if err != nil {
+			return nil, err
+		}
+		wasted = int(k) + 1
+		bps -= wasted
+		if bps <= 0 {
+			return nil, fmt.Errorf("flac: too many wasted bits")
+		}
+	}
+
+	samples := make([]int32, blockSize)
+	switch {
+	case typ == 0:
+		// CONSTANT
+		v, err := b.readSigned(uint(bps))
+		if err != nil {
+			return nil, err
+		}
+		for i := range samples {
+			samples[i] = v
+		}
+	case typ == 1:
+		// VERBATIM
+		for i := range samples {
+			v, err := b.readSigned(uint(bps))
+			if err != nil {
+				return nil, err
+			}
+			samples[i] = v
+		}
+	case typ >= 8 && typ <= 12:
+		// FIXED
+		if err := decodeFixed(b, samples, int(typ-8), bps); err != nil {
+			return nil, err
+		}
+	case typ >= 32:
+		// LPC
+		if err := decodeLPC(b, samples, int(typ-31), bps); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("flac: reserved subframe type: %d", typ)
+	}
+
+	if wasted > 0 {
+		for i := range samples {
+			samples[i] <<= uint(wasted)
+		}
+	}
+	return samples, nil
+}
+
+func readWarmUp(b *bitReader, samples []int32, order int, bps int) error {
+	if order > len(samples) {
+		return fmt.Errorf("flac: the predictor order is larger than the block size")
+	}
+	for i := 0; i < order; i++ {
+		v, err := b.readSigned(uint(bps))
+		if err != nil {
+			return err
+		}
+		samples[i] = v
+	}
+	return nil
+}
+
+func decodeFixed(b *bitReader, samples []int32, order int, bps int) error {
+	if err := readWarmUp(b, samples, order, bps); err != nil {
+		return err
+	}
+	if err := decodeResidual(b, samples, order); err != nil {
+		return err
+	}
+
+	s := samples
+	switch order {
+	case 1:
+		for i := 1; i < len(s); i++ {
+			s[i] += s[i-1]
+		}
+	case 2:
+		for i := 2; i < len(s); i++ {
+			s[i] += 2*s[i-1] - s[i-2]
+		}
+	case 3:
+		for i := 3; i < len(s); i++ {
+			s[i] += 3*s[i-1] - 3*s[i-2] + s[i-3]
+		}
+	case 4:
+		for i := 4; i < len(s); i++ {
+			s[i] += 4*s[i-1] - 6*s[i-2] + 4*s[i-3] - s[i-4]
+		}
+	}
+	return nil
+}
+
+func decodeLPC(b *bitReader, samples []int32, order int, bps int) error {
+	if err := readWarmUp(b, samples, order, bps); err != nil {
+		return err
+	}
+
+	v, err := b.readBits(4)
+	if err != nil {
+		return err
+	}
+	if v == 0xf {
+		return fmt.Errorf("flac: invalid LPC precision")
+	}
+	precision := uint(v) + 1
+
+	shift, err := b.readSigned(5)
+	if err != nil {
+		return err
+	}
+	if shift < 0 {
+		return fmt.Errorf("flac: negative LPC shift")
+	}
+
+	coefs := make([]int32, order)
+	for i := range coefs {
+		c, err := b.readSigned(precision)
+		if err != nil {
+			return err
+		}
+		coefs[i] = c
+	}
+
+	if err := decodeResidual(b, samples, order); err != nil {
+		return err
+	}
+
+	for i := order; i < len(samples); i++ {
+		var sum int64
+		for j, c := range coefs {
+			sum += int64(c) * int64(samples[i-j-1])
+		}
+		samples[i] += int32(sum >> uint(shift))
+	}
+	return nil
+}
+
+// decodeResidual decodes the Rice coded residuals into samples[order:].
+func decodeResidual(b *bitReader, samples []int32, order int) error {
+	method, err := b.readBits(2)
+	if err != nil {
+		return err
+	}
+	var paramBits uint
+	switch method {
+	case 0:
+		paramBits = 4
+	case 1:
+		paramBits = 5
+	default:
+		return fmt.Errorf("flac: reserved residual coding method")
+	}
+	escape := uint32(1)<<paramBits - 1
+
+	partitionOrder, err := b.readBits(4)
+	if err != nil {
+		return err
+	}
+	partitions := 1 << partitionOrder
+	if len(samples)%partitions != 0 || len(samples)/partitions < order {
+		return fmt.Errorf("flac: invalid partition order")
+	}
+
+	i := order
+	for p := 0; p < partitions; p++ {
+		n := len(samples) / partitions
+		if p == 0 {
+			n -= order
+		}
+
+		k, err := b.readBits(paramBits)
+		if err != nil {
+			return err
+		}
+		if k == escape {
+			bits, err := b.readBits(5)
+			if err != nil {
+				return err
+			}
+			for j := 0; j < n; j++ {
+				v, err := b.readSigned(uint(bits))
+				if err != nil {
+					return err
+				}
+				samples[i] = v
+				i++
+			}
+			continue
+		}
+
+		for j := 0; j < n; j++ {
+			q, err := b.readUnary()
+			if err != nil {
+				return err
+			}
+			r, err := b.readBits(uint(k))
+			if err != nil {
+				return err
+			}
+			u := q<<k | r
+			samples[i] = int32(u>>1) ^ -int32(u&1)
+			i++
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+import (
+	"io"
+	"math"
+)
+
+// sampleFormat represents a format of samples of a source stream.
+type sampleFormat int
+
+const (
+	// sampleFormatInt16 is 16bit little endian signed integers.
+	sampleFormatInt16 sampleFormat = iota
+
+	// sampleFormatFloat32 is 32bit little endian floats.
+	sampleFormatFloat32
+)
+
+// bytesPerSample returns the number of bytes of a sample, i.e. a frame of all the channels.
+func (f sampleFormat) bytesPerSample() int {
+	switch f {
+	case sampleFormatInt16:
+		return bytesPerSample
+	case sampleFormatFloat32:
+		return bytesPerSampleF32
+	}
+	panic("audio: invalid sample format")
+}
+
+// float32Reader converts 16bit samples from a source to float32 samples.
+type float32Reader struct {
+	src io.Reader
+	buf []byte
+
+	// pending is the number of bytes in the head of buf that are not converted yet.
+	pending int
+}
+
+func newFloat32Reader(src io.Reader) *float32Reader {
+	return &float32Reader{
+		src: src,
+	}
+}
+
+// reset discards the pending bytes. reset should be called when the source is seeked.
+func (r *float32Reader) reset() {
+	r.pending = 0
+}
+
+func (r *float32Reader) Read(buf []byte) (int, error) {
+	size := len(buf) / bitDepthInBytesF32 * bitDepthInBytes
+	if size == 0 {
+		return 0, nil
+	}
+	if cap(r.buf) < size {
+		b := make([]byte, size)
+		copy(b, r.buf[:r.pending])
+		r.buf = b
+	}
+	r.buf = r.buf[:size]
+
+	n, err := r.src.Read(r.buf[r.pending:])
+	n += r.pending
+	m := n / bitDepthInBytes
+	for i := 0; i < m; i++ {
+		v := float32(int16(r.buf[2*i])|int16(r.buf[2*i+1])<<8) / (1 << 15)
+		putFloat32(buf[4*i:], v)
+	}
+	r.pending = copy(r.buf, r.buf[m*bitDepthInBytes:n])
+	return m * bitDepthInBytesF32, err
+}
+
+func float32At(b []byte) float32 {
+	return math.Float32frombits(uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24)
+}
+
+func putFloat32(b []byte, v float32) {
+	bits := math.Float32bits(v)
+	b[0] = byte(bits)
+	b[1] = byte(bits >> 8)
+	b[2] = byte(bits >> 16)
+	b[3] = byte(bits >> 24)
+}
+
+// float32ToInt16 converts float32 samples in src to 16bit samples in dst with the volume.
+// The values out of the range of [-1, 1] are clipped.
+func float32ToInt16(dst []byte, src []byte, volume float64) {
+	for i := 0; i < len(src)/bitDepthInBytesF32; i++ {
+		v := float64(float32At(src[4*i:])) * volume * (1 << 15)
+		v = math.Max(math.MinInt16, math.Min(v, math.MaxInt16))
+		v16 := int16(v)
+		dst[2*i] = byte(v16)
+		dst[2*i+1] = byte(v16 >> 8)
+	}
+}
@@ -0,0 +1,408 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"time"
+)
+
+// oscillator generates an infinite 16bit little endian, 2 channel stereo linear PCM stream.
+type oscillator struct {
+	sampleRate int
+	frequency  float64
+
+	// pos is the position in bytes.
+	pos int64
+
+	// phase is the phase of the next frame in [0, 1).
+	phase float64
+
+	// current is the value of the current frame.
+	current int16
+
+	// value returns a value in [-1, 1] for the given frame. value is called for each frame in order except for
+	// seeking.
+	value func(frame int64) float64
+
+	m sync.Mutex
+}
+
+func (o *oscillator) advance() float64 {
+	p := o.phase
+	o.phase += o.frequency / float64(o.sampleRate)
+	o.phase -= math.Floor(o.phase)
+	return p
+}
+
+func (o *oscillator) Frequency() float64 {
+	o.m.Lock()
+	defer o.m.Unlock()
+	return o.frequency
+}
+
+func (o *oscillator) SetFrequency(frequency float64) {
+	if frequency < 0 || math.IsNaN(frequency) || math.IsInf(frequency, 0) {
+		panic(fmt.Sprintf("audio: frequency must be non-negative and finite but %f", frequency))
+	}
+	o.m.Lock()
+	defer o.m.Unlock()
+	o.frequency = frequency
+}
+
+func (o *oscillator) nextFrame() {
+	v := o.value(o.pos/bytesPerSample) * (1<<15 - 1)
+	o.current = int16(math.Max(-(1<<15 - 1), math.Min(v, 1<<15-1)))
+}
+
+func (o *oscillator) Read(buf []byte) (int, error) {
+	o.m.Lock()
+	defer o.m.Unlock()
+
+	for i := range buf {
+		if o.pos%bytesPerSample == 0 {
+			o.nextFrame()
+		}
+		if o.pos%2 == 0 {
+			buf[i] = byte(o.current)
+		} else {
+			buf[i] = byte(o.current >> 8)
+		}
+		o.pos++
+	}
+	return len(buf), nil
+}
+
+func (o *oscillator) Seek(offset int64, whence int) (int64, error) {
+	o.m.Lock()
+	defer o.m.Unlock()
+
+	next := int64(0)
+	switch whence {
+	case io.SeekStart:
+		next = offset
+	case io.SeekCurrent:
+		next = o.pos + offset
+	case io.SeekEnd:
+		return 0, fmt.Errorf("audio: whence must be io.SeekStart or io.SeekCurrent for generators")
+	}
+	if next < 0 {
+		return 0, fmt.Errorf("audio: position must >= 0")
+	}
+
+	o.pos = next
+	frame := next / bytesPerSample
+	o.phase = o.frequency * float64(frame) / float64(o.sampleRate)
+	o.phase -= math.Floor(o.phase)
+	if o.pos%bytesPerSample != 0 {
+		o.nextFrame()
+	}
+	return o.pos, nil
+}
+
+// Sine represents an infinite stream of a sine wave.
+//
+// The format is same as NewPlayer's source: 16bit little endian, 2 channel stereo linear PCM.
+// The amplitude is the maximum. Use Player.SetVolume to adjust the volume.
+//
+// Sine is concurrent-safe.
+type Sine struct {
+	o oscillator
+}
+
+// NewSine creates a new sine wave stream with the sample rate and the frequency in Hz.
+func NewSine(sampleRate int, frequency float64) *Sine {
+	s := &Sine{}
+	s.o.sampleRate = sampleRate
+	s.o.SetFrequency(frequency)
+	s.o.value = func(int64) float64 {
+		return math.Sin(2 * math.Pi * s.o.advance())
+	}
+	return s
+}
+
+// Frequency returns the frequency in Hz.
+func (s *Sine) Frequency() float64 {
+	return s.o.Frequency()
+}
+
+// SetFrequency sets the frequency in Hz. The phase is kept continuous.
+//
+// SetFrequency panics if frequency is negative or not finite.
+func (s *Sine) SetFrequency(frequency float64) {
+	s.o.SetFrequency(frequency)
+}
+
+// Read is implementation of io.Reader's Read.
+//
+// Read never returns io.EOF.
+func (s *Sine) Read(buf []byte) (int, error) {
+	return s.o.Read(buf)
+}
+
+// Seek is implementation of io.Seeker's Seek.
+//
+// The phase after seeking is calculated with the current frequency.
+// io.SeekEnd is not available.
+func (s *Sine) Seek(offset int64, whence int) (int64, error) {
+	return s.o.Seek(offset, whence)
+}
+
+// Square represents an infinite stream of a square (pulse) wave.
+//
+// The wave is not band-limited, which sounds like retro sound chips.
+//
+// The format is same as NewPlayer's source: 16bit little endian, 2 channel stereo linear PCM.
+// The amplitude is the maximum. Use Player.SetVolume to adjust the volume.
+//
+// Square is concurrent-safe.
+type Square struct {
+	o    oscillator
+	duty float64
+}
+
+// NewSquare creates a new square wave stream with the sample rate and the frequency in Hz.
+//
+// The default duty cycle is 0.5.
+func NewSquare(sampleRate int, frequency float64) *Square {
+	s := &Square{
+		duty: 0.5,
+	}
+	s.o.sampleRate = sampleRate
+	s.o.SetFrequency(frequency)
+	s.o.value = func(int64) float64 {
+		if s.o.advance() < s.duty {
+			return 1
+		}
+		return -1
+	}
+	return s
+}
+
+// Frequency returns the frequency in Hz.
+func (s *Square) Frequency() float64 {
+	return s.o.Frequency()
+}
+
+// SetFrequency sets the frequency in Hz. The phase is kept continuous.
+//
+// SetFrequency panics if frequency is negative or not finite.
+func (s *Square) SetFrequency(frequency float64) {
+	s.o.SetFrequency(frequency)
+}
+
+// DutyCycle returns the ratio of the high part in a period.
+func (s *Square) DutyCycle() float64 {
+	s.o.m.Lock()
+	defer s.o.m.Unlock()
+	return s.duty
+}
+
+// SetDutyCycle sets the ratio of the high part in a period. For example, retro sound chips have 0.125, 0.25 and 0.5.
+//
+// SetDutyCycle panics if duty is not in [0, 1].
+func (s *Square) SetDutyCycle(duty float64) {
+	if !(duty >= 0 && duty <= 1) {
+		panic(fmt.Sprintf("audio: duty must be in [0, 1] but %f", duty))
+	}
+	s.o.m.Lock()
+	defer s.o.m.Unlock()
+	s.duty = duty
+}
+
+// Read is implementation of io.Reader's Read.
+//
+// Read never returns io.EOF.
+func (s *Square) Read(buf []byte) (int, error) {
+	return s.o.Read(buf)
+}
+
+// Seek is implementation of io.Seeker's Seek.
+//
+// The phase after seeking is calculated with the current frequency.
+// io.SeekEnd is not available.
+func (s *Square) Seek(offset int64, whence int) (int64, error) {
+	return s.o.Seek(offset, whence)
+}
+
+// Noise represents an infinite stream of a white noise.
+//
+// The noise is pseudo-random and deterministic: the same samples are generated at the same position.
+//
+// The format is same as NewPlayer's source: 16bit little endian, 2 channel stereo linear PCM.
+// The amplitude is the maximum. Use Player.SetVolume to adjust the volume.
+//
+// Noise is concurrent-safe.
+type Noise struct {
+	o oscillator
+}
+
+// NewNoise creates a new white noise stream.
+func NewNoise() *Noise {
+	n := &Noise{}
+	n.o.sampleRate = 1
+	n.o.value = func(frame int64) float64 {
+		// Hash the frame index with the finalizer of SplitMix64 so that the value doesn't depend on the history.
+		x := uint64(frame) + 0x9e3779b97f4a7c15
+		x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+		x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+		x ^= x >> 31
+		return float64(x>>11)/(1<<52) - 1
+	}
+	return n
+}
+
+// Read is implementation of io.Reader's Read.
+//
+// Read never returns io.EOF.
+func (n *Noise) Read(buf []byte) (int, error) {
+	return n.o.Read(buf)
+}
+
+// Seek is implementation of io.Seeker's Seek.
+//
+// io.SeekEnd is not available.
+func (n *Noise) Seek(offset int64, whence int) (int64, error) {
+	return n.o.Seek(offset, whence)
+}
+
+// Envelope represents a stream whose volume is shaped by an ADSR (attack, decay, sustain and release) envelope.
+//
+// The volume rises linearly from 0 to 1 in the attack time, falls linearly to the sustain level in the decay time,
+// and then stays at the sustain level until Release is called. After Release is called, the volume falls linearly
+// to 0 in the release time, and then the stream reaches EOF.
+//
+// Envelope is concurrent-safe.
+type Envelope struct {
+	src io.Reader
+
+	attack  int64
+	decay   int64
+	sustain float64
+	release int64
+
+	// pos is the position in bytes.
+	pos int64
+
+	released     bool
+	releaseFrame int64
+	releaseLevel float64
+
+	// pending is an incomplete sample read from the source.
+	pending []byte
+
+	m sync.Mutex
+}
+
+// NewEnvelope creates a new stream with a source stream, the sample rate and the ADSR parameters.
+//
+// src's format must be same as NewPlayer's source: 16bit little endian, 2 channel stereo linear PCM.
+// The returned stream has the same format.
+//
+// sustain is the volume level in [0, 1]. NewEnvelope panics if sustain is out of the range.
+func NewEnvelope(src io.Reader, sampleRate int, attack, decay time.Duration, sustain float64, release time.Duration) *Envelope {
+	if !(sustain >= 0 && sustain <= 1) {
+		panic(fmt.Sprintf("audio: sustain must be in [0, 1] but %f", sustain))
+	}
+	frames := func(d time.Duration) int64 {
+		if d < 0 {
+			return 0
+		}
+		return int64(d) * int64(sampleRate) / int64(time.Second)
+	}
+	return &Envelope{
+		src:     src,
+		attack:  frames(attack),
+		decay:   frames(decay),
+		sustain: sustain,
+		release: frames(release),
+	}
+}
+
+// Release starts the release phase.
+//
+// As a player reads the stream ahead, the release phase starts at the position that is already read.
+// If Release is already called, Release does nothing.
+func (e *Envelope) Release() {
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	if e.released {
+		return
+	}
+	f := (e.pos + bytesPerSample - 1) / bytesPerSample
+	e.releaseLevel = e.levelBeforeRelease(f)
+	e.releaseFrame = f
+	e.released = true
+}
+
+func (e *Envelope) levelBeforeRelease(frame int64) float64 {
+	if frame < e.attack {
+		return float64(frame) / float64(e.attack)
+	}
+	frame -= e.attack
+	if frame < e.decay {
+		return 1 - (1-e.sustain)*float64(frame)/float64(e.decay)
+	}
+	return e.sustain
+}
+
+func (e *Envelope) level(frame int64) float64 {
+	if !e.released || frame < e.releaseFrame {
+		return e.levelBeforeRelease(frame)
+	}
+	frame -= e.releaseFrame
+	if frame >= e.release {
+		return 0
+	}
+	return e.releaseLevel * (1 - float64(frame)/float64(e.release))
+}
+
+// Read is implementation of io.Reader's Read.
+func (e *Envelope) Read(buf []byte) (int, error) {
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	if e.released {
+		rest := (e.releaseFrame+e.release)*bytesPerSample - e.pos
+		if rest <= 0 {
+			return 0, io.EOF
+		}
+		if int64(len(buf)) > rest {
+			buf = buf[:rest]
+		}
+	}
+
+	n := copy(buf, e.pending)
+	e.pending = e.pending[:0]
+	m, err := e.src.Read(buf[n:])
+	n += m
+
+	// Keep the incomplete sample for the next Read.
+	e.pending = append(e.pending, buf[n/2*2:n]...)
+	n = n / 2 * 2
+
+	for i := 0; i < n; i += 2 {
+		v := int16(buf[i]) | int16(buf[i+1])<<8
+		v = int16(float64(v) * e.level(e.pos/bytesPerSample))
+		buf[i] = byte(v)
+		buf[i+1] = byte(v >> 8)
+		e.pos += 2
+	}
+	return n, err
+}
@@ -0,0 +1,151 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math"
+	"testing"
+	"time"
+
+	. "github.com/hajimehoshi/ebiten/v2/audio"
+)
+
+func readInt16s(t *testing.T, r io.Reader, n int) []int16 {
+	buf := make([]byte, n*2)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		t.Fatal(err)
+	}
+	vs := make([]int16, n)
+	for i := range vs {
+		vs[i] = int16(buf[2*i]) | int16(buf[2*i+1])<<8
+	}
+	return vs
+}
+
+func TestSine(t *testing.T) {
+	s := NewSine(8, 1)
+	got := readInt16s(t, s, 16)
+	for i := 0; i < 8; i++ {
+		want := int16(math.Sin(2*math.Pi*float64(i)/8) * math.MaxInt16)
+		if got[2*i] != want || got[2*i+1] != want {
+			t.Errorf("frame %d: got: (%d, %d), want: %d", i, got[2*i], got[2*i+1], want)
+		}
+	}
+
+	// Seek to the middle of a frame.
+	if _, err := s.Seek(2*4+2, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readInt16s(t, s, 3), []int16{got[5], got[6], got[7]}; !equalInt16s(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
+
+func TestSquare(t *testing.T) {
+	s := NewSquare(8, 1)
+	s.SetDutyCycle(0.25)
+	got := readInt16s(t, s, 16)
+	want := []int16{
+		math.MaxInt16, math.MaxInt16,
+		math.MaxInt16, math.MaxInt16,
+		-math.MaxInt16, -math.MaxInt16,
+		-math.MaxInt16, -math.MaxInt16,
+		-math.MaxInt16, -math.MaxInt16,
+		-math.MaxInt16, -math.MaxInt16,
+		-math.MaxInt16, -math.MaxInt16,
+		-math.MaxInt16, -math.MaxInt16,
+	}
+	if !equalInt16s(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
+
+func TestNoise(t *testing.T) {
+	n := NewNoise()
+	got := readInt16s(t, n, 1024)
+
+	var sum float64
+	for _, v := range got {
+		sum += float64(v)
+	}
+	if avg := sum / float64(len(got)); math.Abs(avg) > 2000 {
+		t.Errorf("the average must be around 0 but %f", avg)
+	}
+
+	// The same samples are generated at the same position.
+	if _, err := n.Seek(100*4, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readInt16s(t, n, 4), got[200:204]; !equalInt16s(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
+
+func TestEnvelope(t *testing.T) {
+	src := bytes.NewReader(stereo16(repeatInt16(10000, 16)...))
+	e := NewEnvelope(src, 1000, 2*time.Millisecond, 2*time.Millisecond, 0.5, 4*time.Millisecond)
+
+	got := readInt16s(t, e, 12)
+	want := []int16{
+		0, 0,
+		5000, 5000,
+		10000, 10000,
+		7500, 7500,
+		5000, 5000,
+		5000, 5000,
+	}
+	if !equalInt16s(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+
+	e.Release()
+	rest, err := ioutil.ReadAll(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = readInt16s(t, bytes.NewReader(rest), len(rest)/2)
+	want = []int16{
+		5000, 5000,
+		3750, 3750,
+		2500, 2500,
+		1250, 1250,
+	}
+	if !equalInt16s(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
+
+func repeatInt16(v int16, n int) []int16 {
+	vs := make([]int16, n)
+	for i := range vs {
+		vs[i] = v
+	}
+	return vs
+}
+
+func equalInt16s(a, b []int16) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,116 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package browserdecoder decodes audio with the browser's decoder.
+//
+// The browser's decoder runs on a background thread. Decoding a large file with a pure Go decoder blocks the main
+// thread, since Go programs on browsers run on a single thread.
+package browserdecoder
+
+import (
+	"encoding/binary"
+	"math"
+	"syscall/js"
+)
+
+func offlineAudioContextClass() js.Value {
+	if c := js.Global().Get("OfflineAudioContext"); c.Truthy() {
+		return c
+	}
+	return js.Global().Get("webkitOfflineAudioContext")
+}
+
+// IsAvailable reports whether the browser's decoder is available.
+//
+// The decoder is not available e.g. on Node.js or in a Web Worker.
+func IsAvailable() bool {
+	return offlineAudioContextClass().Truthy()
+}
+
+// Decode decodes the encoded audio data with the browser's decoder, and returns 16bit stereo PCM in little endian
+// resampled to sampleRate.
+//
+// Decode returns false if decoding fails, e.g. when the browser doesn't support the format.
+//
+// Decode blocks until decoding finishes. Decode must not be called in a callback from JavaScript.
+func Decode(data []byte, sampleRate int) ([]byte, bool) {
+	class := offlineAudioContextClass()
+	if !class.Truthy() {
+		return nil, false
+	}
+
+	// decodeAudioData resamples the data to the context's sample rate.
+	context := class.New(2, 1, sampleRate)
+
+	a := js.Global().Get("Uint8Array").New(len(data))
+	js.CopyBytesToJS(a, data)
+
+	ch := make(chan struct{})
+	var buf js.Value
+	success := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		buf = args[0]
+		close(ch)
+		return nil
+	})
+	defer success.Release()
+	failure := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		close(ch)
+		return nil
+	})
+	defer failure.Release()
+
+	// Use the callbacks instead of the promise, as old Safari doesn't return a promise.
+	p := context.Call("decodeAudioData", a.Get("buffer"), success, failure)
+	if p.Truthy() && p.Get("catch").Truthy() {
+		// Suppress the error message about the unhandled rejection. The failure callback is also called.
+		p.Call("catch", js.Global().Get("Function").New())
+	}
+	<-ch
+	if !buf.Truthy() {
+		return nil, false
+	}
+
+	l := float32ArrayToSlice(buf.Call("getChannelData", 0))
+	r := l
+	if buf.Get("numberOfChannels").Int() > 1 {
+		r = float32ArrayToSlice(buf.Call("getChannelData", 1))
+	}
+
+	pcm := make([]byte, len(l)*4)
+	for i := range l {
+		binary.LittleEndian.PutUint16(pcm[4*i:], uint16(toInt16(l[i])))
+		binary.LittleEndian.PutUint16(pcm[4*i+2:], uint16(toInt16(r[i])))
+	}
+	return pcm, true
+}
+
+func float32ArrayToSlice(v js.Value) []float32 {
+	bs := make([]byte, v.Get("byteLength").Int())
+	js.CopyBytesToGo(bs, js.Global().Get("Uint8Array").New(v.Get("buffer"), v.Get("byteOffset"), v.Get("byteLength")))
+	fs := make([]float32, len(bs)/4)
+	for i := range fs {
+		fs[i] = math.Float32frombits(binary.LittleEndian.Uint32(bs[4*i:]))
+	}
+	return fs
+}
+
+func toInt16(v float32) int16 {
+	switch {
+	case v >= 1:
+		return math.MaxInt16
+	case v <= -1:
+		return -math.MaxInt16
+	}
+	return int16(v * math.MaxInt16)
+}
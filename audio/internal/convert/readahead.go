@@ -0,0 +1,165 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"io"
+	"sync"
+)
+
+// readAheadChunkSize is the size of the data read from the source at once on the worker goroutine.
+const readAheadChunkSize = 4096
+
+// ReadAhead reads its source on a worker goroutine ahead of Read calls.
+//
+// ReadAhead is useful to avoid blocking the caller of Read by heavy decoding.
+// The worker goroutine runs only while the buffer is not full, so ReadAhead doesn't have to be closed.
+//
+// ReadAhead is concurrent-safe.
+type ReadAhead struct {
+	source io.ReadSeeker
+	size   int
+
+	// buf is the data read ahead. The position of buf[0] is pos.
+	buf []byte
+	pos int64
+
+	// err is the error to be returned after buf is consumed.
+	err error
+
+	working bool
+
+	// generation is incremented on seeking so that the worker discards the data read before seeking.
+	generation int
+
+	m    sync.Mutex
+	cond *sync.Cond
+}
+
+// NewReadAhead creates a new ReadAhead that keeps at most size bytes read ahead.
+//
+// NewReadAhead starts reading the source immediately.
+func NewReadAhead(source io.ReadSeeker, size int) *ReadAhead {
+	if size < readAheadChunkSize {
+		size = readAheadChunkSize
+	}
+	r := &ReadAhead{
+		source: source,
+		size:   size,
+	}
+	r.cond = sync.NewCond(&r.m)
+
+	// Start reading ahead before the first Read.
+	r.m.Lock()
+	r.startWorkerIfNeeded()
+	r.m.Unlock()
+	return r
+}
+
+// startWorkerIfNeeded starts the worker goroutine if the buffer should be filled.
+//
+// startWorkerIfNeeded must be called with the lock held.
+func (r *ReadAhead) startWorkerIfNeeded() {
+	if r.working || r.err != nil || len(r.buf) >= r.size {
+		return
+	}
+	r.working = true
+	go r.work(r.generation)
+}
+
+func (r *ReadAhead) work(generation int) {
+	chunk := make([]byte, readAheadChunkSize)
+	for {
+		r.m.Lock()
+		if r.generation != generation || r.err != nil || len(r.buf) >= r.size {
+			r.working = false
+			r.cond.Broadcast()
+			r.m.Unlock()
+			return
+		}
+		r.m.Unlock()
+
+		// Read the source without the lock so that Read can consume the buffer meanwhile.
+		// Seek waits for the worker to finish, so the source is not used concurrently.
+		n, err := r.source.Read(chunk)
+
+		r.m.Lock()
+		if r.generation == generation {
+			r.buf = append(r.buf, chunk[:n]...)
+			if err != nil {
+				r.err = err
+			}
+		}
+		r.cond.Broadcast()
+		r.m.Unlock()
+	}
+}
+
+// Read is implementation of io.Reader's Read.
+func (r *ReadAhead) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	for len(r.buf) == 0 && r.err == nil {
+		r.startWorkerIfNeeded()
+		r.cond.Wait()
+	}
+	if len(r.buf) == 0 {
+		return 0, r.err
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	r.pos += int64(n)
+	if len(r.buf) < r.size/2 {
+		r.startWorkerIfNeeded()
+	}
+	return n, nil
+}
+
+// Seek is implementation of io.Seeker's Seek.
+//
+// If seeking the source fails, the subsequent Read returns the same error.
+func (r *ReadAhead) Seek(offset int64, whence int) (int64, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	// The source's position is ahead of the position of the reader.
+	if whence == io.SeekCurrent {
+		offset += r.pos
+		whence = io.SeekStart
+	}
+
+	r.generation++
+	for r.working {
+		r.cond.Wait()
+	}
+	r.buf = r.buf[:0]
+	r.err = nil
+
+	n, err := r.source.Seek(offset, whence)
+	if err != nil {
+		// The data read ahead is already discarded. Make the subsequent Read fail.
+		r.err = err
+		return 0, err
+	}
+	r.pos = n
+	r.startWorkerIfNeeded()
+	return n, nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	. "github.com/hajimehoshi/ebiten/v2/audio/internal/convert"
+)
+
+func TestReadAhead(t *testing.T) {
+	data := make([]byte, 100000)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+
+	r := NewReadAhead(bytes.NewReader(data), 10000)
+	got, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("the read data doesn't match")
+	}
+
+	cases := []struct {
+		offset int64
+		whence int
+		want   int64
+	}{
+		{offset: 50000, whence: io.SeekStart, want: 50000},
+		{offset: -1000, whence: io.SeekCurrent, want: 50000 + 100 - 1000},
+		{offset: -300, whence: io.SeekEnd, want: 100000 - 300},
+		{offset: 0, whence: io.SeekStart, want: 0},
+	}
+	for _, c := range cases {
+		pos, err := r.Seek(c.offset, c.whence)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pos != c.want {
+			t.Errorf("Seek(%d, %d): got: %d, want: %d", c.offset, c.whence, pos, c.want)
+		}
+		buf := make([]byte, 100)
+		n, err := io.ReadFull(r, buf)
+		if err != nil && err != io.ErrUnexpectedEOF {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf[:n], data[pos:pos+int64(n)]) {
+			t.Errorf("Seek(%d, %d): the read data doesn't match", c.offset, c.whence)
+		}
+	}
+}
@@ -39,6 +39,7 @@ public:
   const char *Resume();
   const char *Close();
   const char *AppendBuffer(float *buf, size_t len);
+  double OutputLatency();
 
   oboe::DataCallbackResult onAudioReady(oboe::AudioStream *oboe_stream,
                                         void *audio_data,
@@ -74,8 +75,9 @@ const char *Stream::Play(int sample_rate, int channel_num,
   bit_depth_in_bytes_ = bit_depth_in_bytes;
 
   // TODO: Enable bit_depth_in_bytes_ == 1
-  if (bit_depth_in_bytes_ != 2) {
-    return "bit_depth_in_bytes_ must be 2 but not";
+  // The samples are converted to float32 on the Go side regardless of the bit depth.
+  if (bit_depth_in_bytes_ != 2 && bit_depth_in_bytes_ != 4) {
+    return "bit_depth_in_bytes_ must be 2 or 4 but not";
   }
 
   if (!stream_) {
@@ -143,6 +145,29 @@ const char *Stream::Close() {
   return nullptr;
 }
 
+double Stream::OutputLatency() {
+  if (!stream_) {
+    return 0;
+  }
+
+  size_t buffered = 0;
+  {
+    std::lock_guard<std::mutex> lock{mutex_};
+    buffered = buf_.size() / channel_num_;
+  }
+
+  // calculateLatencyMillis might not be implemented e.g. for OpenSL ES. Use the
+  // buffer size instead in this case.
+  double latency = static_cast<double>(stream_->getBufferSizeInFrames()) /
+                   static_cast<double>(sample_rate_);
+  if (oboe::ResultWithValue<double> result = stream_->calculateLatencyMillis();
+      result) {
+    latency = result.value() / 1000;
+  }
+  return latency +
+         static_cast<double>(buffered) / static_cast<double>(sample_rate_);
+}
+
 oboe::DataCallbackResult Stream::onAudioReady(oboe::AudioStream *oboe_stream,
                                               void *audio_data,
                                               int32_t num_frames) {
@@ -192,4 +217,8 @@ const char *ebiten_oboe_Suspend() { return Stream::GetInstance().Pause(); }
 
 const char *ebiten_oboe_Resume() { return Stream::GetInstance().Resume(); }
 
+double ebiten_oboe_OutputLatency() {
+  return Stream::GetInstance().OutputLatency();
+}
+
 } // extern "C"
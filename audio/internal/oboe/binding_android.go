@@ -55,6 +55,12 @@ func Resume() error {
 	return nil
 }
 
+// OutputLatency returns the latency in seconds from the time when the samples are read to the time when they are
+// heard.
+func OutputLatency() float64 {
+	return float64(C.ebiten_oboe_OutputLatency())
+}
+
 //export ebiten_oboe_read
 func ebiten_oboe_read(buf *C.float, len C.size_t) {
 	var s []float32
@@ -29,6 +29,7 @@ const char *ebiten_oboe_Play(int sample_rate, int channel_num,
                              int bit_depth_in_bytes);
 const char *ebiten_oboe_Suspend();
 const char *ebiten_oboe_Resume();
+double ebiten_oboe_OutputLatency();
 
 #ifdef __cplusplus
 }
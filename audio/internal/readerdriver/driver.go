@@ -15,13 +15,34 @@
 package readerdriver
 
 import (
+	"fmt"
 	"io"
+	"time"
 )
 
+// Context is an audio context created by NewContext.
+//
+// NewContext's bitDepthInBytes is 1 or 2 for unsigned 8bit or signed 16bit integer samples, or 4 for 32bit float
+// samples.
+//
+// NewContext's channelNum is 1, 2, 4, 6 (5.1) or 8 (7.1). The channels in a sample are ordered in the same way as
+// WAVE files:
+//
+//	1: mono
+//	2: front left, front right
+//	4: front left, front right, back left, back right
+//	6: front left, front right, front center, low frequency, back left, back right
+//	8: front left, front right, front center, low frequency, back left, back right, side left, side right
+//
+// If the device has fewer channels, the OS or the browser downmixes the channels.
 type Context interface {
 	NewPlayer(io.Reader) Player
 	Suspend() error
 	Resume() error
+
+	// OutputLatency returns the estimated time from when the samples leave the players' buffers, which are
+	// counted by Player.UnplayedBufferSize, to when they are heard.
+	OutputLatency() time.Duration
 }
 
 type Player interface {
@@ -36,6 +57,69 @@ type Player interface {
 	io.Closer
 }
 
+// Device is an audio output device.
+type Device struct {
+	// ID is the identifier to select the device with SetOutputDevice.
+	ID string
+
+	// Name is the human readable name of the device.
+	Name string
+
+	// IsDefault reports whether the device is the system default device.
+	IsDefault bool
+}
+
+// DeviceSelector is implemented by a Context that can select its output device.
+type DeviceSelector interface {
+	// OutputDevices returns the available output devices.
+	OutputDevices() ([]Device, error)
+
+	// SetOutputDevice switches the output device to the device with the given ID.
+	// The empty ID represents the system default device.
+	//
+	// The players that are already playing are moved to the new device.
+	SetOutputDevice(id string) error
+}
+
+// Speaker positions for channel masks. The values are the same as WAVEFORMATEXTENSIBLE's dwChannelMask and
+// Core Audio's AudioChannelBitmap.
+const (
+	speakerFrontLeft    = 0x1
+	speakerFrontRight   = 0x2
+	speakerFrontCenter  = 0x4
+	speakerLowFrequency = 0x8
+	speakerBackLeft     = 0x10
+	speakerBackRight    = 0x20
+	speakerSideLeft     = 0x200
+	speakerSideRight    = 0x400
+)
+
+// channelMask returns the bit set of the speaker positions for the given number of channels.
+// The order of the channels in a sample is the order of the bits.
+func channelMask(channelNum int) uint32 {
+	switch channelNum {
+	case 1:
+		return speakerFrontCenter
+	case 2:
+		return speakerFrontLeft | speakerFrontRight
+	case 4:
+		return speakerFrontLeft | speakerFrontRight | speakerBackLeft | speakerBackRight
+	case 6:
+		return speakerFrontLeft | speakerFrontRight | speakerFrontCenter | speakerLowFrequency | speakerBackLeft | speakerBackRight
+	case 8:
+		return speakerFrontLeft | speakerFrontRight | speakerFrontCenter | speakerLowFrequency | speakerBackLeft | speakerBackRight | speakerSideLeft | speakerSideRight
+	}
+	return 0
+}
+
+// checkChannelNum returns an error if the given number of channels is not supported.
+func checkChannelNum(channelNum int) error {
+	if channelMask(channelNum) == 0 {
+		return fmt.Errorf("readerdriver: channelNum must be 1, 2, 4, 6 or 8 but was %d", channelNum)
+	}
+	return nil
+}
+
 type playerState int
 
 const (
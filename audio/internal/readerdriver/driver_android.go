@@ -15,6 +15,8 @@
 package readerdriver
 
 import (
+	"time"
+
 	"github.com/hajimehoshi/ebiten/v2/audio/internal/oboe"
 )
 
@@ -31,6 +33,9 @@ type context struct {
 }
 
 func NewContext(sampleRate int, channelNum int, bitDepthInBytes int) (Context, chan struct{}, error) {
+	if err := checkChannelNum(channelNum); err != nil {
+		return nil, nil, err
+	}
 	ready := make(chan struct{})
 	close(ready)
 
@@ -53,3 +58,7 @@ func (c *context) Suspend() error {
 func (c *context) Resume() error {
 	return oboe.Resume()
 }
+
+func (c *context) OutputLatency() time.Duration {
+	return time.Duration(oboe.OutputLatency() * float64(time.Second))
+}
@@ -21,6 +21,7 @@ package readerdriver
 // void ebiten_readerdriver_render(void* inUserData, AudioQueueRef inAQ, AudioQueueBufferRef inBuffer);
 //
 // void ebiten_readerdriver_setNotificationHandler();
+// void ebiten_readerdriver_setDeviceListener();
 import "C"
 
 import (
@@ -39,6 +40,12 @@ func IsAvailable() bool {
 type audioQueuePoolItem struct {
 	queue C.AudioQueueRef
 	bufs  []C.AudioQueueBufferRef
+
+	// deviceID is the UID of the device that the AudioQueue outputs to. The empty string means the default device.
+	deviceID string
+
+	// generation is the pool's generation when the AudioQueue was created.
+	generation int
 }
 
 const audioQueuePoolMaxItemNum = 32 // 32 is an arbitrary number.
@@ -47,7 +54,12 @@ type audioQueuePool struct {
 	c      *context
 	unused []audioQueuePoolItem
 	used   []audioQueuePoolItem
-	m      sync.Mutex
+
+	// generation is incremented when the AudioQueues in the pool must not be reused e.g. when the default device
+	// is changed.
+	generation int
+
+	m sync.Mutex
 }
 
 func (a *audioQueuePool) Prepare(context *context) error {
@@ -66,16 +78,34 @@ func (a *audioQueuePool) Get() (C.AudioQueueRef, []C.AudioQueueBufferRef, error)
 	a.m.Lock()
 	defer a.m.Unlock()
 
-	if len(a.unused) > 0 {
+	deviceID := a.c.currentDeviceID()
+
+	for len(a.unused) > 0 {
 		q := a.unused[0]
 		a.unused = a.unused[1:]
+		if q.generation != a.generation {
+			if err := disposeAudioQueue(q); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		// The output device might be changed after the AudioQueue was created.
+		if q.deviceID != deviceID {
+			if err := setAudioQueueDevice(q.queue, deviceID); err != nil {
+				return nil, nil, err
+			}
+			q.deviceID = deviceID
+		}
 		a.used = append(a.used, q)
 		return q.queue, q.bufs, nil
 	}
 
 	flags := C.kAudioFormatFlagIsPacked
-	if a.c.bitDepthInBytes != 1 {
+	switch a.c.bitDepthInBytes {
+	case 2:
 		flags |= C.kAudioFormatFlagIsSignedInteger
+	case 4:
+		flags |= C.kAudioFormatFlagIsFloat
 	}
 	desc := C.AudioStreamBasicDescription{
 		mSampleRate:       C.double(a.c.sampleRate),
@@ -100,6 +130,22 @@ func (a *audioQueuePool) Get() (C.AudioQueueRef, []C.AudioQueueBufferRef, error)
 		return nil, nil, fmt.Errorf("readerdriver: AudioQueueNewFormat with StreamFormat failed: %d", osstatus)
 	}
 
+	if err := setAudioQueueDevice(audioQueue, deviceID); err != nil {
+		return nil, nil, err
+	}
+
+	if a.c.channelNum > 2 {
+		// Specify the speaker positions explicitly. Otherwise the channels might be treated as discrete ones.
+		// AudioChannelBitmap's values are the same as the channel mask.
+		layout := C.AudioChannelLayout{
+			mChannelLayoutTag: C.kAudioChannelLayoutTag_UseChannelBitmap,
+			mChannelBitmap:    C.AudioChannelBitmap(channelMask(a.c.channelNum)),
+		}
+		if osstatus := C.AudioQueueSetProperty(audioQueue, C.kAudioQueueProperty_ChannelLayout, unsafe.Pointer(&layout), C.UInt32(unsafe.Sizeof(layout))); osstatus != C.noErr {
+			return nil, nil, fmt.Errorf("readerdriver: AudioQueueSetProperty with ChannelLayout failed: %d", osstatus)
+		}
+	}
+
 	size := a.c.oneBufferSize()
 	bufs := make([]C.AudioQueueBufferRef, 0, 2)
 	for len(bufs) < cap(bufs) {
@@ -112,8 +158,10 @@ func (a *audioQueuePool) Get() (C.AudioQueueRef, []C.AudioQueueBufferRef, error)
 	}
 
 	a.used = append(a.used, audioQueuePoolItem{
-		queue: audioQueue,
-		bufs:  bufs,
+		queue:      audioQueue,
+		bufs:       bufs,
+		deviceID:   deviceID,
+		generation: a.generation,
 	})
 
 	return audioQueue, bufs, nil
@@ -129,37 +177,63 @@ func (a *audioQueuePool) Put(audioQueue C.AudioQueueRef) error {
 		}
 
 		a.used = append(a.used[:i], a.used[i+1:]...)
-		if len(a.unused)+len(a.used) < audioQueuePoolMaxItemNum {
+		if len(a.unused)+len(a.used) < audioQueuePoolMaxItemNum && q.generation == a.generation {
 			a.unused = append(a.unused, q)
 			break
 		}
 
-		// As the pool is too big, remove the AudioQueue.
-		for _, b := range q.bufs {
-			if osstatus := C.AudioQueueFreeBuffer(q.queue, b); osstatus != C.noErr {
-				return fmt.Errorf("readerdriver: AudioQueueFreeBuffer failed: %d", osstatus)
-			}
-		}
-		if osstatus := C.AudioQueueDispose(q.queue, C.true); osstatus != C.noErr {
-			return fmt.Errorf("readerdriver: AudioQueueDispose failed: %d", osstatus)
+		// As the pool is too big or the AudioQueue is stale, remove the AudioQueue.
+		if err := disposeAudioQueue(q); err != nil {
+			return err
 		}
 		break
 	}
 	return nil
 }
 
+// Invalidate makes the pool not reuse the existing AudioQueues.
+func (a *audioQueuePool) Invalidate() {
+	a.m.Lock()
+	defer a.m.Unlock()
+	a.generation++
+}
+
+func disposeAudioQueue(q audioQueuePoolItem) error {
+	for _, b := range q.bufs {
+		if osstatus := C.AudioQueueFreeBuffer(q.queue, b); osstatus != C.noErr {
+			return fmt.Errorf("readerdriver: AudioQueueFreeBuffer failed: %d", osstatus)
+		}
+	}
+	if osstatus := C.AudioQueueDispose(q.queue, C.true); osstatus != C.noErr {
+		return fmt.Errorf("readerdriver: AudioQueueDispose failed: %d", osstatus)
+	}
+	return nil
+}
+
 type context struct {
 	sampleRate      int
 	channelNum      int
 	bitDepthInBytes int
 
 	audioQueuePool audioQueuePool
+
+	// deviceID is the UID of the output device. The empty string means the default device.
+	deviceID string
+	m        sync.Mutex
+
+	// devicesChanged is notified when the default device or the list of the devices is changed.
+	devicesChanged chan struct{}
 }
 
+var theContext *context
+
 // TOOD: Convert the error code correctly.
 // See https://stackoverflow.com/questions/2196869/how-do-you-convert-an-iphone-osstatus-code-to-something-useful
 
 func NewContext(sampleRate, channelNum, bitDepthInBytes int) (Context, chan struct{}, error) {
+	if err := checkChannelNum(channelNum); err != nil {
+		return nil, nil, err
+	}
 	ready := make(chan struct{})
 	close(ready)
 
@@ -172,9 +246,83 @@ func NewContext(sampleRate, channelNum, bitDepthInBytes int) (Context, chan stru
 		return nil, nil, err
 	}
 	C.ebiten_readerdriver_setNotificationHandler()
+
+	c.devicesChanged = make(chan struct{}, 1)
+	theContext = c
+	go c.followDevices()
+	C.ebiten_readerdriver_setDeviceListener()
+
 	return c, ready, nil
 }
 
+// followDevices moves the players to a new device when the default device is changed, e.g. when AirPods are
+// connected, or when the selected device is removed.
+func (c *context) followDevices() {
+	lastDefault, _ := defaultOutputDeviceID()
+	for range c.devicesChanged {
+		d, err := defaultOutputDeviceID()
+		if err != nil {
+			continue
+		}
+		defaultChanged := d != lastDefault
+		lastDefault = d
+
+		if id := c.currentDeviceID(); id != "" {
+			if checkOutputDevice(id) == nil {
+				continue
+			}
+			// The selected device was removed. Use the default device instead.
+			c.m.Lock()
+			if c.deviceID == id {
+				c.deviceID = ""
+			}
+			c.m.Unlock()
+		} else if !defaultChanged {
+			continue
+		}
+
+		// The AudioQueues in the pool might be bound to the previous device.
+		c.audioQueuePool.Invalidate()
+		thePlayers.reopen()
+	}
+}
+
+//export ebiten_readerdriver_onDevicesChanged
+func ebiten_readerdriver_onDevicesChanged() {
+	c := theContext
+	if c == nil {
+		return
+	}
+	// This is called on a Core Audio's thread. Handle the change on another goroutine.
+	select {
+	case c.devicesChanged <- struct{}{}:
+	default:
+	}
+}
+
+func (c *context) OutputDevices() ([]Device, error) {
+	return outputDevices()
+}
+
+func (c *context) SetOutputDevice(id string) error {
+	if err := checkOutputDevice(id); err != nil {
+		return err
+	}
+
+	c.m.Lock()
+	c.deviceID = id
+	c.m.Unlock()
+
+	thePlayers.reopen()
+	return nil
+}
+
+func (c *context) currentDeviceID() string {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.deviceID
+}
+
 func (c *context) Suspend() error {
 	return thePlayers.suspend()
 }
@@ -183,6 +331,11 @@ func (c *context) Resume() error {
 	return thePlayers.resume()
 }
 
+func (c *context) OutputLatency() time.Duration {
+	// Each player has its own AudioQueue, and the samples enqueued to it are counted in UnplayedBufferSize.
+	return 0
+}
+
 type player struct {
 	p *playerImpl
 }
@@ -243,6 +396,21 @@ func (p *players) remove(audioQueue C.AudioQueueRef) {
 	p.cond.Signal()
 }
 
+// reopen recreates the AudioQueues of all the players so that they output to the current device.
+func (p *players) reopen() {
+	p.cond.L.Lock()
+	players := make([]*playerImpl, 0, len(p.players))
+	for _, pl := range p.players {
+		players = append(players, pl)
+	}
+	p.cond.L.Unlock()
+
+	// reopen removes the player from p. Call this without the lock.
+	for _, pl := range players {
+		pl.reopen()
+	}
+}
+
 func (p *players) shouldWait() bool {
 	if len(p.players) == 0 {
 		return false
@@ -508,6 +676,33 @@ func (p *playerImpl) resetImpl() {
 	thePlayers.cond.Signal()
 }
 
+// reopen discards the current AudioQueue and gets another one for the context's current device.
+// The samples already enqueued to the previous AudioQueue are discarded.
+func (p *playerImpl) reopen() {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if p.err != nil {
+		return
+	}
+	if p.state == playerClosed {
+		return
+	}
+	if p.audioQueue == nil {
+		return
+	}
+
+	playing := p.state == playerPlay
+	if err := p.closeAudioQueue(); err != nil {
+		p.setErrorImpl(err)
+		return
+	}
+	p.state = playerPaused
+	if playing {
+		p.playImpl()
+	}
+}
+
 func (p *player) IsPlaying() bool {
 	return p.p.IsPlaying()
 }
@@ -551,7 +746,13 @@ func (p *player) UnplayedBufferSize() int {
 func (p *playerImpl) UnplayedBufferSize() int {
 	p.m.Lock()
 	defer p.m.Unlock()
-	return len(p.buf)
+
+	n := len(p.buf)
+	if p.audioQueue != nil {
+		// Count the buffers enqueued to the AudioQueue. The buffer being played is counted as a whole.
+		n += (2 - len(p.unqueuedBufs)) * p.context.oneBufferSize()
+	}
+	return n
 }
 
 func (p *player) Close() error {
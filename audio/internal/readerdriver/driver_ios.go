@@ -0,0 +1,46 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build ios
+// +build ios
+
+package readerdriver
+
+// #import <AudioToolbox/AudioToolbox.h>
+import "C"
+
+import (
+	"errors"
+)
+
+// On iOS, the output route is determined by AVAudioSession and the user.
+
+func outputDevices() ([]Device, error) {
+	return nil, errors.New("readerdriver: enumerating output devices is not supported on iOS")
+}
+
+func defaultOutputDeviceID() (string, error) {
+	return "", nil
+}
+
+func checkOutputDevice(id string) error {
+	if id != "" {
+		return errors.New("readerdriver: selecting an output device is not supported on iOS")
+	}
+	return nil
+}
+
+func setAudioQueueDevice(audioQueue C.AudioQueueRef, id string) error {
+	return nil
+}
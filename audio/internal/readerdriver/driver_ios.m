@@ -20,3 +20,8 @@ void ebiten_readerdriver_setNotificationHandler() {
   // https://stackoverflow.com/questions/24404463/ios-siri-not-available-does-not-return-avaudiosessioninterruptionoptionshouldre
   return;
 }
+
+void ebiten_readerdriver_setDeviceListener() {
+  // On iOS, AudioQueue follows the route changes by itself.
+  return;
+}
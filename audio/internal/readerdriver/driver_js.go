@@ -16,11 +16,14 @@ package readerdriver
 
 import (
 	"errors"
+	"fmt"
 	"io"
+	"math"
 	"reflect"
 	"runtime"
 	"sync"
 	"syscall/js"
+	"time"
 	"unsafe"
 
 	"github.com/hajimehoshi/ebiten/v2/audio/internal/go2cpp"
@@ -35,16 +38,31 @@ type context struct {
 	ready        bool
 	callbacks    map[string]js.Func
 
+	worklet       bool
+	workletLoaded chan struct{}
+
 	sampleRate      int
 	channelNum      int
 	bitDepthInBytes int
 }
 
 func NewContext(sampleRate int, channelNum int, bitDepthInBytes int) (Context, chan struct{}, error) {
+	if err := checkChannelNum(channelNum); err != nil {
+		return nil, nil, err
+	}
 	ready := make(chan struct{})
 	if js.Global().Get("go2cpp").Truthy() {
 		close(ready)
-		return &go2cppDriverWrapper{go2cpp.NewContext(sampleRate, channelNum, bitDepthInBytes)}, ready, nil
+		// go2cpp accepts only integer samples. Convert float32 samples to 16bit ones.
+		if bitDepthInBytes == 4 {
+			return &go2cppDriverWrapper{
+				c:       go2cpp.NewContext(sampleRate, channelNum, 2),
+				float32: true,
+			}, ready, nil
+		}
+		return &go2cppDriverWrapper{
+			c: go2cpp.NewContext(sampleRate, channelNum, bitDepthInBytes),
+		}, ready, nil
 	}
 
 	class := js.Global().Get("AudioContext")
@@ -64,6 +82,15 @@ func NewContext(sampleRate int, channelNum int, bitDepthInBytes int) (Context, c
 		bitDepthInBytes: bitDepthInBytes,
 	}
 
+	if channelNum > 2 {
+		// The destination has 2 channels by default. Use more channels if the device has them.
+		// Otherwise, the browser downmixes the channels.
+		dst := d.audioContext.Get("destination")
+		if max := dst.Get("maxChannelCount").Int(); max >= channelNum {
+			dst.Set("channelCount", channelNum)
+		}
+	}
+
 	setCallback := func(event string) js.Func {
 		var f js.Func
 		f = js.FuncOf(func(this js.Value, arguments []js.Value) interface{} {
@@ -87,6 +114,10 @@ func NewContext(sampleRate int, channelNum int, bitDepthInBytes int) (Context, c
 	setCallback("keyup")
 	setCallback("mouseup")
 
+	// Prefer AudioWorklet to AudioBufferSourceNode. AudioWorklet renders the samples on the audio thread, and
+	// then the audio is not interrupted even when the main thread is busy e.g. with GC or rendering.
+	d.loadAudioWorklet()
+
 	return d, ready, nil
 }
 
@@ -107,6 +138,10 @@ type player struct {
 }
 
 func (c *context) NewPlayer(src io.Reader) Player {
+	if c.isAudioWorkletAvailable() {
+		return newWorkletPlayer(c, src)
+	}
+
 	p := &player{
 		context: c,
 		src:     src,
@@ -129,6 +164,85 @@ func (c *context) Resume() error {
 	return nil
 }
 
+// await waits until the promise is settled.
+//
+// await must not be called on a JavaScript callback, or this blocks forever.
+func await(promise js.Value) (js.Value, error) {
+	ch := make(chan struct{})
+	var result js.Value
+	var err error
+
+	then := js.FuncOf(func(this js.Value, arguments []js.Value) interface{} {
+		if len(arguments) > 0 {
+			result = arguments[0]
+		}
+		close(ch)
+		return nil
+	})
+	defer then.Release()
+	catch := js.FuncOf(func(this js.Value, arguments []js.Value) interface{} {
+		err = fmt.Errorf("readerdriver: %s", arguments[0].Call("toString").String())
+		close(ch)
+		return nil
+	})
+	defer catch.Release()
+
+	promise.Call("then", then, catch)
+	<-ch
+	return result, err
+}
+
+func (c *context) OutputDevices() ([]Device, error) {
+	mediaDevices := js.Global().Get("navigator").Get("mediaDevices")
+	if !mediaDevices.Truthy() || !mediaDevices.Get("enumerateDevices").Truthy() {
+		return nil, errors.New("readerdriver: navigator.mediaDevices.enumerateDevices is not available")
+	}
+	infos, err := await(mediaDevices.Call("enumerateDevices"))
+	if err != nil {
+		return nil, err
+	}
+
+	var devices []Device
+	for i := 0; i < infos.Length(); i++ {
+		info := infos.Index(i)
+		if info.Get("kind").String() != "audiooutput" {
+			continue
+		}
+		// The labels are empty unless the user permits to access the media devices.
+		id := info.Get("deviceId").String()
+		devices = append(devices, Device{
+			ID:        id,
+			Name:      info.Get("label").String(),
+			IsDefault: id == "default",
+		})
+	}
+	return devices, nil
+}
+
+func (c *context) SetOutputDevice(id string) error {
+	if !c.audioContext.Get("setSinkId").Truthy() {
+		return errors.New("readerdriver: AudioContext.setSinkId is not available")
+	}
+	// All the players are connected to the AudioContext's destination. Switching the AudioContext's sink moves
+	// all of them.
+	if _, err := await(c.audioContext.Call("setSinkId", id)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c *context) OutputLatency() time.Duration {
+	// baseLatency and outputLatency are not available on some browsers.
+	var sec float64
+	if v := c.audioContext.Get("baseLatency"); v.Type() == js.TypeNumber {
+		sec += v.Float()
+	}
+	if v := c.audioContext.Get("outputLatency"); v.Type() == js.TypeNumber {
+		sec += v.Float()
+	}
+	return time.Duration(sec * float64(time.Second))
+}
+
 func (p *player) Play() {
 	p.cond.L.Lock()
 	defer p.cond.L.Unlock()
@@ -176,9 +290,9 @@ func (p *player) pauseImpl() {
 	}
 
 	// Change the state first. appendBuffer is called as an 'ended' callback.
-	var data [2][]float32
+	data := make([][]float32, p.context.channelNum)
 	for _, n := range p.bufferSourceNodes {
-		for ch := 0; ch < 2; ch++ {
+		for ch := range data {
 			t := n.Get("buffer").Call("getChannelData", ch)
 			data[ch] = append(data[ch], float32ArrayToFloat32Slice(t)...)
 		}
@@ -186,7 +300,7 @@ func (p *player) pauseImpl() {
 		n.Call("stop")
 		n.Call("disconnect")
 	}
-	p.buf = append(fromLR(data[0], data[1]), p.buf...)
+	p.buf = append(fromChannels(data, p.context.bitDepthInBytes), p.buf...)
 	p.state = playerPaused
 	p.bufferSourceNodes = p.bufferSourceNodes[:0]
 	p.nextPos = 0
@@ -240,17 +354,17 @@ func (p *player) appendBufferImpl(audioBuffer js.Value) {
 		bs = make([]byte, 4096)
 	}
 
-	l, r := toLR(bs)
-	tl, tr := float32SliceToTypedArray(l), float32SliceToTypedArray(r)
+	data := toChannels(bs, p.context.channelNum, p.context.bitDepthInBytes)
 
 	buf := p.context.audioContext.Call("createBuffer", p.context.channelNum, len(bs)/p.context.channelNum/p.context.bitDepthInBytes, p.context.sampleRate)
-	if buf.Get("copyToChannel").Truthy() {
-		buf.Call("copyToChannel", tl, 0, 0)
-		buf.Call("copyToChannel", tr, 1, 0)
-	} else {
-		// copyToChannel is not defined on Safari 11.
-		buf.Call("getChannelData", 0).Call("set", tl)
-		buf.Call("getChannelData", 1).Call("set", tr)
+	for ch, d := range data {
+		t := float32SliceToTypedArray(d)
+		if buf.Get("copyToChannel").Truthy() {
+			buf.Call("copyToChannel", t, ch, 0)
+		} else {
+			// copyToChannel is not defined on Safari 11.
+			buf.Call("getChannelData", ch).Call("set", t)
+		}
 	}
 
 	s := p.context.audioContext.Call("createBufferSource")
@@ -392,12 +506,77 @@ func (p *player) loop() {
 
 type go2cppDriverWrapper struct {
 	c *go2cpp.Context
+
+	// float32 reports whether the sources are float32 samples.
+	float32 bool
 }
 
 func (w *go2cppDriverWrapper) NewPlayer(r io.Reader) Player {
+	if w.float32 {
+		return &go2cppFloat32Player{
+			Player: w.c.NewPlayer(&int16Reader{r: r}),
+		}
+	}
 	return w.c.NewPlayer(r)
 }
 
+// go2cppFloat32Player is a go2cpp player for float32 sources.
+type go2cppFloat32Player struct {
+	Player
+}
+
+func (p *go2cppFloat32Player) UnplayedBufferSize() int {
+	// The buffer has 16bit samples converted from float32 samples.
+	return p.Player.UnplayedBufferSize() * 2
+}
+
+// int16Reader converts float32 samples from a source to 16bit samples.
+type int16Reader struct {
+	r   io.Reader
+	buf []byte
+
+	// pending is the number of bytes in the head of buf that are not converted yet.
+	pending int
+}
+
+func (r *int16Reader) Read(buf []byte) (int, error) {
+	size := len(buf) / 2 * 4
+	if size == 0 {
+		return 0, nil
+	}
+	if cap(r.buf) < size {
+		b := make([]byte, size)
+		copy(b, r.buf[:r.pending])
+		r.buf = b
+	}
+	r.buf = r.buf[:size]
+
+	n, err := r.r.Read(r.buf[r.pending:])
+	n += r.pending
+	m := n / 4
+	for i := 0; i < m; i++ {
+		v := float64(float32At(r.buf[4*i:])) * (1 << 15)
+		v = math.Max(math.MinInt16, math.Min(v, math.MaxInt16))
+		v16 := int16(v)
+		buf[2*i] = byte(v16)
+		buf[2*i+1] = byte(v16 >> 8)
+	}
+	r.pending = copy(r.buf, r.buf[m*4:n])
+	return m * 2, err
+}
+
+func float32At(b []byte) float32 {
+	return math.Float32frombits(uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24)
+}
+
+func putFloat32(b []byte, v float32) {
+	bits := math.Float32bits(v)
+	b[0] = byte(bits)
+	b[1] = byte(bits >> 8)
+	b[2] = byte(bits >> 16)
+	b[3] = byte(bits >> 24)
+}
+
 func (w *go2cppDriverWrapper) Suspend() error {
 	// Do nothing so far.
 	return nil
@@ -408,32 +587,57 @@ func (w *go2cppDriverWrapper) Resume() error {
 	return nil
 }
 
-func toLR(data []byte) ([]float32, []float32) {
+func (w *go2cppDriverWrapper) OutputLatency() time.Duration {
+	// go2cpp doesn't provide the latency so far.
+	return 0
+}
+
+// toChannels converts the interleaved samples to float32 samples for each channel.
+func toChannels(data []byte, channelNum int, bitDepthInBytes int) [][]float32 {
 	const max = 1 << 15
 
-	l := make([]float32, len(data)/4)
-	r := make([]float32, len(data)/4)
-	for i := 0; i < len(data)/4; i++ {
-		l[i] = float32(int16(data[4*i])|int16(data[4*i+1])<<8) / max
-		r[i] = float32(int16(data[4*i+2])|int16(data[4*i+3])<<8) / max
+	n := len(data) / channelNum / bitDepthInBytes
+	chs := make([][]float32, channelNum)
+	for ch := range chs {
+		chs[ch] = make([]float32, n)
+	}
+	for i := 0; i < n; i++ {
+		for ch := range chs {
+			idx := (i*channelNum + ch) * bitDepthInBytes
+			if bitDepthInBytes == 4 {
+				chs[ch][i] = float32At(data[idx:])
+			} else {
+				chs[ch][i] = float32(int16(data[idx])|int16(data[idx+1])<<8) / max
+			}
+		}
 	}
-	return l, r
+	return chs
 }
 
-func fromLR(l, r []float32) []byte {
+// fromChannels converts float32 samples for each channel to the interleaved samples.
+func fromChannels(chs [][]float32, bitDepthInBytes int) []byte {
 	const max = 1 << 15
 
-	if len(l) != len(r) {
-		panic("readerdriver: len(l) must equal to len(r) at fromLR")
-	}
-	bs := make([]byte, len(l)*4)
-	for i := range l {
-		lv := int16(l[i] * max)
-		bs[4*i] = byte(lv)
-		bs[4*i+1] = byte(lv >> 8)
-		rv := int16(r[i] * max)
-		bs[4*i+2] = byte(rv)
-		bs[4*i+3] = byte(rv >> 8)
+	n := len(chs[0])
+	for _, c := range chs {
+		if len(c) != n {
+			panic("readerdriver: all the channels must have the same length at fromChannels")
+		}
+	}
+
+	channelNum := len(chs)
+	bs := make([]byte, n*channelNum*bitDepthInBytes)
+	for i := 0; i < n; i++ {
+		for ch, c := range chs {
+			idx := (i*channelNum + ch) * bitDepthInBytes
+			if bitDepthInBytes == 4 {
+				putFloat32(bs[idx:], c[i])
+			} else {
+				v := int16(c[i] * max)
+				bs[idx] = byte(v)
+				bs[idx+1] = byte(v >> 8)
+			}
+		}
 	}
 	return bs
 }
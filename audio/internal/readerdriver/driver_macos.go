@@ -17,5 +17,153 @@
 
 package readerdriver
 
-// #cgo LDFLAGS: -framework AppKit
+// #cgo LDFLAGS: -framework AppKit -framework CoreAudio
+//
+// #import <AudioToolbox/AudioToolbox.h>
+// #import <CoreAudio/CoreAudio.h>
+// #include <stdlib.h>
+//
+// // ebiten_readerdriver_copyUTF8String copies the string into a C string. The returned string must be freed.
+// static char* ebiten_readerdriver_copyUTF8String(CFStringRef str) {
+//   CFIndex size = CFStringGetMaximumSizeForEncoding(CFStringGetLength(str), kCFStringEncodingUTF8) + 1;
+//   char* buf = malloc(size);
+//   if (!CFStringGetCString(str, buf, size, kCFStringEncodingUTF8)) {
+//     free(buf);
+//     return NULL;
+//   }
+//   return buf;
+// }
 import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// getPropertyData calls AudioObjectGetPropertyData for the given property in the global scope.
+func getPropertyData(id C.AudioObjectID, selector C.AudioObjectPropertySelector, data unsafe.Pointer, size C.UInt32) error {
+	addr := C.AudioObjectPropertyAddress{
+		mSelector: selector,
+		mScope:    C.kAudioObjectPropertyScopeGlobal,
+		mElement:  0, // kAudioObjectPropertyElementMain
+	}
+	if osstatus := C.AudioObjectGetPropertyData(id, &addr, 0, nil, &size, data); osstatus != C.noErr {
+		return fmt.Errorf("readerdriver: AudioObjectGetPropertyData failed: %d", osstatus)
+	}
+	return nil
+}
+
+// stringProperty returns the value of the given CFString property.
+func stringProperty(id C.AudioObjectID, selector C.AudioObjectPropertySelector) (string, error) {
+	var str C.CFStringRef
+	if err := getPropertyData(id, selector, unsafe.Pointer(&str), C.UInt32(unsafe.Sizeof(str))); err != nil {
+		return "", err
+	}
+	defer C.CFRelease(C.CFTypeRef(str))
+
+	cstr := C.ebiten_readerdriver_copyUTF8String(str)
+	if cstr == nil {
+		return "", fmt.Errorf("readerdriver: CFStringGetCString failed")
+	}
+	defer C.free(unsafe.Pointer(cstr))
+	return C.GoString(cstr), nil
+}
+
+// hasOutputStreams reports whether the device has output streams.
+func hasOutputStreams(id C.AudioObjectID) bool {
+	addr := C.AudioObjectPropertyAddress{
+		mSelector: C.kAudioDevicePropertyStreams,
+		mScope:    C.kAudioObjectPropertyScopeOutput,
+		mElement:  0, // kAudioObjectPropertyElementMain
+	}
+	var size C.UInt32
+	if osstatus := C.AudioObjectGetPropertyDataSize(id, &addr, 0, nil, &size); osstatus != C.noErr {
+		return false
+	}
+	return size > 0
+}
+
+func outputDevices() ([]Device, error) {
+	addr := C.AudioObjectPropertyAddress{
+		mSelector: C.kAudioHardwarePropertyDevices,
+		mScope:    C.kAudioObjectPropertyScopeGlobal,
+		mElement:  0, // kAudioObjectPropertyElementMain
+	}
+	var size C.UInt32
+	if osstatus := C.AudioObjectGetPropertyDataSize(C.kAudioObjectSystemObject, &addr, 0, nil, &size); osstatus != C.noErr {
+		return nil, fmt.Errorf("readerdriver: AudioObjectGetPropertyDataSize failed: %d", osstatus)
+	}
+	ids := make([]C.AudioObjectID, int(size)/int(unsafe.Sizeof(C.AudioObjectID(0))))
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	if err := getPropertyData(C.kAudioObjectSystemObject, C.kAudioHardwarePropertyDevices, unsafe.Pointer(&ids[0]), size); err != nil {
+		return nil, err
+	}
+
+	var defaultID C.AudioObjectID
+	if err := getPropertyData(C.kAudioObjectSystemObject, C.kAudioHardwarePropertyDefaultOutputDevice, unsafe.Pointer(&defaultID), C.UInt32(unsafe.Sizeof(defaultID))); err != nil {
+		return nil, err
+	}
+
+	var devices []Device
+	for _, id := range ids {
+		if !hasOutputStreams(id) {
+			continue
+		}
+		uid, err := stringProperty(id, C.kAudioDevicePropertyDeviceUID)
+		if err != nil {
+			return nil, err
+		}
+		name, err := stringProperty(id, C.kAudioObjectPropertyName)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, Device{
+			ID:        uid,
+			Name:      name,
+			IsDefault: id == defaultID,
+		})
+	}
+	return devices, nil
+}
+
+// defaultOutputDeviceID returns the UID of the current default output device.
+func defaultOutputDeviceID() (string, error) {
+	var id C.AudioObjectID
+	if err := getPropertyData(C.kAudioObjectSystemObject, C.kAudioHardwarePropertyDefaultOutputDevice, unsafe.Pointer(&id), C.UInt32(unsafe.Sizeof(id))); err != nil {
+		return "", err
+	}
+	return stringProperty(id, C.kAudioDevicePropertyDeviceUID)
+}
+
+func checkOutputDevice(id string) error {
+	if id == "" {
+		return nil
+	}
+	devices, err := outputDevices()
+	if err != nil {
+		return err
+	}
+	for _, d := range devices {
+		if d.ID == id {
+			return nil
+		}
+	}
+	return fmt.Errorf("readerdriver: invalid device ID: %q", id)
+}
+
+// setAudioQueueDevice sets the output device of the AudioQueue. The empty id means the default device.
+func setAudioQueueDevice(audioQueue C.AudioQueueRef, id string) error {
+	var uid C.CFStringRef
+	if id != "" {
+		cstr := C.CString(id)
+		defer C.free(unsafe.Pointer(cstr))
+		uid = C.CFStringCreateWithCString(C.kCFAllocatorDefault, cstr, C.kCFStringEncodingUTF8)
+		defer C.CFRelease(C.CFTypeRef(uid))
+	}
+	if osstatus := C.AudioQueueSetProperty(audioQueue, C.kAudioQueueProperty_CurrentDevice, unsafe.Pointer(&uid), C.UInt32(unsafe.Sizeof(uid))); osstatus != C.noErr {
+		return fmt.Errorf("readerdriver: AudioQueueSetProperty with CurrentDevice failed: %d", osstatus)
+	}
+	return nil
+}
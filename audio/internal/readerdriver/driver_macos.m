@@ -15,6 +15,7 @@
 // +build darwin,!ios
 
 #import <AppKit/AppKit.h>
+#import <CoreAudio/CoreAudio.h>
 
 #include "_cgo_export.h"
 
@@ -53,3 +54,26 @@ void ebiten_readerdriver_setNotificationHandler() {
              name:NSWorkspaceDidWakeNotification
            object:NULL];
 }
+
+static OSStatus ebiten_readerdriver_devicesListener(
+    AudioObjectID objectID, UInt32 numberAddresses,
+    const AudioObjectPropertyAddress *addresses, void *clientData) {
+  ebiten_readerdriver_onDevicesChanged();
+  return noErr;
+}
+
+// ebiten_readerdriver_setDeviceListener sets a listener for changes of the
+// default output device and the list of the devices.
+void ebiten_readerdriver_setDeviceListener() {
+  AudioObjectPropertyAddress defaultAddr = {
+      kAudioHardwarePropertyDefaultOutputDevice,
+      kAudioObjectPropertyScopeGlobal, kAudioObjectPropertyElementMaster};
+  AudioObjectAddPropertyListener(kAudioObjectSystemObject, &defaultAddr,
+                                 ebiten_readerdriver_devicesListener, NULL);
+
+  AudioObjectPropertyAddress devicesAddr = {kAudioHardwarePropertyDevices,
+                                            kAudioObjectPropertyScopeGlobal,
+                                            kAudioObjectPropertyElementMaster};
+  AudioObjectAddPropertyListener(kAudioObjectSystemObject, &devicesAddr,
+                                 ebiten_readerdriver_devicesListener, NULL);
+}
@@ -15,9 +15,13 @@
 package readerdriver
 
 import (
+	"fmt"
 	"io"
+	"math"
 	"runtime"
+	"strconv"
 	"sync"
+	"time"
 	"unsafe"
 
 	"golang.org/x/sys/windows"
@@ -73,9 +77,16 @@ type context struct {
 	sampleRate      int
 	channelNum      int
 	bitDepthInBytes int
+
+	// deviceID is the index of the output device, or waveMapper for the default device.
+	deviceID uint32
+	m        sync.Mutex
 }
 
 func NewContext(sampleRate, channelNum, bitDepthInBytes int) (Context, chan struct{}, error) {
+	if err := checkChannelNum(channelNum); err != nil {
+		return nil, nil, err
+	}
 	ready := make(chan struct{})
 	close(ready)
 
@@ -83,10 +94,134 @@ func NewContext(sampleRate, channelNum, bitDepthInBytes int) (Context, chan stru
 		sampleRate:      sampleRate,
 		channelNum:      channelNum,
 		bitDepthInBytes: bitDepthInBytes,
+		deviceID:        waveMapper,
 	}
+	go c.watchDefaultDevice()
 	return c, ready, nil
 }
 
+// defaultDeviceCheckInterval is the interval to check the default device.
+const defaultDeviceCheckInterval = time.Second
+
+// watchDefaultDevice moves the players to a new default device when the default device is changed, e.g. when a
+// headset is connected. A device opened with the wave mapper keeps outputting to the previous device otherwise.
+func (c *context) watchDefaultDevice() {
+	last, err := waveOutPreferredDevice()
+	if err != nil {
+		// The preferred device is not available e.g. on old Windows.
+		return
+	}
+	for range time.Tick(defaultDeviceCheckInterval) {
+		d, err := waveOutPreferredDevice()
+		if err != nil {
+			continue
+		}
+		if d == last {
+			continue
+		}
+		last = d
+		if c.currentDeviceID() != waveMapper {
+			continue
+		}
+		thePlayers.reopen()
+	}
+}
+
+func (c *context) OutputDevices() ([]Device, error) {
+	// The preferred device might not be available e.g. on old Windows.
+	preferred, err := waveOutPreferredDevice()
+	if err != nil {
+		preferred = waveMapper
+	}
+
+	n := waveOutGetNumDevs()
+	devices := make([]Device, 0, n)
+	for i := uint32(0); i < n; i++ {
+		caps, err := waveOutGetDevCaps(i)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, Device{
+			ID:        strconv.Itoa(int(i)),
+			Name:      windows.UTF16ToString(caps.szPname[:]),
+			IsDefault: i == preferred,
+		})
+	}
+	return devices, nil
+}
+
+func (c *context) SetOutputDevice(id string) error {
+	deviceID := uint32(waveMapper)
+	if id != "" {
+		n, err := strconv.ParseUint(id, 10, 32)
+		if err != nil || uint32(n) >= waveOutGetNumDevs() {
+			return fmt.Errorf("readerdriver: invalid device ID: %q", id)
+		}
+		deviceID = uint32(n)
+	}
+
+	c.m.Lock()
+	c.deviceID = deviceID
+	c.m.Unlock()
+
+	thePlayers.reopen()
+	return nil
+}
+
+func (c *context) currentDeviceID() uint32 {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.deviceID
+}
+
+// fallBackToDefaultDevice makes the context use the default device when the device deviceID is not available.
+func (c *context) fallBackToDefaultDevice(deviceID uint32) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if c.deviceID == deviceID {
+		c.deviceID = waveMapper
+	}
+}
+
+// waveFormat returns the format to open a device.
+func (c *context) waveFormat() *waveformatex {
+	numBlockAlign := c.channelNum * c.bitDepthInBytes
+	formatTag := uint16(waveFormatPCM)
+	if c.bitDepthInBytes == 4 {
+		formatTag = waveFormatIEEEFloat
+	}
+
+	if c.channelNum <= 2 {
+		return &waveformatex{
+			wFormatTag:      formatTag,
+			nChannels:       uint16(c.channelNum),
+			nSamplesPerSec:  uint32(c.sampleRate),
+			nAvgBytesPerSec: uint32(c.sampleRate * numBlockAlign),
+			wBitsPerSample:  uint16(c.bitDepthInBytes * 8),
+			nBlockAlign:     uint16(numBlockAlign),
+		}
+	}
+
+	// More than 2 channels require WAVEFORMATEXTENSIBLE to specify the speaker positions.
+	subFormat := ksdataformatSubtypePCM
+	if formatTag == waveFormatIEEEFloat {
+		subFormat = ksdataformatSubtypeIEEEFloat
+	}
+	f := &waveformatextensible{
+		wFormatTag:          waveFormatExtensible,
+		nChannels:           uint16(c.channelNum),
+		nSamplesPerSec:      uint32(c.sampleRate),
+		nAvgBytesPerSec:     uint32(c.sampleRate * numBlockAlign),
+		wBitsPerSample:      uint16(c.bitDepthInBytes * 8),
+		nBlockAlign:         uint16(numBlockAlign),
+		cbSize:              uint16(unsafe.Sizeof(waveformatextensible{}) - 18), // 18 is the size of WAVEFORMATEX.
+		wValidBitsPerSample: uint16(c.bitDepthInBytes * 8),
+		dwChannelMask:       channelMask(c.channelNum),
+		subFormat:           subFormat,
+	}
+	return (*waveformatex)(unsafe.Pointer(f))
+}
+
 func (c *context) Suspend() error {
 	return thePlayers.suspend()
 }
@@ -95,6 +230,12 @@ func (c *context) Resume() error {
 	return thePlayers.resume()
 }
 
+func (c *context) OutputLatency() time.Duration {
+	// Each player has its own waveOut device, and the samples written to its headers are counted in
+	// UnplayedBufferSize.
+	return 0
+}
+
 type players struct {
 	players  map[uintptr]*playerImpl
 	toResume map[*playerImpl]struct{}
@@ -159,6 +300,21 @@ func (p *players) resume() error {
 	return nil
 }
 
+// reopen reopens the devices of all the players so that they output to the current device.
+func (p *players) reopen() {
+	p.cond.L.Lock()
+	players := make([]*playerImpl, 0, len(p.players))
+	for _, pl := range p.players {
+		players = append(players, pl)
+	}
+	p.cond.L.Unlock()
+
+	// reopen removes the player from p. Call this without the lock.
+	for _, pl := range players {
+		pl.reopen()
+	}
+}
+
 func (p *players) shouldWait() bool {
 	if len(p.players) == 0 {
 		return false
@@ -266,18 +422,16 @@ func (p *playerImpl) playImpl() {
 	}
 
 	if p.waveOut == 0 {
-		numBlockAlign := p.context.channelNum * p.context.bitDepthInBytes
-		f := &waveformatex{
-			wFormatTag:      waveFormatPCM,
-			nChannels:       uint16(p.context.channelNum),
-			nSamplesPerSec:  uint32(p.context.sampleRate),
-			nAvgBytesPerSec: uint32(p.context.sampleRate * numBlockAlign),
-			wBitsPerSample:  uint16(p.context.bitDepthInBytes * 8),
-			nBlockAlign:     uint16(numBlockAlign),
-		}
+		f := p.context.waveFormat()
 
 		// TOOD: What about using an event instead of a callback? PortAudio and other libraries do that.
-		w, err := waveOutOpen(f, waveOutOpenCallback)
+		deviceID := p.context.currentDeviceID()
+		w, err := waveOutOpen(deviceID, f, waveOutOpenCallback)
+		if e, ok := err.(*winmmError); ok && e.mmresult == mmsyserrBaddeviceid && deviceID != waveMapper {
+			// The selected device was removed. Use the default device instead.
+			p.context.fallBackToDefaultDevice(deviceID)
+			w, err = waveOutOpen(waveMapper, f, waveOutOpenCallback)
+		}
 		const elementNotFound = 1168
 		if e, ok := err.(*winmmError); ok && e.errno == elementNotFound {
 			// TODO: No device was found. Return the dummy device (hajimehoshi/oto#77).
@@ -322,6 +476,48 @@ func (p *playerImpl) playImpl() {
 	// Switching goroutines is very inefficient on Windows. Avoid a dedicated goroutine for a player.
 }
 
+// reopen closes the device and opens the context's current device.
+// The samples already queued to the previous device are discarded.
+func (p *playerImpl) reopen() {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if p.err != nil {
+		return
+	}
+	if p.state == playerClosed {
+		return
+	}
+	if p.waveOut == 0 {
+		return
+	}
+
+	playing := p.state == playerPlay
+
+	// Ignore the errors at closing the previous device, as the device might be already removed.
+	// waveOutReset never returns when there is no queued header.
+	if p.queuedHeadersNum() > 0 {
+		_ = waveOutReset(p.waveOut)
+	}
+	for _, h := range p.headers {
+		_ = h.Close()
+	}
+	p.headers = p.headers[:0]
+	_ = waveOutClose(p.waveOut)
+
+	// This player's lock might block thePlayer's lock. Unlock this first.
+	w := p.waveOut
+	p.m.Unlock()
+	thePlayers.remove(w)
+	p.m.Lock()
+
+	p.waveOut = 0
+	p.state = playerPaused
+	if playing {
+		p.playImpl()
+	}
+}
+
 func (p *playerImpl) queuedHeadersNum() int {
 	var c int
 	for _, h := range p.headers {
@@ -462,7 +658,9 @@ func (p *player) UnplayedBufferSize() int {
 func (p *playerImpl) UnplayedBufferSize() int {
 	p.m.Lock()
 	defer p.m.Unlock()
-	return len(p.buf)
+
+	// Count the headers written to the device. The header being played is counted as a whole.
+	return len(p.buf) + p.queuedHeadersNum()*headerBufferSize
 }
 
 func (p *player) Close() error {
@@ -543,7 +741,9 @@ func (p *playerImpl) readAndWriteBufferImpl() {
 			continue
 		}
 
-		n := headerBufferSize
+		// Align the size with the samples, or a sample might be split into two headers.
+		bytesPerSample := p.context.channelNum * p.context.bitDepthInBytes
+		n := headerBufferSize / bytesPerSample * bytesPerSample
 		if n > len(p.buf) {
 			n = len(p.buf)
 		}
@@ -586,6 +786,15 @@ func (p *playerImpl) readAndWriteBufferImpl() {
 					buf[2*i] = byte(x)
 					buf[2*i+1] = byte(x >> 8)
 				}
+			case 4:
+				for i := 0; i < n/4; i++ {
+					bits := uint32(buf[4*i]) | uint32(buf[4*i+1])<<8 | uint32(buf[4*i+2])<<16 | uint32(buf[4*i+3])<<24
+					bits = math.Float32bits(math.Float32frombits(bits) * float32(p.volume))
+					buf[4*i] = byte(bits)
+					buf[4*i+1] = byte(bits >> 8)
+					buf[4*i+2] = byte(bits >> 16)
+					buf[4*i+3] = byte(bits >> 24)
+				}
 			}
 		}
 
@@ -596,8 +805,11 @@ func (p *playerImpl) readAndWriteBufferImpl() {
 				switch {
 				case werr.mmresult == mmsyserrNomem:
 					continue
-				case werr.errno == errorNotFound:
-					// TODO: Retry later.
+				case werr.errno == errorNotFound || werr.mmresult == mmsyserrNodriver:
+					// The device was removed. Reopen the device asynchronously, as this player's lock and
+					// thePlayers' lock are held here.
+					go p.reopen()
+					return
 				}
 			}
 			p.setErrorImpl(err)
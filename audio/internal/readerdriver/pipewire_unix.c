@@ -0,0 +1,758 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build (aix || dragonfly || freebsd || hurd || illumos || linux || netbsd || openbsd || solaris) && !android
+// +build aix dragonfly freebsd hurd illumos linux netbsd openbsd solaris
+// +build !android
+
+// This file implements a PipeWire client. libpipewire is loaded at runtime so
+// that neither building nor running applications requires PipeWire. The
+// declarations below are the subset of the stable ABI of libpipewire-0.3 that
+// this client uses.
+
+#include <dlfcn.h>
+#include <stdbool.h>
+#include <stddef.h>
+#include <stdint.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+
+#include "_cgo_export.h"
+
+#define PW_ID_CORE 0
+#define PW_ID_ANY 0xffffffff
+
+#define PW_TYPE_INTERFACE_Node "PipeWire:Interface:Node"
+#define PW_TYPE_INTERFACE_Registry "PipeWire:Interface:Registry"
+#define PW_TYPE_INTERFACE_Metadata "PipeWire:Interface:Metadata"
+#define PW_VERSION_REGISTRY 3
+#define PW_VERSION_METADATA 3
+
+#define SPA_DIRECTION_OUTPUT 1
+
+#define PW_STREAM_FLAG_AUTOCONNECT (1 << 0)
+#define PW_STREAM_FLAG_INACTIVE (1 << 1)
+#define PW_STREAM_FLAG_MAP_BUFFERS (1 << 2)
+
+enum pw_stream_state {
+  PW_STREAM_STATE_ERROR = -1,
+  PW_STREAM_STATE_UNCONNECTED = 0,
+  PW_STREAM_STATE_CONNECTING = 1,
+  PW_STREAM_STATE_PAUSED = 2,
+  PW_STREAM_STATE_STREAMING = 3,
+};
+
+// SPA types to build an EnumFormat parameter.
+#define SPA_TYPE_Id 3
+#define SPA_TYPE_Int 4
+#define SPA_TYPE_Array 13
+#define SPA_TYPE_Object 15
+#define SPA_TYPE_OBJECT_Format 0x40003
+#define SPA_PARAM_EnumFormat 3
+#define SPA_FORMAT_mediaType 1
+#define SPA_FORMAT_mediaSubtype 2
+#define SPA_FORMAT_AUDIO_format 0x10001
+#define SPA_FORMAT_AUDIO_rate 0x10003
+#define SPA_FORMAT_AUDIO_channels 0x10004
+#define SPA_FORMAT_AUDIO_position 0x10005
+#define SPA_MEDIA_TYPE_audio 1
+#define SPA_MEDIA_SUBTYPE_raw 1
+#define SPA_AUDIO_FORMAT_F32_LE 0x11b
+
+enum spa_audio_channel {
+  SPA_AUDIO_CHANNEL_MONO = 2,
+  SPA_AUDIO_CHANNEL_FL = 3,
+  SPA_AUDIO_CHANNEL_FR = 4,
+  SPA_AUDIO_CHANNEL_FC = 5,
+  SPA_AUDIO_CHANNEL_LFE = 6,
+  SPA_AUDIO_CHANNEL_SL = 7,
+  SPA_AUDIO_CHANNEL_SR = 8,
+  SPA_AUDIO_CHANNEL_RL = 12,
+  SPA_AUDIO_CHANNEL_RR = 13,
+};
+
+struct pw_thread_loop;
+struct pw_loop;
+struct pw_context;
+struct pw_core;
+struct pw_properties;
+struct pw_stream;
+struct pw_registry;
+struct pw_metadata;
+struct pw_proxy;
+struct spa_pod;
+
+struct spa_list {
+  struct spa_list *next;
+  struct spa_list *prev;
+};
+
+struct spa_callbacks {
+  const void *funcs;
+  void *data;
+};
+
+struct spa_interface {
+  const char *type;
+  uint32_t version;
+  struct spa_callbacks cb;
+};
+
+struct spa_hook {
+  struct spa_list link;
+  struct spa_callbacks cb;
+  void (*removed)(struct spa_hook *hook);
+  void *priv;
+};
+
+struct spa_dict_item {
+  const char *key;
+  const char *value;
+};
+
+struct spa_dict {
+  uint32_t flags;
+  uint32_t n_items;
+  const struct spa_dict_item *items;
+};
+
+struct spa_fraction {
+  uint32_t num;
+  uint32_t denom;
+};
+
+struct spa_chunk {
+  uint32_t offset;
+  uint32_t size;
+  int32_t stride;
+  int32_t flags;
+};
+
+struct spa_data {
+  uint32_t type;
+  uint32_t flags;
+  int64_t fd;
+  uint32_t mapoffset;
+  uint32_t maxsize;
+  void *data;
+  struct spa_chunk *chunk;
+};
+
+struct spa_buffer {
+  uint32_t n_metas;
+  uint32_t n_datas;
+  void *metas;
+  struct spa_data *datas;
+};
+
+struct pw_buffer {
+  struct spa_buffer *buffer;
+  void *user_data;
+  uint64_t size;
+  // requested is available as of PipeWire 0.3.49.
+  uint64_t requested;
+};
+
+struct pw_time {
+  int64_t now;
+  struct spa_fraction rate;
+  uint64_t ticks;
+  int64_t delay;
+  uint64_t queued;
+};
+
+struct pw_stream_events {
+  uint32_t version;
+  void (*destroy)(void *data);
+  void (*state_changed)(void *data, enum pw_stream_state old,
+                        enum pw_stream_state state, const char *error);
+  void (*control_info)(void *data, uint32_t id, const void *control);
+  void (*io_changed)(void *data, uint32_t id, void *area, uint32_t size);
+  void (*param_changed)(void *data, uint32_t id, const struct spa_pod *param);
+  void (*add_buffer)(void *data, struct pw_buffer *buffer);
+  void (*remove_buffer)(void *data, struct pw_buffer *buffer);
+  void (*process)(void *data);
+  void (*drained)(void *data);
+};
+
+struct pw_core_events {
+  uint32_t version;
+  void (*info)(void *data, const void *info);
+  void (*done)(void *data, uint32_t id, int seq);
+  void (*ping)(void *data, uint32_t id, int seq);
+  void (*error)(void *data, uint32_t id, int seq, int res,
+                const char *message);
+};
+
+struct pw_core_methods {
+  uint32_t version;
+  int (*add_listener)(void *object, struct spa_hook *listener,
+                      const struct pw_core_events *events, void *data);
+  int (*hello)(void *object, uint32_t version);
+  int (*sync)(void *object, uint32_t id, int seq);
+  int (*pong)(void *object, uint32_t id, int seq);
+  int (*error)(void *object, uint32_t id, int seq, int res,
+               const char *message);
+  struct pw_registry *(*get_registry)(void *object, uint32_t version,
+                                      size_t user_data_size);
+};
+
+struct pw_registry_events {
+  uint32_t version;
+  void (*global)(void *data, uint32_t id, uint32_t permissions,
+                 const char *type, uint32_t version,
+                 const struct spa_dict *props);
+  void (*global_remove)(void *data, uint32_t id);
+};
+
+struct pw_registry_methods {
+  uint32_t version;
+  int (*add_listener)(void *object, struct spa_hook *listener,
+                      const struct pw_registry_events *events, void *data);
+  void *(*bind)(void *object, uint32_t id, const char *type, uint32_t version,
+                size_t user_data_size);
+};
+
+struct pw_metadata_events {
+  uint32_t version;
+  int (*property)(void *data, uint32_t subject, const char *key,
+                  const char *type, const char *value);
+};
+
+struct pw_metadata_methods {
+  uint32_t version;
+  int (*add_listener)(void *object, struct spa_hook *listener,
+                      const struct pw_metadata_events *events, void *data);
+};
+
+// The functions of libpipewire.
+static struct {
+  void (*init)(int *argc, char **argv[]);
+  const char *(*get_library_version)(void);
+  struct pw_thread_loop *(*thread_loop_new)(const char *name,
+                                            const struct spa_dict *props);
+  void (*thread_loop_destroy)(struct pw_thread_loop *loop);
+  int (*thread_loop_start)(struct pw_thread_loop *loop);
+  void (*thread_loop_stop)(struct pw_thread_loop *loop);
+  void (*thread_loop_lock)(struct pw_thread_loop *loop);
+  void (*thread_loop_unlock)(struct pw_thread_loop *loop);
+  void (*thread_loop_wait)(struct pw_thread_loop *loop);
+  int (*thread_loop_timed_wait)(struct pw_thread_loop *loop, int seconds);
+  void (*thread_loop_signal)(struct pw_thread_loop *loop, bool wait_for_accept);
+  struct pw_loop *(*thread_loop_get_loop)(struct pw_thread_loop *loop);
+  struct pw_context *(*context_new)(struct pw_loop *main_loop,
+                                    struct pw_properties *props,
+                                    size_t user_data_size);
+  void (*context_destroy)(struct pw_context *context);
+  struct pw_core *(*context_connect)(struct pw_context *context,
+                                     struct pw_properties *properties,
+                                     size_t user_data_size);
+  int (*core_disconnect)(struct pw_core *core);
+  struct pw_properties *(*properties_new)(const char *key, ...);
+  struct pw_stream *(*stream_new)(struct pw_core *core, const char *name,
+                                  struct pw_properties *props);
+  void (*stream_destroy)(struct pw_stream *stream);
+  void (*stream_add_listener)(struct pw_stream *stream,
+                              struct spa_hook *listener,
+                              const struct pw_stream_events *events,
+                              void *data);
+  int (*stream_connect)(struct pw_stream *stream, int direction,
+                        uint32_t target_id, int flags,
+                        const struct spa_pod **params, uint32_t n_params);
+  int (*stream_disconnect)(struct pw_stream *stream);
+  int (*stream_update_properties)(struct pw_stream *stream,
+                                  const struct spa_dict *dict);
+  enum pw_stream_state (*stream_get_state)(struct pw_stream *stream,
+                                           const char **error);
+  struct pw_buffer *(*stream_dequeue_buffer)(struct pw_stream *stream);
+  int (*stream_queue_buffer)(struct pw_stream *stream,
+                             struct pw_buffer *buffer);
+  int (*stream_set_active)(struct pw_stream *stream, bool active);
+  int (*stream_get_time)(struct pw_stream *stream, struct pw_time *time);
+  // stream_get_time_n is available as of PipeWire 0.3.50.
+  int (*stream_get_time_n)(struct pw_stream *stream, struct pw_time *time,
+                           size_t size);
+} pw;
+
+static struct pw_thread_loop *threadLoop;
+static struct pw_context *pwContext;
+static struct pw_core *core;
+static struct pw_registry *registry;
+static struct pw_metadata *metadata;
+static struct pw_stream *stream;
+
+static struct spa_hook coreListener;
+static struct spa_hook registryListener;
+static struct spa_hook metadataListener;
+static struct spa_hook streamListener;
+
+static int sampleRate;
+static int channelNum;
+static int bufferFrames;
+static bool active = true;
+static bool hasRequested;
+static int syncSeq;
+static bool synced;
+
+static bool loadLibrary(void) {
+  void *lib = dlopen("libpipewire-0.3.so.0", RTLD_NOW | RTLD_LOCAL);
+  if (!lib) {
+    return false;
+  }
+
+#define LOAD(field, name)                                                      \
+  do {                                                                         \
+    *(void **)(&pw.field) = dlsym(lib, name);                                  \
+    if (!pw.field) {                                                           \
+      return false;                                                            \
+    }                                                                          \
+  } while (0)
+
+  LOAD(init, "pw_init");
+  LOAD(get_library_version, "pw_get_library_version");
+  LOAD(thread_loop_new, "pw_thread_loop_new");
+  LOAD(thread_loop_destroy, "pw_thread_loop_destroy");
+  LOAD(thread_loop_start, "pw_thread_loop_start");
+  LOAD(thread_loop_stop, "pw_thread_loop_stop");
+  LOAD(thread_loop_lock, "pw_thread_loop_lock");
+  LOAD(thread_loop_unlock, "pw_thread_loop_unlock");
+  LOAD(thread_loop_wait, "pw_thread_loop_wait");
+  LOAD(thread_loop_timed_wait, "pw_thread_loop_timed_wait");
+  LOAD(thread_loop_signal, "pw_thread_loop_signal");
+  LOAD(thread_loop_get_loop, "pw_thread_loop_get_loop");
+  LOAD(context_new, "pw_context_new");
+  LOAD(context_destroy, "pw_context_destroy");
+  LOAD(context_connect, "pw_context_connect");
+  LOAD(core_disconnect, "pw_core_disconnect");
+  LOAD(properties_new, "pw_properties_new");
+  LOAD(stream_new, "pw_stream_new");
+  LOAD(stream_destroy, "pw_stream_destroy");
+  LOAD(stream_add_listener, "pw_stream_add_listener");
+  LOAD(stream_connect, "pw_stream_connect");
+  LOAD(stream_disconnect, "pw_stream_disconnect");
+  LOAD(stream_update_properties, "pw_stream_update_properties");
+  LOAD(stream_get_state, "pw_stream_get_state");
+  LOAD(stream_dequeue_buffer, "pw_stream_dequeue_buffer");
+  LOAD(stream_queue_buffer, "pw_stream_queue_buffer");
+  LOAD(stream_set_active, "pw_stream_set_active");
+  LOAD(stream_get_time, "pw_stream_get_time");
+
+#undef LOAD
+
+  *(void **)(&pw.stream_get_time_n) = dlsym(lib, "pw_stream_get_time_n");
+  return true;
+}
+
+// isVersionAtLeast reports whether the loaded libpipewire's version is at
+// least the given version.
+static bool isVersionAtLeast(int major, int minor, int micro) {
+  int v[3] = {0, 0, 0};
+  if (sscanf(pw.get_library_version(), "%d.%d.%d", &v[0], &v[1], &v[2]) < 2) {
+    return false;
+  }
+  if (v[0] != major) {
+    return v[0] > major;
+  }
+  if (v[1] != minor) {
+    return v[1] > minor;
+  }
+  return v[2] >= micro;
+}
+
+static const char *lookup(const struct spa_dict *dict, const char *key) {
+  if (!dict) {
+    return NULL;
+  }
+  for (uint32_t i = 0; i < dict->n_items; i++) {
+    if (!strcmp(dict->items[i].key, key)) {
+      return dict->items[i].value;
+    }
+  }
+  return NULL;
+}
+
+static const void *methods(void *object) {
+  return ((struct spa_interface *)object)->cb.funcs;
+}
+
+static void *methodsData(void *object) {
+  return ((struct spa_interface *)object)->cb.data;
+}
+
+// buildFormat builds an EnumFormat parameter for 32bit float samples into buf.
+static const struct spa_pod *buildFormat(uint32_t *buf) {
+  uint32_t *p = buf + 2;
+  *p++ = SPA_TYPE_OBJECT_Format;
+  *p++ = SPA_PARAM_EnumFormat;
+
+  // Each property is a key, flags and a value. A value is padded to 8 bytes.
+  uint32_t props[][3] = {
+      {SPA_FORMAT_mediaType, SPA_TYPE_Id, SPA_MEDIA_TYPE_audio},
+      {SPA_FORMAT_mediaSubtype, SPA_TYPE_Id, SPA_MEDIA_SUBTYPE_raw},
+      {SPA_FORMAT_AUDIO_format, SPA_TYPE_Id, SPA_AUDIO_FORMAT_F32_LE},
+      {SPA_FORMAT_AUDIO_rate, SPA_TYPE_Int, sampleRate},
+      {SPA_FORMAT_AUDIO_channels, SPA_TYPE_Int, channelNum},
+  };
+  for (size_t i = 0; i < sizeof(props) / sizeof(props[0]); i++) {
+    *p++ = props[i][0];
+    *p++ = 0;
+    *p++ = 4;
+    *p++ = props[i][1];
+    *p++ = props[i][2];
+    *p++ = 0;
+  }
+
+  // The order is the same as WAVE files.
+  uint32_t positions[8];
+  switch (channelNum) {
+  case 1:
+    positions[0] = SPA_AUDIO_CHANNEL_MONO;
+    break;
+  case 2:
+  case 4:
+  case 6:
+  case 8: {
+    const uint32_t p8[] = {SPA_AUDIO_CHANNEL_FL,  SPA_AUDIO_CHANNEL_FR,
+                           SPA_AUDIO_CHANNEL_FC,  SPA_AUDIO_CHANNEL_LFE,
+                           SPA_AUDIO_CHANNEL_RL,  SPA_AUDIO_CHANNEL_RR,
+                           SPA_AUDIO_CHANNEL_SL,  SPA_AUDIO_CHANNEL_SR};
+    const uint32_t p4[] = {SPA_AUDIO_CHANNEL_FL, SPA_AUDIO_CHANNEL_FR,
+                           SPA_AUDIO_CHANNEL_RL, SPA_AUDIO_CHANNEL_RR};
+    memcpy(positions, channelNum == 4 ? p4 : p8,
+           channelNum * sizeof(uint32_t));
+    break;
+  }
+  }
+  *p++ = SPA_FORMAT_AUDIO_position;
+  *p++ = 0;
+  *p++ = 8 + 4 * channelNum;
+  *p++ = SPA_TYPE_Array;
+  *p++ = 4;
+  *p++ = SPA_TYPE_Id;
+  for (int i = 0; i < channelNum; i++) {
+    *p++ = positions[i];
+  }
+  if (channelNum % 2) {
+    *p++ = 0;
+  }
+
+  buf[0] = (p - buf - 2) * sizeof(uint32_t);
+  buf[1] = SPA_TYPE_Object;
+  return (const struct spa_pod *)buf;
+}
+
+static void onCoreDone(void *data, uint32_t id, int seq) {
+  if (id == PW_ID_CORE && seq == syncSeq) {
+    synced = true;
+    pw.thread_loop_signal(threadLoop, false);
+  }
+}
+
+static const struct pw_core_events coreEvents = {
+    .version = 0,
+    .done = onCoreDone,
+};
+
+static int onMetadataProperty(void *data, uint32_t subject, const char *key,
+                              const char *type, const char *value) {
+  if (subject == PW_ID_CORE && (!key || !strcmp(key, "default.audio.sink"))) {
+    ebiten_readerdriver_pipeWireSetDefaultSink((char *)value);
+  }
+  return 0;
+}
+
+static const struct pw_metadata_events metadataEvents = {
+    .version = 0,
+    .property = onMetadataProperty,
+};
+
+static void onRegistryGlobal(void *data, uint32_t id, uint32_t permissions,
+                             const char *type, uint32_t version,
+                             const struct spa_dict *props) {
+  if (!strcmp(type, PW_TYPE_INTERFACE_Node)) {
+    const char *class = lookup(props, "media.class");
+    if (!class || strcmp(class, "Audio/Sink")) {
+      return;
+    }
+    const char *name = lookup(props, "node.name");
+    if (!name) {
+      return;
+    }
+    const char *description = lookup(props, "node.description");
+    if (!description) {
+      description = lookup(props, "node.nick");
+    }
+    if (!description) {
+      description = name;
+    }
+    ebiten_readerdriver_pipeWireAddSink(id, (char *)name, (char *)description);
+    return;
+  }
+
+  if (!strcmp(type, PW_TYPE_INTERFACE_Metadata)) {
+    const char *name = lookup(props, "metadata.name");
+    if (metadata || !name || strcmp(name, "default")) {
+      return;
+    }
+    const struct pw_registry_methods *m = methods(registry);
+    metadata = m->bind(methodsData(registry), id, PW_TYPE_INTERFACE_Metadata,
+                       PW_VERSION_METADATA, 0);
+    if (!metadata) {
+      return;
+    }
+    const struct pw_metadata_methods *mm = methods(metadata);
+    mm->add_listener(methodsData(metadata), &metadataListener, &metadataEvents,
+                     NULL);
+  }
+}
+
+static void onRegistryGlobalRemove(void *data, uint32_t id) {
+  ebiten_readerdriver_pipeWireRemoveSink(id);
+}
+
+static const struct pw_registry_events registryEvents = {
+    .version = 0,
+    .global = onRegistryGlobal,
+    .global_remove = onRegistryGlobalRemove,
+};
+
+static void onStreamStateChanged(void *data, enum pw_stream_state old,
+                                 enum pw_stream_state state,
+                                 const char *error) {
+  pw.thread_loop_signal(threadLoop, false);
+}
+
+static void onStreamProcess(void *data) {
+  struct pw_buffer *b = pw.stream_dequeue_buffer(stream);
+  if (!b) {
+    return;
+  }
+  struct spa_data *d = &b->buffer->datas[0];
+  if (!d->data) {
+    pw.stream_queue_buffer(stream, b);
+    return;
+  }
+
+  uint32_t stride = sizeof(float) * channelNum;
+  uint32_t frames = d->maxsize / stride;
+  // Without the requested size, fill the target size of the buffer. The graph's
+  // quantum doesn't exceed it as the stream's node.latency requests it.
+  uint32_t limit = bufferFrames;
+  if (hasRequested && b->requested) {
+    limit = b->requested;
+  }
+  if (frames > limit) {
+    frames = limit;
+  }
+
+  ebiten_readerdriver_pipeWireProcess((float *)d->data, frames * channelNum);
+
+  d->chunk->offset = 0;
+  d->chunk->stride = stride;
+  d->chunk->size = frames * stride;
+  pw.stream_queue_buffer(stream, b);
+}
+
+static const struct pw_stream_events streamEvents = {
+    .version = 0,
+    .state_changed = onStreamStateChanged,
+    .process = onStreamProcess,
+};
+
+// connectStream connects the stream to the given target and waits until the
+// stream is connected. connectStream must be called with the loop locked.
+static int connectStream(uint32_t targetID) {
+  uint32_t buf[64];
+  const struct spa_pod *params[1] = {buildFormat(buf)};
+  int flags = PW_STREAM_FLAG_AUTOCONNECT | PW_STREAM_FLAG_MAP_BUFFERS;
+  if (!active) {
+    flags |= PW_STREAM_FLAG_INACTIVE;
+  }
+  if (pw.stream_connect(stream, SPA_DIRECTION_OUTPUT, targetID, flags, params,
+                        1) < 0) {
+    return -1;
+  }
+
+  for (;;) {
+    enum pw_stream_state state = pw.stream_get_state(stream, NULL);
+    if (state == PW_STREAM_STATE_ERROR) {
+      return -1;
+    }
+    if (state == PW_STREAM_STATE_PAUSED || state == PW_STREAM_STATE_STREAMING) {
+      return 0;
+    }
+    // When no sink is available, the stream waits for a sink in the connecting
+    // state. Don't block forever in this case.
+    if (pw.thread_loop_timed_wait(threadLoop, 1) != 0) {
+      return 0;
+    }
+  }
+}
+
+static void closeAll(void) {
+  if (threadLoop) {
+    pw.thread_loop_stop(threadLoop);
+  }
+  if (stream) {
+    pw.stream_destroy(stream);
+    stream = NULL;
+  }
+  if (core) {
+    pw.core_disconnect(core);
+    core = NULL;
+    registry = NULL;
+    metadata = NULL;
+  }
+  if (pwContext) {
+    pw.context_destroy(pwContext);
+    pwContext = NULL;
+  }
+  if (threadLoop) {
+    pw.thread_loop_destroy(threadLoop);
+    threadLoop = NULL;
+  }
+}
+
+const char *ebiten_readerdriver_pipeWireOpen(int aSampleRate, int aChannelNum,
+                                             int aBufferFrames) {
+  if (!loadLibrary()) {
+    return "loading libpipewire failed";
+  }
+  sampleRate = aSampleRate;
+  channelNum = aChannelNum;
+  bufferFrames = aBufferFrames;
+  hasRequested = isVersionAtLeast(0, 3, 49);
+
+  pw.init(NULL, NULL);
+
+  threadLoop = pw.thread_loop_new("ebiten-audio", NULL);
+  if (!threadLoop) {
+    return "pw_thread_loop_new failed";
+  }
+  pwContext = pw.context_new(pw.thread_loop_get_loop(threadLoop), NULL, 0);
+  if (!pwContext) {
+    closeAll();
+    return "pw_context_new failed";
+  }
+  if (pw.thread_loop_start(threadLoop) < 0) {
+    closeAll();
+    return "pw_thread_loop_start failed";
+  }
+
+  pw.thread_loop_lock(threadLoop);
+
+  core = pw.context_connect(pwContext, NULL, 0);
+  if (!core) {
+    pw.thread_loop_unlock(threadLoop);
+    closeAll();
+    return "pw_context_connect failed";
+  }
+  const struct pw_core_methods *cm = methods(core);
+  cm->add_listener(methodsData(core), &coreListener, &coreEvents, NULL);
+
+  registry = cm->get_registry(methodsData(core), PW_VERSION_REGISTRY, 0);
+  if (!registry) {
+    pw.thread_loop_unlock(threadLoop);
+    closeAll();
+    return "getting the registry failed";
+  }
+  const struct pw_registry_methods *rm = methods(registry);
+  rm->add_listener(methodsData(registry), &registryListener, &registryEvents,
+                   NULL);
+
+  // Wait until the existing globals are listed.
+  synced = false;
+  syncSeq = cm->sync(methodsData(core), PW_ID_CORE, 0);
+  while (!synced) {
+    if (pw.thread_loop_timed_wait(threadLoop, 1) != 0) {
+      break;
+    }
+  }
+
+  char latency[32];
+  snprintf(latency, sizeof(latency), "%d/%d", bufferFrames, sampleRate);
+  struct pw_properties *props = pw.properties_new(
+      "media.type", "Audio", "media.category", "Playback", "media.role",
+      "Game", "node.latency", latency, NULL);
+  stream = pw.stream_new(core, "Playback", props);
+  if (!stream) {
+    pw.thread_loop_unlock(threadLoop);
+    closeAll();
+    return "pw_stream_new failed";
+  }
+  pw.stream_add_listener(stream, &streamListener, &streamEvents, NULL);
+
+  if (connectStream(PW_ID_ANY) < 0) {
+    pw.thread_loop_unlock(threadLoop);
+    closeAll();
+    return "pw_stream_connect failed";
+  }
+
+  pw.thread_loop_unlock(threadLoop);
+  return NULL;
+}
+
+void ebiten_readerdriver_pipeWireSetActive(int value) {
+  pw.thread_loop_lock(threadLoop);
+  active = value;
+  pw.stream_set_active(stream, active);
+  pw.thread_loop_unlock(threadLoop);
+}
+
+int64_t ebiten_readerdriver_pipeWireLatency(void) {
+  // The size of struct pw_time differs among the versions. Allocate enough
+  // space.
+  union {
+    struct pw_time time;
+    char bytes[256];
+  } t;
+  memset(&t, 0, sizeof(t));
+  int result;
+  if (pw.stream_get_time_n) {
+    result = pw.stream_get_time_n(stream, &t.time, sizeof(t.time));
+  } else {
+    result = pw.stream_get_time(stream, &t.time);
+  }
+  if (result < 0 || t.time.rate.denom == 0 || t.time.delay < 0) {
+    return -1;
+  }
+  return t.time.delay * 1000000000LL * t.time.rate.num / t.time.rate.denom;
+}
+
+const char *ebiten_readerdriver_pipeWireSetTarget(uint32_t targetID,
+                                                  const char *targetName) {
+  pw.thread_loop_lock(threadLoop);
+
+  // target.object is used as of PipeWire 0.3.64, and node.target is used
+  // before that. A NULL value removes the property.
+  char id[16];
+  snprintf(id, sizeof(id), "%u", targetID);
+  struct spa_dict_item items[] = {
+      {"target.object", targetName},
+      {"node.target", targetName ? id : NULL},
+  };
+  struct spa_dict dict = {0, 2, items};
+  pw.stream_update_properties(stream, &dict);
+
+  pw.stream_disconnect(stream);
+  int result = connectStream(targetName ? targetID : PW_ID_ANY);
+
+  pw.thread_loop_unlock(threadLoop);
+
+  if (result < 0) {
+    return "pw_stream_connect failed";
+  }
+  return NULL;
+}
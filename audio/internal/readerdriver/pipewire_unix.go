@@ -0,0 +1,221 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build (aix || dragonfly || freebsd || hurd || illumos || linux || netbsd || openbsd || solaris) && !android
+// +build aix dragonfly freebsd hurd illumos linux netbsd openbsd solaris
+// +build !android
+
+package readerdriver
+
+// #cgo linux LDFLAGS: -ldl
+//
+// #include <stdint.h>
+// #include <stdlib.h>
+//
+// const char* ebiten_readerdriver_pipeWireOpen(int sampleRate, int channelNum, int bufferFrames);
+// void ebiten_readerdriver_pipeWireSetActive(int active);
+// int64_t ebiten_readerdriver_pipeWireLatency(void);
+// const char* ebiten_readerdriver_pipeWireSetTarget(uint32_t targetID, const char* targetName);
+import "C"
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// pipeWireServerExists reports whether a PipeWire server seems to be running.
+func pipeWireServerExists() bool {
+	name := os.Getenv("PIPEWIRE_REMOTE")
+	if name == "" {
+		name = "pipewire-0"
+	}
+	if !filepath.IsAbs(name) {
+		dir := os.Getenv("PIPEWIRE_RUNTIME_DIR")
+		if dir == "" {
+			dir = os.Getenv("XDG_RUNTIME_DIR")
+		}
+		if dir == "" {
+			return false
+		}
+		name = filepath.Join(dir, name)
+	}
+	_, err := os.Stat(name)
+	return err == nil
+}
+
+// pipeWireSink is an audio sink node of PipeWire.
+type pipeWireSink struct {
+	id          uint32
+	name        string
+	description string
+}
+
+// The sinks and the default sink reported by the PipeWire server.
+var (
+	pipeWireSinks       = map[uint32]pipeWireSink{}
+	pipeWireDefaultSink string
+	pipeWireM           sync.Mutex
+)
+
+// pipeWireBuf is the buffer to mix the players. pipeWireBuf is used only on PipeWire's thread.
+var pipeWireBuf []float32
+
+// pipeWireBackend is a backend with PipeWire.
+//
+// libpipewire is loaded at runtime. If libpipewire or the server is not available, newPipeWireBackend fails.
+type pipeWireBackend struct {
+	c *context
+}
+
+func newPipeWireBackend(context *context) (backend, error) {
+	if !pipeWireServerExists() {
+		return nil, fmt.Errorf("readerdriver: PipeWire server is not found")
+	}
+	bufferFrames := bufferSize / (context.channelNum * context.bitDepthInBytes)
+	if msg := C.ebiten_readerdriver_pipeWireOpen(C.int(context.sampleRate), C.int(context.channelNum), C.int(bufferFrames)); msg != nil {
+		return nil, fmt.Errorf("readerdriver: opening PipeWire failed: %s", C.GoString(msg))
+	}
+	return &pipeWireBackend{
+		c: context,
+	}, nil
+}
+
+func (c *pipeWireBackend) suspend() error {
+	C.ebiten_readerdriver_pipeWireSetActive(0)
+	return nil
+}
+
+func (c *pipeWireBackend) resume() error {
+	C.ebiten_readerdriver_pipeWireSetActive(1)
+	return nil
+}
+
+func (c *pipeWireBackend) outputLatency() time.Duration {
+	l := C.ebiten_readerdriver_pipeWireLatency()
+	if l < 0 {
+		// The timing information is not available yet. Use the target length of the buffer instead.
+		return c.c.bufferDuration()
+	}
+	return time.Duration(l)
+}
+
+func (c *pipeWireBackend) outputDevices() ([]Device, error) {
+	pipeWireM.Lock()
+	defer pipeWireM.Unlock()
+
+	devices := make([]Device, 0, len(pipeWireSinks))
+	for _, s := range pipeWireSinks {
+		devices = append(devices, Device{
+			ID:        s.name,
+			Name:      s.description,
+			IsDefault: s.name == pipeWireDefaultSink,
+		})
+	}
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i].ID < devices[j].ID
+	})
+	return devices, nil
+}
+
+func (c *pipeWireBackend) setOutputDevice(id string) error {
+	if id == "" {
+		// Without a target, the session manager links the stream to the default sink and follows its changes.
+		if msg := C.ebiten_readerdriver_pipeWireSetTarget(0, nil); msg != nil {
+			return fmt.Errorf("readerdriver: moving the stream to the default sink failed: %s", C.GoString(msg))
+		}
+		return nil
+	}
+
+	pipeWireM.Lock()
+	var sink *pipeWireSink
+	for _, s := range pipeWireSinks {
+		if s.name == id {
+			s := s
+			sink = &s
+			break
+		}
+	}
+	pipeWireM.Unlock()
+	if sink == nil {
+		return fmt.Errorf("readerdriver: invalid device ID: %q", id)
+	}
+
+	name := C.CString(id)
+	defer C.free(unsafe.Pointer(name))
+	if msg := C.ebiten_readerdriver_pipeWireSetTarget(C.uint32_t(sink.id), name); msg != nil {
+		return fmt.Errorf("readerdriver: moving the stream to the sink %q failed: %s", id, C.GoString(msg))
+	}
+	return nil
+}
+
+//export ebiten_readerdriver_pipeWireAddSink
+func ebiten_readerdriver_pipeWireAddSink(id C.uint32_t, name *C.char, description *C.char) {
+	pipeWireM.Lock()
+	defer pipeWireM.Unlock()
+	pipeWireSinks[uint32(id)] = pipeWireSink{
+		id:          uint32(id),
+		name:        C.GoString(name),
+		description: C.GoString(description),
+	}
+}
+
+//export ebiten_readerdriver_pipeWireRemoveSink
+func ebiten_readerdriver_pipeWireRemoveSink(id C.uint32_t) {
+	pipeWireM.Lock()
+	defer pipeWireM.Unlock()
+	delete(pipeWireSinks, uint32(id))
+}
+
+//export ebiten_readerdriver_pipeWireSetDefaultSink
+func ebiten_readerdriver_pipeWireSetDefaultSink(value *C.char) {
+	pipeWireM.Lock()
+	defer pipeWireM.Unlock()
+
+	pipeWireDefaultSink = ""
+	if value == nil {
+		return
+	}
+	// The value is a JSON object like {"name": "alsa_output.pci-0000_00_1f.3.analog-stereo"}.
+	var v struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal([]byte(C.GoString(value)), &v); err != nil {
+		return
+	}
+	pipeWireDefaultSink = v.Name
+}
+
+//export ebiten_readerdriver_pipeWireProcess
+func ebiten_readerdriver_pipeWireProcess(buf *C.float, n C.int) {
+	c := theContext
+
+	// The buffer is shared memory with the server. Mix the players into a Go slice and then copy it.
+	if cap(pipeWireBuf) < int(n) {
+		pipeWireBuf = make([]float32, int(n))
+	}
+	buf32 := pipeWireBuf[:n]
+	for i := range buf32 {
+		buf32[i] = 0
+	}
+	c.players.read(buf32)
+	for i, v := range buf32 {
+		*(*float32)(unsafe.Pointer(uintptr(unsafe.Pointer(buf)) + 4*uintptr(i))) = v
+	}
+}
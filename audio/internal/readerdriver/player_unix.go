@@ -19,8 +19,12 @@ package readerdriver
 
 import (
 	"io"
+	"math"
 	"runtime"
 	"sync"
+
+	"github.com/hajimehoshi/ebiten/v2/internal/diag"
+	"github.com/hajimehoshi/ebiten/v2/internal/stats"
 )
 
 type players struct {
@@ -120,6 +124,9 @@ type playerImpl struct {
 	buf     []byte
 	eof     bool
 
+	// srcEOF reports whether the source reached EOF. Unlike eof, srcEOF is true even while the buffer has data.
+	srcEOF bool
+
 	m sync.Mutex
 }
 
@@ -185,6 +192,7 @@ func (p *playerImpl) playImpl() {
 			}
 			p.buf = append(p.buf, buf[:n]...)
 			if err == io.EOF {
+				p.srcEOF = true
 				if len(p.buf) == 0 {
 					p.eof = true
 				}
@@ -233,6 +241,7 @@ func (p *playerImpl) resetImpl() {
 	p.state = playerPaused
 	p.buf = p.buf[:0]
 	p.eof = false
+	p.srcEOF = false
 }
 
 func (p *player) IsPlaying() bool {
@@ -315,8 +324,14 @@ func (p *playerImpl) readBufferAndAdd(buf []float32) int {
 	volume := float32(p.volume)
 	src := p.buf[:n*bitDepthInBytes]
 	p.buf = p.buf[n*bitDepthInBytes:]
+	underrun := n < len(buf) && !p.srcEOF
 	p.m.Unlock()
 
+	if underrun {
+		stats.AddAudioUnderrun()
+		diag.Log(diag.CategoryAudio, "audio underrun", "frames", (len(buf)-n)/p.context.channelNum)
+	}
+
 	for i := 0; i < n; i++ {
 		var v float32
 		switch bitDepthInBytes {
@@ -326,6 +341,8 @@ func (p *playerImpl) readBufferAndAdd(buf []float32) int {
 		case 2:
 			v16 := int16(src[2*i]) | (int16(src[2*i+1]) << 8)
 			v = float32(v16) / (1 << 15)
+		case 4:
+			v = math.Float32frombits(uint32(src[4*i]) | uint32(src[4*i+1])<<8 | uint32(src[4*i+2])<<16 | uint32(src[4*i+3])<<24)
 		}
 		buf[i] += v * volume
 	}
@@ -367,6 +384,9 @@ func (p *playerImpl) readSourceToBuffer() {
 	}
 
 	p.buf = append(p.buf, buf[:n]...)
+	if err == io.EOF {
+		p.srcEOF = true
+	}
 	if err == io.EOF && len(p.buf) == 0 {
 		p.state = playerPaused
 		p.eof = true
@@ -0,0 +1,355 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build (aix || dragonfly || freebsd || hurd || illumos || linux || netbsd || openbsd || solaris) && !android
+// +build aix dragonfly freebsd hurd illumos linux netbsd openbsd solaris
+// +build !android
+
+package readerdriver
+
+// #cgo pkg-config: libpulse
+// #cgo LDFLAGS: -lpulse
+//
+// #include <pulse/pulseaudio.h>
+//
+// void ebiten_readerdriver_contextStateCallback(pa_context *context, void *userdata);
+// void ebiten_readerdriver_streamWriteCallback(pa_stream *stream, size_t requested_bytes, void *userdata);
+// void ebiten_readerdriver_streamStateCallback(pa_stream *stream, void *userdata);
+// void ebiten_readerdriver_streamSuccessCallback(pa_stream *stream, void *userdata);
+// void ebiten_readerdriver_contextSuccessCallback(pa_context *context, int success, void *userdata);
+// void ebiten_readerdriver_serverInfoCallback(pa_context *context, pa_server_info *info, void *userdata);
+// void ebiten_readerdriver_sinkInfoCallback(pa_context *context, pa_sink_info *info, int eol, void *userdata);
+import "C"
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+	"unsafe"
+)
+
+// pulseServerExists reports whether a PulseAudio server seems to be running.
+func pulseServerExists() bool {
+	if os.Getenv("PULSE_SERVER") != "" {
+		return true
+	}
+	var paths []string
+	if dir := os.Getenv("PULSE_RUNTIME_PATH"); dir != "" {
+		paths = append(paths, filepath.Join(dir, "native"))
+	}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		paths = append(paths, filepath.Join(dir, "pulse", "native"))
+	}
+	// The system-wide server.
+	paths = append(paths, "/var/run/pulse/native")
+	for _, p := range paths {
+		if _, err := os.Stat(p); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// pulseBackend is a backend with PulseAudio.
+type pulseBackend struct {
+	c *context
+
+	mainloop *C.pa_threaded_mainloop
+	context  *C.pa_context
+	stream   *C.pa_stream
+}
+
+func newPulseBackend(context *context) (backend, error) {
+	c := &pulseBackend{
+		c: context,
+	}
+	sampleRate := context.sampleRate
+	channelNum := context.channelNum
+
+	c.mainloop = C.pa_threaded_mainloop_new()
+	if c.mainloop == nil {
+		return nil, fmt.Errorf("readerdriver: pa_threaded_mainloop_new failed")
+	}
+	mainloopAPI := C.pa_threaded_mainloop_get_api(c.mainloop)
+	if mainloopAPI == nil {
+		return nil, fmt.Errorf("readerdriver: pa_threaded_mainloop_get_api failed")
+	}
+
+	contextName := C.CString("pcm-playback")
+	defer C.free(unsafe.Pointer(contextName))
+	c.context = C.pa_context_new(mainloopAPI, contextName)
+	if c.context == nil {
+		return nil, fmt.Errorf("readerdriver: pa_context_new failed")
+	}
+
+	C.pa_context_set_state_callback(c.context, C.pa_context_notify_cb_t(C.ebiten_readerdriver_contextStateCallback), unsafe.Pointer(c.mainloop))
+
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	C.pa_threaded_mainloop_lock(c.mainloop)
+	defer C.pa_threaded_mainloop_unlock(c.mainloop)
+
+	if code := C.pa_threaded_mainloop_start(c.mainloop); code != 0 {
+		return nil, fmt.Errorf("readerdriver: pa_threaded_mainloop_start failed: %s", C.GoString(C.pa_strerror(code)))
+	}
+	if code := C.pa_context_connect(c.context, nil, C.PA_CONTEXT_NOAUTOSPAWN, nil); code != 0 {
+		return nil, fmt.Errorf("readerdriver: pa_context_connect failed: %s", C.GoString(C.pa_strerror(code)))
+	}
+
+	// Wait until the context is ready.
+	for {
+		contextState := C.pa_context_get_state(c.context)
+		if C.PA_CONTEXT_IS_GOOD(contextState) == 0 {
+			return nil, fmt.Errorf("readerdriver: context state is bad")
+		}
+		if contextState == C.PA_CONTEXT_READY {
+			break
+		}
+		C.pa_threaded_mainloop_wait(c.mainloop)
+	}
+
+	sampleSpecificatiom := C.pa_sample_spec{
+		format:   C.PA_SAMPLE_FLOAT32LE,
+		rate:     C.uint(sampleRate),
+		channels: C.uchar(channelNum),
+	}
+	var m C.pa_channel_map
+	switch channelNum {
+	case 1:
+		C.pa_channel_map_init_mono(&m)
+	case 2:
+		C.pa_channel_map_init_stereo(&m)
+	default:
+		// PA_CHANNEL_MAP_WAVEEX is the same order as WAVE files.
+		if C.pa_channel_map_init_extend(&m, C.uint(channelNum), C.PA_CHANNEL_MAP_WAVEEX) == nil {
+			return nil, fmt.Errorf("readerdriver: pa_channel_map_init_extend failed")
+		}
+	}
+
+	streamName := C.CString("Playback")
+	defer C.free(unsafe.Pointer(streamName))
+	c.stream = C.pa_stream_new(c.context, streamName, &sampleSpecificatiom, &m)
+	C.pa_stream_set_state_callback(c.stream, C.pa_stream_notify_cb_t(C.ebiten_readerdriver_streamStateCallback), unsafe.Pointer(c.mainloop))
+	C.pa_stream_set_write_callback(c.stream, C.pa_stream_request_cb_t(C.ebiten_readerdriver_streamWriteCallback), nil)
+
+	const defaultValue = 0xffffffff
+	bufferAttr := C.pa_buffer_attr{
+		maxlength: defaultValue,
+		tlength:   bufferSize,
+		prebuf:    defaultValue,
+		minreq:    defaultValue,
+	}
+	var streamFlags C.pa_stream_flags_t = C.PA_STREAM_START_CORKED | C.PA_STREAM_INTERPOLATE_TIMING |
+		C.PA_STREAM_NOT_MONOTONIC | C.PA_STREAM_AUTO_TIMING_UPDATE |
+		C.PA_STREAM_ADJUST_LATENCY
+
+	if code := C.pa_stream_connect_playback(c.stream, nil, &bufferAttr, streamFlags, nil, nil); code != 0 {
+		return nil, fmt.Errorf("readerdriver: pa_stream_connect_playback failed: %s", C.GoString(C.pa_strerror(code)))
+	}
+
+	// Wait until the stream is ready.
+	for {
+		streamState := C.pa_stream_get_state(c.stream)
+		if C.PA_STREAM_IS_GOOD(streamState) == 0 {
+			return nil, fmt.Errorf("readerdriver: stream state is bad")
+		}
+		if streamState == C.PA_STREAM_READY {
+			break
+		}
+		C.pa_threaded_mainloop_wait(c.mainloop)
+	}
+
+	C.pa_stream_cork(c.stream, 0, C.pa_stream_success_cb_t(C.ebiten_readerdriver_streamSuccessCallback), unsafe.Pointer(c.mainloop))
+
+	return c, nil
+}
+
+func (c *pulseBackend) suspend() error {
+	C.pa_stream_cork(c.stream, 1, C.pa_stream_success_cb_t(C.ebiten_readerdriver_streamSuccessCallback), unsafe.Pointer(c.mainloop))
+	return nil
+}
+
+func (c *pulseBackend) resume() error {
+	C.pa_stream_cork(c.stream, 0, C.pa_stream_success_cb_t(C.ebiten_readerdriver_streamSuccessCallback), unsafe.Pointer(c.mainloop))
+	return nil
+}
+
+func (c *pulseBackend) outputLatency() time.Duration {
+	C.pa_threaded_mainloop_lock(c.mainloop)
+	defer C.pa_threaded_mainloop_unlock(c.mainloop)
+
+	var usec C.pa_usec_t
+	var negative C.int
+	if C.pa_stream_get_latency(c.stream, &usec, &negative) != 0 {
+		// The timing information is not available yet. Use the target length of the buffer instead.
+		return c.c.bufferDuration()
+	}
+	if negative != 0 {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond
+}
+
+// The results of the PulseAudio operations. These are protected by the mainloop lock, as the callbacks are invoked
+// with the lock held.
+var (
+	pulseSinks       []Device
+	pulseDefaultSink string
+	pulseSucceeded   bool
+)
+
+// waitOperation waits until the operation finishes.
+//
+// waitOperation must be called with the mainloop locked.
+func (c *pulseBackend) waitOperation(op *C.pa_operation, name string) error {
+	if op == nil {
+		return fmt.Errorf("readerdriver: %s failed: %s", name, C.GoString(C.pa_strerror(C.pa_context_errno(c.context))))
+	}
+	defer C.pa_operation_unref(op)
+	for C.pa_operation_get_state(op) == C.PA_OPERATION_RUNNING {
+		C.pa_threaded_mainloop_wait(c.mainloop)
+	}
+	return nil
+}
+
+// defaultSink returns the name of the default sink.
+//
+// defaultSink must be called with the mainloop locked.
+func (c *pulseBackend) defaultSink() (string, error) {
+	pulseDefaultSink = ""
+	op := C.pa_context_get_server_info(c.context, C.pa_server_info_cb_t(C.ebiten_readerdriver_serverInfoCallback), unsafe.Pointer(c.mainloop))
+	if err := c.waitOperation(op, "pa_context_get_server_info"); err != nil {
+		return "", err
+	}
+	return pulseDefaultSink, nil
+}
+
+func (c *pulseBackend) outputDevices() ([]Device, error) {
+	C.pa_threaded_mainloop_lock(c.mainloop)
+	defer C.pa_threaded_mainloop_unlock(c.mainloop)
+
+	defaultSink, err := c.defaultSink()
+	if err != nil {
+		return nil, err
+	}
+
+	pulseSinks = nil
+	op := C.pa_context_get_sink_info_list(c.context, C.pa_sink_info_cb_t(C.ebiten_readerdriver_sinkInfoCallback), unsafe.Pointer(c.mainloop))
+	if err := c.waitOperation(op, "pa_context_get_sink_info_list"); err != nil {
+		return nil, err
+	}
+	devices := pulseSinks
+	pulseSinks = nil
+
+	for i := range devices {
+		devices[i].IsDefault = devices[i].ID == defaultSink
+	}
+	return devices, nil
+}
+
+func (c *pulseBackend) setOutputDevice(id string) error {
+	C.pa_threaded_mainloop_lock(c.mainloop)
+	defer C.pa_threaded_mainloop_unlock(c.mainloop)
+
+	if id == "" {
+		s, err := c.defaultSink()
+		if err != nil {
+			return err
+		}
+		id = s
+	}
+
+	name := C.CString(id)
+	defer C.free(unsafe.Pointer(name))
+
+	// All the players are mixed into one stream. Move the stream to the sink.
+	pulseSucceeded = false
+	op := C.pa_context_move_sink_input_by_name(c.context, C.pa_stream_get_index(c.stream), name, C.pa_context_success_cb_t(C.ebiten_readerdriver_contextSuccessCallback), unsafe.Pointer(c.mainloop))
+	if err := c.waitOperation(op, "pa_context_move_sink_input_by_name"); err != nil {
+		return err
+	}
+	if !pulseSucceeded {
+		return fmt.Errorf("readerdriver: moving the stream to the sink %q failed: %s", id, C.GoString(C.pa_strerror(C.pa_context_errno(c.context))))
+	}
+	return nil
+}
+
+//export ebiten_readerdriver_contextSuccessCallback
+func ebiten_readerdriver_contextSuccessCallback(context *C.pa_context, success C.int, mainloop unsafe.Pointer) {
+	pulseSucceeded = success != 0
+	C.pa_threaded_mainloop_signal((*C.pa_threaded_mainloop)(mainloop), 0)
+}
+
+//export ebiten_readerdriver_serverInfoCallback
+func ebiten_readerdriver_serverInfoCallback(context *C.pa_context, info *C.pa_server_info, mainloop unsafe.Pointer) {
+	if info != nil && info.default_sink_name != nil {
+		pulseDefaultSink = C.GoString(info.default_sink_name)
+	}
+	C.pa_threaded_mainloop_signal((*C.pa_threaded_mainloop)(mainloop), 0)
+}
+
+//export ebiten_readerdriver_sinkInfoCallback
+func ebiten_readerdriver_sinkInfoCallback(context *C.pa_context, info *C.pa_sink_info, eol C.int, mainloop unsafe.Pointer) {
+	if eol != 0 || info == nil {
+		C.pa_threaded_mainloop_signal((*C.pa_threaded_mainloop)(mainloop), 0)
+		return
+	}
+	pulseSinks = append(pulseSinks, Device{
+		ID:   C.GoString(info.name),
+		Name: C.GoString(info.description),
+	})
+}
+
+//export ebiten_readerdriver_contextStateCallback
+func ebiten_readerdriver_contextStateCallback(context *C.pa_context, mainloop unsafe.Pointer) {
+	C.pa_threaded_mainloop_signal((*C.pa_threaded_mainloop)(mainloop), 0)
+}
+
+//export ebiten_readerdriver_streamStateCallback
+func ebiten_readerdriver_streamStateCallback(stream *C.pa_stream, mainloop unsafe.Pointer) {
+	C.pa_threaded_mainloop_signal((*C.pa_threaded_mainloop)(mainloop), 0)
+}
+
+//export ebiten_readerdriver_streamSuccessCallback
+func ebiten_readerdriver_streamSuccessCallback(stream *C.pa_stream, userdata unsafe.Pointer) {
+}
+
+//export ebiten_readerdriver_streamWriteCallback
+func ebiten_readerdriver_streamWriteCallback(stream *C.pa_stream, requestedBytes C.size_t, userdata unsafe.Pointer) {
+	c := theContext
+
+	var buf unsafe.Pointer
+	var buf32 []float32
+	var bytesToFill C.size_t = bufferSize
+	for n := int(requestedBytes); n > 0; n -= int(bytesToFill) {
+		C.pa_stream_begin_write(stream, &buf, &bytesToFill)
+		if len(buf32) < int(bytesToFill)/4 {
+			buf32 = make([]float32, bytesToFill/4)
+		} else {
+			for i := 0; i < int(bytesToFill)/4; i++ {
+				buf32[i] = 0
+			}
+		}
+
+		c.players.read(buf32[:bytesToFill/4])
+
+		for i := uintptr(0); i < uintptr(bytesToFill/4); i++ {
+			*(*float32)(unsafe.Pointer(uintptr(buf) + 4*i)) = buf32[i]
+		}
+
+		C.pa_stream_write(stream, buf, bytesToFill, nil, 0, C.PA_SEEK_RELATIVE)
+	}
+}
@@ -29,6 +29,9 @@ var (
 var (
 	procWaveOutOpen            = winmm.NewProc("waveOutOpen")
 	procWaveOutClose           = winmm.NewProc("waveOutClose")
+	procWaveOutGetDevCapsW     = winmm.NewProc("waveOutGetDevCapsW")
+	procWaveOutGetNumDevs      = winmm.NewProc("waveOutGetNumDevs")
+	procWaveOutMessage         = winmm.NewProc("waveOutMessage")
 	procWaveOutPause           = winmm.NewProc("waveOutPause")
 	procWaveOutPrepareHeader   = winmm.NewProc("waveOutPrepareHeader")
 	procWaveOutReset           = winmm.NewProc("waveOutReset")
@@ -58,9 +61,44 @@ type waveformatex struct {
 	cbSize          uint16
 }
 
+// waveformatextensible is WAVEFORMATEXTENSIBLE. The fields are flattened to keep the same layout as the packed C
+// struct.
+type waveformatextensible struct {
+	wFormatTag          uint16
+	nChannels           uint16
+	nSamplesPerSec      uint32
+	nAvgBytesPerSec     uint32
+	nBlockAlign         uint16
+	wBitsPerSample      uint16
+	cbSize              uint16
+	wValidBitsPerSample uint16
+	dwChannelMask       uint32
+	subFormat           windows.GUID
+}
+
+type waveoutcapsw struct {
+	wMid           uint16
+	wPid           uint16
+	vDriverVersion uint32
+	szPname        [32]uint16
+	dwFormats      uint32
+	wChannels      uint16
+	wReserved1     uint16
+	dwSupport      uint32
+}
+
 const (
-	waveFormatPCM = 1
-	whdrInqueue   = 16
+	waveMapper = 0xffffffff
+
+	waveFormatPCM        = 1
+	waveFormatIEEEFloat  = 3
+	waveFormatExtensible = 0xfffe
+	whdrInqueue          = 16
+)
+
+var (
+	ksdataformatSubtypePCM       = windows.GUID{Data1: 0x00000001, Data2: 0x0000, Data3: 0x0010, Data4: [8]byte{0x80, 0x00, 0x00, 0xaa, 0x00, 0x38, 0x9b, 0x71}}
+	ksdataformatSubtypeIEEEFloat = windows.GUID{Data1: 0x00000003, Data2: 0x0000, Data3: 0x0010, Data4: [8]byte{0x80, 0x00, 0x00, 0xaa, 0x00, 0x38, 0x9b, 0x71}}
 )
 
 type mmresult uint
@@ -123,9 +161,9 @@ func (e *winmmError) Error() string {
 	return fmt.Sprintf("winmm error at %s", e.fname)
 }
 
-func waveOutOpen(f *waveformatex, callback uintptr) (uintptr, error) {
+// waveOutOpen opens the device. deviceID is the index of the device or waveMapper for the default device.
+func waveOutOpen(deviceID uint32, f *waveformatex, callback uintptr) (uintptr, error) {
 	const (
-		waveMapper       = 0xffffffff
 		callbackFunction = 0x30000
 	)
 	var w uintptr
@@ -133,7 +171,7 @@ func waveOutOpen(f *waveformatex, callback uintptr) (uintptr, error) {
 	if callback != 0 {
 		fdwOpen |= callbackFunction
 	}
-	r, _, e := procWaveOutOpen.Call(uintptr(unsafe.Pointer(&w)), waveMapper, uintptr(unsafe.Pointer(f)),
+	r, _, e := procWaveOutOpen.Call(uintptr(unsafe.Pointer(&w)), uintptr(deviceID), uintptr(unsafe.Pointer(f)),
 		callback, 0, fdwOpen)
 	runtime.KeepAlive(f)
 	if e.(windows.Errno) != 0 {
@@ -272,3 +310,46 @@ func waveOutWrite(hwo uintptr, pwh *wavehdr) error {
 	}
 	return nil
 }
+
+func waveOutGetNumDevs() uint32 {
+	r, _, _ := procWaveOutGetNumDevs.Call()
+	return uint32(r)
+}
+
+func waveOutGetDevCaps(deviceID uint32) (*waveoutcapsw, error) {
+	var caps waveoutcapsw
+	r, _, e := procWaveOutGetDevCapsW.Call(uintptr(deviceID), uintptr(unsafe.Pointer(&caps)), unsafe.Sizeof(caps))
+	if e.(windows.Errno) != 0 {
+		return nil, &winmmError{
+			fname: "waveOutGetDevCapsW",
+			errno: e.(windows.Errno),
+		}
+	}
+	if mmresult(r) != mmsyserrNoerror {
+		return nil, &winmmError{
+			fname:    "waveOutGetDevCapsW",
+			mmresult: mmresult(r),
+		}
+	}
+	return &caps, nil
+}
+
+// waveOutPreferredDevice returns the index of the device that the wave mapper uses.
+func waveOutPreferredDevice() (uint32, error) {
+	const drvmMapperPreferredGet = 0x2015
+	var deviceID, flags uint32
+	r, _, e := procWaveOutMessage.Call(waveMapper, drvmMapperPreferredGet, uintptr(unsafe.Pointer(&deviceID)), uintptr(unsafe.Pointer(&flags)))
+	if e.(windows.Errno) != 0 {
+		return 0, &winmmError{
+			fname: "waveOutMessage",
+			errno: e.(windows.Errno),
+		}
+	}
+	if mmresult(r) != mmsyserrNoerror {
+		return 0, &winmmError{
+			fname:    "waveOutMessage",
+			mmresult: mmresult(r),
+		}
+	}
+	return deviceID, nil
+}
@@ -0,0 +1,516 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package readerdriver
+
+import (
+	"io"
+	"runtime"
+	"sync"
+	"syscall/js"
+)
+
+// workletProcessorScript is the source of the AudioWorkletProcessor that plays samples from a ring buffer.
+//
+// The ring buffer is a SharedArrayBuffer of int16 or float32 samples when available. The indices in frames are stored in an
+// Int32Array on another SharedArrayBuffer: [0] is the read index updated by the processor, and [1] is the write
+// index updated by Go. Without SharedArrayBuffer, the samples are sent as messages instead.
+//
+// The processor posts its read index when a quarter of the ring buffer is consumed or an underrun happens so that
+// Go can refill the ring buffer.
+const workletProcessorScript = `
+class EbitenPlayerProcessor extends AudioWorkletProcessor {
+  constructor(options) {
+    super();
+    const o = options.processorOptions;
+    this.channelNum = o.channelNum;
+    this.capacity = o.capacity;
+    this.sampleArray = o.float ? Float32Array : Int16Array;
+    this.scale = o.float ? 1 : 1 / 32768;
+    if (o.samples) {
+      this.samples = new this.sampleArray(o.samples);
+      this.indices = new Int32Array(o.indices);
+    } else {
+      this.queue = [];
+      this.queueOffset = 0;
+    }
+    this.read = 0;
+    this.lastNotified = 0;
+    this.playing = false;
+    this.port.onmessage = (e) => {
+      const m = e.data;
+      switch (m.type) {
+      case 'data':
+        this.queue.push(new this.sampleArray(m.data));
+        break;
+      case 'play':
+        this.playing = true;
+        this.notify();
+        break;
+      case 'pause':
+        this.playing = false;
+        break;
+      case 'reset':
+        this.read = m.position;
+        if (this.samples) {
+          Atomics.store(this.indices, 0, this.read);
+        } else {
+          this.queue = [];
+          this.queueOffset = 0;
+        }
+        this.notify();
+        break;
+      }
+    };
+  }
+
+  notify() {
+    this.lastNotified = this.read;
+    this.port.postMessage(this.read);
+  }
+
+  process(inputs, outputs) {
+    if (!this.playing) {
+      return true;
+    }
+    const out = outputs[0];
+    const frames = out[0].length;
+    const channelNum = this.channelNum;
+    const scale = this.scale;
+    let n = 0;
+    if (this.samples) {
+      const available = (Atomics.load(this.indices, 1) - this.read) | 0;
+      n = Math.min(frames, available);
+      for (let i = 0; i < n; i++) {
+        const idx = ((this.read + i) & (this.capacity - 1)) * channelNum;
+        for (let ch = 0; ch < out.length; ch++) {
+          out[ch][i] = this.samples[idx + Math.min(ch, channelNum - 1)] * scale;
+        }
+      }
+      this.read = (this.read + n) | 0;
+      Atomics.store(this.indices, 0, this.read);
+    } else {
+      while (n < frames && this.queue.length > 0) {
+        const data = this.queue[0];
+        const m = Math.min(frames - n, data.length / channelNum - this.queueOffset);
+        for (let i = 0; i < m; i++) {
+          const idx = (this.queueOffset + i) * channelNum;
+          for (let ch = 0; ch < out.length; ch++) {
+            out[ch][n + i] = data[idx + Math.min(ch, channelNum - 1)] * scale;
+          }
+        }
+        n += m;
+        this.queueOffset += m;
+        if (this.queueOffset * channelNum >= data.length) {
+          this.queue.shift();
+          this.queueOffset = 0;
+        }
+      }
+      this.read = (this.read + n) | 0;
+    }
+    if (((this.read - this.lastNotified) | 0) >= this.capacity / 4 || (n < frames && this.read !== this.lastNotified)) {
+      this.notify();
+    }
+    return true;
+  }
+}
+
+registerProcessor('ebiten-player', EbitenPlayerProcessor);
+`
+
+// loadAudioWorklet starts loading the AudioWorklet module.
+// c.workletLoaded is closed when the loading finishes regardless of its result.
+func (c *context) loadAudioWorklet() {
+	c.workletLoaded = make(chan struct{})
+
+	// AudioWorklet is available only in secure contexts.
+	// The player implementation with AudioWorklet assumes 16bit or float32 samples.
+	if !c.audioContext.Get("audioWorklet").Truthy() || (c.bitDepthInBytes != 2 && c.bitDepthInBytes != 4) {
+		close(c.workletLoaded)
+		return
+	}
+
+	blob := js.Global().Get("Blob").New([]interface{}{workletProcessorScript}, map[string]interface{}{
+		"type": "application/javascript",
+	})
+	url := js.Global().Get("URL").Call("createObjectURL", blob)
+
+	var resolve, reject js.Func
+	finish := func(ok bool) {
+		js.Global().Get("URL").Call("revokeObjectURL", url)
+		c.worklet = ok
+		close(c.workletLoaded)
+		resolve.Release()
+		reject.Release()
+	}
+	resolve = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		finish(true)
+		return nil
+	})
+	reject = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		// Fall back to the AudioBufferSourceNode implementation.
+		finish(false)
+		return nil
+	})
+	c.audioContext.Get("audioWorklet").Call("addModule", url).Call("then", resolve, reject)
+}
+
+// isAudioWorkletAvailable reports whether players can use AudioWorklet.
+// isAudioWorkletAvailable blocks until the module loading finishes.
+func (c *context) isAudioWorkletAvailable() bool {
+	<-c.workletLoaded
+	return c.worklet
+}
+
+// isSharedArrayBufferAvailable reports whether SharedArrayBuffer can be shared with AudioWorklet.
+// SharedArrayBuffer requires cross-origin isolation.
+func isSharedArrayBufferAvailable() bool {
+	return js.Global().Get("SharedArrayBuffer").Truthy() && js.Global().Get("crossOriginIsolated").Truthy()
+}
+
+// workletBufferFrames returns the size of the ring buffer for AudioWorklet in frames.
+// This determines the output latency. The size is a power of two.
+func (c *context) workletBufferFrames() int {
+	n := 1
+	for n < c.sampleRate/20 {
+		n <<= 1
+	}
+	return n
+}
+
+type workletPlayer struct {
+	context *context
+	src     io.Reader
+	eof     bool
+	state   playerState
+	node    js.Value
+	port    js.Value
+	gain    js.Value
+	err     error
+	buf     []byte
+
+	// samples is a Uint8Array on a SharedArrayBuffer. samples is undefined when SharedArrayBuffer is not available.
+	samples js.Value
+	indices js.Value
+
+	// written and read are the indices of the ring buffer in frames. They can wrap around.
+	written int32
+	read    int32
+
+	onMessageFunc js.Func
+
+	cond *sync.Cond
+}
+
+func newWorkletPlayer(c *context, src io.Reader) *workletPlayer {
+	p := &workletPlayer{
+		context: c,
+		src:     src,
+		gain:    c.audioContext.Call("createGain"),
+		cond:    sync.NewCond(&sync.Mutex{}),
+	}
+
+	frames := c.workletBufferFrames()
+	processorOptions := map[string]interface{}{
+		"channelNum": c.channelNum,
+		"capacity":   frames,
+		"float":      c.bitDepthInBytes == 4,
+	}
+	if isSharedArrayBufferAvailable() {
+		samples := js.Global().Get("SharedArrayBuffer").New(frames * c.channelNum * c.bitDepthInBytes)
+		indices := js.Global().Get("SharedArrayBuffer").New(2 * 4)
+		p.samples = js.Global().Get("Uint8Array").New(samples)
+		p.indices = js.Global().Get("Int32Array").New(indices)
+		processorOptions["samples"] = samples
+		processorOptions["indices"] = indices
+	}
+
+	p.node = js.Global().Get("AudioWorkletNode").New(c.audioContext, "ebiten-player", map[string]interface{}{
+		"numberOfInputs":     0,
+		"numberOfOutputs":    1,
+		"outputChannelCount": []interface{}{c.channelNum},
+		"processorOptions":   processorOptions,
+	})
+	p.port = p.node.Get("port")
+	p.onMessageFunc = js.FuncOf(p.onMessage)
+	p.port.Set("onmessage", p.onMessageFunc)
+	p.node.Call("connect", p.gain)
+	p.gain.Call("connect", c.audioContext.Get("destination"))
+	runtime.SetFinalizer(p, (*workletPlayer).Close)
+	return p
+}
+
+func (p *workletPlayer) postMessage(typ string, position int32) {
+	p.port.Call("postMessage", map[string]interface{}{
+		"type":     typ,
+		"position": position,
+	})
+}
+
+func (p *workletPlayer) onMessage(this js.Value, args []js.Value) interface{} {
+	p.cond.L.Lock()
+	defer p.cond.L.Unlock()
+
+	// The message is the processor's read index.
+	p.read = int32(args[0].Get("data").Int())
+	p.refillImpl()
+	return nil
+}
+
+func (p *workletPlayer) Play() {
+	p.cond.L.Lock()
+	defer p.cond.L.Unlock()
+
+	if p.err != nil {
+		return
+	}
+	if p.state != playerPaused {
+		return
+	}
+
+	buf := make([]byte, p.context.maxBufferSize())
+	for len(p.buf) < p.context.maxBufferSize() {
+		n, err := p.src.Read(buf)
+		if err != nil && err != io.EOF {
+			p.setErrorImpl(err)
+			return
+		}
+		p.buf = append(p.buf, buf[:n]...)
+		if err == io.EOF {
+			p.eof = true
+			break
+		}
+	}
+
+	p.state = playerPlay
+	p.refillImpl()
+	p.postMessage("play", 0)
+
+	go p.loop()
+}
+
+func (p *workletPlayer) Pause() {
+	p.cond.L.Lock()
+	defer p.cond.L.Unlock()
+	p.pauseImpl()
+}
+
+func (p *workletPlayer) pauseImpl() {
+	if p.err != nil {
+		return
+	}
+	if p.state != playerPlay {
+		return
+	}
+
+	// The samples in the ring buffer are kept and played when the player is resumed.
+	p.postMessage("pause", 0)
+	p.state = playerPaused
+	p.cond.Signal()
+}
+
+// queuedFrames returns the number of frames in the ring buffer that are not played yet.
+func (p *workletPlayer) queuedFrames() int {
+	if p.indices.Truthy() {
+		p.read = int32(js.Global().Get("Atomics").Call("load", p.indices, 0).Int())
+	}
+	n := int(p.written - p.read)
+	// The read index can be stale just after resetting.
+	if n < 0 {
+		return 0
+	}
+	return n
+}
+
+func (p *workletPlayer) refillImpl() {
+	if p.state != playerPlay {
+		return
+	}
+
+	frames := p.context.workletBufferFrames()
+	bytesPerFrame := p.context.channelNum * p.context.bitDepthInBytes
+	queued := p.queuedFrames()
+
+	if p.eof && len(p.buf) == 0 {
+		if queued == 0 {
+			p.pauseImpl()
+		}
+		return
+	}
+
+	n := frames - queued
+	if n > len(p.buf)/bytesPerFrame {
+		n = len(p.buf) / bytesPerFrame
+	}
+	if n <= 0 {
+		return
+	}
+
+	bs := p.buf[:n*bytesPerFrame]
+	if p.samples.Truthy() {
+		// Copy the bytes into the ring buffer. The region might wrap around.
+		start := int(uint32(p.written)&uint32(frames-1)) * bytesPerFrame
+		m := len(bs)
+		if max := frames*bytesPerFrame - start; m > max {
+			m = max
+		}
+		js.CopyBytesToJS(p.samples.Call("subarray", start, start+m), bs[:m])
+		if m < len(bs) {
+			js.CopyBytesToJS(p.samples.Call("subarray", 0, len(bs)-m), bs[m:])
+		}
+		p.written += int32(n)
+		js.Global().Get("Atomics").Call("store", p.indices, 1, p.written)
+	} else {
+		a := js.Global().Get("Uint8Array").New(len(bs))
+		js.CopyBytesToJS(a, bs)
+		p.port.Call("postMessage", map[string]interface{}{
+			"type": "data",
+			"data": a.Get("buffer"),
+		}, []interface{}{a.Get("buffer")})
+		p.written += int32(n)
+	}
+
+	p.buf = p.buf[len(bs):]
+	if len(p.buf) < p.context.maxBufferSize() {
+		p.cond.Signal()
+	}
+}
+
+func (p *workletPlayer) IsPlaying() bool {
+	return p.state == playerPlay
+}
+
+func (p *workletPlayer) Reset() {
+	p.cond.L.Lock()
+	defer p.cond.L.Unlock()
+	p.resetImpl()
+}
+
+func (p *workletPlayer) resetImpl() {
+	if p.err != nil {
+		return
+	}
+	if p.state == playerClosed {
+		return
+	}
+
+	p.pauseImpl()
+	p.eof = false
+	p.buf = p.buf[:0]
+	p.read = p.written
+	p.postMessage("reset", p.written)
+}
+
+func (p *workletPlayer) Volume() float64 {
+	return p.gain.Get("gain").Get("value").Float()
+}
+
+func (p *workletPlayer) SetVolume(volume float64) {
+	p.gain.Get("gain").Set("value", volume)
+}
+
+func (p *workletPlayer) UnplayedBufferSize() int {
+	p.cond.L.Lock()
+	defer p.cond.L.Unlock()
+	return len(p.buf) + p.queuedFrames()*p.context.channelNum*p.context.bitDepthInBytes
+}
+
+func (p *workletPlayer) Err() error {
+	p.cond.L.Lock()
+	defer p.cond.L.Unlock()
+	return p.err
+}
+
+func (p *workletPlayer) Close() error {
+	runtime.SetFinalizer(p, nil)
+	p.cond.L.Lock()
+	defer p.cond.L.Unlock()
+	return p.closeImpl()
+}
+
+func (p *workletPlayer) closeImpl() error {
+	if p.state == playerClosed {
+		return p.err
+	}
+	p.resetImpl()
+	p.state = playerClosed
+	p.port.Set("onmessage", nil)
+	p.node.Call("disconnect")
+	p.gain.Call("disconnect")
+	p.onMessageFunc.Release()
+	p.cond.Signal()
+	return p.err
+}
+
+func (p *workletPlayer) setError(err error) {
+	p.cond.L.Lock()
+	defer p.cond.L.Unlock()
+	p.setErrorImpl(err)
+}
+
+func (p *workletPlayer) setErrorImpl(err error) {
+	p.err = err
+	p.closeImpl()
+}
+
+func (p *workletPlayer) shouldWait() bool {
+	switch p.state {
+	case playerPaused:
+		// The loop ends when the player is paused, and a new loop starts when the player is resumed.
+		return false
+	case playerPlay:
+		return len(p.buf) >= p.context.maxBufferSize()
+	case playerClosed:
+		return false
+	default:
+		panic("not reached")
+	}
+}
+
+func (p *workletPlayer) wait() bool {
+	p.cond.L.Lock()
+	defer p.cond.L.Unlock()
+
+	for p.shouldWait() {
+		p.cond.Wait()
+	}
+	return p.state == playerPlay && !p.eof
+}
+
+func (p *workletPlayer) loop() {
+	buf := make([]byte, 4096)
+	for {
+		if !p.wait() {
+			return
+		}
+
+		n, err := p.src.Read(buf)
+		if err != nil && err != io.EOF {
+			p.setError(err)
+			return
+		}
+
+		p.cond.L.Lock()
+		p.buf = append(p.buf, buf[:n]...)
+		if err == io.EOF {
+			p.eof = true
+		}
+		// Refill the ring buffer in case the processor is starving.
+		p.refillImpl()
+		p.cond.L.Unlock()
+		if err == io.EOF {
+			return
+		}
+	}
+}
@@ -43,6 +43,45 @@ func NewInfiniteLoopWithIntro(src io.ReadSeeker, introLength int64, loopLength i
 	}
 }
 
+// NewInfiniteLoopWithLoopPoints creates a new infinite loop stream with loop points in samples.
+//
+// src's format must be 16bit little endian and 2 channels (stereo) as well as NewPlayer. For a stream of 32bit
+// floats, use NewInfiniteLoopWithLoopPointsF32.
+//
+// The stream plays the intro part [0, loopStart) once, and then loops the region [loopStart, loopEnd) forever.
+// loopStart and loopEnd are the indices of samples, i.e. frames of all the channels, of src. For a stream
+// decoded by e.g. audio/vorbis, the indices are the ones at the context's sample rate after decoding.
+//
+// The loop boundary is sample-accurate, and Read reads the samples across the boundary at once, so there is no
+// gap at the boundary.
+//
+// NewInfiniteLoopWithLoopPoints panics if loopStart is negative or loopEnd is not greater than loopStart.
+func NewInfiniteLoopWithLoopPoints(src io.ReadSeeker, loopStart, loopEnd int64) *InfiniteLoop {
+	return newInfiniteLoopWithLoopPoints(src, loopStart, loopEnd, sampleFormatInt16)
+}
+
+// NewInfiniteLoopWithLoopPointsF32 creates a new infinite loop stream with loop points in samples of 32bit floats.
+//
+// src's format must be 32bit float little endian and 2 channels (stereo) as well as NewPlayerF32.
+//
+// The other behaviors are same as NewInfiniteLoopWithLoopPoints.
+func NewInfiniteLoopWithLoopPointsF32(src io.ReadSeeker, loopStart, loopEnd int64) *InfiniteLoop {
+	return newInfiniteLoopWithLoopPoints(src, loopStart, loopEnd, sampleFormatFloat32)
+}
+
+func newInfiniteLoopWithLoopPoints(src io.ReadSeeker, loopStart, loopEnd int64, format sampleFormat) *InfiniteLoop {
+	if loopStart < 0 || loopEnd <= loopStart {
+		panic(fmt.Sprintf("audio: invalid loop points: start: %d, end: %d", loopStart, loopEnd))
+	}
+	n := int64(format.bytesPerSample())
+	return &InfiniteLoop{
+		src:     src,
+		lstart:  loopStart * n,
+		llength: (loopEnd - loopStart) * n,
+		pos:     -1,
+	}
+}
+
 func (i *InfiniteLoop) length() int64 {
 	return i.lstart + i.llength
 }
@@ -63,11 +102,29 @@ func (i *InfiniteLoop) ensurePos() error {
 }
 
 // Read is implementation of ReadSeekCloser's Read.
+//
+// Read fills b across the loop boundary unless the source returns an error. When the source returns an error, Read
+// returns the number of the bytes read so far with the error.
 func (i *InfiniteLoop) Read(b []byte) (int, error) {
 	if err := i.ensurePos(); err != nil {
 		return 0, err
 	}
 
+	var total int
+	for total < len(b) {
+		n, err := i.read(b[total:])
+		total += n
+		if err != nil {
+			return total, err
+		}
+		if n == 0 {
+			break
+		}
+	}
+	return total, nil
+}
+
+func (i *InfiniteLoop) read(b []byte) (int, error) {
 	if i.pos+int64(len(b)) > i.length() {
 		b = b[:i.length()-i.pos]
 	}
@@ -79,13 +136,13 @@ func (i *InfiniteLoop) Read(b []byte) (int, error) {
 	}
 
 	if err != nil && err != io.EOF {
-		return 0, err
+		return n, err
 	}
 
 	if err == io.EOF || i.pos == i.length() {
 		pos, err := i.Seek(i.lstart, io.SeekStart)
 		if err != nil {
-			return 0, err
+			return n, err
 		}
 		i.pos = pos
 	}
@@ -16,6 +16,8 @@ package audio_test
 
 import (
 	"bytes"
+	"encoding/binary"
+	"errors"
 	"io"
 	"math"
 	"testing"
@@ -170,3 +172,98 @@ func TestInfiniteLoopWithIncompleteSize(t *testing.T) {
 		t.Errorf("got: %d, want: %d", got, want)
 	}
 }
+
+func TestInfiniteLoopWithLoopPoints(t *testing.T) {
+	// The samples are 0, 1, 2, 3, 4 and 5. The loop region is [2, 5).
+	var values []int16
+	for i := int16(0); i < 6; i++ {
+		values = append(values, i)
+	}
+	l := NewInfiniteLoopWithLoopPoints(bytes.NewReader(stereo16(values...)), 2, 5)
+
+	// Read samples across the loop boundaries at once.
+	buf := make([]byte, len(stereo16(make([]int16, 10)...)))
+	n, err := l.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(buf) {
+		t.Errorf("n: got: %d, want: %d", n, len(buf))
+	}
+	if want := stereo16(0, 1, 2, 3, 4, 2, 3, 4, 2, 3); !bytes.Equal(buf, want) {
+		t.Errorf("got: %v, want: %v", buf, want)
+	}
+}
+
+// stereoF32 returns 32bit float stereo samples where both channels have the given values.
+func stereoF32(values ...float32) []byte {
+	b := make([]byte, 8*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(b[8*i:], math.Float32bits(v))
+		binary.LittleEndian.PutUint32(b[8*i+4:], math.Float32bits(v))
+	}
+	return b
+}
+
+func TestInfiniteLoopWithLoopPointsF32(t *testing.T) {
+	// The samples are 0, 1, 2, 3, 4 and 5. The loop region is [2, 5).
+	var values []float32
+	for i := 0; i < 6; i++ {
+		values = append(values, float32(i)/8)
+	}
+	l := NewInfiniteLoopWithLoopPointsF32(bytes.NewReader(stereoF32(values...)), 2, 5)
+
+	buf := make([]byte, len(stereoF32(make([]float32, 10)...)))
+	n, err := l.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(buf) {
+		t.Errorf("n: got: %d, want: %d", n, len(buf))
+	}
+	want := stereoF32(values[0], values[1], values[2], values[3], values[4], values[2], values[3], values[4], values[2], values[3])
+	if !bytes.Equal(buf, want) {
+		t.Errorf("got: %v, want: %v", buf, want)
+	}
+}
+
+// errorReader returns an error after reading n bytes of the source.
+type errorReader struct {
+	*bytes.Reader
+	n int64
+}
+
+var errTestRead = errors.New("test read error")
+
+func (r *errorReader) Read(b []byte) (int, error) {
+	pos, _ := r.Reader.Seek(0, io.SeekCurrent)
+	if pos >= r.n {
+		return 0, errTestRead
+	}
+	if int64(len(b)) > r.n-pos {
+		b = b[:r.n-pos]
+	}
+	n, _ := r.Reader.Read(b)
+	if pos+int64(n) >= r.n {
+		return n, errTestRead
+	}
+	return n, nil
+}
+
+func TestInfiniteLoopReadError(t *testing.T) {
+	src := &errorReader{
+		Reader: bytes.NewReader(stereo16(0, 1, 2, 3, 4, 5)),
+		n:      int64(len(stereo16(0, 1, 2))),
+	}
+	l := NewInfiniteLoopWithLoopPoints(src, 2, 5)
+
+	// The bytes read before the error are returned with the error.
+	buf := make([]byte, len(stereo16(make([]int16, 5)...)))
+	n, err := l.Read(buf)
+	if err != errTestRead {
+		t.Errorf("err: got: %v, want: %v", err, errTestRead)
+	}
+	if got, want := buf[:n], stereo16(0, 1, 2); !bytes.Equal(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package midi
+
+import (
+	"math"
+	"sync"
+)
+
+var (
+	defaultSoundFont     *SoundFont
+	defaultSoundFontOnce sync.Once
+)
+
+// DefaultSoundFont returns the built-in SoundFont.
+//
+// The built-in SoundFont consists of simple waveforms like sine and square waves, and a noise-based drum kit.
+// This is useful for prototyping or procedurally generated music, but a SoundFont loaded by LoadSoundFont is
+// recommended for realistic sounds.
+//
+// DefaultSoundFont is concurrent-safe.
+func DefaultSoundFont() *SoundFont {
+	defaultSoundFontOnce.Do(func() {
+		defaultSoundFont = makeDefaultSoundFont()
+	})
+	return defaultSoundFont
+}
+
+const (
+	// cycleLength is the number of the samples in a cycle of the built-in waveforms.
+	cycleLength = 256
+
+	// cycleSampleRate is the sample rate of the built-in waveforms, where the cycle is played as C4.
+	cycleSampleRate = 66977
+
+	noiseSampleRate = 44100
+)
+
+type waveform int
+
+const (
+	waveSine waveform = iota
+	waveTriangle
+	waveSquare
+	waveSaw
+	waveNoise
+)
+
+// toTimecents converts seconds to timecents.
+func toTimecents(seconds float64) int32 {
+	if seconds <= 0 {
+		return -12000
+	}
+	return int32(math.Round(1200 * math.Log2(seconds)))
+}
+
+type defaultTone struct {
+	wave    waveform
+	attack  float64
+	decay   float64
+	sustain int32
+	release float64
+}
+
+// defaultTones is the tones of the GM program families with 8 programs each.
+var defaultTones = [16]defaultTone{
+	{wave: waveTriangle, decay: 2, sustain: 500, release: 0.3},            // Piano
+	{wave: waveSine, decay: 1, sustain: 960, release: 0.3},                // Chromatic percussion
+	{wave: waveSquare, attack: 0.01, release: 0.05},                       // Organ
+	{wave: waveSaw, decay: 1.5, sustain: 600, release: 0.2},               // Guitar
+	{wave: waveTriangle, decay: 0.8, sustain: 200, release: 0.1},          // Bass
+	{wave: waveSaw, attack: 0.1, release: 0.3},                            // Strings
+	{wave: waveSaw, attack: 0.15, release: 0.4},                           // Ensemble
+	{wave: waveSaw, attack: 0.03, release: 0.1},                           // Brass
+	{wave: waveSquare, attack: 0.03, release: 0.1},                        // Reed
+	{wave: waveSine, attack: 0.03, release: 0.1},                          // Pipe
+	{wave: waveSquare, release: 0.05},                                     // Synth lead
+	{wave: waveSaw, attack: 0.3, release: 0.6},                            // Synth pad
+	{wave: waveTriangle, attack: 0.1, release: 0.5},                       // Synth effects
+	{wave: waveTriangle, decay: 1, sustain: 600, release: 0.2},            // Ethnic
+	{wave: waveSine, decay: 0.3, sustain: 960, release: 0.1},              // Percussive
+	{wave: waveNoise, attack: 0.05, decay: 1, sustain: 200, release: 0.3}, // Sound effects
+}
+
+func makeDefaultSoundFont() *SoundFont {
+	s := &SoundFont{
+		presets: map[int]*preset{},
+	}
+
+	var samples [5]*sampleHeader
+	for w := waveSine; w <= waveNoise; w++ {
+		start := len(s.data)
+		s.data = append(s.data, makeWaveform(w)...)
+		h := &sampleHeader{
+			start:         start,
+			end:           len(s.data),
+			loopStart:     start,
+			loopEnd:       len(s.data),
+			sampleRate:    cycleSampleRate,
+			originalPitch: 60,
+		}
+		if w == waveNoise {
+			h.sampleRate = noiseSampleRate
+		}
+		samples[w] = h
+	}
+
+	newPreset := func(zones ...*zone) *preset {
+		var g generators
+		g[genKeyRange] = 127 << 8
+		g[genVelRange] = 127 << 8
+		return &preset{
+			zones: []*zone{{gens: g, instrument: &instrument{zones: zones}}},
+		}
+	}
+
+	for program := 0; program < 128; program++ {
+		t := defaultTones[program/8]
+		g := defaultGenerators()
+		g[genAttackVolEnv] = toTimecents(t.attack)
+		g[genDecayVolEnv] = toTimecents(t.decay)
+		g[genSustainVolEnv] = t.sustain
+		g[genReleaseVolEnv] = toTimecents(t.release)
+		g[genSampleModes] = 1
+		s.presets[presetKey(0, program)] = newPreset(&zone{gens: g, sample: samples[t.wave]})
+	}
+
+	// The drum kit.
+	drum := func(keyLo, keyHi int, w waveform, decay float64, coarseTune int32, exclusiveClass int32) *zone {
+		g := defaultGenerators()
+		g[genKeyRange] = int32(keyHi<<8 | keyLo)
+		g[genDecayVolEnv] = toTimecents(decay)
+		g[genSustainVolEnv] = 960
+		g[genReleaseVolEnv] = toTimecents(decay / 2)
+		g[genScaleTuning] = 0
+		g[genCoarseTune] = coarseTune
+		g[genExclusiveClass] = exclusiveClass
+		if w != waveNoise {
+			g[genSampleModes] = 1
+		}
+		return &zone{gens: g, sample: samples[w]}
+	}
+	s.presets[presetKey(percussionBank, 0)] = newPreset(
+		drum(0, 36, waveSine, 0.2, -29, 0),   // Bass drums
+		drum(37, 40, waveNoise, 0.15, 0, 0),  // Snares
+		drum(41, 41, waveSine, 0.3, -17, 0),  // Low floor tom
+		drum(42, 42, waveNoise, 0.05, 12, 1), // Closed hi-hat
+		drum(43, 43, waveSine, 0.3, -14, 0),  // High floor tom
+		drum(44, 44, waveNoise, 0.05, 12, 1), // Pedal hi-hat
+		drum(45, 45, waveSine, 0.3, -10, 0),  // Low tom
+		drum(46, 46, waveNoise, 0.4, 12, 1),  // Open hi-hat
+		drum(47, 48, waveSine, 0.3, -7, 0),   // Mid toms
+		drum(49, 49, waveNoise, 1.2, 0, 0),   // Crash cymbal
+		drum(50, 50, waveSine, 0.3, -3, 0),   // High tom
+		drum(51, 127, waveNoise, 0.8, 0, 0),  // Cymbals and others
+	)
+	return s
+}
+
+func makeWaveform(w waveform) []float32 {
+	if w == waveNoise {
+		// Generate deterministic white noise for one second.
+		r := make([]float32, noiseSampleRate)
+		var x uint32 = 1
+		for i := range r {
+			x ^= x << 13
+			x ^= x >> 17
+			x ^= x << 5
+			r[i] = float32(int32(x)) / (1 << 31) * 0.5
+		}
+		return r
+	}
+
+	r := make([]float32, cycleLength)
+	for i := range r {
+		t := float64(i) / cycleLength
+		var v float64
+		switch w {
+		case waveSine:
+			v = math.Sin(2 * math.Pi * t)
+		case waveTriangle:
+			v = 2*math.Abs(2*(t-math.Floor(t+0.5))) - 1
+		case waveSquare, waveSaw:
+			// Add the harmonics to avoid aliasing.
+			for k := 1; k <= 15; k++ {
+				if w == waveSquare && k%2 == 0 {
+					continue
+				}
+				v += math.Sin(2*math.Pi*float64(k)*t) / float64(k)
+			}
+			v *= 0.6
+		}
+		r[i] = float32(v * 0.5)
+	}
+	return r
+}
@@ -0,0 +1,226 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package midi provides a decoder for Standard MIDI Files with SoundFont synthesis.
+//
+// The MIDI data is rendered with a SoundFont into 16bit stereo PCM at the given sample rate.
+// A SoundFont 2 (SF2) file can be loaded by LoadSoundFont. DefaultSoundFont provides a small built-in SoundFont.
+package midi
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"sync"
+
+	"github.com/hajimehoshi/ebiten/v2/audio"
+)
+
+// tailDuration is the duration in seconds added to the end of the song for the released notes.
+const tailDuration = 1
+
+// Stream is a decoded audio stream of MIDI data.
+type Stream struct {
+	soundFont  *SoundFont
+	sampleRate int
+	events     []event
+	frames     []int64
+	length     int64
+
+	synth *synth
+	next  int
+	frame int64
+
+	pos  int64
+	buf  []float32
+	rest []byte
+
+	m sync.Mutex
+}
+
+// Read is implementation of io.Reader's Read.
+func (s *Stream) Read(p []byte) (int, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if len(s.rest) == 0 {
+		frames := int64(len(p) / 4)
+		if frames == 0 {
+			frames = 1
+		}
+		if rest := s.length/4 - s.frame; frames > rest {
+			frames = rest
+		}
+		if frames <= 0 {
+			return 0, io.EOF
+		}
+		if int64(cap(s.buf)) < frames*2 {
+			s.buf = make([]float32, frames*2)
+		}
+		buf := s.buf[:frames*2]
+		for i := range buf {
+			buf[i] = 0
+		}
+		s.render(buf, int(frames))
+
+		bs := make([]byte, frames*4)
+		for i, v := range buf {
+			if v > 1 {
+				v = 1
+			}
+			if v < -1 {
+				v = -1
+			}
+			binary.LittleEndian.PutUint16(bs[2*i:], uint16(int16(v*(1<<15-1))))
+		}
+		s.rest = bs
+	}
+
+	n := copy(p, s.rest)
+	s.rest = s.rest[n:]
+	s.pos += int64(n)
+	return n, nil
+}
+
+// render renders frames into dst, processing the events on the way. If dst is nil, render only advances the
+// states.
+func (s *Stream) render(dst []float32, frames int) {
+	for n := 0; n < frames; {
+		for s.next < len(s.events) && s.frames[s.next] <= s.frame {
+			s.synth.processEvent(&s.events[s.next])
+			s.next++
+		}
+		size := int64(frames - n)
+		if s.next < len(s.events) {
+			if f := s.frames[s.next] - s.frame; size > f {
+				size = f
+			}
+		}
+		var d []float32
+		if dst != nil {
+			d = dst[2*n : 2*(n+int(size))]
+		}
+		s.synth.render(d, int(size))
+		n += int(size)
+		s.frame += size
+	}
+}
+
+// Seek is implementation of io.Seeker's Seek.
+//
+// Note that Seek can take long since Seek replays the MIDI events from the beginning when seeking backward.
+func (s *Stream) Seek(offset int64, whence int) (int64, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += s.pos
+	case io.SeekEnd:
+		offset += s.length
+	default:
+		return 0, fmt.Errorf("midi: invalid whence: %d", whence)
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("midi: invalid offset: %d", offset)
+	}
+	offset &^= 3
+	if offset > s.length {
+		offset = s.length
+	}
+
+	// Drop the rendered bytes that are not read yet.
+	s.pos -= int64(len(s.rest))
+	s.rest = nil
+
+	if offset < s.pos {
+		s.reset()
+	}
+	s.render(nil, int((offset-s.pos)/4))
+	s.pos = offset
+	return s.pos, nil
+}
+
+func (s *Stream) reset() {
+	s.synth = newSynth(s.soundFont, s.sampleRate)
+	s.next = 0
+	s.frame = 0
+	s.pos = 0
+}
+
+// Length returns the size of decoded stream in bytes.
+//
+// The length includes a short tail after the last event for the released notes.
+func (s *Stream) Length() int64 {
+	return s.length
+}
+
+// SampleRate returns the sample rate of the decoded stream.
+func (s *Stream) SampleRate() int {
+	return s.sampleRate
+}
+
+// DecodeWithSampleRate decodes a Standard MIDI File to playable stream.
+//
+// The MIDI data is rendered with soundFont into 2 channels and 16bit at sampleRate.
+// If soundFont is nil, DefaultSoundFont is used.
+//
+// DecodeWithSampleRate reads all the data from src, and returns error when decoding fails or IO error happens.
+//
+// A Stream doesn't close src even if src implements io.Closer.
+// Closing the source is src owner's responsibility.
+func DecodeWithSampleRate(sampleRate int, src io.Reader, soundFont *SoundFont) (*Stream, error) {
+	if sampleRate <= 0 {
+		return nil, fmt.Errorf("midi: invalid sample rate: %d", sampleRate)
+	}
+
+	data, err := ioutil.ReadAll(src)
+	if err != nil {
+		return nil, err
+	}
+	events, end, err := parseSMF(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if soundFont == nil {
+		soundFont = DefaultSoundFont()
+	}
+	s := &Stream{
+		soundFont:  soundFont,
+		sampleRate: sampleRate,
+		events:     events,
+		frames:     make([]int64, len(events)),
+		length:     int64(math.Round((end+tailDuration)*float64(sampleRate))) * 4,
+	}
+	for i, e := range events {
+		s.frames[i] = int64(math.Round(e.time * float64(sampleRate)))
+	}
+	s.reset()
+	return s, nil
+}
+
+// Decode decodes a Standard MIDI File to playable stream.
+//
+// Decode is the same as DecodeWithSampleRate with the audio context's sample rate.
+func Decode(context *audio.Context, src io.Reader, soundFont *SoundFont) (*Stream, error) {
+	return DecodeWithSampleRate(context.SampleRate(), src, soundFont)
+}
@@ -0,0 +1,293 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package midi_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"math"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2/audio/midi"
+)
+
+const (
+	sampleRate = 44100
+	division   = 480
+)
+
+func makeSMF(format int, tracks ...[]byte) []byte {
+	var b bytes.Buffer
+	b.WriteString("MThd")
+	_ = binary.Write(&b, binary.BigEndian, []uint32{6})
+	_ = binary.Write(&b, binary.BigEndian, []uint16{uint16(format), uint16(len(tracks)), division})
+	for _, t := range tracks {
+		b.WriteString("MTrk")
+		_ = binary.Write(&b, binary.BigEndian, uint32(len(t)+4))
+		b.Write(t)
+		// End of track.
+		b.Write([]byte{0, 0xff, 0x2f, 0})
+	}
+	return b.Bytes()
+}
+
+func vlq(v int) []byte {
+	r := []byte{byte(v & 0x7f)}
+	for v >>= 7; v > 0; v >>= 7 {
+		r = append([]byte{byte(v&0x7f) | 0x80}, r...)
+	}
+	return r
+}
+
+func tempo(delta int, microseconds int) []byte {
+	return append(vlq(delta), 0xff, 0x51, 3, byte(microseconds>>16), byte(microseconds>>8), byte(microseconds))
+}
+
+func ev(delta int, bs ...byte) []byte {
+	return append(vlq(delta), bs...)
+}
+
+func concat(bss ...[]byte) []byte {
+	var r []byte
+	for _, bs := range bss {
+		r = append(r, bs...)
+	}
+	return r
+}
+
+func readAll(t *testing.T, s io.Reader) []int16 {
+	t.Helper()
+	bs, err := ioutil.ReadAll(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := make([]int16, len(bs)/2)
+	for i := range r {
+		r[i] = int16(binary.LittleEndian.Uint16(bs[2*i:]))
+	}
+	return r
+}
+
+// frequency estimates the frequency from the zero crossings of the left channel in [from, to) in seconds.
+func frequency(samples []int16, from, to float64) float64 {
+	var n int
+	for i := int(from*sampleRate) + 1; i < int(to*sampleRate); i++ {
+		if (samples[2*i-2] < 0) != (samples[2*i] < 0) {
+			n++
+		}
+	}
+	return float64(n) / 2 / (to - from)
+}
+
+func TestDecode(t *testing.T) {
+	// A sine-like program plays C4 for 1 second at 120 BPM.
+	track := concat(
+		tempo(0, 500000),
+		ev(0, 0xc0, 72),
+		ev(0, 0x90, 60, 127),
+		ev(2*division, 0x80, 60, 0),
+	)
+	s, err := midi.DecodeWithSampleRate(sampleRate, bytes.NewReader(makeSMF(0, track)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.Length(), int64(2*sampleRate*4); got != want {
+		t.Errorf("Length(): got: %d, want: %d", got, want)
+	}
+	samples := readAll(t, s)
+	if got, want := int64(len(samples)*2), s.Length(); got != want {
+		t.Errorf("the decoded size: got: %d, want: %d", got, want)
+	}
+	if got, want := frequency(samples, 0.1, 0.9), 261.6; math.Abs(got-want) > 3 {
+		t.Errorf("frequency: got: %f, want: %f", got, want)
+	}
+
+	// After the release, the sound should be silent.
+	for i := int(1.5 * sampleRate); i < len(samples)/2; i++ {
+		if samples[2*i] != 0 {
+			t.Fatalf("samples[%d]: got: %d, want: 0", 2*i, samples[2*i])
+		}
+	}
+}
+
+func TestDecodeTempoAndTracks(t *testing.T) {
+	// The conductor track changes the tempo from 120 BPM to 60 BPM after 1 second.
+	conductor := concat(
+		tempo(0, 500000),
+		tempo(2*division, 1000000),
+	)
+	// The note track uses running status. The second note starts after 3 seconds and the song ends after 4
+	// seconds.
+	notes := concat(
+		ev(0, 0xc0, 72),
+		ev(0, 0x90, 60, 100),
+		ev(4*division, 72, 100),
+		ev(0, 60, 0),
+		ev(division, 72, 0),
+	)
+	s, err := midi.DecodeWithSampleRate(sampleRate, bytes.NewReader(makeSMF(1, conductor, notes)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.Length(), int64((4+1)*sampleRate*4); got != want {
+		t.Errorf("Length(): got: %d, want: %d", got, want)
+	}
+	samples := readAll(t, s)
+	if got, want := frequency(samples, 0.1, 2.9), 261.6; math.Abs(got-want) > 3 {
+		t.Errorf("frequency: got: %f, want: %f", got, want)
+	}
+	if got, want := frequency(samples, 3.1, 3.9), 523.3; math.Abs(got-want) > 6 {
+		t.Errorf("frequency: got: %f, want: %f", got, want)
+	}
+}
+
+func TestSeek(t *testing.T) {
+	track := concat(
+		ev(0, 0x90, 60, 100),
+		ev(division/3, 0x99, 38, 100),
+		ev(division/3, 0x90, 64, 100),
+		ev(division/3, 0xe0, 0, 0x50),
+		ev(division, 0x80, 60, 0),
+		ev(0, 0x80, 64, 0),
+	)
+	s, err := midi.DecodeWithSampleRate(sampleRate, bytes.NewReader(makeSMF(0, track)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	all := readAll(t, s)
+
+	for _, offset := range []int64{sampleRate / 2 * 4, 1234 * 4, 0} {
+		if _, err := s.Seek(offset, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		got := readAll(t, s)
+		want := all[offset/2:]
+		if len(got) != len(want) {
+			t.Fatalf("offset: %d: length: got: %d, want: %d", offset, len(got), len(want))
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Fatalf("offset: %d: samples[%d]: got: %d, want: %d", offset, i, got[i], want[i])
+			}
+		}
+	}
+}
+
+func riffChunk(id string, data []byte) []byte {
+	b := []byte(id)
+	b = append(b, byte(len(data)), byte(len(data)>>8), byte(len(data)>>16), byte(len(data)>>24))
+	b = append(b, data...)
+	if len(data)%2 == 1 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+func listChunk(typ string, chunks ...[]byte) []byte {
+	return riffChunk("LIST", append([]byte(typ), concat(chunks...)...))
+}
+
+func le(vs ...interface{}) []byte {
+	var b bytes.Buffer
+	for _, v := range vs {
+		_ = binary.Write(&b, binary.LittleEndian, v)
+	}
+	return b.Bytes()
+}
+
+func name20(s string) []byte {
+	b := make([]byte, 20)
+	copy(b, s)
+	return b
+}
+
+// makeSoundFont makes a SoundFont with a looping sine wave played at 261.63 [Hz] for the key 60.
+func makeSoundFont() []byte {
+	const length = 100
+	var smpl []byte
+	for i := 0; i < length; i++ {
+		smpl = append(smpl, le(int16(math.Sin(2*math.Pi*float64(i)/length)*16000))...)
+	}
+	smpl = append(smpl, make([]byte, 46*2)...)
+
+	pdta := listChunk("pdta",
+		riffChunk("phdr", concat(
+			name20("Test"), le(uint16(0), uint16(0), uint16(0), uint32(0), uint32(0), uint32(0)),
+			name20("EOP"), le(uint16(0), uint16(0), uint16(1), uint32(0), uint32(0), uint32(0)),
+		)),
+		riffChunk("pbag", le(uint16(0), uint16(0), uint16(1), uint16(0))),
+		riffChunk("pmod", make([]byte, 10)),
+		riffChunk("pgen", le(uint16(41), uint16(0), uint16(0), uint16(0))),
+		riffChunk("inst", concat(name20("Sine"), le(uint16(0)), name20("EOI"), le(uint16(1)))),
+		riffChunk("ibag", le(uint16(0), uint16(0), uint16(2), uint16(0))),
+		riffChunk("imod", make([]byte, 10)),
+		riffChunk("igen", le(uint16(54), uint16(1), uint16(53), uint16(0), uint16(0), uint16(0))),
+		riffChunk("shdr", concat(
+			name20("Sine"), le(uint32(0), uint32(length), uint32(0), uint32(length), uint32(26163), uint8(60), int8(0), uint16(0), uint16(1)),
+			name20("EOS"), make([]byte, 26),
+		)),
+	)
+	body := concat(
+		[]byte("sfbk"),
+		listChunk("INFO", riffChunk("ifil", le(uint16(2), uint16(1)))),
+		listChunk("sdta", riffChunk("smpl", smpl)),
+		pdta,
+	)
+	return riffChunk("RIFF", body)
+}
+
+func TestLoadSoundFont(t *testing.T) {
+	sf, err := midi.LoadSoundFont(bytes.NewReader(makeSoundFont()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	track := concat(
+		ev(0, 0x90, 72, 127),
+		ev(2*division, 0x80, 72, 0),
+	)
+	s, err := midi.DecodeWithSampleRate(sampleRate, bytes.NewReader(makeSMF(0, track)), sf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples := readAll(t, s)
+	if got, want := frequency(samples, 0.1, 0.9), 523.3; math.Abs(got-want) > 6 {
+		t.Errorf("frequency: got: %f, want: %f", got, want)
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	for _, data := range [][]byte{
+		nil,
+		[]byte("MThd"),
+		makeSMF(0, []byte{0x00, 0x40}),
+		makeSMF(0, []byte{0x00, 0x90, 0x40})[:20],
+	} {
+		if _, err := midi.DecodeWithSampleRate(sampleRate, bytes.NewReader(data), nil); err == nil {
+			t.Errorf("DecodeWithSampleRate with %v must return an error", data)
+		}
+	}
+	for _, data := range [][]byte{
+		nil,
+		[]byte("RIFF\x04\x00\x00\x00sfbk"),
+		makeSoundFont()[:100],
+	} {
+		if _, err := midi.LoadSoundFont(bytes.NewReader(data)); err == nil {
+			t.Errorf("LoadSoundFont with %d bytes must return an error", len(data))
+		}
+	}
+}
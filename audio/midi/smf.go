@@ -0,0 +1,250 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package midi
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+// event is a MIDI channel event or a tempo change.
+type event struct {
+	// time is the time in seconds.
+	time float64
+
+	// status is the status byte. 0xff means a tempo change.
+	status byte
+	data1  byte
+	data2  byte
+
+	// tempo is the microseconds per quarter note for a tempo change.
+	tempo int
+}
+
+const statusTempo = 0xff
+
+type trackEvent struct {
+	tick  int64
+	track int
+	index int
+	event event
+}
+
+// readVLQ reads a variable-length quantity and returns the value and the number of the read bytes.
+func readVLQ(data []byte) (int, int, error) {
+	var v int
+	for i := 0; i < 4; i++ {
+		if i >= len(data) {
+			return 0, 0, fmt.Errorf("midi: unexpected end of a track")
+		}
+		b := data[i]
+		v = v<<7 | int(b&0x7f)
+		if b&0x80 == 0 {
+			return v, i + 1, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("midi: too long variable-length quantity")
+}
+
+// parseSMF parses a Standard MIDI File and returns the channel events in the time order and the end time in
+// seconds.
+func parseSMF(data []byte) ([]event, float64, error) {
+	if len(data) < 14 || string(data[:4]) != "MThd" {
+		return nil, 0, fmt.Errorf("midi: invalid MIDI header")
+	}
+	headerSize := int(binary.BigEndian.Uint32(data[4:]))
+	if headerSize < 6 || len(data) < 8+headerSize {
+		return nil, 0, fmt.Errorf("midi: invalid MIDI header")
+	}
+	format := binary.BigEndian.Uint16(data[8:])
+	trackNum := int(binary.BigEndian.Uint16(data[10:]))
+	division := binary.BigEndian.Uint16(data[12:])
+	if format > 2 {
+		return nil, 0, fmt.Errorf("midi: format %d is not supported", format)
+	}
+	if division == 0 {
+		return nil, 0, fmt.Errorf("midi: invalid division")
+	}
+
+	var tes []trackEvent
+	var endTick int64
+	offset := 8 + headerSize
+	for track := 0; track < trackNum; track++ {
+		if len(data) < offset+8 {
+			return nil, 0, fmt.Errorf("midi: track %d is missing", track)
+		}
+		id := string(data[offset : offset+4])
+		size := int(binary.BigEndian.Uint32(data[offset+4:]))
+		offset += 8
+		if len(data) < offset+size {
+			return nil, 0, fmt.Errorf("midi: track %d is too short", track)
+		}
+		if id != "MTrk" {
+			// Skip unknown chunks.
+			track--
+			offset += size
+			continue
+		}
+		// In format 2, the tracks are independent sequences. Play them one after another.
+		var base int64
+		if format == 2 {
+			base = endTick
+		}
+		evs, end, err := parseTrack(data[offset:offset+size], track, base)
+		if err != nil {
+			return nil, 0, err
+		}
+		tes = append(tes, evs...)
+		if endTick < end {
+			endTick = end
+		}
+		offset += size
+	}
+
+	sort.SliceStable(tes, func(i, j int) bool {
+		a, b := tes[i], tes[j]
+		if a.tick != b.tick {
+			return a.tick < b.tick
+		}
+		if a.track != b.track {
+			return a.track < b.track
+		}
+		return a.index < b.index
+	})
+
+	// Convert the ticks to seconds.
+	var secondsPerTick float64
+	if division&0x8000 != 0 {
+		// SMPTE time code.
+		fps := -int(int8(division >> 8))
+		if fps == 29 {
+			return nil, 0, fmt.Errorf("midi: 29.97 fps is not supported")
+		}
+		secondsPerTick = 1 / float64(fps*int(division&0xff))
+	} else {
+		secondsPerTick = 0.5 / float64(division)
+	}
+
+	var t float64
+	var lastTick int64
+	var evs []event
+	for _, te := range tes {
+		t += float64(te.tick-lastTick) * secondsPerTick
+		lastTick = te.tick
+		e := te.event
+		e.time = t
+		if e.status == statusTempo {
+			if division&0x8000 == 0 {
+				secondsPerTick = float64(e.tempo) / 1e6 / float64(division)
+			}
+			continue
+		}
+		evs = append(evs, e)
+	}
+	t += float64(endTick-lastTick) * secondsPerTick
+	return evs, t, nil
+}
+
+// parseTrack parses a track chunk and returns the events and the end tick.
+func parseTrack(data []byte, track int, tick int64) ([]trackEvent, int64, error) {
+	var tes []trackEvent
+	var status byte
+	for i := 0; i < len(data); {
+		delta, n, err := readVLQ(data[i:])
+		if err != nil {
+			return nil, 0, err
+		}
+		i += n
+		tick += int64(delta)
+		if i >= len(data) {
+			return nil, 0, fmt.Errorf("midi: unexpected end of track %d", track)
+		}
+
+		b := data[i]
+		switch {
+		case b == 0xff:
+			// A meta event.
+			if i+2 > len(data) {
+				return nil, 0, fmt.Errorf("midi: unexpected end of track %d", track)
+			}
+			typ := data[i+1]
+			size, n, err := readVLQ(data[i+2:])
+			if err != nil {
+				return nil, 0, err
+			}
+			body := i + 2 + n
+			if body+size > len(data) {
+				return nil, 0, fmt.Errorf("midi: unexpected end of track %d", track)
+			}
+			switch typ {
+			case 0x2f:
+				// End of track.
+				return tes, tick, nil
+			case 0x51:
+				if size >= 3 {
+					d := data[body:]
+					tes = append(tes, trackEvent{
+						tick:  tick,
+						track: track,
+						index: len(tes),
+						event: event{
+							status: statusTempo,
+							tempo:  int(d[0])<<16 | int(d[1])<<8 | int(d[2]),
+						},
+					})
+				}
+			}
+			i = body + size
+			continue
+		case b == 0xf0 || b == 0xf7:
+			// A system exclusive event. Skip this.
+			size, n, err := readVLQ(data[i+1:])
+			if err != nil {
+				return nil, 0, err
+			}
+			i += 1 + n + size
+			continue
+		case b > 0xf0:
+			return nil, 0, fmt.Errorf("midi: unexpected status %#02x in track %d", b, track)
+		case b&0x80 != 0:
+			status = b
+			i++
+		case status == 0:
+			return nil, 0, fmt.Errorf("midi: running status without a status in track %d", track)
+		}
+
+		e := event{status: status}
+		size := 2
+		if s := status & 0xf0; s == 0xc0 || s == 0xd0 {
+			size = 1
+		}
+		if i+size > len(data) {
+			return nil, 0, fmt.Errorf("midi: unexpected end of track %d", track)
+		}
+		e.data1 = data[i] & 0x7f
+		if size == 2 {
+			e.data2 = data[i+1] & 0x7f
+		}
+		i += size
+		tes = append(tes, trackEvent{
+			tick:  tick,
+			track: track,
+			index: len(tes),
+			event: e,
+		})
+	}
+	return tes, tick, nil
+}
@@ -0,0 +1,402 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package midi
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// Generator operators of SoundFont 2.
+const (
+	genStartAddrsOffset           = 0
+	genEndAddrsOffset             = 1
+	genStartloopAddrsOffset       = 2
+	genEndloopAddrsOffset         = 3
+	genStartAddrsCoarseOffset     = 4
+	genEndAddrsCoarseOffset       = 12
+	genPan                        = 17
+	genDelayVolEnv                = 33
+	genAttackVolEnv               = 34
+	genHoldVolEnv                 = 35
+	genDecayVolEnv                = 36
+	genSustainVolEnv              = 37
+	genReleaseVolEnv              = 38
+	genInstrument                 = 41
+	genKeyRange                   = 43
+	genVelRange                   = 44
+	genStartloopAddrsCoarseOffset = 45
+	genKeynum                     = 46
+	genVelocity                   = 47
+	genInitialAttenuation         = 48
+	genEndloopAddrsCoarseOffset   = 50
+	genCoarseTune                 = 51
+	genFineTune                   = 52
+	genSampleID                   = 53
+	genSampleModes                = 54
+	genScaleTuning                = 56
+	genExclusiveClass             = 57
+	genOverridingRootKey          = 58
+
+	genCount = 61
+)
+
+// generators is a set of generator values.
+type generators [genCount]int32
+
+// defaultGenerators returns the default values of an instrument zone.
+func defaultGenerators() generators {
+	var g generators
+	g[genDelayVolEnv] = -12000
+	g[genAttackVolEnv] = -12000
+	g[genHoldVolEnv] = -12000
+	g[genDecayVolEnv] = -12000
+	g[genReleaseVolEnv] = -12000
+	g[genKeyRange] = 127 << 8
+	g[genVelRange] = 127 << 8
+	g[genKeynum] = -1
+	g[genVelocity] = -1
+	g[genScaleTuning] = 100
+	g[genOverridingRootKey] = -1
+	return g
+}
+
+// isPresetAdditive reports whether the generator's value in a preset zone is added to the instrument's value.
+func isPresetAdditive(op int) bool {
+	switch op {
+	case genStartAddrsOffset, genEndAddrsOffset, genStartloopAddrsOffset, genEndloopAddrsOffset,
+		genStartAddrsCoarseOffset, genEndAddrsCoarseOffset, genStartloopAddrsCoarseOffset,
+		genEndloopAddrsCoarseOffset, genInstrument, genKeyRange, genVelRange, genKeynum, genVelocity,
+		genSampleID, genSampleModes, genExclusiveClass, genOverridingRootKey:
+		return false
+	}
+	return true
+}
+
+type zone struct {
+	gens generators
+
+	// instrument is the instrument of a preset zone.
+	instrument *instrument
+
+	// sample is the sample of an instrument zone.
+	sample *sampleHeader
+}
+
+func (z *zone) contains(key, velocity int) bool {
+	kr, vr := z.gens[genKeyRange], z.gens[genVelRange]
+	return int(kr&0xff) <= key && key <= int(kr>>8) && int(vr&0xff) <= velocity && velocity <= int(vr>>8)
+}
+
+type instrument struct {
+	zones []*zone
+}
+
+type preset struct {
+	zones []*zone
+}
+
+type sampleHeader struct {
+	start     int
+	end       int
+	loopStart int
+	loopEnd   int
+
+	sampleRate      int
+	originalPitch   int
+	pitchCorrection int
+}
+
+// SoundFont is a set of instruments to play MIDI data.
+//
+// A SoundFont is immutable and can be shared among multiple streams.
+type SoundFont struct {
+	// data is the sample data in [-1, 1].
+	data []float32
+
+	presets map[int]*preset
+}
+
+func presetKey(bank, program int) int {
+	return bank<<8 | program
+}
+
+// preset returns the preset for the bank and the program.
+// If the preset doesn't exist, preset falls back to the bank 0, and then the program 0.
+func (s *SoundFont) preset(bank, program int) *preset {
+	if p, ok := s.presets[presetKey(bank, program)]; ok {
+		return p
+	}
+	if bank == percussionBank {
+		if p, ok := s.presets[presetKey(bank, 0)]; ok {
+			return p
+		}
+		return nil
+	}
+	if p, ok := s.presets[presetKey(0, program)]; ok {
+		return p
+	}
+	return s.presets[presetKey(0, 0)]
+}
+
+// LoadSoundFont loads a SoundFont 2 (SF2) data.
+//
+// Only 16bit samples are used. The modulators, the modulation envelope, the LFOs and the filter are not
+// supported.
+//
+// LoadSoundFont reads all the data from src, and returns error when parsing fails or IO error happens.
+func LoadSoundFont(src io.Reader) (*SoundFont, error) {
+	data, err := ioutil.ReadAll(src)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "sfbk" {
+		return nil, fmt.Errorf("midi: invalid SoundFont header")
+	}
+
+	lists, err := readChunks(data[12:])
+	if err != nil {
+		return nil, err
+	}
+	var sdta, pdta []byte
+	for _, l := range lists {
+		if l.id != "LIST" || len(l.data) < 4 {
+			continue
+		}
+		switch string(l.data[:4]) {
+		case "sdta":
+			sdta = l.data[4:]
+		case "pdta":
+			pdta = l.data[4:]
+		}
+	}
+	if sdta == nil || pdta == nil {
+		return nil, fmt.Errorf("midi: SoundFont must have sdta and pdta chunks")
+	}
+
+	s := &SoundFont{
+		presets: map[int]*preset{},
+	}
+
+	chunks, err := readChunks(sdta)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range chunks {
+		if c.id != "smpl" {
+			continue
+		}
+		s.data = make([]float32, len(c.data)/2)
+		for i := range s.data {
+			s.data[i] = float32(int16(binary.LittleEndian.Uint16(c.data[2*i:]))) / (1 << 15)
+		}
+	}
+
+	chunks, err = readChunks(pdta)
+	if err != nil {
+		return nil, err
+	}
+	hydra := map[string][]byte{}
+	for _, c := range chunks {
+		hydra[c.id] = c.data
+	}
+	if err := s.loadHydra(hydra); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+type chunk struct {
+	id   string
+	data []byte
+}
+
+func readChunks(data []byte) ([]chunk, error) {
+	var cs []chunk
+	for len(data) >= 8 {
+		id := string(data[:4])
+		size := int(binary.LittleEndian.Uint32(data[4:]))
+		data = data[8:]
+		if size > len(data) {
+			return nil, fmt.Errorf("midi: SoundFont chunk %q is too short", id)
+		}
+		cs = append(cs, chunk{id: id, data: data[:size]})
+		if size%2 == 1 && size < len(data) {
+			size++
+		}
+		data = data[size:]
+	}
+	return cs, nil
+}
+
+// records splits the hydra chunk into records with the given size. The last terminal record is included.
+func records(hydra map[string][]byte, id string, size int) ([][]byte, error) {
+	data := hydra[id]
+	if len(data)%size != 0 || len(data) < size {
+		return nil, fmt.Errorf("midi: invalid SoundFont %s chunk", id)
+	}
+	rs := make([][]byte, len(data)/size)
+	for i := range rs {
+		rs[i] = data[i*size : (i+1)*size]
+	}
+	return rs, nil
+}
+
+func (s *SoundFont) loadHydra(hydra map[string][]byte) error {
+	phdr, err := records(hydra, "phdr", 38)
+	if err != nil {
+		return err
+	}
+	pbag, err := records(hydra, "pbag", 4)
+	if err != nil {
+		return err
+	}
+	pgen, err := records(hydra, "pgen", 4)
+	if err != nil {
+		return err
+	}
+	inst, err := records(hydra, "inst", 22)
+	if err != nil {
+		return err
+	}
+	ibag, err := records(hydra, "ibag", 4)
+	if err != nil {
+		return err
+	}
+	igen, err := records(hydra, "igen", 4)
+	if err != nil {
+		return err
+	}
+	shdr, err := records(hydra, "shdr", 46)
+	if err != nil {
+		return err
+	}
+
+	var samples []*sampleHeader
+	for _, r := range shdr[:len(shdr)-1] {
+		h := &sampleHeader{
+			start:           int(binary.LittleEndian.Uint32(r[20:])),
+			end:             int(binary.LittleEndian.Uint32(r[24:])),
+			loopStart:       int(binary.LittleEndian.Uint32(r[28:])),
+			loopEnd:         int(binary.LittleEndian.Uint32(r[32:])),
+			sampleRate:      int(binary.LittleEndian.Uint32(r[36:])),
+			originalPitch:   int(r[40]),
+			pitchCorrection: int(int8(r[41])),
+		}
+		if typ := binary.LittleEndian.Uint16(r[44:]); typ&0x8000 != 0 || h.end > len(s.data) || h.start > h.end {
+			// ROM samples and broken samples are not available.
+			h = nil
+		}
+		if h != nil && h.originalPitch > 127 {
+			h.originalPitch = 60
+		}
+		samples = append(samples, h)
+	}
+
+	var instruments []*instrument
+	for i := 0; i < len(inst)-1; i++ {
+		from := int(binary.LittleEndian.Uint16(inst[i][20:]))
+		to := int(binary.LittleEndian.Uint16(inst[i+1][20:]))
+		zs, err := loadZones(ibag, igen, from, to, genSampleID, defaultGenerators())
+		if err != nil {
+			return err
+		}
+		in := &instrument{}
+		for _, z := range zs {
+			idx := int(z.gens[genSampleID])
+			if idx < 0 || idx >= len(samples) || samples[idx] == nil {
+				continue
+			}
+			z.sample = samples[idx]
+			in.zones = append(in.zones, z)
+		}
+		instruments = append(instruments, in)
+	}
+
+	for i := 0; i < len(phdr)-1; i++ {
+		program := int(binary.LittleEndian.Uint16(phdr[i][20:]))
+		bank := int(binary.LittleEndian.Uint16(phdr[i][22:]))
+		from := int(binary.LittleEndian.Uint16(phdr[i][24:]))
+		to := int(binary.LittleEndian.Uint16(phdr[i+1][24:]))
+		var g generators
+		g[genKeyRange] = 127 << 8
+		g[genVelRange] = 127 << 8
+		zs, err := loadZones(pbag, pgen, from, to, genInstrument, g)
+		if err != nil {
+			return err
+		}
+		p := &preset{}
+		for _, z := range zs {
+			idx := int(z.gens[genInstrument])
+			if idx < 0 || idx >= len(instruments) {
+				continue
+			}
+			z.instrument = instruments[idx]
+			p.zones = append(p.zones, z)
+		}
+		key := presetKey(bank, program)
+		if _, ok := s.presets[key]; !ok {
+			s.presets[key] = p
+		}
+	}
+	return nil
+}
+
+// loadZones loads the zones in the bags [from, to). terminal is the generator that terminates a zone.
+// A zone without the terminal generator is a global zone, and its generators are applied to the other zones.
+func loadZones(bags, gens [][]byte, from, to int, terminal int, defaults generators) ([]*zone, error) {
+	if from > to || to >= len(bags) {
+		return nil, fmt.Errorf("midi: invalid SoundFont bag index")
+	}
+
+	global := defaults
+	var zs []*zone
+	for i := from; i < to; i++ {
+		genFrom := int(binary.LittleEndian.Uint16(bags[i]))
+		genTo := int(binary.LittleEndian.Uint16(bags[i+1]))
+		if genFrom > genTo || genTo > len(gens) {
+			return nil, fmt.Errorf("midi: invalid SoundFont generator index")
+		}
+
+		z := &zone{gens: global}
+		hasTerminal := false
+		for _, g := range gens[genFrom:genTo] {
+			op := int(binary.LittleEndian.Uint16(g))
+			if op >= genCount {
+				continue
+			}
+			switch op {
+			case genKeyRange, genVelRange, genInstrument, genSampleID:
+				// Ranges are two bytes. Indices are unsigned.
+				z.gens[op] = int32(binary.LittleEndian.Uint16(g[2:]))
+			default:
+				z.gens[op] = int32(int16(binary.LittleEndian.Uint16(g[2:])))
+			}
+			if op == terminal {
+				hasTerminal = true
+			}
+		}
+		if hasTerminal {
+			zs = append(zs, z)
+			continue
+		}
+		if i == from {
+			global = z.gens
+		}
+	}
+	return zs, nil
+}
@@ -0,0 +1,563 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package midi
+
+import (
+	"math"
+)
+
+const (
+	percussionChannel = 9
+	percussionBank    = 128
+
+	// maxVoices is the maximum number of the voices played at the same time.
+	maxVoices = 256
+
+	// blockSize is the number of the frames to update the envelopes.
+	blockSize = 64
+
+	// masterGain is the gain applied to the mixed voices.
+	masterGain = 0.3
+
+	// silentAttenuation is the attenuation in centibels where a voice is regarded as silent.
+	silentAttenuation = 960
+)
+
+type channelState struct {
+	bank           int
+	program        int
+	volume         int
+	expression     int
+	pan            int
+	pitchBend      int
+	pitchBendRange float64
+	rpn            int
+	sustain        bool
+}
+
+func (c *channelState) reset(ch int) {
+	*c = channelState{}
+	if ch == percussionChannel {
+		c.bank = percussionBank
+	}
+	c.resetControllers()
+}
+
+func (c *channelState) resetControllers() {
+	c.volume = 100
+	c.expression = 127
+	c.pan = 64
+	c.pitchBend = 0
+	c.pitchBendRange = 2
+	c.rpn = 0x3fff
+	c.sustain = false
+}
+
+type envelopeStage int
+
+const (
+	stageDelay envelopeStage = iota
+	stageAttack
+	stageHold
+	stageDecay
+	stageSustain
+	stageRelease
+	stageDone
+)
+
+type voice struct {
+	channel int
+	key     int
+
+	pos       float64
+	end       int
+	loopStart int
+	loopEnd   int
+	loop      bool
+
+	// loopUntilRelease reports whether the loop is exited when the key is released.
+	loopUntilRelease bool
+
+	// step is the position increment per frame without the pitch bend.
+	step float64
+
+	gain    float32
+	panning float64
+
+	exclusiveClass int
+	released       bool
+	sustained      bool
+
+	stage        envelopeStage
+	stageTime    float64
+	delay        float64
+	attack       float64
+	hold         float64
+	decay        float64
+	sustainLevel float64
+	release      float64
+
+	// attenuation is the attenuation in centibels in the decay, sustain and release stages.
+	attenuation float64
+
+	// amplitude is the envelope's amplitude at the end of the current block.
+	amplitude float64
+
+	// gainEnvelope is the current envelope's amplitude interpolated in the block, and gainEnvelopeDelta is its
+	// increment per frame.
+	gainEnvelope      float32
+	gainEnvelopeDelta float32
+}
+
+// timecents converts timecents to seconds.
+func timecents(tc int32) float64 {
+	if tc <= -12000 {
+		return 0
+	}
+	return math.Pow(2, float64(tc)/1200)
+}
+
+func (v *voice) noteOff() {
+	v.released = true
+	v.sustained = false
+	if v.stage < stageRelease {
+		if v.stage < stageDecay {
+			v.attenuation = -200 * math.Log10(math.Max(v.envelopeAmplitude(), 1e-5))
+		}
+		v.stage = stageRelease
+		v.stageTime = 0
+	}
+	if v.loopUntilRelease {
+		v.loop = false
+	}
+}
+
+func (v *voice) envelopeAmplitude() float64 {
+	switch v.stage {
+	case stageDelay:
+		return 0
+	case stageAttack:
+		if v.attack == 0 {
+			return 1
+		}
+		return math.Min(v.stageTime/v.attack, 1)
+	case stageHold:
+		return 1
+	case stageDone:
+		return 0
+	}
+	return math.Pow(10, -v.attenuation/200)
+}
+
+// updateEnvelope advances the envelope by dt seconds.
+func (v *voice) updateEnvelope(dt float64) {
+	v.stageTime += dt
+	for {
+		switch v.stage {
+		case stageDelay:
+			if v.stageTime < v.delay {
+				return
+			}
+			v.stageTime -= v.delay
+			v.stage = stageAttack
+		case stageAttack:
+			if v.stageTime < v.attack {
+				return
+			}
+			v.stageTime -= v.attack
+			v.stage = stageHold
+		case stageHold:
+			if v.stageTime < v.hold {
+				return
+			}
+			v.stageTime -= v.hold
+			v.stage = stageDecay
+			v.attenuation = 0
+		case stageDecay:
+			if v.decay == 0 {
+				v.attenuation = v.sustainLevel
+			} else {
+				v.attenuation = math.Min(v.stageTime/v.decay*silentAttenuation, v.sustainLevel)
+			}
+			if v.attenuation < v.sustainLevel {
+				return
+			}
+			v.stage = stageSustain
+		case stageSustain:
+			if v.attenuation >= silentAttenuation {
+				v.stage = stageDone
+			}
+			return
+		case stageRelease:
+			if v.release == 0 {
+				v.attenuation = silentAttenuation
+			} else {
+				v.attenuation += dt / v.release * silentAttenuation
+			}
+			v.stageTime = 0
+			if v.attenuation >= silentAttenuation {
+				v.stage = stageDone
+			}
+			return
+		case stageDone:
+			return
+		}
+	}
+}
+
+// advance advances the position by delta.
+func (v *voice) advance(delta float64) {
+	v.pos += delta
+	if v.loop {
+		if v.pos >= float64(v.loopEnd) {
+			l := float64(v.loopEnd - v.loopStart)
+			v.pos = float64(v.loopStart) + math.Mod(v.pos-float64(v.loopStart), l)
+		}
+		return
+	}
+	if v.pos >= float64(v.end) {
+		v.stage = stageDone
+	}
+}
+
+type synth struct {
+	soundFont  *SoundFont
+	sampleRate int
+	channels   [16]channelState
+	voices     []*voice
+
+	// blockRest is the number of the rest frames in the current block.
+	blockRest int
+}
+
+func newSynth(soundFont *SoundFont, sampleRate int) *synth {
+	s := &synth{
+		soundFont:  soundFont,
+		sampleRate: sampleRate,
+	}
+	for i := range s.channels {
+		s.channels[i].reset(i)
+	}
+	return s
+}
+
+func (s *synth) processEvent(e *event) {
+	ch := int(e.status & 0x0f)
+	c := &s.channels[ch]
+	switch e.status & 0xf0 {
+	case 0x80:
+		s.noteOff(ch, int(e.data1))
+	case 0x90:
+		if e.data2 == 0 {
+			s.noteOff(ch, int(e.data1))
+			return
+		}
+		s.noteOn(ch, int(e.data1), int(e.data2))
+	case 0xb0:
+		s.controlChange(ch, int(e.data1), int(e.data2))
+	case 0xc0:
+		c.program = int(e.data1)
+	case 0xe0:
+		c.pitchBend = int(e.data1) | int(e.data2)<<7 - 8192
+	}
+}
+
+func (s *synth) controlChange(ch int, controller, value int) {
+	c := &s.channels[ch]
+	switch controller {
+	case 0:
+		if ch != percussionChannel {
+			c.bank = value
+		}
+	case 6:
+		if c.rpn == 0 {
+			c.pitchBendRange = float64(value) + math.Mod(c.pitchBendRange, 1)
+		}
+	case 38:
+		if c.rpn == 0 {
+			c.pitchBendRange = math.Floor(c.pitchBendRange) + float64(value)/100
+		}
+	case 7:
+		c.volume = value
+	case 10:
+		c.pan = value
+	case 11:
+		c.expression = value
+	case 64:
+		c.sustain = value >= 64
+		if !c.sustain {
+			for _, v := range s.voices {
+				if v.channel == ch && v.sustained {
+					v.noteOff()
+				}
+			}
+		}
+	case 100:
+		c.rpn = c.rpn&^0x7f | value
+	case 101:
+		c.rpn = c.rpn&0x7f | value<<7
+	case 120:
+		// All sound off.
+		s.removeVoices(func(v *voice) bool {
+			return v.channel == ch
+		})
+	case 121:
+		c.resetControllers()
+	case 123:
+		// All notes off.
+		for _, v := range s.voices {
+			if v.channel == ch && !v.released {
+				v.noteOff()
+			}
+		}
+	}
+}
+
+func (s *synth) removeVoices(f func(v *voice) bool) {
+	vs := s.voices[:0]
+	for _, v := range s.voices {
+		if !f(v) {
+			vs = append(vs, v)
+		}
+	}
+	for i := len(vs); i < len(s.voices); i++ {
+		s.voices[i] = nil
+	}
+	s.voices = vs
+}
+
+func (s *synth) noteOff(ch int, key int) {
+	for _, v := range s.voices {
+		if v.channel != ch || v.key != key || v.released || v.sustained {
+			continue
+		}
+		if s.channels[ch].sustain {
+			v.sustained = true
+			continue
+		}
+		v.noteOff()
+	}
+}
+
+func (s *synth) noteOn(ch int, key, velocity int) {
+	c := &s.channels[ch]
+	p := s.soundFont.preset(c.bank, c.program)
+	if p == nil {
+		return
+	}
+
+	// Release the same note that is still played.
+	for _, v := range s.voices {
+		if v.channel == ch && v.key == key && !v.released {
+			v.noteOff()
+		}
+	}
+
+	for _, pz := range p.zones {
+		if !pz.contains(key, velocity) {
+			continue
+		}
+		for _, iz := range pz.instrument.zones {
+			if !iz.contains(key, velocity) {
+				continue
+			}
+			var g generators
+			for i := range g {
+				g[i] = iz.gens[i]
+				if isPresetAdditive(i) {
+					g[i] += pz.gens[i]
+				}
+			}
+			v := s.newVoice(ch, key, velocity, &g, iz.sample)
+			if v == nil {
+				continue
+			}
+			if v.exclusiveClass != 0 {
+				s.removeVoices(func(v2 *voice) bool {
+					return v2.channel == ch && v2.exclusiveClass == v.exclusiveClass
+				})
+			}
+			if len(s.voices) >= maxVoices {
+				// Steal the oldest voice.
+				copy(s.voices, s.voices[1:])
+				s.voices = s.voices[:len(s.voices)-1]
+			}
+			s.voices = append(s.voices, v)
+		}
+	}
+}
+
+func clamp(x, min, max int) int {
+	if x < min {
+		return min
+	}
+	if x > max {
+		return max
+	}
+	return x
+}
+
+func (s *synth) newVoice(ch int, key, velocity int, g *generators, sh *sampleHeader) *voice {
+	n := len(s.soundFont.data)
+	start := clamp(sh.start+int(g[genStartAddrsOffset])+int(g[genStartAddrsCoarseOffset])*32768, 0, n)
+	end := clamp(sh.end+int(g[genEndAddrsOffset])+int(g[genEndAddrsCoarseOffset])*32768, 0, n)
+	loopStart := clamp(sh.loopStart+int(g[genStartloopAddrsOffset])+int(g[genStartloopAddrsCoarseOffset])*32768, 0, n)
+	loopEnd := clamp(sh.loopEnd+int(g[genEndloopAddrsOffset])+int(g[genEndloopAddrsCoarseOffset])*32768, 0, n)
+	if start >= end || sh.sampleRate == 0 {
+		return nil
+	}
+
+	if k := int(g[genKeynum]); k >= 0 && k <= 127 {
+		key = k
+	}
+	if vel := int(g[genVelocity]); vel > 0 && vel <= 127 {
+		velocity = vel
+	}
+
+	rootKey := sh.originalPitch
+	if k := int(g[genOverridingRootKey]); k >= 0 && k <= 127 {
+		rootKey = k
+	}
+	cents := float64(key-rootKey)*float64(g[genScaleTuning]) + float64(g[genCoarseTune])*100 + float64(g[genFineTune]) + float64(sh.pitchCorrection)
+
+	// The attenuation is scaled by 0.4 as the other synthesizers do for the compatibility with EMU's hardware.
+	attenuation := math.Max(0, float64(g[genInitialAttenuation])) * 0.4
+	vel := float64(velocity) / 127
+
+	mode := g[genSampleModes] & 3
+	v := &voice{
+		channel:          ch,
+		key:              key,
+		pos:              float64(start),
+		end:              end,
+		loopStart:        loopStart,
+		loopEnd:          loopEnd,
+		loop:             (mode == 1 || mode == 3) && loopStart+1 < loopEnd,
+		loopUntilRelease: mode == 3,
+		step:             math.Pow(2, cents/1200) * float64(sh.sampleRate) / float64(s.sampleRate),
+		gain:             float32(math.Pow(10, -attenuation/200) * vel * vel),
+		panning:          float64(g[genPan]),
+		exclusiveClass:   int(g[genExclusiveClass]),
+		delay:            timecents(g[genDelayVolEnv]),
+		attack:           timecents(g[genAttackVolEnv]),
+		hold:             timecents(g[genHoldVolEnv]),
+		decay:            timecents(g[genDecayVolEnv]),
+		sustainLevel:     math.Max(0, float64(g[genSustainVolEnv])),
+		release:          timecents(g[genReleaseVolEnv]),
+	}
+	if v.delay == 0 {
+		v.stage = stageAttack
+	}
+	v.amplitude = v.envelopeAmplitude()
+	v.gainEnvelope = float32(v.amplitude)
+	return v
+}
+
+// render renders frames into dst as stereo samples. If dst is nil, render only advances the states.
+//
+// The envelopes are updated at the fixed intervals regardless of how the frames are split, so that the result
+// doesn't depend on the sizes of the rendering.
+func (s *synth) render(dst []float32, frames int) {
+	for offset := 0; offset < frames; {
+		if s.blockRest == 0 {
+			s.startBlock()
+		}
+		n := frames - offset
+		if n > s.blockRest {
+			n = s.blockRest
+		}
+		var d []float32
+		if dst != nil {
+			d = dst[2*offset : 2*(offset+n)]
+		}
+		s.mix(d, n)
+		offset += n
+		s.blockRest -= n
+	}
+}
+
+// startBlock updates the envelopes for the next block.
+func (s *synth) startBlock() {
+	s.removeVoices(func(v *voice) bool {
+		return v.stage == stageDone
+	})
+	dt := float64(blockSize) / float64(s.sampleRate)
+	for _, v := range s.voices {
+		from := v.amplitude
+		v.updateEnvelope(dt)
+		v.amplitude = v.envelopeAmplitude()
+		v.gainEnvelope = float32(from)
+		v.gainEnvelopeDelta = float32(v.amplitude-from) / blockSize
+	}
+	s.blockRest = blockSize
+}
+
+// mix mixes the voices into dst. If dst is nil, mix only advances the states.
+func (s *synth) mix(dst []float32, frames int) {
+	data := s.soundFont.data
+	for _, v := range s.voices {
+		if v.stage == stageDone {
+			continue
+		}
+		c := &s.channels[v.channel]
+		step := v.step
+		if c.pitchBend != 0 {
+			step *= math.Pow(2, float64(c.pitchBend)/8192*c.pitchBendRange/12)
+		}
+
+		if dst == nil {
+			// Advance the states frame by frame so that the result is exactly the same as mixing.
+			for i := 0; i < frames && v.stage != stageDone; i++ {
+				v.gainEnvelope += v.gainEnvelopeDelta
+				v.advance(step)
+			}
+			continue
+		}
+
+		vol := float64(c.volume) / 127 * float64(c.expression) / 127
+		pan := v.panning + float64(c.pan-64)/64*500
+		theta := (math.Max(-500, math.Min(pan, 500)) + 500) / 1000 * math.Pi / 2
+		gain := v.gain * float32(vol*vol) * masterGain
+		gainL := gain * float32(math.Cos(theta))
+		gainR := gain * float32(math.Sin(theta))
+
+		for i := 0; i < frames; i++ {
+			idx := int(v.pos)
+			if idx >= len(data) {
+				v.stage = stageDone
+				break
+			}
+			frac := float32(v.pos - float64(idx))
+			next := idx + 1
+			if v.loop && next >= v.loopEnd {
+				next = v.loopStart
+			}
+			v0 := data[idx]
+			v1 := v0
+			if next < len(data) {
+				v1 = data[next]
+			}
+			x := (v0 + (v1-v0)*frac) * v.gainEnvelope
+			dst[2*i] += x * gainL
+			dst[2*i+1] += x * gainR
+			v.gainEnvelope += v.gainEnvelopeDelta
+
+			v.advance(step)
+			if v.stage == stageDone {
+				break
+			}
+		}
+	}
+}
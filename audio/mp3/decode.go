@@ -14,27 +14,44 @@
 
 // Package mp3 provides MP3 decoder.
 //
-// On desktops and mobiles, a pure Go decoder is used.
-// On browsers, a native decoder on the browser is used.
+// On desktops and mobiles, a pure Go decoder is used. The pure Go decoder decodes frames on demand, and then a long
+// file doesn't consume much memory.
+// On browsers, a native decoder on the browser is used. The native decoder decodes the whole data on a background
+// thread, and then a large file doesn't block the game. If the native decoder fails, the pure Go decoder is used.
+// As the native decoder keeps the whole decoded data, use DecodeWithOptions for a long file like background music.
 package mp3
 
 import (
+	"bytes"
 	"io"
-
-	"github.com/hajimehoshi/go-mp3"
+	"io/ioutil"
+	"time"
 
 	"github.com/hajimehoshi/ebiten/v2/audio"
+	"github.com/hajimehoshi/ebiten/v2/audio/internal/browserdecoder"
 	"github.com/hajimehoshi/ebiten/v2/audio/internal/convert"
 )
 
 // Stream is a decoded stream.
 type Stream struct {
-	orig       *mp3.Decoder
+	orig       *stream
 	resampling *convert.Resampling
+	readAhead  *convert.ReadAhead
+
+	// decoded is the whole data decoded by the browser's decoder.
+	decoded *bytes.Reader
+
+	sampleRate int
 }
 
 // Read is implementation of io.Reader's Read.
 func (s *Stream) Read(buf []byte) (int, error) {
+	if s.decoded != nil {
+		return s.decoded.Read(buf)
+	}
+	if s.readAhead != nil {
+		return s.readAhead.Read(buf)
+	}
 	if s.resampling != nil {
 		return s.resampling.Read(buf)
 	}
@@ -43,6 +60,12 @@ func (s *Stream) Read(buf []byte) (int, error) {
 
 // Seek is implementation of io.Seeker's Seek.
 func (s *Stream) Seek(offset int64, whence int) (int64, error) {
+	if s.decoded != nil {
+		return s.decoded.Seek(offset, whence)
+	}
+	if s.readAhead != nil {
+		return s.readAhead.Seek(offset, whence)
+	}
 	if s.resampling != nil {
 		return s.resampling.Seek(offset, whence)
 	}
@@ -50,13 +73,25 @@ func (s *Stream) Seek(offset int64, whence int) (int64, error) {
 }
 
 // Length returns the size of decoded stream in bytes.
+//
+// Length returns -1 when the size is not available e.g. when the source is not an io.Seeker.
 func (s *Stream) Length() int64 {
+	if s.decoded != nil {
+		return s.decoded.Size()
+	}
 	if s.resampling != nil {
 		return s.resampling.Length()
 	}
 	return s.orig.Length()
 }
 
+// SampleRate returns the sample rate of the decoded stream.
+//
+// audio.NewPlayer uses SampleRate to resample the stream to the audio context's sample rate.
+func (s *Stream) SampleRate() int {
+	return s.sampleRate
+}
+
 // DecodeWithSampleRate decodes MP3 source and returns a decoded stream.
 //
 // DecodeWithSampleRate returns error when decoding fails or IO error happens.
@@ -64,22 +99,119 @@ func (s *Stream) Length() int64 {
 // DecodeWithSampleRate automatically resamples the stream to fit with sampleRate if necessary.
 //
 // The returned Stream's Seek is available only when src is an io.Seeker.
+// On browsers, Seek is always available.
+//
+// On browsers, DecodeWithSampleRate reads the whole src and blocks until decoding finishes.
+// Call DecodeWithSampleRate in a goroutine to keep the game running while decoding.
 //
 // A Stream doesn't close src even if src implements io.Closer.
 // Closing the source is src owner's responsibility.
 func DecodeWithSampleRate(sampleRate int, src io.Reader) (*Stream, error) {
-	d, err := mp3.NewDecoder(src)
+	if browserdecoder.IsAvailable() {
+		bs, err := ioutil.ReadAll(src)
+		if err != nil {
+			return nil, err
+		}
+		if pcm, ok := browserdecoder.Decode(bs, sampleRate); ok {
+			return &Stream{decoded: bytes.NewReader(pcm), sampleRate: sampleRate}, nil
+		}
+		// Fall back to the pure Go decoder.
+		src = bytes.NewReader(bs)
+	}
+
+	return decode(src, sampleRate, defaultSeekIndexInterval)
+}
+
+// DecodeWithoutResampling decodes MP3 source and returns a decoded stream.
+//
+// DecodeWithoutResampling returns error when decoding fails or IO error happens.
+//
+// DecodeWithoutResampling doesn't resample the stream. The returned Stream's SampleRate returns the original
+// sample rate, and audio.NewPlayer resamples the stream to the audio context's sample rate.
+//
+// DecodeWithoutResampling always uses the pure Go decoder even on browsers.
+//
+// The returned Stream's Seek is available only when src is an io.Seeker.
+//
+// A Stream doesn't close src even if src implements io.Closer.
+// Closing the source is src owner's responsibility.
+func DecodeWithoutResampling(src io.Reader) (*Stream, error) {
+	return decode(src, 0, defaultSeekIndexInterval)
+}
+
+// DecodeOptions represents options for DecodeWithOptions.
+type DecodeOptions struct {
+	// SampleRate is the sample rate of the decoded stream.
+	// If SampleRate is 0, the stream is not resampled and the returned Stream's SampleRate returns the original
+	// sample rate.
+	SampleRate int
+
+	// SeekIndexInterval is the interval between the entries of the seek index.
+	//
+	// The seek index records the positions of the frames at every SeekIndexInterval when the stream is created,
+	// and Seek starts decoding at the nearest entry. A shorter interval makes Seek faster and uses more memory.
+	//
+	// If SeekIndexInterval is 0, the seek index is not built and Seek decodes the stream from the beginning.
+	SeekIndexInterval time.Duration
+
+	// DecodeAhead is the duration of the data decoded ahead on a worker goroutine.
+	//
+	// With DecodeAhead, Read rarely waits for decoding, and then the audio playback doesn't stall even on a slow
+	// machine. If DecodeAhead is 0, the stream is decoded when Read is called.
+	DecodeAhead time.Duration
+}
+
+// defaultSeekIndexInterval is the seek index interval for DecodeWithSampleRate and DecodeWithoutResampling.
+const defaultSeekIndexInterval = time.Second
+
+// DecodeWithOptions decodes MP3 source and returns a decoded stream.
+//
+// DecodeWithOptions decodes frames on demand and doesn't keep the whole decoded data, so that a long source
+// doesn't consume much memory. DecodeWithOptions always uses the pure Go decoder even on browsers.
+//
+// DecodeWithOptions returns error when decoding fails or IO error happens.
+//
+// The returned Stream's Seek is available only when src is an io.Seeker.
+//
+// A Stream doesn't close src even if src implements io.Closer.
+// Closing the source is src owner's responsibility.
+func DecodeWithOptions(src io.Reader, options *DecodeOptions) (*Stream, error) {
+	if options == nil {
+		options = &DecodeOptions{}
+	}
+	s, err := decode(src, options.SampleRate, options.SeekIndexInterval)
+	if err != nil {
+		return nil, err
+	}
+	if options.DecodeAhead > 0 {
+		var r io.ReadSeeker = s.orig
+		if s.resampling != nil {
+			r = s.resampling
+		}
+		size := int64(options.DecodeAhead) * int64(s.sampleRate) / int64(time.Second) * 4
+		s.readAhead = convert.NewReadAhead(r, int(size))
+	}
+	return s, nil
+}
+
+// decode decodes MP3 source with the pure Go decoder. If sampleRate is 0, decode doesn't resample the stream.
+func decode(src io.Reader, sampleRate int, seekIndexInterval time.Duration) (*Stream, error) {
+	d, err := newStream(src, seekIndexInterval)
 	if err != nil {
 		return nil, err
 	}
 
+	if sampleRate == 0 {
+		sampleRate = d.sampleRate
+	}
 	var r *convert.Resampling
-	if d.SampleRate() != sampleRate {
-		r = convert.NewResampling(d, d.Length(), d.SampleRate(), sampleRate)
+	if d.sampleRate != sampleRate {
+		r = convert.NewResampling(d, d.Length(), d.sampleRate, sampleRate)
 	}
 	s := &Stream{
 		orig:       d,
 		resampling: r,
+		sampleRate: sampleRate,
 	}
 	return s, nil
 }
@@ -0,0 +1,160 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mp3_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	gomp3 "github.com/hajimehoshi/go-mp3"
+
+	"github.com/hajimehoshi/ebiten/v2/audio/mp3"
+	raudio "github.com/hajimehoshi/ebiten/v2/examples/resources/audio"
+)
+
+func TestDecodeWithOptions(t *testing.T) {
+	d, err := gomp3.NewDecoder(bytes.NewReader(raudio.Ragtime_mp3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Compare the first seconds as decoding the whole data takes long.
+	want := make([]byte, 4*44100*3)
+	if _, err := io.ReadFull(d, want); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := mp3.DecodeWithOptions(bytes.NewReader(raudio.Ragtime_mp3), &mp3.DecodeOptions{
+		SeekIndexInterval: time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.SampleRate(), d.SampleRate(); got != want {
+		t.Errorf("s.SampleRate(): got: %d, want: %d", got, want)
+	}
+	if got, want := s.Length(), d.Length(); got != want {
+		t.Errorf("s.Length(): got: %d, want: %d", got, want)
+	}
+	got := make([]byte, len(want))
+	if _, err := io.ReadFull(s, got); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("the decoded data doesn't match")
+	}
+}
+
+func TestDecodeWithOptionsSeek(t *testing.T) {
+	d, err := gomp3.NewDecoder(bytes.NewReader(raudio.Ragtime_mp3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	offsets := []int64{4 * 44100 * 3, 4 * 1000, 4 * 44100 * 5, 4*44100*2 + 4*7, 0}
+
+	for _, interval := range []time.Duration{0, time.Second, time.Millisecond} {
+		s, err := mp3.DecodeWithOptions(bytes.NewReader(raudio.Ragtime_mp3), &mp3.DecodeOptions{
+			SeekIndexInterval: interval,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, offset := range offsets {
+			if _, err := s.Seek(offset, io.SeekStart); err != nil {
+				t.Fatal(err)
+			}
+			got := make([]byte, 4*4096)
+			if _, err := io.ReadFull(s, got); err != nil {
+				t.Fatal(err)
+			}
+
+			// With a very short interval, the decoding starts at the previous frame as go-mp3 does.
+			// Otherwise, the decoding starts at an earlier frame and the result is the same as sequential decoding.
+			if interval == time.Millisecond {
+				if _, err := d.Seek(offset, io.SeekStart); err != nil {
+					t.Fatal(err)
+				}
+			} else {
+				if _, err := d.Seek(0, io.SeekStart); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := io.CopyN(ioutil.Discard, d, offset); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want := make([]byte, len(got))
+			if _, err := io.ReadFull(d, want); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("interval: %s: offset: %d: the decoded data doesn't match", interval, offset)
+			}
+		}
+	}
+}
+
+func TestDecodeWithOptionsNonSeeker(t *testing.T) {
+	s, err := mp3.DecodeWithOptions(struct{ io.Reader }{bytes.NewReader(raudio.Ragtime_mp3)}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.Length(), int64(-1); got != want {
+		t.Errorf("s.Length(): got: %d, want: %d", got, want)
+	}
+	if _, err := s.Seek(0, io.SeekStart); err == nil {
+		t.Errorf("s.Seek must fail but not")
+	}
+	if _, err := io.ReadFull(s, make([]byte, 4*4096)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDecodeWithOptionsDecodeAhead(t *testing.T) {
+	s0, err := mp3.DecodeWithOptions(bytes.NewReader(raudio.Ragtime_mp3), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s1, err := mp3.DecodeWithOptions(bytes.NewReader(raudio.Ragtime_mp3), &mp3.DecodeOptions{
+		DecodeAhead: 500 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s1.Length(), s0.Length(); got != want {
+		t.Errorf("s.Length(): got: %d, want: %d", got, want)
+	}
+
+	for _, offset := range []int64{0, 4 * 44100 * 2, 4 * 1000} {
+		if _, err := s0.Seek(offset, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s1.Seek(offset, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		want := make([]byte, 4*44100)
+		if _, err := io.ReadFull(s0, want); err != nil {
+			t.Fatal(err)
+		}
+		got := make([]byte, len(want))
+		if _, err := io.ReadFull(s1, got); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("offset: %d: the decoded data doesn't match", offset)
+		}
+	}
+}
@@ -0,0 +1,384 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mp3
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/hajimehoshi/go-mp3"
+)
+
+// frameHeader is a header of an MPEG audio Layer III frame.
+type frameHeader uint32
+
+func (h frameHeader) version() int {
+	return int(h>>19) & 0x3
+}
+
+func (h frameHeader) isValid() bool {
+	const sync = 0xffe00000
+	if h&sync != sync {
+		return false
+	}
+	// Version 1 is reserved.
+	if h.version() == 1 {
+		return false
+	}
+	// Only Layer III is supported.
+	if (h>>17)&0x3 != 1 {
+		return false
+	}
+	// Free format and the bad bitrate are not supported.
+	if b := (h >> 12) & 0xf; b == 0 || b == 15 {
+		return false
+	}
+	if (h>>10)&0x3 == 3 {
+		return false
+	}
+	return true
+}
+
+func (h frameHeader) sampleRate() int {
+	r := [...]int{44100, 48000, 32000}[(h>>10)&0x3]
+	switch h.version() {
+	case 2:
+		// MPEG 2
+		r /= 2
+	case 0:
+		// MPEG 2.5
+		r /= 4
+	}
+	return r
+}
+
+// samplesPerFrame returns the number of the samples per channel in a frame.
+func (h frameHeader) samplesPerFrame() int {
+	if h.version() == 3 {
+		return 1152
+	}
+	return 576
+}
+
+// size returns the size of the frame in bytes including the header.
+func (h frameHeader) size() int {
+	var bitrates = [2][16]int{
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+	}
+	lsf := 0
+	if h.version() != 3 {
+		lsf = 1
+	}
+	bitrate := bitrates[lsf][(h>>12)&0xf] * 1000
+	padding := int(h>>9) & 0x1
+	return h.samplesPerFrame()/8*bitrate/h.sampleRate() + padding
+}
+
+// frameScanner finds frames in an MP3 source without decoding them.
+type frameScanner struct {
+	src io.ReadSeeker
+	pos int64
+	buf [4]byte
+
+	// sampleRate is the sample rate of the first frame. Frames with a different sample rate are ignored.
+	sampleRate int
+}
+
+// next returns the position and the header of the next frame.
+// next returns io.EOF when there are no more complete frames.
+func (s *frameScanner) next() (int64, frameHeader, error) {
+	if _, err := s.src.Seek(s.pos, io.SeekStart); err != nil {
+		return 0, 0, err
+	}
+	if _, err := io.ReadFull(s.src, s.buf[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return 0, 0, io.EOF
+		}
+		return 0, 0, err
+	}
+	h := frameHeader(uint32(s.buf[0])<<24 | uint32(s.buf[1])<<16 | uint32(s.buf[2])<<8 | uint32(s.buf[3]))
+	for !h.isValid() || (s.sampleRate != 0 && h.sampleRate() != s.sampleRate) {
+		if _, err := io.ReadFull(s.src, s.buf[:1]); err != nil {
+			if err == io.ErrUnexpectedEOF {
+				return 0, 0, io.EOF
+			}
+			return 0, 0, err
+		}
+		h = h<<8 | frameHeader(s.buf[0])
+		s.pos++
+	}
+	pos := s.pos
+	size := int64(h.size())
+
+	// Check that the frame is complete.
+	if _, err := s.src.Seek(pos+size-1, io.SeekStart); err != nil {
+		return 0, 0, err
+	}
+	if _, err := io.ReadFull(s.src, s.buf[:1]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return 0, 0, io.EOF
+		}
+		return 0, 0, err
+	}
+
+	s.pos += size
+	if s.sampleRate == 0 {
+		s.sampleRate = h.sampleRate()
+	}
+	return pos, h, nil
+}
+
+// skipID3 returns the size of the ID3v2 tag at the head of src, or 0 if src doesn't have the tag.
+func skipID3(src io.ReadSeeker) (int64, error) {
+	var buf [10]byte
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	if _, err := io.ReadFull(src, buf[:]); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return 0, nil
+		}
+		return 0, err
+	}
+	if string(buf[:3]) != "ID3" {
+		return 0, nil
+	}
+	size := int64(buf[6])<<21 | int64(buf[7])<<14 | int64(buf[8])<<7 | int64(buf[9])
+	return 10 + size, nil
+}
+
+// readerOnly hides Seek of the underlying reader so that go-mp3 doesn't scan the whole source.
+type readerOnly struct {
+	r io.Reader
+}
+
+func (r *readerOnly) Read(buf []byte) (int, error) {
+	return r.r.Read(buf)
+}
+
+// stream is a decoded stream that decodes frames on demand.
+//
+// Unlike mp3.Decoder, stream doesn't keep the positions of all the frames.
+// Instead, stream keeps a coarse seek index, and decodes the frames from the nearest index entry on seeking.
+type stream struct {
+	src io.Reader
+
+	// seeker is src as an io.Seeker, or nil if src is not an io.Seeker.
+	seeker io.ReadSeeker
+
+	dataStart     int64
+	sampleRate    int
+	bytesPerFrame int64
+
+	// length is the size of the decoded stream in bytes, or -1 if the size is unknown.
+	length int64
+
+	// index is the positions of every indexInterval frames.
+	index         []int64
+	indexInterval int64
+
+	decoder *mp3.Decoder
+	pos     int64
+	eof     bool
+}
+
+// newStream creates a stream.
+// indexInterval is the interval between the seek index entries. If indexInterval is 0 or less, no index is built.
+func newStream(src io.Reader, indexInterval time.Duration) (*stream, error) {
+	s := &stream{
+		src:    src,
+		length: -1,
+	}
+
+	seeker, ok := src.(io.ReadSeeker)
+	if !ok {
+		d, err := mp3.NewDecoder(src)
+		if err != nil {
+			return nil, err
+		}
+		s.decoder = d
+		s.sampleRate = d.SampleRate()
+		return s, nil
+	}
+	s.seeker = seeker
+
+	start, err := skipID3(seeker)
+	if err != nil {
+		return nil, err
+	}
+	s.dataStart = start
+
+	// Scan the frame headers to calculate the length and to build the seek index.
+	scanner := &frameScanner{
+		src: seeker,
+		pos: start,
+	}
+	var frames int64
+	for {
+		pos, h, err := scanner.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if frames == 0 {
+			s.dataStart = pos
+			s.sampleRate = h.sampleRate()
+			s.bytesPerFrame = int64(h.samplesPerFrame()) * 4
+			if indexInterval > 0 {
+				// Round up the interval in frames so that the interval is at least 1 frame.
+				n := int64(indexInterval) * int64(s.sampleRate) / int64(time.Second)
+				spf := int64(h.samplesPerFrame())
+				s.indexInterval = (n + spf - 1) / spf
+				if s.indexInterval == 0 {
+					s.indexInterval = 1
+				}
+			}
+		}
+		if s.indexInterval > 0 && frames%s.indexInterval == 0 {
+			s.index = append(s.index, pos)
+		}
+		frames++
+	}
+	if frames == 0 {
+		return nil, errors.New("mp3: no frames found")
+	}
+	s.length = frames * s.bytesPerFrame
+
+	if err := s.startDecoding(s.dataStart); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// startDecoding starts decoding frames from the given position in the source.
+func (s *stream) startDecoding(pos int64) error {
+	if _, err := s.seeker.Seek(pos, io.SeekStart); err != nil {
+		return err
+	}
+	s.decoder = nil
+	s.eof = false
+	d, err := mp3.NewDecoder(&readerOnly{r: s.seeker})
+	if err == io.EOF {
+		s.eof = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	s.decoder = d
+	return nil
+}
+
+// Read is implementation of io.Reader's Read.
+func (s *stream) Read(buf []byte) (int, error) {
+	if s.length >= 0 {
+		if s.pos >= s.length {
+			return 0, io.EOF
+		}
+		if rest := s.length - s.pos; int64(len(buf)) > rest {
+			buf = buf[:rest]
+		}
+	}
+
+	if !s.eof {
+		n, err := s.decoder.Read(buf)
+		s.pos += int64(n)
+		if err == io.EOF {
+			s.eof = true
+			if n > 0 {
+				return n, nil
+			}
+		} else {
+			return n, err
+		}
+	}
+
+	if s.length < 0 {
+		return 0, io.EOF
+	}
+	// The decoder can stop before the scanned length e.g. when a frame is broken. Fill the rest with silence.
+	for i := range buf {
+		buf[i] = 0
+	}
+	s.pos += int64(len(buf))
+	return len(buf), nil
+}
+
+// Seek is implementation of io.Seeker's Seek.
+func (s *stream) Seek(offset int64, whence int) (int64, error) {
+	if s.seeker == nil {
+		return 0, errors.New("mp3: the source must be io.Seeker to seek")
+	}
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += s.pos
+	case io.SeekEnd:
+		offset += s.length
+	default:
+		return 0, fmt.Errorf("mp3: invalid whence: %d", whence)
+	}
+	if offset < 0 {
+		return 0, errors.New("mp3: invalid offset")
+	}
+	if offset > s.length {
+		offset = s.length
+	}
+
+	// Decode the previous frame of the target frame too, as the previous frame can affect the target frame.
+	frame := offset/s.bytesPerFrame - 1
+	if frame < 0 {
+		frame = 0
+	}
+
+	start := s.dataStart
+	startFrame := int64(0)
+	if s.indexInterval > 0 {
+		i := frame / s.indexInterval
+		start = s.index[i]
+		startFrame = i * s.indexInterval
+	}
+
+	// If the target is ahead of the current position and no index entry is closer, keep decoding instead of restarting.
+	if offset < s.pos || startFrame*s.bytesPerFrame > s.pos {
+		if err := s.startDecoding(start); err != nil {
+			return 0, err
+		}
+		s.pos = startFrame * s.bytesPerFrame
+	}
+
+	var buf [4096]byte
+	for s.pos < offset {
+		n := offset - s.pos
+		if n > int64(len(buf)) {
+			n = int64(len(buf))
+		}
+		if _, err := s.Read(buf[:n]); err != nil {
+			return 0, err
+		}
+	}
+	return s.pos, nil
+}
+
+// Length returns the size of decoded stream in bytes, or -1 if the size is unknown.
+func (s *stream) Length() int64 {
+	return s.length
+}
@@ -0,0 +1,264 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+import (
+	"errors"
+	"io"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2/audio/internal/readerdriver"
+)
+
+// NewOfflineContext creates a new audio context that doesn't play sounds on a device.
+// Instead, Render renders the players as fast as possible.
+//
+// An offline context is useful to export game soundscapes, procedural music and effect chains, or to test them
+// deterministically without hardware.
+//
+// As opposed to NewContext, NewOfflineContext can be called multiple times, and doesn't affect CurrentContext.
+// An offline context is not suspended or resumed with the game.
+func NewOfflineContext(sampleRate int) *Context {
+	d := &offlineDriver{}
+	f := newReaderPlayerFactory(sampleRate)
+	f.context = d
+
+	c := &Context{
+		sampleRate: sampleRate,
+		np:         f,
+		players:    map[playerImpl]struct{}{},
+		masterBus:  newMasterBus(),
+		listener:   newListener(),
+		ready:      true,
+		inited:     make(chan struct{}),
+		semaphore:  make(chan struct{}, 1),
+		offline:    d,
+	}
+	close(c.inited)
+	return c
+}
+
+// Render renders the players of the offline context for the given duration, and writes the result to w.
+//
+// The result's format is same as NewPlayer's source: 16bit little endian, 2 channel stereo linear PCM at the
+// context's sample rate. Use e.g. wav.Encode to make a WAV file from the result.
+//
+// The players proceed only by Render. The end callbacks of the players might be called asynchronously after
+// Render returns.
+//
+// Render returns an error if the context is not created by NewOfflineContext.
+func (c *Context) Render(w io.Writer, duration time.Duration) error {
+	if c.offline == nil {
+		return errors.New("audio: Render is available only with an offline context")
+	}
+
+	c.m.Lock()
+	err := c.err
+	c.m.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// Round the number of frames to the nearest integer.
+	frames := (int64(duration)*int64(c.sampleRate) + int64(time.Second)/2) / int64(time.Second)
+	if err := c.offline.render(w, frames); err != nil {
+		return err
+	}
+	return c.gcPlayers()
+}
+
+// offlineRenderFrames is the number of frames rendered at once.
+const offlineRenderFrames = 1024
+
+// offlineDriver is a reader driver that mixes the players only when render is called.
+type offlineDriver struct {
+	players []*offlinePlayer
+
+	m sync.Mutex
+}
+
+func (d *offlineDriver) NewPlayer(r io.Reader) readerdriver.Player {
+	p := &offlinePlayer{
+		driver: d,
+		src:    r,
+		volume: 1,
+	}
+	d.m.Lock()
+	d.players = append(d.players, p)
+	d.m.Unlock()
+	return p
+}
+
+func (d *offlineDriver) Suspend() error {
+	return nil
+}
+
+func (d *offlineDriver) Resume() error {
+	return nil
+}
+
+func (d *offlineDriver) OutputLatency() time.Duration {
+	return 0
+}
+
+func (d *offlineDriver) removePlayer(player *offlinePlayer) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	for i, p := range d.players {
+		if p == player {
+			d.players = append(d.players[:i], d.players[i+1:]...)
+			return
+		}
+	}
+}
+
+func (d *offlineDriver) render(w io.Writer, frames int64) error {
+	fbuf := make([]float32, offlineRenderFrames*channelNum)
+	src := make([]byte, offlineRenderFrames*bytesPerSampleF32)
+	dst := make([]byte, offlineRenderFrames*bytesPerSample)
+
+	for frames > 0 {
+		n := int64(offlineRenderFrames)
+		if n > frames {
+			n = frames
+		}
+		frames -= n
+
+		buf := fbuf[:n*channelNum]
+		for i := range buf {
+			buf[i] = 0
+		}
+
+		// Mix the players in the creation order so that the result is deterministic.
+		d.m.Lock()
+		players := make([]*offlinePlayer, len(d.players))
+		copy(players, d.players)
+		d.m.Unlock()
+		for _, p := range players {
+			p.readAndAdd(buf, src[:n*bytesPerSampleF32])
+		}
+
+		for i, v := range buf {
+			v16 := int16(math.Max(math.MinInt16, math.Min(float64(v)*(1<<15), math.MaxInt16)))
+			dst[2*i] = byte(v16)
+			dst[2*i+1] = byte(v16 >> 8)
+		}
+		if _, err := w.Write(dst[:n*bytesPerSample]); err != nil {
+			return err
+		}
+	}
+
+	for _, p := range d.players {
+		if err := p.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type offlinePlayer struct {
+	driver  *offlineDriver
+	src     io.Reader
+	playing bool
+	volume  float64
+	err     error
+
+	m sync.Mutex
+}
+
+// readAndAdd reads float32 samples from the source and adds them to buf with the volume.
+// src is a temporary buffer for the bytes.
+func (p *offlinePlayer) readAndAdd(buf []float32, src []byte) {
+	p.m.Lock()
+	playing := p.playing
+	volume := float32(p.volume)
+	p.m.Unlock()
+	if !playing {
+		return
+	}
+
+	// Don't lock the mutex while reading the source, as the source might call the player's functions.
+	n, err := io.ReadFull(p.src, src)
+	for i := 0; i < n/bitDepthInBytesF32; i++ {
+		buf[i] += float32At(src[4*i:]) * volume
+	}
+
+	if err == nil {
+		return
+	}
+	p.m.Lock()
+	defer p.m.Unlock()
+	p.playing = false
+	if err != io.EOF && err != io.ErrUnexpectedEOF {
+		p.err = err
+	}
+}
+
+func (p *offlinePlayer) Pause() {
+	p.m.Lock()
+	defer p.m.Unlock()
+	p.playing = false
+}
+
+func (p *offlinePlayer) Play() {
+	p.m.Lock()
+	defer p.m.Unlock()
+	p.playing = true
+}
+
+func (p *offlinePlayer) IsPlaying() bool {
+	p.m.Lock()
+	defer p.m.Unlock()
+	return p.playing
+}
+
+func (p *offlinePlayer) Reset() {
+	p.m.Lock()
+	defer p.m.Unlock()
+	p.playing = false
+}
+
+func (p *offlinePlayer) Volume() float64 {
+	p.m.Lock()
+	defer p.m.Unlock()
+	return p.volume
+}
+
+func (p *offlinePlayer) SetVolume(volume float64) {
+	p.m.Lock()
+	defer p.m.Unlock()
+	p.volume = volume
+}
+
+func (p *offlinePlayer) UnplayedBufferSize() int {
+	// The samples are read only when they are rendered.
+	return 0
+}
+
+func (p *offlinePlayer) Err() error {
+	p.m.Lock()
+	defer p.m.Unlock()
+	return p.err
+}
+
+func (p *offlinePlayer) Close() error {
+	p.m.Lock()
+	p.playing = false
+	p.m.Unlock()
+	p.driver.removePlayer(p)
+	return nil
+}
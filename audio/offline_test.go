@@ -0,0 +1,84 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	. "github.com/hajimehoshi/ebiten/v2/audio"
+)
+
+func TestOfflineContext(t *testing.T) {
+	c := NewOfflineContext(44100)
+
+	// 1000 frames.
+	src := bytes.Repeat(stereo16(1000, -1000, 2000, -2000), 250)
+	p0 := NewPlayerFromBytes(c, src)
+	p1 := NewPlayerFromBytes(c, src)
+	p1.SetVolume(0.5)
+	p0.Play()
+	p1.Play()
+
+	// Render 1000 frames. The output is the mix of the two players.
+	var buf bytes.Buffer
+	if err := c.Render(&buf, 1000*time.Second/44100); err != nil {
+		t.Fatal(err)
+	}
+	if want := bytes.Repeat(stereo16(1500, -1500, 3000, -3000), 250); !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("got: %v, want: %v", buf.Bytes()[:16], want[:16])
+	}
+	if got, want := p0.Current(), 1000*time.Second/44100; got != want {
+		t.Errorf("p0.Current(): got: %v, want: %v", got, want)
+	}
+
+	// Render after the end of the players. The result is silent.
+	buf.Reset()
+	if err := c.Render(&buf, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.Len(), 44100*4; got != want {
+		t.Errorf("buf.Len(): got: %d, want: %d", got, want)
+	}
+	if want := make([]byte, 44100*4); !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("the result must be silent")
+	}
+	if p0.IsPlaying() {
+		t.Errorf("p0 must not be playing")
+	}
+}
+
+func TestOfflineContextAudiblePosition(t *testing.T) {
+	c := NewOfflineContext(44100)
+	if got, want := c.OutputLatency(), time.Duration(0); got != want {
+		t.Errorf("c.OutputLatency(): got: %v, want: %v", got, want)
+	}
+
+	p := NewPlayerFromBytes(c, make([]byte, 44100*4))
+	p.Play()
+	if err := c.Render(ioutil.Discard, time.Second/2); err != nil {
+		t.Fatal(err)
+	}
+
+	// An offline context has no latency, and the position is not extrapolated with the wall clock.
+	if got, want := p.AudiblePosition(), time.Second/2; got != want {
+		t.Errorf("p.AudiblePosition(): got: %v, want: %v", got, want)
+	}
+	if got, want := p.AudiblePosition(), p.Current(); got != want {
+		t.Errorf("p.AudiblePosition(): got: %v, want: %v", got, want)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+import (
+	"time"
+)
+
+// maxPositionExtrapolation is the maximum duration to extrapolate the audible position with the wall clock.
+// This prevents the position from running away when the audio driver stops reading.
+const maxPositionExtrapolation = time.Second / 2
+
+// audiblePosition estimates the position being heard.
+//
+// The position calculated from the driver's buffers is updated only when the driver reads the samples.
+// audiblePosition extrapolates the position with the wall clock between the updates.
+type audiblePosition struct {
+	// base is the last observed position, and baseTime is the time when base was observed.
+	base     time.Duration
+	baseTime time.Time
+	valid    bool
+
+	// last is the last estimated position.
+	last time.Duration
+
+	// floor is the minimum position e.g. the position seeked to.
+	floor time.Duration
+}
+
+// estimate returns the estimated position from the observed position pos.
+// rate is the playback rate to convert the wall clock to the position.
+func (a *audiblePosition) estimate(pos time.Duration, playing bool, rate float64, now time.Time) time.Duration {
+	if pos < a.floor {
+		pos = a.floor
+	}
+	if !playing {
+		a.valid = false
+		a.last = pos
+		return pos
+	}
+
+	if !a.valid || pos != a.base {
+		a.base = pos
+		a.baseTime = now
+		a.valid = true
+	}
+	d := now.Sub(a.baseTime)
+	if d > maxPositionExtrapolation {
+		d = maxPositionExtrapolation
+	}
+	est := a.base + time.Duration(float64(d)*rate)
+
+	// The extrapolated position can be a little ahead of a newly observed position. Keep the position monotonic
+	// in this case.
+	if est < a.last && a.last-est < maxPositionExtrapolation {
+		est = a.last
+	}
+	a.last = est
+	return est
+}
+
+// reset resets the estimation. reset should be called when the player is seeked.
+func (a *audiblePosition) reset(pos time.Duration) {
+	a.valid = false
+	a.last = pos
+	a.floor = pos
+}
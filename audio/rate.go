@@ -0,0 +1,196 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+import (
+	"io"
+	"sync"
+)
+
+// rateReader reads float32 stereo samples from a source at a playback rate.
+//
+// The samples are resampled by linear interpolation, so the pitch changes with the rate.
+// The source is also resampled when its sample rate is different from the output's one.
+// As long as the rate is never changed from 1 and the sample rates are the same, rateReader reads the source as it is.
+type rateReader struct {
+	src  io.Reader
+	rate float64
+
+	// ratio is the ratio of the source's sample rate to the output's sample rate.
+	ratio float64
+
+	// interpolating reports whether the rate has been changed and the samples are interpolated.
+	interpolating bool
+
+	// cur and next are the frames around the current fractional position frac.
+	cur  [channelNum]float32
+	next [channelNum]float32
+	frac float64
+	eof  bool
+
+	// buf is the buffer of the bytes read from the source. buf[bufPos:] are not used yet.
+	buf    []byte
+	bufPos int
+
+	// consumed is the number of the used bytes of the source.
+	consumed int64
+
+	m sync.Mutex
+}
+
+func newRateReader(src io.Reader, srcSampleRate, dstSampleRate int) *rateReader {
+	return &rateReader{
+		src:   src,
+		rate:  1,
+		ratio: float64(srcSampleRate) / float64(dstSampleRate),
+	}
+}
+
+func (r *rateReader) Rate() float64 {
+	r.m.Lock()
+	defer r.m.Unlock()
+	return r.rate
+}
+
+func (r *rateReader) SetRate(rate float64) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.rate = rate
+}
+
+// reset discards the interpolation state. reset should be called when the source is seeked.
+func (r *rateReader) reset() {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.interpolating = false
+	r.frac = 0
+	r.eof = false
+	r.buf = r.buf[:0]
+	r.bufPos = 0
+}
+
+// Consumed returns the number of the used bytes of the source so far.
+func (r *rateReader) Consumed() int64 {
+	r.m.Lock()
+	defer r.m.Unlock()
+	return r.consumed
+}
+
+// SourceSamples returns the number of the source's samples that correspond to the given number of the output's
+// samples at the current rate.
+func (r *rateReader) SourceSamples(samples int64) int64 {
+	r.m.Lock()
+	defer r.m.Unlock()
+	return int64(float64(samples) * r.rate * r.ratio)
+}
+
+// Buffered returns the number of the bytes that are read from the source but not used yet.
+func (r *rateReader) Buffered() int {
+	r.m.Lock()
+	defer r.m.Unlock()
+	return len(r.buf) - r.bufPos
+}
+
+func (r *rateReader) Read(buf []byte) (int, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if !r.interpolating && r.rate == 1 && r.ratio == 1 {
+		// The buffer is always empty when the samples are not interpolated.
+		n, err := r.src.Read(buf)
+		r.consumed += int64(n)
+		return n, err
+	}
+
+	if !r.interpolating {
+		r.interpolating = true
+		r.frac = 0
+		r.eof = false
+		if err := r.readFrame(&r.cur); err != nil {
+			return 0, err
+		}
+		if err := r.readFrame(&r.next); err != nil {
+			return 0, err
+		}
+	}
+
+	step := r.rate * r.ratio
+	n := 0
+	for ; n+bytesPerSampleF32 <= len(buf); n += bytesPerSampleF32 {
+		if r.eof && r.frac >= 1 {
+			break
+		}
+		for ch := 0; ch < channelNum; ch++ {
+			v := float64(r.cur[ch]) + (float64(r.next[ch])-float64(r.cur[ch]))*r.frac
+			putFloat32(buf[n+bitDepthInBytesF32*ch:], float32(v))
+		}
+
+		r.frac += step
+		for r.frac >= 1 && !r.eof {
+			r.cur = r.next
+			if err := r.readFrame(&r.next); err != nil {
+				return n, err
+			}
+			r.frac--
+		}
+	}
+
+	if n == 0 && r.eof {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+// rateReaderBufferSize is the size of the buffer to read the source.
+const rateReaderBufferSize = 4096
+
+// readFrame reads one frame from the source into f.
+// At the end of the source, readFrame sets r.eof and keeps f as it is.
+func (r *rateReader) readFrame(f *[channelNum]float32) error {
+	if r.eof {
+		return nil
+	}
+
+	for len(r.buf)-r.bufPos < bytesPerSampleF32 {
+		if r.buf == nil {
+			r.buf = make([]byte, 0, rateReaderBufferSize)
+		}
+		// Move the remaining bytes to the head.
+		rest := copy(r.buf[:cap(r.buf)], r.buf[r.bufPos:])
+		r.buf = r.buf[:rest]
+		r.bufPos = 0
+
+		n, err := r.src.Read(r.buf[rest:cap(r.buf)])
+		r.buf = r.buf[:rest+n]
+		if err == io.EOF {
+			if len(r.buf) < bytesPerSampleF32 {
+				r.eof = true
+				return nil
+			}
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	b := r.buf[r.bufPos : r.bufPos+bytesPerSampleF32]
+	for ch := 0; ch < channelNum; ch++ {
+		f[ch] = float32At(b[bitDepthInBytesF32*ch:])
+	}
+	r.bufPos += bytesPerSampleF32
+	r.consumed += bytesPerSampleF32
+	return nil
+}
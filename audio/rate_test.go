@@ -0,0 +1,72 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"testing"
+
+	. "github.com/hajimehoshi/ebiten/v2/audio"
+)
+
+// stereo16 returns 16bit stereo samples where both channels have the given values.
+func stereo16(values ...int16) []byte {
+	b := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint16(b[4*i:], uint16(v))
+		binary.LittleEndian.PutUint16(b[4*i+2:], uint16(v))
+	}
+	return b
+}
+
+func TestRateReader(t *testing.T) {
+	cases := []struct {
+		Name string
+		Rate float64
+		In   []int16
+		Out  []int16
+	}{
+		{
+			Name: "1",
+			Rate: 1,
+			In:   []int16{0, 100, 200, 300},
+			Out:  []int16{0, 100, 200, 300},
+		},
+		{
+			Name: "2",
+			Rate: 2,
+			In:   []int16{0, 100, 200, 300, 400, 500},
+			Out:  []int16{0, 200, 400},
+		},
+		{
+			Name: "0.5",
+			Rate: 0.5,
+			In:   []int16{0, 100, 200},
+			Out:  []int16{0, 50, 100, 150, 200, 200},
+		},
+	}
+	for _, c := range cases {
+		r := NewRateReaderForTesting(bytes.NewReader(stereo16(c.In...)), c.Rate)
+		got, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := stereo16(c.Out...); !bytes.Equal(got, want) {
+			t.Errorf("rate %s: got: %v, want: %v", c.Name, got, want)
+		}
+	}
+}
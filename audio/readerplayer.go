@@ -26,6 +26,8 @@ import (
 type readerPlayerFactory struct {
 	context    readerdriver.Context
 	sampleRate int
+
+	m sync.Mutex
 }
 
 var readerDriverForTesting readerdriver.Context
@@ -45,17 +47,45 @@ type readerPlayer struct {
 	context *Context
 	player  readerdriver.Player
 	src     io.Reader
+	format  sampleFormat
 	stream  *timeStream
+
+	// sampleRate is the sample rate of the source.
+	sampleRate int
+
+	f32     *float32Reader
+	rate    *rateReader
+	spatial *spatialReader
+	bus     *busReader
+	fade    *fadeReader
+	tap     *tapReader
 	factory *readerPlayerFactory
-	m       sync.Mutex
+
+	audible audiblePosition
+	v       voice
+
+	m sync.Mutex
+
+	// The states below are for the end callback. These are protected by endM instead of m, since the source
+	// is read on the driver's goroutine while m might be locked.
+	onEnd  func()
+	ending bool
+	endGen int
+	endM   sync.Mutex
 }
 
-func (f *readerPlayerFactory) newPlayerImpl(context *Context, src io.Reader) (playerImpl, error) {
+func (f *readerPlayerFactory) newPlayerImpl(context *Context, src io.Reader, format sampleFormat, sampleRate int) (playerImpl, error) {
 	p := &readerPlayer{
-		src:     src,
-		context: context,
-		factory: f,
+		src:        src,
+		format:     format,
+		sampleRate: sampleRate,
+		context:    context,
+		spatial:    newSpatialReader(context.listener, context.SampleRate()),
+		bus:        newBusReader(context.masterBus),
+		factory:    f,
 	}
+	p.fade = newFadeReader(p.bus, context.SampleRate())
+	p.tap = newTapReader(p.fade, p.bus)
 	runtime.SetFinalizer(p, (*readerPlayer).Close)
 	return p, nil
 }
@@ -74,32 +104,98 @@ func (f *readerPlayerFactory) resume() error {
 	return f.context.Resume()
 }
 
+func (f *readerPlayerFactory) outputLatency() time.Duration {
+	if f.context == nil {
+		return 0
+	}
+	return f.context.OutputLatency()
+}
+
+// ensureContext creates the driver's context if needed.
+func (f *readerPlayerFactory) ensureContext(context *Context) error {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	if f.context != nil {
+		return nil
+	}
+
+	// The players output float32 samples, and the driver mixes them as float32.
+	c, ready, err := readerdriver.NewContext(f.sampleRate, channelNum, bitDepthInBytesF32)
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-ready
+		context.setReady()
+	}()
+	f.context = c
+	return nil
+}
+
+func (f *readerPlayerFactory) outputDevices(context *Context) ([]OutputDevice, error) {
+	if err := f.ensureContext(context); err != nil {
+		return nil, err
+	}
+	s, ok := f.context.(readerdriver.DeviceSelector)
+	if !ok {
+		return nil, errOutputDeviceNotSupported
+	}
+	ds, err := s.OutputDevices()
+	if err != nil {
+		return nil, err
+	}
+	devices := make([]OutputDevice, 0, len(ds))
+	for _, d := range ds {
+		devices = append(devices, OutputDevice{
+			ID:        d.ID,
+			Name:      d.Name,
+			IsDefault: d.IsDefault,
+		})
+	}
+	return devices, nil
+}
+
+func (f *readerPlayerFactory) setOutputDevice(context *Context, id string) error {
+	if err := f.ensureContext(context); err != nil {
+		return err
+	}
+	s, ok := f.context.(readerdriver.DeviceSelector)
+	if !ok {
+		return errOutputDeviceNotSupported
+	}
+	return s.SetOutputDevice(id)
+}
+
 func (p *readerPlayer) ensurePlayer() error {
 	// Initialize the underlying player lazily to enable calling NewContext in an 'init' function.
 	// Accessing the underlying player functions requires the environment to be already initialized,
 	// but if Ebiten is used for a shared library, the timing when init functions are called
 	// is unexpectable.
 	// e.g. a variable for JVM on Android might not be set.
-	if p.factory.context == nil {
-		c, ready, err := readerdriver.NewContext(p.factory.sampleRate, channelNum, bitDepthInBytes)
-		if err != nil {
-			return err
-		}
-		go func() {
-			<-ready
-			p.context.setReady()
-		}()
-		p.factory.context = c
+	if err := p.factory.ensureContext(p.context); err != nil {
+		return err
 	}
 	if p.stream == nil {
-		s, err := newTimeStream(p.src, p.factory.sampleRate)
+		s, err := newTimeStream(p.src, p.sampleRate, p.format.bytesPerSample())
 		if err != nil {
 			return err
 		}
 		p.stream = s
+		if p.format == sampleFormatFloat32 {
+			p.rate = newRateReader(s, p.sampleRate, p.factory.sampleRate)
+		} else {
+			p.f32 = newFloat32Reader(s)
+			p.rate = newRateReader(p.f32, p.sampleRate, p.factory.sampleRate)
+		}
+		p.spatial.src = p.rate
+		p.bus.src = p.spatial
 	}
 	if p.player == nil {
-		p.player = p.factory.context.NewPlayer(p.stream)
+		p.player = p.factory.context.NewPlayer(&eofReader{
+			r:     p.tap,
+			onEOF: p.onEOF,
+		})
 	}
 	return nil
 }
@@ -144,32 +240,24 @@ func (p *readerPlayer) IsPlaying() bool {
 	return p.player.IsPlaying()
 }
 
+// Volume returns the volume applied by fadeReader. The underlying player's volume is always 1.
 func (p *readerPlayer) Volume() float64 {
-	p.m.Lock()
-	defer p.m.Unlock()
-
-	if err := p.ensurePlayer(); err != nil {
-		p.context.setError(err)
-		return 0
-	}
-	return p.player.Volume()
+	return p.fade.Volume()
 }
 
 func (p *readerPlayer) SetVolume(volume float64) {
-	p.m.Lock()
-	defer p.m.Unlock()
+	p.fade.SetVolume(volume)
+}
 
-	if err := p.ensurePlayer(); err != nil {
-		p.context.setError(err)
-		return
-	}
-	p.player.SetVolume(volume)
+func (p *readerPlayer) FadeTo(volume float64, duration time.Duration) {
+	p.fade.FadeTo(volume, duration)
 }
 
 func (p *readerPlayer) Close() error {
 	p.m.Lock()
 	defer p.m.Unlock()
 	runtime.SetFinalizer(p, nil)
+	p.cancelEnd()
 
 	if p.player != nil {
 		defer func() {
@@ -189,8 +277,31 @@ func (p *readerPlayer) Current() time.Duration {
 		return 0
 	}
 
-	sample := (p.stream.Current() - int64(p.player.UnplayedBufferSize())) / bytesPerSample
-	return time.Duration(sample) * time.Second / time.Duration(p.factory.sampleRate)
+	// The unplayed samples in the player's buffer are resampled ones. Convert them to the source's samples.
+	unplayed := p.rate.SourceSamples(int64(p.player.UnplayedBufferSize() / bytesPerSampleF32))
+	buffered := int64(p.rate.Buffered() / bytesPerSampleF32)
+	sample := p.stream.Current()/int64(p.format.bytesPerSample()) - buffered - unplayed
+	return time.Duration(sample) * time.Second / time.Duration(p.sampleRate)
+}
+
+func (p *readerPlayer) AudiblePosition() time.Duration {
+	current := p.Current()
+
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	// An offline context is not synchronized with the wall clock.
+	if p.context.offline != nil {
+		return current
+	}
+	if p.player == nil {
+		return current
+	}
+
+	// The output latency is in the context's time. Convert it to the source's time with the rate.
+	rate := p.rate.Rate()
+	latency := time.Duration(float64(p.factory.outputLatency()) * rate)
+	return p.audible.estimate(current-latency, p.player.IsPlaying(), rate, time.Now())
 }
 
 func (p *readerPlayer) Rewind() error {
@@ -211,7 +322,143 @@ func (p *readerPlayer) Seek(offset time.Duration) error {
 		}()
 	}
 	p.player.Reset()
-	return p.stream.Seek(offset)
+	p.cancelEnd()
+	if err := p.stream.Seek(offset); err != nil {
+		return err
+	}
+	if p.f32 != nil {
+		p.f32.reset()
+	}
+	p.rate.reset()
+	p.spatial.reset()
+	p.bus.reset()
+	p.audible.reset(offset)
+	return nil
+}
+
+func (p *readerPlayer) Rate() float64 {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if err := p.ensurePlayer(); err != nil {
+		p.context.setError(err)
+		return 1
+	}
+	return p.rate.Rate()
+}
+
+func (p *readerPlayer) SetRate(rate float64) {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if err := p.ensurePlayer(); err != nil {
+		p.context.setError(err)
+		return
+	}
+	p.rate.SetRate(rate)
+}
+
+func (p *readerPlayer) Bus() *Bus {
+	return p.bus.Bus()
+}
+
+func (p *readerPlayer) SetBus(bus *Bus) {
+	if bus == nil {
+		bus = p.context.masterBus
+	}
+	p.bus.SetBus(bus)
+}
+
+func (p *readerPlayer) Position() (x, y, z float64) {
+	return p.spatial.Position()
+}
+
+func (p *readerPlayer) SetPosition(x, y, z float64) {
+	p.spatial.SetPosition(x, y, z)
+}
+
+func (p *readerPlayer) SetSpatialOptions(options *SpatialOptions) {
+	p.spatial.SetOptions(options)
+}
+
+func (p *readerPlayer) Tap() *Tap {
+	return p.tap.Tap()
+}
+
+func (p *readerPlayer) SetTap(tap *Tap) {
+	p.tap.SetTap(tap)
+}
+
+func (p *readerPlayer) SetOnEnd(f func()) {
+	p.endM.Lock()
+	defer p.endM.Unlock()
+	p.onEnd = f
+}
+
+// onEOF is called when the source reaches EOF.
+func (p *readerPlayer) onEOF() {
+	p.endM.Lock()
+	defer p.endM.Unlock()
+	if p.ending {
+		return
+	}
+	p.ending = true
+	go p.waitForEnd(p.endGen)
+}
+
+// cancelEnd cancels waiting for the end of the player.
+func (p *readerPlayer) cancelEnd() {
+	p.endM.Lock()
+	defer p.endM.Unlock()
+	p.ending = false
+	p.endGen++
+}
+
+// waitForEnd waits until the player consumes the buffered samples, and then calls the end callback.
+func (p *readerPlayer) waitForEnd(gen int) {
+	for {
+		p.m.Lock()
+		if p.player == nil {
+			p.m.Unlock()
+			return
+		}
+		unplayed := p.player.UnplayedBufferSize()
+		p.m.Unlock()
+
+		if unplayed == 0 {
+			break
+		}
+
+		// Sleep until the buffer is expected to be consumed, but check the state frequently enough.
+		d := time.Duration(unplayed/bytesPerSampleF32) * time.Second / time.Duration(p.factory.sampleRate)
+		if d > 10*time.Millisecond {
+			d = 10 * time.Millisecond
+		}
+		if d < time.Millisecond {
+			d = time.Millisecond
+		}
+		time.Sleep(d)
+
+		p.endM.Lock()
+		canceled := p.endGen != gen
+		p.endM.Unlock()
+		if canceled {
+			return
+		}
+	}
+
+	p.endM.Lock()
+	if p.endGen != gen {
+		p.endM.Unlock()
+		return
+	}
+	// Keep p.ending true so that the callback is not called again until the player is seeked.
+	f := p.onEnd
+	p.endM.Unlock()
+
+	if f != nil {
+		f()
+	}
 }
 
 func (p *readerPlayer) Err() error {
@@ -228,20 +475,40 @@ func (p *readerPlayer) source() io.Reader {
 	return p.src
 }
 
+func (p *readerPlayer) voice() *voice {
+	return &p.v
+}
+
+// eofReader calls onEOF when the reader reaches EOF.
+type eofReader struct {
+	r     io.Reader
+	onEOF func()
+}
+
+func (e *eofReader) Read(buf []byte) (int, error) {
+	n, err := e.r.Read(buf)
+	if err == io.EOF {
+		e.onEOF()
+	}
+	return n, err
+}
+
 type timeStream struct {
-	r          io.Reader
-	sampleRate int
-	pos        int64
+	r              io.Reader
+	sampleRate     int
+	bytesPerSample int
+	pos            int64
 
 	// m is a mutex for this stream.
 	// All the exported functions are protected by this mutex as Read can be read from a different goroutine than Seek.
 	m sync.Mutex
 }
 
-func newTimeStream(r io.Reader, sampleRate int) (*timeStream, error) {
+func newTimeStream(r io.Reader, sampleRate int, bytesPerSample int) (*timeStream, error) {
 	s := &timeStream{
-		r:          r,
-		sampleRate: sampleRate,
+		r:              r,
+		sampleRate:     sampleRate,
+		bytesPerSample: bytesPerSample,
 	}
 	if seeker, ok := s.r.(io.Seeker); ok {
 		// Get the current position of the source.
@@ -267,6 +534,7 @@ func (s *timeStream) Seek(offset time.Duration) error {
 	s.m.Lock()
 	defer s.m.Unlock()
 
+	bytesPerSample := int64(s.bytesPerSample)
 	o := int64(offset) * bytesPerSample * int64(s.sampleRate) / int64(time.Second)
 
 	// Align the byte position with the samples.
@@ -0,0 +1,401 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+import (
+	"io"
+	"math"
+	"sync"
+)
+
+// Listener is the listener of spatialized players. An audio context has one listener.
+//
+// The coordinate system is right-handed. By default, the listener is at the origin, faces the negative Z direction,
+// and the up direction is the positive Y direction. Then, the positive X direction is the listener's right.
+//
+// Changes of a Listener are applied when the players read their next samples from the streams.
+//
+// Listener is concurrent-safe.
+type Listener struct {
+	position [3]float64
+	forward  [3]float64
+	up       [3]float64
+
+	m sync.Mutex
+}
+
+func newListener() *Listener {
+	return &Listener{
+		forward: [3]float64{0, 0, -1},
+		up:      [3]float64{0, 1, 0},
+	}
+}
+
+// Listener returns the listener of the context.
+func (c *Context) Listener() *Listener {
+	return c.listener
+}
+
+// Position returns the listener's position.
+func (l *Listener) Position() (x, y, z float64) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	return l.position[0], l.position[1], l.position[2]
+}
+
+// SetPosition sets the listener's position.
+func (l *Listener) SetPosition(x, y, z float64) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	l.position = [3]float64{x, y, z}
+}
+
+// Orientation returns the listener's forward and up directions.
+func (l *Listener) Orientation() (forwardX, forwardY, forwardZ, upX, upY, upZ float64) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	return l.forward[0], l.forward[1], l.forward[2], l.up[0], l.up[1], l.up[2]
+}
+
+// SetOrientation sets the listener's forward and up directions.
+//
+// The directions don't have to be normalized, but must not be zero vectors or parallel to each other.
+// SetOrientation panics otherwise.
+func (l *Listener) SetOrientation(forwardX, forwardY, forwardZ, upX, upY, upZ float64) {
+	f := [3]float64{forwardX, forwardY, forwardZ}
+	u := [3]float64{upX, upY, upZ}
+	if length(cross(f, u)) == 0 {
+		panic("audio: the forward and up directions must not be zero vectors or parallel to each other")
+	}
+
+	l.m.Lock()
+	defer l.m.Unlock()
+	l.forward = f
+	l.up = u
+}
+
+// basis returns the listener's position, and the right and forward unit vectors.
+func (l *Listener) basis() (position, right, forward [3]float64) {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	forward = normalize(l.forward)
+	right = normalize(cross(forward, l.up))
+	return l.position, right, forward
+}
+
+// SpatialOptions represents options to spatialize a player.
+//
+// The zero value is the default options.
+type SpatialOptions struct {
+	// RefDistance is the distance at which the volume starts to be attenuated.
+	// The volume is not attenuated when the distance is less than RefDistance.
+	//
+	// The default (zero) value is 1.
+	RefDistance float64
+
+	// MaxDistance is the distance after which the volume is not attenuated any more.
+	//
+	// The default (zero) value is 10000.
+	MaxDistance float64
+
+	// Rolloff is how quickly the volume is attenuated by the distance.
+	// The volume is RefDistance / (RefDistance + Rolloff * (distance - RefDistance)).
+	//
+	// The default (zero) value is 1.
+	Rolloff float64
+
+	// DistanceFiltering specifies whether high frequencies are attenuated by the distance as the air absorbs them.
+	DistanceFiltering bool
+
+	// HRTF specifies whether the sound is filtered by a simple head model instead of simple panning.
+	// The sound reaches the farther ear later and with less high frequencies, and the sound from behind the
+	// listener is slightly muffled. This sounds more natural especially with headphones.
+	HRTF bool
+}
+
+func (o *SpatialOptions) refDistance() float64 {
+	if o.RefDistance == 0 {
+		return 1
+	}
+	return o.RefDistance
+}
+
+func (o *SpatialOptions) maxDistance() float64 {
+	if o.MaxDistance == 0 {
+		return 10000
+	}
+	return o.MaxDistance
+}
+
+func (o *SpatialOptions) rolloff() float64 {
+	if o.Rolloff == 0 {
+		return 1
+	}
+	return o.Rolloff
+}
+
+const (
+	// maxInterauralDelay is the maximum difference of the arrival times at the ears in seconds.
+	maxInterauralDelay = 0.00066
+
+	// spatialDelayLineSize is the size of the delay lines for the interaural delay.
+	// This must be a power of two and enough for maxInterauralDelay at 192000 [Hz].
+	spatialDelayLineSize = 256
+
+	// maxCutoffFrequency is the cutoff frequency of the low-pass filters without attenuation.
+	maxCutoffFrequency = 20000
+)
+
+// spatialParams is the parameters for each ear.
+type spatialParams struct {
+	gain   float64
+	delay  float64
+	cutoff float64
+}
+
+// spatialReader spatializes float32 stereo samples by the positions of the player and the listener.
+//
+// The source's channels are mixed into one channel, and then the channel is panned, attenuated and filtered.
+type spatialReader struct {
+	src        io.Reader
+	listener   *Listener
+	sampleRate int
+
+	options  *SpatialOptions
+	position [3]float64
+
+	// prev is the parameters at the end of the previous read. The parameters are ramped from prev.
+	prev [channelNum]spatialParams
+	// initialized reports whether prev is valid.
+	initialized bool
+
+	lowpass [channelNum]float64
+	delays  [channelNum][spatialDelayLineSize]float32
+	delayAt int
+
+	// pending is the bytes of an incomplete frame that are held until the rest of the frame is read.
+	pending []byte
+
+	m sync.Mutex
+}
+
+func newSpatialReader(listener *Listener, sampleRate int) *spatialReader {
+	return &spatialReader{
+		listener:   listener,
+		sampleRate: sampleRate,
+	}
+}
+
+// reset discards the pending bytes and the filter states. reset should be called when the source is seeked.
+func (r *spatialReader) reset() {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.pending = r.pending[:0]
+	r.resetStates()
+}
+
+func (r *spatialReader) resetStates() {
+	r.initialized = false
+	r.lowpass = [channelNum]float64{}
+	r.delays = [channelNum][spatialDelayLineSize]float32{}
+	r.delayAt = 0
+}
+
+func (r *spatialReader) Position() (x, y, z float64) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	return r.position[0], r.position[1], r.position[2]
+}
+
+func (r *spatialReader) SetPosition(x, y, z float64) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.position = [3]float64{x, y, z}
+}
+
+func (r *spatialReader) SetOptions(options *SpatialOptions) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if options == nil {
+		r.options = nil
+		return
+	}
+	o := *options
+	if r.options == nil {
+		r.resetStates()
+	}
+	r.options = &o
+}
+
+// params calculates the current parameters for each ear.
+func (r *spatialReader) params() [channelNum]spatialParams {
+	lp, right, forward := r.listener.basis()
+	v := sub(r.position, lp)
+	d := length(v)
+
+	// Calculate the attenuation by the inverse distance model.
+	ref := r.options.refDistance()
+	dist := math.Max(math.Min(d, r.options.maxDistance()), ref)
+	gain := ref / (ref + r.options.rolloff()*(dist-ref))
+
+	cutoff := float64(maxCutoffFrequency)
+	if r.options.DistanceFiltering {
+		cutoff *= ref / dist
+	}
+
+	// pan is the sine of the angle from the front to the right in [-1, 1].
+	// front is the cosine of the angle from the front in [-1, 1].
+	var pan, front float64
+	if d > 0 {
+		pan = dot(v, right) / d
+		front = dot(v, forward) / d
+		// Ignore the elevation. The sound from above or below is treated as the sound from the front.
+		if h := math.Hypot(pan, front); h > 0 {
+			pan /= h
+			front /= h
+		} else {
+			front = 1
+		}
+	} else {
+		front = 1
+	}
+
+	var ps [channelNum]spatialParams
+	if !r.options.HRTF {
+		// Equal-power panning.
+		a := (pan + 1) * math.Pi / 4
+		ps[0] = spatialParams{gain: gain * math.Cos(a), cutoff: cutoff}
+		ps[1] = spatialParams{gain: gain * math.Sin(a), cutoff: cutoff}
+		return ps
+	}
+
+	// The sound from behind is muffled by the pinnae.
+	if front < 0 {
+		cutoff *= 1 + 0.5*front
+	}
+
+	// The interaural time difference by Woodworth's formula, and the head shadow of the farther ear.
+	theta := math.Asin(math.Abs(pan))
+	delay := maxInterauralDelay * (theta + math.Sin(theta)) / (math.Pi/2 + 1) * float64(r.sampleRate)
+	near := spatialParams{gain: gain, cutoff: cutoff}
+	far := spatialParams{
+		gain:   gain * (1 - 0.3*math.Abs(pan)),
+		delay:  delay,
+		cutoff: cutoff * (1 - 0.8*math.Abs(pan)),
+	}
+	if pan >= 0 {
+		ps[0], ps[1] = far, near
+	} else {
+		ps[0], ps[1] = near, far
+	}
+	return ps
+}
+
+func (r *spatialReader) Read(buf []byte) (int, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	// Process complete frames only.
+	p := copy(buf, r.pending)
+	r.pending = r.pending[:copy(r.pending, r.pending[p:])]
+	n, err := r.src.Read(buf[p:])
+	n += p
+	r.pending = append(r.pending, buf[n-n%bytesPerSampleF32:n]...)
+	n -= n % bytesPerSampleF32
+
+	if r.options == nil || n == 0 {
+		return n, err
+	}
+
+	ps := r.params()
+	prev := r.prev
+	if !r.initialized {
+		prev = ps
+		r.initialized = true
+	}
+	r.prev = ps
+
+	filtering := r.options.DistanceFiltering || r.options.HRTF
+	var coeffs [channelNum]float64
+	for ch := range ps {
+		cutoff := math.Min(ps[ch].cutoff, float64(r.sampleRate)*0.45)
+		coeffs[ch] = math.Exp(-2 * math.Pi * cutoff / float64(r.sampleRate))
+	}
+
+	frames := n / bytesPerSampleF32
+	for i := 0; i < frames; i++ {
+		// Mix the channels into one channel.
+		var v float64
+		for ch := 0; ch < channelNum; ch++ {
+			v += float64(float32At(buf[bytesPerSampleF32*i+bitDepthInBytesF32*ch:]))
+		}
+		v /= channelNum
+
+		// Ramp the parameters linearly to avoid clicking noises.
+		t := float64(i+1) / float64(frames)
+		for ch := 0; ch < channelNum; ch++ {
+			x := v
+			if r.options.HRTF {
+				r.delays[ch][r.delayAt] = float32(v)
+				d := prev[ch].delay + (ps[ch].delay-prev[ch].delay)*t
+				x = r.delayed(ch, d)
+			}
+			if filtering {
+				r.lowpass[ch] = (1-coeffs[ch])*x + coeffs[ch]*r.lowpass[ch]
+				x = r.lowpass[ch]
+			}
+			g := prev[ch].gain + (ps[ch].gain-prev[ch].gain)*t
+			putFloat32(buf[bytesPerSampleF32*i+bitDepthInBytesF32*ch:], float32(x*g))
+		}
+		r.delayAt = (r.delayAt + 1) & (spatialDelayLineSize - 1)
+	}
+	return n, err
+}
+
+// delayed returns the sample delayed by d samples in the delay line of the channel ch.
+func (r *spatialReader) delayed(ch int, d float64) float64 {
+	d = math.Min(d, spatialDelayLineSize-2)
+	i := int(d)
+	f := d - float64(i)
+	a := r.delays[ch][(r.delayAt-i)&(spatialDelayLineSize-1)]
+	b := r.delays[ch][(r.delayAt-i-1)&(spatialDelayLineSize-1)]
+	return float64(a) + (float64(b)-float64(a))*f
+}
+
+func sub(a, b [3]float64) [3]float64 {
+	return [3]float64{a[0] - b[0], a[1] - b[1], a[2] - b[2]}
+}
+
+func dot(a, b [3]float64) float64 {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+}
+
+func cross(a, b [3]float64) [3]float64 {
+	return [3]float64{
+		a[1]*b[2] - a[2]*b[1],
+		a[2]*b[0] - a[0]*b[2],
+		a[0]*b[1] - a[1]*b[0],
+	}
+}
+
+func length(a [3]float64) float64 {
+	return math.Sqrt(dot(a, a))
+}
+
+func normalize(a [3]float64) [3]float64 {
+	l := length(a)
+	return [3]float64{a[0] / l, a[1] / l, a[2] / l}
+}
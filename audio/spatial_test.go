@@ -0,0 +1,123 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	. "github.com/hajimehoshi/ebiten/v2/audio"
+)
+
+func TestSpatialReader(t *testing.T) {
+	setup()
+	defer teardown()
+
+	cases := []struct {
+		Name    string
+		X, Y, Z float64
+		Left    int16
+		Right   int16
+	}{
+		{
+			Name:  "right",
+			X:     1,
+			Left:  0,
+			Right: 10000,
+		},
+		{
+			Name:  "left and far",
+			X:     -2,
+			Left:  5000,
+			Right: 0,
+		},
+		{
+			Name:  "too close",
+			X:     0.5,
+			Left:  0,
+			Right: 10000,
+		},
+	}
+	for _, c := range cases {
+		in := stereo16(10000, 10000, 10000, 10000)
+		r := NewSpatialReaderForTesting(bytes.NewReader(in), context.Listener(), c.X, c.Y, c.Z, &SpatialOptions{})
+		buf := make([]byte, len(in))
+		if _, err := io.ReadFull(r, buf); err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < len(buf)/4; i++ {
+			l := int16(buf[4*i]) | int16(buf[4*i+1])<<8
+			r := int16(buf[4*i+2]) | int16(buf[4*i+3])<<8
+			if abs(int(l)-int(c.Left)) > 1 || abs(int(r)-int(c.Right)) > 1 {
+				t.Errorf("%s: got: (%d, %d), want: (%d, %d)", c.Name, l, r, c.Left, c.Right)
+			}
+		}
+	}
+}
+
+func TestSpatialReaderListener(t *testing.T) {
+	setup()
+	defer teardown()
+
+	// Turn the listener to the positive X direction. Then, the negative Z direction is the listener's left.
+	l := context.Listener()
+	l.SetPosition(10, 0, 0)
+	l.SetOrientation(1, 0, 0, 0, 1, 0)
+
+	in := stereo16(10000, 10000)
+	r := NewSpatialReaderForTesting(bytes.NewReader(in), l, 10, 0, -1, &SpatialOptions{})
+	buf := make([]byte, len(in))
+	if _, err := io.ReadFull(r, buf); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < len(buf)/4; i++ {
+		if got := int16(buf[4*i+2]) | int16(buf[4*i+3])<<8; got != 0 {
+			t.Errorf("right: got: %d, want: 0", got)
+		}
+	}
+}
+
+func TestSpatialReaderHRTF(t *testing.T) {
+	setup()
+	defer teardown()
+
+	in := bytes.Repeat(stereo16(10000), 256)
+	r := NewSpatialReaderForTesting(bytes.NewReader(in), context.Listener(), 1, 0, 0, &SpatialOptions{
+		HRTF: true,
+	})
+	buf := make([]byte, len(in))
+	if _, err := io.ReadFull(r, buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// The farther ear gets the sound later and more quietly.
+	if got := int16(buf[0]) | int16(buf[1])<<8; got != 0 {
+		t.Errorf("left at the first frame: got: %d, want: 0", got)
+	}
+	last := buf[len(buf)-4:]
+	left := int16(last[0]) | int16(last[1])<<8
+	right := int16(last[2]) | int16(last[3])<<8
+	if left >= right {
+		t.Errorf("left must be less than right at the last frame: left: %d, right: %d", left, right)
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
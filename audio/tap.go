@@ -0,0 +1,315 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+import (
+	"io"
+	"math"
+	"math/cmplx"
+	"sync"
+)
+
+// Tap keeps the most recent samples of players, e.g. for oscilloscopes, VU meters and beat detection.
+//
+// A Tap is attached to a player by Player.SetTap, or to a bus by Bus.SetTap. A Tap attached to a bus gets the mix of
+// the players in the bus and its descendant buses. Then, a Tap attached to the master bus gets the mix of all the
+// players.
+//
+// The samples are what are sent to the audio driver after all the effects and the volumes are applied.
+// As the driver has its own buffer, the samples are slightly ahead of what is actually heard.
+// When no players are playing, the samples are kept as they are.
+//
+// Tap is concurrent-safe.
+type Tap struct {
+	// buf is a ring buffer of interleaved 2-channel samples.
+	buf []float32
+
+	// head is the number of the frames written so far.
+	head int64
+
+	m sync.Mutex
+}
+
+// NewTap creates a new Tap that keeps the given number of the most recent frames.
+//
+// size must be positive. NewTap panics otherwise.
+func NewTap(size int) *Tap {
+	if size <= 0 {
+		panic("audio: size must be positive")
+	}
+	return &Tap{
+		buf: make([]float32, size*channelNum),
+	}
+}
+
+// Size returns the number of frames the tap keeps.
+func (t *Tap) Size() int {
+	return len(t.buf) / channelNum
+}
+
+// Samples copies the most recent samples to dst as interleaved 2-channel samples, and returns the number of the
+// copied frames. The samples are ordered from the oldest to the newest.
+func (t *Tap) Samples(dst []float32) int {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	n := len(dst) / channelNum
+	if size := t.Size(); n > size {
+		n = size
+	}
+	t.copyRecent(dst, n)
+	return n
+}
+
+// copyRecent copies the most recent n frames to dst.
+func (t *Tap) copyRecent(dst []float32, n int) {
+	size := int64(t.Size())
+	for i := 0; i < n; i++ {
+		idx := (t.head - int64(n) + int64(i)) % size
+		if idx < 0 {
+			idx += size
+		}
+		copy(dst[channelNum*i:channelNum*(i+1)], t.buf[channelNum*idx:channelNum*(idx+1)])
+	}
+}
+
+// Peak returns the peak absolute values of each channel in the most recent frames.
+func (t *Tap) Peak(frames int) (left, right float64) {
+	var peak [channelNum]float64
+	t.recent(frames, func(ch int, v float64) {
+		peak[ch] = math.Max(peak[ch], math.Abs(v))
+	})
+	return peak[0], peak[1]
+}
+
+// RMS returns the root mean square values of each channel in the most recent frames.
+func (t *Tap) RMS(frames int) (left, right float64) {
+	var sum [channelNum]float64
+	n := t.recent(frames, func(ch int, v float64) {
+		sum[ch] += v * v
+	})
+	if n == 0 {
+		return 0, 0
+	}
+	return math.Sqrt(sum[0] / float64(n)), math.Sqrt(sum[1] / float64(n))
+}
+
+// recent calls f with the samples of the most recent frames, and returns the number of the frames.
+func (t *Tap) recent(frames int, f func(ch int, v float64)) int {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	if size := t.Size(); frames > size {
+		frames = size
+	}
+	if frames <= 0 {
+		return 0
+	}
+	buf := make([]float32, frames*channelNum)
+	t.copyRecent(buf, frames)
+	for i, v := range buf {
+		f(i%channelNum, float64(v))
+	}
+	return frames
+}
+
+// Spectrum calculates the magnitudes of the frequency components of the most recent 2*len(dst) frames, and
+// stores them in dst. The channels are mixed into one channel, and the Hann window is applied.
+//
+// dst[i] is the magnitude at i * sample rate / (2*len(dst)) [Hz]. The magnitude of a full-scale sine wave is
+// about 1.
+//
+// len(dst) must be a power of two, and 2*len(dst) must not exceed the tap's size. Spectrum panics otherwise.
+func (t *Tap) Spectrum(dst []float64) {
+	n := 2 * len(dst)
+	if len(dst) == 0 || len(dst)&(len(dst)-1) != 0 {
+		panic("audio: len(dst) must be a power of two")
+	}
+	if n > t.Size() {
+		panic("audio: 2*len(dst) must not exceed the tap's size")
+	}
+
+	buf := make([]float32, n*channelNum)
+	t.m.Lock()
+	t.copyRecent(buf, n)
+	t.m.Unlock()
+
+	x := make([]complex128, n)
+	for i := range x {
+		var v float64
+		for ch := 0; ch < channelNum; ch++ {
+			v += float64(buf[channelNum*i+ch])
+		}
+		v /= channelNum
+		w := 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(n))
+		x[i] = complex(v*w, 0)
+	}
+	fft(x)
+
+	// The coherent gain of the Hann window is 0.5, and the amplitude is split into the positive and negative
+	// frequencies.
+	for i := range dst {
+		dst[i] = cmplx.Abs(x[i]) * 4 / float64(n)
+	}
+}
+
+// fft calculates the discrete Fourier transform of x in place. len(x) must be a power of two.
+func fft(x []complex128) {
+	n := len(x)
+
+	// Bit-reversal permutation.
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+
+	for size := 2; size <= n; size <<= 1 {
+		w := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			wk := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				a := x[start+k]
+				b := x[start+k+size/2] * wk
+				x[start+k] = a + b
+				x[start+k+size/2] = a - b
+				wk *= w
+			}
+		}
+	}
+}
+
+// write adds the samples at the frame position pos, and returns the position after the samples.
+//
+// As players read their sources at different timings, each player has its own position in the tap. If pos is too
+// old or invalid, the samples are written so that they end at the tap's head, as the samples are assumed to be
+// read at the same time as the latest samples.
+func (t *Tap) write(pos int64, valid bool, samples []float32) int64 {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	size := int64(t.Size())
+	frames := int64(len(samples) / channelNum)
+	if !valid || pos < t.head-size || pos > t.head {
+		pos = t.head - frames
+		if pos < 0 {
+			pos = 0
+		}
+	}
+	end := pos + frames
+
+	// Clear the new frames before adding the samples.
+	from := t.head
+	if from < end-size {
+		from = end - size
+	}
+	for f := from; f < end; f++ {
+		idx := f % size
+		for ch := 0; ch < channelNum; ch++ {
+			t.buf[channelNum*idx+int64(ch)] = 0
+		}
+	}
+	if t.head < end {
+		t.head = end
+	}
+
+	for i := 0; i < len(samples)/channelNum; i++ {
+		f := pos + int64(i)
+		if f < t.head-size {
+			continue
+		}
+		idx := f % size
+		for ch := 0; ch < channelNum; ch++ {
+			t.buf[channelNum*idx+int64(ch)] += samples[channelNum*i+ch]
+		}
+	}
+	return end
+}
+
+// tapReader writes float32 stereo samples from a source to the taps of a player and its buses.
+type tapReader struct {
+	src io.Reader
+	bus *busReader
+	tap *Tap
+
+	// positions are the positions in the taps.
+	positions map[*Tap]int64
+	fbuf      []float32
+
+	m sync.Mutex
+}
+
+func newTapReader(src io.Reader, bus *busReader) *tapReader {
+	return &tapReader{
+		src: src,
+		bus: bus,
+	}
+}
+
+func (r *tapReader) Tap() *Tap {
+	r.m.Lock()
+	defer r.m.Unlock()
+	return r.tap
+}
+
+func (r *tapReader) SetTap(tap *Tap) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.tap = tap
+}
+
+func (r *tapReader) Read(buf []byte) (int, error) {
+	n, err := r.src.Read(buf)
+
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	var taps []*Tap
+	if r.tap != nil {
+		taps = append(taps, r.tap)
+	}
+	for b := r.bus.Bus(); b != nil; b = b.parent {
+		if t := b.Tap(); t != nil {
+			taps = append(taps, t)
+		}
+	}
+	if len(taps) == 0 {
+		r.positions = nil
+		return n, err
+	}
+
+	// busReader returns only complete frames.
+	samples := n / bitDepthInBytesF32
+	if cap(r.fbuf) < samples {
+		r.fbuf = make([]float32, samples)
+	}
+	fbuf := r.fbuf[:samples]
+	for i := range fbuf {
+		fbuf[i] = float32At(buf[4*i:])
+	}
+
+	positions := make(map[*Tap]int64, len(taps))
+	for _, t := range taps {
+		pos, ok := r.positions[t]
+		positions[t] = t.write(pos, ok, fbuf)
+	}
+	r.positions = positions
+	return n, err
+}
@@ -0,0 +1,105 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math"
+	"testing"
+	"time"
+
+	. "github.com/hajimehoshi/ebiten/v2/audio"
+)
+
+func TestTap(t *testing.T) {
+	c := NewOfflineContext(44100)
+
+	p0 := NewPlayerFromBytes(c, bytes.Repeat(stereo16(8192, -8192), 500))
+	p1 := NewPlayerFromBytes(c, bytes.Repeat(stereo16(4096), 1000))
+	p1.SetVolume(0.5)
+
+	playerTap := NewTap(4)
+	p0.SetTap(playerTap)
+	masterTap := NewTap(4)
+	c.MasterBus().SetTap(masterTap)
+
+	p0.Play()
+	p1.Play()
+	if err := c.Render(ioutil.Discard, 1000*time.Second/44100); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]float32, 16)
+	if got, want := playerTap.Samples(buf), 4; got != want {
+		t.Errorf("playerTap.Samples(): got: %d, want: %d", got, want)
+	}
+	if got, want := buf[:8], []float32{0.25, 0.25, -0.25, -0.25, 0.25, 0.25, -0.25, -0.25}; !equalFloat32s(got, want) {
+		t.Errorf("playerTap.Samples(): got: %v, want: %v", got, want)
+	}
+
+	masterTap.Samples(buf)
+	if got, want := buf[:8], []float32{0.3125, 0.3125, -0.1875, -0.1875, 0.3125, 0.3125, -0.1875, -0.1875}; !equalFloat32s(got, want) {
+		t.Errorf("masterTap.Samples(): got: %v, want: %v", got, want)
+	}
+
+	l, r := masterTap.Peak(4)
+	if l != 0.3125 || r != 0.3125 {
+		t.Errorf("masterTap.Peak(): got: (%f, %f), want: (0.3125, 0.3125)", l, r)
+	}
+}
+
+func TestTapSpectrum(t *testing.T) {
+	c := NewOfflineContext(44100)
+
+	// A sine wave at the frequency of the 32nd bin with 512 frames.
+	const (
+		n   = 512
+		bin = 32
+	)
+	src := make([]byte, 4*n)
+	for i := 0; i < n; i++ {
+		v := int16(16384 * math.Sin(2*math.Pi*bin*float64(i)/n))
+		copy(src[4*i:], stereo16(v))
+	}
+	p := NewPlayerFromBytes(c, src)
+	tap := NewTap(n)
+	p.SetTap(tap)
+	p.Play()
+	if err := c.Render(ioutil.Discard, n*time.Second/44100); err != nil {
+		t.Fatal(err)
+	}
+
+	spectrum := make([]float64, n/2)
+	tap.Spectrum(spectrum)
+	if got := spectrum[bin]; math.Abs(got-0.5) > 0.01 {
+		t.Errorf("spectrum[%d]: got: %f, want: 0.5", bin, got)
+	}
+	if got := spectrum[bin*3]; got > 0.01 {
+		t.Errorf("spectrum[%d]: got: %f, want: 0", bin*3, got)
+	}
+}
+
+func equalFloat32s(a, b []float32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if math.Abs(float64(a[i]-b[i])) > 1e-6 {
+			return false
+		}
+	}
+	return true
+}
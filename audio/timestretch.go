@@ -0,0 +1,324 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sync"
+)
+
+// TimeStretch represents a stream whose tempo can be changed without changing the pitch.
+//
+// TimeStretch uses WSOLA (waveform similarity based overlap-add). The source is split into overlapping short
+// segments, and the segments are overlapped at a different interval. Each segment is chosen around the expected
+// position so that the segment is the most similar to the natural continuation of the previous segment.
+//
+// This is different from Player.SetRate, which changes both the tempo and the pitch.
+//
+// TimeStretch is concurrent-safe.
+type TimeStretch struct {
+	src   io.Reader
+	tempo float64
+
+	// stretching reports whether the tempo has been changed and the samples are stretched.
+	// As long as the tempo is never changed from 1, TimeStretch reads the source as it is.
+	stretching bool
+
+	// srcBytes is the number of the bytes read from the source.
+	srcBytes int64
+
+	frameSize int
+	hopSize   int
+	tolerance int
+	window    []float32
+
+	// in is the float32 stereo frames read from the source. inBase is the frame index of in[0].
+	in     []float32
+	inBase int64
+	srcEOF bool
+	// pending is the bytes of an incomplete frame read from the source.
+	pending []byte
+
+	// analysis is the expected position of the next segment in the source.
+	analysis float64
+	// prev is the position of the previous segment. prevValid reports whether prev is valid.
+	prev      int64
+	prevValid bool
+
+	// acc is the float32 stereo frames to overlap and add the segments.
+	acc []float32
+	// skip reports whether the next output should be skipped. The first segment is only for the fade-in.
+	skip bool
+
+	out []byte
+	eof bool
+
+	m sync.Mutex
+}
+
+// NewTimeStretch creates a new time-stretched stream with a source stream and the sample rate.
+//
+// src's format must be same as NewPlayer's source: 16bit little endian, 2 channel stereo linear PCM.
+// The returned stream has the same format.
+func NewTimeStretch(src io.Reader, sampleRate int) *TimeStretch {
+	// The segment is 40 [ms] and the tolerance to search similar segments is 8 [ms].
+	frameSize := sampleRate * 40 / 1000 / 2 * 2
+	t := &TimeStretch{
+		src:       src,
+		tempo:     1,
+		frameSize: frameSize,
+		hopSize:   frameSize / 2,
+		tolerance: sampleRate * 8 / 1000,
+		window:    make([]float32, frameSize),
+		acc:       make([]float32, frameSize*channelNum),
+	}
+	// The periodic Hann window. The windows overlapping by the half sum to 1.
+	for i := range t.window {
+		t.window[i] = float32(0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(frameSize)))
+	}
+	return t
+}
+
+// Tempo returns the tempo. The default value is 1.
+func (t *TimeStretch) Tempo() float64 {
+	t.m.Lock()
+	defer t.m.Unlock()
+	return t.tempo
+}
+
+// SetTempo sets the tempo. For example, 2 means twice as fast and 0.5 means twice as slow.
+//
+// tempo must be positive and finite. SetTempo panics otherwise.
+func (t *TimeStretch) SetTempo(tempo float64) {
+	// The condition must be true when tempo is NaN.
+	if !(tempo > 0 && !math.IsInf(tempo, 0)) {
+		panic(fmt.Sprintf("audio: tempo must be positive and finite but %f", tempo))
+	}
+
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.tempo = tempo
+	if tempo != 1 && !t.stretching {
+		t.stretching = true
+		t.resetStates()
+	}
+}
+
+// resetStates resets the states to stretch the samples from the current position of the source.
+func (t *TimeStretch) resetStates() {
+	// Skip the rest of an incomplete frame.
+	if r := t.srcBytes % bytesPerSample; r != 0 {
+		t.pending = make([]byte, r)
+	} else {
+		t.pending = t.pending[:0]
+	}
+	t.in = t.in[:0]
+	t.inBase = (t.srcBytes + bytesPerSample - 1) / bytesPerSample
+	t.srcEOF = false
+	t.analysis = float64(t.inBase - int64(t.hopSize))
+	t.prevValid = false
+	for i := range t.acc {
+		t.acc[i] = 0
+	}
+	t.skip = true
+	t.out = t.out[:0]
+	t.eof = false
+}
+
+// Read is implementation of io.Reader's Read.
+func (t *TimeStretch) Read(buf []byte) (int, error) {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	if !t.stretching {
+		n, err := t.src.Read(buf)
+		t.srcBytes += int64(n)
+		return n, err
+	}
+
+	for len(t.out) == 0 {
+		if t.eof {
+			return 0, io.EOF
+		}
+		if err := t.hop(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(buf, t.out)
+	t.out = t.out[:copy(t.out, t.out[n:])]
+	return n, nil
+}
+
+// Seek is implementation of io.Seeker's Seek.
+//
+// offset is the position in the source's bytes. As TimeStretch reads the source ahead, io.SeekCurrent is relative
+// to the source's position, which might be ahead of the output.
+//
+// If the source is not an io.Seeker, Seek panics.
+func (t *TimeStretch) Seek(offset int64, whence int) (int64, error) {
+	s, ok := t.src.(io.Seeker)
+	if !ok {
+		panic("audio: the source must be io.Seeker when seeking but not")
+	}
+
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	pos, err := s.Seek(offset, whence)
+	if err != nil {
+		return 0, err
+	}
+	t.srcBytes = pos
+	if t.stretching {
+		t.resetStates()
+	}
+	return pos, nil
+}
+
+// fill reads the source until the frames before end are available or the source reaches EOF.
+func (t *TimeStretch) fill(end int64) error {
+	for !t.srcEOF && t.inBase+int64(len(t.in)/channelNum) < end {
+		const bufSize = 4096
+		b := make([]byte, len(t.pending)+bufSize)
+		copy(b, t.pending)
+		n, err := t.src.Read(b[len(t.pending):])
+		t.srcBytes += int64(n)
+		n += len(t.pending)
+
+		frames := n / bytesPerSample
+		for i := 0; i < frames*channelNum; i++ {
+			v := int16(b[2*i]) | int16(b[2*i+1])<<8
+			t.in = append(t.in, float32(v)/(1<<15))
+		}
+		t.pending = append(t.pending[:0], b[frames*bytesPerSample:n]...)
+
+		if err == io.EOF {
+			t.srcEOF = true
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sample returns the sample at the frame index i and the channel ch. The samples out of the source are 0.
+func (t *TimeStretch) sample(i int64, ch int) float32 {
+	idx := i - t.inBase
+	if idx < 0 || idx >= int64(len(t.in)/channelNum) {
+		return 0
+	}
+	return t.in[idx*channelNum+int64(ch)]
+}
+
+// mono returns the mixed samples of the frames in [from, from+n).
+func (t *TimeStretch) mono(from int64, n int) []float32 {
+	m := make([]float32, n)
+	for i := range m {
+		for ch := 0; ch < channelNum; ch++ {
+			m[i] += t.sample(from+int64(i), ch)
+		}
+	}
+	return m
+}
+
+// hop overlaps and adds the next segment, and outputs the samples of the hop size.
+func (t *TimeStretch) hop() error {
+	n := t.frameSize
+	tol := t.tolerance
+	center := int64(math.Floor(t.analysis))
+
+	end := center + int64(tol+n)
+	if t.prevValid && t.prev+int64(t.hopSize+n) > end {
+		end = t.prev + int64(t.hopSize+n)
+	}
+	if err := t.fill(end); err != nil {
+		return err
+	}
+
+	inEnd := t.inBase + int64(len(t.in)/channelNum)
+	if t.srcEOF && center >= inEnd {
+		// There are no more samples. Output the rest.
+		t.emit()
+		t.eof = true
+		return nil
+	}
+
+	// Search the segment that is the most similar to the natural continuation of the previous segment.
+	pos := center
+	if t.prevValid {
+		// Compare every 4 frames to reduce the calculation.
+		const step = 4
+		natural := t.mono(t.prev+int64(t.hopSize), n)
+		candidates := t.mono(center-int64(tol), 2*tol+n)
+		best := math.Inf(-1)
+		for d := 0; d <= 2*tol; d++ {
+			var c float64
+			for i := 0; i < n; i += step {
+				c += float64(candidates[d+i]) * float64(natural[i])
+			}
+			if c > best {
+				best = c
+				pos = center - int64(tol) + int64(d)
+			}
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		w := t.window[i]
+		for ch := 0; ch < channelNum; ch++ {
+			t.acc[i*channelNum+ch] += t.sample(pos+int64(i), ch) * w
+		}
+	}
+	t.emit()
+
+	t.prev = pos
+	t.prevValid = true
+	t.analysis += float64(t.hopSize) * t.tempo
+
+	// Discard the frames that are no longer used.
+	keep := int64(math.Floor(t.analysis)) - int64(tol)
+	if k := t.prev + int64(t.hopSize); k < keep {
+		keep = k
+	}
+	if d := keep - t.inBase; d > 0 {
+		if d > int64(len(t.in)/channelNum) {
+			d = int64(len(t.in) / channelNum)
+		}
+		t.in = t.in[:copy(t.in, t.in[d*channelNum:])]
+		t.inBase += d
+	}
+	return nil
+}
+
+// emit outputs the first frames of the hop size in acc, and shifts acc.
+func (t *TimeStretch) emit() {
+	h := t.hopSize * channelNum
+	if t.skip {
+		t.skip = false
+	} else {
+		for _, v := range t.acc[:h] {
+			v16 := int16(math.Max(math.MinInt16, math.Min(float64(v)*(1<<15), math.MaxInt16)))
+			t.out = append(t.out, byte(v16), byte(v16>>8))
+		}
+	}
+	copy(t.acc, t.acc[h:])
+	for i := len(t.acc) - h; i < len(t.acc); i++ {
+		t.acc[i] = 0
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math"
+	"testing"
+
+	. "github.com/hajimehoshi/ebiten/v2/audio"
+)
+
+func sineForTesting(frequency float64, sampleRate int, frames int) []byte {
+	buf := make([]byte, frames*4)
+	for i := 0; i < frames; i++ {
+		v := int16(math.Sin(2*math.Pi*frequency*float64(i)/float64(sampleRate)) * 0x4000)
+		buf[4*i] = byte(v)
+		buf[4*i+1] = byte(v >> 8)
+		buf[4*i+2] = byte(v)
+		buf[4*i+3] = byte(v >> 8)
+	}
+	return buf
+}
+
+// zeroCrossings returns the number of the zero crossings of the left channel.
+func zeroCrossings(buf []byte) int {
+	var c int
+	var prev int16
+	for i := 0; i+4 <= len(buf); i += 4 {
+		v := int16(buf[i]) | int16(buf[i+1])<<8
+		if i > 0 && (prev < 0) != (v < 0) {
+			c++
+		}
+		prev = v
+	}
+	return c
+}
+
+func TestTimeStretch(t *testing.T) {
+	const (
+		sampleRate = 44100
+		frequency  = 440
+		frames     = sampleRate
+	)
+	src := sineForTesting(frequency, sampleRate, frames)
+
+	for _, tempo := range []float64{0.5, 0.8, 1, 1.25, 2} {
+		s := NewTimeStretch(bytes.NewReader(src), sampleRate)
+		s.SetTempo(tempo)
+		got, err := ioutil.ReadAll(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The length is changed by the tempo.
+		wantFrames := float64(frames) / tempo
+		if gotFrames := float64(len(got) / 4); math.Abs(gotFrames-wantFrames) > sampleRate*0.05 {
+			t.Errorf("tempo: %f, frames: got: %f, want: %f", tempo, gotFrames, wantFrames)
+		}
+
+		// The pitch is not changed. Check the middle part to avoid the edges.
+		mid := got[len(got)/4/4*4 : len(got)*3/4/4*4]
+		gotFreq := float64(zeroCrossings(mid)) / 2 / (float64(len(mid)/4) / sampleRate)
+		if math.Abs(gotFreq-frequency) > frequency*0.03 {
+			t.Errorf("tempo: %f, frequency: got: %f, want: %f", tempo, gotFreq, float64(frequency))
+		}
+	}
+}
+
+func TestTimeStretchWithoutTempo(t *testing.T) {
+	src := sineForTesting(440, 44100, 1000)
+	s := NewTimeStretch(bytes.NewReader(src), 44100)
+	got, err := ioutil.ReadAll(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, src) {
+		t.Errorf("the source must be read as it is when the tempo is not changed")
+	}
+}
+
+func TestTimeStretchSeek(t *testing.T) {
+	src := sineForTesting(440, 44100, 44100)
+	s := NewTimeStretch(bytes.NewReader(src), 44100)
+	s.SetTempo(2)
+	if _, err := ioutil.ReadAll(s); err != nil {
+		t.Fatal(err)
+	}
+
+	pos, err := s.Seek(int64(len(src)/2), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := pos, int64(len(src)/2); got != want {
+		t.Errorf("got: %d, want: %d", got, want)
+	}
+	got, err := ioutil.ReadAll(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) == 0 || len(got) > len(src)/2 {
+		t.Errorf("len(got): %d, must be in (0, %d]", len(got), len(src)/2)
+	}
+}
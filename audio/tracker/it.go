@@ -0,0 +1,585 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracker
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+func isIT(data []byte) bool {
+	return len(data) >= 0xc0 && string(data[:4]) == "IMPM"
+}
+
+func loadIT(data []byte) (*module, error) {
+	if !isIT(data) {
+		return nil, fmt.Errorf("tracker: invalid IT header")
+	}
+
+	orderNum := int(binary.LittleEndian.Uint16(data[0x20:]))
+	instrumentNum := int(binary.LittleEndian.Uint16(data[0x22:]))
+	sampleNum := int(binary.LittleEndian.Uint16(data[0x24:]))
+	patternNum := int(binary.LittleEndian.Uint16(data[0x26:]))
+	compatibleVersion := binary.LittleEndian.Uint16(data[0x2a:])
+	flags := binary.LittleEndian.Uint16(data[0x2c:])
+	useInstruments := flags&4 != 0
+
+	insOffset := 0xc0 + orderNum
+	smpOffset := insOffset + instrumentNum*4
+	patOffset := smpOffset + sampleNum*4
+	if len(data) < patOffset+patternNum*4 {
+		return nil, fmt.Errorf("tracker: IT data is too short")
+	}
+	if useInstruments && compatibleVersion < 0x200 {
+		return nil, fmt.Errorf("tracker: IT instruments in the old format are not supported")
+	}
+
+	m := &module{
+		format:        formatIT,
+		title:         trimString(data[4:30]),
+		initialSpeed:  int(data[0x32]),
+		initialTempo:  int(data[0x33]),
+		globalVolume:  int(data[0x30]) / 2,
+		linearPeriods: flags&8 != 0,
+		referenceNote: 5*12 + 1,
+	}
+	if m.initialSpeed == 0 {
+		m.initialSpeed = 6
+	}
+	if m.initialTempo < 32 {
+		m.initialTempo = 125
+	}
+	if m.globalVolume > 64 {
+		m.globalVolume = 64
+	}
+
+	for i := 0; i < orderNum; i++ {
+		switch o := data[0xc0+i]; o {
+		case 0xfe:
+			// A marker. Skip this.
+		case 0xff:
+			m.orders = append(m.orders, -1)
+		default:
+			m.orders = append(m.orders, int(o))
+		}
+	}
+
+	var samples []*sample
+	for i := 0; i < sampleNum; i++ {
+		ptr := int(binary.LittleEndian.Uint32(data[smpOffset+i*4:]))
+		s, err := loadITSample(data, ptr)
+		if err != nil {
+			return nil, err
+		}
+		samples = append(samples, s)
+	}
+
+	if useInstruments {
+		for i := 0; i < instrumentNum; i++ {
+			ptr := int(binary.LittleEndian.Uint32(data[insOffset+i*4:]))
+			inst, err := loadITInstrument(data, ptr, samples)
+			if err != nil {
+				return nil, err
+			}
+			m.instruments = append(m.instruments, inst)
+		}
+	} else {
+		for _, s := range samples {
+			m.instruments = append(m.instruments, newSampleInstrument(s))
+		}
+	}
+
+	for i := 0; i < patternNum; i++ {
+		ptr := int(binary.LittleEndian.Uint32(data[patOffset+i*4:]))
+		p, err := loadITPattern(data, ptr)
+		if err != nil {
+			return nil, err
+		}
+		m.patterns = append(m.patterns, p)
+		for _, r := range p.rows {
+			if m.channelNum < len(r) {
+				m.channelNum = len(r)
+			}
+		}
+	}
+	if m.channelNum == 0 {
+		m.channelNum = 1
+	}
+	for _, p := range m.patterns {
+		for i, r := range p.rows {
+			if len(r) < m.channelNum {
+				p.rows[i] = append(r, make([]cell, m.channelNum-len(r))...)
+			}
+		}
+	}
+
+	for i := 0; i < m.channelNum; i++ {
+		pan := int(data[0x40+i])
+		switch {
+		case pan&0x80 != 0:
+			// A disabled channel.
+			pan = 0x80
+		case pan == 100:
+			// Surround. Treat this as center.
+			pan = 0x80
+		case pan > 64:
+			pan = 0x80
+		default:
+			pan = pan * 255 / 64
+		}
+		// Without the stereo flag, all the channels are centered.
+		if flags&1 == 0 {
+			pan = 0x80
+		}
+		m.panning = append(m.panning, pan)
+
+		vol := int(data[0x80+i])
+		if vol > 64 {
+			vol = 64
+		}
+		if data[0x40+i]&0x80 != 0 {
+			vol = 0
+		}
+		m.channelVolume = append(m.channelVolume, vol)
+	}
+	return m, nil
+}
+
+func loadITSample(data []byte, offset int) (*sample, error) {
+	if len(data) < offset+0x50 {
+		return nil, fmt.Errorf("tracker: IT sample header is too short")
+	}
+	h := data[offset:]
+	if string(h[:4]) != "IMPS" {
+		return nil, fmt.Errorf("tracker: invalid IT sample header")
+	}
+
+	flags := h[0x12]
+	convert := h[0x2e]
+	s := &sample{
+		globalVolume: int(h[0x11]),
+		volume:       int(h[0x13]),
+		panning:      -1,
+		frequency:    float64(binary.LittleEndian.Uint32(h[0x3c:])),
+	}
+	if s.globalVolume > 64 {
+		s.globalVolume = 64
+	}
+	if s.volume > 64 {
+		s.volume = 64
+	}
+	if p := h[0x2f]; p&0x80 != 0 {
+		s.panning = int(p&0x7f) * 255 / 64
+		if s.panning > 255 {
+			s.panning = 255
+		}
+	}
+	if s.frequency == 0 {
+		s.frequency = 8363
+	}
+	if flags&1 == 0 {
+		// No sample data is associated.
+		return s, nil
+	}
+
+	length := int(binary.LittleEndian.Uint32(h[0x30:]))
+	ptr := int(binary.LittleEndian.Uint32(h[0x48:]))
+	is16 := flags&2 != 0
+	if ptr < 0 || ptr > len(data) {
+		return nil, fmt.Errorf("tracker: invalid IT sample pointer")
+	}
+	if length < 0 {
+		return nil, fmt.Errorf("tracker: invalid IT sample length")
+	}
+	if flags&8 != 0 {
+		d, err := decompressIT(data[ptr:], length, is16, convert&4 != 0)
+		if err != nil {
+			return nil, err
+		}
+		s.data = d
+	} else {
+		bytesPerSample := 1
+		if is16 {
+			bytesPerSample = 2
+		}
+		// Truncate the sample to the available data. Compare without multiplication to avoid overflows.
+		if length > (len(data)-ptr)/bytesPerSample {
+			length = (len(data) - ptr) / bytesPerSample
+		}
+		// For stereo samples, the left channel is followed by the right channel. Use only the left channel.
+		s.data = decodePCM(data[ptr:ptr+length*bytesPerSample], is16, convert&1 == 0)
+		if convert&4 != 0 {
+			var v float32
+			for i, d := range s.data {
+				v += d
+				s.data[i] = v
+			}
+		}
+	}
+
+	s.loopType, s.loopStart, s.loopEnd = itLoop(flags&0x10 != 0, flags&0x40 != 0,
+		int(binary.LittleEndian.Uint32(h[0x34:])), int(binary.LittleEndian.Uint32(h[0x38:])), len(s.data))
+	s.sustainLoopType, s.sustainLoopStart, s.sustainLoopEnd = itLoop(flags&0x20 != 0, flags&0x80 != 0,
+		int(binary.LittleEndian.Uint32(h[0x40:])), int(binary.LittleEndian.Uint32(h[0x44:])), len(s.data))
+	return s, nil
+}
+
+func itLoop(enabled, pingPong bool, start, end int, length int) (loopType, int, int) {
+	if end > length {
+		end = length
+	}
+	if !enabled || start >= end {
+		return loopNone, 0, 0
+	}
+	if pingPong {
+		return loopPingPong, start, end
+	}
+	return loopForward, start, end
+}
+
+// itBitReader reads bits in the LSB-first order.
+type itBitReader struct {
+	data []byte
+	pos  int
+	bit  uint
+}
+
+func (r *itBitReader) read(n uint) (uint32, error) {
+	var v uint32
+	for i := uint(0); i < n; i++ {
+		if r.pos >= len(r.data) {
+			return 0, fmt.Errorf("tracker: IT compressed sample is too short")
+		}
+		v |= uint32(r.data[r.pos]>>r.bit&1) << i
+		r.bit++
+		if r.bit == 8 {
+			r.bit = 0
+			r.pos++
+		}
+	}
+	return v, nil
+}
+
+// decompressIT decompresses IT 2.14 compressed samples. If it215 is true, the samples are decoded as IT 2.15
+// compressed samples that are double-delta encoded.
+func decompressIT(data []byte, length int, is16, it215 bool) ([]float32, error) {
+	// The length is not trusted. As every sample takes one bit at least, the data cannot have more samples than
+	// its bits.
+	c := length
+	if c > len(data)*8 {
+		c = len(data) * 8
+	}
+	r := make([]float32, 0, c)
+
+	blockLength := 0x8000
+	maxWidth := uint(9)
+	widthBits := uint(3)
+	scale := float32(1 << 7)
+	if is16 {
+		blockLength = 0x4000
+		maxWidth = 17
+		widthBits = 4
+		scale = 1 << 15
+	}
+	sampleBits := maxWidth - 1
+
+	offset := 0
+	for len(r) < length {
+		if len(data) < offset+2 {
+			return nil, fmt.Errorf("tracker: IT compressed sample is too short")
+		}
+		size := int(binary.LittleEndian.Uint16(data[offset:]))
+		offset += 2
+		if len(data) < offset+size {
+			return nil, fmt.Errorf("tracker: IT compressed sample is too short")
+		}
+		br := &itBitReader{data: data[offset : offset+size]}
+		offset += size
+
+		n := length - len(r)
+		if n > blockLength {
+			n = blockLength
+		}
+		width := maxWidth
+		var d1, d2 int32
+		for i := 0; i < n; {
+			v, err := br.read(width)
+			if err != nil {
+				return nil, err
+			}
+
+			// Check whether the value is a command to change the width.
+			switch {
+			case width < 7:
+				if v == 1<<(width-1) {
+					w, err := br.read(widthBits)
+					if err != nil {
+						return nil, err
+					}
+					w++
+					if uint(w) >= width {
+						w++
+					}
+					width = uint(w)
+					continue
+				}
+			case width < maxWidth:
+				border := (uint32(1)<<sampleBits-1)>>(maxWidth-width) - (1 << (widthBits - 1))
+				if v > border && v <= border+(1<<widthBits) {
+					w := uint(v - border)
+					if w >= width {
+						w++
+					}
+					width = w
+					continue
+				}
+			case width == maxWidth:
+				if v&(1<<sampleBits) != 0 {
+					width = uint(v+1) & 0xff
+					continue
+				}
+			default:
+				return nil, fmt.Errorf("tracker: invalid IT compressed sample")
+			}
+
+			// Sign-extend the value.
+			s := int32(v)
+			if width < maxWidth {
+				shift := 32 - width
+				s = int32(v<<shift) >> shift
+			} else {
+				shift := 32 - sampleBits
+				s = int32(v<<shift) >> shift
+			}
+			d1 += s
+			d2 += d1
+			o := d1
+			if it215 {
+				o = d2
+			}
+			if is16 {
+				r = append(r, float32(int16(o))/scale)
+			} else {
+				r = append(r, float32(int8(o))/scale)
+			}
+			i++
+		}
+	}
+	return r, nil
+}
+
+func loadITInstrument(data []byte, offset int, samples []*sample) (*instrument, error) {
+	if len(data) < offset+0x226 {
+		return nil, fmt.Errorf("tracker: IT instrument is too short")
+	}
+	h := data[offset:]
+	if string(h[:4]) != "IMPI" {
+		return nil, fmt.Errorf("tracker: invalid IT instrument header")
+	}
+
+	inst := &instrument{
+		fadeout:      float64(binary.LittleEndian.Uint16(h[0x14:])) / 1024,
+		globalVolume: float64(h[0x18]) / 128,
+		panning:      -1,
+	}
+	if inst.globalVolume > 1 {
+		inst.globalVolume = 1
+	}
+	if p := h[0x19]; p&0x80 == 0 {
+		inst.panning = int(p) * 255 / 64
+		if inst.panning > 255 {
+			inst.panning = 255
+		}
+	}
+
+	// Collect the samples used in this instrument.
+	indices := map[int]int{}
+	for n := range inst.keymap {
+		note := h[0x40+n*2]
+		smp := int(h[0x41+n*2])
+		inst.keymap[n].note = note + 1
+		inst.keymap[n].sample = -1
+		if smp == 0 || smp > len(samples) || note >= 120 {
+			continue
+		}
+		idx, ok := indices[smp]
+		if !ok {
+			idx = len(inst.samples)
+			indices[smp] = idx
+			inst.samples = append(inst.samples, samples[smp-1])
+		}
+		inst.keymap[n].sample = idx
+	}
+
+	inst.volumeEnvelope = loadITEnvelope(h[0x130:], false)
+	inst.panningEnvelope = loadITEnvelope(h[0x182:], true)
+	return inst, nil
+}
+
+func loadITEnvelope(data []byte, panning bool) *envelope {
+	flags := data[0]
+	pointNum := int(data[1])
+	if flags&1 == 0 || pointNum == 0 {
+		return nil
+	}
+	if pointNum > 25 {
+		pointNum = 25
+	}
+	e := &envelope{}
+	for i := 0; i < pointNum; i++ {
+		p := data[6+i*3:]
+		pt := envelopePoint{
+			tick: int(binary.LittleEndian.Uint16(p[1:])),
+		}
+		if panning {
+			pt.value = float64(int8(p[0])) / 32
+		} else {
+			pt.value = float64(p[0]) / 64
+		}
+		e.points = append(e.points, pt)
+	}
+	if ls, le := int(data[2]), int(data[3]); flags&2 != 0 && ls <= le && le < pointNum {
+		e.loop = true
+		e.loopStart = ls
+		e.loopEnd = le
+	}
+	if ss, se := int(data[4]), int(data[5]); flags&4 != 0 && ss <= se && se < pointNum {
+		e.sustain = true
+		e.sustainStart = ss
+		e.sustainEnd = se
+	}
+	return e
+}
+
+func loadITPattern(data []byte, offset int) (*pattern, error) {
+	if offset == 0 {
+		p := &pattern{rows: make([][]cell, 64)}
+		return p, nil
+	}
+	if len(data) < offset+8 {
+		return nil, fmt.Errorf("tracker: IT pattern is too short")
+	}
+	size := int(binary.LittleEndian.Uint16(data[offset:]))
+	rowNum := int(binary.LittleEndian.Uint16(data[offset+2:]))
+	if rowNum == 0 {
+		rowNum = 64
+	}
+	end := offset + 8 + size
+	if end > len(data) {
+		end = len(data)
+	}
+
+	p := &pattern{rows: make([][]cell, rowNum)}
+	i := offset + 8
+	next := func() byte {
+		if i >= end {
+			return 0
+		}
+		b := data[i]
+		i++
+		return b
+	}
+
+	var masks [64]byte
+	var last [64]cell
+	for r := 0; r < rowNum && i < end; {
+		v := next()
+		if v == 0 {
+			r++
+			continue
+		}
+		ch := int(v-1) & 63
+		if v&0x80 != 0 {
+			masks[ch] = next()
+		}
+		mask := masks[ch]
+
+		var c cell
+		if mask&0x01 != 0 {
+			switch n := next(); {
+			case n < 120:
+				last[ch].note = n + 1
+			case n == 255:
+				last[ch].note = noteOff
+			case n == 254:
+				last[ch].note = noteCut
+			default:
+				last[ch].note = noteFade
+			}
+		}
+		if mask&0x02 != 0 {
+			last[ch].instrument = next()
+		}
+		if mask&0x04 != 0 {
+			last[ch].volumeEffect, last[ch].volumeParam = convertITVolume(next())
+		}
+		if mask&0x08 != 0 {
+			e := next()
+			last[ch].effect, last[ch].param = convertS3MEffect(e, next(), formatIT)
+		}
+		if mask&0x11 != 0 {
+			c.note = last[ch].note
+		}
+		if mask&0x22 != 0 {
+			c.instrument = last[ch].instrument
+		}
+		if mask&0x44 != 0 {
+			c.volumeEffect, c.volumeParam = last[ch].volumeEffect, last[ch].volumeParam
+		}
+		if mask&0x88 != 0 {
+			c.effect, c.param = last[ch].effect, last[ch].param
+		}
+
+		row := p.rows[r]
+		if len(row) <= ch {
+			row = append(row, make([]cell, ch+1-len(row))...)
+			p.rows[r] = row
+		}
+		row[ch] = c
+	}
+	return p, nil
+}
+
+func convertITVolume(v byte) (volumeEffect, byte) {
+	switch {
+	case v <= 64:
+		return volumeSet, v
+	case v <= 74:
+		return volumeFineSlideUp, v - 65
+	case v <= 84:
+		return volumeFineSlideDown, v - 75
+	case v <= 94:
+		return volumeSlideUp, v - 85
+	case v <= 104:
+		return volumeSlideDown, v - 95
+	case v <= 114:
+		return volumePortaDown, v - 105
+	case v <= 124:
+		return volumePortaUp, v - 115
+	case v >= 128 && v <= 192:
+		p := int(v-128) * 255 / 64
+		if p > 255 {
+			p = 255
+		}
+		return volumePanning, byte(p)
+	case v >= 193 && v <= 202:
+		return volumeTonePorta, itTonePortaSpeeds[v-193]
+	case v >= 203 && v <= 212:
+		return volumeVibrato, v - 203
+	}
+	return volumeNone, 0
+}
+
+var itTonePortaSpeeds = [...]byte{0x00, 0x01, 0x04, 0x08, 0x10, 0x20, 0x40, 0x60, 0x80, 0xff}
@@ -0,0 +1,335 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracker
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// modPeriods is the ProTracker periods from C-1 to B-3 without finetune.
+var modPeriods = [...]int{
+	856, 808, 762, 720, 678, 640, 604, 570, 538, 508, 480, 453,
+	428, 404, 381, 360, 339, 320, 302, 285, 269, 254, 240, 226,
+	214, 202, 190, 180, 170, 160, 151, 143, 135, 127, 120, 113,
+}
+
+// modChannelNum returns the number of channels of the signature at the offset 1080.
+// modChannelNum returns 0 if the signature is unknown.
+func modChannelNum(sig string) int {
+	switch sig {
+	case "M.K.", "M!K!", "M&K!", "N.T.", "FLT4", "4CHN":
+		return 4
+	case "FLT8", "OKTA", "OCTA", "CD81":
+		return 8
+	}
+	if strings.HasSuffix(sig, "CHN") {
+		if n, err := strconv.Atoi(sig[:1]); err == nil {
+			return n
+		}
+	}
+	if strings.HasSuffix(sig, "CH") || strings.HasSuffix(sig, "CN") {
+		if n, err := strconv.Atoi(sig[:2]); err == nil {
+			return n
+		}
+	}
+	if strings.HasPrefix(sig, "TDZ") {
+		if n, err := strconv.Atoi(sig[3:]); err == nil {
+			return n
+		}
+	}
+	return 0
+}
+
+// modPeriodToNote converts a period to a note.
+func modPeriodToNote(period int) byte {
+	if period == 0 {
+		return noteNone
+	}
+	best := 0
+	for i, p := range modPeriods {
+		if abs(p-period) < abs(modPeriods[best]-period) {
+			best = i
+		}
+	}
+	// C-1 in ProTracker corresponds to C-3 in the other formats.
+	return byte(best + 3*12 + 1)
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func loadMOD(data []byte) (*module, error) {
+	sampleNum := 31
+	channelNum := 0
+	if len(data) >= 1084 {
+		channelNum = modChannelNum(string(data[1080:1084]))
+	}
+	if channelNum == 0 {
+		// The original Sound Tracker format with 15 samples and without a signature.
+		sampleNum = 15
+		channelNum = 4
+	}
+	if channelNum < 1 || channelNum > 32 {
+		return nil, fmt.Errorf("tracker: invalid number of channels: %d", channelNum)
+	}
+
+	orderOffset := 20 + sampleNum*30
+	patternOffset := orderOffset + 2 + 128
+	if sampleNum == 31 {
+		patternOffset += 4
+	}
+	if len(data) < patternOffset {
+		return nil, fmt.Errorf("tracker: MOD data is too short")
+	}
+
+	m := &module{
+		format:        formatMOD,
+		title:         trimString(data[:20]),
+		channelNum:    channelNum,
+		initialSpeed:  6,
+		initialTempo:  125,
+		globalVolume:  64,
+		referenceNote: 4*12 + 1,
+		amiga:         true,
+	}
+
+	songLength := int(data[orderOffset])
+	if songLength == 0 || songLength > 128 {
+		return nil, fmt.Errorf("tracker: invalid song length: %d", songLength)
+	}
+	if r := int(data[orderOffset+1]); r < songLength {
+		m.restart = r
+	}
+	patternNum := 0
+	for i := 0; i < 128; i++ {
+		p := int(data[orderOffset+2+i])
+		if p > 127 {
+			return nil, fmt.Errorf("tracker: invalid pattern index: %d", p)
+		}
+		if i < songLength {
+			m.orders = append(m.orders, p)
+		}
+		if patternNum < p+1 {
+			patternNum = p + 1
+		}
+	}
+
+	offset := patternOffset
+	for i := 0; i < patternNum; i++ {
+		size := 64 * channelNum * 4
+		if len(data) < offset+size {
+			return nil, fmt.Errorf("tracker: MOD pattern %d is too short", i)
+		}
+		p := &pattern{rows: make([][]cell, 64)}
+		for r := range p.rows {
+			p.rows[r] = make([]cell, channelNum)
+			for c := range p.rows[r] {
+				b := data[offset+(r*channelNum+c)*4:]
+				p.rows[r][c] = modCell(b[0], b[1], b[2], b[3])
+			}
+		}
+		m.patterns = append(m.patterns, p)
+		offset += size
+	}
+
+	for i := 0; i < sampleNum; i++ {
+		h := data[20+i*30:]
+		length := int(binary.BigEndian.Uint16(h[22:24])) * 2
+		finetune := int(h[24] & 0xf)
+		if finetune >= 8 {
+			finetune -= 16
+		}
+		volume := int(h[25])
+		if volume > 64 {
+			volume = 64
+		}
+		loopStart := int(binary.BigEndian.Uint16(h[26:28])) * 2
+		loopLength := int(binary.BigEndian.Uint16(h[28:30])) * 2
+
+		// Some MODs are truncated. Use the data as much as possible.
+		if offset+length > len(data) {
+			length = len(data) - offset
+		}
+		s := &sample{
+			data:         make([]float32, length),
+			volume:       volume,
+			globalVolume: 64,
+			panning:      -1,
+			frequency:    modFrequency(finetune),
+		}
+		for j := range s.data {
+			s.data[j] = float32(int8(data[offset+j])) / 128
+		}
+		offset += length
+
+		if loopLength > 2 && loopStart < length {
+			s.loopType = loopForward
+			s.loopStart = loopStart
+			s.loopEnd = loopStart + loopLength
+			if s.loopEnd > length {
+				s.loopEnd = length
+			}
+		}
+		m.instruments = append(m.instruments, newSampleInstrument(s))
+	}
+
+	// The Amiga has the hard-panned channels in the order of LRRL. Soften the panning a little.
+	for i := 0; i < channelNum; i++ {
+		if i%4 == 0 || i%4 == 3 {
+			m.panning = append(m.panning, 64)
+		} else {
+			m.panning = append(m.panning, 192)
+		}
+		m.channelVolume = append(m.channelVolume, 64)
+	}
+	return m, nil
+}
+
+// modFrequency returns the frequency of the reference note with the finetune in 1/8 semitones.
+func modFrequency(finetune int) float64 {
+	return 8363 * math.Pow(2, float64(finetune)/(8*12))
+}
+
+func modCell(b0, b1, b2, b3 byte) cell {
+	c := cell{
+		note:       modPeriodToNote(int(b0&0xf)<<8 | int(b1)),
+		instrument: b0&0xf0 | b2>>4,
+	}
+	c.effect, c.param = convertMODEffect(b2&0xf, b3, formatMOD)
+	return c
+}
+
+// convertMODEffect converts an effect in MOD or XM to the unified form.
+func convertMODEffect(e, p byte, f format) (effect, byte) {
+	switch e {
+	case 0x0:
+		if p == 0 {
+			return effectNone, 0
+		}
+		return effectArpeggio, p
+	case 0x1:
+		return effectPortaUp, p
+	case 0x2:
+		return effectPortaDown, p
+	case 0x3:
+		return effectTonePorta, p
+	case 0x4:
+		return effectVibrato, p
+	case 0x5:
+		return effectTonePortaVolumeSlide, p
+	case 0x6:
+		return effectVibratoVolumeSlide, p
+	case 0x7:
+		return effectTremolo, p
+	case 0x8:
+		return effectSetPanning, p
+	case 0x9:
+		return effectSampleOffset, p
+	case 0xa:
+		return effectVolumeSlide, p
+	case 0xb:
+		return effectPositionJump, p
+	case 0xc:
+		if p > 64 {
+			p = 64
+		}
+		return effectSetVolume, p
+	case 0xd:
+		// The parameter is in BCD.
+		return effectPatternBreak, (p>>4)*10 + p&0xf
+	case 0xe:
+		x := p & 0xf
+		switch p >> 4 {
+		case 0x1:
+			return effectFinePortaUp, x
+		case 0x2:
+			return effectFinePortaDown, x
+		case 0x4:
+			return effectVibratoWaveform, x
+		case 0x5:
+			return effectFinetune, x
+		case 0x6:
+			return effectPatternLoop, x
+		case 0x7:
+			return effectTremoloWaveform, x
+		case 0x8:
+			return effectSetPanning, x * 0x11
+		case 0x9:
+			return effectRetrigger, x
+		case 0xa:
+			return effectFineVolumeSlideUp, x
+		case 0xb:
+			return effectFineVolumeSlideDown, x
+		case 0xc:
+			return effectNoteCut, x
+		case 0xd:
+			return effectNoteDelay, x
+		case 0xe:
+			return effectPatternDelay, x
+		}
+		return effectNone, 0
+	case 0xf:
+		if p == 0 {
+			// F00 stops the song in ProTracker. Ignore this.
+			return effectNone, 0
+		}
+		if p < 0x20 {
+			return effectSetSpeed, p
+		}
+		return effectSetTempo, p
+	}
+
+	if f != formatXM {
+		return effectNone, 0
+	}
+	switch e {
+	case 'G' - 'A' + 10:
+		return effectGlobalVolume, p
+	case 'H' - 'A' + 10:
+		return effectGlobalVolumeSlide, p
+	case 'K' - 'A' + 10:
+		return effectKeyOff, p
+	case 'P' - 'A' + 10:
+		return effectPanningSlide, p
+	case 'R' - 'A' + 10:
+		return effectMultiRetrigger, p
+	case 'T' - 'A' + 10:
+		return effectTremor, p
+	case 'X' - 'A' + 10:
+		switch p >> 4 {
+		case 1:
+			return effectExtraFinePortaUp, p & 0xf
+		case 2:
+			return effectExtraFinePortaDown, p & 0xf
+		}
+	}
+	return effectNone, 0
+}
+
+// trimString returns a string from a null-terminated or space-padded byte slice.
+func trimString(b []byte) string {
+	if i := strings.IndexByte(string(b), 0); i >= 0 {
+		b = b[:i]
+	}
+	return strings.TrimRight(string(b), " ")
+}
@@ -0,0 +1,265 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracker
+
+type format int
+
+const (
+	formatMOD format = iota
+	formatS3M
+	formatXM
+	formatIT
+)
+
+// Special note values. The usual notes are 1 (C-0) to 120 (B-9).
+const (
+	noteNone = 0
+	noteFade = 253
+	noteOff  = 254
+	noteCut  = 255
+)
+
+// effect is an effect command in a unified form among the formats.
+type effect byte
+
+const (
+	effectNone effect = iota
+	effectArpeggio
+	effectPortaUp
+	effectPortaDown
+	effectFinePortaUp
+	effectFinePortaDown
+	effectExtraFinePortaUp
+	effectExtraFinePortaDown
+	// effectS3MPortaUp and effectS3MPortaDown are Fxx and Exx in S3M and IT. The parameter can represent a fine
+	// or an extra fine slide, and is interpreted after the effect memory is applied.
+	effectS3MPortaUp
+	effectS3MPortaDown
+	effectTonePorta
+	effectVibrato
+	effectFineVibrato
+	effectTonePortaVolumeSlide
+	effectVibratoVolumeSlide
+	effectTremolo
+	effectSetPanning
+	effectSampleOffset
+	effectVolumeSlide
+	effectFineVolumeSlideUp
+	effectFineVolumeSlideDown
+	effectPositionJump
+	effectSetVolume
+	effectPatternBreak
+	effectSetSpeed
+	effectSetTempo
+	effectPatternLoop
+	effectVibratoWaveform
+	effectTremoloWaveform
+	effectRetrigger
+	effectMultiRetrigger
+	effectNoteCut
+	effectNoteDelay
+	effectPatternDelay
+	effectFinetune
+	effectGlobalVolume
+	effectGlobalVolumeSlide
+	effectKeyOff
+	effectPanningSlide
+	effectTremor
+	effectChannelVolume
+	effectChannelVolumeSlide
+)
+
+// volumeEffect is an effect in the volume column.
+type volumeEffect byte
+
+const (
+	volumeNone volumeEffect = iota
+	volumeSet
+	volumeSlideUp
+	volumeSlideDown
+	volumeFineSlideUp
+	volumeFineSlideDown
+	volumePanning
+	volumePanningSlideLeft
+	volumePanningSlideRight
+	volumeTonePorta
+	volumeVibratoSpeed
+	volumeVibrato
+	volumePortaUp
+	volumePortaDown
+)
+
+type cell struct {
+	note       byte
+	instrument byte
+
+	volumeEffect volumeEffect
+	volumeParam  byte
+
+	effect effect
+	param  byte
+}
+
+type pattern struct {
+	rows [][]cell
+}
+
+type loopType int
+
+const (
+	loopNone loopType = iota
+	loopForward
+	loopPingPong
+)
+
+type sample struct {
+	// data is the mono samples in [-1, 1].
+	data []float32
+
+	loopType  loopType
+	loopStart int
+	loopEnd   int
+
+	// sustainLoopType is the loop while the key is held (IT).
+	sustainLoopType  loopType
+	sustainLoopStart int
+	sustainLoopEnd   int
+
+	// volume is the default volume in [0, 64].
+	volume int
+
+	// globalVolume is the sample's global volume in [0, 64] (IT).
+	globalVolume int
+
+	// panning is the default panning in [0, 255], or -1 if the sample doesn't have a default panning.
+	panning int
+
+	// frequency is the sample rate when the reference note (C-4 in MOD/S3M/XM, C-5 in IT) is played.
+	frequency float64
+}
+
+type envelopePoint struct {
+	tick  int
+	value float64
+}
+
+type envelope struct {
+	points []envelopePoint
+
+	loop      bool
+	loopStart int
+	loopEnd   int
+
+	sustain      bool
+	sustainStart int
+	sustainEnd   int
+}
+
+// value returns the envelope value at the tick.
+func (e *envelope) value(tick int) float64 {
+	ps := e.points
+	if len(ps) == 0 {
+		return 0
+	}
+	if tick <= ps[0].tick {
+		return ps[0].value
+	}
+	for i := 1; i < len(ps); i++ {
+		if tick < ps[i].tick {
+			p0, p1 := ps[i-1], ps[i]
+			return p0.value + (p1.value-p0.value)*float64(tick-p0.tick)/float64(p1.tick-p0.tick)
+		}
+	}
+	return ps[len(ps)-1].value
+}
+
+// next returns the next tick. released reports whether the key is released.
+func (e *envelope) next(tick int, released bool) int {
+	tick++
+	ps := e.points
+	if e.sustain && !released && e.sustainEnd < len(ps) && tick > ps[e.sustainEnd].tick {
+		return ps[e.sustainStart].tick
+	}
+	if e.loop && e.loopEnd < len(ps) && tick > ps[e.loopEnd].tick {
+		return ps[e.loopStart].tick
+	}
+	return tick
+}
+
+type instrument struct {
+	// keymap maps a note (0-based) to a note to play and the sample index in samples.
+	// A negative sample index means no sample.
+	keymap [120]struct {
+		note   byte
+		sample int
+	}
+	samples []*sample
+
+	volumeEnvelope  *envelope
+	panningEnvelope *envelope
+
+	// fadeout is the amount to decrease the fade volume [0, 1] per tick after the key is released.
+	fadeout float64
+
+	// globalVolume is the instrument's global volume in [0, 1] (IT).
+	globalVolume float64
+
+	// panning is the default panning in [0, 255], or -1 if the instrument doesn't have a default panning.
+	panning int
+}
+
+// newSampleInstrument returns an instrument that plays the sample for all the notes.
+func newSampleInstrument(s *sample) *instrument {
+	i := &instrument{
+		samples:      []*sample{s},
+		globalVolume: 1,
+		panning:      -1,
+	}
+	for n := range i.keymap {
+		i.keymap[n].note = byte(n + 1)
+		i.keymap[n].sample = 0
+	}
+	return i
+}
+
+type module struct {
+	format     format
+	title      string
+	channelNum int
+
+	// orders is the pattern indices in the playing order. A negative value means the end of the song.
+	orders  []int
+	restart int
+
+	patterns    []*pattern
+	instruments []*instrument
+
+	// panning is the initial panning of each channel in [0, 255].
+	panning []int
+
+	// channelVolume is the initial volume of each channel in [0, 64].
+	channelVolume []int
+
+	initialSpeed  int
+	initialTempo  int
+	globalVolume  int
+	linearPeriods bool
+
+	// referenceNote is the note played at the sample's frequency.
+	referenceNote int
+
+	// amiga reports whether the frequency is calculated in the Amiga (PAL) clock.
+	amiga bool
+}
@@ -0,0 +1,1088 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracker
+
+import (
+	"math"
+)
+
+// amigaClock is the clock to convert a period to a frequency. The periods are 4 times finer than the original
+// Amiga periods so that the fine slides in S3M, XM and IT can be represented.
+const amigaClock = 8363 * 1712
+
+// rampDuration is the duration in seconds to ramp the volumes to avoid clicks.
+const rampDuration = 0.002
+
+type channel struct {
+	instrument *instrument
+	sample     *sample
+
+	// note is the note after the instrument's keymap is applied.
+	note int
+
+	// frequency is the sample's frequency of the reference note, including the finetune.
+	frequency float64
+
+	period       float64
+	targetPeriod float64
+	volume       int
+	panning      int
+
+	channelVolume int
+
+	pos      float64
+	backward bool
+	active   bool
+
+	keyOn      bool
+	fading     bool
+	fadeVolume float64
+
+	volumeEnvelopeTick  int
+	panningEnvelopeTick int
+
+	cell         cell
+	delayedCell  bool
+	arpeggio     int
+	vibratoDelta float64
+	tremoloDelta int
+	tremorOff    bool
+
+	// Effect memories.
+	arpeggioParam      byte
+	portaParam         byte
+	portaUpParam       byte
+	portaDownParam     byte
+	finePortaUpParam   byte
+	finePortaDownParam byte
+	tonePortaSpeed     byte
+	vibratoSpeed       byte
+	vibratoDepth       byte
+	vibratoPos         int
+	vibratoWaveform    byte
+	tremoloSpeed       byte
+	tremoloDepth       byte
+	tremoloPos         int
+	tremoloWaveform    byte
+	volumeSlideParam   byte
+	fineVolumeUpParam  byte
+	fineVolumeDnParam  byte
+	sampleOffsetParam  byte
+	retriggerParam     byte
+	retriggerCount     int
+	tremorParam        byte
+	tremorCount        int
+	globalSlideParam   byte
+	panningSlideParam  byte
+	channelSlideParam  byte
+	patternLoopRow     int
+	patternLoopCount   int
+
+	// Mixing states.
+	muted       bool
+	gainL       float32
+	gainR       float32
+	targetGainL float32
+	targetGainR float32
+	rampFrames  int
+	step        float64
+	rampDeltaL  float32
+	rampDeltaR  float32
+}
+
+type player struct {
+	module     *module
+	sampleRate int
+	channels   []channel
+
+	speed        int
+	tempo        int
+	globalVolume int
+
+	order int
+	row   int
+	tick  int
+
+	patternDelay    int
+	patternDelaySet bool
+
+	jump            bool
+	jumpOrder       int
+	jumpRow         int
+	patternLoopJump bool
+
+	// visited records the positions where the song enters a pattern. When the song enters a visited position,
+	// the song is regarded as looping.
+	visited map[int]struct{}
+	looping bool
+	ended   bool
+
+	framesInTick  int
+	tickRemainder float64
+	gain          float32
+}
+
+func newPlayer(m *module, sampleRate int) *player {
+	p := &player{
+		module:       m,
+		sampleRate:   sampleRate,
+		channels:     make([]channel, m.channelNum),
+		speed:        m.initialSpeed,
+		tempo:        m.initialTempo,
+		globalVolume: m.globalVolume,
+		visited:      map[int]struct{}{},
+		gain:         0.5 / float32(math.Sqrt(math.Max(1, float64(m.channelNum)/4))),
+	}
+	for i := range p.channels {
+		c := &p.channels[i]
+		c.panning = m.panning[i]
+		c.channelVolume = m.channelVolume[i]
+	}
+	p.order = -1
+	p.setPosition(0, 0)
+	return p
+}
+
+// pattern returns the pattern at the current order.
+func (p *player) pattern() *pattern {
+	idx := p.module.orders[p.order]
+	if idx < 0 || idx >= len(p.module.patterns) || len(p.module.patterns[idx].rows) == 0 {
+		return emptyPattern
+	}
+	return p.module.patterns[idx]
+}
+
+var emptyPattern = &pattern{rows: make([][]cell, 64)}
+
+// setPosition moves the position to the given order and row. setPosition marks the song as ended if the
+// position was already visited and the player is not looping.
+func (p *player) setPosition(order, row int) {
+	orders := p.module.orders
+	// Skip the end markers and the orders out of range.
+	for i := 0; ; i++ {
+		if order >= len(orders) || orders[order] < 0 {
+			order = p.module.restart
+			row = 0
+		}
+		if order < len(orders) && orders[order] >= 0 {
+			break
+		}
+		order++
+		if i > len(orders) {
+			// No valid orders.
+			p.ended = true
+			return
+		}
+	}
+
+	if order != p.order {
+		for i := range p.channels {
+			p.channels[i].patternLoopRow = 0
+			p.channels[i].patternLoopCount = 0
+		}
+	}
+	p.order = order
+	if row >= len(p.pattern().rows) {
+		row = 0
+	}
+	p.row = row
+
+	key := order<<16 | row
+	if _, ok := p.visited[key]; ok {
+		if !p.looping {
+			p.ended = true
+		}
+		// Start a new pass.
+		p.visited = map[int]struct{}{}
+	}
+	p.visited[key] = struct{}{}
+}
+
+func (p *player) nextRow() {
+	p.tick = 0
+	p.patternDelay = 0
+	p.patternDelaySet = false
+
+	switch {
+	case p.patternLoopJump:
+		p.patternLoopJump = false
+		p.jump = false
+		p.row = p.jumpRow
+	case p.jump:
+		p.jump = false
+		p.setPosition(p.jumpOrder, p.jumpRow)
+	default:
+		if p.row+1 < len(p.pattern().rows) {
+			p.row++
+			return
+		}
+		p.setPosition(p.order+1, 0)
+	}
+}
+
+// render renders frames into dst as stereo samples and returns the number of the rendered frames.
+// If dst is nil, render only advances the states.
+func (p *player) render(dst []float32, frames int) int {
+	n := 0
+	for n < frames {
+		if p.framesInTick == 0 {
+			if p.ended {
+				break
+			}
+			p.processTick()
+			f := float64(p.sampleRate)*5/float64(2*p.tempo) + p.tickRemainder
+			p.framesInTick = int(f)
+			p.tickRemainder = f - float64(p.framesInTick)
+			for i := range p.channels {
+				c := &p.channels[i]
+				p.prepareMixing(c, p.framesInTick, dst == nil)
+				p.updateEnvelopes(c)
+			}
+			p.tick++
+			if p.tick >= p.speed*(1+p.patternDelay) {
+				p.nextRow()
+			}
+			if p.framesInTick == 0 {
+				continue
+			}
+		}
+
+		size := frames - n
+		if size > p.framesInTick {
+			size = p.framesInTick
+		}
+		for i := range p.channels {
+			c := &p.channels[i]
+			if dst == nil {
+				c.skip(size)
+				continue
+			}
+			c.mix(dst[2*n:2*(n+size)], p.gain)
+		}
+		n += size
+		p.framesInTick -= size
+	}
+	return n
+}
+
+func (p *player) processTick() {
+	if p.tick == 0 {
+		p.processRow()
+	} else {
+		t := p.tick % p.speed
+		for i := range p.channels {
+			c := &p.channels[i]
+			if c.delayedCell && p.tick < p.speed && t == int(c.cell.param) {
+				c.delayedCell = false
+				p.triggerCell(c)
+				p.processVolumeColumn(c, true)
+			}
+			p.processVolumeColumn(c, false)
+			p.processEffect(c, t, false)
+		}
+	}
+}
+
+func (p *player) processRow() {
+	row := p.pattern().rows[p.row]
+	positionJump := -1
+	patternBreak := -1
+
+	for i := range p.channels {
+		c := &p.channels[i]
+		if i < len(row) {
+			c.cell = row[i]
+		} else {
+			c.cell = cell{}
+		}
+		c.arpeggio = 0
+		c.tremoloDelta = 0
+		c.tremorOff = false
+		if !isVibrato(c.cell) {
+			c.vibratoDelta = 0
+		}
+
+		if c.cell.effect == effectNoteDelay && c.cell.param > 0 {
+			c.delayedCell = true
+			continue
+		}
+		c.delayedCell = false
+		p.triggerCell(c)
+		p.processVolumeColumn(c, true)
+		p.processEffect(c, 0, true)
+
+		switch c.cell.effect {
+		case effectPositionJump:
+			positionJump = int(c.cell.param)
+		case effectPatternBreak:
+			patternBreak = int(c.cell.param)
+		case effectPatternLoop:
+			if c.cell.param == 0 {
+				c.patternLoopRow = p.row
+				break
+			}
+			if c.patternLoopCount == 0 {
+				c.patternLoopCount = int(c.cell.param)
+			} else {
+				c.patternLoopCount--
+			}
+			if c.patternLoopCount > 0 {
+				p.patternLoopJump = true
+				p.jumpRow = c.patternLoopRow
+			}
+		case effectPatternDelay:
+			if !p.patternDelaySet {
+				p.patternDelay = int(c.cell.param)
+				p.patternDelaySet = true
+			}
+		}
+	}
+
+	if !p.patternLoopJump && (positionJump >= 0 || patternBreak >= 0) {
+		p.jump = true
+		p.jumpOrder = p.order + 1
+		if positionJump >= 0 {
+			p.jumpOrder = positionJump
+		}
+		p.jumpRow = 0
+		if patternBreak >= 0 {
+			p.jumpRow = patternBreak
+		}
+	}
+}
+
+func isVibrato(c cell) bool {
+	switch c.effect {
+	case effectVibrato, effectFineVibrato, effectVibratoVolumeSlide:
+		return true
+	}
+	return c.volumeEffect == volumeVibrato
+}
+
+func isTonePorta(c cell) bool {
+	switch c.effect {
+	case effectTonePorta, effectTonePortaVolumeSlide:
+		return true
+	}
+	return c.volumeEffect == volumeTonePorta
+}
+
+// sampleForNote returns the note and the sample to play for the note.
+func (c *channel) sampleForNote(note int) (int, *sample) {
+	if c.instrument == nil || note < 1 || note > 120 {
+		return 0, nil
+	}
+	k := c.instrument.keymap[note-1]
+	if k.sample < 0 || k.sample >= len(c.instrument.samples) {
+		return 0, nil
+	}
+	return int(k.note), c.instrument.samples[k.sample]
+}
+
+func (p *player) periodForNote(c *channel, note int) float64 {
+	n := float64(note - p.module.referenceNote)
+	if p.module.linearPeriods {
+		return 4608 - n*64
+	}
+	return amigaClock / (c.frequency * math.Pow(2, n/12))
+}
+
+func (p *player) triggerCell(c *channel) {
+	cl := c.cell
+	m := p.module
+
+	var inst *instrument
+	if cl.instrument > 0 && int(cl.instrument) <= len(m.instruments) {
+		inst = m.instruments[cl.instrument-1]
+		c.instrument = inst
+	}
+
+	switch cl.note {
+	case noteNone:
+	case noteOff:
+		p.keyOff(c)
+	case noteCut:
+		c.volume = 0
+		c.active = false
+	case noteFade:
+		c.fading = true
+	default:
+		note, s := c.sampleForNote(int(cl.note))
+		if s == nil {
+			c.active = false
+			break
+		}
+		if isTonePorta(cl) && c.active && c.sample != nil {
+			// Slide to the note without triggering.
+			c.targetPeriod = p.periodForNote(c, note)
+			break
+		}
+		c.sample = s
+		c.note = note
+		c.frequency = s.frequency
+		c.period = p.periodForNote(c, note)
+		c.targetPeriod = c.period
+		c.pos = 0
+		c.backward = false
+		c.active = true
+		c.retriggerCount = 0
+		if c.vibratoWaveform&4 == 0 {
+			c.vibratoPos = 0
+		}
+		if c.tremoloWaveform&4 == 0 {
+			c.tremoloPos = 0
+		}
+		if cl.effect == effectSampleOffset {
+			if cl.param != 0 {
+				c.sampleOffsetParam = cl.param
+			}
+			c.pos = float64(c.sampleOffsetParam) * 256
+			if int(c.pos) >= len(s.data) {
+				c.active = false
+			}
+		}
+		if inst == nil {
+			p.resetEnvelopes(c)
+		}
+	}
+
+	if inst != nil && c.sample != nil {
+		s := c.sample
+		if cl.note >= 1 && cl.note <= 120 {
+			if _, s2 := c.sampleForNote(int(cl.note)); s2 != nil {
+				s = s2
+			}
+		}
+		c.volume = s.volume
+		if s.panning >= 0 {
+			c.panning = s.panning
+		}
+		if inst.panning >= 0 {
+			c.panning = inst.panning
+		}
+		if cl.note != noteOff {
+			p.resetEnvelopes(c)
+		}
+	}
+}
+
+func (p *player) resetEnvelopes(c *channel) {
+	c.keyOn = true
+	c.fading = false
+	c.fadeVolume = 1
+	c.volumeEnvelopeTick = 0
+	c.panningEnvelopeTick = 0
+}
+
+func (p *player) keyOff(c *channel) {
+	c.keyOn = false
+	if c.instrument == nil || c.instrument.volumeEnvelope == nil {
+		if p.module.format == formatXM {
+			c.volume = 0
+			return
+		}
+	}
+	c.fading = true
+}
+
+func clamp(x, min, max int) int {
+	if x < min {
+		return min
+	}
+	if x > max {
+		return max
+	}
+	return x
+}
+
+func (p *player) processVolumeColumn(c *channel, first bool) {
+	if c.delayedCell {
+		return
+	}
+	param := int(c.cell.volumeParam)
+	switch c.cell.volumeEffect {
+	case volumeSet:
+		if first {
+			c.volume = clamp(param, 0, 64)
+		}
+	case volumeSlideUp:
+		if !first {
+			c.volume = clamp(c.volume+param, 0, 64)
+		}
+	case volumeSlideDown:
+		if !first {
+			c.volume = clamp(c.volume-param, 0, 64)
+		}
+	case volumeFineSlideUp:
+		if first {
+			c.volume = clamp(c.volume+param, 0, 64)
+		}
+	case volumeFineSlideDown:
+		if first {
+			c.volume = clamp(c.volume-param, 0, 64)
+		}
+	case volumePanning:
+		if first {
+			c.panning = param
+		}
+	case volumePanningSlideLeft:
+		if !first {
+			c.panning = clamp(c.panning-param, 0, 255)
+		}
+	case volumePanningSlideRight:
+		if !first {
+			c.panning = clamp(c.panning+param, 0, 255)
+		}
+	case volumeTonePorta:
+		if first {
+			if param != 0 {
+				c.tonePortaSpeed = byte(param)
+			}
+		} else {
+			p.tonePorta(c)
+		}
+	case volumeVibratoSpeed:
+		if first && param != 0 {
+			c.vibratoSpeed = byte(param)
+		}
+	case volumeVibrato:
+		if param != 0 {
+			c.vibratoDepth = byte(param)
+		}
+		p.vibrato(c, first, 8)
+	case volumePortaUp:
+		if !first {
+			p.slidePeriod(c, -4*float64(param))
+		}
+	case volumePortaDown:
+		if !first {
+			p.slidePeriod(c, 4*float64(param))
+		}
+	}
+}
+
+// memory returns the parameter with the effect memory.
+func (p *player) memory(param byte, mem *byte) byte {
+	if param == 0 && p.module.format != formatMOD {
+		return *mem
+	}
+	*mem = param
+	return param
+}
+
+func (p *player) processEffect(c *channel, tick int, first bool) {
+	if c.delayedCell {
+		return
+	}
+	param := c.cell.param
+	x, y := int(param>>4), int(param&0xf)
+
+	switch c.cell.effect {
+	case effectArpeggio:
+		if p.module.format == formatS3M || p.module.format == formatIT {
+			param = p.memory(param, &c.arpeggioParam)
+			x, y = int(param>>4), int(param&0xf)
+		}
+		switch tick % 3 {
+		case 0:
+			c.arpeggio = 0
+		case 1:
+			c.arpeggio = x
+		case 2:
+			c.arpeggio = y
+		}
+	case effectPortaUp:
+		param = p.memory(param, &c.portaUpParam)
+		if !first {
+			p.slidePeriod(c, -4*float64(param))
+		}
+	case effectPortaDown:
+		param = p.memory(param, &c.portaDownParam)
+		if !first {
+			p.slidePeriod(c, 4*float64(param))
+		}
+	case effectFinePortaUp:
+		param = p.memory(param, &c.finePortaUpParam)
+		if first {
+			p.slidePeriod(c, -4*float64(param))
+		}
+	case effectFinePortaDown:
+		param = p.memory(param, &c.finePortaDownParam)
+		if first {
+			p.slidePeriod(c, 4*float64(param))
+		}
+	case effectExtraFinePortaUp:
+		if first {
+			p.slidePeriod(c, -float64(param))
+		}
+	case effectExtraFinePortaDown:
+		if first {
+			p.slidePeriod(c, float64(param))
+		}
+	case effectS3MPortaUp, effectS3MPortaDown:
+		param = p.memory(param, &c.portaParam)
+		var delta float64
+		switch {
+		case param >= 0xf0:
+			if first {
+				delta = 4 * float64(param&0xf)
+			}
+		case param >= 0xe0:
+			if first {
+				delta = float64(param & 0xf)
+			}
+		default:
+			if !first {
+				delta = 4 * float64(param)
+			}
+		}
+		if c.cell.effect == effectS3MPortaUp {
+			delta = -delta
+		}
+		p.slidePeriod(c, delta)
+	case effectTonePorta:
+		if first {
+			if param != 0 {
+				c.tonePortaSpeed = param
+			}
+		} else {
+			p.tonePorta(c)
+		}
+	case effectVibrato, effectFineVibrato:
+		if x != 0 {
+			c.vibratoSpeed = byte(x)
+		}
+		if y != 0 {
+			c.vibratoDepth = byte(y)
+		}
+		scale := 8.0
+		if c.cell.effect == effectFineVibrato {
+			scale = 2
+		}
+		p.vibrato(c, first, scale)
+	case effectTonePortaVolumeSlide:
+		if !first {
+			p.tonePorta(c)
+		}
+		p.volumeSlide(c, p.memory(param, &c.volumeSlideParam), first)
+	case effectVibratoVolumeSlide:
+		p.vibrato(c, first, 8)
+		p.volumeSlide(c, p.memory(param, &c.volumeSlideParam), first)
+	case effectTremolo:
+		if x != 0 {
+			c.tremoloSpeed = byte(x)
+		}
+		if y != 0 {
+			c.tremoloDepth = byte(y)
+		}
+		c.tremoloDelta = int(waveform(c.tremoloWaveform, c.tremoloPos) * float64(c.tremoloDepth) * 4)
+		if !first {
+			c.tremoloPos = (c.tremoloPos + int(c.tremoloSpeed)) & 63
+		}
+	case effectSetPanning:
+		if first {
+			c.panning = int(param)
+		}
+	case effectVolumeSlide:
+		p.volumeSlide(c, p.memory(param, &c.volumeSlideParam), first)
+	case effectFineVolumeSlideUp:
+		param = p.memory(param, &c.fineVolumeUpParam)
+		if first {
+			c.volume = clamp(c.volume+int(param), 0, 64)
+		}
+	case effectFineVolumeSlideDown:
+		param = p.memory(param, &c.fineVolumeDnParam)
+		if first {
+			c.volume = clamp(c.volume-int(param), 0, 64)
+		}
+	case effectSetVolume:
+		if first {
+			c.volume = clamp(int(param), 0, 64)
+		}
+	case effectSetSpeed:
+		if first && param > 0 {
+			p.speed = int(param)
+		}
+	case effectSetTempo:
+		if first && param >= 32 {
+			p.tempo = int(param)
+		}
+	case effectVibratoWaveform:
+		if first {
+			c.vibratoWaveform = param & 7
+		}
+	case effectTremoloWaveform:
+		if first {
+			c.tremoloWaveform = param & 7
+		}
+	case effectRetrigger:
+		if param > 0 && tick > 0 && tick%int(param) == 0 {
+			c.retrigger()
+		}
+	case effectMultiRetrigger:
+		param = p.memory(param, &c.retriggerParam)
+		x, y = int(param>>4), int(param&0xf)
+		if first {
+			break
+		}
+		c.retriggerCount++
+		if y == 0 || c.retriggerCount < y {
+			break
+		}
+		c.retriggerCount = 0
+		c.retrigger()
+		switch x {
+		case 1, 2, 3, 4, 5:
+			c.volume -= 1 << (x - 1)
+		case 6:
+			c.volume = c.volume * 2 / 3
+		case 7:
+			c.volume /= 2
+		case 9, 10, 11, 12, 13:
+			c.volume += 1 << (x - 9)
+		case 14:
+			c.volume = c.volume * 3 / 2
+		case 15:
+			c.volume *= 2
+		}
+		c.volume = clamp(c.volume, 0, 64)
+	case effectNoteCut:
+		if tick == int(param) {
+			c.volume = 0
+		}
+	case effectFinetune:
+		if first {
+			ft := int(param)
+			if ft >= 8 {
+				ft -= 16
+			}
+			c.frequency = modFrequency(ft)
+			if c.cell.note >= 1 && c.cell.note <= 120 {
+				c.period = p.periodForNote(c, c.note)
+				c.targetPeriod = c.period
+			}
+		}
+	case effectGlobalVolume:
+		if first {
+			p.globalVolume = clamp(int(param), 0, 64)
+		}
+	case effectGlobalVolumeSlide:
+		param = p.memory(param, &c.globalSlideParam)
+		if !first {
+			if param>>4 != 0 {
+				p.globalVolume = clamp(p.globalVolume+int(param>>4), 0, 64)
+			} else {
+				p.globalVolume = clamp(p.globalVolume-int(param&0xf), 0, 64)
+			}
+		}
+	case effectKeyOff:
+		if tick == int(param) {
+			p.keyOff(c)
+		}
+	case effectPanningSlide:
+		param = p.memory(param, &c.panningSlideParam)
+		if !first {
+			if param>>4 != 0 {
+				c.panning = clamp(c.panning+int(param>>4), 0, 255)
+			} else {
+				c.panning = clamp(c.panning-int(param&0xf), 0, 255)
+			}
+		}
+	case effectTremor:
+		param = p.memory(param, &c.tremorParam)
+		on, off := int(param>>4)+1, int(param&0xf)+1
+		c.tremorOff = c.tremorCount%(on+off) >= on
+		c.tremorCount++
+	case effectChannelVolume:
+		if first {
+			c.channelVolume = clamp(int(param), 0, 64)
+		}
+	case effectChannelVolumeSlide:
+		param = p.memory(param, &c.channelSlideParam)
+		c.channelVolume = s3mVolumeSlide(c.channelVolume, param, first)
+	}
+}
+
+func (c *channel) retrigger() {
+	if c.sample == nil {
+		return
+	}
+	c.pos = 0
+	c.backward = false
+	c.active = true
+}
+
+// slidePeriod adds delta to the period. A negative delta raises the pitch.
+func (p *player) slidePeriod(c *channel, delta float64) {
+	if delta == 0 {
+		return
+	}
+	c.period = math.Max(1, math.Min(c.period+delta, 1<<20))
+}
+
+func (p *player) tonePorta(c *channel) {
+	speed := 4 * float64(c.tonePortaSpeed)
+	if c.period < c.targetPeriod {
+		c.period = math.Min(c.period+speed, c.targetPeriod)
+	} else {
+		c.period = math.Max(c.period-speed, c.targetPeriod)
+	}
+}
+
+func (p *player) vibrato(c *channel, first bool, scale float64) {
+	c.vibratoDelta = waveform(c.vibratoWaveform, c.vibratoPos) * float64(c.vibratoDepth) * scale
+	if !first {
+		c.vibratoPos = (c.vibratoPos + int(c.vibratoSpeed)) & 63
+	}
+}
+
+func (p *player) volumeSlide(c *channel, param byte, first bool) {
+	if p.module.format == formatS3M || p.module.format == formatIT {
+		c.volume = s3mVolumeSlide(c.volume, param, first)
+		return
+	}
+	if first {
+		return
+	}
+	if param>>4 != 0 {
+		c.volume = clamp(c.volume+int(param>>4), 0, 64)
+	} else {
+		c.volume = clamp(c.volume-int(param&0xf), 0, 64)
+	}
+}
+
+// s3mVolumeSlide applies a volume slide in S3M and IT, where the parameter can represent a fine slide.
+func s3mVolumeSlide(volume int, param byte, first bool) int {
+	x, y := int(param>>4), int(param&0xf)
+	switch {
+	case y == 0xf && x != 0:
+		if first {
+			volume += x
+		}
+	case x == 0xf && y != 0:
+		if first {
+			volume -= y
+		}
+	case y == 0:
+		if !first {
+			volume += x
+		}
+	case x == 0:
+		if !first {
+			volume -= y
+		}
+	}
+	return clamp(volume, 0, 64)
+}
+
+// waveform returns the value in [-1, 1] of the vibrato or tremolo waveform at pos in [0, 64).
+func waveform(typ byte, pos int) float64 {
+	switch typ & 3 {
+	case 1:
+		// Ramp down.
+		return 1 - float64(pos)/32
+	case 2:
+		// Square.
+		if pos < 32 {
+			return 1
+		}
+		return -1
+	}
+	return math.Sin(float64(pos) * math.Pi / 32)
+}
+
+func (p *player) updateEnvelopes(c *channel) {
+	if !c.active {
+		return
+	}
+	inst := c.instrument
+	if inst == nil {
+		return
+	}
+	if e := inst.volumeEnvelope; e != nil {
+		c.volumeEnvelopeTick = e.next(c.volumeEnvelopeTick, !c.keyOn)
+	}
+	if e := inst.panningEnvelope; e != nil {
+		c.panningEnvelopeTick = e.next(c.panningEnvelopeTick, !c.keyOn)
+	}
+	if c.fading {
+		c.fadeVolume = math.Max(0, c.fadeVolume-inst.fadeout)
+	}
+}
+
+// prepareMixing calculates the mixing parameters for the next tick.
+func (p *player) prepareMixing(c *channel, frames int, immediate bool) {
+	c.targetGainL = 0
+	c.targetGainR = 0
+	c.step = 0
+	if c.active && c.sample != nil {
+		// Advance the position even when the channel is muted.
+		c.step = p.frequency(c) / float64(p.sampleRate)
+	}
+	if c.step != 0 && !c.muted && !c.tremorOff {
+		v := float64(clamp(c.volume+c.tremoloDelta, 0, 64)) / 64
+		v *= float64(c.channelVolume) / 64
+		v *= float64(p.globalVolume) / 64
+		v *= float64(c.sample.globalVolume) / 64
+		pan := float64(c.panning)
+		if inst := c.instrument; inst != nil {
+			v *= inst.globalVolume
+			if e := inst.volumeEnvelope; e != nil {
+				v *= e.value(c.volumeEnvelopeTick)
+			}
+			if e := inst.panningEnvelope; e != nil {
+				pan += e.value(c.panningEnvelopeTick) * (128 - math.Abs(pan-128))
+			}
+			v *= c.fadeVolume
+		}
+		theta := math.Max(0, math.Min(pan, 255)) / 255 * math.Pi / 2
+		c.targetGainL = float32(v * math.Cos(theta))
+		c.targetGainR = float32(v * math.Sin(theta))
+	}
+
+	if immediate || frames == 0 {
+		c.gainL = c.targetGainL
+		c.gainR = c.targetGainR
+		c.rampFrames = 0
+		return
+	}
+	c.rampFrames = int(rampDuration * float64(p.sampleRate))
+	if c.rampFrames > frames {
+		c.rampFrames = frames
+	}
+	if c.rampFrames < 1 {
+		c.rampFrames = 1
+	}
+	c.rampDeltaL = (c.targetGainL - c.gainL) / float32(c.rampFrames)
+	c.rampDeltaR = (c.targetGainR - c.gainR) / float32(c.rampFrames)
+}
+
+// frequency returns the current frequency of the channel.
+func (p *player) frequency(c *channel) float64 {
+	period := c.period + c.vibratoDelta
+	var f float64
+	if p.module.linearPeriods {
+		f = c.frequency * math.Pow(2, (4608-period)/768)
+	} else {
+		f = amigaClock / math.Max(1, period)
+	}
+	if c.arpeggio != 0 {
+		f *= math.Pow(2, float64(c.arpeggio)/12)
+	}
+	return f
+}
+
+// loop returns the current loop.
+func (c *channel) loop() (loopType, int, int) {
+	s := c.sample
+	if c.keyOn && s.sustainLoopType != loopNone {
+		return s.sustainLoopType, s.sustainLoopStart, s.sustainLoopEnd
+	}
+	return s.loopType, s.loopStart, s.loopEnd
+}
+
+// advance advances the position by delta.
+func (c *channel) advance(delta float64) {
+	if c.backward {
+		c.pos -= delta
+	} else {
+		c.pos += delta
+	}
+
+	typ, start, end := c.loop()
+	switch typ {
+	case loopNone:
+		if c.pos >= float64(len(c.sample.data)) {
+			c.active = false
+		}
+	case loopForward:
+		if c.pos >= float64(end) {
+			c.pos = float64(start) + math.Mod(c.pos-float64(start), float64(end-start))
+		}
+	case loopPingPong:
+		if (!c.backward && c.pos >= float64(end)) || (c.backward && c.pos < float64(start)) {
+			l := float64(end - start)
+			var u float64
+			if c.backward {
+				u = l + float64(end) - c.pos
+			} else {
+				u = c.pos - float64(start)
+			}
+			u = math.Mod(u, 2*l)
+			if u < l {
+				c.backward = false
+				c.pos = float64(start) + u
+			} else {
+				c.backward = true
+				c.pos = float64(end) - (u - l)
+			}
+		}
+	}
+}
+
+// value returns the interpolated sample value at the current position.
+func (c *channel) value() float32 {
+	data := c.sample.data
+	i := int(c.pos)
+	if i < 0 || i >= len(data) {
+		return 0
+	}
+	frac := float32(c.pos - float64(i))
+	v0 := data[i]
+	j := i + 1
+	typ, start, end := c.loop()
+	if typ == loopForward && j >= end {
+		j = start
+	}
+	v1 := v0
+	if j < len(data) {
+		v1 = data[j]
+	}
+	return v0 + (v1-v0)*frac
+}
+
+func (c *channel) skip(frames int) {
+	if !c.active || c.sample == nil || c.step == 0 {
+		return
+	}
+	c.advance(c.step * float64(frames))
+}
+
+func (c *channel) mix(dst []float32, gain float32) {
+	if !c.active || c.sample == nil {
+		return
+	}
+	if c.step == 0 {
+		return
+	}
+	for i := 0; i < len(dst)/2; i++ {
+		if c.rampFrames > 0 {
+			c.gainL += c.rampDeltaL
+			c.gainR += c.rampDeltaR
+			c.rampFrames--
+			if c.rampFrames == 0 {
+				c.gainL = c.targetGainL
+				c.gainR = c.targetGainR
+			}
+		}
+		v := c.value() * gain
+		dst[2*i] += v * c.gainL
+		dst[2*i+1] += v * c.gainR
+		c.advance(c.step)
+		if !c.active {
+			return
+		}
+	}
+}
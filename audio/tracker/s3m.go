@@ -0,0 +1,373 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracker
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+func isS3M(data []byte) bool {
+	return len(data) >= 0x60 && string(data[0x2c:0x30]) == "SCRM"
+}
+
+func loadS3M(data []byte) (*module, error) {
+	if !isS3M(data) {
+		return nil, fmt.Errorf("tracker: invalid S3M header")
+	}
+
+	orderNum := int(binary.LittleEndian.Uint16(data[0x20:]))
+	instrumentNum := int(binary.LittleEndian.Uint16(data[0x22:]))
+	patternNum := int(binary.LittleEndian.Uint16(data[0x24:]))
+	unsigned := binary.LittleEndian.Uint16(data[0x2a:]) != 1
+	defaultPanning := data[0x35] == 0xfc
+
+	tableOffset := 0x60
+	ptrOffset := tableOffset + orderNum
+	panOffset := ptrOffset + (instrumentNum+patternNum)*2
+	if len(data) < panOffset {
+		return nil, fmt.Errorf("tracker: S3M data is too short")
+	}
+
+	m := &module{
+		format:        formatS3M,
+		title:         trimString(data[:28]),
+		initialSpeed:  int(data[0x31]),
+		initialTempo:  int(data[0x32]),
+		globalVolume:  int(data[0x30]),
+		referenceNote: 4*12 + 1,
+	}
+	if m.initialSpeed == 0 || m.initialSpeed == 0xff {
+		m.initialSpeed = 6
+	}
+	if m.initialTempo < 32 {
+		m.initialTempo = 125
+	}
+	if m.globalVolume > 64 {
+		m.globalVolume = 64
+	}
+
+	// Map the enabled channels to the module's channels.
+	var channelMap [32]int
+	for i := range channelMap {
+		channelMap[i] = -1
+		setting := data[0x40+i]
+		if setting >= 16 {
+			// Unused or AdLib channels.
+			continue
+		}
+		channelMap[i] = m.channelNum
+		m.channelNum++
+
+		pan := 0x30
+		if setting >= 8 {
+			pan = 0xc0
+		}
+		if defaultPanning && len(data) >= panOffset+32 {
+			if p := data[panOffset+i]; p&0x20 != 0 {
+				pan = int(p&0xf) * 0x11
+			}
+		}
+		// Without the stereo flag, all the channels are centered.
+		if data[0x33]&0x80 == 0 {
+			pan = 0x80
+		}
+		m.panning = append(m.panning, pan)
+		m.channelVolume = append(m.channelVolume, 64)
+	}
+	if m.channelNum == 0 {
+		return nil, fmt.Errorf("tracker: S3M has no channels")
+	}
+
+	for i := 0; i < orderNum; i++ {
+		switch o := data[tableOffset+i]; o {
+		case 0xfe:
+			// A marker. Skip this.
+		case 0xff:
+			m.orders = append(m.orders, -1)
+		default:
+			m.orders = append(m.orders, int(o))
+		}
+	}
+
+	for i := 0; i < instrumentNum; i++ {
+		ptr := int(binary.LittleEndian.Uint16(data[ptrOffset+i*2:])) * 16
+		s, err := loadS3MSample(data, ptr, unsigned)
+		if err != nil {
+			return nil, err
+		}
+		m.instruments = append(m.instruments, newSampleInstrument(s))
+	}
+
+	for i := 0; i < patternNum; i++ {
+		ptr := int(binary.LittleEndian.Uint16(data[ptrOffset+(instrumentNum+i)*2:])) * 16
+		p, err := loadS3MPattern(data, ptr, &channelMap, m.channelNum)
+		if err != nil {
+			return nil, err
+		}
+		m.patterns = append(m.patterns, p)
+	}
+	return m, nil
+}
+
+func loadS3MSample(data []byte, offset int, unsigned bool) (*sample, error) {
+	s := &sample{
+		globalVolume: 64,
+		panning:      -1,
+		frequency:    8363,
+	}
+	if offset == 0 {
+		return s, nil
+	}
+	if len(data) < offset+0x50 {
+		return nil, fmt.Errorf("tracker: S3M instrument is too short")
+	}
+	h := data[offset:]
+	if h[0] != 1 {
+		// Not a PCM sample (e.g. AdLib). Treat this as an empty sample.
+		return s, nil
+	}
+
+	ptr := (int(h[0x0d])<<16 | int(binary.LittleEndian.Uint16(h[0x0e:]))) * 16
+	length := int(binary.LittleEndian.Uint32(h[0x10:]))
+	loopStart := int(binary.LittleEndian.Uint32(h[0x14:]))
+	loopEnd := int(binary.LittleEndian.Uint32(h[0x18:]))
+	s.volume = int(h[0x1c])
+	if s.volume > 64 {
+		s.volume = 64
+	}
+	if h[0x1e] != 0 {
+		return nil, fmt.Errorf("tracker: packed S3M samples are not supported")
+	}
+	flags := h[0x1f]
+	if c2spd := binary.LittleEndian.Uint32(h[0x20:]); c2spd != 0 {
+		s.frequency = float64(c2spd)
+	}
+
+	bytesPerSample := 1
+	if flags&4 != 0 {
+		bytesPerSample = 2
+	}
+	if ptr > len(data) {
+		return nil, fmt.Errorf("tracker: S3M sample data is out of range")
+	}
+	// Truncate the sample to the available data. Compare without multiplication to avoid overflows.
+	if length > (len(data)-ptr)/bytesPerSample {
+		length = (len(data) - ptr) / bytesPerSample
+	}
+	// For stereo samples, the left channel is followed by the right channel. Use only the left channel.
+	s.data = decodePCM(data[ptr:ptr+length*bytesPerSample], bytesPerSample == 2, unsigned)
+
+	if flags&1 != 0 && loopStart < loopEnd && loopStart < length {
+		s.loopType = loopForward
+		s.loopStart = loopStart
+		s.loopEnd = loopEnd
+		if s.loopEnd > length {
+			s.loopEnd = length
+		}
+	}
+	return s, nil
+}
+
+// decodePCM decodes little-endian PCM data into float32 values.
+func decodePCM(data []byte, is16bit bool, unsigned bool) []float32 {
+	if is16bit {
+		r := make([]float32, len(data)/2)
+		for i := range r {
+			v := binary.LittleEndian.Uint16(data[2*i:])
+			if unsigned {
+				v ^= 0x8000
+			}
+			r[i] = float32(int16(v)) / (1 << 15)
+		}
+		return r
+	}
+	r := make([]float32, len(data))
+	for i, v := range data {
+		if unsigned {
+			v ^= 0x80
+		}
+		r[i] = float32(int8(v)) / (1 << 7)
+	}
+	return r
+}
+
+func loadS3MPattern(data []byte, offset int, channelMap *[32]int, channelNum int) (*pattern, error) {
+	p := &pattern{rows: make([][]cell, 64)}
+	for r := range p.rows {
+		p.rows[r] = make([]cell, channelNum)
+	}
+	if offset == 0 {
+		return p, nil
+	}
+	if len(data) < offset+2 {
+		return nil, fmt.Errorf("tracker: S3M pattern is too short")
+	}
+	end := offset + int(binary.LittleEndian.Uint16(data[offset:]))
+	if end > len(data) {
+		end = len(data)
+	}
+	i := offset + 2
+	next := func() byte {
+		if i >= end {
+			return 0
+		}
+		b := data[i]
+		i++
+		return b
+	}
+	for r := 0; r < 64 && i < end; {
+		what := next()
+		if what == 0 {
+			r++
+			continue
+		}
+		var c cell
+		if what&0x20 != 0 {
+			switch n := next(); n {
+			case 0xff:
+			case 0xfe:
+				c.note = noteCut
+			default:
+				c.note = (n>>4)*12 + n&0xf + 1
+			}
+			c.instrument = next()
+		}
+		if what&0x40 != 0 {
+			v := next()
+			if v > 64 {
+				v = 64
+			}
+			c.volumeEffect = volumeSet
+			c.volumeParam = v
+		}
+		if what&0x80 != 0 {
+			e := next()
+			c.effect, c.param = convertS3MEffect(e, next(), formatS3M)
+		}
+		if ch := channelMap[what&0x1f]; ch >= 0 {
+			p.rows[r][ch] = c
+		}
+	}
+	return p, nil
+}
+
+// convertS3MEffect converts an effect in S3M or IT to the unified form.
+// e is a command where 1 means 'A'.
+func convertS3MEffect(e, p byte, f format) (effect, byte) {
+	switch e {
+	case 'A' - '@':
+		if p == 0 {
+			return effectNone, 0
+		}
+		return effectSetSpeed, p
+	case 'B' - '@':
+		return effectPositionJump, p
+	case 'C' - '@':
+		if f == formatS3M {
+			// The parameter is in BCD in S3M.
+			return effectPatternBreak, (p>>4)*10 + p&0xf
+		}
+		return effectPatternBreak, p
+	case 'D' - '@':
+		return effectVolumeSlide, p
+	case 'E' - '@':
+		return effectS3MPortaDown, p
+	case 'F' - '@':
+		return effectS3MPortaUp, p
+	case 'G' - '@':
+		return effectTonePorta, p
+	case 'H' - '@':
+		return effectVibrato, p
+	case 'I' - '@':
+		return effectTremor, p
+	case 'J' - '@':
+		return effectArpeggio, p
+	case 'K' - '@':
+		return effectVibratoVolumeSlide, p
+	case 'L' - '@':
+		return effectTonePortaVolumeSlide, p
+	case 'M' - '@':
+		if f == formatIT {
+			return effectChannelVolume, p
+		}
+	case 'N' - '@':
+		if f == formatIT {
+			return effectChannelVolumeSlide, p
+		}
+	case 'O' - '@':
+		return effectSampleOffset, p
+	case 'P' - '@':
+		if f == formatIT {
+			// The direction of the panning slide is the opposite of XM's.
+			return effectPanningSlide, p<<4 | p>>4
+		}
+	case 'Q' - '@':
+		return effectMultiRetrigger, p
+	case 'R' - '@':
+		return effectTremolo, p
+	case 'S' - '@':
+		x := p & 0xf
+		switch p >> 4 {
+		case 0x2:
+			return effectFinetune, x
+		case 0x3:
+			return effectVibratoWaveform, x
+		case 0x4:
+			return effectTremoloWaveform, x
+		case 0x8:
+			return effectSetPanning, x * 0x11
+		case 0xb:
+			return effectPatternLoop, x
+		case 0xc:
+			return effectNoteCut, x
+		case 0xd:
+			return effectNoteDelay, x
+		case 0xe:
+			return effectPatternDelay, x
+		}
+	case 'T' - '@':
+		if p < 0x20 {
+			// Tempo slides are not supported.
+			return effectNone, 0
+		}
+		return effectSetTempo, p
+	case 'U' - '@':
+		return effectFineVibrato, p
+	case 'V' - '@':
+		if f == formatIT {
+			// The global volume is in [0, 128] in IT.
+			return effectGlobalVolume, p / 2
+		}
+		return effectGlobalVolume, p
+	case 'W' - '@':
+		if f == formatIT {
+			return effectGlobalVolumeSlide, p
+		}
+	case 'X' - '@':
+		if f == formatS3M {
+			if p > 0x80 {
+				// Surround. Treat this as center.
+				return effectSetPanning, 0x80
+			}
+			if p == 0x80 {
+				return effectSetPanning, 0xff
+			}
+			return effectSetPanning, p * 2
+		}
+		return effectSetPanning, p
+	}
+	return effectNone, 0
+}
@@ -0,0 +1,286 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tracker provides a decoder for tracker modules.
+//
+// The supported formats are ProTracker MOD (including the variants with more channels and the original Sound
+// Tracker format with 15 samples), Scream Tracker 3 S3M, FastTracker 2 XM and Impulse Tracker IT.
+//
+// The decoder renders a module into 16bit stereo PCM at the given sample rate. Some rarely used features like
+// IT's new note actions, filters and MIDI macros, and AdLib instruments in S3M are not supported.
+package tracker
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+
+	"github.com/hajimehoshi/ebiten/v2/audio"
+)
+
+// Stream is a decoded audio stream of a tracker module.
+//
+// The song ends when the module's playing position reaches a position that was already played, e.g. by a
+// position jump effect or by reaching the end of the order list. If the stream is looping, the song continues
+// from there instead.
+type Stream struct {
+	module     *module
+	player     *player
+	sampleRate int
+	length     int64
+
+	pos     int64
+	buf     []float32
+	rest    []byte
+	muted   []bool
+	looping bool
+
+	m sync.Mutex
+}
+
+// Read is implementation of io.Reader's Read.
+func (s *Stream) Read(p []byte) (int, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if len(s.rest) == 0 {
+		frames := len(p) / 4
+		if frames == 0 {
+			frames = 1
+		}
+		if cap(s.buf) < frames*2 {
+			s.buf = make([]float32, frames*2)
+		}
+		buf := s.buf[:frames*2]
+		for i := range buf {
+			buf[i] = 0
+		}
+		n := s.player.render(buf, frames)
+		if n == 0 {
+			return 0, io.EOF
+		}
+
+		bs := make([]byte, n*4)
+		for i, v := range buf[:n*2] {
+			if v > 1 {
+				v = 1
+			}
+			if v < -1 {
+				v = -1
+			}
+			binary.LittleEndian.PutUint16(bs[2*i:], uint16(int16(v*(1<<15-1))))
+		}
+		s.rest = bs
+	}
+
+	n := copy(p, s.rest)
+	s.rest = s.rest[n:]
+	s.pos += int64(n)
+	return n, nil
+}
+
+// Seek is implementation of io.Seeker's Seek.
+//
+// Note that Seek can take long since Seek replays the module from the beginning when seeking backward.
+func (s *Stream) Seek(offset int64, whence int) (int64, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += s.pos
+	case io.SeekEnd:
+		offset += s.length
+	default:
+		return 0, fmt.Errorf("tracker: invalid whence: %d", whence)
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("tracker: invalid offset: %d", offset)
+	}
+	offset &^= 3
+
+	// Drop the rendered bytes that are not read yet.
+	s.pos -= int64(len(s.rest))
+	s.rest = nil
+
+	if offset < s.pos || s.player.ended {
+		s.resetPlayer()
+		s.pos = 0
+	}
+	frames := int((offset - s.pos) / 4)
+	n := s.player.render(nil, frames)
+	s.pos += int64(n) * 4
+	return s.pos, nil
+}
+
+func (s *Stream) resetPlayer() {
+	s.player = newPlayer(s.module, s.sampleRate)
+	s.player.looping = s.looping
+	for i, m := range s.muted {
+		s.player.channels[i].muted = m
+	}
+}
+
+// Length returns the size of decoded stream in bytes.
+//
+// Length is the size of the song played once, even if the stream is looping.
+func (s *Stream) Length() int64 {
+	return s.length
+}
+
+// SampleRate returns the sample rate of the decoded stream.
+func (s *Stream) SampleRate() int {
+	return s.sampleRate
+}
+
+// ChannelNum returns the number of the module's channels.
+func (s *Stream) ChannelNum() int {
+	return s.module.channelNum
+}
+
+// IsChannelMuted reports whether the module's channel is muted.
+//
+// IsChannelMuted panics if channel is out of range.
+//
+// IsChannelMuted is concurrent-safe.
+func (s *Stream) IsChannelMuted(channel int) bool {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if channel < 0 || channel >= len(s.muted) {
+		panic(fmt.Sprintf("tracker: channel out of range: %d", channel))
+	}
+	return s.muted[channel]
+}
+
+// SetChannelMuted mutes or unmutes the module's channel.
+//
+// A muted channel keeps being processed, so unmuting a channel resumes its sound at the right position.
+// The change is applied from the next tick of the module.
+//
+// SetChannelMuted panics if channel is out of range.
+//
+// SetChannelMuted is concurrent-safe.
+func (s *Stream) SetChannelMuted(channel int, muted bool) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if channel < 0 || channel >= len(s.muted) {
+		panic(fmt.Sprintf("tracker: channel out of range: %d", channel))
+	}
+	s.muted[channel] = muted
+	s.player.channels[channel].muted = muted
+}
+
+// IsLooping reports whether the stream is looping.
+//
+// IsLooping is concurrent-safe.
+func (s *Stream) IsLooping() bool {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.looping
+}
+
+// SetLooping sets whether the stream is looping.
+//
+// If the stream is looping, the song continues from the position where the module loops back, e.g. the
+// restart position, instead of reaching EOF. Unlike audio.InfiniteLoop, this keeps the states of the channels
+// like the module is played by a tracker.
+//
+// If the stream already reached EOF, call Seek to resume the stream.
+//
+// SetLooping is concurrent-safe.
+func (s *Stream) SetLooping(looping bool) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.looping = looping
+	s.player.looping = looping
+}
+
+// DecodeWithSampleRate decodes a tracker module to playable stream.
+//
+// The format is detected from the data. The module is rendered into 2 channels and 16bit at sampleRate.
+//
+// DecodeWithSampleRate reads all the data from src, and returns error when decoding fails or IO error happens.
+//
+// A Stream doesn't close src even if src implements io.Closer.
+// Closing the source is src owner's responsibility.
+func DecodeWithSampleRate(sampleRate int, src io.Reader) (*Stream, error) {
+	if sampleRate <= 0 {
+		return nil, fmt.Errorf("tracker: invalid sample rate: %d", sampleRate)
+	}
+
+	data, err := ioutil.ReadAll(src)
+	if err != nil {
+		return nil, err
+	}
+
+	var m *module
+	switch {
+	case isXM(data):
+		m, err = loadXM(data)
+	case isIT(data):
+		m, err = loadIT(data)
+	case isS3M(data):
+		m, err = loadS3M(data)
+	default:
+		m, err = loadMOD(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(m.orders) == 0 {
+		return nil, fmt.Errorf("tracker: the module has no orders")
+	}
+
+	s := &Stream{
+		module:     m,
+		sampleRate: sampleRate,
+		muted:      make([]bool, m.channelNum),
+	}
+	s.length = songLength(m, sampleRate) * 4
+	s.resetPlayer()
+	return s, nil
+}
+
+// Decode decodes a tracker module to playable stream.
+//
+// Decode is the same as DecodeWithSampleRate with the audio context's sample rate.
+func Decode(context *audio.Context, src io.Reader) (*Stream, error) {
+	return DecodeWithSampleRate(context.SampleRate(), src)
+}
+
+// maxSongLength is the maximum length of a song in seconds to prevent a broken module from hanging.
+const maxSongLength = 60 * 60
+
+// songLength returns the number of the frames when the song is played once.
+func songLength(m *module, sampleRate int) int64 {
+	p := newPlayer(m, sampleRate)
+	var n int64
+	for n < maxSongLength*int64(sampleRate) {
+		f := p.render(nil, sampleRate)
+		if f == 0 {
+			break
+		}
+		n += int64(f)
+	}
+	return n
+}
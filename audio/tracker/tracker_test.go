@@ -0,0 +1,540 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracker_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2/audio/tracker"
+)
+
+const (
+	sampleRate = 44100
+
+	// framesPerRow is the number of frames of a row at the default speed 6 and tempo 125.
+	framesPerRow = 6 * sampleRate * 5 / (2 * 125)
+
+	// squareLength is the length of the square wave sample in the test modules. The sample is played at
+	// 8363 [Hz], so the frequency of the sound is 8363 / 32 [Hz].
+	squareLength = 32
+)
+
+func squareWave() []int8 {
+	r := make([]int8, squareLength)
+	for i := range r {
+		if i < squareLength/2 {
+			r[i] = 64
+		} else {
+			r[i] = -64
+		}
+	}
+	return r
+}
+
+type modCell struct {
+	period     int
+	instrument int
+	effect     byte
+	param      byte
+}
+
+// makeMOD makes a 4-channel MOD with a looping square wave sample.
+func makeMOD(orders []byte, patterns [][64][4]modCell) []byte {
+	var b bytes.Buffer
+	b.Write(make([]byte, 20))
+	for i := 0; i < 31; i++ {
+		h := make([]byte, 30)
+		if i == 0 {
+			binary.BigEndian.PutUint16(h[22:], squareLength/2)
+			h[25] = 64
+			binary.BigEndian.PutUint16(h[28:], squareLength/2)
+		}
+		b.Write(h)
+	}
+	b.WriteByte(byte(len(orders)))
+	b.WriteByte(127)
+	o := make([]byte, 128)
+	copy(o, orders)
+	b.Write(o)
+	b.WriteString("M.K.")
+	for _, p := range patterns {
+		for _, r := range p {
+			for _, c := range r {
+				b.WriteByte(byte(c.instrument&0xf0) | byte(c.period>>8))
+				b.WriteByte(byte(c.period))
+				b.WriteByte(byte(c.instrument<<4) | c.effect)
+				b.WriteByte(c.param)
+			}
+		}
+	}
+	for _, v := range squareWave() {
+		b.WriteByte(byte(v))
+	}
+	return b.Bytes()
+}
+
+func makeS3M() []byte {
+	b := make([]byte, 0x200)
+	b[0x1c] = 0x1a
+	b[0x1d] = 16
+	binary.LittleEndian.PutUint16(b[0x20:], 2)
+	binary.LittleEndian.PutUint16(b[0x22:], 1)
+	binary.LittleEndian.PutUint16(b[0x24:], 1)
+	binary.LittleEndian.PutUint16(b[0x28:], 0x1320)
+	binary.LittleEndian.PutUint16(b[0x2a:], 2)
+	copy(b[0x2c:], "SCRM")
+	b[0x30] = 64
+	b[0x31] = 6
+	b[0x32] = 125
+	b[0x33] = 0xb0
+	for i := 0; i < 32; i++ {
+		b[0x40+i] = 0xff
+	}
+	b[0x40] = 0
+	b[0x41] = 8
+	b[0x60] = 0
+	b[0x61] = 0xff
+	binary.LittleEndian.PutUint16(b[0x62:], 0x70/16)
+	binary.LittleEndian.PutUint16(b[0x64:], 0xc0/16)
+
+	// The instrument.
+	ins := b[0x70:]
+	ins[0] = 1
+	binary.LittleEndian.PutUint16(ins[0x0e:], 0x180/16)
+	binary.LittleEndian.PutUint32(ins[0x10:], squareLength)
+	binary.LittleEndian.PutUint32(ins[0x18:], squareLength)
+	ins[0x1c] = 64
+	ins[0x1f] = 1
+	binary.LittleEndian.PutUint32(ins[0x20:], 8363)
+	copy(ins[0x4c:], "SCRS")
+
+	// The pattern.
+	pat := []byte{0, 0, 0x20 | 0x40, 0x40, 1, 64, 0}
+	for i := 1; i < 64; i++ {
+		pat = append(pat, 0)
+	}
+	binary.LittleEndian.PutUint16(pat, uint16(len(pat)))
+	copy(b[0xc0:], pat)
+
+	// The sample in unsigned.
+	b = b[:0x180]
+	for _, v := range squareWave() {
+		b = append(b, byte(v)^0x80)
+	}
+	return b
+}
+
+func makeXM() []byte {
+	var b bytes.Buffer
+	b.WriteString("Extended Module: ")
+	b.Write(make([]byte, 20))
+	b.WriteByte(0x1a)
+	b.Write(make([]byte, 20))
+	write := func(v interface{}) {
+		_ = binary.Write(&b, binary.LittleEndian, v)
+	}
+	write(uint16(0x0104))
+	write(uint32(276))
+	write(uint16(1))   // Song length
+	write(uint16(0))   // Restart position
+	write(uint16(2))   // Channels
+	write(uint16(1))   // Patterns
+	write(uint16(1))   // Instruments
+	write(uint16(1))   // Flags (linear periods)
+	write(uint16(6))   // Speed
+	write(uint16(125)) // Tempo
+	b.Write(make([]byte, 256))
+
+	// The pattern.
+	data := []byte{0x83, 49, 1, 0x80}
+	for i := 1; i < 64; i++ {
+		data = append(data, 0x80, 0x80)
+	}
+	write(uint32(9))
+	b.WriteByte(0)
+	write(uint16(64))
+	write(uint16(len(data)))
+	b.Write(data)
+
+	// The instrument.
+	ins := make([]byte, 263)
+	binary.LittleEndian.PutUint32(ins, 263)
+	binary.LittleEndian.PutUint16(ins[27:], 1)
+	binary.LittleEndian.PutUint32(ins[29:], 40)
+	b.Write(ins)
+
+	// The sample header.
+	write(uint32(squareLength))
+	write(uint32(0))
+	write(uint32(squareLength))
+	b.WriteByte(64)  // Volume
+	b.WriteByte(0)   // Finetune
+	b.WriteByte(1)   // Type (forward loop)
+	b.WriteByte(128) // Panning
+	b.WriteByte(0)   // Relative note
+	b.WriteByte(0)
+	b.Write(make([]byte, 22))
+
+	// The sample data in delta.
+	var prev int8
+	for _, v := range squareWave() {
+		b.WriteByte(byte(v - prev))
+		prev = v
+	}
+	return b.Bytes()
+}
+
+// makeIT makes an IT. If compressed is true, the sample is compressed in IT 2.14 format.
+func makeIT(compressed bool) []byte {
+	b := make([]byte, 0x200)
+	copy(b, "IMPM")
+	binary.LittleEndian.PutUint16(b[0x20:], 2)
+	binary.LittleEndian.PutUint16(b[0x24:], 1)
+	binary.LittleEndian.PutUint16(b[0x26:], 1)
+	binary.LittleEndian.PutUint16(b[0x28:], 0x0214)
+	binary.LittleEndian.PutUint16(b[0x2a:], 0x0214)
+	binary.LittleEndian.PutUint16(b[0x2c:], 1|8)
+	b[0x30] = 128
+	b[0x31] = 48
+	b[0x32] = 6
+	b[0x33] = 125
+	for i := 0; i < 64; i++ {
+		b[0x40+i] = 32
+		b[0x80+i] = 64
+	}
+	b[0xc0] = 0
+	b[0xc1] = 0xff
+	binary.LittleEndian.PutUint32(b[0xc2:], 0xd0)
+	binary.LittleEndian.PutUint32(b[0xc6:], 0x120)
+
+	// The sample header.
+	smp := b[0xd0:]
+	copy(smp, "IMPS")
+	smp[0x11] = 64
+	smp[0x12] = 0x01 | 0x10
+	if compressed {
+		smp[0x12] |= 0x08
+	}
+	smp[0x13] = 64
+	smp[0x2e] = 1
+	binary.LittleEndian.PutUint32(smp[0x30:], squareLength)
+	binary.LittleEndian.PutUint32(smp[0x38:], squareLength)
+	binary.LittleEndian.PutUint32(smp[0x3c:], 8363)
+	binary.LittleEndian.PutUint32(smp[0x48:], 0x180)
+
+	// The pattern.
+	pat := []byte{0x81, 0x03, 60, 1, 0}
+	for i := 1; i < 64; i++ {
+		pat = append(pat, 0)
+	}
+	binary.LittleEndian.PutUint16(b[0x120:], uint16(len(pat)))
+	binary.LittleEndian.PutUint16(b[0x122:], 64)
+	copy(b[0x128:], pat)
+
+	b = b[:0x180]
+	if !compressed {
+		for _, v := range squareWave() {
+			b = append(b, byte(v))
+		}
+		return b
+	}
+
+	// Write the deltas in 9 bits without changing the width.
+	var bits []byte
+	var prev int8
+	for _, v := range squareWave() {
+		d := uint16(uint8(v - prev))
+		prev = v
+		for i := 0; i < 9; i++ {
+			bits = append(bits, byte(d>>uint(i)&1))
+		}
+	}
+	block := make([]byte, (len(bits)+7)/8)
+	for i, bit := range bits {
+		block[i/8] |= bit << uint(i%8)
+	}
+	b = append(b, byte(len(block)), byte(len(block)>>8))
+	return append(b, block...)
+}
+
+func readAll(t *testing.T, s *tracker.Stream) []int16 {
+	t.Helper()
+	bs, err := ioutil.ReadAll(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := make([]int16, len(bs)/2)
+	for i := range r {
+		r[i] = int16(binary.LittleEndian.Uint16(bs[2*i:]))
+	}
+	return r
+}
+
+// leftZeroCrossings returns the number of the sign changes of the left channel in the first frames.
+func leftZeroCrossings(samples []int16, frames int) int {
+	var n int
+	for i := 1; i < frames; i++ {
+		if (samples[2*i-2] < 0) != (samples[2*i] < 0) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestDecodeFormats(t *testing.T) {
+	var p [64][4]modCell
+	p[0][0] = modCell{period: 428, instrument: 1}
+
+	cases := []struct {
+		name string
+		data []byte
+	}{
+		{name: "MOD", data: makeMOD([]byte{0}, [][64][4]modCell{p})},
+		{name: "S3M", data: makeS3M()},
+		{name: "XM", data: makeXM()},
+		{name: "IT", data: makeIT(false)},
+		{name: "IT compressed", data: makeIT(true)},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			s, err := tracker.DecodeWithSampleRate(sampleRate, bytes.NewReader(c.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := s.Length(), int64(64*framesPerRow*4); got != want {
+				t.Errorf("Length(): got: %d, want: %d", got, want)
+			}
+			samples := readAll(t, s)
+			if got, want := int64(len(samples)*2), s.Length(); got != want {
+				t.Errorf("the decoded size: got: %d, want: %d", got, want)
+			}
+
+			// The square wave is played at 8363 / 32 [Hz], and the sign changes twice in a period.
+			got := leftZeroCrossings(samples, sampleRate)
+			want := 2 * 8363 / squareLength
+			if got < want-2 || got > want+2 {
+				t.Errorf("zero crossings in a second: got: %d, want: %d", got, want)
+			}
+		})
+	}
+}
+
+func TestChannelMuted(t *testing.T) {
+	var p [64][4]modCell
+	p[0][0] = modCell{period: 428, instrument: 1}
+	p[0][1] = modCell{period: 214, instrument: 1}
+	data := makeMOD([]byte{0}, [][64][4]modCell{p})
+
+	s, err := tracker.DecodeWithSampleRate(sampleRate, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.ChannelNum(), 4; got != want {
+		t.Errorf("ChannelNum(): got: %d, want: %d", got, want)
+	}
+	s.SetChannelMuted(1, true)
+	if !s.IsChannelMuted(1) {
+		t.Errorf("IsChannelMuted(1): got: false, want: true")
+	}
+
+	// The channel 0 is panned to left and the channel 1 is panned to right. The right channel should be quieter.
+	samples := readAll(t, s)
+	var left, right int
+	for i := 0; i < len(samples)/2; i++ {
+		left += abs(int(samples[2*i]))
+		right += abs(int(samples[2*i+1]))
+	}
+	if right*2 >= left {
+		t.Errorf("the right channel is not quiet enough: left: %d, right: %d", left, right)
+	}
+
+	// Mute all the channels.
+	s.SetChannelMuted(0, true)
+	if _, err := s.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range readAll(t, s) {
+		if v != 0 {
+			t.Fatalf("samples[%d]: got: %d, want: 0", i, v)
+		}
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func TestSeek(t *testing.T) {
+	var p [64][4]modCell
+	p[0][0] = modCell{period: 428, instrument: 1}
+	p[8][1] = modCell{period: 320, instrument: 1, effect: 0x4, param: 0x48}
+	data := makeMOD([]byte{0}, [][64][4]modCell{p})
+
+	s, err := tracker.DecodeWithSampleRate(sampleRate, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	all := readAll(t, s)
+
+	for _, offset := range []int64{framesPerRow * 4 * 10, 12345 * 4, 0} {
+		if _, err := s.Seek(offset, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		got := readAll(t, s)
+		want := all[offset/2:]
+		if len(got) != len(want) {
+			t.Fatalf("offset: %d: length: got: %d, want: %d", offset, len(got), len(want))
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Fatalf("offset: %d: samples[%d]: got: %d, want: %d", offset, i, got[i], want[i])
+			}
+		}
+	}
+}
+
+func TestSongEnd(t *testing.T) {
+	var p0, p1 [64][4]modCell
+	p0[0][0] = modCell{period: 428, instrument: 1}
+	// Break to the row 32 of the next pattern.
+	p0[15][2] = modCell{effect: 0xd, param: 0x32}
+	// Jump back to the first order, which ends the song.
+	p1[40][3] = modCell{effect: 0xb, param: 0x00}
+	data := makeMOD([]byte{0, 1}, [][64][4]modCell{p0, p1})
+
+	s, err := tracker.DecodeWithSampleRate(sampleRate, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.Length(), int64((16+9)*framesPerRow*4); got != want {
+		t.Errorf("Length(): got: %d, want: %d", got, want)
+	}
+}
+
+func TestLooping(t *testing.T) {
+	var p [64][4]modCell
+	p[0][0] = modCell{period: 428, instrument: 1}
+	data := makeMOD([]byte{0}, [][64][4]modCell{p})
+
+	s, err := tracker.DecodeWithSampleRate(sampleRate, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetLooping(true)
+	if !s.IsLooping() {
+		t.Errorf("IsLooping(): got: false, want: true")
+	}
+
+	// Read the stream beyond the length.
+	size := s.Length() * 3
+	buf := make([]byte, 4096)
+	var n int64
+	for n < size {
+		m, err := s.Read(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n += int64(m)
+	}
+
+	// Seeking beyond the length is available while looping.
+	if pos, err := s.Seek(s.Length()+400, io.SeekStart); err != nil {
+		t.Fatal(err)
+	} else if got, want := pos, s.Length()+400; got != want {
+		t.Errorf("Seek: got: %d, want: %d", got, want)
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	for _, data := range [][]byte{
+		nil,
+		[]byte("Extended Module: "),
+		makeS3M()[:0x70],
+		makeIT(false)[:0xc4],
+	} {
+		if _, err := tracker.DecodeWithSampleRate(sampleRate, bytes.NewReader(data)); err == nil {
+			t.Errorf("DecodeWithSampleRate with %d bytes must return an error", len(data))
+		}
+	}
+}
+
+func TestDecodeMalformed(t *testing.T) {
+	s3mSamplePtr := makeS3M()
+	s3mSamplePtr[0x70+0x0d] = 0xff
+
+	s3mSampleLength := makeS3M()
+	binary.LittleEndian.PutUint32(s3mSampleLength[0x70+0x10:], 0x86000020)
+
+	itSamplePtr := makeIT(false)
+	binary.LittleEndian.PutUint32(itSamplePtr[0xd0+0x48:], 0x86000000)
+
+	itSampleLength := makeIT(false)
+	binary.LittleEndian.PutUint32(itSampleLength[0xd0+0x30:], 0x86000020)
+
+	itCompressedLength := makeIT(true)
+	binary.LittleEndian.PutUint32(itCompressedLength[0xd0+0x30:], 0x86000020)
+
+	cases := []struct {
+		name string
+		data []byte
+		err  bool
+	}{
+		{
+			name: "S3M sample pointer out of range",
+			data: s3mSamplePtr,
+			err:  true,
+		},
+		{
+			name: "S3M sample length out of range",
+			data: s3mSampleLength,
+			err:  false,
+		},
+		{
+			name: "IT sample pointer out of range",
+			data: itSamplePtr,
+			err:  true,
+		},
+		{
+			name: "IT sample length out of range",
+			data: itSampleLength,
+			err:  false,
+		},
+		{
+			name: "IT compressed sample length out of range",
+			data: itCompressedLength,
+			err:  true,
+		},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			_, err := tracker.DecodeWithSampleRate(sampleRate, bytes.NewReader(c.data))
+			if c.err && err == nil {
+				t.Errorf("DecodeWithSampleRate must return an error")
+			}
+			if !c.err && err != nil {
+				t.Errorf("DecodeWithSampleRate must truncate the sample but returned an error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,370 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracker
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+const xmSignature = "Extended Module: "
+
+func isXM(data []byte) bool {
+	return len(data) >= 60 && string(data[:17]) == xmSignature
+}
+
+func loadXM(data []byte) (*module, error) {
+	if !isXM(data) {
+		return nil, fmt.Errorf("tracker: invalid XM header")
+	}
+	if v := binary.LittleEndian.Uint16(data[58:]); v < 0x0104 {
+		return nil, fmt.Errorf("tracker: XM version %#04x is not supported", v)
+	}
+
+	headerSize := int(binary.LittleEndian.Uint32(data[60:]))
+	if len(data) < 60+headerSize || headerSize < 20+256 {
+		return nil, fmt.Errorf("tracker: XM header is too short")
+	}
+	h := data[60:]
+	songLength := int(binary.LittleEndian.Uint16(h[4:]))
+	restart := int(binary.LittleEndian.Uint16(h[6:]))
+	channelNum := int(binary.LittleEndian.Uint16(h[8:]))
+	patternNum := int(binary.LittleEndian.Uint16(h[10:]))
+	instrumentNum := int(binary.LittleEndian.Uint16(h[12:]))
+	flags := binary.LittleEndian.Uint16(h[14:])
+	if channelNum < 1 || channelNum > 64 {
+		return nil, fmt.Errorf("tracker: invalid number of channels: %d", channelNum)
+	}
+	if songLength > 256 {
+		songLength = 256
+	}
+
+	m := &module{
+		format:        formatXM,
+		title:         trimString(data[17:37]),
+		channelNum:    channelNum,
+		initialSpeed:  int(binary.LittleEndian.Uint16(h[16:])),
+		initialTempo:  int(binary.LittleEndian.Uint16(h[18:])),
+		globalVolume:  64,
+		linearPeriods: flags&1 != 0,
+		referenceNote: 4*12 + 1,
+	}
+	if m.initialSpeed == 0 {
+		m.initialSpeed = 6
+	}
+	if m.initialTempo < 32 {
+		m.initialTempo = 125
+	}
+	for i := 0; i < songLength; i++ {
+		m.orders = append(m.orders, int(h[20+i]))
+	}
+	if restart < songLength {
+		m.restart = restart
+	}
+	for i := 0; i < channelNum; i++ {
+		m.panning = append(m.panning, 0x80)
+		m.channelVolume = append(m.channelVolume, 64)
+	}
+
+	offset := 60 + headerSize
+	for i := 0; i < patternNum; i++ {
+		p, n, err := loadXMPattern(data[offset:], channelNum)
+		if err != nil {
+			return nil, err
+		}
+		m.patterns = append(m.patterns, p)
+		offset += n
+	}
+
+	for i := 0; i < instrumentNum; i++ {
+		inst, n, err := loadXMInstrument(data[offset:])
+		if err != nil {
+			return nil, err
+		}
+		m.instruments = append(m.instruments, inst)
+		offset += n
+	}
+	return m, nil
+}
+
+// loadXMPattern loads a pattern and returns the pattern and the number of read bytes.
+func loadXMPattern(data []byte, channelNum int) (*pattern, int, error) {
+	if len(data) < 9 {
+		return nil, 0, fmt.Errorf("tracker: XM pattern is too short")
+	}
+	headerSize := int(binary.LittleEndian.Uint32(data))
+	rowNum := int(binary.LittleEndian.Uint16(data[5:]))
+	size := int(binary.LittleEndian.Uint16(data[7:]))
+	if len(data) < headerSize+size {
+		return nil, 0, fmt.Errorf("tracker: XM pattern is too short")
+	}
+	if rowNum == 0 {
+		rowNum = 64
+	}
+
+	p := &pattern{rows: make([][]cell, rowNum)}
+	for r := range p.rows {
+		p.rows[r] = make([]cell, channelNum)
+	}
+	if size == 0 {
+		return p, headerSize, nil
+	}
+
+	d := data[headerSize : headerSize+size]
+	i := 0
+	next := func() byte {
+		if i >= len(d) {
+			return 0
+		}
+		b := d[i]
+		i++
+		return b
+	}
+	for r := 0; r < rowNum; r++ {
+		for ch := 0; ch < channelNum; ch++ {
+			var note, inst, vol, eff, param byte
+			if b := next(); b&0x80 != 0 {
+				if b&0x01 != 0 {
+					note = next()
+				}
+				if b&0x02 != 0 {
+					inst = next()
+				}
+				if b&0x04 != 0 {
+					vol = next()
+				}
+				if b&0x08 != 0 {
+					eff = next()
+				}
+				if b&0x10 != 0 {
+					param = next()
+				}
+			} else {
+				note = b
+				inst = next()
+				vol = next()
+				eff = next()
+				param = next()
+			}
+
+			c := &p.rows[r][ch]
+			switch {
+			case note == 97:
+				c.note = noteOff
+			case note > 0 && note < 97:
+				c.note = note
+			}
+			c.instrument = inst
+			c.volumeEffect, c.volumeParam = convertXMVolume(vol)
+			c.effect, c.param = convertMODEffect(eff, param, formatXM)
+		}
+	}
+	return p, headerSize + size, nil
+}
+
+func convertXMVolume(v byte) (volumeEffect, byte) {
+	x := v & 0xf
+	switch v >> 4 {
+	case 0x1, 0x2, 0x3, 0x4:
+		return volumeSet, v - 0x10
+	case 0x5:
+		if v == 0x50 {
+			return volumeSet, 64
+		}
+	case 0x6:
+		return volumeSlideDown, x
+	case 0x7:
+		return volumeSlideUp, x
+	case 0x8:
+		return volumeFineSlideDown, x
+	case 0x9:
+		return volumeFineSlideUp, x
+	case 0xa:
+		return volumeVibratoSpeed, x
+	case 0xb:
+		return volumeVibrato, x
+	case 0xc:
+		return volumePanning, x * 0x11
+	case 0xd:
+		return volumePanningSlideLeft, x
+	case 0xe:
+		return volumePanningSlideRight, x
+	case 0xf:
+		return volumeTonePorta, x << 4
+	}
+	return volumeNone, 0
+}
+
+// loadXMInstrument loads an instrument and its samples, and returns the instrument and the number of read bytes.
+func loadXMInstrument(data []byte) (*instrument, int, error) {
+	if len(data) < 29 {
+		return nil, 0, fmt.Errorf("tracker: XM instrument is too short")
+	}
+	size := int(binary.LittleEndian.Uint32(data))
+	if size < 29 || len(data) < size {
+		return nil, 0, fmt.Errorf("tracker: XM instrument is too short")
+	}
+	sampleNum := int(binary.LittleEndian.Uint16(data[27:]))
+
+	inst := &instrument{
+		globalVolume: 1,
+		panning:      -1,
+	}
+	for n := range inst.keymap {
+		inst.keymap[n].note = byte(n + 1)
+		inst.keymap[n].sample = -1
+	}
+	if sampleNum == 0 {
+		return inst, size, nil
+	}
+
+	// Some XMs have shorter headers. Fill the missing part with zeros.
+	h := make([]byte, 243)
+	copy(h, data[:size])
+
+	sampleHeaderSize := int(binary.LittleEndian.Uint32(h[29:]))
+	for n := 0; n < 96; n++ {
+		inst.keymap[n].sample = int(h[33+n])
+	}
+	inst.volumeEnvelope = loadXMEnvelope(h[129:], int(h[225]), h[227], h[228], h[229], h[233], false)
+	inst.panningEnvelope = loadXMEnvelope(h[177:], int(h[226]), h[230], h[231], h[232], h[234], true)
+	inst.fadeout = float64(binary.LittleEndian.Uint16(h[239:])) / 65536
+
+	offset := size
+	type header struct {
+		length int
+		is16   bool
+	}
+	var headers []header
+	for i := 0; i < sampleNum; i++ {
+		if len(data) < offset+40 {
+			return nil, 0, fmt.Errorf("tracker: XM sample header is too short")
+		}
+		sh := data[offset:]
+		length := int(binary.LittleEndian.Uint32(sh))
+		loopStart := int(binary.LittleEndian.Uint32(sh[4:]))
+		loopLength := int(binary.LittleEndian.Uint32(sh[8:]))
+		typ := sh[14]
+		s := &sample{
+			volume:       int(sh[12]),
+			globalVolume: 64,
+			panning:      int(sh[15]),
+			frequency:    8363 * math.Pow(2, (float64(int8(sh[16]))+float64(int8(sh[13]))/128)/12),
+		}
+		if s.volume > 64 {
+			s.volume = 64
+		}
+		is16 := typ&0x10 != 0
+		if is16 {
+			length /= 2
+			loopStart /= 2
+			loopLength /= 2
+		}
+		switch typ & 3 {
+		case 1:
+			s.loopType = loopForward
+		case 2:
+			s.loopType = loopPingPong
+		}
+		if s.loopType != loopNone {
+			if loopLength == 0 || loopStart >= length {
+				s.loopType = loopNone
+			} else {
+				s.loopStart = loopStart
+				s.loopEnd = loopStart + loopLength
+				if s.loopEnd > length {
+					s.loopEnd = length
+				}
+			}
+		}
+		inst.samples = append(inst.samples, s)
+		headers = append(headers, header{length: length, is16: is16})
+		offset += sampleHeaderSize
+	}
+
+	for i, s := range inst.samples {
+		h := headers[i]
+		n := h.length
+		if h.is16 {
+			n *= 2
+		}
+		if len(data) < offset+n {
+			return nil, 0, fmt.Errorf("tracker: XM sample data is too short")
+		}
+		s.data = decodeDeltaPCM(data[offset:offset+n], h.is16)
+		offset += n
+	}
+
+	for n := range inst.keymap {
+		if inst.keymap[n].sample >= len(inst.samples) {
+			inst.keymap[n].sample = -1
+		}
+	}
+	return inst, offset, nil
+}
+
+func loadXMEnvelope(data []byte, pointNum int, sustain, loopStart, loopEnd byte, flags byte, panning bool) *envelope {
+	if flags&1 == 0 || pointNum == 0 {
+		return nil
+	}
+	if pointNum > 12 {
+		pointNum = 12
+	}
+	e := &envelope{}
+	for i := 0; i < pointNum; i++ {
+		p := envelopePoint{
+			tick:  int(binary.LittleEndian.Uint16(data[i*4:])),
+			value: float64(binary.LittleEndian.Uint16(data[i*4+2:])),
+		}
+		if panning {
+			p.value = (p.value - 32) / 32
+		} else {
+			p.value /= 64
+		}
+		e.points = append(e.points, p)
+	}
+	if flags&2 != 0 && int(sustain) < pointNum {
+		e.sustain = true
+		e.sustainStart = int(sustain)
+		e.sustainEnd = int(sustain)
+	}
+	if flags&4 != 0 && int(loopStart) <= int(loopEnd) && int(loopEnd) < pointNum {
+		e.loop = true
+		e.loopStart = int(loopStart)
+		e.loopEnd = int(loopEnd)
+	}
+	return e
+}
+
+// decodeDeltaPCM decodes delta-encoded signed little-endian PCM data into float32 values.
+func decodeDeltaPCM(data []byte, is16bit bool) []float32 {
+	if is16bit {
+		r := make([]float32, len(data)/2)
+		var v int16
+		for i := range r {
+			v += int16(binary.LittleEndian.Uint16(data[2*i:]))
+			r[i] = float32(v) / (1 << 15)
+		}
+		return r
+	}
+	r := make([]float32, len(data))
+	var v int8
+	for i, d := range data {
+		v += int8(d)
+		r[i] = float32(v) / (1 << 7)
+	}
+	return r
+}
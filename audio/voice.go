@@ -0,0 +1,188 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio
+
+import (
+	"fmt"
+	"sync"
+)
+
+// VoiceStealing represents how a playing player is chosen to stop when the number of the playing players reaches
+// the limit.
+type VoiceStealing int
+
+const (
+	// VoiceStealingOldest stops the player that started playing the earliest.
+	VoiceStealingOldest VoiceStealing = iota
+
+	// VoiceStealingQuietest stops the player whose volume is the lowest.
+	VoiceStealingQuietest
+)
+
+// voice is the state of a player for the voice limiting.
+type voice struct {
+	priority int
+
+	// seq is the sequence number when the player started playing.
+	seq uint64
+
+	m sync.Mutex
+}
+
+func (v *voice) Priority() int {
+	v.m.Lock()
+	defer v.m.Unlock()
+	return v.priority
+}
+
+func (v *voice) SetPriority(priority int) {
+	v.m.Lock()
+	defer v.m.Unlock()
+	v.priority = priority
+}
+
+// MaxVoices returns the maximum number of the players that can play at the same time.
+// 0 means unlimited. The default value is 0.
+func (c *Context) MaxVoices() int {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.maxVoices
+}
+
+// SetMaxVoices sets the maximum number of the players that can play at the same time.
+// 0 means unlimited. maxVoices must not be negative. SetMaxVoices panics otherwise.
+//
+// When a player starts playing and the number of the playing players reaches the limit, one of the playing players
+// whose priority is lower than or equal to the new player's is paused. The player to pause is the one with the
+// lowest priority, and is chosen by the VoiceStealing policy among them. If there is no such player, the new player
+// doesn't start playing. See also Player.SetPriority.
+//
+// The limit is useful when many one-shot sounds can be played at the same time, since each playing player consumes
+// the resources of the audio driver.
+//
+// The limit is checked only when a player starts playing. The players that are already playing are not paused by
+// SetMaxVoices.
+func (c *Context) SetMaxVoices(maxVoices int) {
+	if maxVoices < 0 {
+		panic(fmt.Sprintf("audio: maxVoices must not be negative but %d", maxVoices))
+	}
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.maxVoices = maxVoices
+}
+
+// VoiceStealing returns the policy to choose a player to pause when the number of the playing players reaches
+// the limit. The default value is VoiceStealingOldest.
+func (c *Context) VoiceStealing() VoiceStealing {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.voiceStealing
+}
+
+// SetVoiceStealing sets the policy to choose a player to pause when the number of the playing players reaches
+// the limit.
+func (c *Context) SetVoiceStealing(policy VoiceStealing) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.voiceStealing = policy
+}
+
+// playWithVoiceLimit starts playing the player p if the voice limit allows.
+func (c *Context) playWithVoiceLimit(p playerImpl) {
+	// voiceM serializes starting players so that the number of the playing players doesn't exceed the limit.
+	c.voiceM.Lock()
+	defer c.voiceM.Unlock()
+
+	c.m.Lock()
+	maxVoices := c.maxVoices
+	policy := c.voiceStealing
+	var players []playerImpl
+	if maxVoices > 0 {
+		players = make([]playerImpl, 0, len(c.players))
+		for pi := range c.players {
+			if pi != p {
+				players = append(players, pi)
+			}
+		}
+	}
+	c.voiceSeq++
+	seq := c.voiceSeq
+	c.m.Unlock()
+
+	// Do not call the players' functions while locking c.m, since the players' functions can lock c.m.
+	if maxVoices > 0 && !p.IsPlaying() {
+		var playing []playerImpl
+		for _, pi := range players {
+			if pi.IsPlaying() {
+				playing = append(playing, pi)
+			}
+		}
+		if len(playing) >= maxVoices {
+			victim := chooseVoiceToSteal(playing, p.voice().Priority(), policy)
+			if victim == nil {
+				return
+			}
+			victim.Pause()
+		}
+	}
+
+	v := p.voice()
+	v.m.Lock()
+	v.seq = seq
+	v.m.Unlock()
+	p.Play()
+}
+
+// chooseVoiceToSteal returns the player to pause among the playing players for a new player with the given
+// priority. chooseVoiceToSteal returns nil if there is no player to pause.
+func chooseVoiceToSteal(playing []playerImpl, priority int, policy VoiceStealing) playerImpl {
+	var victim playerImpl
+	var victimPriority int
+	var victimSeq uint64
+	var victimVolume float64
+	for _, p := range playing {
+		v := p.voice()
+		v.m.Lock()
+		pr, seq := v.priority, v.seq
+		v.m.Unlock()
+		if pr > priority {
+			continue
+		}
+		volume := p.Volume()
+
+		if victim != nil {
+			if pr > victimPriority {
+				continue
+			}
+			if pr == victimPriority {
+				switch policy {
+				case VoiceStealingOldest:
+					if seq >= victimSeq {
+						continue
+					}
+				case VoiceStealingQuietest:
+					if volume > victimVolume || (volume == victimVolume && seq >= victimSeq) {
+						continue
+					}
+				}
+			}
+		}
+		victim = p
+		victimPriority = pr
+		victimSeq = seq
+		victimVolume = volume
+	}
+	return victim
+}
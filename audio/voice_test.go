@@ -0,0 +1,66 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audio_test
+
+import (
+	"testing"
+
+	. "github.com/hajimehoshi/ebiten/v2/audio"
+)
+
+func TestMaxVoices(t *testing.T) {
+	c := NewOfflineContext(44100)
+	c.SetMaxVoices(2)
+
+	src := make([]byte, 44100*4)
+	var ps []*Player
+	for i := 0; i < 6; i++ {
+		ps = append(ps, NewPlayerFromBytes(c, src))
+	}
+
+	check := func(want ...bool) {
+		t.Helper()
+		for i, w := range want {
+			if got := ps[i].IsPlaying(); got != w {
+				t.Errorf("ps[%d].IsPlaying(): got: %v, want: %v", i, got, w)
+			}
+		}
+	}
+
+	ps[0].Play()
+	ps[1].Play()
+	check(true, true)
+
+	// The oldest player is stopped.
+	ps[2].Play()
+	check(false, true, true)
+
+	// A player with a lower priority cannot stop the playing players.
+	ps[3].SetPriority(-1)
+	ps[3].Play()
+	check(false, true, true, false)
+
+	// A player with a higher priority stops the oldest player.
+	ps[4].SetPriority(1)
+	ps[4].Play()
+	check(false, false, true, false, true)
+
+	// The quietest player with the lowest priority is stopped.
+	c.SetVoiceStealing(VoiceStealingQuietest)
+	ps[2].SetVolume(0.5)
+	ps[4].SetVolume(0.1)
+	ps[5].Play()
+	check(false, false, false, false, true, true)
+}
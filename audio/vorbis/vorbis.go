@@ -13,22 +13,31 @@
 // limitations under the License.
 
 // Package vorbis provides Ogg/Vorbis decoder.
+//
+// On browsers, a native decoder on the browser is used if the browser supports Ogg/Vorbis. The native decoder
+// decodes the whole data on a background thread, and then a large file doesn't block the game.
+// Otherwise, a pure Go decoder is used.
 package vorbis
 
 import (
+	"bytes"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"time"
 
 	"github.com/jfreymuth/oggvorbis"
 
 	"github.com/hajimehoshi/ebiten/v2/audio"
+	"github.com/hajimehoshi/ebiten/v2/audio/internal/browserdecoder"
 	"github.com/hajimehoshi/ebiten/v2/audio/internal/convert"
 )
 
 // Stream is a decoded audio stream.
 type Stream struct {
-	decoded io.ReadSeeker
-	size    int64
+	decoded    io.ReadSeeker
+	size       int64
+	sampleRate int
 }
 
 // Read is implementation of io.Reader's Read.
@@ -48,6 +57,13 @@ func (s *Stream) Length() int64 {
 	return s.size
 }
 
+// SampleRate returns the sample rate of the decoded stream.
+//
+// audio.NewPlayer uses SampleRate to resample the stream to the audio context's sample rate.
+func (s *Stream) SampleRate() int {
+	return s.sampleRate
+}
+
 type decoder interface {
 	Read([]float32) (int, error)
 	SetPosition(int64) error
@@ -146,10 +162,88 @@ func decode(in io.Reader) (*decoded, int, int, error) {
 // DecodeWithSampleRate automatically resamples the stream to fit with sampleRate if necessary.
 //
 // The returned Stream's Seek is available only when src is an io.Seeker.
+// On browsers, Seek is always available.
+//
+// On browsers, DecodeWithSampleRate reads the whole src and blocks until decoding finishes.
+// Call DecodeWithSampleRate in a goroutine to keep the game running while decoding.
 //
 // A Stream doesn't close src even if src implements io.Closer.
 // Closing the source is src owner's responsibility.
 func DecodeWithSampleRate(sampleRate int, src io.Reader) (*Stream, error) {
+	if browserdecoder.IsAvailable() {
+		bs, err := ioutil.ReadAll(src)
+		if err != nil {
+			return nil, err
+		}
+		if pcm, ok := browserdecoder.Decode(bs, sampleRate); ok {
+			return &Stream{decoded: bytes.NewReader(pcm), size: int64(len(pcm)), sampleRate: sampleRate}, nil
+		}
+		// Fall back to the pure Go decoder. Safari doesn't support Ogg/Vorbis.
+		src = bytes.NewReader(bs)
+	}
+
+	return decodeStream(src, sampleRate)
+}
+
+// DecodeWithoutResampling decodes Ogg/Vorbis data to playable stream.
+//
+// DecodeWithoutResampling returns error when decoding fails or IO error happens.
+//
+// DecodeWithoutResampling doesn't resample the stream. The returned Stream's SampleRate returns the original
+// sample rate, and audio.NewPlayer resamples the stream to the audio context's sample rate.
+//
+// DecodeWithoutResampling always uses the pure Go decoder even on browsers.
+//
+// The returned Stream's Seek is available only when src is an io.Seeker.
+//
+// A Stream doesn't close src even if src implements io.Closer.
+// Closing the source is src owner's responsibility.
+func DecodeWithoutResampling(src io.Reader) (*Stream, error) {
+	return decodeStream(src, 0)
+}
+
+// DecodeOptions represents options for DecodeWithOptions.
+type DecodeOptions struct {
+	// SampleRate is the sample rate of the decoded stream.
+	// If SampleRate is 0, the stream is not resampled and the returned Stream's SampleRate returns the original
+	// sample rate.
+	SampleRate int
+
+	// DecodeAhead is the duration of the data decoded ahead on a worker goroutine.
+	//
+	// With DecodeAhead, Read rarely waits for decoding, and then the audio playback doesn't stall even on a slow
+	// machine. If DecodeAhead is 0, the stream is decoded when Read is called.
+	DecodeAhead time.Duration
+}
+
+// DecodeWithOptions decodes Ogg/Vorbis data to playable stream.
+//
+// DecodeWithOptions returns error when decoding fails or IO error happens.
+//
+// DecodeWithOptions always uses the pure Go decoder even on browsers.
+//
+// The returned Stream's Seek is available only when src is an io.Seeker.
+//
+// A Stream doesn't close src even if src implements io.Closer.
+// Closing the source is src owner's responsibility.
+func DecodeWithOptions(src io.Reader, options *DecodeOptions) (*Stream, error) {
+	if options == nil {
+		options = &DecodeOptions{}
+	}
+	s, err := decodeStream(src, options.SampleRate)
+	if err != nil {
+		return nil, err
+	}
+	if options.DecodeAhead > 0 {
+		size := int64(options.DecodeAhead) * int64(s.sampleRate) / int64(time.Second) * 4
+		s.decoded = convert.NewReadAhead(s.decoded, int(size))
+	}
+	return s, nil
+}
+
+// decodeStream decodes Ogg/Vorbis data with the pure Go decoder.
+// If sampleRate is 0, decodeStream doesn't resample the stream.
+func decodeStream(src io.Reader, sampleRate int) (*Stream, error) {
 	decoded, channelNum, origSampleRate, err := decode(src)
 	if err != nil {
 		return nil, err
@@ -163,12 +257,15 @@ func DecodeWithSampleRate(sampleRate int, src io.Reader) (*Stream, error) {
 		s = convert.NewStereo16(s, true, false)
 		size *= 2
 	}
+	if sampleRate == 0 {
+		sampleRate = origSampleRate
+	}
 	if origSampleRate != sampleRate {
 		r := convert.NewResampling(s, size, origSampleRate, sampleRate)
 		s = r
 		size = r.Length()
 	}
-	stream := &Stream{decoded: s, size: size}
+	stream := &Stream{decoded: s, size: size, sampleRate: sampleRate}
 	return stream, nil
 }
 
@@ -16,7 +16,10 @@ package vorbis_test
 
 import (
 	"bytes"
+	"io"
+	"io/ioutil"
 	"testing"
+	"time"
 
 	"github.com/jfreymuth/oggvorbis"
 
@@ -66,3 +69,66 @@ func TestTooShort(t *testing.T) {
 		t.Errorf("s.Length(): got: %d, want: %d", got, want)
 	}
 }
+
+func TestDecodeWithoutResampling(t *testing.T) {
+	bs := test_mono_ogg
+
+	s, err := DecodeWithoutResampling(bytes.NewReader(bs))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := oggvorbis.NewReader(bytes.NewReader(bs))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := s.SampleRate(), r.SampleRate(); got != want {
+		t.Errorf("s.SampleRate(): got: %d, want: %d", got, want)
+	}
+	if got, want := s.Length(), r.Length()*2*2; got != want {
+		t.Errorf("s.Length(): got: %d, want: %d", got, want)
+	}
+}
+
+func TestDecodeWithOptionsDecodeAhead(t *testing.T) {
+	bs := test_mono_ogg
+
+	s0, err := DecodeWithoutResampling(bytes.NewReader(bs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ioutil.ReadAll(s0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s1, err := DecodeWithOptions(bytes.NewReader(bs), &DecodeOptions{
+		DecodeAhead: 100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s1.Length(), s0.Length(); got != want {
+		t.Errorf("s.Length(): got: %d, want: %d", got, want)
+	}
+	got, err := ioutil.ReadAll(s1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("the decoded data doesn't match")
+	}
+
+	const offset = 4 * 1000
+	if _, err := s1.Seek(offset, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	got, err = ioutil.ReadAll(s1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want[offset:]) {
+		t.Errorf("the decoded data after seeking doesn't match")
+	}
+}
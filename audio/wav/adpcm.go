@@ -0,0 +1,169 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wav
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+var imaIndexTable = [16]int{
+	-1, -1, -1, -1, 2, 4, 6, 8,
+	-1, -1, -1, -1, 2, 4, 6, 8,
+}
+
+var imaStepTable = [89]int{
+	7, 8, 9, 10, 11, 12, 13, 14, 16, 17,
+	19, 21, 23, 25, 28, 31, 34, 37, 41, 45,
+	50, 55, 60, 66, 73, 80, 88, 97, 107, 118,
+	130, 143, 157, 173, 190, 209, 230, 253, 279, 307,
+	337, 371, 408, 449, 494, 544, 598, 658, 724, 796,
+	876, 963, 1060, 1166, 1282, 1411, 1552, 1707, 1878, 2066,
+	2272, 2499, 2749, 3024, 3327, 3660, 4026, 4428, 4871, 5358,
+	5894, 6484, 7132, 7845, 8630, 9493, 10442, 11487, 12635, 13899,
+	15289, 16818, 18500, 20350, 22385, 24623, 27086, 29794, 32767,
+}
+
+func clamp16(v int) int16 {
+	if v > 32767 {
+		return 32767
+	}
+	if v < -32768 {
+		return -32768
+	}
+	return int16(v)
+}
+
+// imaADPCMFramesPerBlock returns the number of the frames in a block of IMA ADPCM.
+func imaADPCMFramesPerBlock(blockAlign, channelNum int) int {
+	return (blockAlign/channelNum-4)*2 + 1
+}
+
+// decodeIMAADPCM decodes a block of IMA ADPCM.
+func decodeIMAADPCM(channelNum int) blockDecoder {
+	return func(dst []int16, block []byte) (int, error) {
+		if len(block) < 4*channelNum {
+			return 0, fmt.Errorf("wav: IMA ADPCM block is too short")
+		}
+
+		var predictors, indices [2]int
+		for ch := 0; ch < channelNum; ch++ {
+			h := block[4*ch:]
+			predictors[ch] = int(int16(binary.LittleEndian.Uint16(h)))
+			indices[ch] = int(h[2])
+			if indices[ch] > 88 {
+				return 0, fmt.Errorf("wav: invalid IMA ADPCM step index: %d", indices[ch])
+			}
+			dst[ch] = int16(predictors[ch])
+		}
+
+		// Each channel has 4 bytes (8 samples) in turn.
+		data := block[4*channelNum:]
+		groups := len(data) / (4 * channelNum)
+		for g := 0; g < groups; g++ {
+			for ch := 0; ch < channelNum; ch++ {
+				bs := data[(g*channelNum+ch)*4:]
+				for i := 0; i < 8; i++ {
+					nibble := int(bs[i/2]>>(uint(i%2)*4)) & 0xf
+					step := imaStepTable[indices[ch]]
+					diff := step >> 3
+					if nibble&1 != 0 {
+						diff += step >> 2
+					}
+					if nibble&2 != 0 {
+						diff += step >> 1
+					}
+					if nibble&4 != 0 {
+						diff += step
+					}
+					if nibble&8 != 0 {
+						diff = -diff
+					}
+					predictors[ch] = int(clamp16(predictors[ch] + diff))
+					indices[ch] += imaIndexTable[nibble]
+					if indices[ch] < 0 {
+						indices[ch] = 0
+					}
+					if indices[ch] > 88 {
+						indices[ch] = 88
+					}
+					dst[(1+g*8+i)*channelNum+ch] = int16(predictors[ch])
+				}
+			}
+		}
+		return 1 + groups*8, nil
+	}
+}
+
+var msADPCMAdaptationTable = [16]int{
+	230, 230, 230, 230, 307, 409, 512, 614,
+	768, 614, 512, 409, 307, 230, 230, 230,
+}
+
+// msADPCMDefaultCoefficients is the standard coefficients of MS ADPCM.
+var msADPCMDefaultCoefficients = [][2]int{
+	{256, 0}, {512, -256}, {0, 0}, {192, 64}, {240, 0}, {460, -208}, {392, -232},
+}
+
+// msADPCMFramesPerBlock returns the number of the frames in a block of MS ADPCM.
+func msADPCMFramesPerBlock(blockAlign, channelNum int) int {
+	return (blockAlign-7*channelNum)*2/channelNum + 2
+}
+
+// decodeMSADPCM decodes a block of MS ADPCM.
+func decodeMSADPCM(channelNum int, coefficients [][2]int) blockDecoder {
+	return func(dst []int16, block []byte) (int, error) {
+		if len(block) < 7*channelNum {
+			return 0, fmt.Errorf("wav: MS ADPCM block is too short")
+		}
+
+		var coefs [2][2]int
+		var deltas, sample1s, sample2s [2]int
+		for ch := 0; ch < channelNum; ch++ {
+			idx := int(block[ch])
+			if idx >= len(coefficients) {
+				return 0, fmt.Errorf("wav: invalid MS ADPCM predictor index: %d", idx)
+			}
+			coefs[ch] = coefficients[idx]
+			deltas[ch] = int(int16(binary.LittleEndian.Uint16(block[channelNum+2*ch:])))
+			sample1s[ch] = int(int16(binary.LittleEndian.Uint16(block[3*channelNum+2*ch:])))
+			sample2s[ch] = int(int16(binary.LittleEndian.Uint16(block[5*channelNum+2*ch:])))
+			dst[ch] = int16(sample2s[ch])
+			dst[channelNum+ch] = int16(sample1s[ch])
+		}
+
+		// The nibbles are interleaved by channels, and the high nibble comes first.
+		data := block[7*channelNum:]
+		nibbles := len(data) * 2 / channelNum * channelNum
+		for i := 0; i < nibbles; i++ {
+			ch := i % channelNum
+			nibble := int(data[i/2]>>(4*uint(1-i%2))) & 0xf
+			signed := nibble
+			if signed >= 8 {
+				signed -= 16
+			}
+			pred := (sample1s[ch]*coefs[ch][0] + sample2s[ch]*coefs[ch][1]) / 256
+			v := int(clamp16(pred + signed*deltas[ch]))
+			sample2s[ch] = sample1s[ch]
+			sample1s[ch] = v
+			deltas[ch] = msADPCMAdaptationTable[nibble] * deltas[ch] / 256
+			if deltas[ch] < 16 {
+				deltas[ch] = 16
+			}
+			dst[2*channelNum+i] = int16(v)
+		}
+		return 2 + nibbles/channelNum, nil
+	}
+}
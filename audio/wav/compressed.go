@@ -0,0 +1,257 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wav
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Format codes of the fmt chunk.
+const (
+	formatPCM        = 0x0001
+	formatMSADPCM    = 0x0002
+	formatIEEEFloat  = 0x0003
+	formatALaw       = 0x0006
+	formatMuLaw      = 0x0007
+	formatIMAADPCM   = 0x0011
+	formatExtensible = 0xfffe
+)
+
+// blockDecoder decodes a block of compressed data into 16bit samples and returns the number of the frames.
+type blockDecoder func(dst []int16, block []byte) (int, error)
+
+// blockStream decodes compressed data block by block into 16bit little endian samples.
+type blockStream struct {
+	src            io.ReadSeeker
+	decode         blockDecoder
+	blockAlign     int
+	framesPerBlock int
+	channelNum     int
+	totalFrames    int64
+
+	// frame is the frame position of the next block.
+	frame int64
+
+	// pos is the position in bytes of the decoded stream.
+	pos int64
+
+	buf   []byte
+	block []byte
+	pcm   []int16
+}
+
+func newBlockStream(src io.ReadSeeker, decode blockDecoder, blockAlign, framesPerBlock, channelNum int, totalFrames int64) *blockStream {
+	return &blockStream{
+		src:            src,
+		decode:         decode,
+		blockAlign:     blockAlign,
+		framesPerBlock: framesPerBlock,
+		channelNum:     channelNum,
+		totalFrames:    totalFrames,
+		block:          make([]byte, blockAlign),
+		pcm:            make([]int16, framesPerBlock*channelNum),
+	}
+}
+
+func (s *blockStream) bytesPerFrame() int {
+	return 2 * s.channelNum
+}
+
+// readBlock reads and decodes the next block into s.buf.
+func (s *blockStream) readBlock() error {
+	n, err := io.ReadFull(s.src, s.block)
+	if err == io.EOF {
+		return io.EOF
+	}
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	frames, err := s.decode(s.pcm, s.block[:n])
+	if err != nil {
+		return err
+	}
+	start := s.frame
+	s.frame += int64(s.framesPerBlock)
+	if rest := s.totalFrames - start; int64(frames) > rest {
+		frames = int(rest)
+	}
+	if frames <= 0 {
+		return io.EOF
+	}
+
+	size := frames * s.bytesPerFrame()
+	if cap(s.buf) < size {
+		s.buf = make([]byte, size)
+	}
+	s.buf = s.buf[:size]
+	for i, v := range s.pcm[:frames*s.channelNum] {
+		binary.LittleEndian.PutUint16(s.buf[2*i:], uint16(v))
+	}
+	return nil
+}
+
+// Read is implementation of io.Reader's Read.
+func (s *blockStream) Read(p []byte) (int, error) {
+	if len(s.buf) == 0 {
+		if err := s.readBlock(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, s.buf)
+	s.buf = s.buf[n:]
+	s.pos += int64(n)
+	return n, nil
+}
+
+// Seek is implementation of io.Seeker's Seek.
+func (s *blockStream) Seek(offset int64, whence int) (int64, error) {
+	size := s.totalFrames * int64(s.bytesPerFrame())
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += s.pos
+	case io.SeekEnd:
+		offset += size
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("wav: invalid offset")
+	}
+	if offset > size {
+		offset = size
+	}
+
+	frame := offset / int64(s.bytesPerFrame())
+	block := frame / int64(s.framesPerBlock)
+	if _, err := s.src.Seek(block*int64(s.blockAlign), io.SeekStart); err != nil {
+		return 0, err
+	}
+	s.frame = block * int64(s.framesPerBlock)
+	s.buf = s.buf[:0]
+	s.pos = frame * int64(s.bytesPerFrame())
+	if frame == s.totalFrames {
+		return s.pos, nil
+	}
+	if err := s.readBlock(); err != nil {
+		if err == io.EOF {
+			// The data is shorter than the header says. Treat this as the end of the stream.
+			return s.pos, nil
+		}
+		return 0, err
+	}
+	// The last block can be shorter than expected when the data is truncated.
+	skip := (frame - block*int64(s.framesPerBlock)) * int64(s.bytesPerFrame())
+	if skip > int64(len(s.buf)) {
+		skip = int64(len(s.buf))
+	}
+	s.buf = s.buf[skip:]
+	return s.pos, nil
+}
+
+// newCompressedStream returns a stream to decode compressed data in src.
+// 24bit and 32bit PCM and IEEE float data are also decoded by this stream.
+// factFrames is the number of the frames in the fact chunk, or 0 if the fact chunk doesn't exist.
+func newCompressedStream(src io.ReadSeeker, format, channelNum, blockAlign, bitsPerSample int, extension []byte, dataSize, factFrames int64) (*blockStream, error) {
+	var decode blockDecoder
+	var framesPerBlock int
+	// partialFrames returns the number of the frames in a partial block of the given size at the end.
+	var partialFrames func(size int) int
+
+	switch format {
+	case formatPCM, formatIEEEFloat:
+		bytesPerSample := bitsPerSample / 8
+		if format == formatPCM {
+			if bitsPerSample != 24 && bitsPerSample != 32 {
+				return nil, fmt.Errorf("wav: bits per sample must be 8, 16, 24 or 32 but was %d", bitsPerSample)
+			}
+			decode = decodeIntPCM(channelNum, bytesPerSample)
+		} else {
+			if bitsPerSample != 32 && bitsPerSample != 64 {
+				return nil, fmt.Errorf("wav: bits per sample must be 32 or 64 for IEEE float but was %d", bitsPerSample)
+			}
+			decode = decodeFloatPCM(channelNum, bytesPerSample)
+		}
+		framesPerBlock = widePCMFramesPerBlock
+		blockAlign = widePCMFramesPerBlock * channelNum * bytesPerSample
+		partialFrames = func(size int) int {
+			return size / (channelNum * bytesPerSample)
+		}
+	case formatMuLaw, formatALaw:
+		if bitsPerSample != 8 {
+			return nil, fmt.Errorf("wav: bits per sample must be 8 for µ-law and A-law but was %d", bitsPerSample)
+		}
+		if format == formatMuLaw {
+			decode = decodeMuLaw(channelNum)
+		} else {
+			decode = decodeALaw(channelNum)
+		}
+		framesPerBlock = g711FramesPerBlock
+		blockAlign = g711FramesPerBlock * channelNum
+		partialFrames = func(size int) int {
+			return size / channelNum
+		}
+	case formatIMAADPCM:
+		if bitsPerSample != 4 {
+			return nil, fmt.Errorf("wav: bits per sample must be 4 for IMA ADPCM but was %d", bitsPerSample)
+		}
+		if blockAlign <= 4*channelNum || blockAlign%(4*channelNum) != 0 {
+			return nil, fmt.Errorf("wav: invalid block align for IMA ADPCM: %d", blockAlign)
+		}
+		decode = decodeIMAADPCM(channelNum)
+		framesPerBlock = imaADPCMFramesPerBlock(blockAlign, channelNum)
+		partialFrames = func(size int) int {
+			if size < 4*channelNum {
+				return 0
+			}
+			return 1 + (size-4*channelNum)/(4*channelNum)*8
+		}
+	case formatMSADPCM:
+		if bitsPerSample != 4 {
+			return nil, fmt.Errorf("wav: bits per sample must be 4 for MS ADPCM but was %d", bitsPerSample)
+		}
+		if blockAlign < 7*channelNum {
+			return nil, fmt.Errorf("wav: invalid block align for MS ADPCM: %d", blockAlign)
+		}
+		coefs := msADPCMDefaultCoefficients
+		// The extension has cbSize, wSamplesPerBlock, wNumCoef and the coefficients.
+		if len(extension) >= 6 {
+			n := int(binary.LittleEndian.Uint16(extension[4:]))
+			if n > 0 && len(extension) >= 6+4*n {
+				coefs = make([][2]int, n)
+				for i := range coefs {
+					coefs[i][0] = int(int16(binary.LittleEndian.Uint16(extension[6+4*i:])))
+					coefs[i][1] = int(int16(binary.LittleEndian.Uint16(extension[8+4*i:])))
+				}
+			}
+		}
+		decode = decodeMSADPCM(channelNum, coefs)
+		framesPerBlock = msADPCMFramesPerBlock(blockAlign, channelNum)
+		partialFrames = func(size int) int {
+			if size < 7*channelNum {
+				return 0
+			}
+			return 2 + (size-7*channelNum)*2/channelNum
+		}
+	default:
+		return nil, fmt.Errorf("wav: unsupported format: %#04x", format)
+	}
+
+	totalFrames := dataSize/int64(blockAlign)*int64(framesPerBlock) + int64(partialFrames(int(dataSize%int64(blockAlign))))
+	if factFrames > 0 && factFrames < totalFrames {
+		totalFrames = factFrames
+	}
+	return newBlockStream(src, decode, blockAlign, framesPerBlock, channelNum, totalFrames), nil
+}
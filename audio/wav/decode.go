@@ -26,8 +26,9 @@ import (
 
 // Stream is a decoded audio stream.
 type Stream struct {
-	inner io.ReadSeeker
-	size  int64
+	inner      io.ReadSeeker
+	size       int64
+	sampleRate int
 }
 
 // Read is implementation of io.Reader's Read.
@@ -49,6 +50,13 @@ func (s *Stream) Length() int64 {
 	return s.size
 }
 
+// SampleRate returns the sample rate of the decoded stream.
+//
+// audio.NewPlayer uses SampleRate to resample the stream to the audio context's sample rate.
+func (s *Stream) SampleRate() int {
+	return s.sampleRate
+}
+
 type stream struct {
 	src        io.Reader
 	headerSize int64
@@ -104,7 +112,7 @@ func (s *stream) Seek(offset int64, whence int) (int64, error) {
 
 // DecodeWithSampleRate decodes WAV (RIFF) data to playable stream.
 //
-// The format must be 1 or 2 channels, 8bit or 16bit little endian PCM.
+// The format must be 1 or 2 channels, 8bit, 16bit, 24bit or 32bit little endian PCM, 32bit or 64bit IEEE float, IMA ADPCM, MS ADPCM, µ-law or A-law.
 // The format is converted into 2 channels and 16bit.
 //
 // DecodeWithSampleRate returns error when decoding fails or IO error happens.
@@ -116,6 +124,29 @@ func (s *stream) Seek(offset int64, whence int) (int64, error) {
 // A Stream doesn't close src even if src implements io.Closer.
 // Closing the source is src owner's responsibility.
 func DecodeWithSampleRate(sampleRate int, src io.Reader) (*Stream, error) {
+	return decode(src, sampleRate)
+}
+
+// DecodeWithoutResampling decodes WAV (RIFF) data to playable stream.
+//
+// The format must be 1 or 2 channels, 8bit, 16bit, 24bit or 32bit little endian PCM, 32bit or 64bit IEEE float, IMA ADPCM, MS ADPCM, µ-law or A-law.
+// The format is converted into 2 channels and 16bit.
+//
+// DecodeWithoutResampling returns error when decoding fails or IO error happens.
+//
+// DecodeWithoutResampling doesn't resample the stream. The returned Stream's SampleRate returns the original
+// sample rate, and audio.NewPlayer resamples the stream to the audio context's sample rate.
+//
+// The returned Stream's Seek is available only when src is an io.Seeker.
+//
+// A Stream doesn't close src even if src implements io.Closer.
+// Closing the source is src owner's responsibility.
+func DecodeWithoutResampling(src io.Reader) (*Stream, error) {
+	return decode(src, 0)
+}
+
+// decode decodes WAV (RIFF) data. If sampleRate is 0, decode doesn't resample the stream.
+func decode(src io.Reader, sampleRate int) (*Stream, error) {
 	buf := make([]byte, 12)
 	n, err := io.ReadFull(src, buf)
 	if n != len(buf) {
@@ -138,6 +169,11 @@ func DecodeWithSampleRate(sampleRate int, src io.Reader) (*Stream, error) {
 	sampleRateTo := 0
 	mono := false
 	bitsPerSample := 0
+	format := 0
+	channelNum := 0
+	blockAlign := 0
+	var extension []byte
+	var factFrames int64
 chunks:
 	for {
 		buf := make([]byte, 8)
@@ -164,11 +200,21 @@ chunks:
 			if err != nil {
 				return nil, err
 			}
-			format := int(buf[0]) | int(buf[1])<<8
-			if format != 1 {
-				return nil, fmt.Errorf("wav: format must be linear PCM")
+			format = int(buf[0]) | int(buf[1])<<8
+			if format == formatExtensible {
+				// The extension has cbSize, wValidBitsPerSample, dwChannelMask and the sub format GUID.
+				// The first 2 bytes of the GUID is the actual format.
+				if size < 16+24 {
+					return nil, fmt.Errorf("wav: invalid header: too short extensible format")
+				}
+				format = int(buf[24]) | int(buf[25])<<8
+			}
+			switch format {
+			case formatPCM, formatIEEEFloat, formatMSADPCM, formatALaw, formatMuLaw, formatIMAADPCM:
+			default:
+				return nil, fmt.Errorf("wav: format must be linear PCM, IEEE float, IMA ADPCM, MS ADPCM, µ-law or A-law but was %#04x", format)
 			}
-			channelNum := int(buf[2]) | int(buf[3])<<8
+			channelNum = int(buf[2]) | int(buf[3])<<8
 			switch channelNum {
 			case 1:
 				mono = true
@@ -177,11 +223,18 @@ chunks:
 			default:
 				return nil, fmt.Errorf("wav: channel num must be 1 or 2 but was %d", channelNum)
 			}
+			blockAlign = int(buf[12]) | int(buf[13])<<8
 			bitsPerSample = int(buf[14]) | int(buf[15])<<8
-			if bitsPerSample != 8 && bitsPerSample != 16 {
-				return nil, fmt.Errorf("wav: bits per sample must be 8 or 16 but was %d", bitsPerSample)
+			if size > 16 {
+				extension = buf[16:]
+			}
+			if format == formatPCM && bitsPerSample != 8 && bitsPerSample != 16 && bitsPerSample != 24 && bitsPerSample != 32 {
+				return nil, fmt.Errorf("wav: bits per sample must be 8, 16, 24 or 32 but was %d", bitsPerSample)
 			}
 			origSampleRate := int64(buf[4]) | int64(buf[5])<<8 | int64(buf[6])<<16 | int64(buf[7])<<24
+			if sampleRate == 0 {
+				sampleRate = int(origSampleRate)
+			}
 			if int64(sampleRate) != origSampleRate {
 				sampleRateFrom = int(origSampleRate)
 				sampleRateTo = sampleRate
@@ -191,14 +244,18 @@ chunks:
 			dataSize = size
 			break chunks
 		default:
-			buf := make([]byte, size)
-			n, err := io.ReadFull(src, buf)
-			if n != len(buf) {
+			data := make([]byte, size)
+			n, err := io.ReadFull(src, data)
+			if n != len(data) {
 				return nil, fmt.Errorf("wav: invalid header")
 			}
 			if err != nil {
 				return nil, err
 			}
+			// The fact chunk has the number of the frames for compressed formats.
+			if bytes.Equal(buf[0:4], []byte("fact")) && size >= 4 {
+				factFrames = int64(data[0]) | int64(data[1])<<8 | int64(data[2])<<16 | int64(data[3])<<24
+			}
 			headerSize += size
 		}
 	}
@@ -209,6 +266,16 @@ chunks:
 		remaining:  dataSize,
 	}
 
+	if format != formatPCM || bitsPerSample > 16 {
+		cs, err := newCompressedStream(s, format, channelNum, blockAlign, bitsPerSample, extension, dataSize, factFrames)
+		if err != nil {
+			return nil, err
+		}
+		s = cs
+		dataSize = cs.totalFrames * 2 * int64(channelNum)
+		bitsPerSample = 16
+	}
+
 	if mono || bitsPerSample != 16 {
 		s = convert.NewStereo16(s, mono, bitsPerSample != 16)
 		if mono {
@@ -223,13 +290,13 @@ chunks:
 		s = r
 		dataSize = r.Length()
 	}
-	ss := &Stream{inner: s, size: dataSize}
+	ss := &Stream{inner: s, size: dataSize, sampleRate: sampleRate}
 	return ss, nil
 }
 
 // Decode decodes WAV (RIFF) data to playable stream.
 //
-// The format must be 1 or 2 channels, 8bit or 16bit little endian PCM.
+// The format must be 1 or 2 channels, 8bit, 16bit, 24bit or 32bit little endian PCM, 32bit or 64bit IEEE float, IMA ADPCM, MS ADPCM, µ-law or A-law.
 // The format is converted into 2 channels and 16bit.
 //
 // Decode returns error when decoding fails or IO error happens.
@@ -0,0 +1,308 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wav_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2/audio/wav"
+)
+
+type wavFormat struct {
+	format        uint16
+	channelNum    uint16
+	blockAlign    uint16
+	bitsPerSample uint16
+	extension     []byte
+	factFrames    int
+}
+
+func makeWAV(f wavFormat, data []byte) []byte {
+	le := func(vs ...interface{}) []byte {
+		var b bytes.Buffer
+		for _, v := range vs {
+			_ = binary.Write(&b, binary.LittleEndian, v)
+		}
+		return b.Bytes()
+	}
+
+	fmtChunk := le(f.format, f.channelNum, uint32(8000), uint32(8000*int(f.blockAlign)), f.blockAlign, f.bitsPerSample)
+	if f.extension != nil {
+		fmtChunk = append(fmtChunk, le(uint16(len(f.extension)))...)
+		fmtChunk = append(fmtChunk, f.extension...)
+	}
+
+	var body []byte
+	body = append(body, "WAVE"...)
+	body = append(body, "fmt "...)
+	body = append(body, le(uint32(len(fmtChunk)))...)
+	body = append(body, fmtChunk...)
+	if f.factFrames > 0 {
+		body = append(body, "fact"...)
+		body = append(body, le(uint32(4), uint32(f.factFrames))...)
+	}
+	body = append(body, "data"...)
+	body = append(body, le(uint32(len(data)))...)
+	body = append(body, data...)
+
+	r := []byte("RIFF")
+	r = append(r, le(uint32(len(body)))...)
+	return append(r, body...)
+}
+
+// decodeMono decodes the WAV data and returns the left channel.
+func decodeMono(t *testing.T, data []byte) []int16 {
+	t.Helper()
+	s, err := wav.DecodeWithoutResampling(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bs, err := ioutil.ReadAll(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := int64(len(bs)), s.Length(); got != want {
+		t.Errorf("the decoded size: got: %d, want: %d", got, want)
+	}
+	var r []int16
+	for i := 0; i < len(bs); i += 4 {
+		r = append(r, int16(binary.LittleEndian.Uint16(bs[i:])))
+	}
+	return r
+}
+
+func equalInt16s(a, b []int16) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestDecodeG711(t *testing.T) {
+	cases := []struct {
+		format uint16
+		in     []byte
+		out    []int16
+	}{
+		{
+			format: 7,
+			in:     []byte{0xff, 0x7f, 0x80, 0x00, 0xf0},
+			out:    []int16{0, 0, 32124, -32124, 120},
+		},
+		{
+			format: 6,
+			in:     []byte{0xd5, 0x55, 0xaa, 0x2a},
+			out:    []int16{8, -8, 32256, -32256},
+		},
+	}
+	for _, c := range cases {
+		data := makeWAV(wavFormat{format: c.format, channelNum: 1, blockAlign: 1, bitsPerSample: 8}, c.in)
+		if got := decodeMono(t, data); !equalInt16s(got, c.out) {
+			t.Errorf("format: %d: got: %v, want: %v", c.format, got, c.out)
+		}
+	}
+}
+
+func TestDecodeIMAADPCM(t *testing.T) {
+	block := []byte{0, 0, 0, 0, 0x77, 0x0f, 0x00, 0x00}
+	want := []int16{0, 11, 41, -22, -13, -5, 2, 8, 14}
+	f := wavFormat{format: 0x11, channelNum: 1, blockAlign: 8, bitsPerSample: 4, extension: []byte{9, 0}}
+	if got := decodeMono(t, makeWAV(f, block)); !equalInt16s(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+
+	// The fact chunk limits the number of the frames.
+	f.factFrames = 5
+	if got := decodeMono(t, makeWAV(f, block)); !equalInt16s(got, want[:5]) {
+		t.Errorf("got: %v, want: %v", got, want[:5])
+	}
+}
+
+func TestDecodeMSADPCM(t *testing.T) {
+	block := []byte{0, 16, 0, 100, 0, 50, 0, 0x1f, 0x70}
+	want := []int16{50, 100, 116, 100, 212, 212}
+	f := wavFormat{format: 0x02, channelNum: 1, blockAlign: 9, bitsPerSample: 4}
+	if got := decodeMono(t, makeWAV(f, block)); !equalInt16s(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
+
+func TestDecodeCompressedSeek(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	randomBlocks := func(blockAlign, headerSize, n int, rest int) []byte {
+		var data []byte
+		for i := 0; i < n; i++ {
+			b := make([]byte, blockAlign)
+			r.Read(b)
+			// Make the header valid.
+			for j := 0; j < headerSize; j++ {
+				b[j] = 0
+			}
+			data = append(data, b...)
+		}
+		return data[:len(data)-rest]
+	}
+
+	cases := []struct {
+		name   string
+		format wavFormat
+		data   []byte
+	}{
+		{
+			name:   "IMA ADPCM",
+			format: wavFormat{format: 0x11, channelNum: 2, blockAlign: 256, bitsPerSample: 4},
+			data:   randomBlocks(256, 8, 5, 100),
+		},
+		{
+			name:   "MS ADPCM",
+			format: wavFormat{format: 0x02, channelNum: 2, blockAlign: 256, bitsPerSample: 4},
+			data:   randomBlocks(256, 14, 5, 101),
+		},
+		{
+			name:   "µ-law",
+			format: wavFormat{format: 0x07, channelNum: 2, blockAlign: 2, bitsPerSample: 8},
+			data:   randomBlocks(3000, 0, 2, 0),
+		},
+		{
+			name:   "24bit PCM",
+			format: wavFormat{format: 0x01, channelNum: 2, blockAlign: 6, bitsPerSample: 24},
+			data:   randomBlocks(6*1500, 0, 1, 0),
+		},
+	}
+	for _, c := range cases {
+		s, err := wav.DecodeWithoutResampling(bytes.NewReader(makeWAV(c.format, c.data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		all, err := ioutil.ReadAll(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := int64(len(all)), s.Length(); got != want {
+			t.Errorf("%s: the decoded size: got: %d, want: %d", c.name, got, want)
+		}
+		for _, offset := range []int64{1000, 4 * 500, int64(len(all)) - 8, 0} {
+			if _, err := s.Seek(offset, io.SeekStart); err != nil {
+				t.Fatal(err)
+			}
+			got, err := ioutil.ReadAll(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, all[offset:]) {
+				t.Errorf("%s: offset: %d: the decoded data doesn't match", c.name, offset)
+			}
+		}
+	}
+
+	// The fact chunk and the data chunk's size overstate the frames of the truncated data.
+	for _, c := range cases {
+		format := c.format
+		format.factFrames = 1 << 20
+		wavData := makeWAV(format, c.data)
+		wavData = wavData[:len(wavData)-len(c.data)/3]
+		s, err := wav.DecodeWithoutResampling(bytes.NewReader(wavData))
+		if err != nil {
+			t.Fatal(err)
+		}
+		all, err := ioutil.ReadAll(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, offset := range []int64{int64(len(all)) - 8, int64(len(all)), s.Length() - 8, s.Length()} {
+			if _, err := s.Seek(offset, io.SeekStart); err != nil {
+				t.Fatalf("%s (truncated): offset: %d: %v", c.name, offset, err)
+			}
+			got, err := ioutil.ReadAll(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want []byte
+			if offset < int64(len(all)) {
+				want = all[offset:]
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s (truncated): offset: %d: the decoded data doesn't match", c.name, offset)
+			}
+		}
+	}
+}
+
+func TestDecodeWidePCM(t *testing.T) {
+	le := func(vs ...interface{}) []byte {
+		var b bytes.Buffer
+		for _, v := range vs {
+			_ = binary.Write(&b, binary.LittleEndian, v)
+		}
+		return b.Bytes()
+	}
+	want := []int16{0, 0x1234, -0x1234, 32767, -32768}
+
+	// The extension for WAVE_FORMAT_EXTENSIBLE with the PCM sub format.
+	extensible := le(uint16(24), uint32(0x4), uint32(1), uint16(0), uint16(0x10), [8]byte{0x80, 0x00, 0x00, 0xaa, 0x00, 0x38, 0x9b, 0x71})
+
+	cases := []struct {
+		name   string
+		format wavFormat
+		data   []byte
+		want   []int16
+	}{
+		{
+			name:   "24bit",
+			format: wavFormat{format: 1, channelNum: 1, blockAlign: 3, bitsPerSample: 24},
+			data:   []byte{0, 0, 0, 0x56, 0x34, 0x12, 0x00, 0xcc, 0xed, 0xff, 0xff, 0x7f, 0x00, 0x00, 0x80},
+			want:   want,
+		},
+		{
+			name:   "32bit",
+			format: wavFormat{format: 1, channelNum: 1, blockAlign: 4, bitsPerSample: 32},
+			data:   le(int32(0), int32(0x12345678), int32(-0x12340000), int32(0x7fffffff), int32(-0x80000000)),
+			want:   want,
+		},
+		{
+			name:   "24bit extensible",
+			format: wavFormat{format: 0xfffe, channelNum: 1, blockAlign: 3, bitsPerSample: 24, extension: extensible},
+			data:   []byte{0, 0, 0, 0x56, 0x34, 0x12, 0x00, 0xcc, 0xed, 0xff, 0xff, 0x7f, 0x00, 0x00, 0x80},
+			want:   want,
+		},
+		{
+			name:   "32bit float",
+			format: wavFormat{format: 3, channelNum: 1, blockAlign: 4, bitsPerSample: 32},
+			data:   le(float32(0), float32(0.5), float32(-0.5), float32(1), float32(-2)),
+			want:   []int16{0, 16383, -16383, 32767, -32767},
+		},
+		{
+			name:   "64bit float",
+			format: wavFormat{format: 3, channelNum: 1, blockAlign: 8, bitsPerSample: 64},
+			data:   le(float64(0), float64(0.5), float64(-0.5), float64(1), float64(-2)),
+			want:   []int16{0, 16383, -16383, 32767, -32767},
+		},
+	}
+	for _, c := range cases {
+		if got := decodeMono(t, makeWAV(c.format, c.data)); !equalInt16s(got, c.want) {
+			t.Errorf("%s: got: %v, want: %v", c.name, got, c.want)
+		}
+	}
+}
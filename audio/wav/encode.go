@@ -0,0 +1,70 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wav
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// Encode encodes 16bit little endian, 2 channel stereo linear PCM data from src to WAV (RIFF) data, and writes
+// it to w.
+//
+// The format of src is same as audio.NewPlayer's source, e.g. the result of audio.Context.Render.
+//
+// Encode reads the whole src before writing since the header requires the data size.
+func Encode(w io.Writer, src io.Reader, sampleRate int) error {
+	const (
+		channelNum     = 2
+		bitsPerSample  = 16
+		bytesPerSample = channelNum * bitsPerSample / 8
+	)
+
+	data, err := ioutil.ReadAll(src)
+	if err != nil {
+		return err
+	}
+	data = data[:len(data)/bytesPerSample*bytesPerSample]
+	if int64(len(data)) > 0xffffffff-36 {
+		return fmt.Errorf("wav: the data is too big: %d bytes", len(data))
+	}
+
+	header := make([]byte, 44)
+	copy(header[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:8], uint32(36+len(data)))
+	copy(header[8:12], "WAVE")
+
+	copy(header[12:16], "fmt ")
+	binary.LittleEndian.PutUint32(header[16:20], 16)
+	binary.LittleEndian.PutUint16(header[20:22], 1) // Linear PCM
+	binary.LittleEndian.PutUint16(header[22:24], channelNum)
+	binary.LittleEndian.PutUint32(header[24:28], uint32(sampleRate))
+	binary.LittleEndian.PutUint32(header[28:32], uint32(sampleRate*bytesPerSample))
+	binary.LittleEndian.PutUint16(header[32:34], bytesPerSample)
+	binary.LittleEndian.PutUint16(header[34:36], bitsPerSample)
+
+	copy(header[36:40], "data")
+	binary.LittleEndian.PutUint32(header[40:44], uint32(len(data)))
+
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	return nil
+}
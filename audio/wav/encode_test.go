@@ -0,0 +1,50 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wav_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2/audio/wav"
+)
+
+func TestEncode(t *testing.T) {
+	data := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+
+	var buf bytes.Buffer
+	if err := wav.Encode(&buf, bytes.NewReader(data), 22050); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := wav.DecodeWithoutResampling(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.SampleRate(), 22050; got != want {
+		t.Errorf("s.SampleRate(): got: %d, want: %d", got, want)
+	}
+	if got, want := s.Length(), int64(len(data)); got != want {
+		t.Errorf("s.Length(): got: %d, want: %d", got, want)
+	}
+	got, err := ioutil.ReadAll(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("got: %v, want: %v", got, data)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wav
+
+// g711FramesPerBlock is the number of the frames decoded at once for µ-law and A-law.
+const g711FramesPerBlock = 1024
+
+// decodeMuLaw decodes µ-law samples.
+func decodeMuLaw(channelNum int) blockDecoder {
+	return func(dst []int16, block []byte) (int, error) {
+		for i, b := range block {
+			u := ^b
+			t := (int(u&0x0f)<<3 + 0x84) << (uint(u&0x70) >> 4)
+			if u&0x80 != 0 {
+				dst[i] = int16(0x84 - t)
+			} else {
+				dst[i] = int16(t - 0x84)
+			}
+		}
+		return len(block) / channelNum, nil
+	}
+}
+
+// decodeALaw decodes A-law samples.
+func decodeALaw(channelNum int) blockDecoder {
+	return func(dst []int16, block []byte) (int, error) {
+		for i, b := range block {
+			a := b ^ 0x55
+			t := int(a&0x0f) << 4
+			switch seg := uint(a&0x70) >> 4; seg {
+			case 0:
+				t += 8
+			case 1:
+				t += 0x108
+			default:
+				t += 0x108
+				t <<= seg - 1
+			}
+			if a&0x80 != 0 {
+				dst[i] = int16(t)
+			} else {
+				dst[i] = int16(-t)
+			}
+		}
+		return len(block) / channelNum, nil
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wav
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// widePCMFramesPerBlock is the number of the frames decoded at once for 24bit and 32bit PCM.
+const widePCMFramesPerBlock = 1024
+
+// decodeIntPCM decodes 24bit or 32bit little endian integer samples into 16bit samples.
+func decodeIntPCM(channelNum, bytesPerSample int) blockDecoder {
+	return func(dst []int16, block []byte) (int, error) {
+		n := len(block) / bytesPerSample
+		for i := 0; i < n; i++ {
+			// Use the most significant 2 bytes.
+			b := block[i*bytesPerSample+bytesPerSample-2:]
+			dst[i] = int16(binary.LittleEndian.Uint16(b))
+		}
+		return n / channelNum, nil
+	}
+}
+
+// decodeFloatPCM decodes 32bit or 64bit little endian IEEE float samples into 16bit samples.
+func decodeFloatPCM(channelNum, bytesPerSample int) blockDecoder {
+	return func(dst []int16, block []byte) (int, error) {
+		n := len(block) / bytesPerSample
+		for i := 0; i < n; i++ {
+			var v float64
+			if bytesPerSample == 4 {
+				v = float64(math.Float32frombits(binary.LittleEndian.Uint32(block[4*i:])))
+			} else {
+				v = math.Float64frombits(binary.LittleEndian.Uint64(block[8*i:]))
+			}
+			switch {
+			case v > 1:
+				v = 1
+			case v < -1 || math.IsNaN(v):
+				v = -1
+			}
+			dst[i] = int16(v * (1<<15 - 1))
+		}
+		return n / channelNum, nil
+	}
+}
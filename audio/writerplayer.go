@@ -62,25 +62,49 @@ type writerPlayer struct {
 	context          *Context
 	driver           writerDriver
 	src              io.Reader
+	format           sampleFormat
+	sampleRate       int
+	f32              *float32Reader
+	rate             *rateReader
+	spatial          *spatialReader
+	bus              *busReader
+	fade             *fadeReader
+	tap              *tapReader
 	playing          bool
 	closedExplicitly bool
 	isLoopActive     bool
+	reachedEOF       bool
+	onEnd            func()
 
-	buf     []byte
 	readbuf []byte
 	pos     int64
-	volume  float64
+
+	audible audiblePosition
+	v       voice
 
 	m sync.Mutex
 }
 
-func (c *writerPlayerFactory) newPlayerImpl(context *Context, src io.Reader) (playerImpl, error) {
+func (c *writerPlayerFactory) newPlayerImpl(context *Context, src io.Reader, format sampleFormat, sampleRate int) (playerImpl, error) {
 	p := &writerPlayer{
-		context: context,
-		driver:  c.driver,
-		src:     src,
-		volume:  1,
+		context:    context,
+		driver:     c.driver,
+		src:        src,
+		format:     format,
+		sampleRate: sampleRate,
+		spatial:    newSpatialReader(context.listener, context.SampleRate()),
+		bus:        newBusReader(context.masterBus),
+	}
+	p.fade = newFadeReader(p.bus, context.SampleRate())
+	p.tap = newTapReader(p.fade, p.bus)
+	if format == sampleFormatFloat32 {
+		p.rate = newRateReader(src, sampleRate, context.SampleRate())
+	} else {
+		p.f32 = newFloat32Reader(src)
+		p.rate = newRateReader(p.f32, sampleRate, context.SampleRate())
 	}
+	p.spatial.src = p.rate
+	p.bus.src = p.spatial
 	if seeker, ok := p.src.(io.Seeker); ok {
 		// Get the current position of the source.
 		pos, err := seeker.Seek(0, io.SeekCurrent)
@@ -101,6 +125,7 @@ func (p *writerPlayer) Close() error {
 		return fmt.Errorf("audio: the player is already closed")
 	}
 	p.closedExplicitly = true
+	p.reachedEOF = false
 	return nil
 }
 
@@ -134,6 +159,17 @@ func (p *writerPlayer) loop() {
 	defer func() {
 		<-wclosed
 		w.Close()
+
+		p.m.Lock()
+		var f func()
+		if p.reachedEOF {
+			f = p.onEnd
+			p.reachedEOF = false
+		}
+		p.m.Unlock()
+		if f != nil {
+			f()
+		}
 	}()
 
 	defer func() {
@@ -189,33 +225,28 @@ func (p *writerPlayer) read() ([]byte, bool) {
 		return nil, false
 	}
 
-	const bufSize = 2048
+	// The samples are read as float32 and converted to 16bit for the driver.
+	const bufSize = 4096
 	if p.readbuf == nil {
 		p.readbuf = make([]byte, bufSize)
 	}
-	n, err := p.src.Read(p.readbuf[:bufSize-len(p.buf)])
+	consumed := p.rate.Consumed()
+	n, err := p.tap.Read(p.readbuf)
+	p.pos += (p.rate.Consumed() - consumed) / bytesPerSampleF32 * int64(p.format.bytesPerSample())
 	if err != nil {
 		if err != io.EOF {
 			p.context.setError(err)
 			return nil, false
 		}
 		if n == 0 {
+			p.reachedEOF = true
 			return nil, false
 		}
 	}
-	buf := append(p.buf, p.readbuf[:n]...)
-
-	n2 := len(buf) - len(buf)%bytesPerSample
-	buf, p.buf = buf[:n2], buf[n2:]
-
-	for i := 0; i < len(buf)/2; i++ {
-		v16 := int16(buf[2*i]) | (int16(buf[2*i+1]) << 8)
-		v16 = int16(float64(v16) * p.volume)
-		buf[2*i] = byte(v16)
-		buf[2*i+1] = byte(v16 >> 8)
-	}
-	p.pos += int64(len(buf))
-
+	// busReader returns only complete samples.
+	buf := make([]byte, n/bitDepthInBytesF32*bitDepthInBytes)
+	// The volume is already applied by fadeReader.
+	float32ToInt16(buf, p.readbuf[:n], 1)
 	return buf, true
 }
 
@@ -237,7 +268,8 @@ func (p *writerPlayer) Seek(offset time.Duration) error {
 	p.m.Lock()
 	defer p.m.Unlock()
 
-	o := int64(offset) * bytesPerSample * int64(p.context.SampleRate()) / int64(time.Second)
+	bytesPerSample := int64(p.format.bytesPerSample())
+	o := int64(offset) * bytesPerSample * int64(p.sampleRate) / int64(time.Second)
 	o = o - (o % bytesPerSample)
 
 	seeker, ok := p.src.(io.Seeker)
@@ -249,8 +281,15 @@ func (p *writerPlayer) Seek(offset time.Duration) error {
 		return err
 	}
 
-	p.buf = nil
 	p.pos = pos
+	p.reachedEOF = false
+	if p.f32 != nil {
+		p.f32.reset()
+	}
+	p.rate.reset()
+	p.spatial.reset()
+	p.bus.reset()
+	p.audible.reset(offset)
 	return nil
 }
 
@@ -262,29 +301,82 @@ func (p *writerPlayer) Pause() {
 
 func (p *writerPlayer) Current() time.Duration {
 	p.m.Lock()
-	sample := p.pos / bytesPerSample
+	sample := p.pos / int64(p.format.bytesPerSample())
 	p.m.Unlock()
-	return time.Duration(sample) * time.Second / time.Duration(p.context.SampleRate())
+	return time.Duration(sample) * time.Second / time.Duration(p.sampleRate)
 }
 
-func (p *writerPlayer) Volume() float64 {
+// AudiblePosition returns the position extrapolated with the wall clock. The writer drivers don't provide the
+// output latency.
+func (p *writerPlayer) AudiblePosition() time.Duration {
+	current := p.Current()
+
 	p.m.Lock()
-	v := p.volume
-	p.m.Unlock()
-	return v
+	defer p.m.Unlock()
+	return p.audible.estimate(current, p.playing, p.rate.Rate(), time.Now())
+}
+
+func (p *writerPlayer) Volume() float64 {
+	return p.fade.Volume()
 }
 
 func (p *writerPlayer) SetVolume(volume float64) {
-	// The condition must be true when volume is NaN.
-	if !(0 <= volume && volume <= 1) {
-		panic("audio: volume must be in between 0 and 1")
+	p.fade.SetVolume(volume)
+}
+
+func (p *writerPlayer) FadeTo(volume float64, duration time.Duration) {
+	p.fade.FadeTo(volume, duration)
+}
+
+func (p *writerPlayer) Rate() float64 {
+	return p.rate.Rate()
+}
+
+func (p *writerPlayer) SetRate(rate float64) {
+	p.rate.SetRate(rate)
+}
+
+func (p *writerPlayer) Bus() *Bus {
+	return p.bus.Bus()
+}
+
+func (p *writerPlayer) SetBus(bus *Bus) {
+	if bus == nil {
+		bus = p.context.masterBus
 	}
+	p.bus.SetBus(bus)
+}
+
+func (p *writerPlayer) Position() (x, y, z float64) {
+	return p.spatial.Position()
+}
+
+func (p *writerPlayer) SetPosition(x, y, z float64) {
+	p.spatial.SetPosition(x, y, z)
+}
+
+func (p *writerPlayer) SetSpatialOptions(options *SpatialOptions) {
+	p.spatial.SetOptions(options)
+}
 
+func (p *writerPlayer) Tap() *Tap {
+	return p.tap.Tap()
+}
+
+func (p *writerPlayer) SetTap(tap *Tap) {
+	p.tap.SetTap(tap)
+}
+
+func (p *writerPlayer) SetOnEnd(f func()) {
 	p.m.Lock()
-	p.volume = volume
-	p.m.Unlock()
+	defer p.m.Unlock()
+	p.onEnd = f
 }
 
 func (p *writerPlayer) source() io.Reader {
 	return p.src
 }
+
+func (p *writerPlayer) voice() *voice {
+	return &p.v
+}
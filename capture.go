@@ -0,0 +1,382 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten
+
+import (
+	"bytes"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"sync"
+	"time"
+)
+
+// CaptureHotkey represents a combination of inputs that triggers a capture action.
+//
+// A capture action is triggered when all the inputs become pressed. If both Keys and GamepadButtons are empty,
+// the action is never triggered.
+type CaptureHotkey struct {
+	// Keys are the keys that must be pressed at the same time.
+	Keys []Key
+
+	// GamepadButtons are the gamepad buttons that must be pressed at the same time on any one gamepad.
+	GamepadButtons []GamepadButton
+}
+
+// CaptureHotkeyID represents a capture action registered by AddScreenshotHotkey or AddGIFRecordingHotkey.
+type CaptureHotkeyID int
+
+// GIFRecordingOptions represents the options for GIF recording.
+type GIFRecordingOptions struct {
+	// FrameInterval is the number of frames between captured frames.
+	//
+	// The default (zero) value is 2.
+	FrameInterval int
+
+	// MaxFrames is the maximum number of the captured frames.
+	// When the number reaches MaxFrames, the recording stops automatically.
+	//
+	// The default (zero) value is 300.
+	MaxFrames int
+}
+
+// AddScreenshotHotkey registers an action to take a screenshot when the given hotkey is pressed,
+// and returns the ID of the action.
+//
+// f is called with the screen image right after the game's Draw, so the screenshot doesn't include the
+// performance overlay. f is called on the same goroutine as Draw.
+//
+// AddScreenshotHotkey is concurrent-safe.
+func AddScreenshotHotkey(hotkey CaptureHotkey, f func(img image.Image)) CaptureHotkeyID {
+	return theCaptureHotkeys.add(&captureHotkey{
+		hotkey:     hotkey,
+		screenshot: f,
+	})
+}
+
+// AddGIFRecordingHotkey registers an action to start and stop recording the screen as an animated GIF when the
+// given hotkey is pressed, and returns the ID of the action.
+//
+// When the recording stops, f is called with the encoded GIF data. f is called on a different goroutine from
+// the game, as encoding a GIF can take a while.
+//
+// Recording reads pixels from GPU at every captured frame, so the game can be slow during the recording.
+//
+// If options is nil, the default options are used.
+//
+// AddGIFRecordingHotkey is concurrent-safe.
+func AddGIFRecordingHotkey(hotkey CaptureHotkey, options *GIFRecordingOptions, f func(data []byte)) CaptureHotkeyID {
+	h := &captureHotkey{
+		hotkey:        hotkey,
+		gif:           f,
+		frameInterval: 2,
+		maxFrames:     300,
+	}
+	if options != nil {
+		if options.FrameInterval > 0 {
+			h.frameInterval = options.FrameInterval
+		}
+		if options.MaxFrames > 0 {
+			h.maxFrames = options.MaxFrames
+		}
+	}
+	return theCaptureHotkeys.add(h)
+}
+
+// RemoveCaptureHotkey unregisters the action of the given ID.
+//
+// If a GIF recording by the action is in progress, the recording is discarded.
+//
+// RemoveCaptureHotkey is concurrent-safe.
+func RemoveCaptureHotkey(id CaptureHotkeyID) {
+	theCaptureHotkeys.remove(id)
+}
+
+// IsRecordingGIF reports whether a GIF recording is in progress.
+//
+// IsRecordingGIF is concurrent-safe.
+func IsRecordingGIF() bool {
+	return theCaptureHotkeys.isRecording()
+}
+
+type captureHotkey struct {
+	hotkey  CaptureHotkey
+	pressed bool
+
+	screenshot func(img image.Image)
+
+	gif           func(data []byte)
+	frameInterval int
+	maxFrames     int
+	recording     *gifRecording
+}
+
+func (c *captureHotkey) isPressed() bool {
+	if len(c.hotkey.Keys) == 0 && len(c.hotkey.GamepadButtons) == 0 {
+		return false
+	}
+	for _, k := range c.hotkey.Keys {
+		if !IsKeyPressed(k) {
+			return false
+		}
+	}
+	if len(c.hotkey.GamepadButtons) == 0 {
+		return true
+	}
+	for _, id := range GamepadIDs() {
+		pressed := true
+		for _, b := range c.hotkey.GamepadButtons {
+			if !IsGamepadButtonPressed(id, b) {
+				pressed = false
+				break
+			}
+		}
+		if pressed {
+			return true
+		}
+	}
+	return false
+}
+
+type captureHotkeys struct {
+	hotkeys map[CaptureHotkeyID]*captureHotkey
+	nextID  CaptureHotkeyID
+	m       sync.Mutex
+}
+
+var theCaptureHotkeys captureHotkeys
+
+func (c *captureHotkeys) add(hotkey *captureHotkey) CaptureHotkeyID {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if c.hotkeys == nil {
+		c.hotkeys = map[CaptureHotkeyID]*captureHotkey{}
+	}
+	id := c.nextID
+	c.nextID++
+	c.hotkeys[id] = hotkey
+	return id
+}
+
+func (c *captureHotkeys) remove(id CaptureHotkeyID) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	h, ok := c.hotkeys[id]
+	if !ok {
+		return
+	}
+	if h.recording != nil {
+		h.recording.discard()
+		h.recording = nil
+	}
+	delete(c.hotkeys, id)
+}
+
+func (c *captureHotkeys) isRecording() bool {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	for _, h := range c.hotkeys {
+		if h.recording != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// capture processes the registered actions with the given screen. capture must be called after the game's Draw.
+func (c *captureHotkeys) capture(screen *Image) error {
+	screenshots, err := c.record(screen)
+	if err != nil {
+		return err
+	}
+
+	// Call the functions without the lock so that the functions can register or unregister actions.
+	for _, f := range screenshots {
+		img, err := screenToRGBA(screen)
+		if err != nil {
+			return err
+		}
+		f(img)
+	}
+	return nil
+}
+
+// record updates the hotkeys' states and records the screen for the GIF recordings.
+// record returns the screenshot functions to be called.
+func (c *captureHotkeys) record(screen *Image) ([]func(img image.Image), error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	var screenshots []func(img image.Image)
+	var img *image.RGBA
+	for _, h := range c.hotkeys {
+		pressed := h.isPressed()
+		triggered := pressed && !h.pressed
+		h.pressed = pressed
+
+		if h.screenshot != nil && triggered {
+			screenshots = append(screenshots, h.screenshot)
+		}
+		if h.gif == nil {
+			continue
+		}
+
+		if triggered {
+			if h.recording == nil {
+				h.recording = newGIFRecording(h.gif, h.frameInterval, h.maxFrames)
+			} else {
+				h.recording.finish()
+				h.recording = nil
+			}
+		}
+		if h.recording == nil || !h.recording.shouldCapture() {
+			continue
+		}
+		// The same image can be shared among the recordings as the recordings never modify the image.
+		if img == nil {
+			var err error
+			img, err = screenToRGBA(screen)
+			if err != nil {
+				return nil, err
+			}
+		}
+		h.recording.append(img, time.Now())
+		if h.recording.isFull() {
+			h.recording.finish()
+			h.recording = nil
+		}
+	}
+	return screenshots, nil
+}
+
+func screenToRGBA(screen *Image) (*image.RGBA, error) {
+	img, err := readPixels(screen)
+	if err != nil {
+		return nil, err
+	}
+	if !IsScreenTransparent() {
+		// The screen is rendered on a black background.
+		for i := 3; i < len(img.Pix); i += 4 {
+			img.Pix[i] = 0xff
+		}
+	}
+	return img, nil
+}
+
+// readPixels reads the pixels of the given image at once.
+func readPixels(img *Image) (*image.RGBA, error) {
+	b := img.physicalBounds()
+	pix, err := img.mipmap.Pixels(b.Min.X, b.Min.Y, b.Dx(), b.Dy())
+	if err != nil {
+		return nil, err
+	}
+	return &image.RGBA{
+		Pix:    pix,
+		Stride: 4 * b.Dx(),
+		Rect:   image.Rect(0, 0, b.Dx(), b.Dy()),
+	}, nil
+}
+
+type gifFrame struct {
+	img  *image.RGBA
+	time time.Time
+}
+
+// gifRecording is a GIF recording. The captured frames are quantized and encoded on another goroutine.
+type gifRecording struct {
+	frameInterval int
+	maxFrames     int
+	frameCount    int
+	capturedCount int
+
+	frames chan gifFrame
+	done   chan struct{}
+}
+
+func newGIFRecording(f func(data []byte), frameInterval, maxFrames int) *gifRecording {
+	r := &gifRecording{
+		frameInterval: frameInterval,
+		maxFrames:     maxFrames,
+		frames:        make(chan gifFrame, 8),
+		done:          make(chan struct{}),
+	}
+	go r.loop(f)
+	return r
+}
+
+func (r *gifRecording) loop(f func(data []byte)) {
+	g := &gif.GIF{}
+	var lastTime time.Time
+	for frame := range r.frames {
+		b := frame.img.Bounds()
+		p := image.NewPaletted(b, palette.Plan9)
+		draw.FloydSteinberg.Draw(p, b, frame.img, b.Min)
+
+		if len(g.Image) > 0 {
+			// The delay's unit is 1/100 seconds.
+			g.Delay[len(g.Delay)-1] = int((frame.time.Sub(lastTime) + 5*time.Millisecond) / (10 * time.Millisecond))
+		}
+		g.Image = append(g.Image, p)
+		g.Delay = append(g.Delay, 0)
+		lastTime = frame.time
+	}
+
+	select {
+	case <-r.done:
+		// The recording is discarded.
+		return
+	default:
+	}
+
+	if len(g.Delay) > 1 {
+		g.Delay[len(g.Delay)-1] = g.Delay[len(g.Delay)-2]
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		theUIContext.setError(err)
+		return
+	}
+	f(buf.Bytes())
+}
+
+func (r *gifRecording) shouldCapture() bool {
+	r.frameCount++
+	return (r.frameCount-1)%r.frameInterval == 0
+}
+
+func (r *gifRecording) isFull() bool {
+	return r.capturedCount >= r.maxFrames
+}
+
+func (r *gifRecording) append(img *image.RGBA, t time.Time) {
+	r.frames <- gifFrame{
+		img:  img,
+		time: t,
+	}
+	r.capturedCount++
+}
+
+func (r *gifRecording) finish() {
+	close(r.frames)
+}
+
+func (r *gifRecording) discard() {
+	close(r.done)
+	close(r.frames)
+}
@@ -0,0 +1,197 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"reflect"
+	"testing"
+	"time"
+
+	. "github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestGIFRecordingShouldCapture(t *testing.T) {
+	r := NewGIFRecording(func(data []byte) {}, 3, 300)
+	defer r.Discard()
+
+	var got []bool
+	for i := 0; i < 7; i++ {
+		got = append(got, r.ShouldCapture())
+	}
+	want := []bool{true, false, false, true, false, false, true}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
+
+func TestGIFRecordingIsFull(t *testing.T) {
+	r := NewGIFRecording(func(data []byte) {}, 1, 2)
+	defer r.Discard()
+
+	img := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	now := time.Now()
+	for i := 0; i < 2; i++ {
+		if r.IsFull() {
+			t.Fatalf("IsFull() after %d frames: got: true, want: false", i)
+		}
+		r.Append(img, now)
+	}
+	if !r.IsFull() {
+		t.Errorf("IsFull() after 2 frames: got: false, want: true")
+	}
+}
+
+func TestGIFRecordingEncode(t *testing.T) {
+	ch := make(chan []byte, 1)
+	r := NewGIFRecording(func(data []byte) {
+		ch <- data
+	}, 1, 300)
+
+	colors := []color.RGBA{
+		{0xff, 0, 0, 0xff},
+		{0, 0xff, 0, 0xff},
+		{0, 0, 0xff, 0xff},
+	}
+	times := []time.Duration{0, 50 * time.Millisecond, 120 * time.Millisecond}
+	start := time.Now()
+	for i, c := range colors {
+		img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+		for j := 0; j < len(img.Pix); j += 4 {
+			img.Pix[j] = c.R
+			img.Pix[j+1] = c.G
+			img.Pix[j+2] = c.B
+			img.Pix[j+3] = c.A
+		}
+		r.Append(img, start.Add(times[i]))
+	}
+	r.Finish()
+
+	var data []byte
+	select {
+	case data = <-ch:
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout")
+	}
+
+	g, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(g.Image), len(colors); got != want {
+		t.Fatalf("len(g.Image): got: %d, want: %d", got, want)
+	}
+	// The delays are in 1/100 seconds. The last frame's delay is the same as the previous one.
+	if got, want := g.Delay, []int{5, 7, 7}; !reflect.DeepEqual(got, want) {
+		t.Errorf("g.Delay: got: %v, want: %v", got, want)
+	}
+	for i, img := range g.Image {
+		if got, want := img.Bounds(), image.Rect(0, 0, 4, 4); got != want {
+			t.Errorf("g.Image[%d].Bounds(): got: %v, want: %v", i, got, want)
+		}
+		got := color.RGBAModel.Convert(img.At(0, 0)).(color.RGBA)
+		if want := colors[i]; got != want {
+			t.Errorf("g.Image[%d].At(0, 0): got: %v, want: %v", i, got, want)
+		}
+	}
+}
+
+func TestGIFRecordingDiscard(t *testing.T) {
+	ch := make(chan []byte, 1)
+	r := NewGIFRecording(func(data []byte) {
+		ch <- data
+	}, 1, 300)
+	r.Append(image.NewRGBA(image.Rect(0, 0, 1, 1)), time.Now())
+	r.Discard()
+
+	select {
+	case <-ch:
+		t.Errorf("a discarded recording must not be encoded")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestAddGIFRecordingHotkeyOptions(t *testing.T) {
+	hotkey := CaptureHotkey{
+		Keys: []Key{KeyF12},
+	}
+
+	cases := []struct {
+		Name          string
+		Options       *GIFRecordingOptions
+		FrameInterval int
+		MaxFrames     int
+	}{
+		{
+			Name:          "nil",
+			Options:       nil,
+			FrameInterval: 2,
+			MaxFrames:     300,
+		},
+		{
+			Name:          "zero",
+			Options:       &GIFRecordingOptions{},
+			FrameInterval: 2,
+			MaxFrames:     300,
+		},
+		{
+			Name: "specified",
+			Options: &GIFRecordingOptions{
+				FrameInterval: 5,
+				MaxFrames:     10,
+			},
+			FrameInterval: 5,
+			MaxFrames:     10,
+		},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			id := AddGIFRecordingHotkey(hotkey, c.Options, func(data []byte) {})
+			frameInterval, maxFrames, ok := GIFRecordingOptionsOf(id)
+			if !ok {
+				t.Fatalf("the action %d is not registered", id)
+			}
+			if frameInterval != c.FrameInterval {
+				t.Errorf("frame interval: got: %d, want: %d", frameInterval, c.FrameInterval)
+			}
+			if maxFrames != c.MaxFrames {
+				t.Errorf("max frames: got: %d, want: %d", maxFrames, c.MaxFrames)
+			}
+
+			RemoveCaptureHotkey(id)
+			if _, _, ok := GIFRecordingOptionsOf(id); ok {
+				t.Errorf("the action %d is still registered after RemoveCaptureHotkey", id)
+			}
+		})
+	}
+}
+
+func TestCaptureHotkeyIDsAreUnique(t *testing.T) {
+	id0 := AddScreenshotHotkey(CaptureHotkey{}, func(img image.Image) {})
+	id1 := AddScreenshotHotkey(CaptureHotkey{}, func(img image.Image) {})
+	defer RemoveCaptureHotkey(id0)
+	defer RemoveCaptureHotkey(id1)
+
+	if id0 == id1 {
+		t.Errorf("got the same IDs: %d", id0)
+	}
+	if IsRecordingGIF() {
+		t.Errorf("IsRecordingGIF(): got: true, want: false")
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten
+
+// SetClipboardText writes text to the system clipboard on browsers.
+//
+// Browsers allow to write to the clipboard only in or soon after a user gesture like a click or a key press.
+// If the current timing is not in a user gesture, writing is deferred until the next user gesture on the screen.
+//
+// SetClipboardText does nothing on non-browsers.
+//
+// SetClipboardText must be called from the main thread e.g. in Update.
+func SetClipboardText(text string) {
+	setClipboardText(text)
+}
+
+// RequestClipboardText requests to read text from the system clipboard on browsers.
+//
+// Reading the clipboard is asynchronous. The browser might ask the user for permission. When the read succeeds,
+// the text is reported by PastedText in a later tick. If the read fails, e.g. the permission is denied,
+// nothing is reported.
+//
+// Firefox doesn't allow web pages to read the clipboard. PastedText still reports the text pasted with the browser's
+// paste operation like Ctrl+V.
+//
+// RequestClipboardText does nothing on non-browsers.
+//
+// RequestClipboardText must be called from the main thread e.g. in Update.
+func RequestClipboardText() {
+	requestClipboardText()
+}
+
+// PastedText returns the text pasted in the current tick on browsers.
+//
+// The text is pasted by the browser's paste operation like Ctrl+V while the screen is focused, or by
+// RequestClipboardText. PastedText returns an empty string if nothing is pasted.
+//
+// PastedText always returns an empty string on non-browsers.
+//
+// PastedText must be called from the main thread e.g. in Update.
+func PastedText() string {
+	return pastedText()
+}
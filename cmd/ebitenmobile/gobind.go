@@ -135,6 +135,9 @@ func run() error {
 			if err := writeFile(filepath.Join("java", dir, "EbitenSurfaceView.java"), replacePrefixes(surfaceViewJava)); err != nil {
 				return err
 			}
+			if err := writeFile(filepath.Join("java", dir, "EbitenFileProvider.java"), replacePrefixes(fileProviderJava)); err != nil {
+				return err
+			}
 		case "go":
 			// Do nothing.
 		default:
@@ -164,7 +167,12 @@ const objcM = `// Code generated by ebitenmobile. DO NOT EDIT.
 
 #import "Ebitenmobileview.objc.h"
 
-@interface {{.PrefixUpper}}EbitenViewController : UIViewController
+#if !EBITEN_METAL
+// theGLContext is the GL context that the game's GL objects belong to.
+static EAGLContext* theGLContext;
+#endif
+
+@interface {{.PrefixUpper}}EbitenViewController : UIViewController<EbitenmobileviewOrientationController, EbitenmobileviewSystemUIController, EbitenmobileviewFrameRateController, EbitenmobileviewSoftKeyboardController, EbitenmobileviewShareController, UITextFieldDelegate>
 @end
 
 @implementation {{.PrefixUpper}}EbitenViewController {
@@ -173,6 +181,16 @@ const objcM = `// Code generated by ebitenmobile. DO NOT EDIT.
   bool     started_;
   bool     active_;
   bool     error_;
+
+  CADisplayLink* displayLink_;
+  double         lastDisplayRefreshRate_;
+
+  // allowedOrientations_, immersive_, preferredFrameRate_ and softKeyboardTextField_ must be accessed only on
+  // the main thread.
+  UIInterfaceOrientationMask allowedOrientations_;
+  bool                       immersive_;
+  long                       preferredFrameRate_;
+  UITextField*               softKeyboardTextField_;
 }
 
 - (UIView*)metalView {
@@ -214,8 +232,205 @@ const objcM = `// Code generated by ebitenmobile. DO NOT EDIT.
   [EAGLContext setCurrentContext:context];
 #endif
 
-  CADisplayLink *displayLink = [CADisplayLink displayLinkWithTarget:self selector:@selector(drawFrame)];
-  [displayLink addToRunLoop:[NSRunLoop currentRunLoop] forMode:NSDefaultRunLoopMode];
+  [[NSNotificationCenter defaultCenter] addObserver:self
+                                           selector:@selector(updatePowerState)
+                                               name:NSProcessInfoPowerStateDidChangeNotification
+                                             object:nil];
+  if (@available(iOS 11.0, *)) {
+    [[NSNotificationCenter defaultCenter] addObserver:self
+                                             selector:@selector(updatePowerState)
+                                                 name:NSProcessInfoThermalStateDidChangeNotification
+                                               object:nil];
+  }
+  [self updatePowerState];
+
+  for (NSNotificationName name in @[UIScreenDidConnectNotification, UIScreenDidDisconnectNotification, UIScreenModeDidChangeNotification]) {
+    [[NSNotificationCenter defaultCenter] addObserver:self
+                                             selector:@selector(updateExternalDisplay)
+                                                 name:name
+                                               object:nil];
+  }
+  [self updateExternalDisplay];
+}
+
+- (void)share:(NSData*)data name:(NSString*)name mimeType:(NSString*)mimeType {
+  // This can be called from any thread.
+  dispatch_async(dispatch_get_main_queue(), ^{
+    // Write the data to a file so that the file name is kept.
+    NSURL* url = [[NSURL fileURLWithPath:NSTemporaryDirectory()] URLByAppendingPathComponent:name];
+    if (![data writeToURL:url atomically:YES]) {
+      NSLog(@"Ebiten: failed to write a file to share: %@", url);
+      return;
+    }
+    UIActivityViewController* controller = [[UIActivityViewController alloc] initWithActivityItems:@[url]
+                                                                             applicationActivities:nil];
+    // On iPad, the share sheet is shown as a popover and requires its source.
+    CGRect bounds = self.view.bounds;
+    controller.popoverPresentationController.sourceView = self.view;
+    controller.popoverPresentationController.sourceRect = CGRectMake(CGRectGetMidX(bounds), CGRectGetMidY(bounds), 0, 0);
+    [self presentViewController:controller animated:YES completion:nil];
+  });
+}
+
+- (void)updateExternalDisplay {
+  long width = 0;
+  long height = 0;
+  for (UIScreen* screen in [UIScreen screens]) {
+    if (screen == [UIScreen mainScreen]) {
+      continue;
+    }
+    // The size of the mode is in pixels.
+    CGSize size = screen.currentMode.size;
+    width = (long)size.width;
+    height = (long)size.height;
+    break;
+  }
+  EbitenmobileviewSetExternalDisplaySize(width, height);
+}
+
+- (void)viewWillAppear:(BOOL)animated {
+  [super viewWillAppear:animated];
+
+  // There can be multiple view controllers for the game, e.g. when a view controller is recreated.
+  // The most recently shown view controller renders the game.
+#if EBITEN_METAL
+  EbitenmobileviewSetUIView((uintptr_t)(self.metalView));
+#else
+  EAGLContext* context = self.glkView.context;
+  [EAGLContext setCurrentContext:context];
+  if (theGLContext && theGLContext != context) {
+    // The game's GL objects are not available in this context. Restore them.
+    EbitenmobileviewOnContextLost();
+  }
+  theGLContext = context;
+#endif
+
+  EbitenmobileviewSetOrientationController(self);
+  EbitenmobileviewSetSystemUIController(self);
+  EbitenmobileviewSetFrameRateController(self);
+  EbitenmobileviewSetSoftKeyboardController(self);
+  EbitenmobileviewSetShareController(self);
+
+  if (!displayLink_) {
+    displayLink_ = [CADisplayLink displayLinkWithTarget:self selector:@selector(drawFrame)];
+    [displayLink_ addToRunLoop:[NSRunLoop currentRunLoop] forMode:NSDefaultRunLoopMode];
+    [self applyPreferredFrameRate];
+  }
+
+  // Notify the size of this view again.
+  [self.view setNeedsLayout];
+}
+
+- (void)viewDidDisappear:(BOOL)animated {
+  [super viewDidDisappear:animated];
+
+  // CADisplayLink retains its target. Invalidate it so that this view controller can be released.
+  [displayLink_ invalidate];
+  displayLink_ = nil;
+}
+
+- (void)showSoftKeyboard:(NSString*)text {
+  // This can be called from any thread.
+  dispatch_async(dispatch_get_main_queue(), ^{
+    if (!softKeyboardTextField_) {
+      // The text field must not be hidden to become the first responder. Make it transparent instead.
+      softKeyboardTextField_ = [[UITextField alloc] initWithFrame:CGRectMake(0, 0, 1, 1)];
+      softKeyboardTextField_.alpha = 0;
+      softKeyboardTextField_.autocorrectionType = UITextAutocorrectionTypeNo;
+      softKeyboardTextField_.returnKeyType = UIReturnKeyDone;
+      softKeyboardTextField_.delegate = self;
+      [softKeyboardTextField_ addTarget:self
+                                 action:@selector(softKeyboardTextDidChange)
+                       forControlEvents:UIControlEventEditingChanged];
+      [self.view addSubview:softKeyboardTextField_];
+    }
+    softKeyboardTextField_.text = text;
+    [softKeyboardTextField_ becomeFirstResponder];
+    [self softKeyboardTextDidChange];
+  });
+}
+
+- (void)hideSoftKeyboard {
+  // This can be called from any thread.
+  dispatch_async(dispatch_get_main_queue(), ^{
+    [softKeyboardTextField_ resignFirstResponder];
+  });
+}
+
+- (void)softKeyboardTextDidChange {
+  UITextField* textField = softKeyboardTextField_;
+  NSString* text = textField.text;
+  long start = text.length;
+  long end = text.length;
+  UITextRange* range = textField.markedTextRange;
+  if (range) {
+    start = [textField offsetFromPosition:textField.beginningOfDocument toPosition:range.start];
+    end = [textField offsetFromPosition:textField.beginningOfDocument toPosition:range.end];
+  }
+  EbitenmobileviewSetSoftKeyboardText(text, start, end);
+}
+
+- (void)textFieldDidBeginEditing:(UITextField*)textField {
+  EbitenmobileviewSetSoftKeyboardVisible(true);
+}
+
+- (void)textFieldDidEndEditing:(UITextField*)textField {
+  EbitenmobileviewSetSoftKeyboardVisible(false);
+}
+
+- (BOOL)textFieldShouldReturn:(UITextField*)textField {
+  [textField resignFirstResponder];
+  return NO;
+}
+
+- (void)updatePowerState {
+  // This can be called from any thread.
+  NSProcessInfo* info = [NSProcessInfo processInfo];
+  if (@available(iOS 11.0, *)) {
+    // The values in Go are NSProcessInfoThermalState + 1, as 0 means unknown.
+    EbitenmobileviewSetThermalState((long)info.thermalState + 1);
+  }
+  EbitenmobileviewSetLowPowerMode(info.lowPowerModeEnabled);
+}
+
+- (void)setPreferredFrameRate:(long)fps {
+  // This can be called from any thread.
+  dispatch_async(dispatch_get_main_queue(), ^{
+    preferredFrameRate_ = fps;
+    [self applyPreferredFrameRate];
+  });
+}
+
+- (void)applyPreferredFrameRate {
+  if (!displayLink_) {
+    return;
+  }
+  float fps = (float)preferredFrameRate_;
+#if __IPHONE_OS_VERSION_MAX_ALLOWED >= 150000
+  if (@available(iOS 15.0, *)) {
+    if (fps == 0) {
+      displayLink_.preferredFrameRateRange = CAFrameRateRangeDefault;
+    } else {
+      displayLink_.preferredFrameRateRange = CAFrameRateRangeMake(fps, fps, fps);
+    }
+    return;
+  }
+#endif
+  displayLink_.preferredFramesPerSecond = (NSInteger)fps;
+}
+
+- (void)updateDisplayRefreshRate {
+  CFTimeInterval interval = displayLink_.targetTimestamp - displayLink_.timestamp;
+  if (interval <= 0) {
+    return;
+  }
+  // Round the value as the interval fluctuates slightly.
+  double rate = round(1.0 / interval);
+  if (rate == lastDisplayRefreshRate_) {
+    return;
+  }
+  lastDisplayRefreshRate_ = rate;
+  EbitenmobileviewSetDisplayRefreshRate(rate);
 }
 
 - (void)viewWillLayoutSubviews {
@@ -231,16 +446,107 @@ const objcM = `// Code generated by ebitenmobile. DO NOT EDIT.
   [super viewDidLayoutSubviews];
   CGRect viewRect = [[self view] frame];
 
+  // The orientation and the insets must be notified before the size so that the game doesn't observe an
+  // intermediate state.
+  EbitenmobileviewSetOrientation([self currentOrientation]);
+  if (@available(iOS 11.0, *)) {
+    UIEdgeInsets insets = self.view.safeAreaInsets;
+    EbitenmobileviewSetSafeAreaInsets(insets.left, insets.top, insets.right, insets.bottom);
+  }
   EbitenmobileviewLayout(viewRect.size.width, viewRect.size.height);
 }
 
+- (void)viewSafeAreaInsetsDidChange {
+  [super viewSafeAreaInsetsDidChange];
+  [self.view setNeedsLayout];
+}
+
+- (void)setImmersiveMode:(BOOL)immersive {
+  // This can be called from any thread.
+  dispatch_async(dispatch_get_main_queue(), ^{
+    immersive_ = immersive;
+    [self setNeedsStatusBarAppearanceUpdate];
+    if (@available(iOS 11.0, *)) {
+      [self setNeedsUpdateOfHomeIndicatorAutoHidden];
+    }
+  });
+}
+
+- (void)setEdgeToEdge:(BOOL)edgeToEdge {
+  // The view is always laid out edge-to-edge on iOS.
+}
+
+- (BOOL)prefersStatusBarHidden {
+  return immersive_;
+}
+
+- (BOOL)prefersHomeIndicatorAutoHidden {
+  return immersive_;
+}
+
+// The values must be synced with mobile/orientation.go.
+static const long kOrientationPortrait           = 1 << 0;
+static const long kOrientationPortraitUpsideDown = 1 << 1;
+static const long kOrientationLandscapeLeft      = 1 << 2;
+static const long kOrientationLandscapeRight     = 1 << 3;
+
+- (long)currentOrientation {
+  UIInterfaceOrientation orientation;
+  if (@available(iOS 13.0, *)) {
+    orientation = self.view.window.windowScene.interfaceOrientation;
+  } else {
+    orientation = [[UIApplication sharedApplication] statusBarOrientation];
+  }
+  switch (orientation) {
+  case UIInterfaceOrientationPortrait:
+    return kOrientationPortrait;
+  case UIInterfaceOrientationPortraitUpsideDown:
+    return kOrientationPortraitUpsideDown;
+  case UIInterfaceOrientationLandscapeLeft:
+    return kOrientationLandscapeLeft;
+  case UIInterfaceOrientationLandscapeRight:
+    return kOrientationLandscapeRight;
+  default:
+    return 0;
+  }
+}
+
+- (void)setAllowedOrientations:(long)orientations {
+  UIInterfaceOrientationMask mask = 0;
+  if (orientations & kOrientationPortrait) {
+    mask |= UIInterfaceOrientationMaskPortrait;
+  }
+  if (orientations & kOrientationPortraitUpsideDown) {
+    mask |= UIInterfaceOrientationMaskPortraitUpsideDown;
+  }
+  if (orientations & kOrientationLandscapeLeft) {
+    mask |= UIInterfaceOrientationMaskLandscapeLeft;
+  }
+  if (orientations & kOrientationLandscapeRight) {
+    mask |= UIInterfaceOrientationMaskLandscapeRight;
+  }
+  // This can be called from any thread.
+  dispatch_async(dispatch_get_main_queue(), ^{
+    allowedOrientations_ = mask;
+    [UIViewController attemptRotationToDeviceOrientation];
+  });
+}
+
+- (UIInterfaceOrientationMask)supportedInterfaceOrientations {
+  if (allowedOrientations_ == 0) {
+    return [super supportedInterfaceOrientations];
+  }
+  return allowedOrientations_;
+}
+
 - (void)didReceiveMemoryWarning {
   [super didReceiveMemoryWarning];
-  // Dispose of any resources that can be recreated.
-  // TODO: Notify this to Go world?
+  EbitenmobileviewOnLowMemory();
 }
 
 - (void)drawFrame{
+  [self updateDisplayRefreshRate];
+
   @synchronized(self) {
     if (!active_) {
       return;
@@ -336,6 +642,19 @@ const objcM = `// Code generated by ebitenmobile. DO NOT EDIT.
   }
 }
 
+- (void)terminateGame {
+  NSAssert(started_, @"terminateGame must not be called before viewDidLoad is called");
+
+  @synchronized(self) {
+    active_ = false;
+    NSError* err = nil;
+    EbitenmobileviewTerminate(&err);
+    if (err != nil) {
+      [self onErrorOnGameUpdate:err];
+    }
+  }
+}
+
 @end
 `
 
@@ -343,23 +662,57 @@ const viewJava = `// Code generated by ebitenmobile. DO NOT EDIT.
 
 package {{.JavaPkg}}.{{.PrefixLower}};
 
+import android.app.Activity;
+import android.app.GameManager;
+import android.content.BroadcastReceiver;
+import android.content.ComponentCallbacks2;
 import android.content.Context;
+import android.content.ContextWrapper;
+import android.content.Intent;
+import android.content.IntentFilter;
+import android.content.pm.ActivityInfo;
+import android.content.res.Configuration;
+import android.hardware.display.DisplayManager;
 import android.hardware.input.InputManager;
+import android.os.Build;
 import android.os.Handler;
 import android.os.Looper;
+import android.os.PowerManager;
+import android.text.Editable;
+import android.text.TextWatcher;
 import android.util.AttributeSet;
 import android.util.DisplayMetrics;
 import android.util.Log;
 import android.view.Display;
+import android.view.DisplayCutout;
 import android.view.KeyEvent;
 import android.view.InputDevice;
 import android.view.MotionEvent;
+import android.view.Surface;
+import android.view.SurfaceHolder;
+import android.view.View;
 import android.view.ViewGroup;
+import android.view.Window;
+import android.view.WindowInsets;
 import android.view.WindowManager;
+import android.view.inputmethod.BaseInputConnection;
+import android.view.inputmethod.EditorInfo;
+import android.view.inputmethod.InputMethodManager;
+import android.widget.EditText;
+import android.widget.TextView;
 
 import {{.JavaPkg}}.ebitenmobileview.Ebitenmobileview;
+import {{.JavaPkg}}.ebitenmobileview.FrameRateController;
+import {{.JavaPkg}}.ebitenmobileview.OrientationController;
+import {{.JavaPkg}}.ebitenmobileview.ShareController;
+import {{.JavaPkg}}.ebitenmobileview.SoftKeyboardController;
+import {{.JavaPkg}}.ebitenmobileview.SystemUIController;
+
+import java.io.File;
+import java.io.FileOutputStream;
+import java.io.IOException;
 
-public class EbitenView extends ViewGroup implements InputManager.InputDeviceListener {
+public class EbitenView extends ViewGroup implements InputManager.InputDeviceListener, DisplayManager.DisplayListener, OrientationController, SystemUIController, FrameRateController, SoftKeyboardController, ShareController {
     private static double pxToDp(double x) {
         return x / Ebitenmobileview.deviceScale();
     }
@@ -375,6 +728,8 @@ public class EbitenView extends ViewGroup implements InputManager.InputDeviceLis
     }
 
     private void initialize(Context context) {
+        Ebitenmobileview.setFilesDir(context.getFilesDir().getAbsolutePath());
+
         this.ebitenSurfaceView = new EbitenSurfaceView(getContext());
         LayoutParams params = new LayoutParams(LayoutParams.MATCH_PARENT, LayoutParams.MATCH_PARENT);
         addView(this.ebitenSurfaceView, params);
@@ -384,16 +739,526 @@ public class EbitenView extends ViewGroup implements InputManager.InputDeviceLis
         for (int id : this.inputManager.getInputDeviceIds()) {
             this.onInputDeviceAdded(id);
         }
+
+        this.displayManager = (DisplayManager)context.getSystemService(Context.DISPLAY_SERVICE);
+        this.powerManager = (PowerManager)context.getSystemService(Context.POWER_SERVICE);
+
+        this.ebitenSurfaceView.getHolder().addCallback(new SurfaceHolder.Callback() {
+            @Override
+            public void surfaceCreated(SurfaceHolder holder) {
+                applyPreferredFrameRate();
+            }
+
+            @Override
+            public void surfaceChanged(SurfaceHolder holder, int format, int width, int height) {
+            }
+
+            @Override
+            public void surfaceDestroyed(SurfaceHolder holder) {
+            }
+        });
+
+        this.componentCallbacks = new ComponentCallbacks2() {
+            @Override
+            public void onTrimMemory(int level) {
+                // TRIM_MEMORY_UI_HIDDEN just means the UI is hidden, and TRIM_MEMORY_RUNNING_MODERATE is not
+                // serious yet.
+                if (level == TRIM_MEMORY_UI_HIDDEN || level == TRIM_MEMORY_RUNNING_MODERATE) {
+                    return;
+                }
+                Ebitenmobileview.onLowMemory();
+            }
+
+            @Override
+            public void onLowMemory() {
+                Ebitenmobileview.onLowMemory();
+            }
+
+            @Override
+            public void onConfigurationChanged(Configuration newConfig) {
+            }
+        };
+
+        this.powerSaveModeReceiver = new BroadcastReceiver() {
+            @Override
+            public void onReceive(Context context, Intent intent) {
+                Ebitenmobileview.setLowPowerMode(powerManager.isPowerSaveMode());
+            }
+        };
+        if (Build.VERSION.SDK_INT >= Build.VERSION_CODES.Q) {
+            this.thermalStatusListener = new PowerManager.OnThermalStatusChangedListener() {
+                @Override
+                public void onThermalStatusChanged(int status) {
+                    Ebitenmobileview.setThermalState(toThermalState(status));
+                }
+            };
+        }
+    }
+
+    @Override
+    protected void onAttachedToWindow() {
+        super.onAttachedToWindow();
+
+        // There can be multiple views for the game, e.g. when an activity is recreated.
+        // The most recently attached view receives the requests from the game.
+        Ebitenmobileview.setOrientationController(this);
+        Ebitenmobileview.setSystemUIController(this);
+        Ebitenmobileview.setFrameRateController(this);
+        Ebitenmobileview.setSoftKeyboardController(this);
+        Ebitenmobileview.setShareController(this);
+
+        this.displayManager.registerDisplayListener(this, new Handler(Looper.getMainLooper()));
+        this.onDisplayChanged(Display.DEFAULT_DISPLAY);
+        updateExternalDisplay();
+
+        Context appContext = getContext().getApplicationContext();
+        appContext.registerComponentCallbacks(this.componentCallbacks);
+        appContext.registerReceiver(this.powerSaveModeReceiver, new IntentFilter(PowerManager.ACTION_POWER_SAVE_MODE_CHANGED));
+        Ebitenmobileview.setLowPowerMode(this.powerManager.isPowerSaveMode());
+        if (Build.VERSION.SDK_INT >= Build.VERSION_CODES.Q) {
+            this.powerManager.addThermalStatusListener((PowerManager.OnThermalStatusChangedListener)this.thermalStatusListener);
+            Ebitenmobileview.setThermalState(toThermalState(this.powerManager.getCurrentThermalStatus()));
+        }
+        updateGameMode();
+    }
+
+    @Override
+    protected void onDetachedFromWindow() {
+        super.onDetachedFromWindow();
+
+        this.displayManager.unregisterDisplayListener(this);
+
+        Context appContext = getContext().getApplicationContext();
+        appContext.unregisterComponentCallbacks(this.componentCallbacks);
+        appContext.unregisterReceiver(this.powerSaveModeReceiver);
+        if (Build.VERSION.SDK_INT >= Build.VERSION_CODES.Q) {
+            this.powerManager.removeThermalStatusListener((PowerManager.OnThermalStatusChangedListener)this.thermalStatusListener);
+        }
+    }
+
+    @Override
+    public void share(final byte[] data, final String name, final String mimeType) {
+        // This can be called from any thread.
+        new Handler(Looper.getMainLooper()).post(new Runnable() {
+            @Override
+            public void run() {
+                Context context = getContext();
+                try {
+                    File file = EbitenFileProvider.getFile(context, name);
+                    file.getParentFile().mkdirs();
+                    FileOutputStream out = new FileOutputStream(file);
+                    try {
+                        out.write(data);
+                    } finally {
+                        out.close();
+                    }
+                } catch (IOException e) {
+                    Log.e("Go", "failed to write a file to share: " + e.toString());
+                    return;
+                }
+
+                Intent intent = new Intent(Intent.ACTION_SEND);
+                intent.setType(mimeType);
+                intent.putExtra(Intent.EXTRA_STREAM, EbitenFileProvider.getUri(context, name));
+                intent.addFlags(Intent.FLAG_GRANT_READ_URI_PERMISSION);
+                Intent chooser = Intent.createChooser(intent, null);
+                Activity activity = getActivity();
+                if (activity != null) {
+                    activity.startActivity(chooser);
+                    return;
+                }
+                chooser.addFlags(Intent.FLAG_ACTIVITY_NEW_TASK);
+                context.startActivity(chooser);
+            }
+        });
+    }
+
+    // softKeyboardEditText must be accessed only on the main thread.
+    private EditText softKeyboardEditText;
+
+    @Override
+    public void showSoftKeyboard(final String text) {
+        // This can be called from any thread.
+        new Handler(Looper.getMainLooper()).post(new Runnable() {
+            @Override
+            public void run() {
+                EditText editText = getSoftKeyboardEditText();
+                editText.setText(text);
+                editText.setSelection(editText.length());
+                editText.requestFocus();
+                InputMethodManager imm = (InputMethodManager)getContext().getSystemService(Context.INPUT_METHOD_SERVICE);
+                imm.showSoftInput(editText, InputMethodManager.SHOW_IMPLICIT);
+                Ebitenmobileview.setSoftKeyboardVisible(true);
+            }
+        });
+    }
+
+    @Override
+    public void hideSoftKeyboard() {
+        // This can be called from any thread.
+        new Handler(Looper.getMainLooper()).post(new Runnable() {
+            @Override
+            public void run() {
+                hideSoftKeyboardOnMainThread();
+            }
+        });
+    }
+
+    private void hideSoftKeyboardOnMainThread() {
+        if (this.softKeyboardEditText == null) {
+            return;
+        }
+        InputMethodManager imm = (InputMethodManager)getContext().getSystemService(Context.INPUT_METHOD_SERVICE);
+        imm.hideSoftInputFromWindow(this.softKeyboardEditText.getWindowToken(), 0);
+        this.softKeyboardEditText.clearFocus();
+        Ebitenmobileview.setSoftKeyboardVisible(false);
+    }
+
+    private EditText getSoftKeyboardEditText() {
+        if (this.softKeyboardEditText != null) {
+            return this.softKeyboardEditText;
+        }
+
+        // The edit text is invisible. The game renders the text by itself.
+        EditText editText = new EditText(getContext());
+        editText.setAlpha(0);
+        editText.setSingleLine(true);
+        editText.setImeOptions(EditorInfo.IME_ACTION_DONE | EditorInfo.IME_FLAG_NO_EXTRACT_UI);
+        editText.addTextChangedListener(new TextWatcher() {
+            @Override
+            public void beforeTextChanged(CharSequence s, int start, int count, int after) {
+            }
+
+            @Override
+            public void onTextChanged(CharSequence s, int start, int before, int count) {
+            }
+
+            @Override
+            public void afterTextChanged(Editable s) {
+                int start = BaseInputConnection.getComposingSpanStart(s);
+                int end = BaseInputConnection.getComposingSpanEnd(s);
+                if (start < 0 || end < 0) {
+                    start = s.length();
+                    end = s.length();
+                }
+                Ebitenmobileview.setSoftKeyboardText(s.toString(), start, end);
+            }
+        });
+        editText.setOnEditorActionListener(new TextView.OnEditorActionListener() {
+            @Override
+            public boolean onEditorAction(TextView v, int actionId, KeyEvent event) {
+                hideSoftKeyboardOnMainThread();
+                return true;
+            }
+        });
+        addView(editText, new LayoutParams(1, 1));
+        this.softKeyboardEditText = editText;
+        return editText;
+    }
+
+    // The values must be synced with mobile/power.go.
+    private static final int THERMAL_STATE_NOMINAL  = 1;
+    private static final int THERMAL_STATE_FAIR     = 2;
+    private static final int THERMAL_STATE_SERIOUS  = 3;
+    private static final int THERMAL_STATE_CRITICAL = 4;
+
+    private static int toThermalState(int status) {
+        switch (status) {
+        case PowerManager.THERMAL_STATUS_NONE:
+            return THERMAL_STATE_NOMINAL;
+        case PowerManager.THERMAL_STATUS_LIGHT:
+        case PowerManager.THERMAL_STATUS_MODERATE:
+            return THERMAL_STATE_FAIR;
+        case PowerManager.THERMAL_STATUS_SEVERE:
+            return THERMAL_STATE_SERIOUS;
+        default:
+            return THERMAL_STATE_CRITICAL;
+        }
+    }
+
+    // preferredFrameRate must be accessed only on the main thread.
+    private int preferredFrameRate = 0;
+
+    @Override
+    public void setPreferredFrameRate(final long fps) {
+        // This can be called from any thread.
+        new Handler(Looper.getMainLooper()).post(new Runnable() {
+            @Override
+            public void run() {
+                preferredFrameRate = (int)fps;
+                applyPreferredFrameRate();
+            }
+        });
+    }
+
+    private void applyPreferredFrameRate() {
+        // Even if the display doesn't adopt the frame rate, the frame pacer skips frames to match the frame rate.
+        this.ebitenSurfaceView.setPreferredFrameRate(this.preferredFrameRate);
+
+        if (Build.VERSION.SDK_INT >= Build.VERSION_CODES.R) {
+            Surface surface = this.ebitenSurfaceView.getHolder().getSurface();
+            if (surface == null || !surface.isValid()) {
+                return;
+            }
+            // 0 means no preference.
+            surface.setFrameRate(this.preferredFrameRate, Surface.FRAME_RATE_COMPATIBILITY_DEFAULT);
+            return;
+        }
+        if (Build.VERSION.SDK_INT >= Build.VERSION_CODES.M) {
+            Activity activity = getActivity();
+            if (activity == null) {
+                return;
+            }
+            Display display = activity.getWindowManager().getDefaultDisplay();
+            int modeId = 0;
+            if (this.preferredFrameRate != 0) {
+                Display.Mode current = display.getMode();
+                float bestRate = -1;
+                for (Display.Mode mode : display.getSupportedModes()) {
+                    if (mode.getPhysicalWidth() != current.getPhysicalWidth() || mode.getPhysicalHeight() != current.getPhysicalHeight()) {
+                        continue;
+                    }
+                    float rate = mode.getRefreshRate();
+                    if (bestRate < 0 || Math.abs(rate - this.preferredFrameRate) < Math.abs(bestRate - this.preferredFrameRate)) {
+                        bestRate = rate;
+                        modeId = mode.getModeId();
+                    }
+                }
+            }
+            Window window = activity.getWindow();
+            WindowManager.LayoutParams attrs = window.getAttributes();
+            attrs.preferredDisplayModeId = modeId;
+            window.setAttributes(attrs);
+        }
+    }
+
+    @Override
+    public void onDisplayAdded(int displayId) {
+        updateExternalDisplay();
+    }
+
+    @Override
+    public void onDisplayRemoved(int displayId) {
+        updateExternalDisplay();
+    }
+
+    @Override
+    public void onDisplayChanged(int displayId) {
+        if (displayId != Display.DEFAULT_DISPLAY) {
+            updateExternalDisplay();
+            return;
+        }
+        Display display = this.displayManager.getDisplay(displayId);
+        if (display == null) {
+            return;
+        }
+        this.ebitenSurfaceView.setRefreshRate(display.getRefreshRate());
+        Ebitenmobileview.setDisplayRefreshRate(display.getRefreshRate());
+    }
+
+    private void updateExternalDisplay() {
+        int width = 0;
+        int height = 0;
+        Display[] displays = this.displayManager.getDisplays(DisplayManager.DISPLAY_CATEGORY_PRESENTATION);
+        if (displays.length > 0) {
+            DisplayMetrics metrics = new DisplayMetrics();
+            displays[0].getRealMetrics(metrics);
+            width = metrics.widthPixels;
+            height = metrics.heightPixels;
+        }
+        Ebitenmobileview.setExternalDisplaySize(width, height);
+    }
+
+    private void updateGameMode() {
+        if (Build.VERSION.SDK_INT < Build.VERSION_CODES.S) {
+            return;
+        }
+        GameManager gameManager = (GameManager)getContext().getSystemService(Context.GAME_SERVICE);
+        if (gameManager == null) {
+            return;
+        }
+        Ebitenmobileview.setGameMode(gameManager.getGameMode());
     }
 
     @Override
     protected void onLayout(boolean changed, int left, int top, int right, int bottom) {
         this.ebitenSurfaceView.layout(0, 0, right - left, bottom - top);
+        if (this.softKeyboardEditText != null) {
+            this.softKeyboardEditText.layout(0, 0, 1, 1);
+        }
         double widthInDp = pxToDp(right - left);
         double heightInDp = pxToDp(bottom - top);
+        // The orientation must be notified before the size so that the game doesn't observe an intermediate state.
+        Ebitenmobileview.setOrientation(getCurrentOrientation());
         Ebitenmobileview.layout(widthInDp, heightInDp);
     }
 
+    // The values must be synced with mobile/orientation.go.
+    private static final int ORIENTATION_PORTRAIT             = 1 << 0;
+    private static final int ORIENTATION_PORTRAIT_UPSIDE_DOWN = 1 << 1;
+    private static final int ORIENTATION_LANDSCAPE_LEFT       = 1 << 2;
+    private static final int ORIENTATION_LANDSCAPE_RIGHT      = 1 << 3;
+
+    private Activity getActivity() {
+        Context context = getContext();
+        while (context instanceof ContextWrapper) {
+            if (context instanceof Activity) {
+                return (Activity)context;
+            }
+            context = ((ContextWrapper)context).getBaseContext();
+        }
+        return null;
+    }
+
+    private int getCurrentOrientation() {
+        Display display = ((WindowManager)getContext().getSystemService(Context.WINDOW_SERVICE)).getDefaultDisplay();
+        switch (display.getRotation()) {
+        case Surface.ROTATION_0:
+            return ORIENTATION_PORTRAIT;
+        case Surface.ROTATION_90:
+            return ORIENTATION_LANDSCAPE_RIGHT;
+        case Surface.ROTATION_180:
+            return ORIENTATION_PORTRAIT_UPSIDE_DOWN;
+        case Surface.ROTATION_270:
+            return ORIENTATION_LANDSCAPE_LEFT;
+        }
+        return 0;
+    }
+
+    @Override
+    public void setAllowedOrientations(long orientations) {
+        int requested = ActivityInfo.SCREEN_ORIENTATION_FULL_SENSOR;
+        boolean portrait = (orientations & ORIENTATION_PORTRAIT) != 0;
+        boolean portraitUpsideDown = (orientations & ORIENTATION_PORTRAIT_UPSIDE_DOWN) != 0;
+        boolean landscapeLeft = (orientations & ORIENTATION_LANDSCAPE_LEFT) != 0;
+        boolean landscapeRight = (orientations & ORIENTATION_LANDSCAPE_RIGHT) != 0;
+        if (orientations == 0) {
+            requested = ActivityInfo.SCREEN_ORIENTATION_UNSPECIFIED;
+        } else if (!landscapeLeft && !landscapeRight) {
+            if (portrait && portraitUpsideDown) {
+                requested = ActivityInfo.SCREEN_ORIENTATION_SENSOR_PORTRAIT;
+            } else if (portrait) {
+                requested = ActivityInfo.SCREEN_ORIENTATION_PORTRAIT;
+            } else {
+                requested = ActivityInfo.SCREEN_ORIENTATION_REVERSE_PORTRAIT;
+            }
+        } else if (!portrait && !portraitUpsideDown) {
+            if (landscapeLeft && landscapeRight) {
+                requested = ActivityInfo.SCREEN_ORIENTATION_SENSOR_LANDSCAPE;
+            } else if (landscapeRight) {
+                requested = ActivityInfo.SCREEN_ORIENTATION_LANDSCAPE;
+            } else {
+                requested = ActivityInfo.SCREEN_ORIENTATION_REVERSE_LANDSCAPE;
+            }
+        }
+
+        final Activity activity = getActivity();
+        if (activity == null) {
+            return;
+        }
+        final int requestedOrientation = requested;
+        // This can be called from any thread.
+        new Handler(Looper.getMainLooper()).post(new Runnable() {
+            @Override
+            public void run() {
+                activity.setRequestedOrientation(requestedOrientation);
+            }
+        });
+    }
+
+    // immersive and edgeToEdge must be accessed only on the main thread.
+    private boolean immersive = false;
+    private boolean edgeToEdge = false;
+
+    private void updateSystemUiVisibility() {
+        Activity activity = getActivity();
+        if (activity == null) {
+            return;
+        }
+        Window window = activity.getWindow();
+        int flags = 0;
+        if (this.edgeToEdge || this.immersive) {
+            flags |= View.SYSTEM_UI_FLAG_LAYOUT_STABLE |
+                View.SYSTEM_UI_FLAG_LAYOUT_HIDE_NAVIGATION |
+                View.SYSTEM_UI_FLAG_LAYOUT_FULLSCREEN;
+        }
+        if (this.immersive) {
+            flags |= View.SYSTEM_UI_FLAG_IMMERSIVE_STICKY |
+                View.SYSTEM_UI_FLAG_HIDE_NAVIGATION |
+                View.SYSTEM_UI_FLAG_FULLSCREEN;
+        }
+        if (Build.VERSION.SDK_INT >= Build.VERSION_CODES.P) {
+            WindowManager.LayoutParams attrs = window.getAttributes();
+            if (this.edgeToEdge || this.immersive) {
+                attrs.layoutInDisplayCutoutMode = WindowManager.LayoutParams.LAYOUT_IN_DISPLAY_CUTOUT_MODE_SHORT_EDGES;
+            } else {
+                attrs.layoutInDisplayCutoutMode = WindowManager.LayoutParams.LAYOUT_IN_DISPLAY_CUTOUT_MODE_DEFAULT;
+            }
+            window.setAttributes(attrs);
+        }
+        window.getDecorView().setSystemUiVisibility(flags);
+    }
+
+    @Override
+    public void setImmersiveMode(final boolean immersive) {
+        // This can be called from any thread.
+        new Handler(Looper.getMainLooper()).post(new Runnable() {
+            @Override
+            public void run() {
+                EbitenView.this.immersive = immersive;
+                updateSystemUiVisibility();
+            }
+        });
+    }
+
+    @Override
+    public void setEdgeToEdge(final boolean edgeToEdge) {
+        // This can be called from any thread.
+        new Handler(Looper.getMainLooper()).post(new Runnable() {
+            @Override
+            public void run() {
+                EbitenView.this.edgeToEdge = edgeToEdge;
+                updateSystemUiVisibility();
+            }
+        });
+    }
+
+    @Override
+    public void onWindowFocusChanged(boolean hasWindowFocus) {
+        super.onWindowFocusChanged(hasWindowFocus);
+        // The game mode can be changed e.g. via the Game Dashboard, which takes the window focus.
+        if (hasWindowFocus) {
+            updateGameMode();
+        }
+        // The system UI visibility can be reset e.g. when a dialog is shown. Apply the flags again.
+        if (hasWindowFocus && this.immersive) {
+            updateSystemUiVisibility();
+        }
+    }
+
+    @Override
+    public WindowInsets onApplyWindowInsets(WindowInsets insets) {
+        int left = insets.getSystemWindowInsetLeft();
+        int top = insets.getSystemWindowInsetTop();
+        int right = insets.getSystemWindowInsetRight();
+        int bottom = insets.getSystemWindowInsetBottom();
+        if (Build.VERSION.SDK_INT >= Build.VERSION_CODES.P) {
+            DisplayCutout cutout = insets.getDisplayCutout();
+            if (cutout != null) {
+                left = Math.max(left, cutout.getSafeInsetLeft());
+                top = Math.max(top, cutout.getSafeInsetTop());
+                right = Math.max(right, cutout.getSafeInsetRight());
+                bottom = Math.max(bottom, cutout.getSafeInsetBottom());
+            }
+        }
+        Ebitenmobileview.setSafeAreaInsets(pxToDp(left), pxToDp(top), pxToDp(right), pxToDp(bottom));
+        // The keyboard can be dismissed without the edit text, e.g. by the back button.
+        if (Build.VERSION.SDK_INT >= Build.VERSION_CODES.R && this.softKeyboardEditText != null && this.softKeyboardEditText.hasFocus()) {
+            Ebitenmobileview.setSoftKeyboardVisible(insets.isVisible(WindowInsets.Type.ime()));
+        }
+        return super.onApplyWindowInsets(insets);
+    }
+
     @Override
     public boolean onKeyDown(int keyCode, KeyEvent event) {
         Ebitenmobileview.onKeyDownOnAndroid(keyCode, event.getUnicodeChar(), event.getSource(), event.getDeviceId());
@@ -719,6 +1584,27 @@ public class EbitenView extends ViewGroup implements InputManager.InputDeviceLis
         }
     }
 
+    // terminateGame lets the game finish its work, e.g. saving the game state, by calling the game's Shutdown.
+    // It is recommended to call this before suspendGame when the application is being terminated e.g.,
+    // Activity's onPause is called and isFinishing returns true.
+    public void terminateGame() {
+        this.ebitenSurfaceView.queueEvent(new Runnable() {
+            @Override
+            public void run() {
+                try {
+                    Ebitenmobileview.terminate();
+                } catch (final Exception e) {
+                    new Handler(Looper.getMainLooper()).post(new Runnable() {
+                        @Override
+                        public void run() {
+                            onErrorOnGameUpdate(e);
+                        }
+                    });
+                }
+            }
+        });
+    }
+
     // onErrorOnGameUpdate is called on the main thread when an error happens when updating a game.
     // You can define your own error handler, e.g., using Crashlytics, by overriding this method.
     protected void onErrorOnGameUpdate(Exception e) {
@@ -727,6 +1613,127 @@ public class EbitenView extends ViewGroup implements InputManager.InputDeviceLis
 
     private EbitenSurfaceView ebitenSurfaceView;
     private InputManager inputManager;
+    private DisplayManager displayManager;
+    private PowerManager powerManager;
+    private ComponentCallbacks2 componentCallbacks;
+    private BroadcastReceiver powerSaveModeReceiver;
+    // thermalStatusListener is a PowerManager.OnThermalStatusChangedListener, which is available on Android 10 or later.
+    private Object thermalStatusListener;
+}
+`
+
+const fileProviderJava = `// Code generated by ebitenmobile. DO NOT EDIT.
+
+package {{.JavaPkg}}.{{.PrefixLower}};
+
+import android.content.ContentProvider;
+import android.content.ContentValues;
+import android.content.Context;
+import android.database.Cursor;
+import android.database.MatrixCursor;
+import android.net.Uri;
+import android.os.ParcelFileDescriptor;
+import android.provider.OpenableColumns;
+import android.webkit.MimeTypeMap;
+
+import java.io.File;
+import java.io.FileNotFoundException;
+
+// EbitenFileProvider provides the files shared by mobile.Share to other applications.
+//
+// To use mobile.Share, declare this provider in AndroidManifest.xml of the application:
+//
+//     <provider
+//         android:name="{{.JavaPkg}}.{{.PrefixLower}}.EbitenFileProvider"
+//         android:authorities="${applicationId}.ebitenfileprovider"
+//         android:exported="false"
+//         android:grantUriPermissions="true" />
+public class EbitenFileProvider extends ContentProvider {
+
+    private static final String DIRECTORY = "ebiten_share";
+
+    static File getFile(Context context, String name) {
+        return new File(new File(context.getCacheDir(), DIRECTORY), name);
+    }
+
+    static Uri getUri(Context context, String name) {
+        return new Uri.Builder()
+            .scheme("content")
+            .authority(context.getPackageName() + ".ebitenfileprovider")
+            .appendPath(name)
+            .build();
+    }
+
+    private File getFile(Uri uri) throws FileNotFoundException {
+        String name = uri.getLastPathSegment();
+        if (name == null || name.contains("/") || name.equals("..")) {
+            throw new FileNotFoundException("invalid URI: " + uri);
+        }
+        File file = getFile(getContext(), name);
+        if (!file.isFile()) {
+            throw new FileNotFoundException("file not found: " + uri);
+        }
+        return file;
+    }
+
+    @Override
+    public boolean onCreate() {
+        return true;
+    }
+
+    @Override
+    public Cursor query(Uri uri, String[] projection, String selection, String[] selectionArgs, String sortOrder) {
+        File file;
+        try {
+            file = getFile(uri);
+        } catch (FileNotFoundException e) {
+            return null;
+        }
+        if (projection == null) {
+            projection = new String[] {OpenableColumns.DISPLAY_NAME, OpenableColumns.SIZE};
+        }
+        MatrixCursor cursor = new MatrixCursor(projection, 1);
+        Object[] row = new Object[projection.length];
+        for (int i = 0; i < projection.length; i++) {
+            if (OpenableColumns.DISPLAY_NAME.equals(projection[i])) {
+                row[i] = file.getName();
+            } else if (OpenableColumns.SIZE.equals(projection[i])) {
+                row[i] = file.length();
+            }
+        }
+        cursor.addRow(row);
+        return cursor;
+    }
+
+    @Override
+    public String getType(Uri uri) {
+        String ext = MimeTypeMap.getFileExtensionFromUrl(uri.getLastPathSegment());
+        String type = MimeTypeMap.getSingleton().getMimeTypeFromExtension(ext);
+        if (type == null) {
+            return "application/octet-stream";
+        }
+        return type;
+    }
+
+    @Override
+    public ParcelFileDescriptor openFile(Uri uri, String mode) throws FileNotFoundException {
+        return ParcelFileDescriptor.open(getFile(uri), ParcelFileDescriptor.MODE_READ_ONLY);
+    }
+
+    @Override
+    public Uri insert(Uri uri, ContentValues values) {
+        throw new UnsupportedOperationException("insert is not supported");
+    }
+
+    @Override
+    public int delete(Uri uri, String selection, String[] selectionArgs) {
+        throw new UnsupportedOperationException("delete is not supported");
+    }
+
+    @Override
+    public int update(Uri uri, ContentValues values, String selection, String[] selectionArgs) {
+        throw new UnsupportedOperationException("update is not supported");
+    }
 }
 `
 
@@ -735,11 +1742,15 @@ const surfaceViewJava = `// Code generated by ebitenmobile. DO NOT EDIT.
 package {{.JavaPkg}}.{{.PrefixLower}};
 
 import android.content.Context;
+import android.opengl.EGL14;
+import android.opengl.EGLExt;
 import android.opengl.GLSurfaceView;
+import android.os.Build;
 import android.os.Handler;
 import android.os.Looper;
 import android.util.AttributeSet;
 import android.util.Log;
+import android.view.Choreographer;
 
 import javax.microedition.khronos.egl.EGLConfig;
 import javax.microedition.khronos.opengles.GL10;
@@ -749,6 +1760,46 @@ import {{.JavaPkg}}.{{.PrefixLower}}.EbitenView;
 
 class EbitenSurfaceView extends GLSurfaceView {
 
+    // FramePacer requests rendering at the display's vsync timings, in the same way as the Android Frame Pacing
+    // library (Swappy). With the continuous rendering mode, frames are rendered as fast as eglSwapBuffers allows
+    // and their timings can drift from vsync, which causes judder.
+    private class FramePacer implements Choreographer.FrameCallback {
+
+        private boolean running_ = false;
+        private long vsyncCount_ = 0;
+
+        void start() {
+            if (running_) {
+                return;
+            }
+            running_ = true;
+            Choreographer.getInstance().postFrameCallback(this);
+        }
+
+        void stop() {
+            running_ = false;
+            Choreographer.getInstance().removeFrameCallback(this);
+        }
+
+        @Override
+        public void doFrame(long frameTimeNanos) {
+            if (!running_) {
+                return;
+            }
+            Choreographer.getInstance().postFrameCallback(this);
+
+            int swapInterval = getSwapInterval();
+            vsyncCount_++;
+            if (vsyncCount_ % swapInterval != 0) {
+                return;
+            }
+            // Present the frame at the vsync when the next frame is requested, so that every frame is shown
+            // for the same duration.
+            presentationTimeNanos_ = frameTimeNanos + swapInterval * (long)(1e9 / refreshRate_);
+            requestRender();
+        }
+    }
+
     private class EbitenRenderer implements GLSurfaceView.Renderer {
 
         private boolean errored_ = false;
@@ -768,6 +1819,11 @@ class EbitenSurfaceView extends GLSurfaceView {
                     }
                 });
                 errored_ = true;
+                return;
+            }
+            long presentationTimeNanos = presentationTimeNanos_;
+            if (Build.VERSION.SDK_INT >= Build.VERSION_CODES.JELLY_BEAN_MR2 && presentationTimeNanos != 0) {
+                EGLExt.eglPresentationTimeANDROID(EGL14.eglGetCurrentDisplay(), EGL14.eglGetCurrentSurface(EGL14.EGL_DRAW), presentationTimeNanos);
             }
         }
 
@@ -795,6 +1851,47 @@ class EbitenSurfaceView extends GLSurfaceView {
         setEGLContextClientVersion(2);
         setEGLConfigChooser(8, 8, 8, 8, 0, 0);
         setRenderer(new EbitenRenderer());
+        // Frames are requested by the frame pacer.
+        setRenderMode(RENDERMODE_WHEN_DIRTY);
+    }
+
+    // presentationTimeNanos_ is accessed on both the main thread and the GL thread.
+    private volatile long presentationTimeNanos_ = 0;
+
+    // refreshRate_ and preferredFrameRate_ must be accessed only on the main thread.
+    private float refreshRate_ = 60;
+    private int preferredFrameRate_ = 0;
+
+    private FramePacer framePacer_ = new FramePacer();
+
+    void setRefreshRate(float refreshRate) {
+        if (refreshRate <= 0) {
+            return;
+        }
+        refreshRate_ = refreshRate;
+    }
+
+    void setPreferredFrameRate(int fps) {
+        preferredFrameRate_ = fps;
+    }
+
+    private int getSwapInterval() {
+        if (preferredFrameRate_ <= 0) {
+            return 1;
+        }
+        return Math.max(Math.round(refreshRate_ / preferredFrameRate_), 1);
+    }
+
+    @Override
+    protected void onAttachedToWindow() {
+        super.onAttachedToWindow();
+        framePacer_.start();
+    }
+
+    @Override
+    protected void onDetachedFromWindow() {
+        framePacer_.stop();
+        super.onDetachedFromWindow();
     }
 
     private void onErrorOnGameUpdate(Exception e) {
@@ -2,4 +2,4 @@
 
 package main
 
-var gobindsrc = []byte("// Copyright 2019 The Ebiten Authors\n//\n// Licensed under the Apache License, Version 2.0 (the \"License\");\n// you may not use this file except in compliance with the License.\n// You may obtain a copy of the License at\n//\n//     http://www.apache.org/licenses/LICENSE-2.0\n//\n// Unless required by applicable law or agreed to in writing, software\n// distributed under the License is distributed on an \"AS IS\" BASIS,\n// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.\n// See the License for the specific language governing permissions and\n// limitations under the License.\n\n//go:build ebitenmobilegobind\n// +build ebitenmobilegobind\n\n// gobind is a wrapper of the original gobind. This command adds extra files like a view controller.\npackage main\n\nimport (\n\t\"flag\"\n\t\"fmt\"\n\t\"io/ioutil\"\n\t\"log\"\n\t\"os\"\n\t\"os/exec\"\n\t\"path/filepath\"\n\t\"strings\"\n\n\t\"golang.org/x/tools/go/packages\"\n)\n\nvar (\n\tlang          = flag.String(\"lang\", \"\", \"\")\n\toutdir        = flag.String(\"outdir\", \"\", \"\")\n\tjavaPkg       = flag.String(\"javapkg\", \"\", \"\")\n\tprefix        = flag.String(\"prefix\", \"\", \"\")\n\tbootclasspath = flag.String(\"bootclasspath\", \"\", \"\")\n\tclasspath     = flag.String(\"classpath\", \"\", \"\")\n\ttags          = flag.String(\"tags\", \"\", \"\")\n)\n\nvar usage = `The Gobind tool generates Java language bindings for Go.\n\nFor usage details, see doc.go.`\n\nfunc main() {\n\tflag.Parse()\n\tif err := run(); err != nil {\n\t\tlog.Fatal(err)\n\t}\n}\n\nfunc invokeOriginalGobind(lang string) (pkgName string, err error) {\n\tcmd := exec.Command(\"gobind-original\", os.Args[1:]...)\n\tcmd.Stdout = os.Stdout\n\tcmd.Stderr = os.Stderr\n\tif err := cmd.Run(); err != nil {\n\t\treturn \"\", err\n\t}\n\n\tcfgtags := strings.Join(strings.Split(*tags, \",\"), \" \")\n\tcfg := &packages.Config{}\n\tswitch lang {\n\tcase \"java\":\n\t\tcfg.Env = append(os.Environ(), \"GOOS=android\")\n\tcase \"objc\":\n\t\tcfg.Env = append(os.Environ(), \"GOOS=darwin\")\n\t\tif cfgtags != \"\" {\n\t\t\tcfgtags += \" \"\n\t\t}\n\t\tcfgtags += \"ios\"\n\t}\n\tcfg.BuildFlags = []string{\"-tags\", cfgtags}\n\tpkgs, err := packages.Load(cfg, flag.Args()[0])\n\tif err != nil {\n\t\treturn \"\", err\n\t}\n\treturn pkgs[0].Name, nil\n}\n\nfunc forceGL() bool {\n\tfor _, tag := range strings.Split(*tags, \",\") {\n\t\tif tag == \"ebitengl\" {\n\t\t\treturn true\n\t\t}\n\t}\n\treturn false\n}\n\nfunc run() error {\n\twriteFile := func(filename string, content string) error {\n\t\tif err := ioutil.WriteFile(filepath.Join(*outdir, filename), []byte(content), 0644); err != nil {\n\t\t\treturn err\n\t\t}\n\t\treturn nil\n\t}\n\n\t// Add additional files.\n\tlangs := strings.Split(*lang, \",\")\n\tfor _, lang := range langs {\n\t\tpkgName, err := invokeOriginalGobind(lang)\n\t\tif err != nil {\n\t\t\treturn err\n\t\t}\n\t\tprefixLower := *prefix + pkgName\n\t\tprefixUpper := strings.Title(*prefix) + strings.Title(pkgName)\n\t\treplacePrefixes := func(content string) string {\n\t\t\tcontent = strings.ReplaceAll(content, \"{{.PrefixUpper}}\", prefixUpper)\n\t\t\tcontent = strings.ReplaceAll(content, \"{{.PrefixLower}}\", prefixLower)\n\t\t\tcontent = strings.ReplaceAll(content, \"{{.JavaPkg}}\", *javaPkg)\n\n\t\t\tf := \"0\"\n\t\t\tif forceGL() {\n\t\t\t\tf = \"1\"\n\t\t\t}\n\t\t\tcontent = strings.ReplaceAll(content, \"{{.ForceGL}}\", f)\n\t\t\treturn content\n\t\t}\n\n\t\tswitch lang {\n\t\tcase \"objc\":\n\t\t\t// iOS\n\t\t\tif err := writeFile(filepath.Join(\"src\", \"gobind\", prefixLower+\"ebitenviewcontroller_ios.m\"), replacePrefixes(objcM)); err != nil {\n\t\t\t\treturn err\n\t\t\t}\n\t\tcase \"java\":\n\t\t\t// Android\n\t\t\tdir := filepath.Join(strings.Split(*javaPkg, \".\")...)\n\t\t\tdir = filepath.Join(dir, prefixLower)\n\t\t\tif err := writeFile(filepath.Join(\"java\", dir, \"EbitenView.java\"), replacePrefixes(viewJava)); err != nil {\n\t\t\t\treturn err\n\t\t\t}\n\t\t\tif err := writeFile(filepath.Join(\"java\", dir, \"EbitenSurfaceView.java\"), replacePrefixes(surfaceViewJava)); err != nil {\n\t\t\t\treturn err\n\t\t\t}\n\t\tcase \"go\":\n\t\t\t// Do nothing.\n\t\tdefault:\n\t\t\tpanic(fmt.Sprintf(\"unsupported language: %s\", lang))\n\t\t}\n\t}\n\n\treturn nil\n}\n\nconst objcM = `// Code generated by ebitenmobile. DO NOT EDIT.\n\n//go:build ios\n// +build ios\n\n#import <TargetConditionals.h>\n\n#if TARGET_IPHONE_SIMULATOR || {{.ForceGL}}\n#define EBITEN_METAL 0\n#else\n#define EBITEN_METAL 1\n#endif\n\n#import <stdint.h>\n#import <UIKit/UIKit.h>\n#import <GLKit/GLkit.h>\n\n#import \"Ebitenmobileview.objc.h\"\n\n@interface {{.PrefixUpper}}EbitenViewController : UIViewController\n@end\n\n@implementation {{.PrefixUpper}}EbitenViewController {\n  UIView*  metalView_;\n  GLKView* glkView_;\n  bool     started_;\n  bool     active_;\n  bool     error_;\n}\n\n- (UIView*)metalView {\n  if (!metalView_) {\n    metalView_ = [[UIView alloc] init];\n    metalView_.multipleTouchEnabled = YES;\n  }\n  return metalView_;\n}\n\n- (GLKView*)glkView {\n  if (!glkView_) {\n    glkView_ = [[GLKView alloc] init];\n    glkView_.multipleTouchEnabled = YES;\n  }\n  return glkView_;\n}\n\n- (void)viewDidLoad {\n  [super viewDidLoad];\n\n  if (!started_) {\n    @synchronized(self) {\n      active_ = true;\n    }\n    started_ = true;\n  }\n\n#if EBITEN_METAL\n  [self.view addSubview: self.metalView];\n  EbitenmobileviewSetUIView((uintptr_t)(self.metalView));\n#else\n  self.glkView.delegate = (id<GLKViewDelegate>)(self);\n  [self.view addSubview: self.glkView];\n\n  EAGLContext *context = [[EAGLContext alloc] initWithAPI:kEAGLRenderingAPIOpenGLES2];\n  [self glkView].context = context;\n\t\n  [EAGLContext setCurrentContext:context];\n#endif\n\n  CADisplayLink *displayLink = [CADisplayLink displayLinkWithTarget:self selector:@selector(drawFrame)];\n  [displayLink addToRunLoop:[NSRunLoop currentRunLoop] forMode:NSDefaultRunLoopMode];\n}\n\n- (void)viewWillLayoutSubviews {\n  CGRect viewRect = [[self view] frame];\n#if EBITEN_METAL\n  [[self metalView] setFrame:viewRect];\n#else\n  [[self glkView] setFrame:viewRect];\n#endif\n}\n\n- (void)viewDidLayoutSubviews {\n  [super viewDidLayoutSubviews];\n  CGRect viewRect = [[self view] frame];\n\n  EbitenmobileviewLayout(viewRect.size.width, viewRect.size.height);\n}\n\n- (void)didReceiveMemoryWarning {\n  [super didReceiveMemoryWarning];\n  // Dispose of any resources that can be recreated.\n  // TODO: Notify this to Go world?\n}\n\n- (void)drawFrame{\n  @synchronized(self) {\n    if (!active_) {\n      return;\n    }\n\n#if EBITEN_METAL\n    [self updateEbiten];\n#else\n    [[self glkView] setNeedsDisplay];\n#endif\n  }\n}\n\n- (void)glkView:(GLKView*)view drawInRect:(CGRect)rect {\n  @synchronized(self) {\n    [self updateEbiten];\n  }\n}\n\n- (void)updateEbiten {\n  if (error_) {\n    return;\n  }\n  NSError* err = nil;\n  EbitenmobileviewUpdate(&err);\n  if (err != nil) {\n    [self performSelectorOnMainThread:@selector(onErrorOnGameUpdate:)\n                           withObject:err\n                        waitUntilDone:NO];\n    error_ = true;\n  }\n}\n\n- (void)onErrorOnGameUpdate:(NSError*)err {\n  NSLog(@\"Error: %@\", err);\n}\n\n- (void)updateTouches:(NSSet*)touches {\n  for (UITouch* touch in touches) {\n#if EBITEN_METAL\n    if (touch.view != [self metalView]) {\n      continue;\n    }\n#else\n    if (touch.view != [self glkView]) {\n      continue;\n    }\n#endif\n    CGPoint location = [touch locationInView:touch.view];\n    EbitenmobileviewUpdateTouchesOnIOS(touch.phase, (uintptr_t)touch, location.x, location.y);\n  }\n}\n\n- (void)touchesBegan:(NSSet*)touches withEvent:(UIEvent*)event {\n  [self updateTouches:touches];\n}\n\n- (void)touchesMoved:(NSSet*)touches withEvent:(UIEvent*)event {\n  [self updateTouches:touches];\n}\n\n- (void)touchesEnded:(NSSet*)touches withEvent:(UIEvent*)event {\n  [self updateTouches:touches];\n}\n\n- (void)touchesCancelled:(NSSet*)touches withEvent:(UIEvent*)event {\n  [self updateTouches:touches];\n}\n\n- (void)suspendGame {\n  NSAssert(started_, @\"suspendGame must not be called before viewDidLoad is called\");\n\n  @synchronized(self) {\n    active_ = false;\n    NSError* err = nil;\n    EbitenmobileviewSuspend(&err);\n    if (err != nil) {\n      [self onErrorOnGameUpdate:err];\n    }\n  }\n}\n\n- (void)resumeGame {\n  NSAssert(started_, @\"resumeGame must not be called before viewDidLoad is called\");\n\n  @synchronized(self) {\n    active_ = true;\n    NSError* err = nil;\n    EbitenmobileviewResume(&err);\n    if (err != nil) {\n      [self onErrorOnGameUpdate:err];\n    }\n  }\n}\n\n@end\n`\n\nconst viewJava = `// Code generated by ebitenmobile. DO NOT EDIT.\n\npackage {{.JavaPkg}}.{{.PrefixLower}};\n\nimport android.content.Context;\nimport android.hardware.input.InputManager;\nimport android.os.Handler;\nimport android.os.Looper;\nimport android.util.AttributeSet;\nimport android.util.DisplayMetrics;\nimport android.util.Log;\nimport android.view.Display;\nimport android.view.KeyEvent;\nimport android.view.InputDevice;\nimport android.view.MotionEvent;\nimport android.view.ViewGroup;\nimport android.view.WindowManager;\n\nimport {{.JavaPkg}}.ebitenmobileview.Ebitenmobileview;\n\npublic class EbitenView extends ViewGroup implements InputManager.InputDeviceListener {\n    private static double pxToDp(double x) {\n        return x / Ebitenmobileview.deviceScale();\n    }\n\n    public EbitenView(Context context) {\n        super(context);\n        initialize(context);\n    }\n\n    public EbitenView(Context context, AttributeSet attrs) {\n        super(context, attrs);\n        initialize(context);\n    }\n\n    private void initialize(Context context) {\n        this.ebitenSurfaceView = new EbitenSurfaceView(getContext());\n        LayoutParams params = new LayoutParams(LayoutParams.MATCH_PARENT, LayoutParams.MATCH_PARENT);\n        addView(this.ebitenSurfaceView, params);\n\n        this.inputManager = (InputManager)context.getSystemService(Context.INPUT_SERVICE);\n        this.inputManager.registerInputDeviceListener(this, null);\n        for (int id : this.inputManager.getInputDeviceIds()) {\n            this.onInputDeviceAdded(id);\n        }\n    }\n\n    @Override\n    protected void onLayout(boolean changed, int left, int top, int right, int bottom) {\n        this.ebitenSurfaceView.layout(0, 0, right - left, bottom - top);\n        double widthInDp = pxToDp(right - left);\n        double heightInDp = pxToDp(bottom - top);\n        Ebitenmobileview.layout(widthInDp, heightInDp);\n    }\n\n    @Override\n    public boolean onKeyDown(int keyCode, KeyEvent event) {\n        Ebitenmobileview.onKeyDownOnAndroid(keyCode, event.getUnicodeChar(), event.getSource(), event.getDeviceId());\n        return true;\n    }\n\n    @Override\n    public boolean onKeyUp(int keyCode, KeyEvent event) {\n        Ebitenmobileview.onKeyUpOnAndroid(keyCode, event.getSource(), event.getDeviceId());\n        return true;\n    }\n\n    @Override\n    public boolean onTouchEvent(MotionEvent e) {\n        for (int i = 0; i < e.getPointerCount(); i++) {\n            int id = e.getPointerId(i);\n            int x = (int)e.getX(i);\n            int y = (int)e.getY(i);\n            Ebitenmobileview.updateTouchesOnAndroid(e.getActionMasked(), id, (int)pxToDp(x), (int)pxToDp(y));\n        }\n        return true;\n    }\n\n    // The order must be the same as mobile/ebitenmobileview/input_android.go.\n    static int[] gamepadButtons = {\n        KeyEvent.KEYCODE_BUTTON_A,\n        KeyEvent.KEYCODE_BUTTON_B,\n        KeyEvent.KEYCODE_BUTTON_C,\n        KeyEvent.KEYCODE_BUTTON_X,\n        KeyEvent.KEYCODE_BUTTON_Y,\n        KeyEvent.KEYCODE_BUTTON_Z,\n        KeyEvent.KEYCODE_BUTTON_L1,\n        KeyEvent.KEYCODE_BUTTON_R1,\n        KeyEvent.KEYCODE_BUTTON_L2,\n        KeyEvent.KEYCODE_BUTTON_R2,\n        KeyEvent.KEYCODE_BUTTON_THUMBL,\n        KeyEvent.KEYCODE_BUTTON_THUMBR,\n        KeyEvent.KEYCODE_BUTTON_START,\n        KeyEvent.KEYCODE_BUTTON_SELECT,\n        KeyEvent.KEYCODE_BUTTON_MODE,\n        KeyEvent.KEYCODE_BUTTON_1,\n        KeyEvent.KEYCODE_BUTTON_2,\n        KeyEvent.KEYCODE_BUTTON_3,\n        KeyEvent.KEYCODE_BUTTON_4,\n        KeyEvent.KEYCODE_BUTTON_5,\n        KeyEvent.KEYCODE_BUTTON_6,\n        KeyEvent.KEYCODE_BUTTON_7,\n        KeyEvent.KEYCODE_BUTTON_8,\n        KeyEvent.KEYCODE_BUTTON_9,\n        KeyEvent.KEYCODE_BUTTON_10,\n        KeyEvent.KEYCODE_BUTTON_11,\n        KeyEvent.KEYCODE_BUTTON_12,\n        KeyEvent.KEYCODE_BUTTON_13,\n        KeyEvent.KEYCODE_BUTTON_14,\n        KeyEvent.KEYCODE_BUTTON_15,\n        KeyEvent.KEYCODE_BUTTON_16,\n    };\n\n    // The order must be the same as mobile/ebitenmobileview/input_android.go.\n    static int[] axes = {\n        MotionEvent.AXIS_X,\n        MotionEvent.AXIS_Y,\n        MotionEvent.AXIS_Z,\n        MotionEvent.AXIS_RX,\n        MotionEvent.AXIS_RY,\n        MotionEvent.AXIS_RZ,\n        MotionEvent.AXIS_HAT_X,\n        MotionEvent.AXIS_HAT_Y,\n        MotionEvent.AXIS_LTRIGGER,\n        MotionEvent.AXIS_RTRIGGER,\n        MotionEvent.AXIS_THROTTLE,\n        MotionEvent.AXIS_RUDDER,\n        MotionEvent.AXIS_WHEEL,\n        MotionEvent.AXIS_GAS,\n        MotionEvent.AXIS_BRAKE,\n        MotionEvent.AXIS_GENERIC_1,\n        MotionEvent.AXIS_GENERIC_2,\n        MotionEvent.AXIS_GENERIC_3,\n        MotionEvent.AXIS_GENERIC_4,\n        MotionEvent.AXIS_GENERIC_5,\n        MotionEvent.AXIS_GENERIC_6,\n        MotionEvent.AXIS_GENERIC_7,\n        MotionEvent.AXIS_GENERIC_8,\n        MotionEvent.AXIS_GENERIC_9,\n        MotionEvent.AXIS_GENERIC_10,\n        MotionEvent.AXIS_GENERIC_11,\n        MotionEvent.AXIS_GENERIC_12,\n        MotionEvent.AXIS_GENERIC_13,\n        MotionEvent.AXIS_GENERIC_14,\n        MotionEvent.AXIS_GENERIC_15,\n        MotionEvent.AXIS_GENERIC_16,\n    };\n\n    @Override\n    public boolean onGenericMotionEvent(MotionEvent event) {\n        if ((event.getSource() & InputDevice.SOURCE_JOYSTICK) != InputDevice.SOURCE_JOYSTICK) {\n            return super.onGenericMotionEvent(event);\n        }\n        if (event.getAction() != MotionEvent.ACTION_MOVE) {\n            return super.onGenericMotionEvent(event);\n        }\n        InputDevice inputDevice = this.inputManager.getInputDevice(event.getDeviceId());\n        for (int axis : axes) {\n            InputDevice.MotionRange motionRange = inputDevice.getMotionRange(axis, event.getSource());\n            float value = 0.0f;\n            if (motionRange != null) {\n                value = event.getAxisValue(axis);\n                if (Math.abs(value) <= motionRange.getFlat()) {\n                    value = 0.0f;\n                }\n            }\n            Ebitenmobileview.onGamepadAxesChanged(event.getDeviceId(), axis, value);\n        }\n        return true;\n    }\n\n    @Override\n    public void onInputDeviceAdded(int deviceId) {\n        InputDevice inputDevice = this.inputManager.getInputDevice(deviceId);\n        // The InputDevice can be null on some deivces (#1342).\n        if (inputDevice == null) {\n            return;\n        }\n\n        // A fingerprint reader is unexpectedly recognized as a joystick. Skip this (#1542).\n        if (inputDevice.getName().equals(\"uinput-fpc\")) {\n            return;\n        }\n\n        int sources = inputDevice.getSources();\n        if ((sources & InputDevice.SOURCE_GAMEPAD) != InputDevice.SOURCE_GAMEPAD &&\n            (sources & InputDevice.SOURCE_JOYSTICK) != InputDevice.SOURCE_JOYSTICK) {\n            return;\n        }\n\n        boolean[] keyExistences = inputDevice.hasKeys(gamepadButtons);\n        int buttonNum = gamepadButtons.length - 1;\n        for (int i = gamepadButtons.length - 1; i >= 0; i--) {\n            if (keyExistences[i]) {\n                break;\n            }\n            buttonNum--;\n        }\n\n        int axisNum = axes.length - 1;\n        for (int i = axes.length - 1; i >= 0; i--) {\n            if (inputDevice.getMotionRange(axes[i], InputDevice.SOURCE_JOYSTICK) != null) {\n                break;\n            }\n            axisNum--;\n        }\n\n        String descriptor = inputDevice.getDescriptor();\n        int vendorId = inputDevice.getVendorId();\n        int productId = inputDevice.getProductId();\n\n        // These values are required to calculate SDL's GUID.\n        int buttonMask = getButtonMask(inputDevice);\n        int axisMask = getAxisMask(inputDevice);\n\n        Ebitenmobileview.onGamepadAdded(deviceId, inputDevice.getName(), buttonNum, axisNum, descriptor, vendorId, productId, buttonMask, axisMask);\n    }\n\n    // The implementation is copied from SDL:\n    // https://hg.libsdl.org/SDL/file/bc90ce38f1e2/android-project/app/src/main/java/org/libsdl/app/SDLControllerManager.java#l308\n    private int getButtonMask(InputDevice joystickDevice) {\n        int button_mask = 0;\n        int[] keys = new int[] {\n            KeyEvent.KEYCODE_BUTTON_A,\n            KeyEvent.KEYCODE_BUTTON_B,\n            KeyEvent.KEYCODE_BUTTON_X,\n            KeyEvent.KEYCODE_BUTTON_Y,\n            KeyEvent.KEYCODE_BACK,\n            KeyEvent.KEYCODE_BUTTON_MODE,\n            KeyEvent.KEYCODE_BUTTON_START,\n            KeyEvent.KEYCODE_BUTTON_THUMBL,\n            KeyEvent.KEYCODE_BUTTON_THUMBR,\n            KeyEvent.KEYCODE_BUTTON_L1,\n            KeyEvent.KEYCODE_BUTTON_R1,\n            KeyEvent.KEYCODE_DPAD_UP,\n            KeyEvent.KEYCODE_DPAD_DOWN,\n            KeyEvent.KEYCODE_DPAD_LEFT,\n            KeyEvent.KEYCODE_DPAD_RIGHT,\n            KeyEvent.KEYCODE_BUTTON_SELECT,\n            KeyEvent.KEYCODE_DPAD_CENTER,\n\n            // These don't map into any SDL controller buttons directly\n            KeyEvent.KEYCODE_BUTTON_L2,\n            KeyEvent.KEYCODE_BUTTON_R2,\n            KeyEvent.KEYCODE_BUTTON_C,\n            KeyEvent.KEYCODE_BUTTON_Z,\n            KeyEvent.KEYCODE_BUTTON_1,\n            KeyEvent.KEYCODE_BUTTON_2,\n            KeyEvent.KEYCODE_BUTTON_3,\n            KeyEvent.KEYCODE_BUTTON_4,\n            KeyEvent.KEYCODE_BUTTON_5,\n            KeyEvent.KEYCODE_BUTTON_6,\n            KeyEvent.KEYCODE_BUTTON_7,\n            KeyEvent.KEYCODE_BUTTON_8,\n            KeyEvent.KEYCODE_BUTTON_9,\n            KeyEvent.KEYCODE_BUTTON_10,\n            KeyEvent.KEYCODE_BUTTON_11,\n            KeyEvent.KEYCODE_BUTTON_12,\n            KeyEvent.KEYCODE_BUTTON_13,\n            KeyEvent.KEYCODE_BUTTON_14,\n            KeyEvent.KEYCODE_BUTTON_15,\n            KeyEvent.KEYCODE_BUTTON_16,\n        };\n        int[] masks = new int[] {\n            (1 << 0),   // A -> A\n            (1 << 1),   // B -> B\n            (1 << 2),   // X -> X\n            (1 << 3),   // Y -> Y\n            (1 << 4),   // BACK -> BACK\n            (1 << 5),   // MODE -> GUIDE\n            (1 << 6),   // START -> START\n            (1 << 7),   // THUMBL -> LEFTSTICK\n            (1 << 8),   // THUMBR -> RIGHTSTICK\n            (1 << 9),   // L1 -> LEFTSHOULDER\n            (1 << 10),  // R1 -> RIGHTSHOULDER\n            (1 << 11),  // DPAD_UP -> DPAD_UP\n            (1 << 12),  // DPAD_DOWN -> DPAD_DOWN\n            (1 << 13),  // DPAD_LEFT -> DPAD_LEFT\n            (1 << 14),  // DPAD_RIGHT -> DPAD_RIGHT\n            (1 << 4),   // SELECT -> BACK\n            (1 << 0),   // DPAD_CENTER -> A\n            (1 << 15),  // L2 -> ??\n            (1 << 16),  // R2 -> ??\n            (1 << 17),  // C -> ??\n            (1 << 18),  // Z -> ??\n            (1 << 20),  // 1 -> ??\n            (1 << 21),  // 2 -> ??\n            (1 << 22),  // 3 -> ??\n            (1 << 23),  // 4 -> ??\n            (1 << 24),  // 5 -> ??\n            (1 << 25),  // 6 -> ??\n            (1 << 26),  // 7 -> ??\n            (1 << 27),  // 8 -> ??\n            (1 << 28),  // 9 -> ??\n            (1 << 29),  // 10 -> ??\n            (1 << 30),  // 11 -> ??\n            (1 << 31),  // 12 -> ??\n            // We're out of room...\n            0xFFFFFFFF,  // 13 -> ??\n            0xFFFFFFFF,  // 14 -> ??\n            0xFFFFFFFF,  // 15 -> ??\n            0xFFFFFFFF,  // 16 -> ??\n        };\n        boolean[] has_keys = joystickDevice.hasKeys(keys);\n        for (int i = 0; i < keys.length; ++i) {\n            if (has_keys[i]) {\n                button_mask |= masks[i];\n            }\n        }\n        return button_mask;\n    }\n\n    private int getAxisMask(InputDevice joystickDevice) {\n        final int SDL_CONTROLLER_AXIS_LEFTX = 0;\n        final int SDL_CONTROLLER_AXIS_LEFTY = 1;\n        final int SDL_CONTROLLER_AXIS_RIGHTX = 2;\n        final int SDL_CONTROLLER_AXIS_RIGHTY = 3;\n        final int SDL_CONTROLLER_AXIS_TRIGGERLEFT = 4;\n        final int SDL_CONTROLLER_AXIS_TRIGGERRIGHT = 5;\n\n        int naxes = 0;\n        for (InputDevice.MotionRange range : joystickDevice.getMotionRanges()) {\n            if ((range.getSource() & InputDevice.SOURCE_CLASS_JOYSTICK) != 0) {\n                if (range.getAxis() != MotionEvent.AXIS_HAT_X && range.getAxis() != MotionEvent.AXIS_HAT_Y) {\n                    naxes++;\n                }\n            }\n        }\n        // The variable is_accelerometer seems always false, then skip the checking:\n        // https://hg.libsdl.org/SDL/file/bc90ce38f1e2/android-project/app/src/main/java/org/libsdl/app/SDLControllerManager.java#l207\n        int axisMask = 0;\n        if (naxes >= 2) {\n            axisMask |= ((1 << SDL_CONTROLLER_AXIS_LEFTX) | (1 << SDL_CONTROLLER_AXIS_LEFTY));\n        }\n        if (naxes >= 4) {\n            axisMask |= ((1 << SDL_CONTROLLER_AXIS_RIGHTX) | (1 << SDL_CONTROLLER_AXIS_RIGHTY));\n        }\n        if (naxes >= 6) {\n            axisMask |= ((1 << SDL_CONTROLLER_AXIS_TRIGGERLEFT) | (1 << SDL_CONTROLLER_AXIS_TRIGGERRIGHT));\n        }\n        return axisMask;\n    }\n\n    @Override\n    public void onInputDeviceChanged(int deviceId) {\n        // Do nothing.\n    }\n\n    @Override\n    public void onInputDeviceRemoved(int deviceId) {\n        // Do not call inputManager.getInputDevice(), which returns null (#1185).\n        Ebitenmobileview.onInputDeviceRemoved(deviceId);\n    }\n\n    // suspendGame suspends the game.\n    // It is recommended to call this when the application is being suspended e.g.,\n    // Activity's onPause is called.\n    public void suspendGame() {\n        this.inputManager.unregisterInputDeviceListener(this);\n        this.ebitenSurfaceView.onPause();\n        try {\n            Ebitenmobileview.suspend();\n        } catch (final Exception e) {\n            onErrorOnGameUpdate(e);\n        }\n    }\n\n    // resumeGame resumes the game.\n    // It is recommended to call this when the application is being resumed e.g.,\n    // Activity's onResume is called.\n    public void resumeGame() {\n        this.inputManager.registerInputDeviceListener(this, null);\n        this.ebitenSurfaceView.onResume();\n        try {\n            Ebitenmobileview.resume();\n        } catch (final Exception e) {\n            onErrorOnGameUpdate(e);\n        }\n    }\n\n    // onErrorOnGameUpdate is called on the main thread when an error happens when updating a game.\n    // You can define your own error handler, e.g., using Crashlytics, by overriding this method.\n    protected void onErrorOnGameUpdate(Exception e) {\n        Log.e(\"Go\", e.toString());\n    }\n\n    private EbitenSurfaceView ebitenSurfaceView;\n    private InputManager inputManager;\n}\n`\n\nconst surfaceViewJava = `// Code generated by ebitenmobile. DO NOT EDIT.\n\npackage {{.JavaPkg}}.{{.PrefixLower}};\n\nimport android.content.Context;\nimport android.opengl.GLSurfaceView;\nimport android.os.Handler;\nimport android.os.Looper;\nimport android.util.AttributeSet;\nimport android.util.Log;\n\nimport javax.microedition.khronos.egl.EGLConfig;\nimport javax.microedition.khronos.opengles.GL10;\n\nimport {{.JavaPkg}}.ebitenmobileview.Ebitenmobileview;\nimport {{.JavaPkg}}.{{.PrefixLower}}.EbitenView;\n\nclass EbitenSurfaceView extends GLSurfaceView {\n\n    private class EbitenRenderer implements GLSurfaceView.Renderer {\n\n        private boolean errored_ = false;\n\n        @Override\n        public void onDrawFrame(GL10 gl) {\n            if (errored_) {\n                return;\n            }\n            try {\n                Ebitenmobileview.update();\n            } catch (final Exception e) {\n                new Handler(Looper.getMainLooper()).post(new Runnable() {\n                    @Override\n                    public void run() {\n                        onErrorOnGameUpdate(e);\n                    }\n                });\n                errored_ = true;\n            }\n        }\n\n        @Override\n        public void onSurfaceCreated(GL10 gl, EGLConfig config) {\n            Ebitenmobileview.onContextLost();\n        }\n\n        @Override\n        public void onSurfaceChanged(GL10 gl, int width, int height) {\n        }\n    }\n\n    public EbitenSurfaceView(Context context) {\n        super(context);\n        initialize();\n    }\n\n    public EbitenSurfaceView(Context context, AttributeSet attrs) {\n        super(context, attrs);\n        initialize();\n    }\n\n    private void initialize() {\n        setEGLContextClientVersion(2);\n        setEGLConfigChooser(8, 8, 8, 8, 0, 0);\n        setRenderer(new EbitenRenderer());\n    }\n\n    private void onErrorOnGameUpdate(Exception e) {\n        ((EbitenView)getParent()).onErrorOnGameUpdate(e);\n    }\n}\n`\n")
+var gobindsrc = []byte("// Copyright 2019 The Ebiten Authors\n//\n// Licensed under the Apache License, Version 2.0 (the \"License\");\n// you may not use this file except in compliance with the License.\n// You may obtain a copy of the License at\n//\n//     http://www.apache.org/licenses/LICENSE-2.0\n//\n// Unless required by applicable law or agreed to in writing, software\n// distributed under the License is distributed on an \"AS IS\" BASIS,\n// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.\n// See the License for the specific language governing permissions and\n// limitations under the License.\n\n//go:build ebitenmobilegobind\n// +build ebitenmobilegobind\n\n// gobind is a wrapper of the original gobind. This command adds extra files like a view controller.\npackage main\n\nimport (\n\t\"flag\"\n\t\"fmt\"\n\t\"io/ioutil\"\n\t\"log\"\n\t\"os\"\n\t\"os/exec\"\n\t\"path/filepath\"\n\t\"strings\"\n\n\t\"golang.org/x/tools/go/packages\"\n)\n\nvar (\n\tlang          = flag.String(\"lang\", \"\", \"\")\n\toutdir        = flag.String(\"outdir\", \"\", \"\")\n\tjavaPkg       = flag.String(\"javapkg\", \"\", \"\")\n\tprefix        = flag.String(\"prefix\", \"\", \"\")\n\tbootclasspath = flag.String(\"bootclasspath\", \"\", \"\")\n\tclasspath     = flag.String(\"classpath\", \"\", \"\")\n\ttags          = flag.String(\"tags\", \"\", \"\")\n)\n\nvar usage = `The Gobind tool generates Java language bindings for Go.\n\nFor usage details, see doc.go.`\n\nfunc main() {\n\tflag.Parse()\n\tif err := run(); err != nil {\n\t\tlog.Fatal(err)\n\t}\n}\n\nfunc invokeOriginalGobind(lang string) (pkgName string, err error) {\n\tcmd := exec.Command(\"gobind-original\", os.Args[1:]...)\n\tcmd.Stdout = os.Stdout\n\tcmd.Stderr = os.Stderr\n\tif err := cmd.Run(); err != nil {\n\t\treturn \"\", err\n\t}\n\n\tcfgtags := strings.Join(strings.Split(*tags, \",\"), \" \")\n\tcfg := &packages.Config{}\n\tswitch lang {\n\tcase \"java\":\n\t\tcfg.Env = append(os.Environ(), \"GOOS=android\")\n\tcase \"objc\":\n\t\tcfg.Env = append(os.Environ(), \"GOOS=darwin\")\n\t\tif cfgtags != \"\" {\n\t\t\tcfgtags += \" \"\n\t\t}\n\t\tcfgtags += \"ios\"\n\t}\n\tcfg.BuildFlags = []string{\"-tags\", cfgtags}\n\tpkgs, err := packages.Load(cfg, flag.Args()[0])\n\tif err != nil {\n\t\treturn \"\", err\n\t}\n\treturn pkgs[0].Name, nil\n}\n\nfunc forceGL() bool {\n\tfor _, tag := range strings.Split(*tags, \",\") {\n\t\tif tag == \"ebitengl\" {\n\t\t\treturn true\n\t\t}\n\t}\n\treturn false\n}\n\nfunc run() error {\n\twriteFile := func(filename string, content string) error {\n\t\tif err := ioutil.WriteFile(filepath.Join(*outdir, filename), []byte(content), 0644); err != nil {\n\t\t\treturn err\n\t\t}\n\t\treturn nil\n\t}\n\n\t// Add additional files.\n\tlangs := strings.Split(*lang, \",\")\n\tfor _, lang := range langs {\n\t\tpkgName, err := invokeOriginalGobind(lang)\n\t\tif err != nil {\n\t\t\treturn err\n\t\t}\n\t\tprefixLower := *prefix + pkgName\n\t\tprefixUpper := strings.Title(*prefix) + strings.Title(pkgName)\n\t\treplacePrefixes := func(content string) string {\n\t\t\tcontent = strings.ReplaceAll(content, \"{{.PrefixUpper}}\", prefixUpper)\n\t\t\tcontent = strings.ReplaceAll(content, \"{{.PrefixLower}}\", prefixLower)\n\t\t\tcontent = strings.ReplaceAll(content, \"{{.JavaPkg}}\", *javaPkg)\n\n\t\t\tf := \"0\"\n\t\t\tif forceGL() {\n\t\t\t\tf = \"1\"\n\t\t\t}\n\t\t\tcontent = strings.ReplaceAll(content, \"{{.ForceGL}}\", f)\n\t\t\treturn content\n\t\t}\n\n\t\tswitch lang {\n\t\tcase \"objc\":\n\t\t\t// iOS\n\t\t\tif err := writeFile(filepath.Join(\"src\", \"gobind\", prefixLower+\"ebitenviewcontroller_ios.m\"), replacePrefixes(objcM)); err != nil {\n\t\t\t\treturn err\n\t\t\t}\n\t\tcase \"java\":\n\t\t\t// Android\n\t\t\tdir := filepath.Join(strings.Split(*javaPkg, \".\")...)\n\t\t\tdir = filepath.Join(dir, prefixLower)\n\t\t\tif err := writeFile(filepath.Join(\"java\", dir, \"EbitenView.java\"), replacePrefixes(viewJava)); err != nil {\n\t\t\t\treturn err\n\t\t\t}\n\t\t\tif err := writeFile(filepath.Join(\"java\", dir, \"EbitenSurfaceView.java\"), replacePrefixes(surfaceViewJava)); err != nil {\n\t\t\t\treturn err\n\t\t\t}\n\t\t\tif err := writeFile(filepath.Join(\"java\", dir, \"EbitenFileProvider.java\"), replacePrefixes(fileProviderJava)); err != nil {\n\t\t\t\treturn err\n\t\t\t}\n\t\tcase \"go\":\n\t\t\t// Do nothing.\n\t\tdefault:\n\t\t\tpanic(fmt.Sprintf(\"unsupported language: %s\", lang))\n\t\t}\n\t}\n\n\treturn nil\n}\n\nconst objcM = `// Code generated by ebitenmobile. DO NOT EDIT.\n\n//go:build ios\n// +build ios\n\n#import <TargetConditionals.h>\n\n#if TARGET_IPHONE_SIMULATOR || {{.ForceGL}}\n#define EBITEN_METAL 0\n#else\n#define EBITEN_METAL 1\n#endif\n\n#import <stdint.h>\n#import <UIKit/UIKit.h>\n#import <GLKit/GLkit.h>\n\n#import \"Ebitenmobileview.objc.h\"\n\n#if !EBITEN_METAL\n// theGLContext is the GL context that the game's GL objects belong to.\nstatic EAGLContext* theGLContext;\n#endif\n\n@interface {{.PrefixUpper}}EbitenViewController : UIViewController<EbitenmobileviewOrientationController, EbitenmobileviewSystemUIController, EbitenmobileviewFrameRateController, EbitenmobileviewSoftKeyboardController, EbitenmobileviewShareController, UITextFieldDelegate>\n@end\n\n@implementation {{.PrefixUpper}}EbitenViewController {\n  UIView*  metalView_;\n  GLKView* glkView_;\n  bool     started_;\n  bool     active_;\n  bool     error_;\n\n  CADisplayLink* displayLink_;\n  double         lastDisplayRefreshRate_;\n\n  // allowedOrientations_, immersive_, preferredFrameRate_ and softKeyboardTextField_ must be accessed only on\n  // the main thread.\n  UIInterfaceOrientationMask allowedOrientations_;\n  bool                       immersive_;\n  long                       preferredFrameRate_;\n  UITextField*               softKeyboardTextField_;\n}\n\n- (UIView*)metalView {\n  if (!metalView_) {\n    metalView_ = [[UIView alloc] init];\n    metalView_.multipleTouchEnabled = YES;\n  }\n  return metalView_;\n}\n\n- (GLKView*)glkView {\n  if (!glkView_) {\n    glkView_ = [[GLKView alloc] init];\n    glkView_.multipleTouchEnabled = YES;\n  }\n  return glkView_;\n}\n\n- (void)viewDidLoad {\n  [super viewDidLoad];\n\n  if (!started_) {\n    @synchronized(self) {\n      active_ = true;\n    }\n    started_ = true;\n  }\n\n#if EBITEN_METAL\n  [self.view addSubview: self.metalView];\n  EbitenmobileviewSetUIView((uintptr_t)(self.metalView));\n#else\n  self.glkView.delegate = (id<GLKViewDelegate>)(self);\n  [self.view addSubview: self.glkView];\n\n  EAGLContext *context = [[EAGLContext alloc] initWithAPI:kEAGLRenderingAPIOpenGLES2];\n  [self glkView].context = context;\n\t\n  [EAGLContext setCurrentContext:context];\n#endif\n\n  [[NSNotificationCenter defaultCenter] addObserver:self\n                                           selector:@selector(updatePowerState)\n                                               name:NSProcessInfoPowerStateDidChangeNotification\n                                             object:nil];\n  if (@available(iOS 11.0, *)) {\n    [[NSNotificationCenter defaultCenter] addObserver:self\n                                             selector:@selector(updatePowerState)\n                                                 name:NSProcessInfoThermalStateDidChangeNotification\n                                               object:nil];\n  }\n  [self updatePowerState];\n\n  for (NSNotificationName name in @[UIScreenDidConnectNotification, UIScreenDidDisconnectNotification, UIScreenModeDidChangeNotification]) {\n    [[NSNotificationCenter defaultCenter] addObserver:self\n                                             selector:@selector(updateExternalDisplay)\n                                                 name:name\n                                               object:nil];\n  }\n  [self updateExternalDisplay];\n}\n\n- (void)share:(NSData*)data name:(NSString*)name mimeType:(NSString*)mimeType {\n  // This can be called from any thread.\n  dispatch_async(dispatch_get_main_queue(), ^{\n    // Write the data to a file so that the file name is kept.\n    NSURL* url = [[NSURL fileURLWithPath:NSTemporaryDirectory()] URLByAppendingPathComponent:name];\n    if (![data writeToURL:url atomically:YES]) {\n      NSLog(@\"Ebiten: failed to write a file to share: %@\", url);\n      return;\n    }\n    UIActivityViewController* controller = [[UIActivityViewController alloc] initWithActivityItems:@[url]\n                                                                             applicationActivities:nil];\n    // On iPad, the share sheet is shown as a popover and requires its source.\n    CGRect bounds = self.view.bounds;\n    controller.popoverPresentationController.sourceView = self.view;\n    controller.popoverPresentationController.sourceRect = CGRectMake(CGRectGetMidX(bounds), CGRectGetMidY(bounds), 0, 0);\n    [self presentViewController:controller animated:YES completion:nil];\n  });\n}\n\n- (void)updateExternalDisplay {\n  long width = 0;\n  long height = 0;\n  for (UIScreen* screen in [UIScreen screens]) {\n    if (screen == [UIScreen mainScreen]) {\n      continue;\n    }\n    // The size of the mode is in pixels.\n    CGSize size = screen.currentMode.size;\n    width = (long)size.width;\n    height = (long)size.height;\n    break;\n  }\n  EbitenmobileviewSetExternalDisplaySize(width, height);\n}\n\n- (void)viewWillAppear:(BOOL)animated {\n  [super viewWillAppear:animated];\n\n  // There can be multiple view controllers for the game, e.g. when a view controller is recreated.\n  // The most recently shown view controller renders the game.\n#if EBITEN_METAL\n  EbitenmobileviewSetUIView((uintptr_t)(self.metalView));\n#else\n  EAGLContext* context = self.glkView.context;\n  [EAGLContext setCurrentContext:context];\n  if (theGLContext && theGLContext != context) {\n    // The game's GL objects are not available in this context. Restore them.\n    EbitenmobileviewOnContextLost();\n  }\n  theGLContext = context;\n#endif\n\n  EbitenmobileviewSetOrientationController(self);\n  EbitenmobileviewSetSystemUIController(self);\n  EbitenmobileviewSetFrameRateController(self);\n  EbitenmobileviewSetSoftKeyboardController(self);\n  EbitenmobileviewSetShareController(self);\n\n  if (!displayLink_) {\n    displayLink_ = [CADisplayLink displayLinkWithTarget:self selector:@selector(drawFrame)];\n    [displayLink_ addToRunLoop:[NSRunLoop currentRunLoop] forMode:NSDefaultRunLoopMode];\n    [self applyPreferredFrameRate];\n  }\n\n  // Notify the size of this view again.\n  [self.view setNeedsLayout];\n}\n\n- (void)viewDidDisappear:(BOOL)animated {\n  [super viewDidDisappear:animated];\n\n  // CADisplayLink retains its target. Invalidate it so that this view controller can be released.\n  [displayLink_ invalidate];\n  displayLink_ = nil;\n}\n\n- (void)showSoftKeyboard:(NSString*)text {\n  // This can be called from any thread.\n  dispatch_async(dispatch_get_main_queue(), ^{\n    if (!softKeyboardTextField_) {\n      // The text field must not be hidden to become the first responder. Make it transparent instead.\n      softKeyboardTextField_ = [[UITextField alloc] initWithFrame:CGRectMake(0, 0, 1, 1)];\n      softKeyboardTextField_.alpha = 0;\n      softKeyboardTextField_.autocorrectionType = UITextAutocorrectionTypeNo;\n      softKeyboardTextField_.returnKeyType = UIReturnKeyDone;\n      softKeyboardTextField_.delegate = self;\n      [softKeyboardTextField_ addTarget:self\n                                 action:@selector(softKeyboardTextDidChange)\n                       forControlEvents:UIControlEventEditingChanged];\n      [self.view addSubview:softKeyboardTextField_];\n    }\n    softKeyboardTextField_.text = text;\n    [softKeyboardTextField_ becomeFirstResponder];\n    [self softKeyboardTextDidChange];\n  });\n}\n\n- (void)hideSoftKeyboard {\n  // This can be called from any thread.\n  dispatch_async(dispatch_get_main_queue(), ^{\n    [softKeyboardTextField_ resignFirstResponder];\n  });\n}\n\n- (void)softKeyboardTextDidChange {\n  UITextField* textField = softKeyboardTextField_;\n  NSString* text = textField.text;\n  long start = text.length;\n  long end = text.length;\n  UITextRange* range = textField.markedTextRange;\n  if (range) {\n    start = [textField offsetFromPosition:textField.beginningOfDocument toPosition:range.start];\n    end = [textField offsetFromPosition:textField.beginningOfDocument toPosition:range.end];\n  }\n  EbitenmobileviewSetSoftKeyboardText(text, start, end);\n}\n\n- (void)textFieldDidBeginEditing:(UITextField*)textField {\n  EbitenmobileviewSetSoftKeyboardVisible(true);\n}\n\n- (void)textFieldDidEndEditing:(UITextField*)textField {\n  EbitenmobileviewSetSoftKeyboardVisible(false);\n}\n\n- (BOOL)textFieldShouldReturn:(UITextField*)textField {\n  [textField resignFirstResponder];\n  return NO;\n}\n\n- (void)updatePowerState {\n  // This can be called from any thread.\n  NSProcessInfo* info = [NSProcessInfo processInfo];\n  if (@available(iOS 11.0, *)) {\n    // The values in Go are NSProcessInfoThermalState + 1, as 0 means unknown.\n    EbitenmobileviewSetThermalState((long)info.thermalState + 1);\n  }\n  EbitenmobileviewSetLowPowerMode(info.lowPowerModeEnabled);\n}\n\n- (void)setPreferredFrameRate:(long)fps {\n  // This can be called from any thread.\n  dispatch_async(dispatch_get_main_queue(), ^{\n    preferredFrameRate_ = fps;\n    [self applyPreferredFrameRate];\n  });\n}\n\n- (void)applyPreferredFrameRate {\n  if (!displayLink_) {\n    return;\n  }\n  float fps = (float)preferredFrameRate_;\n#if __IPHONE_OS_VERSION_MAX_ALLOWED >= 150000\n  if (@available(iOS 15.0, *)) {\n    if (fps == 0) {\n      displayLink_.preferredFrameRateRange = CAFrameRateRangeDefault;\n    } else {\n      displayLink_.preferredFrameRateRange = CAFrameRateRangeMake(fps, fps, fps);\n    }\n    return;\n  }\n#endif\n  displayLink_.preferredFramesPerSecond = (NSInteger)fps;\n}\n\n- (void)updateDisplayRefreshRate {\n  CFTimeInterval interval = displayLink_.targetTimestamp - displayLink_.timestamp;\n  if (interval <= 0) {\n    return;\n  }\n  // Round the value as the interval fluctuates slightly.\n  double rate = round(1.0 / interval);\n  if (rate == lastDisplayRefreshRate_) {\n    return;\n  }\n  lastDisplayRefreshRate_ = rate;\n  EbitenmobileviewSetDisplayRefreshRate(rate);\n}\n\n- (void)viewWillLayoutSubviews {\n  CGRect viewRect = [[self view] frame];\n#if EBITEN_METAL\n  [[self metalView] setFrame:viewRect];\n#else\n  [[self glkView] setFrame:viewRect];\n#endif\n}\n\n- (void)viewDidLayoutSubviews {\n  [super viewDidLayoutSubviews];\n  CGRect viewRect = [[self view] frame];\n\n  // The orientation and the insets must be notified before the size so that the game doesn't observe an\n  // intermediate state.\n  EbitenmobileviewSetOrientation([self currentOrientation]);\n  if (@available(iOS 11.0, *)) {\n    UIEdgeInsets insets = self.view.safeAreaInsets;\n    EbitenmobileviewSetSafeAreaInsets(insets.left, insets.top, insets.right, insets.bottom);\n  }\n  EbitenmobileviewLayout(viewRect.size.width, viewRect.size.height);\n}\n\n- (void)viewSafeAreaInsetsDidChange {\n  [super viewSafeAreaInsetsDidChange];\n  [self.view setNeedsLayout];\n}\n\n- (void)setImmersiveMode:(BOOL)immersive {\n  // This can be called from any thread.\n  dispatch_async(dispatch_get_main_queue(), ^{\n    immersive_ = immersive;\n    [self setNeedsStatusBarAppearanceUpdate];\n    if (@available(iOS 11.0, *)) {\n      [self setNeedsUpdateOfHomeIndicatorAutoHidden];\n    }\n  });\n}\n\n- (void)setEdgeToEdge:(BOOL)edgeToEdge {\n  // The view is always laid out edge-to-edge on iOS.\n}\n\n- (BOOL)prefersStatusBarHidden {\n  return immersive_;\n}\n\n- (BOOL)prefersHomeIndicatorAutoHidden {\n  return immersive_;\n}\n\n// The values must be synced with mobile/orientation.go.\nstatic const long kOrientationPortrait           = 1 << 0;\nstatic const long kOrientationPortraitUpsideDown = 1 << 1;\nstatic const long kOrientationLandscapeLeft      = 1 << 2;\nstatic const long kOrientationLandscapeRight     = 1 << 3;\n\n- (long)currentOrientation {\n  UIInterfaceOrientation orientation;\n  if (@available(iOS 13.0, *)) {\n    orientation = self.view.window.windowScene.interfaceOrientation;\n  } else {\n    orientation = [[UIApplication sharedApplication] statusBarOrientation];\n  }\n  switch (orientation) {\n  case UIInterfaceOrientationPortrait:\n    return kOrientationPortrait;\n  case UIInterfaceOrientationPortraitUpsideDown:\n    return kOrientationPortraitUpsideDown;\n  case UIInterfaceOrientationLandscapeLeft:\n    return kOrientationLandscapeLeft;\n  case UIInterfaceOrientationLandscapeRight:\n    return kOrientationLandscapeRight;\n  default:\n    return 0;\n  }\n}\n\n- (void)setAllowedOrientations:(long)orientations {\n  UIInterfaceOrientationMask mask = 0;\n  if (orientations & kOrientationPortrait) {\n    mask |= UIInterfaceOrientationMaskPortrait;\n  }\n  if (orientations & kOrientationPortraitUpsideDown) {\n    mask |= UIInterfaceOrientationMaskPortraitUpsideDown;\n  }\n  if (orientations & kOrientationLandscapeLeft) {\n    mask |= UIInterfaceOrientationMaskLandscapeLeft;\n  }\n  if (orientations & kOrientationLandscapeRight) {\n    mask |= UIInterfaceOrientationMaskLandscapeRight;\n  }\n  // This can be called from any thread.\n  dispatch_async(dispatch_get_main_queue(), ^{\n    allowedOrientations_ = mask;\n    [UIViewController attemptRotationToDeviceOrientation];\n  });\n}\n\n- (UIInterfaceOrientationMask)supportedInterfaceOrientations {\n  if (allowedOrientations_ == 0) {\n    return [super supportedInterfaceOrientations];\n  }\n  return allowedOrientations_;\n}\n\n- (void)didReceiveMemoryWarning {\n  [super didReceiveMemoryWarning];\n  EbitenmobileviewOnLowMemory();\n}\n\n- (void)drawFrame{\n  [self updateDisplayRefreshRate];\n\n  @synchronized(self) {\n    if (!active_) {\n      return;\n    }\n\n#if EBITEN_METAL\n    [self updateEbiten];\n#else\n    [[self glkView] setNeedsDisplay];\n#endif\n  }\n}\n\n- (void)glkView:(GLKView*)view drawInRect:(CGRect)rect {\n  @synchronized(self) {\n    [self updateEbiten];\n  }\n}\n\n- (void)updateEbiten {\n  if (error_) {\n    return;\n  }\n  NSError* err = nil;\n  EbitenmobileviewUpdate(&err);\n  if (err != nil) {\n    [self performSelectorOnMainThread:@selector(onErrorOnGameUpdate:)\n                           withObject:err\n                        waitUntilDone:NO];\n    error_ = true;\n  }\n}\n\n- (void)onErrorOnGameUpdate:(NSError*)err {\n  NSLog(@\"Error: %@\", err);\n}\n\n- (void)updateTouches:(NSSet*)touches {\n  for (UITouch* touch in touches) {\n#if EBITEN_METAL\n    if (touch.view != [self metalView]) {\n      continue;\n    }\n#else\n    if (touch.view != [self glkView]) {\n      continue;\n    }\n#endif\n    CGPoint location = [touch locationInView:touch.view];\n    EbitenmobileviewUpdateTouchesOnIOS(touch.phase, (uintptr_t)touch, location.x, location.y);\n  }\n}\n\n- (void)touchesBegan:(NSSet*)touches withEvent:(UIEvent*)event {\n  [self updateTouches:touches];\n}\n\n- (void)touchesMoved:(NSSet*)touches withEvent:(UIEvent*)event {\n  [self updateTouches:touches];\n}\n\n- (void)touchesEnded:(NSSet*)touches withEvent:(UIEvent*)event {\n  [self updateTouches:touches];\n}\n\n- (void)touchesCancelled:(NSSet*)touches withEvent:(UIEvent*)event {\n  [self updateTouches:touches];\n}\n\n- (void)suspendGame {\n  NSAssert(started_, @\"suspendGame must not be called before viewDidLoad is called\");\n\n  @synchronized(self) {\n    active_ = false;\n    NSError* err = nil;\n    EbitenmobileviewSuspend(&err);\n    if (err != nil) {\n      [self onErrorOnGameUpdate:err];\n    }\n  }\n}\n\n- (void)resumeGame {\n  NSAssert(started_, @\"resumeGame must not be called before viewDidLoad is called\");\n\n  @synchronized(self) {\n    active_ = true;\n    NSError* err = nil;\n    EbitenmobileviewResume(&err);\n    if (err != nil) {\n      [self onErrorOnGameUpdate:err];\n    }\n  }\n}\n\n- (void)terminateGame {\n  NSAssert(started_, @\"terminateGame must not be called before viewDidLoad is called\");\n\n  @synchronized(self) {\n    active_ = false;\n    NSError* err = nil;\n    EbitenmobileviewTerminate(&err);\n    if (err != nil) {\n      [self onErrorOnGameUpdate:err];\n    }\n  }\n}\n\n@end\n`\n\nconst viewJava = `// Code generated by ebitenmobile. DO NOT EDIT.\n\npackage {{.JavaPkg}}.{{.PrefixLower}};\n\nimport android.app.Activity;\nimport android.app.GameManager;\nimport android.content.BroadcastReceiver;\nimport android.content.ComponentCallbacks2;\nimport android.content.Context;\nimport android.content.ContextWrapper;\nimport android.content.Intent;\nimport android.content.IntentFilter;\nimport android.content.pm.ActivityInfo;\nimport android.content.res.Configuration;\nimport android.hardware.display.DisplayManager;\nimport android.hardware.input.InputManager;\nimport android.os.Build;\nimport android.os.Handler;\nimport android.os.Looper;\nimport android.os.PowerManager;\nimport android.text.Editable;\nimport android.text.TextWatcher;\nimport android.util.AttributeSet;\nimport android.util.DisplayMetrics;\nimport android.util.Log;\nimport android.view.Display;\nimport android.view.DisplayCutout;\nimport android.view.KeyEvent;\nimport android.view.InputDevice;\nimport android.view.MotionEvent;\nimport android.view.Surface;\nimport android.view.SurfaceHolder;\nimport android.view.View;\nimport android.view.ViewGroup;\nimport android.view.Window;\nimport android.view.WindowInsets;\nimport android.view.WindowManager;\nimport android.view.inputmethod.BaseInputConnection;\nimport android.view.inputmethod.EditorInfo;\nimport android.view.inputmethod.InputMethodManager;\nimport android.widget.EditText;\nimport android.widget.TextView;\n\nimport {{.JavaPkg}}.ebitenmobileview.Ebitenmobileview;\nimport {{.JavaPkg}}.ebitenmobileview.FrameRateController;\nimport {{.JavaPkg}}.ebitenmobileview.OrientationController;\nimport {{.JavaPkg}}.ebitenmobileview.ShareController;\nimport {{.JavaPkg}}.ebitenmobileview.SoftKeyboardController;\nimport {{.JavaPkg}}.ebitenmobileview.SystemUIController;\n\nimport java.io.File;\nimport java.io.FileOutputStream;\nimport java.io.IOException;\n\npublic class EbitenView extends ViewGroup implements InputManager.InputDeviceListener, DisplayManager.DisplayListener, OrientationController, SystemUIController, FrameRateController, SoftKeyboardController, ShareController {\n    private static double pxToDp(double x) {\n        return x / Ebitenmobileview.deviceScale();\n    }\n\n    public EbitenView(Context context) {\n        super(context);\n        initialize(context);\n    }\n\n    public EbitenView(Context context, AttributeSet attrs) {\n        super(context, attrs);\n        initialize(context);\n    }\n\n    private void initialize(Context context) {\n        Ebitenmobileview.setFilesDir(context.getFilesDir().getAbsolutePath());\n\n        this.ebitenSurfaceView = new EbitenSurfaceView(getContext());\n        LayoutParams params = new LayoutParams(LayoutParams.MATCH_PARENT, LayoutParams.MATCH_PARENT);\n        addView(this.ebitenSurfaceView, params);\n\n        this.inputManager = (InputManager)context.getSystemService(Context.INPUT_SERVICE);\n        this.inputManager.registerInputDeviceListener(this, null);\n        for (int id : this.inputManager.getInputDeviceIds()) {\n            this.onInputDeviceAdded(id);\n        }\n\n        this.displayManager = (DisplayManager)context.getSystemService(Context.DISPLAY_SERVICE);\n        this.powerManager = (PowerManager)context.getSystemService(Context.POWER_SERVICE);\n\n        this.ebitenSurfaceView.getHolder().addCallback(new SurfaceHolder.Callback() {\n            @Override\n            public void surfaceCreated(SurfaceHolder holder) {\n                applyPreferredFrameRate();\n            }\n\n            @Override\n            public void surfaceChanged(SurfaceHolder holder, int format, int width, int height) {\n            }\n\n            @Override\n            public void surfaceDestroyed(SurfaceHolder holder) {\n            }\n        });\n\n        this.componentCallbacks = new ComponentCallbacks2() {\n            @Override\n            public void onTrimMemory(int level) {\n                // TRIM_MEMORY_UI_HIDDEN just means the UI is hidden, and TRIM_MEMORY_RUNNING_MODERATE is not\n                // serious yet.\n                if (level == TRIM_MEMORY_UI_HIDDEN || level == TRIM_MEMORY_RUNNING_MODERATE) {\n                    return;\n                }\n                Ebitenmobileview.onLowMemory();\n            }\n\n            @Override\n            public void onLowMemory() {\n                Ebitenmobileview.onLowMemory();\n            }\n\n            @Override\n            public void onConfigurationChanged(Configuration newConfig) {\n            }\n        };\n\n        this.powerSaveModeReceiver = new BroadcastReceiver() {\n            @Override\n            public void onReceive(Context context, Intent intent) {\n                Ebitenmobileview.setLowPowerMode(powerManager.isPowerSaveMode());\n            }\n        };\n        if (Build.VERSION.SDK_INT >= Build.VERSION_CODES.Q) {\n            this.thermalStatusListener = new PowerManager.OnThermalStatusChangedListener() {\n                @Override\n                public void onThermalStatusChanged(int status) {\n                    Ebitenmobileview.setThermalState(toThermalState(status));\n                }\n            };\n        }\n    }\n\n    @Override\n    protected void onAttachedToWindow() {\n        super.onAttachedToWindow();\n\n        // There can be multiple views for the game, e.g. when an activity is recreated.\n        // The most recently attached view receives the requests from the game.\n        Ebitenmobileview.setOrientationController(this);\n        Ebitenmobileview.setSystemUIController(this);\n        Ebitenmobileview.setFrameRateController(this);\n        Ebitenmobileview.setSoftKeyboardController(this);\n        Ebitenmobileview.setShareController(this);\n\n        this.displayManager.registerDisplayListener(this, new Handler(Looper.getMainLooper()));\n        this.onDisplayChanged(Display.DEFAULT_DISPLAY);\n        updateExternalDisplay();\n\n        Context appContext = getContext().getApplicationContext();\n        appContext.registerComponentCallbacks(this.componentCallbacks);\n        appContext.registerReceiver(this.powerSaveModeReceiver, new IntentFilter(PowerManager.ACTION_POWER_SAVE_MODE_CHANGED));\n        Ebitenmobileview.setLowPowerMode(this.powerManager.isPowerSaveMode());\n        if (Build.VERSION.SDK_INT >= Build.VERSION_CODES.Q) {\n            this.powerManager.addThermalStatusListener((PowerManager.OnThermalStatusChangedListener)this.thermalStatusListener);\n            Ebitenmobileview.setThermalState(toThermalState(this.powerManager.getCurrentThermalStatus()));\n        }\n        updateGameMode();\n    }\n\n    @Override\n    protected void onDetachedFromWindow() {\n        super.onDetachedFromWindow();\n\n        this.displayManager.unregisterDisplayListener(this);\n\n        Context appContext = getContext().getApplicationContext();\n        appContext.unregisterComponentCallbacks(this.componentCallbacks);\n        appContext.unregisterReceiver(this.powerSaveModeReceiver);\n        if (Build.VERSION.SDK_INT >= Build.VERSION_CODES.Q) {\n            this.powerManager.removeThermalStatusListener((PowerManager.OnThermalStatusChangedListener)this.thermalStatusListener);\n        }\n    }\n\n    @Override\n    public void share(final byte[] data, final String name, final String mimeType) {\n        // This can be called from any thread.\n        new Handler(Looper.getMainLooper()).post(new Runnable() {\n            @Override\n            public void run() {\n                Context context = getContext();\n                try {\n                    File file = EbitenFileProvider.getFile(context, name);\n                    file.getParentFile().mkdirs();\n                    FileOutputStream out = new FileOutputStream(file);\n                    try {\n                        out.write(data);\n                    } finally {\n                        out.close();\n                    }\n                } catch (IOException e) {\n                    Log.e(\"Go\", \"failed to write a file to share: \" + e.toString());\n                    return;\n                }\n\n                Intent intent = new Intent(Intent.ACTION_SEND);\n                intent.setType(mimeType);\n                intent.putExtra(Intent.EXTRA_STREAM, EbitenFileProvider.getUri(context, name));\n                intent.addFlags(Intent.FLAG_GRANT_READ_URI_PERMISSION);\n                Intent chooser = Intent.createChooser(intent, null);\n                Activity activity = getActivity();\n                if (activity != null) {\n                    activity.startActivity(chooser);\n                    return;\n                }\n                chooser.addFlags(Intent.FLAG_ACTIVITY_NEW_TASK);\n                context.startActivity(chooser);\n            }\n        });\n    }\n\n    // softKeyboardEditText must be accessed only on the main thread.\n    private EditText softKeyboardEditText;\n\n    @Override\n    public void showSoftKeyboard(final String text) {\n        // This can be called from any thread.\n        new Handler(Looper.getMainLooper()).post(new Runnable() {\n            @Override\n            public void run() {\n                EditText editText = getSoftKeyboardEditText();\n                editText.setText(text);\n                editText.setSelection(editText.length());\n                editText.requestFocus();\n                InputMethodManager imm = (InputMethodManager)getContext().getSystemService(Context.INPUT_METHOD_SERVICE);\n                imm.showSoftInput(editText, InputMethodManager.SHOW_IMPLICIT);\n                Ebitenmobileview.setSoftKeyboardVisible(true);\n            }\n        });\n    }\n\n    @Override\n    public void hideSoftKeyboard() {\n        // This can be called from any thread.\n        new Handler(Looper.getMainLooper()).post(new Runnable() {\n            @Override\n            public void run() {\n                hideSoftKeyboardOnMainThread();\n            }\n        });\n    }\n\n    private void hideSoftKeyboardOnMainThread() {\n        if (this.softKeyboardEditText == null) {\n            return;\n        }\n        InputMethodManager imm = (InputMethodManager)getContext().getSystemService(Context.INPUT_METHOD_SERVICE);\n        imm.hideSoftInputFromWindow(this.softKeyboardEditText.getWindowToken(), 0);\n        this.softKeyboardEditText.clearFocus();\n        Ebitenmobileview.setSoftKeyboardVisible(false);\n    }\n\n    private EditText getSoftKeyboardEditText() {\n        if (this.softKeyboardEditText != null) {\n            return this.softKeyboardEditText;\n        }\n\n        // The edit text is invisible. The game renders the text by itself.\n        EditText editText = new EditText(getContext());\n        editText.setAlpha(0);\n        editText.setSingleLine(true);\n        editText.setImeOptions(EditorInfo.IME_ACTION_DONE | EditorInfo.IME_FLAG_NO_EXTRACT_UI);\n        editText.addTextChangedListener(new TextWatcher() {\n            @Override\n            public void beforeTextChanged(CharSequence s, int start, int count, int after) {\n            }\n\n            @Override\n            public void onTextChanged(CharSequence s, int start, int before, int count) {\n            }\n\n            @Override\n            public void afterTextChanged(Editable s) {\n                int start = BaseInputConnection.getComposingSpanStart(s);\n                int end = BaseInputConnection.getComposingSpanEnd(s);\n                if (start < 0 || end < 0) {\n                    start = s.length();\n                    end = s.length();\n                }\n                Ebitenmobileview.setSoftKeyboardText(s.toString(), start, end);\n            }\n        });\n        editText.setOnEditorActionListener(new TextView.OnEditorActionListener() {\n            @Override\n            public boolean onEditorAction(TextView v, int actionId, KeyEvent event) {\n                hideSoftKeyboardOnMainThread();\n                return true;\n            }\n        });\n        addView(editText, new LayoutParams(1, 1));\n        this.softKeyboardEditText = editText;\n        return editText;\n    }\n\n    // The values must be synced with mobile/power.go.\n    private static final int THERMAL_STATE_NOMINAL  = 1;\n    private static final int THERMAL_STATE_FAIR     = 2;\n    private static final int THERMAL_STATE_SERIOUS  = 3;\n    private static final int THERMAL_STATE_CRITICAL = 4;\n\n    private static int toThermalState(int status) {\n        switch (status) {\n        case PowerManager.THERMAL_STATUS_NONE:\n            return THERMAL_STATE_NOMINAL;\n        case PowerManager.THERMAL_STATUS_LIGHT:\n        case PowerManager.THERMAL_STATUS_MODERATE:\n            return THERMAL_STATE_FAIR;\n        case PowerManager.THERMAL_STATUS_SEVERE:\n            return THERMAL_STATE_SERIOUS;\n        default:\n            return THERMAL_STATE_CRITICAL;\n        }\n    }\n\n    // preferredFrameRate must be accessed only on the main thread.\n    private int preferredFrameRate = 0;\n\n    @Override\n    public void setPreferredFrameRate(final long fps) {\n        // This can be called from any thread.\n        new Handler(Looper.getMainLooper()).post(new Runnable() {\n            @Override\n            public void run() {\n                preferredFrameRate = (int)fps;\n                applyPreferredFrameRate();\n            }\n        });\n    }\n\n    private void applyPreferredFrameRate() {\n        // Even if the display doesn't adopt the frame rate, the frame pacer skips frames to match the frame rate.\n        this.ebitenSurfaceView.setPreferredFrameRate(this.preferredFrameRate);\n\n        if (Build.VERSION.SDK_INT >= Build.VERSION_CODES.R) {\n            Surface surface = this.ebitenSurfaceView.getHolder().getSurface();\n            if (surface == null || !surface.isValid()) {\n                return;\n            }\n            // 0 means no preference.\n            surface.setFrameRate(this.preferredFrameRate, Surface.FRAME_RATE_COMPATIBILITY_DEFAULT);\n            return;\n        }\n        if (Build.VERSION.SDK_INT >= Build.VERSION_CODES.M) {\n            Activity activity = getActivity();\n            if (activity == null) {\n                return;\n            }\n            Display display = activity.getWindowManager().getDefaultDisplay();\n            int modeId = 0;\n            if (this.preferredFrameRate != 0) {\n                Display.Mode current = display.getMode();\n                float bestRate = -1;\n                for (Display.Mode mode : display.getSupportedModes()) {\n                    if (mode.getPhysicalWidth() != current.getPhysicalWidth() || mode.getPhysicalHeight() != current.getPhysicalHeight()) {\n                        continue;\n                    }\n                    float rate = mode.getRefreshRate();\n                    if (bestRate < 0 || Math.abs(rate - this.preferredFrameRate) < Math.abs(bestRate - this.preferredFrameRate)) {\n                        bestRate = rate;\n                        modeId = mode.getModeId();\n                    }\n                }\n            }\n            Window window = activity.getWindow();\n            WindowManager.LayoutParams attrs = window.getAttributes();\n            attrs.preferredDisplayModeId = modeId;\n            window.setAttributes(attrs);\n        }\n    }\n\n    @Override\n    public void onDisplayAdded(int displayId) {\n        updateExternalDisplay();\n    }\n\n    @Override\n    public void onDisplayRemoved(int displayId) {\n        updateExternalDisplay();\n    }\n\n    @Override\n    public void onDisplayChanged(int displayId) {\n        if (displayId != Display.DEFAULT_DISPLAY) {\n            updateExternalDisplay();\n            return;\n        }\n        Display display = this.displayManager.getDisplay(displayId);\n        if (display == null) {\n            return;\n        }\n        this.ebitenSurfaceView.setRefreshRate(display.getRefreshRate());\n        Ebitenmobileview.setDisplayRefreshRate(display.getRefreshRate());\n    }\n\n    private void updateExternalDisplay() {\n        int width = 0;\n        int height = 0;\n        Display[] displays = this.displayManager.getDisplays(DisplayManager.DISPLAY_CATEGORY_PRESENTATION);\n        if (displays.length > 0) {\n            DisplayMetrics metrics = new DisplayMetrics();\n            displays[0].getRealMetrics(metrics);\n            width = metrics.widthPixels;\n            height = metrics.heightPixels;\n        }\n        Ebitenmobileview.setExternalDisplaySize(width, height);\n    }\n\n    private void updateGameMode() {\n        if (Build.VERSION.SDK_INT < Build.VERSION_CODES.S) {\n            return;\n        }\n        GameManager gameManager = (GameManager)getContext().getSystemService(Context.GAME_SERVICE);\n        if (gameManager == null) {\n            return;\n        }\n        Ebitenmobileview.setGameMode(gameManager.getGameMode());\n    }\n\n    @Override\n    protected void onLayout(boolean changed, int left, int top, int right, int bottom) {\n        this.ebitenSurfaceView.layout(0, 0, right - left, bottom - top);\n        if (this.softKeyboardEditText != null) {\n            this.softKeyboardEditText.layout(0, 0, 1, 1);\n        }\n        double widthInDp = pxToDp(right - left);\n        double heightInDp = pxToDp(bottom - top);\n        // The orientation must be notified before the size so that the game doesn't observe an intermediate state.\n        Ebitenmobileview.setOrientation(getCurrentOrientation());\n        Ebitenmobileview.layout(widthInDp, heightInDp);\n    }\n\n    // The values must be synced with mobile/orientation.go.\n    private static final int ORIENTATION_PORTRAIT             = 1 << 0;\n    private static final int ORIENTATION_PORTRAIT_UPSIDE_DOWN = 1 << 1;\n    private static final int ORIENTATION_LANDSCAPE_LEFT       = 1 << 2;\n    private static final int ORIENTATION_LANDSCAPE_RIGHT      = 1 << 3;\n\n    private Activity getActivity() {\n        Context context = getContext();\n        while (context instanceof ContextWrapper) {\n            if (context instanceof Activity) {\n                return (Activity)context;\n            }\n            context = ((ContextWrapper)context).getBaseContext();\n        }\n        return null;\n    }\n\n    private int getCurrentOrientation() {\n        Display display = ((WindowManager)getContext().getSystemService(Context.WINDOW_SERVICE)).getDefaultDisplay();\n        switch (display.getRotation()) {\n        case Surface.ROTATION_0:\n            return ORIENTATION_PORTRAIT;\n        case Surface.ROTATION_90:\n            return ORIENTATION_LANDSCAPE_RIGHT;\n        case Surface.ROTATION_180:\n            return ORIENTATION_PORTRAIT_UPSIDE_DOWN;\n        case Surface.ROTATION_270:\n            return ORIENTATION_LANDSCAPE_LEFT;\n        }\n        return 0;\n    }\n\n    @Override\n    public void setAllowedOrientations(long orientations) {\n        int requested = ActivityInfo.SCREEN_ORIENTATION_FULL_SENSOR;\n        boolean portrait = (orientations & ORIENTATION_PORTRAIT) != 0;\n        boolean portraitUpsideDown = (orientations & ORIENTATION_PORTRAIT_UPSIDE_DOWN) != 0;\n        boolean landscapeLeft = (orientations & ORIENTATION_LANDSCAPE_LEFT) != 0;\n        boolean landscapeRight = (orientations & ORIENTATION_LANDSCAPE_RIGHT) != 0;\n        if (orientations == 0) {\n            requested = ActivityInfo.SCREEN_ORIENTATION_UNSPECIFIED;\n        } else if (!landscapeLeft && !landscapeRight) {\n            if (portrait && portraitUpsideDown) {\n                requested = ActivityInfo.SCREEN_ORIENTATION_SENSOR_PORTRAIT;\n            } else if (portrait) {\n                requested = ActivityInfo.SCREEN_ORIENTATION_PORTRAIT;\n            } else {\n                requested = ActivityInfo.SCREEN_ORIENTATION_REVERSE_PORTRAIT;\n            }\n        } else if (!portrait && !portraitUpsideDown) {\n            if (landscapeLeft && landscapeRight) {\n                requested = ActivityInfo.SCREEN_ORIENTATION_SENSOR_LANDSCAPE;\n            } else if (landscapeRight) {\n                requested = ActivityInfo.SCREEN_ORIENTATION_LANDSCAPE;\n            } else {\n                requested = ActivityInfo.SCREEN_ORIENTATION_REVERSE_LANDSCAPE;\n            }\n        }\n\n        final Activity activity = getActivity();\n        if (activity == null) {\n            return;\n        }\n        final int requestedOrientation = requested;\n        // This can be called from any thread.\n        new Handler(Looper.getMainLooper()).post(new Runnable() {\n            @Override\n            public void run() {\n                activity.setRequestedOrientation(requestedOrientation);\n            }\n        });\n    }\n\n    // immersive and edgeToEdge must be accessed only on the main thread.\n    private boolean immersive = false;\n    private boolean edgeToEdge = false;\n\n    private void updateSystemUiVisibility() {\n        Activity activity = getActivity();\n        if (activity == null) {\n            return;\n        }\n        Window window = activity.getWindow();\n        int flags = 0;\n        if (this.edgeToEdge || this.immersive) {\n            flags |= View.SYSTEM_UI_FLAG_LAYOUT_STABLE |\n                View.SYSTEM_UI_FLAG_LAYOUT_HIDE_NAVIGATION |\n                View.SYSTEM_UI_FLAG_LAYOUT_FULLSCREEN;\n        }\n        if (this.immersive) {\n            flags |= View.SYSTEM_UI_FLAG_IMMERSIVE_STICKY |\n                View.SYSTEM_UI_FLAG_HIDE_NAVIGATION |\n                View.SYSTEM_UI_FLAG_FULLSCREEN;\n        }\n        if (Build.VERSION.SDK_INT >= Build.VERSION_CODES.P) {\n            WindowManager.LayoutParams attrs = window.getAttributes();\n            if (this.edgeToEdge || this.immersive) {\n                attrs.layoutInDisplayCutoutMode = WindowManager.LayoutParams.LAYOUT_IN_DISPLAY_CUTOUT_MODE_SHORT_EDGES;\n            } else {\n                attrs.layoutInDisplayCutoutMode = WindowManager.LayoutParams.LAYOUT_IN_DISPLAY_CUTOUT_MODE_DEFAULT;\n            }\n            window.setAttributes(attrs);\n        }\n        window.getDecorView().setSystemUiVisibility(flags);\n    }\n\n    @Override\n    public void setImmersiveMode(final boolean immersive) {\n        // This can be called from any thread.\n        new Handler(Looper.getMainLooper()).post(new Runnable() {\n            @Override\n            public void run() {\n                EbitenView.this.immersive = immersive;\n                updateSystemUiVisibility();\n            }\n        });\n    }\n\n    @Override\n    public void setEdgeToEdge(final boolean edgeToEdge) {\n        // This can be called from any thread.\n        new Handler(Looper.getMainLooper()).post(new Runnable() {\n            @Override\n            public void run() {\n                EbitenView.this.edgeToEdge = edgeToEdge;\n                updateSystemUiVisibility();\n            }\n        });\n    }\n\n    @Override\n    public void onWindowFocusChanged(boolean hasWindowFocus) {\n        super.onWindowFocusChanged(hasWindowFocus);\n        // The game mode can be changed e.g. via the Game Dashboard, which takes the window focus.\n        if (hasWindowFocus) {\n            updateGameMode();\n        }\n        // The system UI visibility can be reset e.g. when a dialog is shown. Apply the flags again.\n        if (hasWindowFocus && this.immersive) {\n            updateSystemUiVisibility();\n        }\n    }\n\n    @Override\n    public WindowInsets onApplyWindowInsets(WindowInsets insets) {\n        int left = insets.getSystemWindowInsetLeft();\n        int top = insets.getSystemWindowInsetTop();\n        int right = insets.getSystemWindowInsetRight();\n        int bottom = insets.getSystemWindowInsetBottom();\n        if (Build.VERSION.SDK_INT >= Build.VERSION_CODES.P) {\n            DisplayCutout cutout = insets.getDisplayCutout();\n            if (cutout != null) {\n                left = Math.max(left, cutout.getSafeInsetLeft());\n                top = Math.max(top, cutout.getSafeInsetTop());\n                right = Math.max(right, cutout.getSafeInsetRight());\n                bottom = Math.max(bottom, cutout.getSafeInsetBottom());\n            }\n        }\n        Ebitenmobileview.setSafeAreaInsets(pxToDp(left), pxToDp(top), pxToDp(right), pxToDp(bottom));\n        // The keyboard can be dismissed without the edit text, e.g. by the back button.\n        if (Build.VERSION.SDK_INT >= Build.VERSION_CODES.R && this.softKeyboardEditText != null && this.softKeyboardEditText.hasFocus()) {\n            Ebitenmobileview.setSoftKeyboardVisible(insets.isVisible(WindowInsets.Type.ime()));\n        }\n        return super.onApplyWindowInsets(insets);\n    }\n\n    @Override\n    public boolean onKeyDown(int keyCode, KeyEvent event) {\n        Ebitenmobileview.onKeyDownOnAndroid(keyCode, event.getUnicodeChar(), event.getSource(), event.getDeviceId());\n        return true;\n    }\n\n    @Override\n    public boolean onKeyUp(int keyCode, KeyEvent event) {\n        Ebitenmobileview.onKeyUpOnAndroid(keyCode, event.getSource(), event.getDeviceId());\n        return true;\n    }\n\n    @Override\n    public boolean onTouchEvent(MotionEvent e) {\n        for (int i = 0; i < e.getPointerCount(); i++) {\n            int id = e.getPointerId(i);\n            int x = (int)e.getX(i);\n            int y = (int)e.getY(i);\n            Ebitenmobileview.updateTouchesOnAndroid(e.getActionMasked(), id, (int)pxToDp(x), (int)pxToDp(y));\n        }\n        return true;\n    }\n\n    // The order must be the same as mobile/ebitenmobileview/input_android.go.\n    static int[] gamepadButtons = {\n        KeyEvent.KEYCODE_BUTTON_A,\n        KeyEvent.KEYCODE_BUTTON_B,\n        KeyEvent.KEYCODE_BUTTON_C,\n        KeyEvent.KEYCODE_BUTTON_X,\n        KeyEvent.KEYCODE_BUTTON_Y,\n        KeyEvent.KEYCODE_BUTTON_Z,\n        KeyEvent.KEYCODE_BUTTON_L1,\n        KeyEvent.KEYCODE_BUTTON_R1,\n        KeyEvent.KEYCODE_BUTTON_L2,\n        KeyEvent.KEYCODE_BUTTON_R2,\n        KeyEvent.KEYCODE_BUTTON_THUMBL,\n        KeyEvent.KEYCODE_BUTTON_THUMBR,\n        KeyEvent.KEYCODE_BUTTON_START,\n        KeyEvent.KEYCODE_BUTTON_SELECT,\n        KeyEvent.KEYCODE_BUTTON_MODE,\n        KeyEvent.KEYCODE_BUTTON_1,\n        KeyEvent.KEYCODE_BUTTON_2,\n        KeyEvent.KEYCODE_BUTTON_3,\n        KeyEvent.KEYCODE_BUTTON_4,\n        KeyEvent.KEYCODE_BUTTON_5,\n        KeyEvent.KEYCODE_BUTTON_6,\n        KeyEvent.KEYCODE_BUTTON_7,\n        KeyEvent.KEYCODE_BUTTON_8,\n        KeyEvent.KEYCODE_BUTTON_9,\n        KeyEvent.KEYCODE_BUTTON_10,\n        KeyEvent.KEYCODE_BUTTON_11,\n        KeyEvent.KEYCODE_BUTTON_12,\n        KeyEvent.KEYCODE_BUTTON_13,\n        KeyEvent.KEYCODE_BUTTON_14,\n        KeyEvent.KEYCODE_BUTTON_15,\n        KeyEvent.KEYCODE_BUTTON_16,\n    };\n\n    // The order must be the same as mobile/ebitenmobileview/input_android.go.\n    static int[] axes = {\n        MotionEvent.AXIS_X,\n        MotionEvent.AXIS_Y,\n        MotionEvent.AXIS_Z,\n        MotionEvent.AXIS_RX,\n        MotionEvent.AXIS_RY,\n        MotionEvent.AXIS_RZ,\n        MotionEvent.AXIS_HAT_X,\n        MotionEvent.AXIS_HAT_Y,\n        MotionEvent.AXIS_LTRIGGER,\n        MotionEvent.AXIS_RTRIGGER,\n        MotionEvent.AXIS_THROTTLE,\n        MotionEvent.AXIS_RUDDER,\n        MotionEvent.AXIS_WHEEL,\n        MotionEvent.AXIS_GAS,\n        MotionEvent.AXIS_BRAKE,\n        MotionEvent.AXIS_GENERIC_1,\n        MotionEvent.AXIS_GENERIC_2,\n        MotionEvent.AXIS_GENERIC_3,\n        MotionEvent.AXIS_GENERIC_4,\n        MotionEvent.AXIS_GENERIC_5,\n        MotionEvent.AXIS_GENERIC_6,\n        MotionEvent.AXIS_GENERIC_7,\n        MotionEvent.AXIS_GENERIC_8,\n        MotionEvent.AXIS_GENERIC_9,\n        MotionEvent.AXIS_GENERIC_10,\n        MotionEvent.AXIS_GENERIC_11,\n        MotionEvent.AXIS_GENERIC_12,\n        MotionEvent.AXIS_GENERIC_13,\n        MotionEvent.AXIS_GENERIC_14,\n        MotionEvent.AXIS_GENERIC_15,\n        MotionEvent.AXIS_GENERIC_16,\n    };\n\n    @Override\n    public boolean onGenericMotionEvent(MotionEvent event) {\n        if ((event.getSource() & InputDevice.SOURCE_JOYSTICK) != InputDevice.SOURCE_JOYSTICK) {\n            return super.onGenericMotionEvent(event);\n        }\n        if (event.getAction() != MotionEvent.ACTION_MOVE) {\n            return super.onGenericMotionEvent(event);\n        }\n        InputDevice inputDevice = this.inputManager.getInputDevice(event.getDeviceId());\n        for (int axis : axes) {\n            InputDevice.MotionRange motionRange = inputDevice.getMotionRange(axis, event.getSource());\n            float value = 0.0f;\n            if (motionRange != null) {\n                value = event.getAxisValue(axis);\n                if (Math.abs(value) <= motionRange.getFlat()) {\n                    value = 0.0f;\n                }\n            }\n            Ebitenmobileview.onGamepadAxesChanged(event.getDeviceId(), axis, value);\n        }\n        return true;\n    }\n\n    @Override\n    public void onInputDeviceAdded(int deviceId) {\n        InputDevice inputDevice = this.inputManager.getInputDevice(deviceId);\n        // The InputDevice can be null on some deivces (#1342).\n        if (inputDevice == null) {\n            return;\n        }\n\n        // A fingerprint reader is unexpectedly recognized as a joystick. Skip this (#1542).\n        if (inputDevice.getName().equals(\"uinput-fpc\")) {\n            return;\n        }\n\n        int sources = inputDevice.getSources();\n        if ((sources & InputDevice.SOURCE_GAMEPAD) != InputDevice.SOURCE_GAMEPAD &&\n            (sources & InputDevice.SOURCE_JOYSTICK) != InputDevice.SOURCE_JOYSTICK) {\n            return;\n        }\n\n        boolean[] keyExistences = inputDevice.hasKeys(gamepadButtons);\n        int buttonNum = gamepadButtons.length - 1;\n        for (int i = gamepadButtons.length - 1; i >= 0; i--) {\n            if (keyExistences[i]) {\n                break;\n            }\n            buttonNum--;\n        }\n\n        int axisNum = axes.length - 1;\n        for (int i = axes.length - 1; i >= 0; i--) {\n            if (inputDevice.getMotionRange(axes[i], InputDevice.SOURCE_JOYSTICK) != null) {\n                break;\n            }\n            axisNum--;\n        }\n\n        String descriptor = inputDevice.getDescriptor();\n        int vendorId = inputDevice.getVendorId();\n        int productId = inputDevice.getProductId();\n\n        // These values are required to calculate SDL's GUID.\n        int buttonMask = getButtonMask(inputDevice);\n        int axisMask = getAxisMask(inputDevice);\n\n        Ebitenmobileview.onGamepadAdded(deviceId, inputDevice.getName(), buttonNum, axisNum, descriptor, vendorId, productId, buttonMask, axisMask);\n    }\n\n    // The implementation is copied from SDL:\n    // https://hg.libsdl.org/SDL/file/bc90ce38f1e2/android-project/app/src/main/java/org/libsdl/app/SDLControllerManager.java#l308\n    private int getButtonMask(InputDevice joystickDevice) {\n        int button_mask = 0;\n        int[] keys = new int[] {\n            KeyEvent.KEYCODE_BUTTON_A,\n            KeyEvent.KEYCODE_BUTTON_B,\n            KeyEvent.KEYCODE_BUTTON_X,\n            KeyEvent.KEYCODE_BUTTON_Y,\n            KeyEvent.KEYCODE_BACK,\n            KeyEvent.KEYCODE_BUTTON_MODE,\n            KeyEvent.KEYCODE_BUTTON_START,\n            KeyEvent.KEYCODE_BUTTON_THUMBL,\n            KeyEvent.KEYCODE_BUTTON_THUMBR,\n            KeyEvent.KEYCODE_BUTTON_L1,\n            KeyEvent.KEYCODE_BUTTON_R1,\n            KeyEvent.KEYCODE_DPAD_UP,\n            KeyEvent.KEYCODE_DPAD_DOWN,\n            KeyEvent.KEYCODE_DPAD_LEFT,\n            KeyEvent.KEYCODE_DPAD_RIGHT,\n            KeyEvent.KEYCODE_BUTTON_SELECT,\n            KeyEvent.KEYCODE_DPAD_CENTER,\n\n            // These don't map into any SDL controller buttons directly\n            KeyEvent.KEYCODE_BUTTON_L2,\n            KeyEvent.KEYCODE_BUTTON_R2,\n            KeyEvent.KEYCODE_BUTTON_C,\n            KeyEvent.KEYCODE_BUTTON_Z,\n            KeyEvent.KEYCODE_BUTTON_1,\n            KeyEvent.KEYCODE_BUTTON_2,\n            KeyEvent.KEYCODE_BUTTON_3,\n            KeyEvent.KEYCODE_BUTTON_4,\n            KeyEvent.KEYCODE_BUTTON_5,\n            KeyEvent.KEYCODE_BUTTON_6,\n            KeyEvent.KEYCODE_BUTTON_7,\n            KeyEvent.KEYCODE_BUTTON_8,\n            KeyEvent.KEYCODE_BUTTON_9,\n            KeyEvent.KEYCODE_BUTTON_10,\n            KeyEvent.KEYCODE_BUTTON_11,\n            KeyEvent.KEYCODE_BUTTON_12,\n            KeyEvent.KEYCODE_BUTTON_13,\n            KeyEvent.KEYCODE_BUTTON_14,\n            KeyEvent.KEYCODE_BUTTON_15,\n            KeyEvent.KEYCODE_BUTTON_16,\n        };\n        int[] masks = new int[] {\n            (1 << 0),   // A -> A\n            (1 << 1),   // B -> B\n            (1 << 2),   // X -> X\n            (1 << 3),   // Y -> Y\n            (1 << 4),   // BACK -> BACK\n            (1 << 5),   // MODE -> GUIDE\n            (1 << 6),   // START -> START\n            (1 << 7),   // THUMBL -> LEFTSTICK\n            (1 << 8),   // THUMBR -> RIGHTSTICK\n            (1 << 9),   // L1 -> LEFTSHOULDER\n            (1 << 10),  // R1 -> RIGHTSHOULDER\n            (1 << 11),  // DPAD_UP -> DPAD_UP\n            (1 << 12),  // DPAD_DOWN -> DPAD_DOWN\n            (1 << 13),  // DPAD_LEFT -> DPAD_LEFT\n            (1 << 14),  // DPAD_RIGHT -> DPAD_RIGHT\n            (1 << 4),   // SELECT -> BACK\n            (1 << 0),   // DPAD_CENTER -> A\n            (1 << 15),  // L2 -> ??\n            (1 << 16),  // R2 -> ??\n            (1 << 17),  // C -> ??\n            (1 << 18),  // Z -> ??\n            (1 << 20),  // 1 -> ??\n            (1 << 21),  // 2 -> ??\n            (1 << 22),  // 3 -> ??\n            (1 << 23),  // 4 -> ??\n            (1 << 24),  // 5 -> ??\n            (1 << 25),  // 6 -> ??\n            (1 << 26),  // 7 -> ??\n            (1 << 27),  // 8 -> ??\n            (1 << 28),  // 9 -> ??\n            (1 << 29),  // 10 -> ??\n            (1 << 30),  // 11 -> ??\n            (1 << 31),  // 12 -> ??\n            // We're out of room...\n            0xFFFFFFFF,  // 13 -> ??\n            0xFFFFFFFF,  // 14 -> ??\n            0xFFFFFFFF,  // 15 -> ??\n            0xFFFFFFFF,  // 16 -> ??\n        };\n        boolean[] has_keys = joystickDevice.hasKeys(keys);\n        for (int i = 0; i < keys.length; ++i) {\n            if (has_keys[i]) {\n                button_mask |= masks[i];\n            }\n        }\n        return button_mask;\n    }\n\n    private int getAxisMask(InputDevice joystickDevice) {\n        final int SDL_CONTROLLER_AXIS_LEFTX = 0;\n        final int SDL_CONTROLLER_AXIS_LEFTY = 1;\n        final int SDL_CONTROLLER_AXIS_RIGHTX = 2;\n        final int SDL_CONTROLLER_AXIS_RIGHTY = 3;\n        final int SDL_CONTROLLER_AXIS_TRIGGERLEFT = 4;\n        final int SDL_CONTROLLER_AXIS_TRIGGERRIGHT = 5;\n\n        int naxes = 0;\n        for (InputDevice.MotionRange range : joystickDevice.getMotionRanges()) {\n            if ((range.getSource() & InputDevice.SOURCE_CLASS_JOYSTICK) != 0) {\n                if (range.getAxis() != MotionEvent.AXIS_HAT_X && range.getAxis() != MotionEvent.AXIS_HAT_Y) {\n                    naxes++;\n                }\n            }\n        }\n        // The variable is_accelerometer seems always false, then skip the checking:\n        // https://hg.libsdl.org/SDL/file/bc90ce38f1e2/android-project/app/src/main/java/org/libsdl/app/SDLControllerManager.java#l207\n        int axisMask = 0;\n        if (naxes >= 2) {\n            axisMask |= ((1 << SDL_CONTROLLER_AXIS_LEFTX) | (1 << SDL_CONTROLLER_AXIS_LEFTY));\n        }\n        if (naxes >= 4) {\n            axisMask |= ((1 << SDL_CONTROLLER_AXIS_RIGHTX) | (1 << SDL_CONTROLLER_AXIS_RIGHTY));\n        }\n        if (naxes >= 6) {\n            axisMask |= ((1 << SDL_CONTROLLER_AXIS_TRIGGERLEFT) | (1 << SDL_CONTROLLER_AXIS_TRIGGERRIGHT));\n        }\n        return axisMask;\n    }\n\n    @Override\n    public void onInputDeviceChanged(int deviceId) {\n        // Do nothing.\n    }\n\n    @Override\n    public void onInputDeviceRemoved(int deviceId) {\n        // Do not call inputManager.getInputDevice(), which returns null (#1185).\n        Ebitenmobileview.onInputDeviceRemoved(deviceId);\n    }\n\n    // suspendGame suspends the game.\n    // It is recommended to call this when the application is being suspended e.g.,\n    // Activity's onPause is called.\n    public void suspendGame() {\n        this.inputManager.unregisterInputDeviceListener(this);\n        this.ebitenSurfaceView.onPause();\n        try {\n            Ebitenmobileview.suspend();\n        } catch (final Exception e) {\n            onErrorOnGameUpdate(e);\n        }\n    }\n\n    // resumeGame resumes the game.\n    // It is recommended to call this when the application is being resumed e.g.,\n    // Activity's onResume is called.\n    public void resumeGame() {\n        this.inputManager.registerInputDeviceListener(this, null);\n        this.ebitenSurfaceView.onResume();\n        try {\n            Ebitenmobileview.resume();\n        } catch (final Exception e) {\n            onErrorOnGameUpdate(e);\n        }\n    }\n\n    // terminateGame lets the game finish its work, e.g. saving the game state, by calling the game's Shutdown.\n    // It is recommended to call this before suspendGame when the application is being terminated e.g.,\n    // Activity's onPause is called and isFinishing returns true.\n    public void terminateGame() {\n        this.ebitenSurfaceView.queueEvent(new Runnable() {\n            @Override\n            public void run() {\n                try {\n                    Ebitenmobileview.terminate();\n                } catch (final Exception e) {\n                    new Handler(Looper.getMainLooper()).post(new Runnable() {\n                        @Override\n                        public void run() {\n                            onErrorOnGameUpdate(e);\n                        }\n                    });\n                }\n            }\n        });\n    }\n\n    // onErrorOnGameUpdate is called on the main thread when an error happens when updating a game.\n    // You can define your own error handler, e.g., using Crashlytics, by overriding this method.\n    protected void onErrorOnGameUpdate(Exception e) {\n        Log.e(\"Go\", e.toString());\n    }\n\n    private EbitenSurfaceView ebitenSurfaceView;\n    private InputManager inputManager;\n    private DisplayManager displayManager;\n    private PowerManager powerManager;\n    private ComponentCallbacks2 componentCallbacks;\n    private BroadcastReceiver powerSaveModeReceiver;\n    // thermalStatusListener is a PowerManager.OnThermalStatusChangedListener, which is available on Android 10 or later.\n    private Object thermalStatusListener;\n}\n`\n\nconst fileProviderJava = `// Code generated by ebitenmobile. DO NOT EDIT.\n\npackage {{.JavaPkg}}.{{.PrefixLower}};\n\nimport android.content.ContentProvider;\nimport android.content.ContentValues;\nimport android.content.Context;\nimport android.database.Cursor;\nimport android.database.MatrixCursor;\nimport android.net.Uri;\nimport android.os.ParcelFileDescriptor;\nimport android.provider.OpenableColumns;\nimport android.webkit.MimeTypeMap;\n\nimport java.io.File;\nimport java.io.FileNotFoundException;\n\n// EbitenFileProvider provides the files shared by mobile.Share to other applications.\n//\n// To use mobile.Share, declare this provider in AndroidManifest.xml of the application:\n//\n//     <provider\n//         android:name=\"{{.JavaPkg}}.{{.PrefixLower}}.EbitenFileProvider\"\n//         android:authorities=\"${applicationId}.ebitenfileprovider\"\n//         android:exported=\"false\"\n//         android:grantUriPermissions=\"true\" />\npublic class EbitenFileProvider extends ContentProvider {\n\n    private static final String DIRECTORY = \"ebiten_share\";\n\n    static File getFile(Context context, String name) {\n        return new File(new File(context.getCacheDir(), DIRECTORY), name);\n    }\n\n    static Uri getUri(Context context, String name) {\n        return new Uri.Builder()\n            .scheme(\"content\")\n            .authority(context.getPackageName() + \".ebitenfileprovider\")\n            .appendPath(name)\n            .build();\n    }\n\n    private File getFile(Uri uri) throws FileNotFoundException {\n        String name = uri.getLastPathSegment();\n        if (name == null || name.contains(\"/\") || name.equals(\"..\")) {\n            throw new FileNotFoundException(\"invalid URI: \" + uri);\n        }\n        File file = getFile(getContext(), name);\n        if (!file.isFile()) {\n            throw new FileNotFoundException(\"file not found: \" + uri);\n        }\n        return file;\n    }\n\n    @Override\n    public boolean onCreate() {\n        return true;\n    }\n\n    @Override\n    public Cursor query(Uri uri, String[] projection, String selection, String[] selectionArgs, String sortOrder) {\n        File file;\n        try {\n            file = getFile(uri);\n        } catch (FileNotFoundException e) {\n            return null;\n        }\n        if (projection == null) {\n            projection = new String[] {OpenableColumns.DISPLAY_NAME, OpenableColumns.SIZE};\n        }\n        MatrixCursor cursor = new MatrixCursor(projection, 1);\n        Object[] row = new Object[projection.length];\n        for (int i = 0; i < projection.length; i++) {\n            if (OpenableColumns.DISPLAY_NAME.equals(projection[i])) {\n                row[i] = file.getName();\n            } else if (OpenableColumns.SIZE.equals(projection[i])) {\n                row[i] = file.length();\n            }\n        }\n        cursor.addRow(row);\n        return cursor;\n    }\n\n    @Override\n    public String getType(Uri uri) {\n        String ext = MimeTypeMap.getFileExtensionFromUrl(uri.getLastPathSegment());\n        String type = MimeTypeMap.getSingleton().getMimeTypeFromExtension(ext);\n        if (type == null) {\n            return \"application/octet-stream\";\n        }\n        return type;\n    }\n\n    @Override\n    public ParcelFileDescriptor openFile(Uri uri, String mode) throws FileNotFoundException {\n        return ParcelFileDescriptor.open(getFile(uri), ParcelFileDescriptor.MODE_READ_ONLY);\n    }\n\n    @Override\n    public Uri insert(Uri uri, ContentValues values) {\n        throw new UnsupportedOperationException(\"insert is not supported\");\n    }\n\n    @Override\n    public int delete(Uri uri, String selection, String[] selectionArgs) {\n        throw new UnsupportedOperationException(\"delete is not supported\");\n    }\n\n    @Override\n    public int update(Uri uri, ContentValues values, String selection, String[] selectionArgs) {\n        throw new UnsupportedOperationException(\"update is not supported\");\n    }\n}\n`\n\nconst surfaceViewJava = `// Code generated by ebitenmobile. DO NOT EDIT.\n\npackage {{.JavaPkg}}.{{.PrefixLower}};\n\nimport android.content.Context;\nimport android.opengl.EGL14;\nimport android.opengl.EGLExt;\nimport android.opengl.GLSurfaceView;\nimport android.os.Build;\nimport android.os.Handler;\nimport android.os.Looper;\nimport android.util.AttributeSet;\nimport android.util.Log;\nimport android.view.Choreographer;\n\nimport javax.microedition.khronos.egl.EGLConfig;\nimport javax.microedition.khronos.opengles.GL10;\n\nimport {{.JavaPkg}}.ebitenmobileview.Ebitenmobileview;\nimport {{.JavaPkg}}.{{.PrefixLower}}.EbitenView;\n\nclass EbitenSurfaceView extends GLSurfaceView {\n\n    // FramePacer requests rendering at the display's vsync timings, in the same way as the Android Frame Pacing\n    // library (Swappy). With the continuous rendering mode, frames are rendered as fast as eglSwapBuffers allows\n    // and their timings can drift from vsync, which causes judder.\n    private class FramePacer implements Choreographer.FrameCallback {\n\n        private boolean running_ = false;\n        private long vsyncCount_ = 0;\n\n        void start() {\n            if (running_) {\n                return;\n            }\n            running_ = true;\n            Choreographer.getInstance().postFrameCallback(this);\n        }\n\n        void stop() {\n            running_ = false;\n            Choreographer.getInstance().removeFrameCallback(this);\n        }\n\n        @Override\n        public void doFrame(long frameTimeNanos) {\n            if (!running_) {\n                return;\n            }\n            Choreographer.getInstance().postFrameCallback(this);\n\n            int swapInterval = getSwapInterval();\n            vsyncCount_++;\n            if (vsyncCount_ % swapInterval != 0) {\n                return;\n            }\n            // Present the frame at the vsync when the next frame is requested, so that every frame is shown\n            // for the same duration.\n            presentationTimeNanos_ = frameTimeNanos + swapInterval * (long)(1e9 / refreshRate_);\n            requestRender();\n        }\n    }\n\n    private class EbitenRenderer implements GLSurfaceView.Renderer {\n\n        private boolean errored_ = false;\n\n        @Override\n        public void onDrawFrame(GL10 gl) {\n            if (errored_) {\n                return;\n            }\n            try {\n                Ebitenmobileview.update();\n            } catch (final Exception e) {\n                new Handler(Looper.getMainLooper()).post(new Runnable() {\n                    @Override\n                    public void run() {\n                        onErrorOnGameUpdate(e);\n                    }\n                });\n                errored_ = true;\n                return;\n            }\n            long presentationTimeNanos = presentationTimeNanos_;\n            if (Build.VERSION.SDK_INT >= Build.VERSION_CODES.JELLY_BEAN_MR2 && presentationTimeNanos != 0) {\n                EGLExt.eglPresentationTimeANDROID(EGL14.eglGetCurrentDisplay(), EGL14.eglGetCurrentSurface(EGL14.EGL_DRAW), presentationTimeNanos);\n            }\n        }\n\n        @Override\n        public void onSurfaceCreated(GL10 gl, EGLConfig config) {\n            Ebitenmobileview.onContextLost();\n        }\n\n        @Override\n        public void onSurfaceChanged(GL10 gl, int width, int height) {\n        }\n    }\n\n    public EbitenSurfaceView(Context context) {\n        super(context);\n        initialize();\n    }\n\n    public EbitenSurfaceView(Context context, AttributeSet attrs) {\n        super(context, attrs);\n        initialize();\n    }\n\n    private void initialize() {\n        setEGLContextClientVersion(2);\n        setEGLConfigChooser(8, 8, 8, 8, 0, 0);\n        setRenderer(new EbitenRenderer());\n        // Frames are requested by the frame pacer.\n        setRenderMode(RENDERMODE_WHEN_DIRTY);\n    }\n\n    // presentationTimeNanos_ is accessed on both the main thread and the GL thread.\n    private volatile long presentationTimeNanos_ = 0;\n\n    // refreshRate_ and preferredFrameRate_ must be accessed only on the main thread.\n    private float refreshRate_ = 60;\n    private int preferredFrameRate_ = 0;\n\n    private FramePacer framePacer_ = new FramePacer();\n\n    void setRefreshRate(float refreshRate) {\n        if (refreshRate <= 0) {\n            return;\n        }\n        refreshRate_ = refreshRate;\n    }\n\n    void setPreferredFrameRate(int fps) {\n        preferredFrameRate_ = fps;\n    }\n\n    private int getSwapInterval() {\n        if (preferredFrameRate_ <= 0) {\n            return 1;\n        }\n        return Math.max(Math.round(refreshRate_ / preferredFrameRate_), 1);\n    }\n\n    @Override\n    protected void onAttachedToWindow() {\n        super.onAttachedToWindow();\n        framePacer_.start();\n    }\n\n    @Override\n    protected void onDetachedFromWindow() {\n        framePacer_.stop();\n        super.onDetachedFromWindow();\n    }\n\n    private void onErrorOnGameUpdate(Exception e) {\n        ((EbitenView)getParent()).onErrorOnGameUpdate(e);\n    }\n}\n`\n")
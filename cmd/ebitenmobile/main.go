@@ -282,6 +282,11 @@ const objcH = `// Code generated by ebitenmobile. DO NOT EDIT.
 // UIApplicationDelegate's applicationDidBecomeActive is called.
 - (void)resumeGame;
 
+// terminateGame lets the game finish its work, e.g. saving the game state, by calling the game's Shutdown.
+// It is recommended to call this when the application is being terminated e.g.,
+// UIApplicationDelegate's applicationWillTerminate is called.
+- (void)terminateGame;
+
 @end
 `
 
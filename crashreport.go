@@ -0,0 +1,365 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten
+
+import (
+	"archive/zip"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CrashReport represents the information about a panic in the game's Update or Draw.
+type CrashReport struct {
+	// Panic is the value recovered from the panic.
+	Panic interface{}
+
+	// Stack is the stack trace of the goroutine that panicked.
+	Stack []byte
+
+	// Time is the time when the panic happened.
+	Time time.Time
+
+	// Screenshot is the last screen image that the game's Draw rendered completely.
+	// Screenshot is nil if the image is not available, e.g. when the panic happens before the first Draw ends.
+	Screenshot image.Image
+
+	// InputHistory is the recent input states from the oldest one.
+	// A state is recorded at each tick only when the pressed keys, mouse buttons or touches change.
+	InputHistory []CrashReportInput
+
+	// ScreenWidth and ScreenHeight are the game screen size returned by the game's Layout.
+	ScreenWidth  int
+	ScreenHeight int
+
+	// OutsideWidth and OutsideHeight are the outside size given to the game's Layout.
+	OutsideWidth  float64
+	OutsideHeight float64
+
+	// DeviceScaleFactor is the device scale factor.
+	DeviceScaleFactor float64
+
+	// Graphics is the name of the graphics driver.
+	Graphics string
+
+	// CurrentFPS and CurrentTPS are the values of CurrentFPS and CurrentTPS at the panic.
+	CurrentFPS float64
+	CurrentTPS float64
+
+	// GoVersion, GOOS and GOARCH are the values in the runtime package.
+	GoVersion string
+	GOOS      string
+	GOARCH    string
+}
+
+// CrashReportInput represents an input state in a crash report.
+type CrashReportInput struct {
+	// Time is the time when the state is recorded.
+	Time time.Time
+
+	// Keys are the pressed keys.
+	Keys []Key
+
+	// MouseButtons are the pressed mouse buttons.
+	MouseButtons []MouseButton
+
+	// CursorX and CursorY are the cursor position.
+	CursorX int
+	CursorY int
+
+	// TouchNum is the number of the touches.
+	TouchNum int
+}
+
+// String returns a human-readable text of the report, except for the screenshot.
+func (r *CrashReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "panic: %v\n", r.Panic)
+	fmt.Fprintf(&b, "time: %s\n", r.Time.Format(time.RFC3339Nano))
+	fmt.Fprintf(&b, "screen size: %dx%d\n", r.ScreenWidth, r.ScreenHeight)
+	fmt.Fprintf(&b, "outside size: %gx%g\n", r.OutsideWidth, r.OutsideHeight)
+	fmt.Fprintf(&b, "device scale factor: %g\n", r.DeviceScaleFactor)
+	fmt.Fprintf(&b, "graphics: %s\n", r.Graphics)
+	fmt.Fprintf(&b, "FPS: %0.2f, TPS: %0.2f\n", r.CurrentFPS, r.CurrentTPS)
+	fmt.Fprintf(&b, "go: %s %s/%s\n", r.GoVersion, r.GOOS, r.GOARCH)
+	b.WriteString("\ninput history:\n")
+	for _, i := range r.InputHistory {
+		fmt.Fprintf(&b, "%s keys: %v, mouse buttons: %v, cursor: (%d, %d), touches: %d\n", i.Time.Format("15:04:05.000"), i.Keys, i.MouseButtons, i.CursorX, i.CursorY, i.TouchNum)
+	}
+	b.WriteString("\nstack:\n")
+	b.Write(r.Stack)
+	return b.String()
+}
+
+// WriteZip writes the report as a zip archive to w.
+// The archive includes report.txt, which is the result of String, and screenshot.png if the screenshot exists.
+func (r *CrashReport) WriteZip(w io.Writer) error {
+	z := zip.NewWriter(w)
+
+	f, err := z.Create("report.txt")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(f, r.String()); err != nil {
+		return err
+	}
+
+	if r.Screenshot != nil {
+		f, err := z.Create("screenshot.png")
+		if err != nil {
+			return err
+		}
+		if err := png.Encode(f, r.Screenshot); err != nil {
+			return err
+		}
+	}
+
+	return z.Close()
+}
+
+// SetCrashReportHandler sets the function called when the game's Update or Draw panics.
+//
+// f is called with the crash report before the panic is re-raised, so the game still crashes after f returns.
+// f is useful to save the report to a file or to send it to a server so that crash reports from users are
+// actionable.
+//
+// While a handler is set, the last completed screen is kept and the input states are recorded at every tick,
+// which costs a little. If f is nil, the handler is unset.
+//
+// SetCrashReportHandler is concurrent-safe.
+func SetCrashReportHandler(f func(report *CrashReport)) {
+	theCrashReporter.setHandler(f)
+}
+
+const crashReportInputHistorySize = 60
+
+type crashReporter struct {
+	handler func(report *CrashReport)
+
+	screen       *Image
+	screenValid  bool
+	inputHistory []CrashReportInput
+	inputIndex   int
+
+	// panicStack is the stack of the panic that happened on another goroutine and is forwarded by repanic.
+	panicStack []byte
+
+	m sync.Mutex
+}
+
+var theCrashReporter crashReporter
+
+func (c *crashReporter) setHandler(f func(report *CrashReport)) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.handler = f
+	if f == nil {
+		if c.screen != nil {
+			c.screen.Dispose()
+			c.screen = nil
+		}
+		c.screenValid = false
+		c.inputHistory = nil
+		c.inputIndex = 0
+	}
+}
+
+func (c *crashReporter) isEnabled() bool {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.handler != nil
+}
+
+// recordInput records the current input state if the state changes. recordInput must be called at every tick.
+func (c *crashReporter) recordInput() {
+	if !c.isEnabled() {
+		return
+	}
+
+	var keys []Key
+	for k := Key(0); k <= KeyMax; k++ {
+		if IsKeyPressed(k) {
+			keys = append(keys, k)
+		}
+	}
+	var buttons []MouseButton
+	for _, b := range []MouseButton{MouseButtonLeft, MouseButtonRight, MouseButtonMiddle} {
+		if IsMouseButtonPressed(b) {
+			buttons = append(buttons, b)
+		}
+	}
+	x, y := CursorPosition()
+	input := CrashReportInput{
+		Time:         time.Now(),
+		Keys:         keys,
+		MouseButtons: buttons,
+		CursorX:      x,
+		CursorY:      y,
+		TouchNum:     len(TouchIDs()),
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if n := len(c.inputHistory); n > 0 {
+		last := c.inputHistory[(c.inputIndex+n-1)%n]
+		if equalKeys(last.Keys, input.Keys) && equalMouseButtons(last.MouseButtons, input.MouseButtons) && last.TouchNum == input.TouchNum {
+			return
+		}
+	}
+	if len(c.inputHistory) < crashReportInputHistorySize {
+		c.inputHistory = append(c.inputHistory, input)
+		return
+	}
+	c.inputHistory[c.inputIndex] = input
+	c.inputIndex = (c.inputIndex + 1) % crashReportInputHistorySize
+}
+
+func equalKeys(a, b []Key) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func equalMouseButtons(a, b []MouseButton) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// keepScreen keeps a copy of the given screen as the last completed screen.
+// keepScreen must be called after the game's Draw ends.
+func (c *crashReporter) keepScreen(screen *Image) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if c.handler == nil {
+		return
+	}
+
+	w, h := screen.Size()
+	if c.screen != nil {
+		if sw, sh := c.screen.Size(); sw != w || sh != h {
+			c.screen.Dispose()
+			c.screen = nil
+		}
+	}
+	if c.screen == nil {
+		c.screen = NewImage(w, h)
+	}
+	op := &DrawImageOptions{}
+	op.CompositeMode = CompositeModeCopy
+	c.screen.DrawImage(screen, op)
+	c.screenValid = true
+}
+
+// handlePanic reports the panic if the handler exists, and then re-panics.
+// handlePanic must be called directly by defer.
+func (c *crashReporter) handlePanic() {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	c.m.Lock()
+	f := c.handler
+	stack := c.panicStack
+	c.panicStack = nil
+	c.m.Unlock()
+
+	if stack == nil {
+		stack = debug.Stack()
+	}
+	if f != nil {
+		f(c.newReport(r, stack))
+	}
+	panic(r)
+}
+
+// repanic panics with the value recovered on another goroutine.
+// The given stack is used for the report instead of the current goroutine's stack.
+func (c *crashReporter) repanic(value interface{}, stack []byte) {
+	c.m.Lock()
+	c.panicStack = stack
+	c.m.Unlock()
+	panic(value)
+}
+
+func (c *crashReporter) newReport(value interface{}, stack []byte) *CrashReport {
+	r := &CrashReport{
+		Panic:             value,
+		Stack:             stack,
+		Time:              time.Now(),
+		OutsideWidth:      theUIContext.outsideWidth,
+		OutsideHeight:     theUIContext.outsideHeight,
+		DeviceScaleFactor: uiDriver().DeviceScaleFactor(),
+		Graphics:          fmt.Sprintf("%T", uiDriver().Graphics()),
+		CurrentFPS:        CurrentFPS(),
+		CurrentTPS:        CurrentTPS(),
+		GoVersion:         runtime.Version(),
+		GOOS:              runtime.GOOS,
+		GOARCH:            runtime.GOARCH,
+	}
+	if theUIContext.offscreen != nil {
+		r.ScreenWidth, r.ScreenHeight = theUIContext.offscreen.Size()
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	n := len(c.inputHistory)
+	for i := 0; i < n; i++ {
+		r.InputHistory = append(r.InputHistory, c.inputHistory[(c.inputIndex+i)%n])
+	}
+
+	if c.screen != nil && c.screenValid {
+		r.Screenshot = c.readScreen()
+	}
+	return r
+}
+
+// readScreen reads the pixels of the kept screen. readScreen returns nil if reading fails, e.g. when the panic
+// happens in the graphics driver.
+func (c *crashReporter) readScreen() (img image.Image) {
+	defer func() {
+		if r := recover(); r != nil {
+			img = nil
+		}
+	}()
+
+	rgba, err := screenToRGBA(c.screen)
+	if err != nil {
+		return nil
+	}
+	return rgba
+}
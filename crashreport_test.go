@@ -0,0 +1,155 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	. "github.com/hajimehoshi/ebiten/v2"
+)
+
+func newTestCrashReport() *CrashReport {
+	t0 := time.Date(2021, 4, 1, 12, 34, 56, 789000000, time.UTC)
+	return &CrashReport{
+		Panic: errors.New("boom"),
+		Stack: []byte("goroutine 1 [running]:\nmain.main()\n"),
+		Time:  t0,
+		InputHistory: []CrashReportInput{
+			{
+				Time:    t0.Add(-time.Second),
+				CursorX: 1,
+				CursorY: 2,
+			},
+			{
+				Time:         t0.Add(-500 * time.Millisecond),
+				Keys:         []Key{KeyA, KeySpace},
+				MouseButtons: []MouseButton{MouseButtonLeft},
+				CursorX:      3,
+				CursorY:      4,
+				TouchNum:     1,
+			},
+		},
+		ScreenWidth:       320,
+		ScreenHeight:      240,
+		OutsideWidth:      640,
+		OutsideHeight:     480.5,
+		DeviceScaleFactor: 2,
+		Graphics:          "*opengl.Graphics",
+		CurrentFPS:        59.94,
+		CurrentTPS:        60,
+		GoVersion:         "go1.16",
+		GOOS:              "linux",
+		GOARCH:            "amd64",
+	}
+}
+
+const testCrashReportText = `panic: boom
+time: 2021-04-01T12:34:56.789Z
+screen size: 320x240
+outside size: 640x480.5
+device scale factor: 2
+graphics: *opengl.Graphics
+FPS: 59.94, TPS: 60.00
+go: go1.16 linux/amd64
+
+input history:
+12:34:55.789 keys: [], mouse buttons: [], cursor: (1, 2), touches: 0
+12:34:56.289 keys: [A Space], mouse buttons: [0], cursor: (3, 4), touches: 1
+
+stack:
+goroutine 1 [running]:
+main.main()
+`
+
+func TestCrashReportString(t *testing.T) {
+	if got, want := newTestCrashReport().String(), testCrashReportText; got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func readZip(t *testing.T, data []byte) map[string][]byte {
+	z, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{}
+	for _, f := range z.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		bs, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name] = bs
+	}
+	return files
+}
+
+func TestCrashReportWriteZip(t *testing.T) {
+	r := newTestCrashReport()
+
+	var buf bytes.Buffer
+	if err := r.WriteZip(&buf); err != nil {
+		t.Fatal(err)
+	}
+	files := readZip(t, buf.Bytes())
+	if got, want := len(files), 1; got != want {
+		t.Errorf("the number of files: got: %d, want: %d", got, want)
+	}
+	if got, want := string(files["report.txt"]), testCrashReportText; got != want {
+		t.Errorf("report.txt: got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestCrashReportWriteZipWithScreenshot(t *testing.T) {
+	r := newTestCrashReport()
+	screenshot := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	screenshot.SetRGBA(1, 1, color.RGBA{0xff, 0x80, 0, 0xff})
+	r.Screenshot = screenshot
+
+	var buf bytes.Buffer
+	if err := r.WriteZip(&buf); err != nil {
+		t.Fatal(err)
+	}
+	files := readZip(t, buf.Bytes())
+	if got, want := len(files), 2; got != want {
+		t.Errorf("the number of files: got: %d, want: %d", got, want)
+	}
+	if got, want := string(files["report.txt"]), testCrashReportText; got != want {
+		t.Errorf("report.txt: got:\n%s\nwant:\n%s", got, want)
+	}
+
+	img, err := png.Decode(bytes.NewReader(files["screenshot.png"]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := img.Bounds(), screenshot.Bounds(); got != want {
+		t.Errorf("screenshot bounds: got: %v, want: %v", got, want)
+	}
+	if got, want := color.RGBAModel.Convert(img.At(1, 1)), (color.RGBA{0xff, 0x80, 0, 0xff}); got != want {
+		t.Errorf("screenshot At(1, 1): got: %v, want: %v", got, want)
+	}
+}
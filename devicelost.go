@@ -0,0 +1,119 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten
+
+import (
+	"errors"
+
+	"github.com/hajimehoshi/ebiten/v2/internal/atlas"
+	"github.com/hajimehoshi/ebiten/v2/internal/driver"
+	"github.com/hajimehoshi/ebiten/v2/internal/hooks"
+)
+
+// DeviceLostError is returned from RunGame when the graphics device is lost, e.g. by a driver reset or a lost
+// WebGL context, and Ebiten fails to recover from it.
+type DeviceLostError struct {
+	// Err is the error that occurred while recovering.
+	Err error
+}
+
+// Error implements error.
+func (e *DeviceLostError) Error() string {
+	return "ebiten: the graphics device is lost: " + e.Err.Error()
+}
+
+// Unwrap returns the error that occurred while recovering.
+func (e *DeviceLostError) Unwrap() error {
+	return e.Err
+}
+
+// DeviceLostHandler is an optional interface for a Game to be notified when Ebiten recovers from the loss of the
+// graphics device.
+//
+// A graphics device can be lost when e.g. the GPU driver is reset, the WebGL context is lost on browsers, or the
+// OpenGL ES context is lost on Android. Ebiten recreates the textures and restores the contents of the images
+// where possible:
+//
+//   - On the environments where Ebiten records the drawing history (e.g. Android and desktop browsers), all the
+//     images are restored.
+//   - Otherwise (e.g. mobile browsers), the images whose pixels are kept in the system memory are restored. These
+//     include cold images (see SetCold) and images whose pixels were read by At or ReplacePixels recently. Images
+//     created by other Ebiten packages like text and ebitenutil are recreated by the packages.
+//
+// The other images are cleared and reported to HandleDeviceLost.
+type DeviceLostHandler interface {
+	// HandleDeviceLost is called at the beginning of the next frame after the recovery, before Update.
+	//
+	// lostImages are the images created by NewImage or NewImageFromImage whose contents were lost. The game must
+	// re-fill them, e.g. by ReplacePixels or by drawing them again. lostImages is empty when all the images are
+	// restored.
+	//
+	// The screen is cleared regardless of lostImages. If the screen is not cleared every frame (see
+	// SetScreenClearedEveryFrame), the game should draw the whole screen again at the next Draw.
+	//
+	// If HandleDeviceLost returns an error, RunGame returns the error.
+	HandleDeviceLost(lostImages []*Image) error
+}
+
+// handleDeviceLost recovers the images after the loss of the graphics device, and notifies the game.
+//
+// handleDeviceLost must be called in a frame.
+func (c *uiContext) handleDeviceLost() error {
+	lost, contentsLost := atlas.TakeDeviceLost()
+	if !lost {
+		return nil
+	}
+
+	var lostImages []*Image
+	if contentsLost {
+		imgs := theResources.allImages()
+
+		// Let the packages recreate their internal images. The images disposed by the hooks are not reported.
+		if err := hooks.RunDeviceLostHooks(); err != nil {
+			return err
+		}
+
+		for _, img := range imgs {
+			if img.isDisposed() {
+				continue
+			}
+			if !img.mipmap.RecoverContents() {
+				lostImages = append(lostImages, img)
+			}
+		}
+	}
+
+	theDrawSkip.invalidate()
+
+	c.m.Lock()
+	g := c.game
+	c.m.Unlock()
+	if d, ok := g.(*imageDumperGame); ok {
+		g = d.game
+	}
+	if h, ok := g.(DeviceLostHandler); ok {
+		return h.HandleDeviceLost(lostImages)
+	}
+	return nil
+}
+
+// fromInternalError converts an internal error to an exported error type if possible.
+func fromInternalError(err error) error {
+	var e *driver.DeviceLostError
+	if errors.As(err, &e) {
+		return &DeviceLostError{Err: e.Err}
+	}
+	return err
+}
@@ -60,6 +60,8 @@
 // to take a screenshot. For example, if you run your game with
 // `EBITEN_SCREENSHOT_KEY=q`, you can take a game screen's screenshot
 // by pressing Q key. This works only on desktops.
+// `EBITEN_SCREENSHOT_KEY` is deprecated as of v2.2. Use AddScreenshotHotkey instead, which works on all the platforms
+// and gives the screenshot as an image.Image.
 //
 // `EBITEN_INTERNAL_IMAGES_KEY` environment variable specifies the key
 // to dump all the internal images. This is valid only when the build tag
@@ -70,7 +72,8 @@
 // `ebitendebug` outputs a log of graphics commands. This is useful to know what happens in Ebiten. In general, the
 // number of graphics commands affects the performance of your game.
 //
-// `ebitengl` forces to use OpenGL in any environments.
+// `ebitengl` forces to use OpenGL in any environments. On browsers, WebGPU is used when available, and WebGL is used
+// otherwise. This build tag forces to use WebGL.
 //
 // `ebitenwebgl1` forces to use WebGL 1 on browsers.
 //
@@ -78,4 +81,46 @@
 // to manage threads yourself. Functions like IsKeyPressed will no longer be concurrent-safe with this build tag.
 // They must be called from the main thread or the same goroutine as the given game's callback functions like Update
 // to RunGame.
+//
+// `ebitennokage` removes the shader compiler for Kage. NewShader always returns an error with this build tag. The
+// compiler is about 1MB in a WebAssembly binary. The compiler is already removed when nothing calls NewShader, but
+// this build tag removes it even when NewShader is referenced e.g. by a library that your game uses.
+//
+// `ebitennogamepad` removes the gamepad support on browsers. Gamepads are never detected with this build tag.
+//
+// Packages like audio and text are not included unless your game imports them. You don't need a build tag to remove
+// them.
+//
+// Web Workers
+//
+// On browsers, a game can run in a dedicated worker with an OffscreenCanvas so that heavy frames don't freeze the
+// page. In this case, the page must transfer the canvas and send the size, the focus state and the input events to
+// the worker. For example:
+//
+//     const canvas = document.querySelector('canvas');
+//     const offscreen = canvas.transferControlToOffscreen();
+//     const worker = new Worker('worker.js'); // worker.js runs the game with wasm_exec.js.
+//     const size = () => ({width: canvas.clientWidth, height: canvas.clientHeight, devicePixelRatio: window.devicePixelRatio});
+//     const focused = () => document.hasFocus() && !document.hidden;
+//     worker.postMessage({type: 'ebiten:init', canvas: offscreen, focused: focused(), ...size()}, [offscreen]);
+//     new ResizeObserver(() => worker.postMessage({type: 'ebiten:resize', ...size()})).observe(canvas);
+//     for (const t of ['focus', 'blur', 'visibilitychange']) {
+//         window.addEventListener(t, () => worker.postMessage({type: 'ebiten:focus', focused: focused()}));
+//     }
+//     for (const t of ['keydown', 'keypress', 'keyup', 'mousedown', 'mouseup', 'mousemove', 'wheel', 'touchstart', 'touchend', 'touchmove']) {
+//         canvas.addEventListener(t, (e) => {
+//             // Don't call preventDefault for keydown, or keypress is not fired.
+//             if (t !== 'keydown') e.preventDefault();
+//             const r = canvas.getBoundingClientRect();
+//             const touches = Array.from(e.targetTouches || [], (t) => ({identifier: t.identifier, clientX: t.clientX - r.left, clientY: t.clientY - r.top}));
+//             worker.postMessage({type: 'ebiten:event', event: {
+//                 type: e.type, code: e.code, keyCode: e.keyCode, charCode: e.charCode, button: e.button,
+//                 clientX: e.clientX - r.left, clientY: e.clientY - r.top, movementX: e.movementX, movementY: e.movementY,
+//                 deltaX: e.deltaX, deltaY: e.deltaY, targetTouches: touches}});
+//         });
+//     }
+//
+// The canvas must be focusable (e.g. tabindex="1") to receive keyboard events.
+//
+// In a worker, gamepads, audio, the cursor mode, the cursor shape and the fullscreen mode are not available.
 package ebiten
@@ -0,0 +1,328 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten
+
+import (
+	"github.com/hajimehoshi/ebiten/v2/internal/affine"
+	"github.com/hajimehoshi/ebiten/v2/internal/driver"
+	"github.com/hajimehoshi/ebiten/v2/internal/graphics"
+	"github.com/hajimehoshi/ebiten/v2/internal/mipmap"
+)
+
+// DrawList is a list of recorded draw commands.
+//
+// Recording draw commands into a DrawList doesn't touch the GPU or the internal command queue, and can be done on
+// any goroutine. This enables to use multiple CPU cores to build draw commands of a complex scene, e.g. one
+// DrawList per layer or per chunk of a map. The recorded commands are executed by SubmitDrawLists in the given
+// order, so the result is deterministic regardless of the timings of the recording goroutines:
+//
+//     var wg sync.WaitGroup
+//     for i := range chunks {
+//         i := i
+//         wg.Add(1)
+//         go func() {
+//             defer wg.Done()
+//             lists[i].Reset()
+//             chunks[i].Record(&lists[i], screen)
+//         }()
+//     }
+//     wg.Wait()
+//     ebiten.SubmitDrawLists(lists...)
+//
+// Successive recorded commands that can be batched are merged into one command at recording.
+//
+// A DrawList must not be used from multiple goroutines at the same time.
+// Images used in a DrawList must not be disposed until the list is submitted.
+//
+// The zero value is an empty list ready to use.
+type DrawList struct {
+	commands []drawListCommand
+	vertices []float32
+	indices  []uint16
+}
+
+type drawListCommand struct {
+	dst           *Image
+	src           *Image
+	colorm        *affine.ColorM
+	mode          driver.CompositeMode
+	filter        driver.Filter
+	address       driver.Address
+	dstRegion     driver.Region
+	srcRegion     driver.Region
+	canSkipMipmap bool
+
+	// vertexStart and vertexEnd are the range of the vertices in the number of floats.
+	vertexStart int
+	vertexEnd   int
+
+	// indexStart and indexEnd are the range of the indices.
+	// The indices are relative to the first vertex of the command.
+	indexStart int
+	indexEnd   int
+}
+
+func (c *drawListCommand) canMerge(other *drawListCommand, vertexNum, indexNum int) bool {
+	if c.dst != other.dst || c.src.mipmap != other.src.mipmap {
+		return false
+	}
+	if c.colorm != other.colorm || c.mode != other.mode || c.filter != other.filter || c.address != other.address {
+		return false
+	}
+	if c.srcRegion != other.srcRegion || c.canSkipMipmap != other.canSkipMipmap {
+		return false
+	}
+	if (c.vertexEnd-c.vertexStart)/graphics.VertexFloatNum+vertexNum > 1<<16 {
+		return false
+	}
+	if c.indexEnd-c.indexStart+indexNum > graphics.IndicesNum {
+		return false
+	}
+	return true
+}
+
+// Reset empties the list while keeping the allocated memory.
+func (l *DrawList) Reset() {
+	for i := range l.commands {
+		// Release the references to the images.
+		l.commands[i] = drawListCommand{}
+	}
+	l.commands = l.commands[:0]
+	l.vertices = l.vertices[:0]
+	l.indices = l.indices[:0]
+}
+
+// Len returns the number of the commands in the list after merging.
+func (l *DrawList) Len() int {
+	return len(l.commands)
+}
+
+// DrawImage records a command to draw img on dst in the same way as dst.DrawImage(img, options).
+//
+// When img is disposed, DrawImage panics.
+// When dst is disposed, DrawImage does nothing.
+func (l *DrawList) DrawImage(dst *Image, img *Image, options *DrawImageOptions) {
+	dst.copyCheck()
+
+	if img.isDisposed() {
+		panic("ebiten: the given image to DrawList.DrawImage must not be disposed")
+	}
+	if dst == img {
+		panic("ebiten: the destination image must be different from the source image at DrawList.DrawImage")
+	}
+	if dst.isDisposed() {
+		return
+	}
+
+	if options == nil {
+		options = &DrawImageOptions{}
+	}
+
+	b := img.Bounds()
+	geoM := options.GeoM
+	if s := img.textureScale(); s != 1 {
+		// See the comment at (*Image).drawImage.
+		b = scaleRect(b, s)
+		var g GeoM
+		g.Scale(1/s, 1/s)
+		g.Concat(geoM)
+		geoM = g
+	}
+
+	c := drawListCommand{
+		dst:           dst,
+		src:           img,
+		colorm:        options.ColorM.impl,
+		mode:          driver.CompositeMode(options.CompositeMode),
+		filter:        driver.Filter(options.Filter),
+		address:       driver.AddressUnsafe,
+		dstRegion:     dstRegionOf(dst),
+		canSkipMipmap: canSkipMipmap(geoM, driver.Filter(options.Filter)),
+	}
+
+	a, b0, c0, d, tx, ty := geoM.elements32()
+	vs := l.appendVertices(4)
+	graphics.PutQuadVertices(vs, float32(b.Min.X), float32(b.Min.Y), float32(b.Max.X), float32(b.Max.Y), a, b0, c0, d, tx, ty, 1, 1, 1, 1)
+	l.addCommand(&c, 4, graphics.QuadIndices())
+}
+
+// DrawTriangles records a command to draw triangles on dst in the same way as
+// dst.DrawTriangles(vertices, indices, img, options).
+//
+// DrawTriangles doesn't retain vertices and indices after returning.
+//
+// If len(indices) is not multiple of 3, DrawTriangles panics.
+// If len(indices) is more than MaxIndicesNum, DrawTriangles panics.
+// When img is disposed, DrawTriangles panics.
+// When dst is disposed, DrawTriangles does nothing.
+func (l *DrawList) DrawTriangles(dst *Image, vertices []Vertex, indices []uint16, img *Image, options *DrawTrianglesOptions) {
+	dst.copyCheck()
+
+	if img.isDisposed() {
+		panic("ebiten: the given image to DrawList.DrawTriangles must not be disposed")
+	}
+	if dst == img {
+		panic("ebiten: the destination image must be different from the source image at DrawList.DrawTriangles")
+	}
+	if dst.isDisposed() {
+		return
+	}
+	if len(indices)%3 != 0 {
+		panic("ebiten: len(indices) % 3 must be 0")
+	}
+	if len(indices) > MaxIndicesNum {
+		panic("ebiten: len(indices) must be <= MaxIndicesNum")
+	}
+
+	if options == nil {
+		options = &DrawTrianglesOptions{}
+	}
+
+	address := driver.Address(options.Address)
+	var sr driver.Region
+	if address != driver.AddressUnsafe {
+		b := img.Bounds()
+		sr = driver.Region{
+			X:      float32(b.Min.X),
+			Y:      float32(b.Min.Y),
+			Width:  float32(b.Dx()),
+			Height: float32(b.Dy()),
+		}
+	}
+
+	c := drawListCommand{
+		dst:       dst,
+		src:       img,
+		colorm:    options.ColorM.impl,
+		mode:      driver.CompositeMode(options.CompositeMode),
+		filter:    driver.Filter(options.Filter),
+		address:   address,
+		dstRegion: dstRegionOf(dst),
+		srcRegion: sr,
+	}
+
+	vs := l.appendVertices(len(vertices))
+	for i, v := range vertices {
+		vs[i*graphics.VertexFloatNum] = v.DstX
+		vs[i*graphics.VertexFloatNum+1] = v.DstY
+		vs[i*graphics.VertexFloatNum+2] = v.SrcX
+		vs[i*graphics.VertexFloatNum+3] = v.SrcY
+		vs[i*graphics.VertexFloatNum+4] = v.ColorR
+		vs[i*graphics.VertexFloatNum+5] = v.ColorG
+		vs[i*graphics.VertexFloatNum+6] = v.ColorB
+		vs[i*graphics.VertexFloatNum+7] = v.ColorA
+	}
+	l.addCommand(&c, len(vertices), indices)
+}
+
+// appendVertices extends the vertices by n vertices and returns the extended part.
+func (l *DrawList) appendVertices(n int) []float32 {
+	s := len(l.vertices)
+	need := s + n*graphics.VertexFloatNum
+	if cap(l.vertices) < need {
+		vs := make([]float32, s, 2*need)
+		copy(vs, l.vertices)
+		l.vertices = vs
+	}
+	l.vertices = l.vertices[:need]
+	return l.vertices[s:]
+}
+
+// addCommand adds the command whose vertexNum vertices are already appended by appendVertices.
+func (l *DrawList) addCommand(c *drawListCommand, vertexNum int, indices []uint16) {
+	vs := l.vertices[len(l.vertices)-vertexNum*graphics.VertexFloatNum:]
+
+	// Apply a scale-only color matrix to the vertices so that the command can be merged with the others.
+	// This is the same as what the internal package does.
+	if c.colorm != nil && c.colorm.ScaleOnly() {
+		body, _ := c.colorm.UnsafeElements()
+		cr := body[0]
+		cg := body[5]
+		cb := body[10]
+		ca := body[15]
+		c.colorm = nil
+		const n = graphics.VertexFloatNum
+		for i := 0; i < len(vs)/n; i++ {
+			vs[i*n+4] *= cr
+			vs[i*n+5] *= cg
+			vs[i*n+6] *= cb
+			vs[i*n+7] *= ca
+		}
+	}
+
+	if len(l.commands) > 0 {
+		last := &l.commands[len(l.commands)-1]
+		if last.canMerge(c, vertexNum, len(indices)) {
+			base := uint16((last.vertexEnd - last.vertexStart) / graphics.VertexFloatNum)
+			for _, idx := range indices {
+				l.indices = append(l.indices, base+idx)
+			}
+			last.vertexEnd = len(l.vertices)
+			last.indexEnd = len(l.indices)
+			return
+		}
+	}
+
+	c.vertexStart = len(l.vertices) - vertexNum*graphics.VertexFloatNum
+	c.vertexEnd = len(l.vertices)
+	c.indexStart = len(l.indices)
+	l.indices = append(l.indices, indices...)
+	c.indexEnd = len(l.indices)
+	l.commands = append(l.commands, *c)
+}
+
+// SubmitDrawLists executes the recorded commands of the given lists in the given order.
+//
+// SubmitDrawLists doesn't reset the lists. The same lists can be submitted again, e.g. for a static layer that
+// doesn't change every frame.
+//
+// SubmitDrawLists must not be called while any of the lists is being recorded.
+//
+// When a source image of a command is disposed, SubmitDrawLists panics.
+// Commands whose destination images are disposed are skipped.
+func SubmitDrawLists(lists ...*DrawList) {
+	for _, l := range lists {
+		for i := range l.commands {
+			c := &l.commands[i]
+			if c.src.isDisposed() {
+				panic("ebiten: the source image of a command in a DrawList must not be disposed")
+			}
+			if c.dst.isDisposed() {
+				continue
+			}
+
+			// Copy the vertices and the indices since the internal packages might modify or retain them.
+			vs := graphics.Vertices((c.vertexEnd - c.vertexStart) / graphics.VertexFloatNum)
+			copy(vs, l.vertices[c.vertexStart:c.vertexEnd])
+			is := graphics.Indices(c.indexEnd - c.indexStart)
+			copy(is, l.indices[c.indexStart:c.indexEnd])
+
+			dstRegion := c.dst.applyRenderScale(vs, c.dstRegion)
+
+			srcs := [graphics.ShaderImageNum]*mipmap.Mipmap{c.src.mipmap}
+			c.dst.mipmap.DrawTriangles(srcs, vs, is, c.colorm, c.mode, c.filter, c.address, dstRegion, c.srcRegion, [graphics.ShaderImageNum - 1][2]float32{}, nil, nil, c.canSkipMipmap)
+		}
+	}
+}
+
+func dstRegionOf(img *Image) driver.Region {
+	b := img.Bounds()
+	return driver.Region{
+		X:      float32(b.Min.X),
+		Y:      float32(b.Min.Y),
+		Width:  float32(b.Dx()),
+		Height: float32(b.Dy()),
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten_test
+
+import (
+	"image"
+	"image/color"
+	"sync"
+	"testing"
+
+	. "github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestDrawList(t *testing.T) {
+	const (
+		w = 16
+		h = 16
+	)
+	src := NewImage(w, h)
+	pix := make([]byte, 4*w*h)
+	for j := 0; j < h; j++ {
+		for i := 0; i < w; i++ {
+			idx := 4 * (i + j*w)
+			pix[idx] = byte(i * 16)
+			pix[idx+1] = byte(j * 16)
+			pix[idx+3] = 0xff
+		}
+	}
+	src.ReplacePixels(pix)
+
+	dst0 := NewImage(w, h)
+	dst1 := NewImage(w, h)
+
+	// draw draws the n-th layer. Later layers overwrite earlier ones.
+	draw := func(n int, draw func(img *Image, op *DrawImageOptions)) {
+		for i := 0; i < 4; i++ {
+			op := &DrawImageOptions{}
+			op.GeoM.Translate(float64(n+i), float64(n*2))
+			op.ColorM.Scale(1, 1, 1, 0.5+float64(i)/8)
+			draw(src.SubImage(image.Rect(i, n, i+8, n+8)).(*Image), op)
+		}
+	}
+
+	const layerNum = 4
+	for n := 0; n < layerNum; n++ {
+		draw(n, func(img *Image, op *DrawImageOptions) {
+			dst0.DrawImage(img, op)
+		})
+	}
+
+	lists := make([]*DrawList, layerNum)
+	var wg sync.WaitGroup
+	for n := 0; n < layerNum; n++ {
+		n := n
+		lists[n] = &DrawList{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			draw(n, func(img *Image, op *DrawImageOptions) {
+				lists[n].DrawImage(dst1, img, op)
+			})
+		}()
+	}
+	wg.Wait()
+
+	for _, l := range lists {
+		// The commands in one layer are merged since only the color scales are different.
+		if got, want := l.Len(), 1; got != want {
+			t.Errorf("l.Len(): got: %d, want: %d", got, want)
+		}
+	}
+	SubmitDrawLists(lists...)
+
+	for j := 0; j < h; j++ {
+		for i := 0; i < w; i++ {
+			got := dst1.At(i, j).(color.RGBA)
+			want := dst0.At(i, j).(color.RGBA)
+			if !sameColors(got, want, 1) {
+				t.Errorf("dst.At(%d, %d): got: %v, want: %v", i, j, got, want)
+			}
+		}
+	}
+}
+
+func TestDrawListRenderScaledSource(t *testing.T) {
+	const (
+		w = 16
+		h = 16
+	)
+	src := newRenderScaledImage(8, 8)
+
+	dst0 := NewImage(w, h)
+	dst1 := NewImage(w, h)
+
+	op := &DrawImageOptions{}
+	op.GeoM.Scale(1.5, 1)
+	op.GeoM.Translate(2, 3)
+	dst0.DrawImage(src, op)
+
+	var l DrawList
+	l.DrawImage(dst1, src, op)
+	SubmitDrawLists(&l)
+
+	for j := 0; j < h; j++ {
+		for i := 0; i < w; i++ {
+			got := dst1.At(i, j)
+			want := dst0.At(i, j)
+			if got != want {
+				t.Errorf("dst.At(%d, %d): got: %v, want: %v", i, j, got, want)
+			}
+		}
+	}
+}
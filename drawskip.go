@@ -0,0 +1,91 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten
+
+import (
+	"sync"
+)
+
+type drawSkip struct {
+	enabled     bool
+	invalidated bool
+
+	m sync.Mutex
+}
+
+var theDrawSkip drawSkip
+
+// SetDrawSkippingEnabled enables or disables skipping Draw.
+//
+// While draw skipping is enabled, Draw is called only in the frames after the screen is invalidated by
+// InvalidateScreen. In the other frames, Draw is not called, no drawing commands are encoded and the screen is
+// not presented, so the last frame is kept shown. Update is still called at the current TPS.
+//
+// The screen is also invalidated by Ebiten when the screen must be redrawn e.g. by resizing the window.
+// The screen given to Draw is never cleared regardless of IsScreenClearedEveryFrame.
+//
+// On Android and iOS, the last screen is presented every frame, but Draw is still skipped.
+//
+// Draw skipping is disabled by default.
+//
+// SetDrawSkippingEnabled is concurrent-safe.
+func SetDrawSkippingEnabled(enabled bool) {
+	theDrawSkip.m.Lock()
+	theDrawSkip.enabled = enabled
+	theDrawSkip.invalidated = true
+	theDrawSkip.m.Unlock()
+
+	theUIContext.setScreenClearedEveryFrame(IsScreenClearedEveryFrame())
+}
+
+// IsDrawSkippingEnabled reports whether draw skipping is enabled.
+//
+// IsDrawSkippingEnabled is concurrent-safe.
+func IsDrawSkippingEnabled() bool {
+	theDrawSkip.m.Lock()
+	defer theDrawSkip.m.Unlock()
+	return theDrawSkip.enabled
+}
+
+// InvalidateScreen declares that the scene is changed and makes Draw called in the next frame.
+//
+// InvalidateScreen is useful only when draw skipping is enabled.
+//
+// InvalidateScreen is concurrent-safe.
+func InvalidateScreen() {
+	theDrawSkip.invalidate()
+}
+
+func isOffscreenClearedEveryFrame() bool {
+	return IsScreenClearedEveryFrame() && !IsDrawSkippingEnabled()
+}
+
+func (d *drawSkip) invalidate() {
+	d.m.Lock()
+	defer d.m.Unlock()
+	d.invalidated = true
+}
+
+// needsToDraw reports whether Draw needs to be called in the current frame, and resets the invalidated state.
+func (d *drawSkip) needsToDraw() bool {
+	d.m.Lock()
+	defer d.m.Unlock()
+	if !d.enabled {
+		return true
+	}
+	v := d.invalidated
+	d.invalidated = false
+	return v
+}
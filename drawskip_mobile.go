@@ -0,0 +1,23 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build android || ios
+// +build android ios
+
+package ebiten
+
+// canSkipPresent indicates whether presenting the screen can be skipped while keeping the last presented frame.
+//
+// On mobiles, the platform presents the back buffer after every frame, so the screen must always be redrawn.
+const canSkipPresent = false
@@ -0,0 +1,39 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten
+
+// DroppedFile represents a file dropped onto the screen.
+type DroppedFile struct {
+	// Name is the name of the file without the directory.
+	Name string
+
+	// Type is the MIME type of the file e.g. "image/png". Type is empty if the type is unknown.
+	Type string
+
+	// Data is the content of the file.
+	Data []byte
+}
+
+// DroppedFiles returns the files dropped onto the screen in the current tick on browsers.
+//
+// Reading a dropped file is asynchronous. Then, DroppedFiles might report the files a few ticks after the drop,
+// and the files dropped at the same time might be reported in different ticks.
+//
+// DroppedFiles always returns nil on non-browsers.
+//
+// DroppedFiles must be called from the main thread e.g. in Update.
+func DroppedFiles() []DroppedFile {
+	return droppedFiles()
+}
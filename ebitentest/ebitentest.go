@@ -0,0 +1,185 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ebitentest provides utilities for end-to-end testing of games.
+//
+// A Harness drives a game with a manual clock instead of the real time: Advance calls the game's Update and Draw
+// exactly the given times. Input is injected programmatically, and each frame can be captured as an image.
+// Then, the results are deterministic regardless of the machine's performance.
+//
+// A Harness runs the game in the same way as the Ebiten's main loop: LayoutF, TickUpdater, PipelinedGame,
+// pausing Update, draw skipping and the screen clearing work as well as in RunGame. The DeltaTime of TickInfo is
+// always 1/TPS.
+//
+// A Harness requires the Ebiten's main loop to be running, as the graphics driver is needed to draw images.
+// Use Main in TestMain to run tests in the main loop:
+//
+//     func TestMain(m *testing.M) {
+//         ebitentest.Main(m)
+//     }
+//
+//     func TestGame(t *testing.T) {
+//         h := ebitentest.NewHarness(NewGame(), 640, 480)
+//         defer h.Close()
+//
+//         h.PressKey(ebiten.KeySpace)
+//         if err := h.Advance(60); err != nil {
+//             t.Fatal(err)
+//         }
+//         img, err := h.Screen()
+//         ...
+//     }
+//
+// The graphics driver still requires a display. On CI without a display, use a virtual display like Xvfb.
+package ebitentest
+
+import (
+	"errors"
+	"image"
+	"os"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/internal/testhook"
+)
+
+var regularTermination = errors.New("regular termination")
+
+type mainGame struct {
+	m    *testing.M
+	code int
+}
+
+func (g *mainGame) Update() error {
+	g.code = g.m.Run()
+	return regularTermination
+}
+
+func (*mainGame) Draw(*ebiten.Image) {
+}
+
+func (*mainGame) Layout(int, int) (int, int) {
+	return 320, 240
+}
+
+// Main runs the tests in the Ebiten's main loop, and exits with the tests' result.
+//
+// Main must be called from TestMain.
+func Main(m *testing.M) {
+	g := &mainGame{
+		m: m,
+	}
+	if err := ebiten.RunGame(g); err != nil && err != regularTermination {
+		panic(err)
+	}
+	os.Exit(g.code)
+}
+
+// Harness drives a game with a manual clock and injected input.
+//
+// Only one Harness can be active at the same time, since the input is overridden globally.
+type Harness struct {
+	runner  testhook.Runner
+	input   *input
+	tick    int
+	onFrame func(tick int, screen image.Image)
+	closed  bool
+}
+
+// NewHarness creates a new Harness to drive the game with the given outside size, which is passed to the game's
+// Layout.
+//
+// NewHarness overrides the input functions like ebiten.IsKeyPressed with the injected input until Close is called.
+// NewHarness panics if another Harness is active.
+func NewHarness(game ebiten.Game, outsideWidth, outsideHeight int) *Harness {
+	if testhook.Input() != nil {
+		panic("ebitentest: another Harness is active")
+	}
+	h := &Harness{
+		runner: testhook.NewRunner(game),
+		input:  newInput(),
+	}
+	h.runner.SetOutsideSize(float64(outsideWidth), float64(outsideHeight))
+	testhook.SetInput(h.input)
+	return h
+}
+
+// Close deactivates the harness and restores the input.
+func (h *Harness) Close() {
+	if h.closed {
+		return
+	}
+	h.closed = true
+	testhook.SetInput(nil)
+	h.runner.Dispose()
+}
+
+// Tick returns the number of the ticks advanced so far.
+func (h *Harness) Tick() int {
+	return h.tick
+}
+
+// SetFrameHandler sets the function called with the screen image after every Draw.
+// Capturing the screen reads pixels from GPU, so the handler makes Advance slow.
+// If f is nil, the handler is unset.
+func (h *Harness) SetFrameHandler(f func(tick int, screen image.Image)) {
+	h.onFrame = f
+}
+
+// Advance advances the game by the given number of ticks.
+// In each tick, the game's Update and then the game's Draw are called once, unless Update is paused by
+// ebiten.SetUpdatePaused or Draw is skipped by ebiten.SetDrawSkippingEnabled.
+//
+// Advance returns the error returned by the game's Update.
+func (h *Harness) Advance(ticks int) error {
+	if h.closed {
+		panic("ebitentest: the harness is already closed")
+	}
+	for i := 0; i < ticks; i++ {
+		if err := h.advance(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *Harness) advance() error {
+	if err := h.runner.Advance(); err != nil {
+		return err
+	}
+	h.tick++
+
+	if h.onFrame != nil {
+		img, err := h.Screen()
+		if err != nil {
+			return err
+		}
+		h.onFrame(h.tick, img)
+	}
+	return nil
+}
+
+// Screen returns the screen image drawn at the last tick.
+// Screen returns nil if no tick is advanced yet.
+func (h *Harness) Screen() (image.Image, error) {
+	screen := h.runner.Screen()
+	if screen == nil {
+		return nil, nil
+	}
+	img, err := testhook.ReadPixels(screen)
+	if err != nil {
+		return nil, err
+	}
+	return img, nil
+}
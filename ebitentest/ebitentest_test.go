@@ -0,0 +1,604 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebitentest_test
+
+import (
+	"image"
+	"image/color"
+	"testing"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitentest"
+)
+
+func TestMain(m *testing.M) {
+	ebitentest.Main(m)
+}
+
+type testGame struct {
+	updateCount int
+	drawCount   int
+	ticks       []ebiten.TickInfo
+	keyPressed  []bool
+	runes       [][]rune
+	fillColor   color.Color
+}
+
+func (g *testGame) Update() error {
+	g.updateCount++
+	g.keyPressed = append(g.keyPressed, ebiten.IsKeyPressed(ebiten.KeySpace))
+	g.runes = append(g.runes, ebiten.InputChars())
+	return nil
+}
+
+func (g *testGame) Draw(screen *ebiten.Image) {
+	g.drawCount++
+	if g.fillColor != nil {
+		screen.Fill(g.fillColor)
+	}
+}
+
+func (g *testGame) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return outsideWidth / 2, outsideHeight / 2
+}
+
+type tickGame struct {
+	testGame
+}
+
+func (g *tickGame) UpdateWithTick(info ebiten.TickInfo) error {
+	g.ticks = append(g.ticks, info)
+	return g.Update()
+}
+
+type layoutFGame struct {
+	testGame
+	width  float64
+	height float64
+}
+
+func (g *layoutFGame) LayoutF(outsideWidth, outsideHeight float64) (float64, float64) {
+	return g.width, g.height
+}
+
+type pipelinedGame struct {
+	testGame
+	panicAt int
+	drawn   []int
+}
+
+func (g *pipelinedGame) Update() error {
+	if g.updateCount+1 == g.panicAt {
+		panic("pipelined panic")
+	}
+	return g.testGame.Update()
+}
+
+func (g *pipelinedGame) Snapshot() interface{} {
+	return g.updateCount
+}
+
+func (g *pipelinedGame) DrawSnapshot(screen *ebiten.Image, snapshot interface{}) {
+	g.drawn = append(g.drawn, snapshot.(int))
+}
+
+type pipelinedTickGame struct {
+	pipelinedGame
+}
+
+func (g *pipelinedTickGame) UpdateWithTick(info ebiten.TickInfo) error {
+	g.ticks = append(g.ticks, info)
+	return g.Update()
+}
+
+type countingMiddleware struct {
+	updateCount int
+	drawCount   int
+}
+
+func (m *countingMiddleware) Update(next func() error) error {
+	m.updateCount++
+	return next()
+}
+
+func (m *countingMiddleware) Draw(screen *ebiten.Image, next func(screen *ebiten.Image)) {
+	m.drawCount++
+	next(screen)
+}
+
+func TestHarnessAdvance(t *testing.T) {
+	g := &testGame{}
+	h := ebitentest.NewHarness(g, 320, 240)
+	defer h.Close()
+
+	if err := h.Advance(3); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := g.updateCount, 3; got != want {
+		t.Errorf("updateCount: got: %d, want: %d", got, want)
+	}
+	if got, want := g.drawCount, 3; got != want {
+		t.Errorf("drawCount: got: %d, want: %d", got, want)
+	}
+	if got, want := h.Tick(), 3; got != want {
+		t.Errorf("Tick(): got: %d, want: %d", got, want)
+	}
+}
+
+func TestHarnessTickUpdater(t *testing.T) {
+	g := &tickGame{}
+	h := ebitentest.NewHarness(g, 320, 240)
+	defer h.Close()
+
+	if err := h.Advance(3); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(g.ticks), 3; got != want {
+		t.Fatalf("len(ticks): got: %d, want: %d", got, want)
+	}
+	step := time.Second / time.Duration(ebiten.MaxTPS())
+	for i, info := range g.ticks {
+		want := ebiten.TickInfo{
+			Tick:      int64(i),
+			DeltaTime: step,
+		}
+		if i == 0 {
+			want.DeltaTime = 0
+		}
+		if info != want {
+			t.Errorf("ticks[%d]: got: %+v, want: %+v", i, info, want)
+		}
+	}
+}
+
+func TestHarnessPipelined(t *testing.T) {
+	ebiten.SetPipelinedUpdateEnabled(true)
+	defer ebiten.SetPipelinedUpdateEnabled(false)
+
+	g := &pipelinedTickGame{}
+	h := ebitentest.NewHarness(g, 320, 240)
+	defer h.Close()
+
+	h.PressKey(ebiten.KeySpace)
+	if err := h.Advance(2); err != nil {
+		t.Fatal(err)
+	}
+	h.ReleaseKey(ebiten.KeySpace)
+	if err := h.Advance(2); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := g.updateCount, 4; got != want {
+		t.Errorf("updateCount: got: %d, want: %d", got, want)
+	}
+	if got, want := g.drawCount, 0; got != want {
+		t.Errorf("drawCount: got: %d, want: %d", got, want)
+	}
+	// The first frame draws the snapshot taken synchronously. After that, the drawn frames are one frame behind.
+	if got, want := g.drawn, []int{1, 1, 2, 3}; !equalInts(got, want) {
+		t.Errorf("drawn: got: %v, want: %v", got, want)
+	}
+	// Update running on another goroutine sees the input of its tick.
+	if got, want := g.keyPressed, []bool{true, true, false, false}; !equalBools(got, want) {
+		t.Errorf("keyPressed: got: %v, want: %v", got, want)
+	}
+	if got, want := len(g.ticks), 4; got != want {
+		t.Fatalf("len(ticks): got: %d, want: %d", got, want)
+	}
+	for i, info := range g.ticks {
+		if got, want := info.Tick, int64(i); got != want {
+			t.Errorf("ticks[%d].Tick: got: %d, want: %d", i, got, want)
+		}
+		if info.CatchUp {
+			t.Errorf("ticks[%d].CatchUp: got: true, want: false", i)
+		}
+	}
+}
+
+func TestHarnessPipelinedPanic(t *testing.T) {
+	ebiten.SetPipelinedUpdateEnabled(true)
+	defer ebiten.SetPipelinedUpdateEnabled(false)
+
+	g := &pipelinedGame{
+		panicAt: 2,
+	}
+	h := ebitentest.NewHarness(g, 320, 240)
+	defer h.Close()
+
+	if err := h.Advance(1); err != nil {
+		t.Fatal(err)
+	}
+
+	// The panic in Update on another goroutine is forwarded to the caller of the main loop.
+	defer func() {
+		if got, want := recover(), "pipelined panic"; got != want {
+			t.Errorf("recover(): got: %v, want: %v", got, want)
+		}
+	}()
+	h.Advance(1)
+	t.Errorf("Advance must panic")
+}
+
+func TestHarnessUpdatePaused(t *testing.T) {
+	ebiten.SetUpdatePaused(true)
+	defer ebiten.SetUpdatePaused(false)
+
+	g := &testGame{}
+	h := ebitentest.NewHarness(g, 320, 240)
+	defer h.Close()
+
+	// The first Update is called even while Update is paused.
+	if err := h.Advance(3); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := g.updateCount, 1; got != want {
+		t.Errorf("updateCount: got: %d, want: %d", got, want)
+	}
+	if got, want := g.drawCount, 3; got != want {
+		t.Errorf("drawCount: got: %d, want: %d", got, want)
+	}
+
+	// Each step advances exactly one tick.
+	ebiten.StepUpdate()
+	ebiten.StepUpdate()
+	if err := h.Advance(3); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := g.updateCount, 3; got != want {
+		t.Errorf("updateCount: got: %d, want: %d", got, want)
+	}
+	if got, want := g.drawCount, 6; got != want {
+		t.Errorf("drawCount: got: %d, want: %d", got, want)
+	}
+
+	ebiten.SetUpdatePaused(false)
+	if err := h.Advance(2); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := g.updateCount, 5; got != want {
+		t.Errorf("updateCount: got: %d, want: %d", got, want)
+	}
+}
+
+func TestHarnessUpdatePauseKeys(t *testing.T) {
+	ebiten.SetUpdatePauseKeys(ebiten.KeyP, ebiten.KeyN)
+	defer ebiten.ResetUpdatePauseKeys()
+	defer ebiten.SetUpdatePaused(false)
+
+	g := &testGame{}
+	h := ebitentest.NewHarness(g, 320, 240)
+	defer h.Close()
+
+	if err := h.Advance(1); err != nil {
+		t.Fatal(err)
+	}
+
+	// Pressing the pause key pauses Update. Holding the key doesn't toggle the state again.
+	h.PressKey(ebiten.KeyP)
+	if err := h.Advance(2); err != nil {
+		t.Fatal(err)
+	}
+	h.ReleaseKey(ebiten.KeyP)
+	if !ebiten.IsUpdatePaused() {
+		t.Errorf("IsUpdatePaused: got: false, want: true")
+	}
+	if got, want := g.updateCount, 1; got != want {
+		t.Errorf("updateCount: got: %d, want: %d", got, want)
+	}
+
+	// Pressing the step key advances one tick.
+	h.PressKey(ebiten.KeyN)
+	if err := h.Advance(2); err != nil {
+		t.Fatal(err)
+	}
+	h.ReleaseKey(ebiten.KeyN)
+	if got, want := g.updateCount, 2; got != want {
+		t.Errorf("updateCount: got: %d, want: %d", got, want)
+	}
+
+	// Pressing the pause key again resumes Update.
+	h.PressKey(ebiten.KeyP)
+	if err := h.Advance(1); err != nil {
+		t.Fatal(err)
+	}
+	h.ReleaseKey(ebiten.KeyP)
+	if err := h.Advance(1); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := g.updateCount, 4; got != want {
+		t.Errorf("updateCount: got: %d, want: %d", got, want)
+	}
+}
+
+func TestHarnessTickUpdaterCount(t *testing.T) {
+	ebiten.SetUpdatePaused(true)
+	defer ebiten.SetUpdatePaused(false)
+
+	g := &tickGame{}
+	h := ebitentest.NewHarness(g, 320, 240)
+	defer h.Close()
+
+	// The tick number counts the Update calls, not the frames.
+	if err := h.Advance(3); err != nil {
+		t.Fatal(err)
+	}
+	ebiten.StepUpdate()
+	if err := h.Advance(2); err != nil {
+		t.Fatal(err)
+	}
+	ebiten.SetUpdatePaused(false)
+	if err := h.Advance(2); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := h.Tick(), 7; got != want {
+		t.Errorf("Tick(): got: %d, want: %d", got, want)
+	}
+	if got, want := len(g.ticks), 4; got != want {
+		t.Fatalf("len(ticks): got: %d, want: %d", got, want)
+	}
+	for i, info := range g.ticks {
+		if got, want := info.Tick, int64(i); got != want {
+			t.Errorf("ticks[%d].Tick: got: %d, want: %d", i, got, want)
+		}
+		if info.CatchUp {
+			t.Errorf("ticks[%d].CatchUp: got: true, want: false", i)
+		}
+	}
+}
+
+func TestHarnessMiddleware(t *testing.T) {
+	g := &testGame{}
+	m := &countingMiddleware{}
+	h := ebitentest.NewHarness(ebiten.WrapGame(g, m), 320, 240)
+	defer h.Close()
+
+	if err := h.Advance(2); err != nil {
+		t.Fatal(err)
+	}
+	if m.updateCount != 2 || m.drawCount != 2 {
+		t.Errorf("middleware: got: (%d, %d), want: (2, 2)", m.updateCount, m.drawCount)
+	}
+	if g.updateCount != 2 || g.drawCount != 2 {
+		t.Errorf("game: got: (%d, %d), want: (2, 2)", g.updateCount, g.drawCount)
+	}
+}
+
+func TestHarnessLayout(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Game   ebiten.Game
+		Width  int
+		Height int
+	}{
+		{
+			Name:   "Layout",
+			Game:   &testGame{},
+			Width:  160,
+			Height: 120,
+		},
+		{
+			Name: "LayoutF",
+			Game: &layoutFGame{
+				width:  30,
+				height: 20,
+			},
+			Width:  30,
+			Height: 20,
+		},
+		{
+			// The screen size is rounded up.
+			Name: "LayoutFFractional",
+			Game: &layoutFGame{
+				width:  30.5,
+				height: 20.25,
+			},
+			Width:  31,
+			Height: 21,
+		},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			h := ebitentest.NewHarness(c.Game, 320, 240)
+			defer h.Close()
+
+			img, err := h.Screen()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if img != nil {
+				t.Errorf("Screen() before Advance: got: %v, want: nil", img.Bounds())
+			}
+
+			if err := h.Advance(1); err != nil {
+				t.Fatal(err)
+			}
+			img, err = h.Screen()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := img.Bounds().Size(), image.Pt(c.Width, c.Height); got != want {
+				t.Errorf("got: %v, want: %v", got, want)
+			}
+		})
+	}
+}
+
+func TestHarnessLayoutZero(t *testing.T) {
+	h := ebitentest.NewHarness(&layoutFGame{}, 320, 240)
+	defer h.Close()
+
+	defer func() {
+		if got, want := recover(), "ebiten: Layout must return positive numbers"; got != want {
+			t.Errorf("recover(): got: %v, want: %v", got, want)
+		}
+	}()
+	h.Advance(1)
+	t.Errorf("Advance must panic")
+}
+
+func TestHarnessInput(t *testing.T) {
+	g := &testGame{}
+	h := ebitentest.NewHarness(g, 320, 240)
+	defer h.Close()
+
+	h.PressKey(ebiten.KeySpace)
+	h.InputChars('a', 'b')
+	if err := h.Advance(2); err != nil {
+		t.Fatal(err)
+	}
+	h.ReleaseKey(ebiten.KeySpace)
+	if err := h.Advance(1); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := g.keyPressed, []bool{true, true, false}; !equalBools(got, want) {
+		t.Errorf("keyPressed: got: %v, want: %v", got, want)
+	}
+	// The input characters are reset at the end of each tick.
+	if got, want := string(g.runes[0]), "ab"; got != want {
+		t.Errorf("runes[0]: got: %q, want: %q", got, want)
+	}
+	if got := string(g.runes[1]); got != "" {
+		t.Errorf("runes[1]: got: %q, want: %q", got, "")
+	}
+}
+
+func TestHarnessInputRestored(t *testing.T) {
+	h := ebitentest.NewHarness(&testGame{}, 320, 240)
+	h.PressKey(ebiten.KeySpace)
+	if !ebiten.IsKeyPressed(ebiten.KeySpace) {
+		t.Errorf("IsKeyPressed with a harness: got: false, want: true")
+	}
+	h.Close()
+	if ebiten.IsKeyPressed(ebiten.KeySpace) {
+		t.Errorf("IsKeyPressed after Close: got: true, want: false")
+	}
+
+	// Another harness can be created after Close.
+	ebitentest.NewHarness(&testGame{}, 320, 240).Close()
+}
+
+func TestHarnessScreen(t *testing.T) {
+	clr := color.RGBA{0x80, 0x40, 0x20, 0xff}
+	g := &testGame{
+		fillColor: clr,
+	}
+	h := ebitentest.NewHarness(g, 32, 32)
+	defer h.Close()
+
+	var ticks []int
+	h.SetFrameHandler(func(tick int, screen image.Image) {
+		ticks = append(ticks, tick)
+		if got := color.RGBAModel.Convert(screen.At(0, 0)); got != clr {
+			t.Errorf("tick %d: got: %v, want: %v", tick, got, clr)
+		}
+	})
+	if err := h.Advance(2); err != nil {
+		t.Fatal(err)
+	}
+	if len(ticks) != 2 || ticks[0] != 1 || ticks[1] != 2 {
+		t.Errorf("ticks: got: %v, want: [1 2]", ticks)
+	}
+
+	// The screen is cleared every frame by default.
+	g.fillColor = nil
+	h.SetFrameHandler(nil)
+	if err := h.Advance(1); err != nil {
+		t.Fatal(err)
+	}
+	img, err := h.Screen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := color.RGBAModel.Convert(img.At(0, 0)), (color.RGBA{}); got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
+
+func TestHarnessDrawSkipping(t *testing.T) {
+	ebiten.SetDrawSkippingEnabled(true)
+	defer ebiten.SetDrawSkippingEnabled(false)
+
+	clr := color.RGBA{0x80, 0x40, 0x20, 0xff}
+	g := &testGame{
+		fillColor: clr,
+	}
+	h := ebitentest.NewHarness(g, 32, 32)
+	defer h.Close()
+
+	// The new screen is drawn.
+	if err := h.Advance(1); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := g.drawCount, 1; got != want {
+		t.Errorf("drawCount: got: %d, want: %d", got, want)
+	}
+
+	// Draw is skipped until the screen is invalidated, while Update is still called.
+	g.fillColor = nil
+	if err := h.Advance(2); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := g.updateCount, 3; got != want {
+		t.Errorf("updateCount: got: %d, want: %d", got, want)
+	}
+	if got, want := g.drawCount, 1; got != want {
+		t.Errorf("drawCount: got: %d, want: %d", got, want)
+	}
+
+	ebiten.InvalidateScreen()
+	if err := h.Advance(2); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := g.drawCount, 2; got != want {
+		t.Errorf("drawCount: got: %d, want: %d", got, want)
+	}
+
+	// The screen is not cleared while draw skipping is enabled.
+	img, err := h.Screen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := color.RGBAModel.Convert(img.At(0, 0)); got != clr {
+		t.Errorf("got: %v, want: %v", got, clr)
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func equalBools(a, b []bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
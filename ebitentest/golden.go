@@ -0,0 +1,227 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebitentest
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/internal/testhook"
+)
+
+// DefaultGoldenThreshold is the default threshold of the perceptual color difference.
+const DefaultGoldenThreshold = 0.1
+
+// GoldenOptions represents options for CompareGolden.
+type GoldenOptions struct {
+	// Dir is the directory of the golden files.
+	// If Dir is empty, "testdata" is used.
+	Dir string
+
+	// Threshold is the threshold of the perceptual color difference of a pixel in between 0 and 1.
+	// A pixel whose difference is larger than Threshold is counted as a different pixel.
+	// If Threshold is 0, DefaultGoldenThreshold is used. To require exact matches, specify a negative value.
+	Threshold float64
+
+	// MaxDiffPixels is the number of the different pixels allowed.
+	MaxDiffPixels int
+
+	// PerBackend specifies whether a golden file for the current graphics backend is preferred.
+	// If PerBackend is true, a golden file named name.<backend>.png, e.g. foo.metal.png, is used if it exists,
+	// and name.png is used otherwise.
+	PerBackend bool
+}
+
+// CompareGolden compares img with the golden file name.png, and reports a test failure if they are different.
+//
+// img can be an *ebiten.Image. Then the pixels are read from GPU at once.
+//
+// On failure, CompareGolden writes the actual image as name.actual.png and the difference image as name.diff.png
+// in the same directory as the golden file. In the difference image, the different pixels are red.
+//
+// If the environment variable EBITEN_UPDATE_GOLDEN is set to 1, CompareGolden writes img as the golden file instead
+// of comparing. If PerBackend is true, the file for the current backend is written.
+func CompareGolden(t testing.TB, name string, img image.Image, options *GoldenOptions) {
+	t.Helper()
+
+	var op GoldenOptions
+	if options != nil {
+		op = *options
+	}
+	if op.Dir == "" {
+		op.Dir = "testdata"
+	}
+	if op.Threshold == 0 {
+		op.Threshold = DefaultGoldenThreshold
+	}
+	if op.Threshold < 0 {
+		op.Threshold = 0
+	}
+
+	if eimg, ok := img.(*ebiten.Image); ok {
+		rgba, err := testhook.ReadPixels(eimg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		img = rgba
+	}
+
+	path := filepath.Join(op.Dir, name+".png")
+	var backendPath string
+	if op.PerBackend {
+		backendPath = filepath.Join(op.Dir, name+"."+testhook.GraphicsBackend()+".png")
+	}
+
+	if os.Getenv("EBITEN_UPDATE_GOLDEN") == "1" {
+		p := path
+		if backendPath != "" {
+			p = backendPath
+		}
+		if err := writePNG(p, img); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	if backendPath != "" {
+		if _, err := os.Stat(backendPath); err == nil {
+			path = backendPath
+		}
+	}
+	golden, err := readPNG(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			t.Fatalf("ebitentest: golden file %s doesn't exist. Run the test with EBITEN_UPDATE_GOLDEN=1 to create it", path)
+		}
+		t.Fatal(err)
+	}
+
+	n, diff := Diff(golden, img, op.Threshold)
+	// diff is nil when the sizes are different. Then n is -1, which must not be regarded as a match.
+	if diff != nil && n <= op.MaxDiffPixels {
+		return
+	}
+
+	base := path[:len(path)-len(".png")]
+	if err := writePNG(base+".actual.png", img); err != nil {
+		t.Error(err)
+	}
+	if diff != nil {
+		if err := writePNG(base+".diff.png", diff); err != nil {
+			t.Error(err)
+		}
+	}
+	if diff == nil {
+		t.Errorf("ebitentest: the size differs from the golden %s: got: %v, want: %v", path, img.Bounds().Size(), golden.Bounds().Size())
+		return
+	}
+	t.Errorf("ebitentest: %d pixels differ from the golden %s (allowed: %d). See %s.diff.png", n, path, op.MaxDiffPixels, base)
+}
+
+// Diff compares the two images with the perceptual color difference, and returns the number of the different
+// pixels and the difference image.
+//
+// threshold is in between 0 and 1. A pixel whose difference is larger than threshold is counted as a different
+// pixel. The perceptual difference is based on the YIQ color space, where the colors are blended with white.
+//
+// If the sizes of the images are different, Diff returns -1 and nil.
+func Diff(want, got image.Image, threshold float64) (int, *image.RGBA) {
+	wb := want.Bounds()
+	gb := got.Bounds()
+	if wb.Dx() != gb.Dx() || wb.Dy() != gb.Dy() {
+		return -1, nil
+	}
+
+	// 35215 is the maximum value of the YIQ difference.
+	maxDelta := 35215 * threshold * threshold
+
+	diff := image.NewRGBA(image.Rect(0, 0, wb.Dx(), wb.Dy()))
+	n := 0
+	for j := 0; j < wb.Dy(); j++ {
+		for i := 0; i < wb.Dx(); i++ {
+			c0 := color.RGBAModel.Convert(want.At(wb.Min.X+i, wb.Min.Y+j)).(color.RGBA)
+			c1 := color.RGBAModel.Convert(got.At(gb.Min.X+i, gb.Min.Y+j)).(color.RGBA)
+			if colorDelta(c0, c1) > maxDelta {
+				n++
+				diff.SetRGBA(i, j, color.RGBA{0xff, 0, 0, 0xff})
+				continue
+			}
+			// Show the same pixels as faded gray.
+			y := uint8(0xff - (0xff-yOfColor(c1))/8)
+			diff.SetRGBA(i, j, color.RGBA{y, y, y, 0xff})
+		}
+	}
+	return n, diff
+}
+
+// blendWithWhite blends a premultiplied color with white.
+func blendWithWhite(c color.RGBA) (r, g, b float64) {
+	bg := float64(0xff - c.A)
+	return float64(c.R) + bg, float64(c.G) + bg, float64(c.B) + bg
+}
+
+func yOfColor(c color.RGBA) uint8 {
+	r, g, b := blendWithWhite(c)
+	return uint8(r*0.29889531 + g*0.58662247 + b*0.11448223)
+}
+
+// colorDelta returns the squared YIQ difference of the two colors.
+func colorDelta(c0, c1 color.RGBA) float64 {
+	if c0 == c1 {
+		return 0
+	}
+	r0, g0, b0 := blendWithWhite(c0)
+	r1, g1, b1 := blendWithWhite(c1)
+	dr, dg, db := r0-r1, g0-g1, b0-b1
+
+	y := dr*0.29889531 + dg*0.58662247 + db*0.11448223
+	i := dr*0.59597799 - dg*0.27417610 - db*0.32180189
+	q := dr*0.21147017 - dg*0.52261711 + db*0.31114694
+	return 0.5053*y*y + 0.299*i*i + 0.1957*q*q
+}
+
+func readPNG(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("ebitentest: decoding %s failed: %v", path, err)
+	}
+	return img, nil
+}
+
+func writePNG(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,344 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebitentest_test
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2/ebitentest"
+)
+
+func newFilledImage(w, h int, clr color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for j := 0; j < h; j++ {
+		for i := 0; i < w; i++ {
+			img.SetRGBA(i, j, clr)
+		}
+	}
+	return img
+}
+
+func TestDiff(t *testing.T) {
+	white := color.RGBA{0xff, 0xff, 0xff, 0xff}
+	base := newFilledImage(4, 4, white)
+
+	oneRed := newFilledImage(4, 4, white)
+	oneRed.SetRGBA(1, 2, color.RGBA{0xff, 0, 0, 0xff})
+
+	slightlyDifferent := newFilledImage(4, 4, color.RGBA{0xfe, 0xfe, 0xfe, 0xff})
+
+	// A sub-image whose bounds don't start at (0, 0).
+	offset := newFilledImage(6, 6, color.RGBA{0, 0, 0, 0xff}).SubImage(image.Rect(2, 2, 6, 6)).(*image.RGBA)
+	for j := 2; j < 6; j++ {
+		for i := 2; i < 6; i++ {
+			offset.SetRGBA(i, j, white)
+		}
+	}
+
+	cases := []struct {
+		Name      string
+		Got       image.Image
+		Threshold float64
+		Want      int
+	}{
+		{
+			Name:      "same",
+			Got:       base,
+			Threshold: 0,
+			Want:      0,
+		},
+		{
+			Name:      "one pixel",
+			Got:       oneRed,
+			Threshold: 0.1,
+			Want:      1,
+		},
+		{
+			Name:      "within threshold",
+			Got:       slightlyDifferent,
+			Threshold: 0.1,
+			Want:      0,
+		},
+		{
+			Name:      "exact",
+			Got:       slightlyDifferent,
+			Threshold: 0,
+			Want:      16,
+		},
+		{
+			Name:      "offset bounds",
+			Got:       offset,
+			Threshold: 0,
+			Want:      0,
+		},
+		{
+			Name:      "different size",
+			Got:       newFilledImage(4, 5, white),
+			Threshold: 0.1,
+			Want:      -1,
+		},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			n, diff := ebitentest.Diff(base, c.Got, c.Threshold)
+			if n != c.Want {
+				t.Errorf("got: %d, want: %d", n, c.Want)
+			}
+			if c.Want < 0 {
+				if diff != nil {
+					t.Errorf("the difference image must be nil for different sizes")
+				}
+				return
+			}
+			if got, want := diff.Bounds(), image.Rect(0, 0, 4, 4); got != want {
+				t.Errorf("the bounds of the difference image: got: %v, want: %v", got, want)
+			}
+		})
+	}
+}
+
+func TestDiffImage(t *testing.T) {
+	white := color.RGBA{0xff, 0xff, 0xff, 0xff}
+	want := newFilledImage(2, 1, white)
+	got := newFilledImage(2, 1, white)
+	got.SetRGBA(1, 0, color.RGBA{0, 0, 0, 0xff})
+
+	_, diff := ebitentest.Diff(want, got, 0.1)
+	// The different pixels are red, and the same pixels are gray.
+	if got, want := diff.RGBAAt(1, 0), (color.RGBA{0xff, 0, 0, 0xff}); got != want {
+		t.Errorf("diff.At(1, 0): got: %v, want: %v", got, want)
+	}
+	if c := diff.RGBAAt(0, 0); c.R != c.G || c.G != c.B || c.A != 0xff {
+		t.Errorf("diff.At(0, 0): got: %v, want: an opaque gray", c)
+	}
+}
+
+// recordingTB is a testing.TB that records failures instead of failing the test.
+type recordingTB struct {
+	testing.TB
+	failed   bool
+	messages []string
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Error(args ...interface{}) {
+	r.failed = true
+	r.messages = append(r.messages, fmt.Sprint(args...))
+}
+
+func (r *recordingTB) Errorf(format string, args ...interface{}) {
+	r.failed = true
+	r.messages = append(r.messages, fmt.Sprintf(format, args...))
+}
+
+func (r *recordingTB) Fatal(args ...interface{}) {
+	r.Error(args...)
+	runtime.Goexit()
+}
+
+func (r *recordingTB) Fatalf(format string, args ...interface{}) {
+	r.Errorf(format, args...)
+	runtime.Goexit()
+}
+
+// compareGolden calls CompareGolden and returns the recorded failures.
+func compareGolden(t *testing.T, name string, img image.Image, options *ebitentest.GoldenOptions) *recordingTB {
+	r := &recordingTB{
+		TB: t,
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ebitentest.CompareGolden(r, name, img, options)
+	}()
+	<-done
+	return r
+}
+
+func writeTestPNG(t *testing.T, path string, img image.Image) {
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readTestPNG(t *testing.T, path string) image.Image {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func setUpdateGolden(t *testing.T, update bool) {
+	orig, ok := os.LookupEnv("EBITEN_UPDATE_GOLDEN")
+	if update {
+		os.Setenv("EBITEN_UPDATE_GOLDEN", "1")
+	} else {
+		os.Unsetenv("EBITEN_UPDATE_GOLDEN")
+	}
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv("EBITEN_UPDATE_GOLDEN", orig)
+		} else {
+			os.Unsetenv("EBITEN_UPDATE_GOLDEN")
+		}
+	})
+}
+
+func TestCompareGolden(t *testing.T) {
+	setUpdateGolden(t, false)
+
+	dir := t.TempDir()
+	golden := newFilledImage(4, 4, color.RGBA{0xff, 0xff, 0xff, 0xff})
+	writeTestPNG(t, filepath.Join(dir, "foo.png"), golden)
+
+	r := compareGolden(t, "foo", golden, &ebitentest.GoldenOptions{
+		Dir: dir,
+	})
+	if r.failed {
+		t.Errorf("CompareGolden failed with the same image: %v", r.messages)
+	}
+	if exists(filepath.Join(dir, "foo.actual.png")) || exists(filepath.Join(dir, "foo.diff.png")) {
+		t.Errorf("CompareGolden must not write files when the images match")
+	}
+}
+
+func TestCompareGoldenMismatch(t *testing.T) {
+	setUpdateGolden(t, false)
+
+	dir := t.TempDir()
+	golden := newFilledImage(4, 4, color.RGBA{0xff, 0xff, 0xff, 0xff})
+	writeTestPNG(t, filepath.Join(dir, "foo.png"), golden)
+
+	img := newFilledImage(4, 4, color.RGBA{0xff, 0xff, 0xff, 0xff})
+	img.SetRGBA(0, 0, color.RGBA{0, 0, 0xff, 0xff})
+	img.SetRGBA(3, 3, color.RGBA{0, 0, 0xff, 0xff})
+
+	// Up to MaxDiffPixels pixels are allowed.
+	if r := compareGolden(t, "foo", img, &ebitentest.GoldenOptions{
+		Dir:           dir,
+		MaxDiffPixels: 2,
+	}); r.failed {
+		t.Errorf("CompareGolden failed within MaxDiffPixels: %v", r.messages)
+	}
+
+	r := compareGolden(t, "foo", img, &ebitentest.GoldenOptions{
+		Dir:           dir,
+		MaxDiffPixels: 1,
+	})
+	if !r.failed {
+		t.Fatalf("CompareGolden must fail with different images")
+	}
+	if got, want := strings.Join(r.messages, "\n"), "2 pixels differ"; !strings.Contains(got, want) {
+		t.Errorf("message: got: %q, want: containing %q", got, want)
+	}
+
+	actual := readTestPNG(t, filepath.Join(dir, "foo.actual.png"))
+	if n, _ := ebitentest.Diff(img, actual, 0); n != 0 {
+		t.Errorf("foo.actual.png differs from the actual image")
+	}
+	diff := readTestPNG(t, filepath.Join(dir, "foo.diff.png"))
+	if got, want := color.RGBAModel.Convert(diff.At(3, 3)), (color.RGBA{0xff, 0, 0, 0xff}); got != want {
+		t.Errorf("foo.diff.png At(3, 3): got: %v, want: %v", got, want)
+	}
+}
+
+func TestCompareGoldenSizeMismatch(t *testing.T) {
+	setUpdateGolden(t, false)
+
+	dir := t.TempDir()
+	writeTestPNG(t, filepath.Join(dir, "foo.png"), newFilledImage(4, 4, color.RGBA{0xff, 0xff, 0xff, 0xff}))
+
+	r := compareGolden(t, "foo", newFilledImage(2, 2, color.RGBA{0xff, 0xff, 0xff, 0xff}), &ebitentest.GoldenOptions{
+		Dir: dir,
+	})
+	if !r.failed {
+		t.Fatalf("CompareGolden must fail with a different size")
+	}
+	if got, want := strings.Join(r.messages, "\n"), "the size differs"; !strings.Contains(got, want) {
+		t.Errorf("message: got: %q, want: containing %q", got, want)
+	}
+	if !exists(filepath.Join(dir, "foo.actual.png")) {
+		t.Errorf("foo.actual.png is not written")
+	}
+	if exists(filepath.Join(dir, "foo.diff.png")) {
+		t.Errorf("foo.diff.png must not be written for a different size")
+	}
+}
+
+func TestCompareGoldenMissing(t *testing.T) {
+	setUpdateGolden(t, false)
+
+	r := compareGolden(t, "foo", newFilledImage(1, 1, color.RGBA{}), &ebitentest.GoldenOptions{
+		Dir: t.TempDir(),
+	})
+	if !r.failed {
+		t.Fatalf("CompareGolden must fail without the golden file")
+	}
+	if got, want := strings.Join(r.messages, "\n"), "EBITEN_UPDATE_GOLDEN=1"; !strings.Contains(got, want) {
+		t.Errorf("message: got: %q, want: containing %q", got, want)
+	}
+}
+
+func TestCompareGoldenUpdate(t *testing.T) {
+	setUpdateGolden(t, true)
+
+	// The directory is created if it doesn't exist.
+	dir := filepath.Join(t.TempDir(), "golden")
+	img := newFilledImage(3, 2, color.RGBA{0x80, 0x40, 0, 0xff})
+	if r := compareGolden(t, "foo", img, &ebitentest.GoldenOptions{
+		Dir: dir,
+	}); r.failed {
+		t.Fatalf("CompareGolden failed at updating: %v", r.messages)
+	}
+
+	golden := readTestPNG(t, filepath.Join(dir, "foo.png"))
+	if n, _ := ebitentest.Diff(img, golden, 0); n != 0 {
+		t.Errorf("the updated golden file differs from the image")
+	}
+
+	// The updated golden file is used for comparison.
+	setUpdateGolden(t, false)
+	if r := compareGolden(t, "foo", img, &ebitentest.GoldenOptions{
+		Dir: dir,
+	}); r.failed {
+		t.Errorf("CompareGolden failed with the updated golden file: %v", r.messages)
+	}
+}
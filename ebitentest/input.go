@@ -0,0 +1,316 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebitentest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/internal/driver"
+)
+
+type gamepad struct {
+	buttons map[driver.GamepadButton]bool
+	axes    map[int]float64
+}
+
+type touch struct {
+	x int
+	y int
+}
+
+// input is a simulated input that implements driver.Input.
+type input struct {
+	keys         map[driver.Key]bool
+	mouseButtons map[driver.MouseButton]bool
+	cursorX      int
+	cursorY      int
+	wheelX       float64
+	wheelY       float64
+	runes        []rune
+	touches      map[driver.TouchID]touch
+	gamepads     map[driver.GamepadID]*gamepad
+
+	m sync.Mutex
+}
+
+func newInput() *input {
+	return &input{
+		keys:         map[driver.Key]bool{},
+		mouseButtons: map[driver.MouseButton]bool{},
+		touches:      map[driver.TouchID]touch{},
+		gamepads:     map[driver.GamepadID]*gamepad{},
+	}
+}
+
+// ResetForFrame is called by the ebiten package at the end of each tick.
+func (i *input) ResetForFrame() {
+	i.m.Lock()
+	defer i.m.Unlock()
+	i.wheelX = 0
+	i.wheelY = 0
+	i.runes = nil
+}
+
+func (i *input) CursorPosition() (x, y int) {
+	i.m.Lock()
+	defer i.m.Unlock()
+	return i.cursorX, i.cursorY
+}
+
+func (i *input) GamepadSDLID(id driver.GamepadID) string {
+	return ""
+}
+
+func (i *input) GamepadName(id driver.GamepadID) string {
+	i.m.Lock()
+	defer i.m.Unlock()
+	if _, ok := i.gamepads[id]; !ok {
+		return ""
+	}
+	return "ebitentest"
+}
+
+func (i *input) GamepadAxis(id driver.GamepadID, axis int) float64 {
+	i.m.Lock()
+	defer i.m.Unlock()
+	g, ok := i.gamepads[id]
+	if !ok {
+		return 0
+	}
+	return g.axes[axis]
+}
+
+func (i *input) GamepadAxisNum(id driver.GamepadID) int {
+	i.m.Lock()
+	defer i.m.Unlock()
+	g, ok := i.gamepads[id]
+	if !ok {
+		return 0
+	}
+	n := 0
+	for a := range g.axes {
+		if n < a+1 {
+			n = a + 1
+		}
+	}
+	return n
+}
+
+func (i *input) GamepadButtonNum(id driver.GamepadID) int {
+	i.m.Lock()
+	defer i.m.Unlock()
+	g, ok := i.gamepads[id]
+	if !ok {
+		return 0
+	}
+	n := 0
+	for b := range g.buttons {
+		if n < int(b)+1 {
+			n = int(b) + 1
+		}
+	}
+	return n
+}
+
+func (i *input) GamepadIDs() []driver.GamepadID {
+	i.m.Lock()
+	defer i.m.Unlock()
+	ids := make([]driver.GamepadID, 0, len(i.gamepads))
+	for id := range i.gamepads {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(a, b int) bool {
+		return ids[a] < ids[b]
+	})
+	return ids
+}
+
+func (i *input) IsGamepadButtonPressed(id driver.GamepadID, button driver.GamepadButton) bool {
+	i.m.Lock()
+	defer i.m.Unlock()
+	g, ok := i.gamepads[id]
+	if !ok {
+		return false
+	}
+	return g.buttons[button]
+}
+
+func (i *input) IsKeyPressed(key driver.Key) bool {
+	i.m.Lock()
+	defer i.m.Unlock()
+	return i.keys[key]
+}
+
+func (i *input) IsMouseButtonPressed(button driver.MouseButton) bool {
+	i.m.Lock()
+	defer i.m.Unlock()
+	return i.mouseButtons[button]
+}
+
+func (i *input) RuneBuffer() []rune {
+	i.m.Lock()
+	defer i.m.Unlock()
+	return append([]rune(nil), i.runes...)
+}
+
+func (i *input) TouchIDs() []driver.TouchID {
+	i.m.Lock()
+	defer i.m.Unlock()
+	ids := make([]driver.TouchID, 0, len(i.touches))
+	for id := range i.touches {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(a, b int) bool {
+		return ids[a] < ids[b]
+	})
+	return ids
+}
+
+func (i *input) TouchPosition(id driver.TouchID) (x, y int) {
+	i.m.Lock()
+	defer i.m.Unlock()
+	t := i.touches[id]
+	return t.x, t.y
+}
+
+func (i *input) Wheel() (xoff, yoff float64) {
+	i.m.Lock()
+	defer i.m.Unlock()
+	return i.wheelX, i.wheelY
+}
+
+func (i *input) VibrateGamepad(id driver.GamepadID, duration time.Duration, strongMagnitude float64, weakMagnitude float64) {
+}
+
+func (i *input) gamepad(id driver.GamepadID) *gamepad {
+	g, ok := i.gamepads[id]
+	if !ok {
+		g = &gamepad{
+			buttons: map[driver.GamepadButton]bool{},
+			axes:    map[int]float64{},
+		}
+		i.gamepads[id] = g
+	}
+	return g
+}
+
+// PressKey makes the key pressed until ReleaseKey is called.
+func (h *Harness) PressKey(key ebiten.Key) {
+	h.input.m.Lock()
+	defer h.input.m.Unlock()
+	h.input.keys[driver.Key(key)] = true
+}
+
+// ReleaseKey releases the key.
+func (h *Harness) ReleaseKey(key ebiten.Key) {
+	h.input.m.Lock()
+	defer h.input.m.Unlock()
+	delete(h.input.keys, driver.Key(key))
+}
+
+// PressMouseButton makes the mouse button pressed until ReleaseMouseButton is called.
+func (h *Harness) PressMouseButton(button ebiten.MouseButton) {
+	h.input.m.Lock()
+	defer h.input.m.Unlock()
+	h.input.mouseButtons[driver.MouseButton(button)] = true
+}
+
+// ReleaseMouseButton releases the mouse button.
+func (h *Harness) ReleaseMouseButton(button ebiten.MouseButton) {
+	h.input.m.Lock()
+	defer h.input.m.Unlock()
+	delete(h.input.mouseButtons, driver.MouseButton(button))
+}
+
+// SetCursorPosition sets the cursor position.
+func (h *Harness) SetCursorPosition(x, y int) {
+	h.input.m.Lock()
+	defer h.input.m.Unlock()
+	h.input.cursorX = x
+	h.input.cursorY = y
+}
+
+// SetWheel sets the wheel offsets for the next tick.
+func (h *Harness) SetWheel(xoff, yoff float64) {
+	h.input.m.Lock()
+	defer h.input.m.Unlock()
+	h.input.wheelX = xoff
+	h.input.wheelY = yoff
+}
+
+// InputChars sets the runes returned by ebiten.InputChars for the next tick.
+func (h *Harness) InputChars(runes ...rune) {
+	h.input.m.Lock()
+	defer h.input.m.Unlock()
+	h.input.runes = append(h.input.runes, runes...)
+}
+
+// Touch adds a touch or moves the existing touch of the given ID.
+func (h *Harness) Touch(id ebiten.TouchID, x, y int) {
+	h.input.m.Lock()
+	defer h.input.m.Unlock()
+	h.input.touches[driver.TouchID(id)] = touch{
+		x: x,
+		y: y,
+	}
+}
+
+// ReleaseTouch removes the touch of the given ID.
+func (h *Harness) ReleaseTouch(id ebiten.TouchID) {
+	h.input.m.Lock()
+	defer h.input.m.Unlock()
+	delete(h.input.touches, driver.TouchID(id))
+}
+
+// ConnectGamepad connects a gamepad of the given ID. The gamepad is also connected implicitly by
+// PressGamepadButton or SetGamepadAxis.
+func (h *Harness) ConnectGamepad(id ebiten.GamepadID) {
+	h.input.m.Lock()
+	defer h.input.m.Unlock()
+	h.input.gamepad(driver.GamepadID(id))
+}
+
+// DisconnectGamepad disconnects the gamepad of the given ID.
+func (h *Harness) DisconnectGamepad(id ebiten.GamepadID) {
+	h.input.m.Lock()
+	defer h.input.m.Unlock()
+	delete(h.input.gamepads, driver.GamepadID(id))
+}
+
+// PressGamepadButton makes the gamepad button pressed until ReleaseGamepadButton is called.
+func (h *Harness) PressGamepadButton(id ebiten.GamepadID, button ebiten.GamepadButton) {
+	h.input.m.Lock()
+	defer h.input.m.Unlock()
+	h.input.gamepad(driver.GamepadID(id)).buttons[driver.GamepadButton(button)] = true
+}
+
+// ReleaseGamepadButton releases the gamepad button.
+func (h *Harness) ReleaseGamepadButton(id ebiten.GamepadID, button ebiten.GamepadButton) {
+	h.input.m.Lock()
+	defer h.input.m.Unlock()
+	if g, ok := h.input.gamepads[driver.GamepadID(id)]; ok {
+		delete(g.buttons, driver.GamepadButton(button))
+	}
+}
+
+// SetGamepadAxis sets the value of the gamepad axis. The value is in between -1 and 1.
+func (h *Harness) SetGamepadAxis(id ebiten.GamepadID, axis int, value float64) {
+	h.input.m.Lock()
+	defer h.input.m.Unlock()
+	h.input.gamepad(driver.GamepadID(id)).axes[axis] = value
+}
@@ -15,10 +15,17 @@
 package ebitenutil
 
 import (
+	"fmt"
 	"image"
+	"image/color"
+	"strings"
+
+	"golang.org/x/image/font"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil/internal/assets"
+	"github.com/hajimehoshi/ebiten/v2/internal/hooks"
+	"github.com/hajimehoshi/ebiten/v2/text"
 )
 
 var (
@@ -26,6 +33,25 @@ var (
 	debugPrintTextSubImages = map[rune]*ebiten.Image{}
 )
 
+func init() {
+	// debugPrintTextImage is disposed when the game is restarted by ebiten.RestartGame. Recreate it.
+	hooks.AppendHookOnReset(func() error {
+		initDebugPrintTextImage()
+		return nil
+	})
+	// The content of debugPrintTextImage might be lost when the graphics device is lost. Recreate it.
+	hooks.AppendHookOnDeviceLost(func() error {
+		debugPrintTextImage.Dispose()
+		initDebugPrintTextImage()
+		return nil
+	})
+}
+
+func initDebugPrintTextImage() {
+	debugPrintTextImage = ebiten.NewImageFromImage(assets.CreateTextImage())
+	debugPrintTextSubImages = map[rune]*ebiten.Image{}
+}
+
 // DebugPrint draws the string str on the image on left top corner.
 //
 // The available runes are in U+0000 to U+00FF, which is C0 Controls and Basic Latin and C1 Controls and Latin-1 Supplement.
@@ -37,15 +63,90 @@ func DebugPrint(image *ebiten.Image, str string) {
 //
 // The available runes are in U+0000 to U+00FF, which is C0 Controls and Basic Latin and C1 Controls and Latin-1 Supplement.
 func DebugPrintAt(image *ebiten.Image, str string, x, y int) {
-	drawDebugText(image, str, x+1, y+1, true)
-	drawDebugText(image, str, x, y, false)
+	DebugPrintWithOptions(image, str, x, y, nil)
 }
 
-func drawDebugText(rt *ebiten.Image, str string, ox, oy int, shadow bool) {
-	op := &ebiten.DrawImageOptions{}
-	if shadow {
-		op.ColorM.Scale(0, 0, 0, 0.5)
+// DebugPrintf formats according to a format specifier and draws the result on the image on left top corner.
+func DebugPrintf(image *ebiten.Image, format string, a ...interface{}) {
+	DebugPrintWithOptions(image, fmt.Sprintf(format, a...), 0, 0, nil)
+}
+
+// DebugPrintfAt formats according to a format specifier and draws the result on the image at (x, y) position.
+func DebugPrintfAt(image *ebiten.Image, x, y int, format string, a ...interface{}) {
+	DebugPrintWithOptions(image, fmt.Sprintf(format, a...), x, y, nil)
+}
+
+// DebugPrintOptions represents options for DebugPrintWithOptions.
+type DebugPrintOptions struct {
+	// Color is the color of the text.
+	// If Color is nil, white is used.
+	Color color.Color
+
+	// Scale is the scale of the built-in font.
+	// If Scale is 0 or negative, 1 is used.
+	// Scale is ignored when Face is specified. Use a face of a different size instead.
+	Scale float64
+
+	// BackgroundColor is the color of the box drawn behind the text.
+	// If BackgroundColor is nil, no box is drawn.
+	BackgroundColor color.Color
+
+	// Face is the font face of the text.
+	// If Face is nil, the built-in font is used, whose available runes are in U+0000 to U+00FF.
+	// The text with Face is drawn by the text package.
+	Face font.Face
+}
+
+// DebugPrintWithOptions draws the string str on the image at (x, y) position with the given options.
+// (x, y) is the upper-left position of the text, not the dot position.
+//
+// If options is nil, DebugPrintWithOptions is the same as DebugPrintAt.
+func DebugPrintWithOptions(image *ebiten.Image, str string, x, y int, options *DebugPrintOptions) {
+	if options == nil {
+		options = &DebugPrintOptions{}
+	}
+	clr := options.Color
+	if clr == nil {
+		clr = color.White
+	}
+
+	if options.Face != nil {
+		b := text.BoundString(options.Face, str)
+		m := options.Face.Metrics()
+		// The upper-left position of the first line is the dot position minus the ascent.
+		ascent := m.Ascent.Ceil()
+		if options.BackgroundColor != nil && !b.Empty() {
+			const padding = 2
+			DrawRect(image, float64(x+b.Min.X-padding), float64(y+ascent+b.Min.Y-padding), float64(b.Dx()+2*padding), float64(b.Dy()+2*padding), options.BackgroundColor)
+		}
+		text.Draw(image, str, options.Face, x, y+ascent, clr)
+		return
+	}
+
+	scale := options.Scale
+	if scale <= 0 {
+		scale = 1
+	}
+	if options.BackgroundColor != nil {
+		lines := strings.Split(str, "\n")
+		var w int
+		for _, l := range lines {
+			if n := len([]rune(l)); w < n {
+				w = n
+			}
+		}
+		if w > 0 {
+			const padding = 2
+			DrawRect(image, float64(x), float64(y), (float64(w*assets.CharWidth)+2*padding)*scale, (float64(len(lines)*assets.CharHeight)+padding)*scale, options.BackgroundColor)
+		}
 	}
+	drawDebugText(image, str, float64(x)+scale, float64(y)+scale, scale, color.RGBA{0, 0, 0, 0x80})
+	drawDebugText(image, str, float64(x), float64(y), scale, clr)
+}
+
+func drawDebugText(rt *ebiten.Image, str string, ox, oy float64, scale float64, clr color.Color) {
+	op := &ebiten.DrawImageOptions{}
+	op.ColorM.Scale(colorToScale(clr))
 	x := 0
 	y := 0
 	w, _ := debugPrintTextImage.Size()
@@ -68,8 +169,9 @@ func drawDebugText(rt *ebiten.Image, str string, ox, oy int, shadow bool) {
 			debugPrintTextSubImages[c] = s
 		}
 		op.GeoM.Reset()
-		op.GeoM.Translate(float64(x), float64(y))
-		op.GeoM.Translate(float64(ox+1), float64(oy))
+		op.GeoM.Translate(float64(x+1), float64(y))
+		op.GeoM.Scale(scale, scale)
+		op.GeoM.Translate(ox, oy)
 		rt.DrawImage(s, op)
 		x += cw
 	}
@@ -0,0 +1,313 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package debugserver provides an HTTP server to inspect the engine internals of a running game.
+//
+// The server is opt-in, and is intended to be used only for debugging. The server provides these endpoints:
+//
+//     /            An index page
+//     /images      The list of the images with their sizes and their placements on the texture atlases (JSON)
+//     /drawcalls   The draw calls in the last frame (JSON)
+//     /shaders     The list of the shaders with their GLSL sources (JSON)
+//     /stats       The current statistics like FPS and the frame timings (JSON)
+//     /events      The stream of the statistics every second (Server-Sent Events)
+//     /screenshot  The game screen at the next frame (PNG)
+//
+// As the server exposes the game's internals, the server should not be accessible from untrusted networks.
+// The server doesn't work on browsers.
+package debugserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/internal/atlas"
+	"github.com/hajimehoshi/ebiten/v2/internal/graphicscommand"
+	"github.com/hajimehoshi/ebiten/v2/internal/hooks"
+	"github.com/hajimehoshi/ebiten/v2/internal/shaderir/glsl"
+	"github.com/hajimehoshi/ebiten/v2/internal/stats"
+)
+
+// Server is a debug server.
+type Server struct {
+	server   *http.Server
+	listener net.Listener
+	done     chan struct{}
+}
+
+var (
+	serverNum  int
+	serverNumM sync.Mutex
+)
+
+// Start starts a debug server listening on the given TCP address, e.g. "localhost:8080".
+//
+// While a server is running, the descriptions of the draw calls are recorded, which costs a little.
+func Start(addr string) (*Server, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		listener: l,
+		done:     make(chan struct{}),
+	}
+	s.server = &http.Server{
+		Handler: s.handler(),
+	}
+
+	serverNumM.Lock()
+	serverNum++
+	stats.SetDrawCallLogEnabled(true)
+	serverNumM.Unlock()
+
+	go func() {
+		_ = s.server.Serve(l)
+	}()
+	return s, nil
+}
+
+// Addr returns the address the server is listening on.
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Close stops the server.
+func (s *Server) Close() error {
+	close(s.done)
+
+	serverNumM.Lock()
+	serverNum--
+	if serverNum == 0 {
+		stats.SetDrawCallLogEnabled(false)
+	}
+	serverNumM.Unlock()
+
+	return s.server.Shutdown(context.Background())
+}
+
+func (s *Server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", serveIndex)
+	mux.HandleFunc("/images", serveImages)
+	mux.HandleFunc("/drawcalls", serveDrawCalls)
+	mux.HandleFunc("/shaders", serveShaders)
+	mux.HandleFunc("/stats", serveStats)
+	mux.HandleFunc("/events", s.serveEvents)
+	mux.HandleFunc("/screenshot", serveScreenshot)
+	return mux
+}
+
+const indexHTML = `<!DOCTYPE html>
+<title>Ebiten Debug Server</title>
+<h1>Ebiten Debug Server</h1>
+<ul>
+<li><a href="/images">Images</a>
+<li><a href="/drawcalls">Draw calls</a>
+<li><a href="/shaders">Shaders</a>
+<li><a href="/stats">Stats</a>
+<li><a href="/events">Events</a>
+<li><a href="/screenshot">Screenshot</a>
+</ul>
+`
+
+func serveIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, indexHTML)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	if err := e.Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+type imagesResponse struct {
+	Atlases []atlas.AtlasInfo `json:"atlases"`
+	Images  []atlas.ImageInfo `json:"images"`
+}
+
+func serveImages(w http.ResponseWriter, r *http.Request) {
+	atlases, images := atlas.Infos()
+	writeJSON(w, &imagesResponse{
+		Atlases: atlases,
+		Images:  images,
+	})
+}
+
+func serveDrawCalls(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, stats.LastFrameDrawCallLog())
+}
+
+type shaderResponse struct {
+	ID             int      `json:"id"`
+	Uniforms       []string `json:"uniforms"`
+	TextureNum     int      `json:"textureNum"`
+	VertexShader   string   `json:"vertexShader"`
+	FragmentShader string   `json:"fragmentShader"`
+}
+
+func serveShaders(w http.ResponseWriter, r *http.Request) {
+	shaders := []shaderResponse{}
+	for _, s := range graphicscommand.Shaders() {
+		vs, fs := glsl.Compile(s.Program, glsl.GLSLVersionDefault)
+		shaders = append(shaders, shaderResponse{
+			ID:             s.ID,
+			Uniforms:       s.Program.UniformNames,
+			TextureNum:     s.Program.TextureNum,
+			VertexShader:   vs,
+			FragmentShader: fs,
+		})
+	}
+	writeJSON(w, shaders)
+}
+
+type statsResponse struct {
+	FPS          float64 `json:"fps"`
+	TPS          float64 `json:"tps"`
+	UpdateTime   float64 `json:"updateTimeMs"`
+	DrawTime     float64 `json:"drawTimeMs"`
+	GPUTime      float64 `json:"gpuTimeMs"`
+	PresentTime  float64 `json:"presentTimeMs"`
+	DrawCalls    int     `json:"drawCalls"`
+	TextureNum   int     `json:"textureNum"`
+	TextureBytes int64   `json:"textureBytes"`
+}
+
+func currentStats() *statsResponse {
+	ms := func(d time.Duration) float64 {
+		return float64(d) / float64(time.Millisecond)
+	}
+	t := ebiten.LastFrameTimings()
+	n, b := stats.Textures()
+	return &statsResponse{
+		FPS:          ebiten.CurrentFPS(),
+		TPS:          ebiten.CurrentTPS(),
+		UpdateTime:   ms(t.Update),
+		DrawTime:     ms(t.Draw),
+		GPUTime:      ms(t.GPU),
+		PresentTime:  ms(t.Present),
+		DrawCalls:    stats.DrawCalls(),
+		TextureNum:   n,
+		TextureBytes: b,
+	}
+}
+
+func serveStats(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, currentStats())
+}
+
+func (s *Server) serveEvents(w http.ResponseWriter, r *http.Request) {
+	f, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	t := time.NewTicker(time.Second)
+	defer t.Stop()
+	for {
+		bs, err := json.Marshal(currentStats())
+		if err != nil {
+			return
+		}
+		if _, err := fmt.Fprintf(w, "data: %s\n\n", bs); err != nil {
+			return
+		}
+		f.Flush()
+
+		select {
+		case <-t.C:
+		case <-r.Context().Done():
+			return
+		case <-s.done:
+			return
+		}
+	}
+}
+
+type screenshotResult struct {
+	img image.Image
+	err error
+}
+
+var (
+	screenshotRequests []chan<- screenshotResult
+	screenshotM        sync.Mutex
+	screenshotOnce     sync.Once
+)
+
+func requestScreenshot() <-chan screenshotResult {
+	screenshotOnce.Do(func() {
+		hooks.AppendHookOnScreen(func(readScreen func() (image.Image, error)) {
+			screenshotM.Lock()
+			reqs := screenshotRequests
+			screenshotRequests = nil
+			screenshotM.Unlock()
+
+			if len(reqs) == 0 {
+				return
+			}
+			img, err := readScreen()
+			for _, req := range reqs {
+				req <- screenshotResult{
+					img: img,
+					err: err,
+				}
+			}
+		})
+	})
+
+	ch := make(chan screenshotResult, 1)
+	screenshotM.Lock()
+	screenshotRequests = append(screenshotRequests, ch)
+	screenshotM.Unlock()
+	return ch
+}
+
+func serveScreenshot(w http.ResponseWriter, r *http.Request) {
+	select {
+	case res := <-requestScreenshot():
+		if res.err != nil {
+			http.Error(w, res.err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		if err := png.Encode(w, res.img); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	case <-time.After(5 * time.Second):
+		http.Error(w, "the game didn't render a frame in time", http.StatusServiceUnavailable)
+	case <-r.Context().Done():
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debugserver_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil/debugserver"
+	"github.com/hajimehoshi/ebiten/v2/internal/hooks"
+	"github.com/hajimehoshi/ebiten/v2/internal/stats"
+)
+
+func serve(h http.Handler, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestIndex(t *testing.T) {
+	h, stop := debugserver.NewHandler()
+	defer stop()
+
+	w := serve(h, "/")
+	if got, want := w.Code, http.StatusOK; got != want {
+		t.Fatalf("status: got: %d, want: %d", got, want)
+	}
+	if got, want := w.Header().Get("Content-Type"), "text/html; charset=utf-8"; got != want {
+		t.Errorf("Content-Type: got: %q, want: %q", got, want)
+	}
+	for _, path := range []string{"/images", "/drawcalls", "/shaders", "/stats", "/events", "/screenshot"} {
+		if !strings.Contains(w.Body.String(), `href="`+path+`"`) {
+			t.Errorf("the index doesn't link to %s", path)
+		}
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	h, stop := debugserver.NewHandler()
+	defer stop()
+
+	if got, want := serve(h, "/foo").Code, http.StatusNotFound; got != want {
+		t.Errorf("status: got: %d, want: %d", got, want)
+	}
+}
+
+func TestStats(t *testing.T) {
+	h, stop := debugserver.NewHandler()
+	defer stop()
+
+	w := serve(h, "/stats")
+	if got, want := w.Code, http.StatusOK; got != want {
+		t.Fatalf("status: got: %d, want: %d", got, want)
+	}
+	if got, want := w.Header().Get("Content-Type"), "application/json"; got != want {
+		t.Errorf("Content-Type: got: %q, want: %q", got, want)
+	}
+	var v map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &v); err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{"fps", "tps", "updateTimeMs", "drawTimeMs", "gpuTimeMs", "presentTimeMs", "drawCalls", "textureNum", "textureBytes"} {
+		if _, ok := v[k]; !ok {
+			t.Errorf("the key %q is missing: %s", k, w.Body.String())
+		}
+	}
+}
+
+func TestShaders(t *testing.T) {
+	h, stop := debugserver.NewHandler()
+	defer stop()
+
+	w := serve(h, "/shaders")
+	if got, want := w.Code, http.StatusOK; got != want {
+		t.Fatalf("status: got: %d, want: %d", got, want)
+	}
+	// The list must be an array even if there are no shaders.
+	var v []interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v == nil {
+		t.Errorf("got: null, want: an array")
+	}
+}
+
+func TestDrawCalls(t *testing.T) {
+	h, stop := debugserver.NewHandler()
+	defer stop()
+
+	stats.LogDrawCall("draw 1")
+	stats.LogDrawCall("draw 2")
+	stats.EndFrame(time.Now())
+	defer stats.EndFrame(time.Now())
+
+	w := serve(h, "/drawcalls")
+	if got, want := w.Code, http.StatusOK; got != want {
+		t.Fatalf("status: got: %d, want: %d", got, want)
+	}
+	var got []string
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"draw 1", "draw 2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
+
+func TestEventsStopsAtClose(t *testing.T) {
+	h, stop := debugserver.NewHandler()
+	// Stop the server first so that the stream ends after the first event.
+	stop()
+
+	w := serve(h, "/events")
+	if got, want := w.Header().Get("Content-Type"), "text/event-stream"; got != want {
+		t.Errorf("Content-Type: got: %q, want: %q", got, want)
+	}
+	if !w.Flushed {
+		t.Errorf("the event is not flushed")
+	}
+
+	body := w.Body.String()
+	if !strings.HasPrefix(body, "data: ") || !strings.HasSuffix(body, "\n\n") {
+		t.Fatalf("invalid event: %q", body)
+	}
+	var v map[string]interface{}
+	if err := json.Unmarshal([]byte(strings.TrimSuffix(strings.TrimPrefix(body, "data: "), "\n\n")), &v); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := v["fps"]; !ok {
+		t.Errorf("the key \"fps\" is missing: %s", body)
+	}
+}
+
+// serveScreenshot requests a screenshot and runs the screen hooks with readScreen until the response is written.
+func serveScreenshot(t *testing.T, readScreen func() (image.Image, error)) *httptest.ResponseRecorder {
+	h, stop := debugserver.NewHandler()
+	defer stop()
+
+	var w *httptest.ResponseRecorder
+	done := make(chan struct{})
+	go func() {
+		w = serve(h, "/screenshot")
+		close(done)
+	}()
+
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case <-done:
+			return w
+		case <-timeout:
+			t.Fatal("timeout")
+		default:
+		}
+		hooks.RunScreenHooks(readScreen)
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestScreenshot(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	img.SetRGBA(1, 0, color.RGBA{0xff, 0, 0, 0xff})
+
+	w := serveScreenshot(t, func() (image.Image, error) {
+		return img, nil
+	})
+	if got, want := w.Code, http.StatusOK; got != want {
+		t.Fatalf("status: got: %d, want: %d", got, want)
+	}
+	if got, want := w.Header().Get("Content-Type"), "image/png"; got != want {
+		t.Errorf("Content-Type: got: %q, want: %q", got, want)
+	}
+	got, err := png.Decode(bytes.NewReader(w.Body.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Bounds() != img.Bounds() {
+		t.Errorf("bounds: got: %v, want: %v", got.Bounds(), img.Bounds())
+	}
+	if got, want := color.RGBAModel.Convert(got.At(1, 0)), img.At(1, 0); got != want {
+		t.Errorf("At(1, 0): got: %v, want: %v", got, want)
+	}
+}
+
+func TestScreenshotError(t *testing.T) {
+	w := serveScreenshot(t, func() (image.Image, error) {
+		return nil, errors.New("read error")
+	})
+	if got, want := w.Code, http.StatusInternalServerError; got != want {
+		t.Errorf("status: got: %d, want: %d", got, want)
+	}
+	if got, want := strings.TrimSpace(w.Body.String()), "read error"; got != want {
+		t.Errorf("body: got: %q, want: %q", got, want)
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debugserver
+
+import (
+	"net/http"
+)
+
+// NewHandler returns the handler of a server that doesn't listen on any address, and a function to stop the
+// server.
+func NewHandler() (http.Handler, func()) {
+	s := &Server{
+		done: make(chan struct{}),
+	}
+	return s.handler(), func() {
+		close(s.done)
+	}
+}
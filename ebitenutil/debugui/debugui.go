@@ -0,0 +1,441 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package debugui provides a small immediate-mode UI for debugging, like tweaking game parameters at runtime.
+//
+// The widgets are declared in Update every tick, and the last declared widgets are drawn by Draw:
+//
+//     var ui debugui.UI
+//
+//     func (g *Game) Update() error {
+//         ui.Begin()
+//         ui.Label(fmt.Sprintf("Enemies: %d", len(g.enemies)))
+//         ui.SliderFloat("Speed", &g.speed, 0, 10)
+//         ui.Checkbox("God mode", &g.godMode)
+//         if ui.Section("Physics") {
+//             ui.SliderFloat("Gravity", &g.gravity, 0, 20)
+//             ui.EndSection()
+//         }
+//         if ui.Button("Reset") {
+//             g.reset()
+//         }
+//         ui.End()
+//
+//         if !ui.IsHovered() {
+//             // Handle the game's mouse input.
+//         }
+//         return nil
+//     }
+//
+//     func (g *Game) Draw(screen *ebiten.Image) {
+//         // Draw the game.
+//         ui.Draw(screen)
+//     }
+//
+// A widget is identified by its label and the labels of its enclosing sections. To use the same label for multiple
+// widgets, append "##" and a unique suffix to the label. The part after "##" is not shown.
+//
+// debugui is intended to be used mainly for debugging or prototyping purpose.
+package debugui
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil/internal/assets"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// DefaultWidth is the default width of a UI in pixels.
+const DefaultWidth = 200
+
+const (
+	padding     = 4
+	rowHeight   = assets.CharHeight + 2
+	indentWidth = 12
+	boxSize     = 10
+)
+
+var (
+	backgroundColor = color.RGBA{0x20, 0x20, 0x20, 0xc0}
+	widgetColor     = color.RGBA{0x50, 0x50, 0x50, 0xff}
+	hoveredColor    = color.RGBA{0x70, 0x70, 0x70, 0xff}
+	activeColor     = color.RGBA{0x40, 0x80, 0xc0, 0xff}
+)
+
+type commandType int
+
+const (
+	commandTypeRect commandType = iota
+	commandTypeText
+)
+
+type command struct {
+	typ   commandType
+	rect  image.Rectangle
+	color color.Color
+	text  string
+}
+
+// UI is an immediate-mode debug UI.
+//
+// The zero value of UI is ready to use.
+type UI struct {
+	// X and Y are the upper-left position of the UI in pixels.
+	X int
+	Y int
+
+	// Width is the width of the UI in pixels.
+	// If Width is 0 or negative, DefaultWidth is used.
+	Width int
+
+	commands []command
+	drawn    []command
+
+	// sections is the stack of the IDs of the enclosing sections.
+	sections []string
+	expanded map[string]bool
+
+	// y is the position of the next widget.
+	y int
+
+	// active is the ID of the widget being pressed, like a dragged slider.
+	active string
+
+	cursor       image.Point
+	pressed      bool
+	justPressed  bool
+	justReleased bool
+	hovered      bool
+
+	touchID     ebiten.TouchID
+	touchActive bool
+}
+
+func (u *UI) width() int {
+	if u.Width <= 0 {
+		return DefaultWidth
+	}
+	return u.Width
+}
+
+// Begin starts declaring the widgets for the current tick. Begin must be called in Update.
+func (u *UI) Begin() {
+	u.commands = u.commands[:0]
+	u.sections = u.sections[:0]
+	u.y = u.Y + padding
+	u.hovered = false
+	u.updateInput()
+
+	// The background's height is determined at End.
+	u.commands = append(u.commands, command{
+		typ:   commandTypeRect,
+		color: backgroundColor,
+	})
+}
+
+// pointerInput is the state of the pointer in a tick.
+type pointerInput struct {
+	cursor       image.Point
+	pressed      bool
+	justPressed  bool
+	justReleased bool
+}
+
+// readInput reads the pointer input of the current tick. readInput is replaced in tests.
+var readInput = (*UI).readInput
+
+func (u *UI) updateInput() {
+	in := readInput(u)
+	u.cursor = in.cursor
+	u.pressed = in.pressed
+	u.justPressed = in.justPressed
+	u.justReleased = in.justReleased
+}
+
+func (u *UI) readInput() pointerInput {
+	// A touch has priority over the mouse.
+	if u.touchActive {
+		if inpututil.IsTouchJustReleased(u.touchID) {
+			u.touchActive = false
+			return pointerInput{
+				cursor:       u.cursor,
+				justReleased: true,
+			}
+		}
+		x, y := ebiten.TouchPosition(u.touchID)
+		return pointerInput{
+			cursor:  image.Pt(x, y),
+			pressed: true,
+		}
+	}
+	if ids := inpututil.JustPressedTouchIDs(); len(ids) > 0 {
+		u.touchID = ids[0]
+		u.touchActive = true
+		x, y := ebiten.TouchPosition(u.touchID)
+		return pointerInput{
+			cursor:      image.Pt(x, y),
+			pressed:     true,
+			justPressed: true,
+		}
+	}
+
+	x, y := ebiten.CursorPosition()
+	return pointerInput{
+		cursor:       image.Pt(x, y),
+		pressed:      ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft),
+		justPressed:  inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft),
+		justReleased: inpututil.IsMouseButtonJustReleased(ebiten.MouseButtonLeft),
+	}
+}
+
+// End finishes declaring the widgets. End must be called after Begin in Update.
+func (u *UI) End() {
+	bg := image.Rect(u.X, u.Y, u.X+u.width(), u.y)
+	u.commands[0].rect = bg
+	if u.cursor.In(bg) || u.active != "" {
+		u.hovered = true
+	}
+	if u.justReleased {
+		u.active = ""
+	}
+	u.drawn = append(u.drawn[:0], u.commands...)
+}
+
+// IsHovered reports whether the pointer is on the UI or a widget is being operated in the current tick.
+// IsHovered is useful to ignore the game's pointer input handled by the UI.
+//
+// IsHovered must be called after End.
+func (u *UI) IsHovered() bool {
+	return u.hovered
+}
+
+// Draw draws the widgets declared in the last tick on dst.
+func (u *UI) Draw(dst *ebiten.Image) {
+	for _, c := range u.drawn {
+		switch c.typ {
+		case commandTypeRect:
+			r := c.rect
+			ebitenutil.DrawRect(dst, float64(r.Min.X), float64(r.Min.Y), float64(r.Dx()), float64(r.Dy()), c.color)
+		case commandTypeText:
+			ebitenutil.DebugPrintAt(dst, c.text, c.rect.Min.X, c.rect.Min.Y)
+		}
+	}
+}
+
+// id returns the ID of the widget with the label.
+func (u *UI) id(label string) string {
+	if len(u.sections) == 0 {
+		return label
+	}
+	return u.sections[len(u.sections)-1] + "/" + label
+}
+
+// displayedLabel returns the label without the part after "##".
+func displayedLabel(label string) string {
+	if i := strings.Index(label, "##"); i >= 0 {
+		return label[:i]
+	}
+	return label
+}
+
+// nextRect allocates the rectangle of the next widget.
+func (u *UI) nextRect() image.Rectangle {
+	x := u.X + padding + indentWidth*len(u.sections)
+	r := image.Rect(x, u.y, u.X+u.width()-padding, u.y+rowHeight)
+	u.y += rowHeight + padding
+	return r
+}
+
+func (u *UI) drawRect(r image.Rectangle, clr color.Color) {
+	u.commands = append(u.commands, command{
+		typ:   commandTypeRect,
+		rect:  r,
+		color: clr,
+	})
+}
+
+func (u *UI) drawText(x, y int, text string) {
+	u.commands = append(u.commands, command{
+		typ:  commandTypeText,
+		rect: image.Rect(x, y, x, y),
+		text: text,
+	})
+}
+
+// drawTextInRect draws text vertically centered in r.
+func (u *UI) drawTextInRect(r image.Rectangle, x int, text string) {
+	u.drawText(x, r.Min.Y+(r.Dy()-assets.CharHeight)/2, text)
+}
+
+// widgetColor returns the color of the widget's background.
+func (u *UI) widgetColor(id string, r image.Rectangle) color.Color {
+	if u.active == id {
+		return activeColor
+	}
+	if u.active == "" && u.cursor.In(r) {
+		return hoveredColor
+	}
+	return widgetColor
+}
+
+// press handles a press on the widget, and reports whether the widget is pressed in this tick.
+func (u *UI) press(id string, r image.Rectangle) bool {
+	if u.justPressed && u.active == "" && u.cursor.In(r) {
+		u.active = id
+		return true
+	}
+	return false
+}
+
+// Label shows a text.
+func (u *UI) Label(text string) {
+	r := u.nextRect()
+	u.drawTextInRect(r, r.Min.X, text)
+}
+
+// Button shows a button, and reports whether the button is clicked.
+// A click is a press and a release in the button.
+func (u *UI) Button(label string) bool {
+	id := u.id(label)
+	r := u.nextRect()
+	u.press(id, r)
+	clicked := u.active == id && u.justReleased && u.cursor.In(r)
+
+	u.drawRect(r, u.widgetColor(id, r))
+	text := displayedLabel(label)
+	u.drawTextInRect(r, r.Min.X+(r.Dx()-len(text)*assets.CharWidth)/2, text)
+	return clicked
+}
+
+// Checkbox shows a checkbox for value, and reports whether value is changed by the checkbox.
+func (u *UI) Checkbox(label string, value *bool) bool {
+	id := u.id(label)
+	r := u.nextRect()
+	changed := false
+	if u.press(id, r) {
+		*value = !*value
+		changed = true
+	}
+
+	box := image.Rect(r.Min.X, r.Min.Y+(r.Dy()-boxSize)/2, r.Min.X+boxSize, r.Min.Y+(r.Dy()+boxSize)/2)
+	u.drawRect(box, u.widgetColor(id, r))
+	if *value {
+		u.drawRect(box.Inset(2), color.White)
+	}
+	u.drawTextInRect(r, box.Max.X+padding, displayedLabel(label))
+	return changed
+}
+
+// SliderFloat shows a slider for value in the range [min, max], and reports whether value is changed by
+// the slider.
+func (u *UI) SliderFloat(label string, value *float64, min, max float64) bool {
+	id := u.id(label)
+	r := u.nextRect()
+	u.press(id, r)
+
+	changed := false
+	if u.active == id && (u.pressed || u.justReleased) && r.Dx() > 0 {
+		rate := float64(u.cursor.X-r.Min.X) / float64(r.Dx())
+		v := min + (max-min)*math.Min(math.Max(rate, 0), 1)
+		if v != *value {
+			*value = v
+			changed = true
+		}
+	}
+
+	u.drawSlider(id, r, label, (*value-min)/(max-min), fmt.Sprintf("%.3g", *value))
+	return changed
+}
+
+// SliderInt shows a slider for value in the range [min, max], and reports whether value is changed by the slider.
+func (u *UI) SliderInt(label string, value *int, min, max int) bool {
+	id := u.id(label)
+	r := u.nextRect()
+	u.press(id, r)
+
+	changed := false
+	if u.active == id && (u.pressed || u.justReleased) && r.Dx() > 0 {
+		rate := float64(u.cursor.X-r.Min.X) / float64(r.Dx())
+		v := min + int(math.Round(float64(max-min)*math.Min(math.Max(rate, 0), 1)))
+		if v != *value {
+			*value = v
+			changed = true
+		}
+	}
+
+	var rate float64
+	if max != min {
+		rate = float64(*value-min) / float64(max-min)
+	}
+	u.drawSlider(id, r, label, rate, fmt.Sprintf("%d", *value))
+	return changed
+}
+
+func (u *UI) drawSlider(id string, r image.Rectangle, label string, rate float64, value string) {
+	if math.IsNaN(rate) {
+		rate = 0
+	}
+	rate = math.Min(math.Max(rate, 0), 1)
+
+	u.drawRect(r, widgetColor)
+	fill := r
+	fill.Max.X = r.Min.X + int(float64(r.Dx())*rate)
+	if u.active == id {
+		u.drawRect(fill, activeColor)
+	} else {
+		u.drawRect(fill, hoveredColor)
+	}
+	u.drawTextInRect(r, r.Min.X+padding, displayedLabel(label)+": "+value)
+}
+
+// Section shows a collapsible section header, and reports whether the section is expanded.
+// If Section returns true, the following widgets are in the section until EndSection is called.
+// EndSection must be called only when Section returns true.
+func (u *UI) Section(label string) bool {
+	id := u.id(label)
+	r := u.nextRect()
+	if u.expanded == nil {
+		u.expanded = map[string]bool{}
+	}
+	if u.press(id, r) {
+		u.expanded[id] = !u.expanded[id]
+	}
+
+	u.drawRect(r, u.widgetColor(id, r))
+	mark := "+ "
+	if u.expanded[id] {
+		mark = "- "
+	}
+	u.drawTextInRect(r, r.Min.X+padding, mark+displayedLabel(label))
+
+	if !u.expanded[id] {
+		return false
+	}
+	u.sections = append(u.sections, id)
+	return true
+}
+
+// EndSection ends the section started by Section.
+func (u *UI) EndSection() {
+	if len(u.sections) == 0 {
+		panic("debugui: EndSection is called without Section")
+	}
+	u.sections = u.sections[:len(u.sections)-1]
+}
@@ -0,0 +1,246 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debugui_test
+
+import (
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil/debugui"
+)
+
+// The height of a row is the character height 16 + 2, and the rows are separated by the padding 4.
+// The widgets start at (4, 4), and the widgets in a section are indented by 12.
+const (
+	rowLeft  = 4
+	rowRight = debugui.DefaultWidth - 4
+)
+
+func rowY(i int) int {
+	return 4 + 22*i + 9
+}
+
+// uiTester runs a UI with a fake pointer input. A call of tick corresponds to a tick of a game.
+type uiTester struct {
+	ui      debugui.UI
+	input   debugui.FakeInput
+	update  func(ui *debugui.UI)
+	hovered bool
+}
+
+func newUITester(t *testing.T, update func(ui *debugui.UI)) *uiTester {
+	u := &uiTester{
+		update: update,
+	}
+	t.Cleanup(debugui.SetFakeInput(&u.input))
+	return u
+}
+
+func (u *uiTester) tick() {
+	u.ui.Begin()
+	u.update(&u.ui)
+	u.ui.End()
+	u.hovered = u.ui.IsHovered()
+}
+
+func (u *uiTester) setCursorPosition(x, y int) {
+	u.input.X = x
+	u.input.Y = y
+}
+
+// click presses and releases the left mouse button at (x, y) in two ticks.
+func (u *uiTester) click(x, y int) {
+	u.setCursorPosition(x, y)
+	u.input.Pressed = true
+	u.tick()
+	u.input.Pressed = false
+	u.tick()
+}
+
+func TestButton(t *testing.T) {
+	var clicks0, clicks1 int
+	u := newUITester(t, func(ui *debugui.UI) {
+		ui.Label("Label")
+		// The same displayed label with different IDs.
+		if ui.Button("OK##0") {
+			clicks0++
+		}
+		if ui.Button("OK##1") {
+			clicks1++
+		}
+	})
+
+	u.click(100, rowY(1))
+	if clicks0 != 1 || clicks1 != 0 {
+		t.Errorf("after clicking OK##0: got: (%d, %d), want: (1, 0)", clicks0, clicks1)
+	}
+	u.click(100, rowY(2))
+	if clicks0 != 1 || clicks1 != 1 {
+		t.Errorf("after clicking OK##1: got: (%d, %d), want: (1, 1)", clicks0, clicks1)
+	}
+
+	// Clicking a label does nothing.
+	u.click(100, rowY(0))
+	if clicks0 != 1 || clicks1 != 1 {
+		t.Errorf("after clicking the label: got: (%d, %d), want: (1, 1)", clicks0, clicks1)
+	}
+
+	// A release out of the button is not a click.
+	u.setCursorPosition(100, rowY(1))
+	u.input.Pressed = true
+	u.tick()
+	u.setCursorPosition(100, rowY(3))
+	u.input.Pressed = false
+	u.tick()
+	if clicks0 != 1 {
+		t.Errorf("after releasing out of OK##0: got: %d, want: 1", clicks0)
+	}
+}
+
+func TestCheckbox(t *testing.T) {
+	var value bool
+	var changes int
+	u := newUITester(t, func(ui *debugui.UI) {
+		if ui.Checkbox("Check", &value) {
+			changes++
+		}
+	})
+
+	u.click(rowLeft+2, rowY(0))
+	if !value || changes != 1 {
+		t.Errorf("after the first click: got: (%v, %d), want: (true, 1)", value, changes)
+	}
+	u.click(rowLeft+2, rowY(0))
+	if value || changes != 2 {
+		t.Errorf("after the second click: got: (%v, %d), want: (false, 2)", value, changes)
+	}
+}
+
+func TestSlider(t *testing.T) {
+	var f float64
+	var i int
+	u := newUITester(t, func(ui *debugui.UI) {
+		ui.SliderFloat("Float", &f, 0, 10)
+		ui.SliderInt("Int", &i, 0, 4)
+	})
+
+	w := rowRight - rowLeft
+	u.click(rowLeft+w/2, rowY(0))
+	if f != 5 {
+		t.Errorf("SliderFloat: got: %v, want: 5", f)
+	}
+	u.click(rowLeft+w*3/4, rowY(1))
+	if i != 3 {
+		t.Errorf("SliderInt: got: %v, want: 3", i)
+	}
+
+	// Dragging out of the slider clamps the value.
+	u.setCursorPosition(rowLeft+w/2, rowY(0))
+	u.input.Pressed = true
+	u.tick()
+	u.setCursorPosition(300, rowY(3))
+	u.tick()
+	if f != 10 {
+		t.Errorf("SliderFloat after dragging: got: %v, want: 10", f)
+	}
+	// The UI is regarded as hovered while the slider is dragged.
+	if !u.hovered {
+		t.Errorf("IsHovered while dragging: got: false, want: true")
+	}
+	u.input.Pressed = false
+	u.tick()
+	if i != 3 {
+		t.Errorf("SliderInt after dragging the other slider: got: %v, want: 3", i)
+	}
+	u.tick()
+	if u.hovered {
+		t.Errorf("IsHovered after dragging: got: true, want: false")
+	}
+}
+
+func TestSection(t *testing.T) {
+	var expanded bool
+	var clicks int
+	u := newUITester(t, func(ui *debugui.UI) {
+		expanded = ui.Section("Section")
+		if expanded {
+			if ui.Button("Inner") {
+				clicks++
+			}
+			ui.EndSection()
+		}
+	})
+
+	u.tick()
+	if expanded {
+		t.Errorf("Section at first: got: true, want: false")
+	}
+
+	u.click(100, rowY(0))
+	if !expanded {
+		t.Errorf("Section after clicking: got: false, want: true")
+	}
+
+	// The widgets in the section are indented.
+	u.click(rowLeft+8, rowY(1))
+	if clicks != 0 {
+		t.Errorf("clicks on the indent: got: %d, want: 0", clicks)
+	}
+	u.click(rowLeft+16, rowY(1))
+	if clicks != 1 {
+		t.Errorf("clicks: got: %d, want: 1", clicks)
+	}
+
+	u.click(100, rowY(0))
+	if expanded {
+		t.Errorf("Section after clicking again: got: true, want: false")
+	}
+}
+
+func TestEndSectionWithoutSection(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("EndSection without Section must panic")
+		}
+	}()
+	var ui debugui.UI
+	ui.EndSection()
+}
+
+func TestIsHovered(t *testing.T) {
+	u := newUITester(t, func(ui *debugui.UI) {
+		ui.Label("Label")
+	})
+	u.ui.X = 100
+
+	cases := []struct {
+		X    int
+		Y    int
+		Want bool
+	}{
+		{50, rowY(0), false},
+		{100 + rowLeft, rowY(0), true},
+		{100 + debugui.DefaultWidth - 1, rowY(0), true},
+		{100 + debugui.DefaultWidth, rowY(0), false},
+		// The height of the UI is determined by the widgets.
+		{150, rowY(1), false},
+	}
+	for _, c := range cases {
+		u.setCursorPosition(c.X, c.Y)
+		u.tick()
+		if u.hovered != c.Want {
+			t.Errorf("IsHovered at (%d, %d): got: %v, want: %v", c.X, c.Y, u.hovered, c.Want)
+		}
+	}
+}
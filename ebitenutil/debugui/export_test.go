@@ -0,0 +1,50 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debugui
+
+import (
+	"image"
+)
+
+// FakeInput is a pointer input for tests.
+type FakeInput struct {
+	X       int
+	Y       int
+	Pressed bool
+
+	prevPressed bool
+}
+
+func (f *FakeInput) read() pointerInput {
+	in := pointerInput{
+		cursor:       image.Pt(f.X, f.Y),
+		pressed:      f.Pressed,
+		justPressed:  f.Pressed && !f.prevPressed,
+		justReleased: !f.Pressed && f.prevPressed,
+	}
+	f.prevPressed = f.Pressed
+	return in
+}
+
+// SetFakeInput makes the UIs read the pointer input from f instead of Ebiten, and returns a function to restore it.
+func SetFakeInput(f *FakeInput) func() {
+	orig := readInput
+	readInput = func(*UI) pointerInput {
+		return f.read()
+	}
+	return func() {
+		readInput = orig
+	}
+}
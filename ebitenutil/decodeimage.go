@@ -0,0 +1,34 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebitenutil
+
+import (
+	"image"
+	"io"
+)
+
+// DecodeImage decodes an image.
+//
+// On browsers, DecodeImage decodes the image with the browser's decoder, which runs on a background thread. Then,
+// decoding a large image doesn't block the game. The result is an *image.NRGBA. If the browser fails to decode
+// the image, DecodeImage falls back to image.Decode.
+//
+// On the other platforms, DecodeImage is the same as image.Decode, and image decoders must be imported.
+// For example, if you want to load a PNG image, you'd need to add `_ "image/png"` to the import section.
+//
+// On browsers, DecodeImage reads the whole r and blocks until decoding finishes.
+func DecodeImage(r io.Reader) (image.Image, error) {
+	return decodeImage(r)
+}
@@ -0,0 +1,86 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebitenutil
+
+import (
+	"bytes"
+	"image"
+	"io"
+	"io/ioutil"
+	"syscall/js"
+
+	"github.com/hajimehoshi/ebiten/v2/internal/web"
+)
+
+func decodeImage(r io.Reader) (image.Image, error) {
+	createImageBitmap := js.Global().Get("createImageBitmap")
+	if !createImageBitmap.Truthy() {
+		img, _, err := image.Decode(r)
+		return img, err
+	}
+
+	bs, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if img, ok := decodeImageWithBrowser(bs); ok {
+		return img, nil
+	}
+	img, _, err := image.Decode(bytes.NewReader(bs))
+	return img, err
+}
+
+func decodeImageWithBrowser(bs []byte) (*image.NRGBA, bool) {
+	a := js.Global().Get("Uint8Array").New(len(bs))
+	js.CopyBytesToJS(a, bs)
+	blob := js.Global().Get("Blob").New([]interface{}{a})
+
+	// createImageBitmap decodes the image on a background thread.
+	bitmap, err := web.AwaitPromise(js.Global().Call("createImageBitmap", blob, map[string]interface{}{
+		"premultiplyAlpha":     "none",
+		"colorSpaceConversion": "none",
+	}))
+	if err != nil {
+		return nil, false
+	}
+	defer bitmap.Call("close")
+
+	w := bitmap.Get("width").Int()
+	h := bitmap.Get("height").Int()
+	if w == 0 || h == 0 {
+		return nil, false
+	}
+
+	var canvas js.Value
+	if c := js.Global().Get("OffscreenCanvas"); c.Truthy() {
+		canvas = c.New(w, h)
+	} else if doc := js.Global().Get("document"); doc.Truthy() {
+		canvas = doc.Call("createElement", "canvas")
+		canvas.Set("width", w)
+		canvas.Set("height", h)
+	} else {
+		return nil, false
+	}
+	ctx := canvas.Call("getContext", "2d")
+	if !ctx.Truthy() {
+		return nil, false
+	}
+	ctx.Call("drawImage", bitmap, 0, 0)
+	data := ctx.Call("getImageData", 0, 0, w, h).Get("data")
+
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	js.CopyBytesToGo(img.Pix, js.Global().Get("Uint8Array").New(data.Get("buffer"), data.Get("byteOffset"), data.Get("byteLength")))
+	return img, true
+}
@@ -0,0 +1,334 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebitenutil
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	"io"
+	"io/ioutil"
+	"runtime"
+	"sync"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// DefaultImageLoaderUploadBytesPerTick is the default number of bytes of pixels uploaded to the GPU per tick.
+const DefaultImageLoaderUploadBytesPerTick = 4 * 1024 * 1024
+
+// ImageLoaderOptions represents options for an ImageLoader.
+type ImageLoaderOptions struct {
+	// Concurrency is the maximum number of images decoded concurrently.
+	// If Concurrency is 0 or negative, the number of the CPUs is used.
+	Concurrency int
+
+	// UploadBytesPerTick is the maximum number of bytes of pixels uploaded to the GPU at each Update call.
+	// A large image is uploaded over multiple ticks.
+	// If UploadBytesPerTick is 0 or negative, DefaultImageLoaderUploadBytesPerTick is used.
+	UploadBytesPerTick int
+
+	// OnLoad is called when an image is loaded or fails to be loaded.
+	// OnLoad is called from (*ImageLoader).Update, i.e. on the game's goroutine.
+	OnLoad func(image *AsyncImage)
+}
+
+// ImageLoader loads images asynchronously.
+//
+// The images are read and decoded on background goroutines, and then uploaded to the GPU incrementally at each
+// Update call. Then a loading screen doesn't freeze even while loading dozens of large images:
+//
+//     l := ebitenutil.NewImageLoader(nil)
+//     bg := l.AddFile("bg.png")
+//     player := l.Add(playerPNG)
+//
+//     // In Update
+//     l.Update()
+//     if l.IsDone() {
+//         img, err := bg.Image()
+//         ...
+//     }
+//
+//     // In Draw
+//     done, total := l.Progress()
+//
+// Image decoders must be imported except on browsers. See also DecodeImage.
+type ImageLoader struct {
+	options ImageLoaderOptions
+
+	images   []*AsyncImage
+	queue    []*AsyncImage
+	decoded  []*AsyncImage
+	workers  int
+	numDone  int
+	numTotal int
+
+	m sync.Mutex
+}
+
+// NewImageLoader creates a new ImageLoader.
+//
+// If options is nil, the default options are used.
+func NewImageLoader(options *ImageLoaderOptions) *ImageLoader {
+	l := &ImageLoader{}
+	if options != nil {
+		l.options = *options
+	}
+	if l.options.Concurrency <= 0 {
+		l.options.Concurrency = runtime.NumCPU()
+	}
+	if l.options.UploadBytesPerTick <= 0 {
+		l.options.UploadBytesPerTick = DefaultImageLoaderUploadBytesPerTick
+	}
+	return l
+}
+
+// AsyncImage represents an image loaded by an ImageLoader.
+type AsyncImage struct {
+	open func() (io.ReadCloser, error)
+
+	// rgba is the decoded pixels. rgba is set by the decoder and is used by the uploader.
+	rgba *image.RGBA
+
+	// uploadedRows is the number of the rows uploaded to the GPU.
+	uploadedRows int
+
+	image *ebiten.Image
+	err   error
+	done  bool
+
+	m sync.Mutex
+}
+
+// ErrImageNotLoaded is returned by (*AsyncImage).Image when the image is not loaded yet.
+var ErrImageNotLoaded = errors.New("ebitenutil: the image is not loaded yet")
+
+// Add adds an image to load from the encoded data like PNG and JPEG.
+//
+// Add is concurrent-safe.
+func (l *ImageLoader) Add(data []byte) *AsyncImage {
+	return l.add(func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(data)), nil
+	})
+}
+
+// AddReader adds an image to load from r. r is read on a background goroutine.
+//
+// If r is an io.Closer, r is closed after r is read.
+//
+// AddReader is concurrent-safe.
+func (l *ImageLoader) AddReader(r io.Reader) *AsyncImage {
+	return l.add(func() (io.ReadCloser, error) {
+		if rc, ok := r.(io.ReadCloser); ok {
+			return rc, nil
+		}
+		return ioutil.NopCloser(r), nil
+	})
+}
+
+func (l *ImageLoader) add(open func() (io.ReadCloser, error)) *AsyncImage {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	img := &AsyncImage{
+		open: open,
+	}
+	l.images = append(l.images, img)
+	l.queue = append(l.queue, img)
+	l.numTotal++
+	for l.workers < l.options.Concurrency && l.workers < len(l.queue) {
+		l.workers++
+		go l.loop()
+	}
+	return img
+}
+
+// next returns the next image to decode.
+// If the queue is empty, next returns nil and the calling worker must end.
+func (l *ImageLoader) next() *AsyncImage {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	if len(l.queue) == 0 {
+		l.workers--
+		return nil
+	}
+	img := l.queue[0]
+	l.queue[0] = nil
+	l.queue = l.queue[1:]
+	return img
+}
+
+func (l *ImageLoader) loop() {
+	for {
+		img := l.next()
+		if img == nil {
+			return
+		}
+
+		rgba, err := decodeToRGBA(img.open)
+		img.m.Lock()
+		img.rgba = rgba
+		img.err = err
+		img.open = nil
+		img.m.Unlock()
+
+		l.m.Lock()
+		l.decoded = append(l.decoded, img)
+		l.m.Unlock()
+	}
+}
+
+// decodeToRGBA decodes an image and converts it into premultiplied RGBA pixels, which are ready to upload.
+func decodeToRGBA(open func() (io.ReadCloser, error)) (*image.RGBA, error) {
+	r, err := open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+
+	img, err := DecodeImage(r)
+	if err != nil {
+		return nil, err
+	}
+
+	b := img.Bounds()
+	if b.Empty() {
+		return nil, errors.New("ebitenutil: the image is empty")
+	}
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+	return rgba, nil
+}
+
+// Update uploads the decoded images to the GPU, and calls OnLoad for the loaded images.
+//
+// Update must be called in the game's Update every tick while loading images.
+func (l *ImageLoader) Update() {
+	l.m.Lock()
+	decoded := l.decoded
+	l.m.Unlock()
+
+	budget := l.options.UploadBytesPerTick
+	var loaded []*AsyncImage
+	for _, img := range decoded {
+		if budget <= 0 {
+			break
+		}
+		var done bool
+		done, budget = img.upload(budget)
+		if !done {
+			break
+		}
+		loaded = append(loaded, img)
+	}
+
+	l.m.Lock()
+	l.decoded = l.decoded[len(loaded):]
+	l.numDone += len(loaded)
+	l.m.Unlock()
+
+	if l.options.OnLoad != nil {
+		for _, img := range loaded {
+			l.options.OnLoad(img)
+		}
+	}
+}
+
+// upload uploads the pixels to the GPU within the budget in bytes.
+// upload returns whether the image is done and the rest of the budget.
+func (a *AsyncImage) upload(budget int) (bool, int) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	if a.err != nil {
+		a.rgba = nil
+		a.done = true
+		return true, budget
+	}
+
+	w, h := a.rgba.Rect.Dx(), a.rgba.Rect.Dy()
+	if a.image == nil {
+		a.image = ebiten.NewImage(w, h)
+	}
+
+	// Upload at least one row to progress.
+	stride := a.rgba.Stride
+	rows := budget / stride
+	if rows < 1 {
+		rows = 1
+	}
+	if rows > h-a.uploadedRows {
+		rows = h - a.uploadedRows
+	}
+	y := a.uploadedRows
+	sub := a.image.SubImage(image.Rect(0, y, w, y+rows)).(*ebiten.Image)
+	sub.ReplacePixels(a.rgba.Pix[y*stride : (y+rows)*stride])
+	a.uploadedRows += rows
+	budget -= rows * stride
+
+	if a.uploadedRows < h {
+		return false, budget
+	}
+	a.rgba = nil
+	a.done = true
+	return true, budget
+}
+
+// Progress returns the number of the loaded images and the number of all the added images.
+// Images that fail to be loaded are counted as loaded.
+//
+// Progress is concurrent-safe.
+func (l *ImageLoader) Progress() (done, total int) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	return l.numDone, l.numTotal
+}
+
+// IsDone reports whether all the added images are loaded regardless of errors.
+//
+// IsDone is concurrent-safe.
+func (l *ImageLoader) IsDone() bool {
+	l.m.Lock()
+	defer l.m.Unlock()
+	return l.numDone == l.numTotal
+}
+
+// IsDone reports whether the image is loaded regardless of an error.
+//
+// IsDone is concurrent-safe.
+func (a *AsyncImage) IsDone() bool {
+	a.m.Lock()
+	defer a.m.Unlock()
+	return a.done
+}
+
+// Image returns the loaded image. Image returns ErrImageNotLoaded if the image is not loaded yet.
+//
+// Image is concurrent-safe.
+func (a *AsyncImage) Image() (*ebiten.Image, error) {
+	a.m.Lock()
+	defer a.m.Unlock()
+	if !a.done {
+		return nil, ErrImageNotLoaded
+	}
+	if a.err != nil {
+		return nil, a.err
+	}
+	return a.image, nil
+}
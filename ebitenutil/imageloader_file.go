@@ -0,0 +1,36 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build (darwin || freebsd || js || linux || windows) && !android && !ios
+// +build darwin freebsd js linux windows
+// +build !android
+// +build !ios
+
+package ebitenutil
+
+import (
+	"io"
+)
+
+// AddFile adds an image to load from the file with path. The file is opened with OpenFile on a background
+// goroutine.
+//
+// Note that this doesn't work on mobiles.
+//
+// AddFile is concurrent-safe.
+func (l *ImageLoader) AddFile(path string) *AsyncImage {
+	return l.add(func() (io.ReadCloser, error) {
+		return OpenFile(path)
+	})
+}
@@ -0,0 +1,189 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebitenutil_test
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeToRGBA(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	src.SetNRGBA(0, 0, color.NRGBA{0xff, 0, 0, 0xff})
+	src.SetNRGBA(1, 0, color.NRGBA{0xff, 0xff, 0, 0x80})
+	data := encodePNG(t, src)
+
+	rgba, err := ebitenutil.DecodeToRGBA(func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(data)), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := rgba.Bounds(), image.Rect(0, 0, 2, 1); got != want {
+		t.Errorf("bounds: got: %v, want: %v", got, want)
+	}
+	// The pixels are premultiplied.
+	if got, want := rgba.RGBAAt(0, 0), (color.RGBA{0xff, 0, 0, 0xff}); got != want {
+		t.Errorf("At(0, 0): got: %v, want: %v", got, want)
+	}
+	if got, want := rgba.RGBAAt(1, 0), (color.RGBA{0x80, 0x80, 0, 0x80}); got != want {
+		t.Errorf("At(1, 0): got: %v, want: %v", got, want)
+	}
+}
+
+func TestDecodeToRGBAError(t *testing.T) {
+	errOpen := errors.New("open error")
+
+	cases := []struct {
+		Name string
+		Open func() (io.ReadCloser, error)
+		Err  error
+	}{
+		{
+			Name: "open error",
+			Open: func() (io.ReadCloser, error) {
+				return nil, errOpen
+			},
+			Err: errOpen,
+		},
+		{
+			Name: "invalid data",
+			Open: func() (io.ReadCloser, error) {
+				return ioutil.NopCloser(bytes.NewReader([]byte("not an image"))), nil
+			},
+		},
+		{
+			Name: "broken PNG",
+			Open: func() (io.ReadCloser, error) {
+				data := encodePNG(t, image.NewRGBA(image.Rect(0, 0, 16, 16)))
+				return ioutil.NopCloser(bytes.NewReader(data[:len(data)/2])), nil
+			},
+		},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			_, err := ebitenutil.DecodeToRGBA(c.Open)
+			if err == nil {
+				t.Fatalf("got: nil, want: an error")
+			}
+			if c.Err != nil && err != c.Err {
+				t.Errorf("got: %v, want: %v", err, c.Err)
+			}
+		})
+	}
+}
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+// waitForLoader calls Update until all the images are loaded.
+func waitForLoader(t *testing.T, l *ebitenutil.ImageLoader) {
+	t.Helper()
+	timeout := time.Now().Add(10 * time.Second)
+	for !l.IsDone() {
+		if time.Now().After(timeout) {
+			t.Fatal("timeout")
+		}
+		l.Update()
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestImageLoaderError(t *testing.T) {
+	var loaded []*ebitenutil.AsyncImage
+	l := ebitenutil.NewImageLoader(&ebitenutil.ImageLoaderOptions{
+		Concurrency: 2,
+		OnLoad: func(image *ebitenutil.AsyncImage) {
+			loaded = append(loaded, image)
+		},
+	})
+
+	r := &closeRecorder{
+		Reader: bytes.NewReader([]byte("not an image")),
+	}
+	imgs := []*ebitenutil.AsyncImage{
+		l.Add([]byte("not an image")),
+		l.AddReader(r),
+		l.AddReader(bytes.NewReader(nil)),
+	}
+
+	// The images are not loaded until Update is called.
+	if _, err := imgs[0].Image(); err != ebitenutil.ErrImageNotLoaded {
+		t.Errorf("Image() before Update: got: %v, want: %v", err, ebitenutil.ErrImageNotLoaded)
+	}
+	if imgs[0].IsDone() {
+		t.Errorf("IsDone() before Update: got: true, want: false")
+	}
+	if done, total := l.Progress(); done != 0 || total != 3 {
+		t.Errorf("Progress() before Update: got: (%d, %d), want: (0, 3)", done, total)
+	}
+
+	waitForLoader(t, l)
+
+	// Images that fail to be loaded are counted as loaded.
+	if done, total := l.Progress(); done != 3 || total != 3 {
+		t.Errorf("Progress(): got: (%d, %d), want: (3, 3)", done, total)
+	}
+	for i, img := range imgs {
+		if !img.IsDone() {
+			t.Errorf("imgs[%d].IsDone(): got: false, want: true", i)
+		}
+		if _, err := img.Image(); err == nil || err == ebitenutil.ErrImageNotLoaded {
+			t.Errorf("imgs[%d].Image(): got: %v, want: a decoding error", i, err)
+		}
+	}
+	if got, want := len(loaded), 3; got != want {
+		t.Errorf("len(loaded): got: %d, want: %d", got, want)
+	}
+	if !r.closed {
+		t.Errorf("the reader is not closed")
+	}
+}
+
+func TestImageLoaderEmpty(t *testing.T) {
+	l := ebitenutil.NewImageLoader(nil)
+	if !l.IsDone() {
+		t.Errorf("IsDone() without images: got: false, want: true")
+	}
+	l.Update()
+	if done, total := l.Progress(); done != 0 || total != 0 {
+		t.Errorf("Progress(): got: (%d, %d), want: (0, 0)", done, total)
+	}
+}
@@ -29,6 +29,7 @@ import (
 //
 // Image decoders must be imported when using NewImageFromFile. For example,
 // if you want to load a PNG image, you'd need to add `_ "image/png"` to the import section.
+// On browsers, the browser's decoder is used. See also DecodeImage.
 //
 // How to solve path depends on your environment. This varies on your desktop or web browser.
 // Note that this doesn't work on mobiles.
@@ -42,7 +43,7 @@ func NewImageFromFile(path string) (*ebiten.Image, image.Image, error) {
 	defer func() {
 		_ = file.Close()
 	}()
-	img, _, err := image.Decode(file)
+	img, err := DecodeImage(file)
 	if err != nil {
 		return nil, nil, err
 	}
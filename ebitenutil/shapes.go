@@ -20,15 +20,34 @@ import (
 	"math"
 
 	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/internal/hooks"
 )
 
 var (
-	emptyImage    = ebiten.NewImage(3, 3)
-	emptySubImage = emptyImage.SubImage(image.Rect(1, 1, 2, 2)).(*ebiten.Image)
+	emptyImage    *ebiten.Image
+	emptySubImage *ebiten.Image
 )
 
 func init() {
+	initEmptyImage()
+
+	// emptyImage is disposed when the game is restarted by ebiten.RestartGame. Recreate it.
+	hooks.AppendHookOnReset(func() error {
+		initEmptyImage()
+		return nil
+	})
+	// The content of emptyImage might be lost when the graphics device is lost. Recreate it.
+	hooks.AppendHookOnDeviceLost(func() error {
+		emptyImage.Dispose()
+		initEmptyImage()
+		return nil
+	})
+}
+
+func initEmptyImage() {
+	emptyImage = ebiten.NewImage(3, 3)
 	emptyImage.Fill(color.White)
+	emptySubImage = emptyImage.SubImage(image.Rect(1, 1, 2, 2)).(*ebiten.Image)
 }
 
 func colorToScale(clr color.Color) (float64, float64, float64, float64) {
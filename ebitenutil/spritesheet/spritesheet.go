@@ -0,0 +1,311 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package spritesheet provides loaders of sprite sheet metadata.
+//
+// A loader parses the metadata of a sprite sheet and makes named sub-images of the sheet image with the trimming
+// and the pivot data:
+//
+//     sheet, err := spritesheet.LoadTexturePacker(jsonData, sheetImage)
+//     s := sheet.Sprite("hero_idle_0.png")
+//
+//     // In Draw
+//     op := &ebiten.DrawImageOptions{}
+//     op.GeoM = s.GeoM()
+//     op.GeoM.Translate(x, y)
+//     screen.DrawImage(s.Image, op)
+package spritesheet
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"math"
+	"sort"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Sprite represents a sprite in a sprite sheet.
+type Sprite struct {
+	// Name is the name of the sprite.
+	Name string
+
+	// Image is the sub-image of the sheet image. If Rotated is true, Image is rotated in the sheet.
+	Image *ebiten.Image
+
+	// Rotated reports whether the sprite is rotated by 90 degrees clockwise in the sheet.
+	Rotated bool
+
+	// Trimmed reports whether the transparent pixels around the sprite are trimmed.
+	Trimmed bool
+
+	// SourceWidth and SourceHeight are the size of the sprite before trimming.
+	SourceWidth  int
+	SourceHeight int
+
+	// OffsetX and OffsetY are the position of the trimmed sprite in the sprite before trimming.
+	OffsetX int
+	OffsetY int
+
+	// PivotX and PivotY are the pivot of the sprite in the sprite before trimming, relative to the size.
+	// For example, (0.5, 0.5) is the center of the sprite.
+	PivotX float64
+	PivotY float64
+}
+
+// GeoM returns the geometry matrix to draw Image so that the pivot of the sprite is at the origin.
+// GeoM takes care of the rotation and the trimming.
+func (s *Sprite) GeoM() ebiten.GeoM {
+	var g ebiten.GeoM
+	if s.Rotated {
+		// The sprite is rotated clockwise in the sheet. Rotate it back counterclockwise.
+		// The image's width is the sprite's height.
+		w, _ := s.Image.Size()
+		g.Rotate(-math.Pi / 2)
+		g.Translate(0, float64(w))
+	}
+	g.Translate(float64(s.OffsetX), float64(s.OffsetY))
+	g.Translate(-s.PivotX*float64(s.SourceWidth), -s.PivotY*float64(s.SourceHeight))
+	return g
+}
+
+// Sheet represents a sprite sheet.
+type Sheet struct {
+	// ImagePath is the path of the sheet image in the metadata, if exists.
+	ImagePath string
+
+	sprites map[string]*Sprite
+	names   []string
+}
+
+// Sprite returns the sprite of the given name, or nil if the sprite doesn't exist.
+func (s *Sheet) Sprite(name string) *Sprite {
+	return s.sprites[name]
+}
+
+// Names returns the names of the sprites in the order of the metadata. For an object in JSON, whose order is
+// undefined, the names are sorted.
+func (s *Sheet) Names() []string {
+	return append([]string(nil), s.names...)
+}
+
+func (s *Sheet) add(sprite *Sprite) error {
+	if _, ok := s.sprites[sprite.Name]; ok {
+		return fmt.Errorf("spritesheet: duplicated sprite name: %s", sprite.Name)
+	}
+	s.sprites[sprite.Name] = sprite
+	s.names = append(s.names, sprite.Name)
+	return nil
+}
+
+func newSheet() *Sheet {
+	return &Sheet{
+		sprites: map[string]*Sprite{},
+	}
+}
+
+func subImage(img *ebiten.Image, x, y, width, height int) (*ebiten.Image, error) {
+	r := image.Rect(x, y, x+width, y+height)
+	if !r.In(img.Bounds()) {
+		return nil, fmt.Errorf("spritesheet: the region %v is out of the image bounds %v", r, img.Bounds())
+	}
+	return img.SubImage(r).(*ebiten.Image), nil
+}
+
+type tpRect struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+type tpSize struct {
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+type tpPoint struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+type tpFrame struct {
+	Filename         string   `json:"filename"`
+	Frame            tpRect   `json:"frame"`
+	Rotated          bool     `json:"rotated"`
+	Trimmed          bool     `json:"trimmed"`
+	SpriteSourceSize tpRect   `json:"spriteSourceSize"`
+	SourceSize       tpSize   `json:"sourceSize"`
+	Pivot            *tpPoint `json:"pivot"`
+}
+
+type tpSheet struct {
+	Frames json.RawMessage `json:"frames"`
+	Meta   struct {
+		Image string `json:"image"`
+	} `json:"meta"`
+}
+
+// LoadTexturePacker loads the metadata in TexturePacker's JSON format, both JSON (Hash) and JSON (Array), and
+// returns the sprite sheet on the given image.
+//
+// If a frame doesn't have a pivot, the pivot is (0.5, 0.5).
+func LoadTexturePacker(data []byte, img *ebiten.Image) (*Sheet, error) {
+	var tp tpSheet
+	if err := json.Unmarshal(data, &tp); err != nil {
+		return nil, err
+	}
+
+	var frames []tpFrame
+	var array []tpFrame
+	if err := json.Unmarshal(tp.Frames, &array); err == nil {
+		frames = array
+	} else {
+		var hash map[string]tpFrame
+		if err := json.Unmarshal(tp.Frames, &hash); err != nil {
+			return nil, fmt.Errorf("spritesheet: frames must be an array or an object: %v", err)
+		}
+		for name, f := range hash {
+			f.Filename = name
+			frames = append(frames, f)
+		}
+		sort.Slice(frames, func(i, j int) bool {
+			return frames[i].Filename < frames[j].Filename
+		})
+	}
+
+	sheet := newSheet()
+	sheet.ImagePath = tp.Meta.Image
+	for _, f := range frames {
+		// Frame's size is the size before rotating.
+		w, h := f.Frame.W, f.Frame.H
+		if f.Rotated {
+			w, h = h, w
+		}
+		sub, err := subImage(img, f.Frame.X, f.Frame.Y, w, h)
+		if err != nil {
+			return nil, err
+		}
+
+		s := &Sprite{
+			Name:         f.Filename,
+			Image:        sub,
+			Rotated:      f.Rotated,
+			Trimmed:      f.Trimmed,
+			SourceWidth:  f.SourceSize.W,
+			SourceHeight: f.SourceSize.H,
+			OffsetX:      f.SpriteSourceSize.X,
+			OffsetY:      f.SpriteSourceSize.Y,
+			PivotX:       0.5,
+			PivotY:       0.5,
+		}
+		if s.SourceWidth == 0 && s.SourceHeight == 0 {
+			s.SourceWidth, s.SourceHeight = f.Frame.W, f.Frame.H
+		}
+		if f.Pivot != nil {
+			s.PivotX, s.PivotY = f.Pivot.X, f.Pivot.Y
+		}
+		if err := sheet.add(s); err != nil {
+			return nil, err
+		}
+	}
+	return sheet, nil
+}
+
+type jsonSprite struct {
+	X      int      `json:"x"`
+	Y      int      `json:"y"`
+	W      int      `json:"w"`
+	H      int      `json:"h"`
+	PivotX *float64 `json:"pivotX"`
+	PivotY *float64 `json:"pivotY"`
+}
+
+func (j *jsonSprite) UnmarshalJSON(data []byte) error {
+	// An array [x, y, w, h] is also accepted.
+	var xs []int
+	if err := json.Unmarshal(data, &xs); err == nil {
+		if len(xs) != 4 {
+			return fmt.Errorf("spritesheet: an array of a sprite must have 4 values but %d", len(xs))
+		}
+		j.X, j.Y, j.W, j.H = xs[0], xs[1], xs[2], xs[3]
+		return nil
+	}
+
+	type sprite jsonSprite
+	var s sprite
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*j = jsonSprite(s)
+	return nil
+}
+
+// LoadJSON loads the metadata in a simple JSON format, and returns the sprite sheet on the given image.
+//
+// The format is an object whose keys are the sprite names and whose values are the regions in the sheet image.
+// A region is either an object with x, y, w and h, optionally with pivotX and pivotY, or an array [x, y, w, h]:
+//
+//     {
+//         "idle": {"x": 0, "y": 0, "w": 16, "h": 16, "pivotX": 0.5, "pivotY": 1},
+//         "jump": [16, 0, 16, 16]
+//     }
+//
+// The object can also be in the "sprites" property of the top-level object. The sprites are not trimmed nor
+// rotated. If a sprite doesn't have a pivot, the pivot is (0.5, 0.5).
+func LoadJSON(data []byte, img *ebiten.Image) (*Sheet, error) {
+	var wrapper struct {
+		Sprites map[string]jsonSprite `json:"sprites"`
+	}
+	sprites := map[string]jsonSprite{}
+	if err := json.Unmarshal(data, &wrapper); err == nil && wrapper.Sprites != nil {
+		sprites = wrapper.Sprites
+	} else if err := json.Unmarshal(data, &sprites); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(sprites))
+	for name := range sprites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sheet := newSheet()
+	for _, name := range names {
+		j := sprites[name]
+		sub, err := subImage(img, j.X, j.Y, j.W, j.H)
+		if err != nil {
+			return nil, err
+		}
+		s := &Sprite{
+			Name:         name,
+			Image:        sub,
+			SourceWidth:  j.W,
+			SourceHeight: j.H,
+			PivotX:       0.5,
+			PivotY:       0.5,
+		}
+		if j.PivotX != nil {
+			s.PivotX = *j.PivotX
+		}
+		if j.PivotY != nil {
+			s.PivotY = *j.PivotY
+		}
+		if err := sheet.add(s); err != nil {
+			return nil, err
+		}
+	}
+	return sheet, nil
+}
@@ -0,0 +1,325 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spritesheet_test
+
+import (
+	"image"
+	"math"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil/spritesheet"
+	t "github.com/hajimehoshi/ebiten/v2/internal/testing"
+)
+
+func TestMain(m *testing.M) {
+	t.MainWithRunLoop(m)
+}
+
+const texturePackerArray = `{
+  "frames": [
+    {
+      "filename": "hero_0.png",
+      "frame": {"x": 0, "y": 0, "w": 16, "h": 8},
+      "rotated": false,
+      "trimmed": true,
+      "spriteSourceSize": {"x": 2, "y": 3, "w": 16, "h": 8},
+      "sourceSize": {"w": 20, "h": 14},
+      "pivot": {"x": 0, "y": 1}
+    },
+    {
+      "filename": "hero_1.png",
+      "frame": {"x": 16, "y": 0, "w": 8, "h": 12},
+      "rotated": true,
+      "trimmed": false,
+      "spriteSourceSize": {"x": 0, "y": 0, "w": 8, "h": 12},
+      "sourceSize": {"w": 8, "h": 12}
+    }
+  ],
+  "meta": {
+    "image": "hero.png"
+  }
+}`
+
+const texturePackerHash = `{
+  "frames": {
+    "b.png": {
+      "frame": {"x": 8, "y": 0, "w": 8, "h": 8}
+    },
+    "a.png": {
+      "frame": {"x": 0, "y": 0, "w": 8, "h": 8}
+    }
+  }
+}`
+
+func TestLoadTexturePacker(t *testing.T) {
+	img := ebiten.NewImage(64, 64)
+	sheet, err := spritesheet.LoadTexturePacker([]byte(texturePackerArray), img)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := sheet.ImagePath, "hero.png"; got != want {
+		t.Errorf("ImagePath: got: %q, want: %q", got, want)
+	}
+	if got, want := sheet.Names(), []string{"hero_0.png", "hero_1.png"}; !equalStrings(got, want) {
+		t.Errorf("Names(): got: %v, want: %v", got, want)
+	}
+	if s := sheet.Sprite("missing.png"); s != nil {
+		t.Errorf("Sprite(\"missing.png\"): got: %v, want: nil", s)
+	}
+
+	s0 := sheet.Sprite("hero_0.png")
+	if got, want := s0.Image.Bounds(), image.Rect(0, 0, 16, 8); got != want {
+		t.Errorf("hero_0.png: bounds: got: %v, want: %v", got, want)
+	}
+	if !s0.Trimmed || s0.Rotated {
+		t.Errorf("hero_0.png: (Trimmed, Rotated): got: (%v, %v), want: (true, false)", s0.Trimmed, s0.Rotated)
+	}
+	if s0.SourceWidth != 20 || s0.SourceHeight != 14 {
+		t.Errorf("hero_0.png: source size: got: (%d, %d), want: (20, 14)", s0.SourceWidth, s0.SourceHeight)
+	}
+	if s0.OffsetX != 2 || s0.OffsetY != 3 {
+		t.Errorf("hero_0.png: offset: got: (%d, %d), want: (2, 3)", s0.OffsetX, s0.OffsetY)
+	}
+	if s0.PivotX != 0 || s0.PivotY != 1 {
+		t.Errorf("hero_0.png: pivot: got: (%v, %v), want: (0, 1)", s0.PivotX, s0.PivotY)
+	}
+
+	// The size of a rotated frame is the size before rotating. The region in the sheet is 12x8.
+	s1 := sheet.Sprite("hero_1.png")
+	if got, want := s1.Image.Bounds(), image.Rect(16, 0, 28, 8); got != want {
+		t.Errorf("hero_1.png: bounds: got: %v, want: %v", got, want)
+	}
+	if !s1.Rotated {
+		t.Errorf("hero_1.png: Rotated: got: false, want: true")
+	}
+	if s1.PivotX != 0.5 || s1.PivotY != 0.5 {
+		t.Errorf("hero_1.png: pivot: got: (%v, %v), want: (0.5, 0.5)", s1.PivotX, s1.PivotY)
+	}
+}
+
+func TestLoadTexturePackerHash(t *testing.T) {
+	img := ebiten.NewImage(16, 8)
+	sheet, err := spritesheet.LoadTexturePacker([]byte(texturePackerHash), img)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The names in an object are sorted.
+	if got, want := sheet.Names(), []string{"a.png", "b.png"}; !equalStrings(got, want) {
+		t.Errorf("Names(): got: %v, want: %v", got, want)
+	}
+
+	// Without sourceSize, the source size is the frame size.
+	s := sheet.Sprite("b.png")
+	if got, want := s.Image.Bounds(), image.Rect(8, 0, 16, 8); got != want {
+		t.Errorf("b.png: bounds: got: %v, want: %v", got, want)
+	}
+	if s.SourceWidth != 8 || s.SourceHeight != 8 {
+		t.Errorf("b.png: source size: got: (%d, %d), want: (8, 8)", s.SourceWidth, s.SourceHeight)
+	}
+}
+
+func TestLoadTexturePackerError(t *testing.T) {
+	cases := []struct {
+		Name string
+		Data string
+	}{
+		{
+			Name: "invalid JSON",
+			Data: `{"frames": `,
+		},
+		{
+			Name: "invalid frames",
+			Data: `{"frames": 1}`,
+		},
+		{
+			Name: "out of bounds",
+			Data: `{"frames": [{"filename": "a", "frame": {"x": 8, "y": 0, "w": 16, "h": 8}}]}`,
+		},
+		{
+			Name: "rotated out of bounds",
+			Data: `{"frames": [{"filename": "a", "frame": {"x": 0, "y": 0, "w": 16, "h": 8}, "rotated": true}]}`,
+		},
+		{
+			Name: "duplicated names",
+			Data: `{"frames": [{"filename": "a", "frame": {"w": 8, "h": 8}}, {"filename": "a", "frame": {"w": 8, "h": 8}}]}`,
+		},
+	}
+
+	img := ebiten.NewImage(16, 8)
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			if _, err := spritesheet.LoadTexturePacker([]byte(c.Data), img); err == nil {
+				t.Errorf("got: nil, want: an error")
+			}
+		})
+	}
+}
+
+func TestLoadJSON(t *testing.T) {
+	cases := []struct {
+		Name string
+		Data string
+	}{
+		{
+			Name: "object",
+			Data: `{"idle": {"x": 0, "y": 0, "w": 16, "h": 16, "pivotX": 0.5, "pivotY": 1}, "jump": [16, 0, 16, 8]}`,
+		},
+		{
+			Name: "sprites property",
+			Data: `{"sprites": {"idle": {"x": 0, "y": 0, "w": 16, "h": 16, "pivotX": 0.5, "pivotY": 1}, "jump": [16, 0, 16, 8]}}`,
+		},
+	}
+
+	img := ebiten.NewImage(32, 16)
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			sheet, err := spritesheet.LoadJSON([]byte(c.Data), img)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := sheet.Names(), []string{"idle", "jump"}; !equalStrings(got, want) {
+				t.Errorf("Names(): got: %v, want: %v", got, want)
+			}
+
+			idle := sheet.Sprite("idle")
+			if got, want := idle.Image.Bounds(), image.Rect(0, 0, 16, 16); got != want {
+				t.Errorf("idle: bounds: got: %v, want: %v", got, want)
+			}
+			if idle.PivotX != 0.5 || idle.PivotY != 1 {
+				t.Errorf("idle: pivot: got: (%v, %v), want: (0.5, 1)", idle.PivotX, idle.PivotY)
+			}
+
+			jump := sheet.Sprite("jump")
+			if got, want := jump.Image.Bounds(), image.Rect(16, 0, 32, 8); got != want {
+				t.Errorf("jump: bounds: got: %v, want: %v", got, want)
+			}
+			if jump.SourceWidth != 16 || jump.SourceHeight != 8 {
+				t.Errorf("jump: source size: got: (%d, %d), want: (16, 8)", jump.SourceWidth, jump.SourceHeight)
+			}
+			if jump.PivotX != 0.5 || jump.PivotY != 0.5 {
+				t.Errorf("jump: pivot: got: (%v, %v), want: (0.5, 0.5)", jump.PivotX, jump.PivotY)
+			}
+		})
+	}
+}
+
+func TestLoadJSONError(t *testing.T) {
+	cases := []struct {
+		Name string
+		Data string
+	}{
+		{
+			Name: "invalid JSON",
+			Data: `{"a": `,
+		},
+		{
+			Name: "short array",
+			Data: `{"a": [0, 0, 8]}`,
+		},
+		{
+			Name: "out of bounds",
+			Data: `{"a": [24, 0, 16, 16]}`,
+		},
+	}
+
+	img := ebiten.NewImage(32, 16)
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			if _, err := spritesheet.LoadJSON([]byte(c.Data), img); err == nil {
+				t.Errorf("got: nil, want: an error")
+			}
+		})
+	}
+}
+
+func TestSpriteGeoM(t *testing.T) {
+	img := ebiten.NewImage(64, 64)
+	sheet, err := spritesheet.LoadTexturePacker([]byte(texturePackerArray), img)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		Name string
+		X    float64
+		Y    float64
+		OutX float64
+		OutY float64
+	}{
+		// The pivot (0, 1) of the 20x14 sprite is at the origin. The trimmed image is at (2, 3) in the sprite.
+		{
+			Name: "hero_0.png",
+			X:    0,
+			Y:    0,
+			OutX: 2,
+			OutY: 3 - 14,
+		},
+		{
+			Name: "hero_0.png",
+			X:    16,
+			Y:    8,
+			OutX: 2 + 16,
+			OutY: 3 + 8 - 14,
+		},
+		// The 8x12 sprite is rotated clockwise in the 12x8 region. The top-right corner of the region is the
+		// top-left corner of the sprite. The pivot (0.5, 0.5) is at the origin.
+		{
+			Name: "hero_1.png",
+			X:    12,
+			Y:    0,
+			OutX: -4,
+			OutY: -6,
+		},
+		{
+			Name: "hero_1.png",
+			X:    0,
+			Y:    8,
+			OutX: 4,
+			OutY: 6,
+		},
+		{
+			Name: "hero_1.png",
+			X:    0,
+			Y:    0,
+			OutX: -4,
+			OutY: 6,
+		},
+	}
+	for _, c := range cases {
+		g := sheet.Sprite(c.Name).GeoM()
+		x, y := g.Apply(c.X, c.Y)
+		if math.Abs(x-c.OutX) > 1e-9 || math.Abs(y-c.OutY) > 1e-9 {
+			t.Errorf("%s: Apply(%v, %v): got: (%v, %v), want: (%v, %v)", c.Name, c.X, c.Y, x, y, c.OutX, c.OutY)
+		}
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -14,10 +14,57 @@
 
 package ebiten
 
+import (
+	"image"
+	"time"
+)
+
 var (
 	ImageToBytes = imageToBytes
 )
 
+// NewImageWithRenderScale creates an image rendered at the given scale like the screen.
+func NewImageWithRenderScale(width, height int, scale float64) *Image {
+	return newImageWithRenderScale(width, height, scale)
+}
+
 func PanicOnErrorAtImageAt() {
 	panicOnErrorAtImageAt = true
 }
+
+type GIFRecording = gifRecording
+
+func NewGIFRecording(f func(data []byte), frameInterval, maxFrames int) *GIFRecording {
+	return newGIFRecording(f, frameInterval, maxFrames)
+}
+
+func (r *gifRecording) ShouldCapture() bool {
+	return r.shouldCapture()
+}
+
+func (r *gifRecording) IsFull() bool {
+	return r.isFull()
+}
+
+func (r *gifRecording) Append(img *image.RGBA, t time.Time) {
+	r.append(img, t)
+}
+
+func (r *gifRecording) Finish() {
+	r.finish()
+}
+
+func (r *gifRecording) Discard() {
+	r.discard()
+}
+
+// GIFRecordingOptionsOf returns the frame interval and the maximum number of frames of the action of the given ID.
+func GIFRecordingOptionsOf(id CaptureHotkeyID) (frameInterval, maxFrames int, ok bool) {
+	theCaptureHotkeys.m.Lock()
+	defer theCaptureHotkeys.m.Unlock()
+	h, ok := theCaptureHotkeys.hotkeys[id]
+	if !ok {
+		return 0, 0, false
+	}
+	return h.frameInterval, h.maxFrames, true
+}
@@ -0,0 +1,58 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten
+
+import (
+	"github.com/hajimehoshi/ebiten/v2/internal/graphicscommand"
+)
+
+// BeginExternal prepares for native rendering outside of Ebiten, e.g. rendering by a 3D library or a video SDK.
+//
+// BeginExternal executes all the drawing commands that Ebiten has queued so far, so that the external rendering
+// is done after them. Call EndExternal after the external rendering finishes, before calling any other Ebiten
+// drawing functions.
+//
+// The external rendering should draw into its own textures or framebuffers. The screen framebuffer is
+// overwritten by Ebiten when the frame is presented. Contents drawn by the external rendering are not managed by
+// Ebiten and are not restored when the graphics context is lost.
+//
+// With OpenGL on desktops, the native functions must be called on the rendering thread. Use RunOnRenderingThread
+// for this.
+//
+// BeginExternal must be called in Update or Draw.
+func BeginExternal() error {
+	return graphicscommand.BeginExternal()
+}
+
+// EndExternal restores the graphics states that Ebiten assumes after native rendering outside of Ebiten.
+//
+// With OpenGL, EndExternal restores the bound program, textures, framebuffer, buffers, viewport, blending and
+// scissor test. The external rendering is responsible for resetting the other states that Ebiten doesn't use
+// (e.g. depth test, face culling and stencil test) to the defaults.
+//
+// EndExternal must be called in Update or Draw after BeginExternal.
+func EndExternal() error {
+	return graphicscommand.EndExternal()
+}
+
+// RunOnRenderingThread calls f on the thread where Ebiten calls the graphics driver's functions, and returns the
+// error f returns.
+//
+// On platforms where there is no dedicated rendering thread, f is called on the current goroutine.
+//
+// RunOnRenderingThread must be called in Update or Draw, between BeginExternal and EndExternal.
+func RunOnRenderingThread(f func() error) error {
+	return graphicscommand.RunOnRenderingThread(f)
+}
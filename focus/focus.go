@@ -0,0 +1,436 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package focus provides focus management for user interfaces navigated with keyboards and gamepads.
+//
+// A Manager holds focusable rectangles, moves the focus among them by directional inputs (arrow keys, D-pads and
+// sticks) and reports activations (Enter, Space or a gamepad's button). The destination of a directional
+// navigation is the nearest rectangle in the direction, so games don't have to define navigation graphs for
+// their menus:
+//
+//	m := focus.NewManager()
+//	m.Add(idStart, image.Rect(100, 100, 220, 130))
+//	m.Add(idOptions, image.Rect(100, 140, 220, 170))
+//	m.Add(idQuit, image.Rect(100, 180, 220, 210))
+//
+//	// In Update
+//	m.Update()
+//	if id, ok := m.JustActivated(); ok {
+//	    // Run the action for id.
+//	}
+package focus
+
+import (
+	"image"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// Direction represents a direction of a navigation.
+type Direction int
+
+const (
+	DirectionUp Direction = iota
+	DirectionDown
+	DirectionLeft
+	DirectionRight
+)
+
+const directionNum = 4
+
+const (
+	// repeatDelay is the number of ticks until a held directional input starts repeating.
+	repeatDelay = 30
+
+	// repeatInterval is the number of ticks between repeated navigations.
+	repeatInterval = 6
+
+	// stickThreshold is the threshold of a stick's axis value to be treated as a directional input.
+	stickThreshold = 0.5
+)
+
+// Bindings represents the inputs that Manager.Update treats as navigations and activations.
+//
+// The gamepad buttons and axes depend on environments. The default bindings follow the standard gamepad layout
+// of the W3C Gamepad specification, which browsers adopt for known gamepads.
+type Bindings struct {
+	// Keys are the keys for navigations, indexed by Direction.
+	Keys [directionNum][]ebiten.Key
+
+	// ActivateKeys are the keys for activations.
+	ActivateKeys []ebiten.Key
+
+	// GamepadButtons are the gamepad buttons for navigations, indexed by Direction.
+	GamepadButtons [directionNum][]ebiten.GamepadButton
+
+	// ActivateGamepadButtons are the gamepad buttons for activations.
+	ActivateGamepadButtons []ebiten.GamepadButton
+
+	// GamepadAxisX and GamepadAxisY are the axes of a stick for navigations.
+	// A negative value disables the axis.
+	GamepadAxisX int
+	GamepadAxisY int
+}
+
+// DefaultBindings returns the default bindings.
+func DefaultBindings() *Bindings {
+	return &Bindings{
+		Keys: [directionNum][]ebiten.Key{
+			DirectionUp:    {ebiten.KeyArrowUp},
+			DirectionDown:  {ebiten.KeyArrowDown},
+			DirectionLeft:  {ebiten.KeyArrowLeft},
+			DirectionRight: {ebiten.KeyArrowRight},
+		},
+		ActivateKeys: []ebiten.Key{ebiten.KeyEnter, ebiten.KeySpace},
+		GamepadButtons: [directionNum][]ebiten.GamepadButton{
+			DirectionUp:    {ebiten.GamepadButton12},
+			DirectionDown:  {ebiten.GamepadButton13},
+			DirectionLeft:  {ebiten.GamepadButton14},
+			DirectionRight: {ebiten.GamepadButton15},
+		},
+		ActivateGamepadButtons: []ebiten.GamepadButton{ebiten.GamepadButton0},
+		GamepadAxisX:           0,
+		GamepadAxisY:           1,
+	}
+}
+
+type item struct {
+	id   int
+	rect image.Rectangle
+}
+
+// Manager manages focusable rectangles and the focus among them.
+//
+// The zero value is not usable. Use NewManager to create a Manager.
+type Manager struct {
+	items    []item
+	focused  int
+	hasFocus bool
+	wrap     bool
+	bindings *Bindings
+
+	durations [directionNum]int
+
+	focusChanged bool
+	activated    bool
+}
+
+// NewManager creates a new Manager with the default bindings.
+func NewManager() *Manager {
+	return &Manager{
+		bindings: DefaultBindings(),
+	}
+}
+
+// SetBindings sets the bindings of the inputs.
+//
+// If bindings is nil, Update ignores all the inputs.
+func (m *Manager) SetBindings(bindings *Bindings) {
+	m.bindings = bindings
+}
+
+// SetWrap sets whether a navigation wraps around to the opposite side when there is no rectangle in the
+// direction.
+//
+// The default value is false.
+func (m *Manager) SetWrap(wrap bool) {
+	m.wrap = wrap
+}
+
+// Add adds a focusable rectangle with the given ID.
+//
+// If a rectangle with the same ID already exists, Add updates its rectangle.
+func (m *Manager) Add(id int, rect image.Rectangle) {
+	if i := m.index(id); i >= 0 {
+		m.items[i].rect = rect
+		return
+	}
+	m.items = append(m.items, item{
+		id:   id,
+		rect: rect,
+	})
+}
+
+// Remove removes the rectangle with the given ID.
+//
+// If the rectangle is focused, the focus moves to the nearest remaining rectangle.
+func (m *Manager) Remove(id int) {
+	i := m.index(id)
+	if i < 0 {
+		return
+	}
+	rect := m.items[i].rect
+	m.items = append(m.items[:i], m.items[i+1:]...)
+	if !m.hasFocus || m.focused != id {
+		return
+	}
+
+	m.hasFocus = false
+	m.focusChanged = true
+	if n := m.nearest(rect); n >= 0 {
+		m.focused = m.items[n].id
+		m.hasFocus = true
+	}
+}
+
+// Clear removes all the rectangles and the focus.
+func (m *Manager) Clear() {
+	m.items = m.items[:0]
+	if m.hasFocus {
+		m.hasFocus = false
+		m.focusChanged = true
+	}
+}
+
+// Focused returns the ID of the focused rectangle.
+//
+// Focused returns false when no rectangle is focused.
+func (m *Manager) Focused() (int, bool) {
+	return m.focused, m.hasFocus
+}
+
+// SetFocused focuses the rectangle with the given ID.
+//
+// SetFocused returns false when there is no rectangle with the ID.
+func (m *Manager) SetFocused(id int) bool {
+	if m.index(id) < 0 {
+		return false
+	}
+	m.setFocused(id)
+	return true
+}
+
+func (m *Manager) setFocused(id int) {
+	if m.hasFocus && m.focused == id {
+		return
+	}
+	m.focused = id
+	m.hasFocus = true
+	m.focusChanged = true
+}
+
+// Navigate moves the focus to the nearest rectangle in the given direction.
+//
+// If no rectangle is focused, Navigate focuses the first added rectangle.
+//
+// Navigate returns false when the focus doesn't move.
+func (m *Manager) Navigate(dir Direction) bool {
+	if len(m.items) == 0 {
+		return false
+	}
+	if !m.hasFocus {
+		m.setFocused(m.items[0].id)
+		return true
+	}
+
+	cur := m.index(m.focused)
+	if cur < 0 {
+		return false
+	}
+	next := m.neighbor(cur, dir)
+	if next < 0 && m.wrap {
+		next = m.wrapped(cur, dir)
+	}
+	if next < 0 {
+		return false
+	}
+	m.setFocused(m.items[next].id)
+	return true
+}
+
+// Update updates the focus and the activation by the inputs.
+//
+// Update should be called once per tick in the game's Update.
+func (m *Manager) Update() {
+	m.focusChanged = false
+	m.activated = false
+
+	b := m.bindings
+	if b == nil {
+		return
+	}
+
+	gamepadIDs := ebiten.GamepadIDs()
+
+	for d := Direction(0); d < directionNum; d++ {
+		if !m.isDirectionPressed(d, gamepadIDs) {
+			m.durations[d] = 0
+			continue
+		}
+		m.durations[d]++
+		if n := m.durations[d]; n == 1 || (n >= repeatDelay && (n-repeatDelay)%repeatInterval == 0) {
+			m.Navigate(d)
+		}
+	}
+
+	if !m.hasFocus {
+		return
+	}
+	for _, k := range b.ActivateKeys {
+		if inpututil.IsKeyJustPressed(k) {
+			m.activated = true
+			return
+		}
+	}
+	for _, id := range gamepadIDs {
+		for _, btn := range b.ActivateGamepadButtons {
+			if inpututil.IsGamepadButtonJustPressed(id, btn) {
+				m.activated = true
+				return
+			}
+		}
+	}
+}
+
+// IsFocusJustChanged reports whether the focus changed in the current tick.
+func (m *Manager) IsFocusJustChanged() bool {
+	return m.focusChanged
+}
+
+// JustActivated returns the ID of the focused rectangle if it is activated in the current tick.
+//
+// JustActivated returns false when no rectangle is activated.
+func (m *Manager) JustActivated() (int, bool) {
+	if !m.activated || !m.hasFocus {
+		return 0, false
+	}
+	return m.focused, true
+}
+
+func (m *Manager) isDirectionPressed(dir Direction, gamepadIDs []ebiten.GamepadID) bool {
+	b := m.bindings
+	for _, k := range b.Keys[dir] {
+		if ebiten.IsKeyPressed(k) {
+			return true
+		}
+	}
+	for _, id := range gamepadIDs {
+		for _, btn := range b.GamepadButtons[dir] {
+			if ebiten.IsGamepadButtonPressed(id, btn) {
+				return true
+			}
+		}
+
+		var axis int
+		var sign float64
+		switch dir {
+		case DirectionUp:
+			axis, sign = b.GamepadAxisY, -1
+		case DirectionDown:
+			axis, sign = b.GamepadAxisY, 1
+		case DirectionLeft:
+			axis, sign = b.GamepadAxisX, -1
+		case DirectionRight:
+			axis, sign = b.GamepadAxisX, 1
+		}
+		if axis < 0 || axis >= ebiten.GamepadAxisNum(id) {
+			continue
+		}
+		if ebiten.GamepadAxis(id, axis)*sign >= stickThreshold {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Manager) index(id int) int {
+	for i, item := range m.items {
+		if item.id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func center(r image.Rectangle) (float64, float64) {
+	return float64(r.Min.X+r.Max.X) / 2, float64(r.Min.Y+r.Max.Y) / 2
+}
+
+// axes returns the distance along the direction and the distance perpendicular to the direction from (x0, y0)
+// to (x1, y1).
+func axes(dir Direction, x0, y0, x1, y1 float64) (float64, float64) {
+	switch dir {
+	case DirectionUp:
+		return y0 - y1, math.Abs(x1 - x0)
+	case DirectionDown:
+		return y1 - y0, math.Abs(x1 - x0)
+	case DirectionLeft:
+		return x0 - x1, math.Abs(y1 - y0)
+	case DirectionRight:
+		return x1 - x0, math.Abs(y1 - y0)
+	}
+	panic("focus: invalid direction")
+}
+
+// perpendicularWeight is the weight of the perpendicular distance in the score of a candidate.
+// A bigger value prefers the candidates aligned with the current rectangle.
+const perpendicularWeight = 2
+
+// neighbor returns the index of the nearest item in the direction from the item at cur.
+func (m *Manager) neighbor(cur int, dir Direction) int {
+	x0, y0 := center(m.items[cur].rect)
+	best := -1
+	bestScore := math.Inf(1)
+	for i, item := range m.items {
+		if i == cur {
+			continue
+		}
+		x1, y1 := center(item.rect)
+		along, perp := axes(dir, x0, y0, x1, y1)
+		if along <= 0 {
+			continue
+		}
+		if s := along + perpendicularWeight*perp; s < bestScore {
+			best = i
+			bestScore = s
+		}
+	}
+	return best
+}
+
+// wrapped returns the index of the farthest item on the opposite side of the direction from the item at cur.
+func (m *Manager) wrapped(cur int, dir Direction) int {
+	x0, y0 := center(m.items[cur].rect)
+	best := -1
+	bestScore := math.Inf(1)
+	for i, item := range m.items {
+		if i == cur {
+			continue
+		}
+		x1, y1 := center(item.rect)
+		along, perp := axes(dir, x0, y0, x1, y1)
+		if s := along + perpendicularWeight*perp; s < bestScore {
+			best = i
+			bestScore = s
+		}
+	}
+	return best
+}
+
+// nearest returns the index of the item nearest to rect.
+func (m *Manager) nearest(rect image.Rectangle) int {
+	x0, y0 := center(rect)
+	best := -1
+	bestDist := math.Inf(1)
+	for i, item := range m.items {
+		x1, y1 := center(item.rect)
+		if d := math.Hypot(x1-x0, y1-y0); d < bestDist {
+			best = i
+			bestDist = d
+		}
+	}
+	return best
+}
@@ -0,0 +1,96 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package focus_test
+
+import (
+	"image"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2/focus"
+)
+
+// newGrid creates a Manager with a 3x2 grid:
+//
+//	0 1 2
+//	3 4 5
+func newGrid() *focus.Manager {
+	m := focus.NewManager()
+	for i := 0; i < 6; i++ {
+		x := (i % 3) * 100
+		y := (i / 3) * 50
+		m.Add(i, image.Rect(x, y, x+80, y+30))
+	}
+	return m
+}
+
+func TestNavigate(t *testing.T) {
+	cases := []struct {
+		Wrap  bool
+		Start int
+		Dir   focus.Direction
+		Want  int
+		Moved bool
+	}{
+		{Start: 0, Dir: focus.DirectionRight, Want: 1, Moved: true},
+		{Start: 1, Dir: focus.DirectionDown, Want: 4, Moved: true},
+		{Start: 5, Dir: focus.DirectionLeft, Want: 4, Moved: true},
+		{Start: 3, Dir: focus.DirectionUp, Want: 0, Moved: true},
+		{Start: 2, Dir: focus.DirectionRight, Want: 2, Moved: false},
+		{Start: 0, Dir: focus.DirectionUp, Want: 0, Moved: false},
+		{Wrap: true, Start: 2, Dir: focus.DirectionRight, Want: 0, Moved: true},
+		{Wrap: true, Start: 4, Dir: focus.DirectionDown, Want: 1, Moved: true},
+		{Wrap: true, Start: 3, Dir: focus.DirectionLeft, Want: 5, Moved: true},
+	}
+	for _, c := range cases {
+		m := newGrid()
+		m.SetWrap(c.Wrap)
+		if !m.SetFocused(c.Start) {
+			t.Fatalf("SetFocused(%d) failed", c.Start)
+		}
+		if got := m.Navigate(c.Dir); got != c.Moved {
+			t.Errorf("wrap: %v, start: %d, dir: %d: Navigate(): got: %v, want: %v", c.Wrap, c.Start, c.Dir, got, c.Moved)
+		}
+		if got, _ := m.Focused(); got != c.Want {
+			t.Errorf("wrap: %v, start: %d, dir: %d: Focused(): got: %d, want: %d", c.Wrap, c.Start, c.Dir, got, c.Want)
+		}
+	}
+}
+
+func TestNavigateWithoutFocus(t *testing.T) {
+	m := newGrid()
+	if _, ok := m.Focused(); ok {
+		t.Errorf("Focused(): got: true, want: false")
+	}
+	if !m.Navigate(focus.DirectionDown) {
+		t.Errorf("Navigate(): got: false, want: true")
+	}
+	if got, ok := m.Focused(); !ok || got != 0 {
+		t.Errorf("Focused(): got: (%d, %v), want: (0, true)", got, ok)
+	}
+}
+
+func TestRemoveFocused(t *testing.T) {
+	m := newGrid()
+	m.SetFocused(4)
+	m.Remove(4)
+	got, ok := m.Focused()
+	if !ok {
+		t.Fatalf("Focused(): got: false, want: true")
+	}
+	// 1 is the nearest to 4 since the rows are closer than the columns.
+	if got != 1 {
+		t.Errorf("Focused(): got: %d, want: 1", got)
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten
+
+import (
+	"sync"
+)
+
+type frameStep struct {
+	paused bool
+	steps  int
+
+	keysEnabled     bool
+	pauseKey        Key
+	stepKey         Key
+	pauseKeyPressed bool
+	stepKeyPressed  bool
+
+	m sync.Mutex
+}
+
+var theFrameStep frameStep
+
+// IsUpdatePaused reports whether Update is paused by SetUpdatePaused or the pause key.
+//
+// IsUpdatePaused is concurrent-safe.
+func IsUpdatePaused() bool {
+	theFrameStep.m.Lock()
+	defer theFrameStep.m.Unlock()
+	return theFrameStep.paused
+}
+
+// SetUpdatePaused pauses or resumes calling Update.
+//
+// While Update is paused, Draw is still called every frame, and StepUpdate advances exactly one tick.
+// This is useful to debug glitches of physics and animations tick by tick.
+//
+// The first Update is called even while Update is paused, so that Update can be used for initialization.
+//
+// SetUpdatePaused is concurrent-safe.
+func SetUpdatePaused(paused bool) {
+	theFrameStep.m.Lock()
+	defer theFrameStep.m.Unlock()
+	theFrameStep.paused = paused
+	theFrameStep.steps = 0
+}
+
+// StepUpdate makes Update called once in the next frame while Update is paused.
+// If StepUpdate is called multiple times, Update is called once per frame as many times.
+//
+// StepUpdate does nothing if Update is not paused.
+//
+// StepUpdate is concurrent-safe.
+func StepUpdate() {
+	theFrameStep.m.Lock()
+	defer theFrameStep.m.Unlock()
+	if !theFrameStep.paused {
+		return
+	}
+	theFrameStep.steps++
+}
+
+// SetUpdatePauseKeys sets the keys to pause and step Update.
+//
+// Pressing pauseKey toggles pausing Update like SetUpdatePaused, and pressing stepKey advances one tick like
+// StepUpdate while Update is paused. The keys are still passed to the game.
+//
+// The keys are disabled by default.
+//
+// SetUpdatePauseKeys is concurrent-safe.
+func SetUpdatePauseKeys(pauseKey, stepKey Key) {
+	theFrameStep.m.Lock()
+	defer theFrameStep.m.Unlock()
+	theFrameStep.keysEnabled = true
+	theFrameStep.pauseKey = pauseKey
+	theFrameStep.stepKey = stepKey
+}
+
+// ResetUpdatePauseKeys disables the keys set by SetUpdatePauseKeys.
+//
+// ResetUpdatePauseKeys is concurrent-safe.
+func ResetUpdatePauseKeys() {
+	theFrameStep.m.Lock()
+	defer theFrameStep.m.Unlock()
+	theFrameStep.keysEnabled = false
+}
+
+// updateCount returns the number of Update calls in this frame, where n is the number by the clock.
+func (f *frameStep) updateCount(n int) int {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	if f.keysEnabled {
+		pressed := IsKeyPressed(f.pauseKey)
+		if pressed && !f.pauseKeyPressed {
+			f.paused = !f.paused
+			f.steps = 0
+		}
+		f.pauseKeyPressed = pressed
+
+		pressed = IsKeyPressed(f.stepKey)
+		if pressed && !f.stepKeyPressed && f.paused {
+			f.steps++
+		}
+		f.stepKeyPressed = pressed
+	}
+
+	if !f.paused {
+		return n
+	}
+	if f.steps > 0 {
+		f.steps--
+		return 1
+	}
+	return 0
+}
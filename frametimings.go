@@ -0,0 +1,67 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten
+
+import (
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2/internal/stats"
+)
+
+// FrameTimings represents the time durations spent in the phases of a frame.
+type FrameTimings struct {
+	// Update is the total time of the game's Update calls in the frame.
+	// Update is 0 when Update is not called in the frame.
+	Update time.Duration
+
+	// Draw is the time of the game's Draw and rendering the screen on CPU, i.e. encoding the graphics commands.
+	// If pixels are read from images in Draw, e.g. by (*Image).At, Draw includes the time to wait for GPU.
+	Draw time.Duration
+
+	// GPU is the time to submit the graphics commands to the GPU driver and to wait for the driver.
+	//
+	// As GPU works asynchronously with CPU, the actual execution time on GPU can be included in Present
+	// instead. On macOS and iOS, GPU includes the time to present the screen.
+	GPU time.Duration
+
+	// Present is the time to wait for swapping the screen buffers, which typically includes the wait for
+	// the vertical sync.
+	//
+	// Present is measured only with OpenGL on desktops, and is always 0 on the other environments.
+	Present time.Duration
+}
+
+// Total returns the sum of the durations.
+func (f FrameTimings) Total() time.Duration {
+	return f.Update + f.Draw + f.GPU + f.Present
+}
+
+// LastFrameTimings returns the time durations spent in the phases of the last frame.
+//
+// LastFrameTimings is useful to log where the time goes, or to adjust the quality settings automatically.
+// For example, if GPU is dominant, decreasing the number of the draw calls or the screen size might help.
+//
+// LastFrameTimings returns zero values before the first frame ends.
+//
+// LastFrameTimings is concurrent-safe.
+func LastFrameTimings() FrameTimings {
+	ts := stats.PhaseTimes()
+	return FrameTimings{
+		Update:  ts[stats.PhaseUpdate],
+		Draw:    ts[stats.PhaseDraw],
+		GPU:     ts[stats.PhaseGPU],
+		Present: ts[stats.PhasePresent],
+	}
+}
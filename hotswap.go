@@ -0,0 +1,58 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten
+
+import (
+	"github.com/hajimehoshi/ebiten/v2/internal/hotswap"
+)
+
+func init() {
+	hotswap.Image = func(dst, src interface{}) {
+		swapImage(dst.(*Image), src.(*Image))
+	}
+	hotswap.Shader = func(dst, src interface{}) {
+		swapShader(dst.(*Shader), src.(*Shader))
+	}
+}
+
+// swapImage replaces the content of dst with src's, and disposes the old content of dst.
+//
+// The sub-images of dst created before swapImage still refer to the old content.
+func swapImage(dst, src *Image) {
+	dst.copyCheck()
+	src.copyCheck()
+	if dst.isSubImage() || src.isSubImage() {
+		panic("ebiten: a sub-image cannot be swapped")
+	}
+	if !dst.isDisposed() {
+		dst.mipmap.MarkDisposed()
+	}
+	dst.mipmap = src.mipmap
+	dst.bounds = src.bounds
+	src.mipmap = nil
+	theResources.removeImage(src)
+}
+
+// swapShader replaces the content of dst with src's, and disposes the old content of dst.
+func swapShader(dst, src *Shader) {
+	if dst.shader != nil {
+		dst.shader.MarkDisposed()
+	}
+	dst.shader = src.shader
+	dst.uniformNames = src.uniformNames
+	dst.uniformTypes = src.uniformTypes
+	src.shader = nil
+	theResources.removeShader(src)
+}
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"image"
 	"image/color"
+	"unsafe"
 
 	"github.com/hajimehoshi/ebiten/v2/internal/driver"
 	"github.com/hajimehoshi/ebiten/v2/internal/graphics"
@@ -32,19 +33,23 @@ var panicOnErrorAtImageAt bool
 // The pixel format is alpha-premultiplied RGBA.
 // Image implements image.Image and draw.Image.
 type Image struct {
-	// addr holds self to check copying.
+	// addr holds the address of self to check copying.
 	// See strings.Builder for similar examples.
-	addr *Image
+	// addr is not a pointer, or the self reference would prevent the image from being finalized.
+	addr uintptr
 
 	mipmap *mipmap.Mipmap
 
 	bounds   image.Rectangle
 	original *Image
 	screen   bool
+
+	// renderScale is the scale of the texture relative to bounds. 0 means 1. See SetRenderScale.
+	renderScale float64
 }
 
 func (i *Image) copyCheck() {
-	if i.addr != i {
+	if i.addr != uintptr(unsafe.Pointer(i)) {
 		panic("ebiten: illegal use of non-zero Image copied by value")
 	}
 }
@@ -71,7 +76,7 @@ func (i *Image) Clear() {
 }
 
 var (
-	emptyImage    = NewImage(3, 3)
+	emptyImage    = newImage(3, 3)
 	emptySubImage = emptyImage.SubImage(image.Rect(1, 1, 2, 2)).(*Image)
 )
 
@@ -94,15 +99,7 @@ func (i *Image) Fill(clr color.Color) {
 	op := &DrawImageOptions{}
 	op.GeoM.Scale(float64(w), float64(h))
 
-	r, g, b, a := clr.RGBA()
-	var rf, gf, bf, af float64
-	if a > 0 {
-		rf = float64(r) / float64(a)
-		gf = float64(g) / float64(a)
-		bf = float64(b) / float64(a)
-		af = float64(a) / 0xffff
-	}
-	op.ColorM.Scale(rf, gf, bf, af)
+	op.ColorM.Scale(colorToScale(clr))
 	op.CompositeMode = CompositeModeCopy
 
 	i.DrawImage(emptySubImage, op)
@@ -134,6 +131,16 @@ type DrawImageOptions struct {
 	Filter Filter
 }
 
+// Reset resets the options to the default values.
+//
+// Reusing one DrawImageOptions value with Reset for many draw calls avoids allocating new options every time.
+func (o *DrawImageOptions) Reset() {
+	o.GeoM.Reset()
+	o.ColorM.Reset()
+	o.CompositeMode = 0
+	o.Filter = 0
+}
+
 // DrawImage draws the given image on the image i.
 //
 // DrawImage accepts the options. For details, see the document of
@@ -179,6 +186,34 @@ func (i *Image) DrawImage(img *Image, options *DrawImageOptions) {
 		return
 	}
 
+	i.drawImage(img, img.Bounds(), options)
+}
+
+// DrawSubImage draws the given region of the given image on the image i.
+//
+// DrawSubImage(img, r, op) works in the same way as DrawImage(img.SubImage(r).(*Image), op), but DrawSubImage
+// doesn't allocate a sub-image. The region r is clipped by img's bounds.
+//
+// When the image i is disposed, DrawSubImage does nothing.
+// When the given image img is disposed, DrawSubImage panics.
+func (i *Image) DrawSubImage(img *Image, r image.Rectangle, options *DrawImageOptions) {
+	i.copyCheck()
+
+	if img.isDisposed() {
+		panic("ebiten: the given image to DrawSubImage must not be disposed")
+	}
+	if i.isDisposed() {
+		return
+	}
+
+	r = r.Intersect(img.Bounds())
+	if r.Empty() {
+		return
+	}
+	i.drawImage(img, r, options)
+}
+
+func (i *Image) drawImage(img *Image, bounds image.Rectangle, options *DrawImageOptions) {
 	dstBounds := i.Bounds()
 	dstRegion := driver.Region{
 		X:      float32(dstBounds.Min.X),
@@ -193,11 +228,20 @@ func (i *Image) DrawImage(img *Image, options *DrawImageOptions) {
 		options = &DrawImageOptions{}
 	}
 
-	bounds := img.Bounds()
 	mode := driver.CompositeMode(options.CompositeMode)
 	filter := driver.Filter(options.Filter)
 
-	a, b, c, d, tx, ty := options.GeoM.elements32()
+	geoM := options.GeoM
+	if s := img.textureScale(); s != 1 {
+		// The source image is rendered at the render scale e.g. the screen. Use the corresponding region of the
+		// texture, and shrink it to the logical size.
+		bounds = scaleRect(bounds, s)
+		var g GeoM
+		g.Scale(1/s, 1/s)
+		g.Concat(geoM)
+		geoM = g
+	}
+	a, b, c, d, tx, ty := geoM.elements32()
 
 	sx0 := float32(bounds.Min.X)
 	sy0 := float32(bounds.Min.Y)
@@ -205,9 +249,152 @@ func (i *Image) DrawImage(img *Image, options *DrawImageOptions) {
 	sy1 := float32(bounds.Max.Y)
 	vs := graphics.QuadVertices(sx0, sy0, sx1, sy1, a, b, c, d, tx, ty, 1, 1, 1, 1)
 	is := graphics.QuadIndices()
+	dstRegion = i.applyRenderScale(vs, dstRegion)
 
 	srcs := [graphics.ShaderImageNum]*mipmap.Mipmap{img.mipmap}
-	i.mipmap.DrawTriangles(srcs, vs, is, options.ColorM.impl, mode, filter, driver.AddressUnsafe, dstRegion, driver.Region{}, [graphics.ShaderImageNum - 1][2]float32{}, nil, nil, canSkipMipmap(options.GeoM, filter))
+	i.mipmap.DrawTriangles(srcs, vs, is, options.ColorM.impl, mode, filter, driver.AddressUnsafe, dstRegion, driver.Region{}, [graphics.ShaderImageNum - 1][2]float32{}, nil, nil, canSkipMipmap(geoM, filter))
+}
+
+// DrawImageAt draws the given image on the image i at (x, y) without any transformation.
+//
+// DrawImageAt(img, x, y) works in the same way as DrawImage with the options that only translate by (x, y), but
+// DrawImageAt skips processing the options. DrawImageAt is the fastest way to draw a sprite without scaling,
+// rotating or changing colors.
+//
+// When the image i is disposed, DrawImageAt does nothing.
+// When the given image img is disposed or is the same as i, DrawImageAt panics.
+func (i *Image) DrawImageAt(img *Image, x, y float64) {
+	i.copyCheck()
+
+	if img.isDisposed() {
+		panic("ebiten: the given image to DrawImageAt must not be disposed")
+	}
+	if i == img {
+		panic("ebiten: the destination image must be different from the source image at DrawImageAt")
+	}
+	if i.isDisposed() {
+		return
+	}
+
+	if img.textureScale() != 1 {
+		// The source image is rendered at the render scale. Let drawImage shrink it to the logical size.
+		op := &DrawImageOptions{}
+		op.GeoM.Translate(x, y)
+		i.drawImage(img, img.Bounds(), op)
+		return
+	}
+
+	dstBounds := i.bounds
+	dstRegion := driver.Region{
+		X:      float32(dstBounds.Min.X),
+		Y:      float32(dstBounds.Min.Y),
+		Width:  float32(dstBounds.Dx()),
+		Height: float32(dstBounds.Dy()),
+	}
+
+	b := img.bounds
+	vs := graphics.QuadVertices(float32(b.Min.X), float32(b.Min.Y), float32(b.Max.X), float32(b.Max.Y), 1, 0, 0, 1, float32(x), float32(y), 1, 1, 1, 1)
+	is := graphics.QuadIndices()
+	dstRegion = i.applyRenderScale(vs, dstRegion)
+
+	srcs := [graphics.ShaderImageNum]*mipmap.Mipmap{img.mipmap}
+	i.mipmap.DrawTriangles(srcs, vs, is, nil, driver.CompositeModeSourceOver, driver.FilterNearest, driver.AddressUnsafe, dstRegion, driver.Region{}, [graphics.ShaderImageNum - 1][2]float32{}, nil, nil, true)
+}
+
+// DrawImagePart represents a part of an image drawn by DrawImageParts.
+type DrawImagePart struct {
+	// SrcRect is the region of the source image to draw.
+	// SrcRect is clipped by the source image's bounds.
+	SrcRect image.Rectangle
+
+	// GeoM is a geometry matrix of the part.
+	// GeoM is applied before DrawImageOptions.GeoM.
+	// The default (zero) value is identity, which draws the part at (0, 0).
+	GeoM GeoM
+}
+
+// DrawImageParts draws the given parts of the given image on the image i with one draw call.
+//
+// DrawImageParts works in the same way as calling DrawImage for each part with the sub-image of SrcRect and
+// the options whose GeoM is the part's GeoM concatenated with options.GeoM, but DrawImageParts is much more
+// efficient. DrawImageParts is useful to draw many sprites from one sprite sheet, e.g. tiles of a tile map or
+// particles.
+//
+// DrawImageParts doesn't retain parts. The caller can reuse the slice after DrawImageParts returns.
+//
+// When the image i is disposed, DrawImageParts does nothing.
+// When the given image img is disposed, DrawImageParts panics.
+func (i *Image) DrawImageParts(img *Image, parts []DrawImagePart, options *DrawImageOptions) {
+	i.copyCheck()
+
+	if img.isDisposed() {
+		panic("ebiten: the given image to DrawImageParts must not be disposed")
+	}
+	if i.isDisposed() {
+		return
+	}
+
+	dstBounds := i.Bounds()
+	dstRegion := driver.Region{
+		X:      float32(dstBounds.Min.X),
+		Y:      float32(dstBounds.Min.Y),
+		Width:  float32(dstBounds.Dx()),
+		Height: float32(dstBounds.Dy()),
+	}
+
+	if options == nil {
+		options = &DrawImageOptions{}
+	}
+
+	bounds := img.Bounds()
+	mode := driver.CompositeMode(options.CompositeMode)
+	filter := driver.Filter(options.Filter)
+	srcs := [graphics.ShaderImageNum]*mipmap.Mipmap{img.mipmap}
+	scale := img.textureScale()
+
+	const maxQuadNum = graphics.IndicesNum / 6
+	for len(parts) > 0 {
+		n := len(parts)
+		if n > maxQuadNum {
+			n = maxQuadNum
+		}
+
+		vs := graphics.Vertices(4 * n)
+		is := graphics.Indices(6 * n)
+		var quadNum int
+		skipMipmap := true
+		for _, p := range parts[:n] {
+			r := p.SrcRect.Intersect(bounds)
+			if r.Empty() {
+				continue
+			}
+			geom := p.GeoM
+			geom.Concat(options.GeoM)
+			if scale != 1 {
+				// See the comment at drawImage.
+				r = scaleRect(r, scale)
+				var g GeoM
+				g.Scale(1/scale, 1/scale)
+				g.Concat(geom)
+				geom = g
+			}
+			if !canSkipMipmap(geom, filter) {
+				skipMipmap = false
+			}
+			a, b, c, d, tx, ty := geom.elements32()
+			graphics.PutQuadVertices(vs[4*quadNum*graphics.VertexFloatNum:], float32(r.Min.X), float32(r.Min.Y), float32(r.Max.X), float32(r.Max.Y), a, b, c, d, tx, ty, 1, 1, 1, 1)
+			base := uint16(4 * quadNum)
+			copy(is[6*quadNum:], []uint16{base, base + 1, base + 2, base + 1, base + 2, base + 3})
+			quadNum++
+		}
+		parts = parts[n:]
+
+		if quadNum == 0 {
+			continue
+		}
+		dr := i.applyRenderScale(vs[:4*quadNum*graphics.VertexFloatNum], dstRegion)
+		i.mipmap.DrawTriangles(srcs, vs[:4*quadNum*graphics.VertexFloatNum], is[:6*quadNum], options.ColorM.impl, mode, filter, driver.AddressUnsafe, dr, driver.Region{}, [graphics.ShaderImageNum - 1][2]float32{}, nil, nil, skipMipmap)
+	}
 }
 
 // Vertex represents a vertex passed to DrawTriangles.
@@ -276,6 +463,9 @@ const MaxIndicesNum = graphics.IndicesNum
 //
 // If len(indices) is more than MaxIndicesNum, DrawTriangles panics.
 //
+// DrawTriangles doesn't retain vertices and indices after returning. The caller can reuse them for the next call
+// e.g. by TrianglesBuffer.
+//
 // The rule in which DrawTriangles works effectively is same as DrawImage's.
 //
 // When the given image is disposed, DrawTriangles panics.
@@ -338,11 +528,13 @@ func (i *Image) DrawTriangles(vertices []Vertex, indices []uint16, img *Image, o
 		vs[i*graphics.VertexFloatNum+6] = v.ColorB
 		vs[i*graphics.VertexFloatNum+7] = v.ColorA
 	}
-	is := make([]uint16, len(indices))
+	// Use the indices backend instead of calling make to reduce GCs.
+	is := graphics.Indices(len(indices))
 	copy(is, indices)
 
 	srcs := [graphics.ShaderImageNum]*mipmap.Mipmap{img.mipmap}
 
+	dstRegion = i.applyRenderScale(vs, dstRegion)
 	i.mipmap.DrawTriangles(srcs, vs, is, options.ColorM.impl, mode, filter, address, dstRegion, sr, [graphics.ShaderImageNum - 1][2]float32{}, nil, nil, false)
 }
 
@@ -382,6 +574,9 @@ func init() {
 //
 // If len(indices) is more than MaxIndicesNum, DrawTrianglesShader panics.
 //
+// DrawTrianglesShader doesn't retain vertices and indices after returning. The caller can reuse them for the next call
+// e.g. by TrianglesBuffer.
+//
 // When a specified image is non-nil and is disposed, DrawTrianglesShader panics.
 //
 // When the image i is disposed, DrawTrianglesShader does nothing.
@@ -427,7 +622,8 @@ func (i *Image) DrawTrianglesShader(vertices []Vertex, indices []uint16, shader
 		vs[i*graphics.VertexFloatNum+6] = v.ColorB
 		vs[i*graphics.VertexFloatNum+7] = v.ColorA
 	}
-	is := make([]uint16, len(indices))
+	// Use the indices backend instead of calling make to reduce GCs.
+	is := graphics.Indices(len(indices))
 	copy(is, indices)
 
 	var imgs [graphics.ShaderImageNum]*mipmap.Mipmap
@@ -479,6 +675,7 @@ func (i *Image) DrawTrianglesShader(vertices []Vertex, indices []uint16, shader
 	}
 
 	us := shader.convertUniforms(options.Uniforms)
+	dstRegion = i.applyRenderScale(vs, dstRegion)
 	i.mipmap.DrawTriangles(imgs, vs, is, nil, mode, driver.FilterNearest, driver.AddressUnsafe, dstRegion, sr, offsets, shader.shader, us, false)
 }
 
@@ -591,6 +788,7 @@ func (i *Image) DrawRectShader(width, height int, shader *Shader, options *DrawR
 	}
 
 	us := shader.convertUniforms(options.Uniforms)
+	dstRegion = i.applyRenderScale(vs, dstRegion)
 	i.mipmap.DrawTriangles(imgs, vs, is, nil, mode, driver.FilterNearest, driver.AddressUnsafe, dstRegion, sr, offsets, shader.shader, us, canSkipMipmap(options.GeoM, driver.FilterNearest))
 }
 
@@ -627,7 +825,7 @@ func (i *Image) SubImage(r image.Rectangle) image.Image {
 		bounds:   r,
 		original: orig,
 	}
-	img.addr = img
+	img.addr = uintptr(unsafe.Pointer(img))
 
 	return img
 }
@@ -662,6 +860,10 @@ func (i *Image) At(x, y int) color.Color {
 	if !image.Pt(x, y).In(i.Bounds()) {
 		return color.RGBA{}
 	}
+	if s := i.textureScale(); s != 1 {
+		x = int((float64(x) + 0.5) * s)
+		y = int((float64(y) + 0.5) * s)
+	}
 	pix, err := i.mipmap.Pixels(x, y, 1, 1)
 	if err != nil {
 		if panicOnErrorAtImageAt {
@@ -688,6 +890,10 @@ func (i *Image) Set(x, y int, clr color.Color) {
 	if !image.Pt(x, y).In(i.Bounds()) {
 		return
 	}
+	if i.textureScale() != 1 {
+		i.setWithRenderScale(x, y, clr)
+		return
+	}
 	if i.isSubImage() {
 		i = i.original
 	}
@@ -702,8 +908,8 @@ func (i *Image) Set(x, y int, clr color.Color) {
 // Dispose disposes the image data.
 // After disposing, most of image functions do nothing and returns meaningless values.
 //
-// Calling Dispose is not mandatory. GC automatically collects internal resources that no objects refer to.
-// However, calling Dispose explicitly is helpful if memory usage matters.
+// The images created by NewImage and NewImageFromImage are kept alive until Dispose is called, as RestartGame and
+// DeviceLostHandler refer to them. Call Dispose when such an image is no longer used.
 //
 // If the image is a sub-image, Dispose does nothing.
 //
@@ -717,10 +923,111 @@ func (i *Image) Dispose() {
 	if i.isSubImage() {
 		return
 	}
+	theResources.removeImage(i)
 	i.mipmap.MarkDisposed()
 	i.mipmap = nil
 }
 
+// SetAtlasGroup sets the atlas group of the image.
+//
+// Ebiten puts images onto internal texture atlases automatically, and consecutive draw calls with source images
+// on the same atlas can be merged into one. Images with the same non-zero group are put onto the same atlases if
+// possible, and the atlases don't accept images of other groups. Setting the same group to the images drawn
+// together, e.g. the sprite sheets used in a scene, reduces the draw calls.
+//
+// Images without a group prefer the atlases that are used together as rendering sources.
+//
+// The default group is 0, which means no group.
+//
+// If the image is a sub-image, SetAtlasGroup sets the group of the original image.
+// If the image is disposed, SetAtlasGroup does nothing.
+func (i *Image) SetAtlasGroup(group int) {
+	i.copyCheck()
+	if i.isDisposed() {
+		return
+	}
+	if i.isSubImage() {
+		i = i.original
+	}
+	if err := i.mipmap.SetAtlasGroup(group); err != nil {
+		theUIContext.setError(err)
+	}
+}
+
+// SetCold sets whether the image is cold.
+//
+// A cold image is an image that is rarely drawn, like a character portrait or a card illustration. The GPU texture
+// of a cold image is released and its pixels are kept compressed in the system memory. The texture is recreated
+// when the image is used next time, and released again after the image is not used for a while (about 5 seconds
+// at 60 FPS). Making images cold reduces GPU memory usage when there are a lot of images that are not drawn at the
+// same time. The cost is the time to recreate the textures, so calling Prefetch in advance is recommended.
+//
+// Releasing a texture of a cold image that is modified after its texture is recreated requires reading pixels from
+// GPU, which is slow. Cold images should be used as rendering sources rather than destinations.
+//
+// The default value is false.
+//
+// If the image is a sub-image, SetCold sets the state of the original image.
+// If the image is disposed, SetCold does nothing.
+func (i *Image) SetCold(cold bool) {
+	i.copyCheck()
+	if i.isDisposed() {
+		return
+	}
+	if i.isSubImage() {
+		i = i.original
+	}
+	if i.screen {
+		return
+	}
+	if err := i.mipmap.SetCold(cold); err != nil {
+		theUIContext.setError(err)
+	}
+}
+
+// Evict releases the GPU texture of the image immediately, and keeps its pixels compressed in the system memory.
+// The texture is recreated when the image is used next time.
+//
+// Evict works on both cold and non-cold images. A non-cold image is not evicted automatically after it is used.
+//
+// Evict does nothing for the screen image.
+//
+// If the image is a sub-image, Evict evicts the original image.
+// If the image is disposed, Evict does nothing.
+func (i *Image) Evict() {
+	i.copyCheck()
+	if i.isDisposed() {
+		return
+	}
+	if i.isSubImage() {
+		i = i.original
+	}
+	if i.screen {
+		return
+	}
+	if err := i.mipmap.Evict(); err != nil {
+		theUIContext.setError(err)
+	}
+}
+
+// Prefetch recreates the GPU texture of the image if the image is evicted.
+//
+// Prefetch is useful to avoid hitches by recreating the textures of the images before they are drawn,
+// e.g. at a loading screen.
+//
+// If the image is a sub-image, Prefetch works on the original image.
+// If the image is disposed, Prefetch does nothing.
+func (i *Image) Prefetch() {
+	i.copyCheck()
+	if i.isDisposed() {
+		return
+	}
+	if i.isSubImage() {
+		i = i.original
+	}
+	i.mipmap.Prefetch()
+}
+
 // ReplacePixels replaces the pixels of the image with p.
 //
 // The given p must represent RGBA pre-multiplied alpha values.
@@ -737,6 +1044,10 @@ func (i *Image) ReplacePixels(pixels []byte) {
 	if i.isDisposed() {
 		return
 	}
+	if i.textureScale() != 1 {
+		i.replacePixelsWithRenderScale(pixels)
+		return
+	}
 	r := i.Bounds()
 
 	// Do not need to copy pixels here.
@@ -749,10 +1060,26 @@ func (i *Image) ReplacePixels(pixels []byte) {
 
 // NewImage returns an empty image.
 //
-// If width or height is less than 1 or more than device-dependent maximum size, NewImage panics.
+// If width or height is less than 1, NewImage panics.
+//
+// If width or height is more than the device-dependent maximum texture size, the image is split into multiple
+// textures (tiles) internally. Such a big image works as a usual image with these limitations:
+//
+//   * Sampling with FilterLinear might show seams at the boundaries of tiles.
+//   * Mipmaps are not used when the image is drawn scaled down.
+//   * The image cannot be a source with AddressRepeat.
+//   * When the image is a destination of DrawRectShader or DrawTrianglesShader, the position given to the shader
+//     is relative to each tile.
 //
 // NewImage panics if RunGame already finishes.
 func NewImage(width, height int) *Image {
+	i := newImage(width, height)
+	theResources.addImage(i)
+	return i
+}
+
+// newImage creates a new image that is not disposed by RestartGame.
+func newImage(width, height int) *Image {
 	if isRunGameEnded() {
 		panic(fmt.Sprintf("ebiten: NewImage cannot be called after RunGame finishes"))
 	}
@@ -766,16 +1093,26 @@ func NewImage(width, height int) *Image {
 		mipmap: mipmap.New(width, height),
 		bounds: image.Rect(0, 0, width, height),
 	}
-	i.addr = i
+	i.addr = uintptr(unsafe.Pointer(i))
 	return i
 }
 
 // NewImageFromImage creates a new image with the given image (source).
 //
-// If source's width or height is less than 1 or more than device-dependent maximum size, NewImageFromImage panics.
+// If source's width or height is less than 1, NewImageFromImage panics.
+//
+// If source is bigger than the device-dependent maximum texture size, the image is split into tiles internally.
+// See NewImage for the limitations.
 //
 // NewImageFromImage panics if RunGame already finishes.
 func NewImageFromImage(source image.Image) *Image {
+	i := newImageFromImage(source)
+	theResources.addImage(i)
+	return i
+}
+
+// newImageFromImage creates a new image that is not disposed by RestartGame.
+func newImageFromImage(source image.Image) *Image {
 	if isRunGameEnded() {
 		panic(fmt.Sprintf("ebiten: NewImage cannot be called after RunGame finishes"))
 	}
@@ -793,7 +1130,7 @@ func NewImageFromImage(source image.Image) *Image {
 		mipmap: mipmap.New(width, height),
 		bounds: image.Rect(0, 0, width, height),
 	}
-	i.addr = i
+	i.addr = uintptr(unsafe.Pointer(i))
 
 	i.ReplacePixels(imageToBytes(source))
 	return i
@@ -805,6 +1142,6 @@ func newScreenFramebufferImage(width, height int) *Image {
 		bounds: image.Rect(0, 0, width, height),
 		screen: true,
 	}
-	i.addr = i
+	i.addr = uintptr(unsafe.Pointer(i))
 	return i
 }
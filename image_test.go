@@ -2207,3 +2207,363 @@ func TestImageClip(t *testing.T) {
 		}
 	}
 }
+
+func TestImageDrawSubImage(t *testing.T) {
+	const (
+		w = 16
+		h = 16
+	)
+	src := NewImage(w, h)
+	pix := make([]byte, 4*w*h)
+	for j := 0; j < h; j++ {
+		for i := 0; i < w; i++ {
+			idx := 4 * (i + j*w)
+			pix[idx] = byte(i * 16)
+			pix[idx+1] = byte(j * 16)
+			pix[idx+3] = 0xff
+		}
+	}
+	src.ReplacePixels(pix)
+
+	dst0 := NewImage(w, h)
+	dst1 := NewImage(w, h)
+	r := image.Rect(2, 3, 10, 12)
+	op := &DrawImageOptions{}
+	op.GeoM.Translate(1, 2)
+	dst0.DrawImage(src.SubImage(r).(*Image), op)
+	dst1.DrawSubImage(src, r, op)
+
+	for j := 0; j < h; j++ {
+		for i := 0; i < w; i++ {
+			got := dst1.At(i, j)
+			want := dst0.At(i, j)
+			if got != want {
+				t.Errorf("dst.At(%d, %d): got: %v, want: %v", i, j, got, want)
+			}
+		}
+	}
+}
+
+func TestImageDrawImageParts(t *testing.T) {
+	const (
+		w = 16
+		h = 16
+	)
+	src := NewImage(w, h)
+	pix := make([]byte, 4*w*h)
+	for j := 0; j < h; j++ {
+		for i := 0; i < w; i++ {
+			idx := 4 * (i + j*w)
+			pix[idx] = byte(i * 16)
+			pix[idx+1] = byte(j * 16)
+			pix[idx+3] = 0xff
+		}
+	}
+	src.ReplacePixels(pix)
+
+	parts := []DrawImagePart{
+		{SrcRect: image.Rect(0, 0, 4, 4)},
+		{SrcRect: image.Rect(4, 4, 8, 8)},
+		{SrcRect: image.Rect(8, 0, 16, 4)},
+		// An empty part is skipped.
+		{SrcRect: image.Rect(20, 20, 24, 24)},
+	}
+	parts[1].GeoM.Translate(8, 0)
+	parts[2].GeoM.Scale(0.5, 2)
+	parts[2].GeoM.Translate(0, 8)
+
+	dst0 := NewImage(w, h)
+	dst1 := NewImage(w, h)
+	op := &DrawImageOptions{}
+	op.GeoM.Translate(1, 1)
+	dst1.DrawImageParts(src, parts, op)
+
+	for _, p := range parts {
+		r := p.SrcRect.Intersect(src.Bounds())
+		if r.Empty() {
+			continue
+		}
+		op := &DrawImageOptions{}
+		op.GeoM = p.GeoM
+		op.GeoM.Translate(1, 1)
+		dst0.DrawImage(src.SubImage(r).(*Image), op)
+	}
+
+	for j := 0; j < h; j++ {
+		for i := 0; i < w; i++ {
+			got := dst1.At(i, j)
+			want := dst0.At(i, j)
+			if got != want {
+				t.Errorf("dst.At(%d, %d): got: %v, want: %v", i, j, got, want)
+			}
+		}
+	}
+}
+
+func TestImageDrawImagePartsRenderScaledSource(t *testing.T) {
+	const (
+		w = 16
+		h = 16
+	)
+	src := newRenderScaledImage(8, 8)
+
+	parts := []DrawImagePart{
+		{SrcRect: image.Rect(0, 0, 4, 4)},
+		{SrcRect: image.Rect(4, 4, 8, 8)},
+	}
+	parts[1].GeoM.Scale(2, 1)
+	parts[1].GeoM.Translate(8, 0)
+
+	dst0 := NewImage(w, h)
+	dst1 := NewImage(w, h)
+	op := &DrawImageOptions{}
+	op.GeoM.Translate(1, 1)
+	dst1.DrawImageParts(src, parts, op)
+
+	for _, p := range parts {
+		op := &DrawImageOptions{}
+		op.GeoM = p.GeoM
+		op.GeoM.Translate(1, 1)
+		dst0.DrawSubImage(src, p.SrcRect, op)
+	}
+
+	for j := 0; j < h; j++ {
+		for i := 0; i < w; i++ {
+			got := dst1.At(i, j)
+			want := dst0.At(i, j)
+			if got != want {
+				t.Errorf("dst.At(%d, %d): got: %v, want: %v", i, j, got, want)
+			}
+		}
+	}
+	if got, want := dst1.At(1+8, 1+5), (color.RGBA{4 * 16, 5 * 16, 0, 0xff}); got != want {
+		t.Errorf("dst.At(%d, %d): got: %v, want: %v", 1+8, 1+5, got, want)
+	}
+}
+
+func TestImageDrawImageAt(t *testing.T) {
+	const (
+		w = 16
+		h = 16
+	)
+	src := NewImage(w, h)
+	pix := make([]byte, 4*w*h)
+	for j := 0; j < h; j++ {
+		for i := 0; i < w; i++ {
+			idx := 4 * (i + j*w)
+			pix[idx] = byte(i * 16)
+			pix[idx+1] = byte(j * 16)
+			pix[idx+3] = byte(i * 16)
+		}
+	}
+	src.ReplacePixels(pix)
+
+	dst0 := NewImage(w, h)
+	dst1 := NewImage(w, h)
+	dst0.Fill(color.RGBA{0, 0, 0xff, 0xff})
+	dst1.Fill(color.RGBA{0, 0, 0xff, 0xff})
+
+	sub := src.SubImage(image.Rect(2, 3, 10, 12)).(*Image)
+	op := &DrawImageOptions{}
+	op.GeoM.Translate(5, 4)
+	dst0.DrawImage(sub, op)
+	dst1.DrawImageAt(sub, 5, 4)
+
+	for j := 0; j < h; j++ {
+		for i := 0; i < w; i++ {
+			got := dst1.At(i, j)
+			want := dst0.At(i, j)
+			if got != want {
+				t.Errorf("dst.At(%d, %d): got: %v, want: %v", i, j, got, want)
+			}
+		}
+	}
+}
+
+// newRenderScaledImage returns an image rendered at the doubled scale, filled with a gradation.
+func newRenderScaledImage(w, h int) *Image {
+	img := NewImageWithRenderScale(w, h, 2)
+	pix := make([]byte, 4*w*h)
+	for j := 0; j < h; j++ {
+		for i := 0; i < w; i++ {
+			idx := 4 * (i + j*w)
+			pix[idx] = byte(i * 16)
+			pix[idx+1] = byte(j * 16)
+			pix[idx+3] = 0xff
+		}
+	}
+	img.ReplacePixels(pix)
+	return img
+}
+
+func TestImageDrawImageAtRenderScaledSource(t *testing.T) {
+	const (
+		w = 16
+		h = 16
+	)
+	src := newRenderScaledImage(8, 8)
+
+	dst0 := NewImage(w, h)
+	dst1 := NewImage(w, h)
+	op := &DrawImageOptions{}
+	op.GeoM.Translate(5, 4)
+	dst0.DrawImage(src, op)
+	dst1.DrawImageAt(src, 5, 4)
+
+	for j := 0; j < h; j++ {
+		for i := 0; i < w; i++ {
+			got := dst1.At(i, j)
+			want := dst0.At(i, j)
+			if got != want {
+				t.Errorf("dst.At(%d, %d): got: %v, want: %v", i, j, got, want)
+			}
+		}
+	}
+	if got, want := dst1.At(5+3, 4+2), (color.RGBA{3 * 16, 2 * 16, 0, 0xff}); got != want {
+		t.Errorf("dst.At(%d, %d): got: %v, want: %v", 5+3, 4+2, got, want)
+	}
+}
+
+func TestImageDrawImageAtSelf(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("DrawImageAt must panic but not")
+		}
+	}()
+	img := NewImage(16, 16)
+	img.DrawImageAt(img, 0, 0)
+}
+
+// newPatternImage returns an image filled with a pattern whose pixels differ from each other.
+func newPatternImage(w, h int) *Image {
+	img := NewImage(w, h)
+	pix := make([]byte, 4*w*h)
+	for j := 0; j < h; j++ {
+		for i := 0; i < w; i++ {
+			idx := 4 * (i + j*w)
+			pix[idx] = byte(i * 16)
+			pix[idx+1] = byte(j * 16)
+			pix[idx+3] = 0xff
+		}
+	}
+	img.ReplacePixels(pix)
+	return img
+}
+
+func TestImageEvict(t *testing.T) {
+	const (
+		w = 16
+		h = 16
+	)
+	img := newPatternImage(w, h)
+	// Render to the image so that the pixels have to be read from GPU at evicting.
+	fill := NewImage(4, 4)
+	fill.Fill(color.White)
+	img.DrawImage(fill, nil)
+
+	want := func(i, j int) color.RGBA {
+		if i < 4 && j < 4 {
+			return color.RGBA{0xff, 0xff, 0xff, 0xff}
+		}
+		return color.RGBA{byte(i * 16), byte(j * 16), 0, 0xff}
+	}
+
+	img.Evict()
+	for j := 0; j < h; j++ {
+		for i := 0; i < w; i++ {
+			if got, want := img.At(i, j), want(i, j); got != want {
+				t.Errorf("img.At(%d, %d): got: %v, want: %v", i, j, got, want)
+			}
+		}
+	}
+
+	// An evicted image can be used as a rendering source.
+	img.Evict()
+	dst := NewImage(w, h)
+	dst.DrawImage(img, nil)
+	for j := 0; j < h; j++ {
+		for i := 0; i < w; i++ {
+			if got, want := dst.At(i, j), want(i, j); got != want {
+				t.Errorf("dst.At(%d, %d): got: %v, want: %v", i, j, got, want)
+			}
+		}
+	}
+
+	// An evicted image can be used as a rendering destination.
+	img.Evict()
+	op := &DrawImageOptions{}
+	op.GeoM.Translate(w-4, h-4)
+	img.DrawImage(fill, op)
+	for j := 0; j < h; j++ {
+		for i := 0; i < w; i++ {
+			want := want(i, j)
+			if i >= w-4 && j >= h-4 {
+				want = color.RGBA{0xff, 0xff, 0xff, 0xff}
+			}
+			if got := img.At(i, j); got != want {
+				t.Errorf("img.At(%d, %d): got: %v, want: %v", i, j, got, want)
+			}
+		}
+	}
+}
+
+func TestImageColdModified(t *testing.T) {
+	const (
+		w = 16
+		h = 16
+	)
+	img := newPatternImage(w, h)
+	img.SetCold(true)
+	defer img.SetCold(false)
+
+	if got, want := img.At(3, 2), (color.RGBA{3 * 16, 2 * 16, 0, 0xff}); got != want {
+		t.Errorf("img.At(%d, %d): got: %v, want: %v", 3, 2, got, want)
+	}
+
+	// The compressed pixels must not be reused after the image is modified.
+	img.Fill(color.RGBA{0x80, 0x80, 0x80, 0x80})
+	img.Evict()
+	img.Prefetch()
+	for j := 0; j < h; j++ {
+		for i := 0; i < w; i++ {
+			got := img.At(i, j)
+			want := color.RGBA{0x80, 0x80, 0x80, 0x80}
+			if got != want {
+				t.Errorf("img.At(%d, %d): got: %v, want: %v", i, j, got, want)
+			}
+		}
+	}
+}
+
+func TestImageEvictSubImage(t *testing.T) {
+	const (
+		w = 16
+		h = 16
+	)
+	img := newPatternImage(w, h)
+	sub := img.SubImage(image.Rect(4, 4, 12, 12)).(*Image)
+
+	// Evicting a sub-image evicts the original image.
+	sub.Evict()
+	sub.Prefetch()
+	if got, want := sub.At(5, 6), (color.RGBA{5 * 16, 6 * 16, 0, 0xff}); got != want {
+		t.Errorf("sub.At(%d, %d): got: %v, want: %v", 5, 6, got, want)
+	}
+	if got, want := img.At(1, 2), (color.RGBA{1 * 16, 2 * 16, 0, 0xff}); got != want {
+		t.Errorf("img.At(%d, %d): got: %v, want: %v", 1, 2, got, want)
+	}
+}
+
+func TestImageEvictDisposed(t *testing.T) {
+	img := newPatternImage(16, 16)
+	img.Evict()
+	img.Dispose()
+
+	// These functions do nothing for a disposed image.
+	img.SetCold(true)
+	img.Evict()
+	img.Prefetch()
+	if got, want := img.At(0, 0), (color.RGBA{}); got != want {
+		t.Errorf("img.At(0, 0): got: %v, want: %v", got, want)
+	}
+}
@@ -19,6 +19,8 @@ package ebiten
 
 import (
 	"fmt"
+	"image"
+	"image/png"
 	"os"
 	"time"
 
@@ -45,14 +47,19 @@ func availableFilename(prefix, postfix string) (string, error) {
 	return name, nil
 }
 
-func takeScreenshot(screen *Image) error {
+func saveScreenshot(img image.Image) error {
 	newname, err := availableFilename("screenshot_", ".png")
 	if err != nil {
 		return err
 	}
 
-	blackbg := !IsScreenTransparent()
-	if err := screen.mipmap.Dump(newname, blackbg); err != nil {
+	f, err := os.Create(newname)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := png.Encode(f, img); err != nil {
 		return err
 	}
 
@@ -87,10 +94,6 @@ type imageDumper struct {
 
 	keyState map[Key]int
 
-	hasScreenshotKey bool
-	screenshotKey    Key
-	toTakeScreenshot bool
-
 	hasDumpInternalImagesKey bool
 	dumpInternalImagesKey    Key
 	toDumpInternalImages     bool
@@ -108,7 +111,7 @@ func (i *imageDumper) update() error {
 		envInternalImagesKey = "EBITEN_INTERNAL_IMAGES_KEY"
 	)
 
-	if err := i.g.Update(); err != nil {
+	if err := updateGame(i.g); err != nil {
 		return err
 	}
 
@@ -118,8 +121,11 @@ func (i *imageDumper) update() error {
 
 		if keyname := os.Getenv(envScreenshotKey); keyname != "" {
 			if key, ok := keyNameToKeyCode(keyname); ok {
-				i.hasScreenshotKey = true
-				i.screenshotKey = key
+				AddScreenshotHotkey(CaptureHotkey{Keys: []Key{key}}, func(img image.Image) {
+					if err := saveScreenshot(img); err != nil {
+						i.err = err
+					}
+				})
 			}
 		}
 
@@ -136,9 +142,6 @@ func (i *imageDumper) update() error {
 	}
 
 	keys := map[Key]struct{}{}
-	if i.hasScreenshotKey {
-		keys[i.screenshotKey] = struct{}{}
-	}
 	if i.hasDumpInternalImagesKey {
 		keys[i.dumpInternalImagesKey] = struct{}{}
 	}
@@ -147,9 +150,6 @@ func (i *imageDumper) update() error {
 		if IsKeyPressed(key) {
 			i.keyState[key]++
 			if i.keyState[key] == 1 {
-				if i.hasScreenshotKey && key == i.screenshotKey {
-					i.toTakeScreenshot = true
-				}
 				if i.hasDumpInternalImagesKey && key == i.dumpInternalImagesKey {
 					i.toDumpInternalImages = true
 				}
@@ -162,11 +162,8 @@ func (i *imageDumper) update() error {
 }
 
 func (i *imageDumper) dump(screen *Image) error {
-	if i.toTakeScreenshot {
-		i.toTakeScreenshot = false
-		if err := takeScreenshot(screen); err != nil {
-			return err
-		}
+	if i.err != nil {
+		return i.err
 	}
 
 	if i.toDumpInternalImages {
@@ -22,7 +22,7 @@ type imageDumper struct {
 }
 
 func (i *imageDumper) update() error {
-	return i.g.Update()
+	return updateGame(i.g)
 }
 
 func (i *imageDumper) dump(screen *Image) error {
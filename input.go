@@ -15,9 +15,20 @@
 package ebiten
 
 import (
+	"time"
+
 	"github.com/hajimehoshi/ebiten/v2/internal/driver"
+	"github.com/hajimehoshi/ebiten/v2/internal/testhook"
 )
 
+// theInput returns the current input. The input can be overridden by the ebitentest package.
+func theInput() driver.Input {
+	if i := testhook.Input(); i != nil {
+		return i
+	}
+	return uiDriver().Input()
+}
+
 // InputChars return "printable" runes read from the keyboard at the time update is called.
 //
 // InputChars represents the environment's locale-dependent translation of keyboard
@@ -32,7 +43,7 @@ import (
 //
 // Keyboards don't work on iOS yet (#1090).
 func InputChars() []rune {
-	return uiDriver().Input().RuneBuffer()
+	return theInput().RuneBuffer()
 }
 
 // IsKeyPressed returns a boolean indicating whether key is pressed.
@@ -69,7 +80,7 @@ func IsKeyPressed(key Key) bool {
 		keys = []driver.Key{driver.Key(key)}
 	}
 	for _, k := range keys {
-		if uiDriver().Input().IsKeyPressed(k) {
+		if theInput().IsKeyPressed(k) {
 			return true
 		}
 	}
@@ -85,7 +96,7 @@ func IsKeyPressed(key Key) bool {
 //
 // CursorPosition is concurrent-safe.
 func CursorPosition() (x, y int) {
-	return uiDriver().Input().CursorPosition()
+	return theInput().CursorPosition()
 }
 
 // Wheel returns the x and y offset of the mouse wheel or touchpad scroll.
@@ -93,7 +104,7 @@ func CursorPosition() (x, y int) {
 //
 // Wheel is concurrent-safe.
 func Wheel() (xoff, yoff float64) {
-	return uiDriver().Input().Wheel()
+	return theInput().Wheel()
 }
 
 // IsMouseButtonPressed returns a boolean indicating whether mouseButton is pressed.
@@ -103,7 +114,7 @@ func Wheel() (xoff, yoff float64) {
 //
 // IsMouseButtonPressed is concurrent-safe.
 func IsMouseButtonPressed(mouseButton MouseButton) bool {
-	return uiDriver().Input().IsMouseButtonPressed(driver.MouseButton(mouseButton))
+	return theInput().IsMouseButtonPressed(driver.MouseButton(mouseButton))
 }
 
 // GamepadID represents a gamepad's identifier.
@@ -116,7 +127,7 @@ type GamepadID = driver.GamepadID
 //
 // GamepadSDLID is concurrent-safe.
 func GamepadSDLID(id GamepadID) string {
-	return uiDriver().Input().GamepadSDLID(id)
+	return theInput().GamepadSDLID(id)
 }
 
 // GamepadName returns a string with the name.
@@ -131,7 +142,7 @@ func GamepadSDLID(id GamepadID) string {
 //
 // GamepadName is concurrent-safe.
 func GamepadName(id GamepadID) string {
-	return uiDriver().Input().GamepadName(id)
+	return theInput().GamepadName(id)
 }
 
 // GamepadIDs returns a slice indicating available gamepad IDs.
@@ -140,7 +151,7 @@ func GamepadName(id GamepadID) string {
 //
 // GamepadIDs always returns an empty slice on iOS.
 func GamepadIDs() []GamepadID {
-	return uiDriver().Input().GamepadIDs()
+	return theInput().GamepadIDs()
 }
 
 // GamepadAxisNum returns the number of axes of the gamepad (id).
@@ -149,7 +160,7 @@ func GamepadIDs() []GamepadID {
 //
 // GamepadAxisNum always returns 0 on iOS.
 func GamepadAxisNum(id GamepadID) int {
-	return uiDriver().Input().GamepadAxisNum(id)
+	return theInput().GamepadAxisNum(id)
 }
 
 // GamepadAxis returns the float value [-1.0 - 1.0] of the given gamepad (id)'s axis (axis).
@@ -158,7 +169,7 @@ func GamepadAxisNum(id GamepadID) int {
 //
 // GamepadAxis always returns 0 on iOS.
 func GamepadAxis(id GamepadID, axis int) float64 {
-	return uiDriver().Input().GamepadAxis(id, axis)
+	return theInput().GamepadAxis(id, axis)
 }
 
 // GamepadButtonNum returns the number of the buttons of the given gamepad (id).
@@ -167,7 +178,7 @@ func GamepadAxis(id GamepadID, axis int) float64 {
 //
 // GamepadButtonNum always returns 0 on iOS.
 func GamepadButtonNum(id GamepadID) int {
-	return uiDriver().Input().GamepadButtonNum(id)
+	return theInput().GamepadButtonNum(id)
 }
 
 // IsGamepadButtonPressed returns the boolean indicating the given button of the gamepad (id) is pressed or not.
@@ -182,7 +193,34 @@ func GamepadButtonNum(id GamepadID) int {
 //
 // IsGamepadButtonPressed always returns false on iOS.
 func IsGamepadButtonPressed(id GamepadID, button GamepadButton) bool {
-	return uiDriver().Input().IsGamepadButtonPressed(id, driver.GamepadButton(button))
+	return theInput().IsGamepadButtonPressed(id, driver.GamepadButton(button))
+}
+
+// VibrateGamepadOptions represents the options for gamepad vibration.
+type VibrateGamepadOptions struct {
+	// Duration is the time duration of the effect.
+	Duration time.Duration
+
+	// StrongMagnitude is the rumble intensity of a low-frequency rumble motor.
+	// The value is in between 0 and 1.
+	StrongMagnitude float64
+
+	// WeakMagnitude is the rumble intensity of a high-frequency rumble motor.
+	// The value is in between 0 and 1.
+	WeakMagnitude float64
+}
+
+// VibrateGamepad vibrates the specified gamepad with the specified options.
+//
+// VibrateGamepad works only on browsers that support the gamepad vibration so far.
+// VibrateGamepad does nothing on the other environments.
+//
+// VibrateGamepad is concurrent-safe.
+func VibrateGamepad(id GamepadID, options *VibrateGamepadOptions) {
+	if options == nil {
+		return
+	}
+	theInput().VibrateGamepad(id, options.Duration, options.StrongMagnitude, options.WeakMagnitude)
 }
 
 // TouchID represents a touch's identifier.
@@ -198,7 +236,7 @@ type TouchID = driver.TouchID
 //
 // TouchIDs is concurrent-safe.
 func TouchIDs() []TouchID {
-	return uiDriver().Input().TouchIDs()
+	return theInput().TouchIDs()
 }
 
 // TouchPosition returns the position for the touch of the specified ID.
@@ -208,7 +246,7 @@ func TouchIDs() []TouchID {
 // TouchPosition is cuncurrent-safe.
 func TouchPosition(id TouchID) (int, int) {
 	found := false
-	for _, i := range uiDriver().Input().TouchIDs() {
+	for _, i := range theInput().TouchIDs() {
 		if id == i {
 			found = true
 			break
@@ -218,5 +256,5 @@ func TouchPosition(id TouchID) (int, int) {
 		return 0, 0
 	}
 
-	return uiDriver().Input().TouchPosition(id)
+	return theInput().TouchPosition(id)
 }
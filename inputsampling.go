@@ -0,0 +1,61 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten
+
+import (
+	"sync/atomic"
+)
+
+var isLateInputSamplingEnabled = int32(0)
+
+// SetLateInputSamplingEnabled enables or disables late input sampling.
+//
+// By default, the input state is sampled once at the beginning of each frame, and all the Update calls in the
+// frame see the state sampled at that time. When late input sampling is enabled, the input state is sampled
+// again immediately before each Update call. This shaves the input latency for games that care about every
+// millisecond, like fighting games and rhythm games.
+//
+// On browsers, only the gamepads' state is sampled late, as the other input events are dispatched
+// asynchronously. On mobiles, the input state is always updated as soon as the platform notifies it.
+//
+// Late input sampling is disabled by default.
+//
+// SetLateInputSamplingEnabled is concurrent-safe.
+func SetLateInputSamplingEnabled(enabled bool) {
+	v := int32(0)
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&isLateInputSamplingEnabled, v)
+}
+
+// IsLateInputSamplingEnabled reports whether late input sampling is enabled.
+//
+// IsLateInputSamplingEnabled is concurrent-safe.
+func IsLateInputSamplingEnabled() bool {
+	return atomic.LoadInt32(&isLateInputSamplingEnabled) != 0
+}
+
+// SampleInput samples the input state immediately.
+//
+// SampleInput is useful to refresh the input state right before drawing, e.g. to draw a cursor or a crosshair
+// at the latest position in Draw. Note that the sampled state is also seen by the next Update.
+//
+// The same limitations as SetLateInputSamplingEnabled apply on browsers and mobiles.
+//
+// SampleInput is concurrent-safe.
+func SampleInput() {
+	uiDriver().UpdateInput()
+}
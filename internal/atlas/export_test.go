@@ -60,3 +60,9 @@ func (i *Image) EnsureIsolatedForTesting() {
 func ResolveDeferredForTesting() {
 	resolveDeferred()
 }
+
+func IsOnSameAtlasForTesting(i0, i1 *Image) bool {
+	backendsM.Lock()
+	defer backendsM.Unlock()
+	return i0.isOnAtlas() && i1.isOnAtlas() && i0.backend == i1.backend
+}
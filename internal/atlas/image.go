@@ -17,9 +17,12 @@ package atlas
 import (
 	"fmt"
 	"runtime"
+	"sort"
 	"sync"
+	"sync/atomic"
 
 	"github.com/hajimehoshi/ebiten/v2/internal/affine"
+	"github.com/hajimehoshi/ebiten/v2/internal/diag"
 	"github.com/hajimehoshi/ebiten/v2/internal/driver"
 	"github.com/hajimehoshi/ebiten/v2/internal/graphics"
 	"github.com/hajimehoshi/ebiten/v2/internal/hooks"
@@ -38,6 +41,10 @@ var (
 	maxSize = 0
 )
 
+// maxImageSize is the maximum size of an image excluding the paddings, or 0 if the graphics driver is not
+// initialized yet. maxImageSize must be accessed atomically.
+var maxImageSize int32
+
 type temporaryPixels struct {
 	pixels     []byte
 	pos        int
@@ -85,6 +92,11 @@ func (t *temporaryPixels) resetAtFrameEnd() {
 	t.totalUse = 0
 }
 
+func (t *temporaryPixels) release() {
+	t.pixels = nil
+	t.pos = 0
+}
+
 func max(a, b int) int {
 	if a > b {
 		return a
@@ -107,6 +119,13 @@ func init() {
 		resolveDeferred()
 		return putImagesOnAtlas()
 	})
+	hooks.AppendHookOnLowMemory(func() error {
+		backendsM.Lock()
+		defer backendsM.Unlock()
+
+		theTemporaryPixels.release()
+		return nil
+	})
 }
 
 func resolveDeferred() {
@@ -140,6 +159,9 @@ func putImagesOnAtlas() error {
 	for k := range imagesToPutOnAtlas {
 		delete(imagesToPutOnAtlas, k)
 	}
+	for k := range sourceBackendCounts {
+		delete(sourceBackendCounts, k)
+	}
 	return nil
 }
 
@@ -150,6 +172,9 @@ type backend struct {
 	// page is an atlas map. Each part is called a node.
 	// If page is nil, the backend's image is isolated and not on an atlas.
 	page *packing.Page
+
+	// group is the atlas group of the images on the atlas. 0 means that the atlas is not dedicated to a group.
+	group int
 }
 
 func (b *backend) tryAlloc(width, height int) (*packing.Node, bool) {
@@ -176,6 +201,7 @@ func (b *backend) tryAlloc(width, height int) (*packing.Node, bool) {
 
 	s := b.page.Size()
 	b.restorable = b.restorable.Extend(s, s)
+	diag.Log(diag.CategoryAtlas, "atlas extended", "size", s)
 
 	if n == nil {
 		panic("atlas: Alloc result must not be nil at TryAlloc")
@@ -194,6 +220,14 @@ var (
 
 	imagesToPutOnAtlas = map[*Image]struct{}{}
 
+	// theImageRecords is a set of the records of the allocated images, for diagnostics.
+	theImageRecords = map[*imageRecord]struct{}{}
+
+	// sourceBackendCounts is the number of the usages of atlases as rendering sources since the last
+	// putImagesOnAtlas. An image put onto an atlas prefers the atlas used with it, so that the draw calls are
+	// more likely to be merged.
+	sourceBackendCounts = map[*backend]int{}
+
 	deferred []func()
 
 	// deferredM is a mutext for the slice operations. This must not be used for other usages.
@@ -238,6 +272,49 @@ type Image struct {
 	// isolatedCount represents how many times the image on a texture atlas is changed into an isolated image.
 	// isolatedCount affects the calculation when to put the image onto a texture atlas again.
 	isolatedCount int
+
+	// group is the atlas group. Images with the same non-zero group are put onto the same atlases if possible.
+	group int
+
+	// record is the record of the image for diagnostics. record is nil if the image is not allocated.
+	record *imageRecord
+}
+
+// imageRecord is a snapshot of an allocated image for diagnostics.
+//
+// imageRecord doesn't refer to the image so that the image can be collected by GC.
+type imageRecord struct {
+	width    int
+	height   int
+	screen   bool
+	volatile bool
+	group    int
+	backend  *backend
+	node     *packing.Node
+}
+
+// updateRecord updates the record of the image after its allocation state is changed.
+func (i *Image) updateRecord() {
+	if i.backend == nil {
+		if i.record != nil {
+			delete(theImageRecords, i.record)
+			i.record = nil
+		}
+		return
+	}
+	if i.record == nil {
+		i.record = &imageRecord{}
+		theImageRecords[i.record] = struct{}{}
+	}
+	*i.record = imageRecord{
+		width:    i.width,
+		height:   i.height,
+		screen:   i.screen,
+		volatile: i.volatile,
+		group:    i.group,
+		backend:  i.backend,
+		node:     i.node,
+	}
 }
 
 // moveTo moves its content to the given image dst.
@@ -306,6 +383,7 @@ func (i *Image) ensureIsolated() {
 	i.backend = &backend{
 		restorable: newImg,
 	}
+	i.updateRecord()
 
 	i.isolatedCount++
 }
@@ -326,6 +404,7 @@ func (i *Image) putOnAtlas() error {
 
 	newI := NewImage(i.width, i.height)
 	newI.SetVolatile(i.volatile)
+	newI.group = i.group
 
 	if restorable.NeedsRestoring() {
 		// If the underlying graphics driver requires restoring from the context lost, the pixel data is
@@ -495,6 +574,9 @@ func (i *Image) drawTriangles(srcs [graphics.ShaderImageNum]*Image, vertices []f
 		if src == nil {
 			continue
 		}
+		if src.isOnAtlas() {
+			sourceBackendCounts[src.backend]++
+		}
 		if !src.isOnAtlas() && src.canBePutOnAtlas() {
 			// src might already registered, but assiging it again is not harmful.
 			imagesToPutOnAtlas[src] = struct{}{}
@@ -600,6 +682,7 @@ func (i *Image) dispose(markDisposed bool) {
 		}
 		i.backend = nil
 		i.node = nil
+		i.updateRecord()
 		if markDisposed {
 			runtime.SetFinalizer(i, nil)
 		}
@@ -640,6 +723,7 @@ func (i *Image) dispose(markDisposed bool) {
 		panic("atlas: backend not found at an image being disposed")
 	}
 	theBackends = append(theBackends[:index], theBackends[index+1:]...)
+	delete(sourceBackendCounts, i.backend)
 }
 
 func NewImage(width, height int) *Image {
@@ -659,6 +743,70 @@ func (i *Image) SetVolatile(volatile bool) {
 		i.ensureIsolated()
 	}
 	i.backend.restorable.SetVolatile(i.volatile)
+	i.updateRecord()
+}
+
+// SetGroup sets the atlas group of the image.
+//
+// Images with the same non-zero group are put onto the same atlases if possible, so that the draw calls with
+// the images are more likely to be merged. An atlas that has images of a group doesn't accept images of another
+// group. If the image is already on an atlas of another group, the image is moved.
+func (i *Image) SetGroup(group int) error {
+	backendsM.Lock()
+	defer backendsM.Unlock()
+
+	if i.group == group {
+		return nil
+	}
+	i.group = group
+	defer i.updateRecord()
+
+	if i.backend == nil || !i.isOnAtlas() {
+		return nil
+	}
+	if i.backend.group == group {
+		return nil
+	}
+	i.ensureIsolated()
+	return i.putOnAtlas()
+}
+
+// backendsToAllocate returns the atlases to try to allocate an image of the given group, in the order of
+// preference.
+//
+// An image of a group prefers the atlases of the same group, and then the atlases without a group.
+// An image without a group prefers the atlases without a group used as rendering sources more often, and then the
+// atlases of groups.
+func backendsToAllocate(group int) []*backend {
+	var bs []*backend
+	if group != 0 {
+		for _, b := range theBackends {
+			if b.page != nil && b.group == group {
+				bs = append(bs, b)
+			}
+		}
+		for _, b := range theBackends {
+			if b.page != nil && b.group == 0 {
+				bs = append(bs, b)
+			}
+		}
+		return bs
+	}
+
+	for _, b := range theBackends {
+		if b.page != nil && b.group == 0 {
+			bs = append(bs, b)
+		}
+	}
+	sort.SliceStable(bs, func(i, j int) bool {
+		return sourceBackendCounts[bs[i]] > sourceBackendCounts[bs[j]]
+	})
+	for _, b := range theBackends {
+		if b.page != nil && b.group != 0 {
+			bs = append(bs, b)
+		}
+	}
+	return bs
 }
 
 func (i *Image) canBePutOnAtlas() bool {
@@ -680,6 +828,7 @@ func (i *Image) allocate(putOnAtlas bool) {
 	}
 
 	runtime.SetFinalizer(i, (*Image).MarkDisposed)
+	defer i.updateRecord()
 
 	if i.screen {
 		// A screen image doesn't have a padding.
@@ -697,8 +846,11 @@ func (i *Image) allocate(putOnAtlas bool) {
 		return
 	}
 
-	for _, b := range theBackends {
+	for _, b := range backendsToAllocate(i.group) {
 		if n, ok := b.tryAlloc(i.width+2*paddingSize, i.height+2*paddingSize); ok {
+			if b.group == 0 {
+				b.group = i.group
+			}
 			i.backend = b
 			i.node = n
 			return
@@ -715,9 +867,11 @@ func (i *Image) allocate(putOnAtlas bool) {
 	b := &backend{
 		restorable: restorable.NewImage(size, size),
 		page:       packing.NewPage(size, maxSize),
+		group:      i.group,
 	}
 	b.restorable.SetVolatile(i.volatile)
 	theBackends = append(theBackends, b)
+	diag.Log(diag.CategoryAtlas, "atlas created", "size", size, "atlases", len(theBackends))
 
 	n := b.page.Alloc(i.width+2*paddingSize, i.height+2*paddingSize)
 	if n == nil {
@@ -766,6 +920,7 @@ func BeginFrame() error {
 		}
 		minSize = 1024
 		maxSize = restorable.MaxImageSize()
+		atomic.StoreInt32(&maxImageSize, int32(maxSize-2*paddingSize))
 	})
 	if err != nil {
 		return err
@@ -774,8 +929,98 @@ func BeginFrame() error {
 	return restorable.RestoreIfNeeded()
 }
 
+// TakeDeviceLost reports whether the textures were recreated due to the loss of the graphics device after the last
+// call. contentsLost reports whether the contents of the images were not restored.
+//
+// TakeDeviceLost must be called between BeginFrame and EndFrame.
+func TakeDeviceLost() (lost bool, contentsLost bool) {
+	backendsM.Lock()
+	defer backendsM.Unlock()
+	return restorable.TakeDeviceLost()
+}
+
+// MaxImageSize returns the maximum width and height of an image.
+// MaxImageSize returns false if the graphics driver is not initialized yet.
+//
+// MaxImageSize is concurrent-safe.
+func MaxImageSize() (int, bool) {
+	s := atomic.LoadInt32(&maxImageSize)
+	return int(s), s != 0
+}
+
 func DumpImages(dir string) error {
 	backendsM.Lock()
 	defer backendsM.Unlock()
 	return restorable.DumpImages(dir)
 }
+
+// AtlasInfo represents the information of a texture atlas for diagnostics.
+type AtlasInfo struct {
+	// Size is the width and the height of the atlas.
+	Size int
+
+	// ImageNum is the number of the images on the atlas.
+	ImageNum int
+
+	// UsedArea is the area allocated for the images in pixels, including the paddings.
+	UsedArea int
+
+	// Group is the atlas group of the atlas. 0 means that the atlas is not dedicated to a group.
+	Group int
+}
+
+// ImageInfo represents the information of an image for diagnostics.
+type ImageInfo struct {
+	Width    int
+	Height   int
+	Screen   bool
+	Volatile bool
+	Group    int
+
+	// Atlas is the index of the atlas in the result of Infos, or -1 if the image is not on an atlas.
+	Atlas int
+
+	// X and Y are the position on the atlas, excluding the padding.
+	X int
+	Y int
+}
+
+// Infos returns the information of the atlases and the allocated images.
+func Infos() ([]AtlasInfo, []ImageInfo) {
+	backendsM.Lock()
+	defer backendsM.Unlock()
+
+	atlases := make([]AtlasInfo, 0, len(theBackends))
+	indices := map[*backend]int{}
+	for idx, b := range theBackends {
+		atlases = append(atlases, AtlasInfo{
+			Size:  b.page.Size(),
+			Group: b.group,
+		})
+		indices[b] = idx
+	}
+
+	images := make([]ImageInfo, 0, len(theImageRecords))
+	for r := range theImageRecords {
+		info := ImageInfo{
+			Width:    r.width,
+			Height:   r.height,
+			Screen:   r.screen,
+			Volatile: r.volatile,
+			Group:    r.group,
+			Atlas:    -1,
+		}
+		if r.node != nil {
+			if idx, ok := indices[r.backend]; ok {
+				x, y, w, h := r.node.Region()
+				info.Atlas = idx
+				info.X = x + paddingSize
+				info.Y = y + paddingSize
+				atlases[idx].ImageNum++
+				atlases[idx].UsedArea += w * h
+			}
+		}
+		images = append(images, info)
+	}
+	return atlases, images
+}
@@ -15,6 +15,7 @@
 package atlas_test
 
 import (
+	"bytes"
 	"image/color"
 	"runtime"
 	"testing"
@@ -645,3 +646,66 @@ func TestImageIsNotReputOnAtlasWithoutUsingAsSource(t *testing.T) {
 }
 
 // TODO: Add tests to extend image on an atlas out of the main loop
+
+func TestGroup(t *testing.T) {
+	const (
+		size   = 16
+		group0 = 1001
+		group1 = 1002
+	)
+
+	img0 := NewImage(size, size)
+	defer img0.MarkDisposed()
+	img1 := NewImage(size, size)
+	defer img1.MarkDisposed()
+	img2 := NewImage(size, size)
+	defer img2.MarkDisposed()
+
+	if err := img0.SetGroup(group0); err != nil {
+		t.Fatal(err)
+	}
+	if err := img1.SetGroup(group0); err != nil {
+		t.Fatal(err)
+	}
+	if err := img2.SetGroup(group1); err != nil {
+		t.Fatal(err)
+	}
+	for _, img := range []*Image{img0, img1, img2} {
+		img.ReplacePixels(make([]byte, 4*size*size))
+	}
+
+	if !IsOnSameAtlasForTesting(img0, img1) {
+		t.Errorf("img0 and img1 of the same group must be on the same atlas")
+	}
+	if IsOnSameAtlasForTesting(img0, img2) {
+		t.Errorf("img0 and img2 of different groups must not be on the same atlas")
+	}
+
+	// Changing the group moves the image onto an atlas of the new group.
+	if err := img2.SetGroup(group0); err != nil {
+		t.Fatal(err)
+	}
+	if !IsOnSameAtlasForTesting(img0, img2) {
+		t.Errorf("img2 must be moved onto the atlas of img0 after changing its group")
+	}
+
+	// The pixels are kept after the move.
+	pix := make([]byte, 4*size*size)
+	for i := range pix {
+		pix[i] = 0xff
+	}
+	img2.ReplacePixels(pix)
+	if err := img2.SetGroup(group1); err != nil {
+		t.Fatal(err)
+	}
+	if IsOnSameAtlasForTesting(img0, img2) {
+		t.Errorf("img2 must be moved out of the atlas of img0 after changing its group")
+	}
+	got, err := img2.Pixels(0, 0, size, size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, pix) {
+		t.Errorf("img2.Pixels: the pixels must be kept after changing its group")
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buffered
+
+import (
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/hajimehoshi/ebiten/v2/internal/atlas"
+)
+
+// coldImageIdleFrames is the number of frames after which an unused cold image is evicted.
+const coldImageIdleFrames = 300
+
+var (
+	// frameCount is the number of finished frames.
+	frameCount uint64
+
+	coldImages  = map[*Image]struct{}{}
+	coldImagesM sync.Mutex
+)
+
+// SetCold sets whether the image is cold.
+//
+// A cold image is evicted immediately, and evicted again automatically when it is not used for a while.
+// The compressed pixels of a cold image are kept as long as the image is not modified so that the image can be
+// evicted again without reading pixels from GPU.
+func (i *Image) SetCold(cold bool) error {
+	if maybeCanAddDelayedCommand() {
+		if tryAddDelayedCommand(func() error {
+			return i.SetCold(cold)
+		}) {
+			return nil
+		}
+	}
+
+	if i.cold == cold {
+		return nil
+	}
+	i.cold = cold
+
+	coldImagesM.Lock()
+	if cold {
+		coldImages[i] = struct{}{}
+	} else {
+		delete(coldImages, i)
+	}
+	coldImagesM.Unlock()
+
+	if !cold {
+		if !i.evicted {
+			i.compressed = nil
+		}
+		return nil
+	}
+	return i.Evict()
+}
+
+// Evict releases the GPU texture of the image and keeps the pixels compressed in the system memory.
+// The texture is recreated when the image is used next time.
+//
+// Evict does nothing for a volatile image.
+func (i *Image) Evict() error {
+	if maybeCanAddDelayedCommand() {
+		if tryAddDelayedCommand(func() error {
+			return i.Evict()
+		}) {
+			return nil
+		}
+	}
+
+	if i.evicted || i.volatile || i.img == nil {
+		return nil
+	}
+
+	if i.compressed == nil {
+		// If there are pending pixels or cached pixels, they are the latest pixels.
+		pix := i.pixels
+		if pix == nil {
+			p, err := i.img.Pixels(0, 0, i.width, i.height)
+			if err != nil {
+				return err
+			}
+			pix = p
+		}
+		c, err := compressPixels(pix)
+		if err != nil {
+			return err
+		}
+		i.compressed = c
+	}
+
+	i.invalidatePendingPixels()
+	i.img.MarkDisposed()
+	i.img = nil
+	i.evicted = true
+	return nil
+}
+
+// Prefetch recreates the GPU texture of the image if the image is evicted.
+func (i *Image) Prefetch() {
+	if maybeCanAddDelayedCommand() {
+		if tryAddDelayedCommand(func() error {
+			i.Prefetch()
+			return nil
+		}) {
+			return
+		}
+	}
+	i.ensureResident()
+}
+
+// ensureResident recreates the GPU texture of the image if the image is evicted, and marks the image as used.
+func (i *Image) ensureResident() {
+	i.lastUsedFrame = frameCount
+	if !i.evicted {
+		return
+	}
+
+	pix, err := decompressPixels(i.compressed, 4*i.width*i.height)
+	if err != nil {
+		// The compressed pixels are created by compressPixels and must be valid.
+		panic(fmt.Sprintf("buffered: decompressing pixels failed: %v", err))
+	}
+	img := atlas.NewImage(i.width, i.height)
+	if i.atlasGroup != 0 {
+		// SetGroup never fails for an image that doesn't have its texture yet.
+		_ = img.SetGroup(i.atlasGroup)
+	}
+	img.ReplacePixels(pix)
+
+	i.img = img
+	i.evicted = false
+	if !i.cold {
+		i.compressed = nil
+	}
+}
+
+// markModified is called when the pixels of the image are changed.
+func (i *Image) markModified() {
+	i.compressed = nil
+}
+
+// evictColdImages evicts cold images that have not been used for a while.
+func evictColdImages() error {
+	coldImagesM.Lock()
+	var imgs []*Image
+	for img := range coldImages {
+		if img.evicted {
+			continue
+		}
+		if frameCount-img.lastUsedFrame < coldImageIdleFrames {
+			continue
+		}
+		imgs = append(imgs, img)
+	}
+	coldImagesM.Unlock()
+
+	for _, img := range imgs {
+		if err := img.Evict(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func compressPixels(pix []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.BestSpeed)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(pix); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decompressPixels(compressed []byte, size int) ([]byte, error) {
+	r := flate.NewReader(bytes.NewReader(compressed))
+	defer r.Close()
+
+	pix := make([]byte, size)
+	if _, err := io.ReadFull(r, pix); err != nil {
+		return nil, err
+	}
+	return pix, nil
+}
@@ -0,0 +1,53 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buffered
+
+import (
+	"fmt"
+)
+
+// RecoverContents restores the pixels of the image from the system memory after the contents of the textures are
+// lost by the loss of the graphics device.
+//
+// RecoverContents returns false if the pixels are not available in the system memory. Then, the image is cleared.
+func (i *Image) RecoverContents() bool {
+	checkDelayedCommandsFlushed("RecoverContents")
+
+	// A volatile image is cleared every frame anyway.
+	// An evicted image has its texture recreated from the compressed pixels when it is used.
+	if i.volatile || i.evicted {
+		return true
+	}
+
+	if i.compressed != nil {
+		pix, err := decompressPixels(i.compressed, 4*i.width*i.height)
+		if err != nil {
+			// The compressed pixels are created by compressPixels and must be valid.
+			panic(fmt.Sprintf("buffered: decompressing pixels failed: %v", err))
+		}
+		i.img.ReplacePixels(pix)
+		i.invalidatePendingPixels()
+		return true
+	}
+
+	// If there are pending pixels or cached pixels, they are the latest pixels.
+	if i.pixels != nil {
+		i.img.ReplacePixels(i.pixels)
+		i.needsToResolvePixels = false
+		return true
+	}
+
+	return false
+}
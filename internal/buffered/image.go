@@ -32,6 +32,15 @@ type Image struct {
 
 	pixels               []byte
 	needsToResolvePixels bool
+
+	volatile   bool
+	atlasGroup int
+
+	// cold, evicted, compressed and lastUsedFrame are for cold images. See cold.go.
+	cold          bool
+	evicted       bool
+	compressed    []byte
+	lastUsedFrame uint64
 }
 
 func BeginFrame() error {
@@ -42,9 +51,19 @@ func BeginFrame() error {
 }
 
 func EndFrame() error {
+	frameCount++
+	if err := evictColdImages(); err != nil {
+		return err
+	}
 	return atlas.EndFrame()
 }
 
+// MaxImageSize returns the maximum width and height of an image.
+// MaxImageSize returns false if the graphics driver is not initialized yet.
+func MaxImageSize() (int, bool) {
+	return atlas.MaxImageSize()
+}
+
 func NewImage(width, height int) *Image {
 	i := &Image{}
 	i.initialize(width, height)
@@ -74,9 +93,30 @@ func (i *Image) SetVolatile(volatile bool) {
 			return
 		}
 	}
+	i.ensureResident()
+	if volatile {
+		i.markModified()
+	}
+	i.volatile = volatile
 	i.img.SetVolatile(volatile)
 }
 
+func (i *Image) SetAtlasGroup(group int) error {
+	if maybeCanAddDelayedCommand() {
+		if tryAddDelayedCommand(func() error {
+			return i.SetAtlasGroup(group)
+		}) {
+			return nil
+		}
+	}
+	i.atlasGroup = group
+	if i.evicted {
+		// The group is applied when the image is restored.
+		return nil
+	}
+	return i.img.SetGroup(group)
+}
+
 func NewScreenFramebufferImage(width, height int) *Image {
 	i := &Image{}
 	i.initializeAsScreenFramebuffer(width, height)
@@ -122,6 +162,16 @@ func (i *Image) MarkDisposed() {
 			return
 		}
 	}
+	if i.cold {
+		coldImagesM.Lock()
+		delete(coldImages, i)
+		coldImagesM.Unlock()
+	}
+	i.compressed = nil
+	if i.evicted {
+		i.evicted = false
+		return
+	}
 	i.invalidatePendingPixels()
 	i.img.MarkDisposed()
 }
@@ -132,6 +182,7 @@ func (img *Image) Pixels(x, y, width, height int) (pix []byte, err error) {
 	if !image.Rect(x, y, x+width, y+height).In(image.Rect(0, 0, img.width, img.height)) {
 		return nil, fmt.Errorf("buffered: out of range")
 	}
+	img.ensureResident()
 
 	pix = make([]byte, 4*width*height)
 
@@ -151,6 +202,7 @@ func (img *Image) Pixels(x, y, width, height int) (pix []byte, err error) {
 
 func (i *Image) Dump(name string, blackbg bool) error {
 	checkDelayedCommandsFlushed("Dump")
+	i.ensureResident()
 	return i.img.Dump(name, blackbg)
 }
 
@@ -170,6 +222,9 @@ func (i *Image) ReplacePixels(pix []byte, x, y, width, height int) error {
 		}
 	}
 
+	i.ensureResident()
+	i.markModified()
+
 	if x == 0 && y == 0 && width == i.width && height == i.height {
 		i.invalidatePendingPixels()
 
@@ -224,6 +279,7 @@ func (i *Image) DrawTriangles(srcs [graphics.ShaderImageNum]*Image, vertices []f
 	if shader == nil {
 		// Fast path for rendering without a shader (#1355).
 		img := srcs[0]
+		img.ensureResident()
 		img.resolvePendingPixels(true)
 		imgs[0] = img.img
 	} else {
@@ -231,11 +287,14 @@ func (i *Image) DrawTriangles(srcs [graphics.ShaderImageNum]*Image, vertices []f
 			if img == nil {
 				continue
 			}
+			img.ensureResident()
 			img.resolvePendingPixels(true)
 			imgs[i] = img.img
 		}
 		s = shader.shader
 	}
+	i.ensureResident()
+	i.markModified()
 	i.resolvePendingPixels(false)
 
 	i.img.DrawTriangles(imgs, vertices, indices, colorm, mode, filter, address, dstRegion, srcRegion, subimageOffsets, s, uniforms)
@@ -38,10 +38,17 @@ func GetAt(x, y int) float64 {
 	s := impl(x, y)
 	cache[pos{x, y}] = s
 
-	// TODO: Provide a way to invalidate the cache, or move the cache.
 	// The device scale can vary even for the same monitor.
-	// The only known case is when the application works on macOS, with OpenGL, with a wider screen mode,
-	// and in the fullscreen mode (#1573).
+	// The known cases are when the application works on macOS, with OpenGL, with a wider screen mode,
+	// and in the fullscreen mode (#1573), and when a page is zoomed on browsers. Invalidate is called for the latter.
 
 	return s
 }
+
+// Invalidate clears the cached device scales.
+// Invalidate should be called when the platform notifies that the device scale is changed.
+func Invalidate() {
+	m.Lock()
+	defer m.Unlock()
+	cache = map[pos]float64{}
+}
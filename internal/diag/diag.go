@@ -0,0 +1,98 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package diag delivers noteworthy internal events of the engine to a logger registered by the ebiten package.
+package diag
+
+import (
+	"sync"
+	"time"
+)
+
+// Category represents a category of an event.
+//
+// The values must be synced with ebiten.LogCategory.
+type Category int
+
+const (
+	CategoryGraphics Category = iota
+	CategoryAtlas
+	CategoryAudio
+	CategoryInput
+)
+
+// Entry represents an event.
+type Entry struct {
+	Time     time.Time
+	Category Category
+	Message  string
+	Fields   map[string]interface{}
+}
+
+// maxPendingEntries is the maximum number of the entries kept until a logger is set.
+const maxPendingEntries = 64
+
+var (
+	logger  func(entry *Entry)
+	pending []*Entry
+	m       sync.Mutex
+)
+
+// SetLogger sets the function to receive the events.
+//
+// The events logged before a logger is set, e.g. at the initialization, are delivered to the first logger.
+func SetLogger(f func(entry *Entry)) {
+	m.Lock()
+	logger = f
+	entries := pending
+	pending = nil
+	m.Unlock()
+
+	if f == nil {
+		return
+	}
+	for _, e := range entries {
+		f(e)
+	}
+}
+
+// Log logs an event. fields are pairs of a key string and a value.
+//
+// Log is concurrent-safe.
+func Log(category Category, message string, fields ...interface{}) {
+	e := &Entry{
+		Time:     time.Now(),
+		Category: category,
+		Message:  message,
+	}
+	if len(fields) > 0 {
+		e.Fields = map[string]interface{}{}
+		for i := 0; i+1 < len(fields); i += 2 {
+			e.Fields[fields[i].(string)] = fields[i+1]
+		}
+	}
+
+	m.Lock()
+	f := logger
+	if f == nil {
+		if len(pending) < maxPendingEntries {
+			pending = append(pending, e)
+		}
+		m.Unlock()
+		return
+	}
+	m.Unlock()
+
+	f(e)
+}
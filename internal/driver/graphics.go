@@ -34,10 +34,22 @@ type Graphics interface {
 	End()
 	SetTransparent(transparent bool)
 	SetVertices(vertices []float32, indices []uint16)
+
+	// BeginExternal is called before native rendering outside of Ebiten.
+	BeginExternal()
+
+	// EndExternal is called after native rendering outside of Ebiten, and restores the states that the driver
+	// assumes.
+	EndExternal()
+
 	NewImage(width, height int) (Image, error)
 	NewScreenFramebufferImage(width, height int) (Image, error)
 	Reset() error
 	SetVsyncEnabled(enabled bool)
+
+	// SetScreenBufferCount sets the number of the screen buffers. 0 means the driver's default.
+	// SetScreenBufferCount must be concurrent-safe.
+	SetScreenBufferCount(count int)
 	FramebufferYDirection() YDirection
 	NeedsRestoring() bool
 	IsGL() bool
@@ -64,6 +76,19 @@ type Graphics interface {
 // GraphicsNotReady represents that the graphics driver is not ready for recovering from the context lost.
 var GraphicsNotReady = errors.New("graphics not ready")
 
+// DeviceLostError represents an error that occurs when recovering from the loss of the graphics device.
+type DeviceLostError struct {
+	Err error
+}
+
+func (e *DeviceLostError) Error() string {
+	return "graphics device lost: " + e.Err.Error()
+}
+
+func (e *DeviceLostError) Unwrap() error {
+	return e.Err
+}
+
 type Image interface {
 	ID() ImageID
 	Dispose()
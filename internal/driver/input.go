@@ -14,6 +14,10 @@
 
 package driver
 
+import (
+	"time"
+)
+
 type GamepadID int
 
 type TouchID int
@@ -33,4 +37,5 @@ type Input interface {
 	TouchIDs() []TouchID
 	TouchPosition(id TouchID) (x, y int)
 	Wheel() (xoff, yoff float64)
+	VibrateGamepad(id GamepadID, duration time.Duration, strongMagnitude float64, weakMagnitude float64)
 }
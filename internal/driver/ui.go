@@ -24,6 +24,14 @@ type UIContext interface {
 	ForceUpdate() error
 	Layout(outsideWidth, outsideHeight float64)
 
+	// IsScreenUpdated reports whether the screen framebuffer is updated at the last Update or ForceUpdate.
+	// If not, the UI driver can skip presenting the screen.
+	IsScreenUpdated() bool
+
+	// Shutdown is called when the game loop ends regularly, e.g. when the window is closed.
+	// Shutdown must be called on the same goroutine as Update's, and can be called multiple times.
+	Shutdown() error
+
 	// AdjustPosition can be called from a different goroutine from Update's or Layout's.
 	AdjustPosition(x, y float64, deviceScaleFactor float64) (float64, float64)
 }
@@ -42,6 +50,9 @@ type UI interface {
 	ScreenSizeInFullscreen() (int, int)
 	ResetForFrame()
 
+	// UpdateInput samples the input state immediately. UpdateInput can be called from the game's goroutine.
+	UpdateInput()
+
 	CursorMode() CursorMode
 	SetCursorMode(mode CursorMode)
 
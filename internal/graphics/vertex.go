@@ -92,18 +92,61 @@ func Vertices(n int) []float32 {
 	return theVerticesBackend.slice(n)
 }
 
+var (
+	theIndicesBackend = &indicesBackend{}
+)
+
+type indicesBackend struct {
+	backend []uint16
+	head    int
+	m       sync.Mutex
+}
+
+func (i *indicesBackend) slice(n int) []uint16 {
+	i.m.Lock()
+	defer i.m.Unlock()
+
+	if i.head+n > len(i.backend) {
+		i.backend = nil
+		i.head = 0
+	}
+	if i.backend == nil {
+		l := 1024 * 6
+		if n > l {
+			l = n
+		}
+		i.backend = make([]uint16, l)
+	}
+
+	s := i.backend[i.head : i.head+n]
+	i.head += n
+	return s
+}
+
+// Indices returns a uint16 slice for n indices.
+// Indices returns a slice that never overlaps with other slices returned this function.
+func Indices(n int) []uint16 {
+	return theIndicesBackend.slice(n)
+}
+
 // QuadVertices returns a float32 slice for a quadrangle.
 // QuadVertices returns a slice that never overlaps with other slices returned this function,
 // and users can do optimization based on this fact.
 func QuadVertices(sx0, sy0, sx1, sy1 float32, a, b, c, d, tx, ty float32, cr, cg, cb, ca float32) []float32 {
+	// Use the vertex backend instead of calling make to reduce GCs (#1521).
+	vs := theVerticesBackend.slice(4)
+	PutQuadVertices(vs, sx0, sy0, sx1, sy1, a, b, c, d, tx, ty, cr, cg, cb, ca)
+	return vs
+}
+
+// PutQuadVertices puts the vertices for a quadrangle to vs in the same way as QuadVertices.
+// The length of vs must be at least 4 * VertexFloatNum.
+func PutQuadVertices(vs []float32, sx0, sy0, sx1, sy1 float32, a, b, c, d, tx, ty float32, cr, cg, cb, ca float32) {
 	x := sx1 - sx0
 	y := sy1 - sy0
 	ax, by, cx, dy := a*x, b*y, c*x, d*y
 	u0, v0, u1, v1 := float32(sx0), float32(sy0), float32(sx1), float32(sy1)
 
-	// Use the vertex backend instead of calling make to reduce GCs (#1521).
-	vs := theVerticesBackend.slice(4)
-
 	// This function is very performance-sensitive and implement in a very dumb way.
 	_ = vs[:4*VertexFloatNum]
 
@@ -142,6 +185,4 @@ func QuadVertices(sx0, sy0, sx1, sy1 float32, a, b, c, d, tx, ty float32, cr, cg
 	vs[29] = cg
 	vs[30] = cb
 	vs[31] = ca
-
-	return vs
 }
@@ -18,12 +18,14 @@ import (
 	"fmt"
 	"math"
 	"strings"
+	"time"
 
 	"github.com/hajimehoshi/ebiten/v2/internal/affine"
 	"github.com/hajimehoshi/ebiten/v2/internal/debug"
 	"github.com/hajimehoshi/ebiten/v2/internal/driver"
 	"github.com/hajimehoshi/ebiten/v2/internal/graphics"
 	"github.com/hajimehoshi/ebiten/v2/internal/shaderir"
+	"github.com/hajimehoshi/ebiten/v2/internal/stats"
 )
 
 var theGraphicsDriver driver.Graphics
@@ -203,6 +205,10 @@ func (q *commandQueue) Enqueue(command command) {
 // Flush flushes the command queue.
 func (q *commandQueue) Flush() error {
 	return runOnMainThread(func() error {
+		t := time.Now()
+		defer func() {
+			stats.AddPhaseTime(stats.PhaseGPU, time.Since(t))
+		}()
 		return q.flush()
 	})
 }
@@ -412,6 +418,10 @@ func (c *drawTrianglesCommand) Exec(indexOffset int) error {
 	if c.nindices == 0 {
 		return nil
 	}
+	stats.AddDrawCall()
+	if stats.IsDrawCallLogEnabled() {
+		stats.LogDrawCall(c.String())
+	}
 
 	if c.shader != nil {
 		var imgs [graphics.ShaderImageNum]driver.ImageID
@@ -563,6 +573,9 @@ func (c *disposeImageCommand) String() string {
 // Exec executes the disposeImageCommand.
 func (c *disposeImageCommand) Exec(indexOffset int) error {
 	c.target.image.Dispose()
+	if !c.target.screen {
+		stats.RemoveTexture(c.target.textureBytes())
+	}
 	return nil
 }
 
@@ -635,6 +648,7 @@ func (c *newImageCommand) Exec(indexOffset int) error {
 		return err
 	}
 	c.result.image = i
+	stats.AddTexture(c.result.textureBytes())
 	return nil
 }
 
@@ -734,6 +748,31 @@ func ResetGraphicsDriverState() error {
 	})
 }
 
+// BeginExternal flushes the queued commands and prepares the graphics driver for native rendering outside of
+// Ebiten.
+func BeginExternal() error {
+	if err := theCommandQueue.Flush(); err != nil {
+		return err
+	}
+	return runOnMainThread(func() error {
+		theGraphicsDriver.BeginExternal()
+		return nil
+	})
+}
+
+// EndExternal restores the graphics driver's states after native rendering outside of Ebiten.
+func EndExternal() error {
+	return runOnMainThread(func() error {
+		theGraphicsDriver.EndExternal()
+		return nil
+	})
+}
+
+// RunOnRenderingThread calls f on the thread where the graphics driver's functions are called.
+func RunOnRenderingThread(f func() error) error {
+	return runOnMainThread(f)
+}
+
 // MaxImageSize returns the maximum size of an image.
 func MaxImageSize() int {
 	var size int
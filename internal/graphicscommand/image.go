@@ -118,6 +118,12 @@ func (i *Image) InternalSize() (int, int) {
 	return i.internalWidth, i.internalHeight
 }
 
+// textureBytes returns the approximate size of the texture in bytes.
+func (i *Image) textureBytes() int64 {
+	w, h := graphics.InternalImageSize(i.width), graphics.InternalImageSize(i.height)
+	return int64(w) * int64(h) * 4
+}
+
 // DrawTriangles draws triangles with the given image.
 //
 // The vertex floats are:
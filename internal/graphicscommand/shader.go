@@ -15,16 +15,60 @@
 package graphicscommand
 
 import (
+	"sort"
+	"sync"
+
 	"github.com/hajimehoshi/ebiten/v2/internal/driver"
 	"github.com/hajimehoshi/ebiten/v2/internal/shaderir"
 )
 
 type Shader struct {
 	shader driver.Shader
+	id     int
+	ir     *shaderir.Program
+}
+
+var (
+	// theShaders is a set of live shaders for diagnostics.
+	theShaders   = map[*Shader]struct{}{}
+	nextShaderID = 1
+	shadersM     sync.Mutex
+)
+
+// ShaderInfo represents the information of a shader for diagnostics.
+type ShaderInfo struct {
+	ID      int
+	Program *shaderir.Program
+}
+
+// Shaders returns the information of the live shaders in the order of creation.
+func Shaders() []ShaderInfo {
+	shadersM.Lock()
+	defer shadersM.Unlock()
+
+	infos := make([]ShaderInfo, 0, len(theShaders))
+	for s := range theShaders {
+		infos = append(infos, ShaderInfo{
+			ID:      s.id,
+			Program: s.ir,
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ID < infos[j].ID
+	})
+	return infos
 }
 
 func NewShader(ir *shaderir.Program) *Shader {
-	s := &Shader{}
+	shadersM.Lock()
+	s := &Shader{
+		id: nextShaderID,
+		ir: ir,
+	}
+	nextShaderID++
+	theShaders[s] = struct{}{}
+	shadersM.Unlock()
+
 	c := &newShaderCommand{
 		result: s,
 		ir:     ir,
@@ -34,6 +78,10 @@ func NewShader(ir *shaderir.Program) *Shader {
 }
 
 func (s *Shader) Dispose() {
+	shadersM.Lock()
+	delete(theShaders, s)
+	shadersM.Unlock()
+
 	c := &disposeShaderCommand{
 		target: s,
 	}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"math"
 	"strings"
+	"sync/atomic"
 	"unsafe"
 
 	"github.com/hajimehoshi/ebiten/v2/internal/affine"
@@ -330,6 +331,9 @@ type Graphics struct {
 	tmpTextures  []mtl.Texture
 
 	pool unsafe.Pointer
+
+	// screenBufferCount is the number of the screen buffers. This must be accessed atomically.
+	screenBufferCount int32
 }
 
 var theGraphics Graphics
@@ -342,6 +346,8 @@ func (g *Graphics) Begin() {
 	// NSAutoreleasePool is required to release drawable correctly (#847).
 	// https://developer.apple.com/library/archive/documentation/3DDrawing/Conceptual/MTLBestPracticesGuide/Drawables.html
 	g.pool = C.allocAutoreleasePool()
+
+	g.view.setMaximumDrawableCount(int(atomic.LoadInt32(&g.screenBufferCount)))
 }
 
 func (g *Graphics) End() {
@@ -362,6 +368,15 @@ func (g *Graphics) SetUIView(uiview uintptr) {
 	g.view.setUIView(uiview)
 }
 
+func (g *Graphics) BeginExternal() {
+	// Commit Ebiten's command buffer so that the commands are executed before the external commands.
+	g.flushIfNeeded(false)
+}
+
+func (g *Graphics) EndExternal() {
+	// A new command buffer and new encoders are created at the next drawing. There is no state to restore.
+}
+
 func (g *Graphics) SetVertices(vertices []float32, indices []uint16) {
 	if g.vb != (mtl.Buffer{}) {
 		g.vb.Release()
@@ -743,6 +758,10 @@ func (g *Graphics) SetVsyncEnabled(enabled bool) {
 	g.view.setDisplaySyncEnabled(enabled)
 }
 
+func (g *Graphics) SetScreenBufferCount(count int) {
+	atomic.StoreInt32(&g.screenBufferCount, int32(count))
+}
+
 func (g *Graphics) FramebufferYDirection() driver.YDirection {
 	return driver.Downward
 }
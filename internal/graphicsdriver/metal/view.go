@@ -18,8 +18,6 @@
 package metal
 
 import (
-	"sync"
-
 	"github.com/hajimehoshi/ebiten/v2/internal/graphicsdriver/metal/ca"
 	"github.com/hajimehoshi/ebiten/v2/internal/graphicsdriver/metal/mtl"
 )
@@ -31,10 +29,14 @@ type view struct {
 	windowChanged bool
 	vsync         bool
 
+	// maximumDrawableCount is the maximum number of the drawables. 0 means the default.
+	maximumDrawableCount int
+
 	device mtl.Device
 	ml     ca.MetalLayer
 
-	once sync.Once
+	// layerView is the UIView that the Metal layer is added to. This is used only on iOS.
+	layerView uintptr
 }
 
 func (v *view) setDrawableSize(width, height int) {
@@ -50,6 +52,21 @@ func (v *view) setDisplaySyncEnabled(enabled bool) {
 	v.vsync = enabled
 }
 
+func (v *view) setMaximumDrawableCount(count int) {
+	if v.maximumDrawableCount == count {
+		return
+	}
+	v.maximumDrawableCount = count
+	v.ml.SetMaximumDrawableCount(v.actualMaximumDrawableCount())
+}
+
+func (v *view) actualMaximumDrawableCount() int {
+	if v.maximumDrawableCount == 0 {
+		return 3
+	}
+	return v.maximumDrawableCount
+}
+
 func (v *view) colorPixelFormat() mtl.PixelFormat {
 	return v.ml.PixelFormat()
 }
@@ -69,7 +86,7 @@ func (v *view) reset() error {
 	// MTLPixelFormatBGRA8Unorm_sRGB, MTLPixelFormatRGBA16Float, MTLPixelFormatBGRA10_XR, or
 	// MTLPixelFormatBGRA10_XR_sRGB.
 	v.ml.SetPixelFormat(mtl.PixelFormatBGRA8UNorm)
-	v.ml.SetMaximumDrawableCount(3)
+	v.ml.SetMaximumDrawableCount(v.actualMaximumDrawableCount())
 
 	// The vsync state might be reset. Set the state again (#1364).
 	v.ml.SetDisplaySyncEnabled(v.vsync)
@@ -48,12 +48,15 @@ func (v *view) setUIView(uiview uintptr) {
 }
 
 func (v *view) update() {
-	v.once.Do(func() {
-		if v.ml.Layer() == nil {
-			panic("metal: CAMetalLayer is not initialized yet")
-		}
+	if v.ml.Layer() == nil {
+		panic("metal: CAMetalLayer is not initialized yet")
+	}
+	// The UIView can be replaced e.g. when the view controller is recreated.
+	// Adding the layer to a new view removes the layer from the previous view.
+	if v.layerView != v.uiview {
 		C.addSublayer(unsafe.Pointer(v.uiview), v.ml.Layer())
-	})
+		v.layerView = v.uiview
+	}
 	C.setFrame(v.ml.Layer(), unsafe.Pointer(v.uiview))
 }
 
@@ -103,6 +103,16 @@ func (c *context) reset() error {
 	}
 
 	c.locationCache = newLocationCache()
+	c.restoreState()
+
+	f := int32(0)
+	gl.GetIntegerv(gl.FRAMEBUFFER_BINDING, &f)
+	c.screenFramebuffer = framebufferNative(f)
+	return nil
+}
+
+// restoreState invalidates the cached states and restores the OpenGL states that Ebiten assumes.
+func (c *context) restoreState() {
 	c.lastTexture = invalidTexture
 	c.lastFramebuffer = invalidFramebuffer
 	c.lastViewportWidth = 0
@@ -112,11 +122,6 @@ func (c *context) reset() error {
 	gl.Enable(gl.SCISSOR_TEST)
 
 	c.blendFunc(driver.CompositeModeSourceOver)
-
-	f := int32(0)
-	gl.GetIntegerv(gl.FRAMEBUFFER_BINDING, &f)
-	c.screenFramebuffer = framebufferNative(f)
-	return nil
 }
 
 func (c *context) blendFunc(mode driver.CompositeMode) {
@@ -440,6 +445,10 @@ func (c *context) getShaderPrecisionFormatPrecision() int {
 	return highpPrecision
 }
 
+func (c *context) finish() {
+	gl.Finish()
+}
+
 func (c *context) flush() {
 	gl.Flush()
 }
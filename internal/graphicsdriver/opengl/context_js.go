@@ -91,14 +91,26 @@ var (
 type contextImpl struct {
 	gl            *gl
 	lastProgramID programID
+
+	// canvas is the canvas to render to. If canvas is not set, the first canvas element in the document is used.
+	canvas js.Value
+
+	// glContext is the underlying WebGL rendering context.
+	glContext js.Value
 }
 
 func (c *context) initGL() {
 	var gl js.Value
 
-	// TODO: Define id?
-	if doc := js.Global().Get("document"); doc.Truthy() {
-		canvas := doc.Call("querySelector", "canvas")
+	canvas := c.canvas
+	if !canvas.Truthy() {
+		// TODO: Define id?
+		if doc := js.Global().Get("document"); doc.Truthy() {
+			canvas = doc.Call("querySelector", "canvas")
+		}
+	}
+
+	if canvas.Truthy() {
 		attr := js.Global().Get("Object").New()
 		attr.Set("alpha", true)
 		attr.Set("premultipliedAlpha", true)
@@ -118,26 +130,20 @@ func (c *context) initGL() {
 		gl = go2cpp.Get("gl")
 	}
 
+	c.glContext = gl
 	c.gl = newGL(gl)
 }
 
 func (c *context) reset() error {
 	c.locationCache = newLocationCache()
-	c.lastTexture = textureNative(js.Null())
-	c.lastFramebuffer = framebufferNative(js.Null())
-	c.lastViewportWidth = 0
-	c.lastViewportHeight = 0
-	c.lastCompositeMode = driver.CompositeModeUnknown
 
 	c.initGL()
 
 	if c.gl.isContextLost.Invoke().Bool() {
 		return driver.GraphicsNotReady
 	}
+	c.restoreState()
 	gl := c.gl
-	gl.enable.Invoke(gles.BLEND)
-	gl.enable.Invoke(gles.SCISSOR_TEST)
-	c.blendFunc(driver.CompositeModeSourceOver)
 	f := gl.getParameter.Invoke(gles.FRAMEBUFFER_BINDING)
 	c.screenFramebuffer = framebufferNative(f)
 
@@ -147,6 +153,19 @@ func (c *context) reset() error {
 	return nil
 }
 
+// restoreState invalidates the cached states and restores the WebGL states that Ebiten assumes.
+func (c *context) restoreState() {
+	c.lastTexture = textureNative(js.Null())
+	c.lastFramebuffer = framebufferNative(js.Null())
+	c.lastViewportWidth = 0
+	c.lastViewportHeight = 0
+	c.lastCompositeMode = driver.CompositeModeUnknown
+	gl := c.gl
+	gl.enable.Invoke(gles.BLEND)
+	gl.enable.Invoke(gles.SCISSOR_TEST)
+	c.blendFunc(driver.CompositeModeSourceOver)
+}
+
 func (c *context) blendFunc(mode driver.CompositeMode) {
 	if c.lastCompositeMode == mode {
 		return
@@ -520,6 +539,11 @@ func (c *context) getShaderPrecisionFormatPrecision() int {
 	return gl.getShaderPrecisionFormat.Invoke(gles.FRAGMENT_SHADER, gles.HIGH_FLOAT).Get("precision").Int()
 }
 
+func (c *context) finish() {
+	gl := c.gl
+	gl.finish.Invoke()
+}
+
 func (c *context) flush() {
 	gl := c.gl
 	gl.flush.Invoke()
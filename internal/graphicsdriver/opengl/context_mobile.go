@@ -93,6 +93,16 @@ type contextImpl struct {
 
 func (c *context) reset() error {
 	c.locationCache = newLocationCache()
+	c.restoreState()
+	f := make([]int32, 1)
+	c.ctx.GetIntegerv(f, gles.FRAMEBUFFER_BINDING)
+	c.screenFramebuffer = framebufferNative(f[0])
+	// TODO: Need to update screenFramebufferWidth/Height?
+	return nil
+}
+
+// restoreState invalidates the cached states and restores the OpenGL states that Ebiten assumes.
+func (c *context) restoreState() {
 	c.lastTexture = invalidTexture
 	c.lastFramebuffer = invalidFramebuffer
 	c.lastViewportWidth = 0
@@ -101,11 +111,6 @@ func (c *context) reset() error {
 	c.ctx.Enable(gles.BLEND)
 	c.ctx.Enable(gles.SCISSOR_TEST)
 	c.blendFunc(driver.CompositeModeSourceOver)
-	f := make([]int32, 1)
-	c.ctx.GetIntegerv(f, gles.FRAMEBUFFER_BINDING)
-	c.screenFramebuffer = framebufferNative(f[0])
-	// TODO: Need to update screenFramebufferWidth/Height?
-	return nil
 }
 
 func (c *context) blendFunc(mode driver.CompositeMode) {
@@ -405,6 +410,10 @@ func (c *context) getShaderPrecisionFormatPrecision() int {
 	return p
 }
 
+func (c *context) finish() {
+	c.ctx.Finish()
+}
+
 func (c *context) flush() {
 	c.ctx.Flush()
 }
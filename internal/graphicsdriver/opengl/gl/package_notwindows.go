@@ -118,6 +118,7 @@ package gl
 // typedef void  (APIENTRYP GPDRAWELEMENTS)(GLenum  mode, GLsizei  count, GLenum  type, const uintptr_t indices);
 // typedef void  (APIENTRYP GPENABLE)(GLenum  cap);
 // typedef void  (APIENTRYP GPENABLEVERTEXATTRIBARRAY)(GLuint  index);
+// typedef void  (APIENTRYP GPFINISH)();
 // typedef void  (APIENTRYP GPFLUSH)();
 // typedef void  (APIENTRYP GPFRAMEBUFFERTEXTURE2DEXT)(GLenum  target, GLenum  attachment, GLenum  textarget, GLuint  texture, GLint  level);
 // typedef void  (APIENTRYP GPGENBUFFERS)(GLsizei  n, GLuint * buffers);
@@ -233,6 +234,9 @@ package gl
 // static void  glowEnableVertexAttribArray(GPENABLEVERTEXATTRIBARRAY fnptr, GLuint  index) {
 //   (*fnptr)(index);
 // }
+// static void  glowFinish(GPFINISH fnptr) {
+//   (*fnptr)();
+// }
 // static void  glowFlush(GPFLUSH fnptr) {
 //   (*fnptr)();
 // }
@@ -407,6 +411,7 @@ var (
 	gpDrawElements                C.GPDRAWELEMENTS
 	gpEnable                      C.GPENABLE
 	gpEnableVertexAttribArray     C.GPENABLEVERTEXATTRIBARRAY
+	gpFinish                      C.GPFINISH
 	gpFlush                       C.GPFLUSH
 	gpFramebufferTexture2DEXT     C.GPFRAMEBUFFERTEXTURE2DEXT
 	gpGenBuffers                  C.GPGENBUFFERS
@@ -555,6 +560,10 @@ func EnableVertexAttribArray(index uint32) {
 	C.glowEnableVertexAttribArray(gpEnableVertexAttribArray, (C.GLuint)(index))
 }
 
+func Finish() {
+	C.glowFinish(gpFinish)
+}
+
 func Flush() {
 	C.glowFlush(gpFlush)
 }
@@ -828,6 +837,10 @@ func InitWithProcAddrFunc(getProcAddr func(name string) unsafe.Pointer) error {
 	if gpEnableVertexAttribArray == nil {
 		return errors.New("glEnableVertexAttribArray")
 	}
+	gpFinish = (C.GPFINISH)(getProcAddr("glFinish"))
+	if gpFinish == nil {
+		return errors.New("glFinish")
+	}
 	gpFlush = (C.GPFLUSH)(getProcAddr("glFlush"))
 	if gpFlush == nil {
 		return errors.New("glFlush")
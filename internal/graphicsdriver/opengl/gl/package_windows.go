@@ -32,6 +32,7 @@ var (
 	gpDrawElements                uintptr
 	gpEnable                      uintptr
 	gpEnableVertexAttribArray     uintptr
+	gpFinish                      uintptr
 	gpFlush                       uintptr
 	gpFramebufferTexture2DEXT     uintptr
 	gpGenBuffers                  uintptr
@@ -180,6 +181,10 @@ func EnableVertexAttribArray(index uint32) {
 	syscall.Syscall(gpEnableVertexAttribArray, 1, uintptr(index), 0, 0)
 }
 
+func Finish() {
+	syscall.Syscall(gpFinish, 0, 0, 0, 0)
+}
+
 func Flush() {
 	syscall.Syscall(gpFlush, 0, 0, 0, 0)
 }
@@ -453,6 +458,10 @@ func InitWithProcAddrFunc(getProcAddr func(name string) uintptr) error {
 	if gpEnableVertexAttribArray == 0 {
 		return errors.New("glEnableVertexAttribArray")
 	}
+	gpFinish = getProcAddr("glFinish")
+	if gpFinish == 0 {
+		return errors.New("glFinish")
+	}
 	gpFlush = getProcAddr("glFlush")
 	if gpFlush == 0 {
 		return errors.New("glFlush")
@@ -45,6 +45,7 @@ type gl struct {
 	enable                   js.Value
 	enableVertexAttribArray  js.Value
 	framebufferTexture2D     js.Value
+	finish                   js.Value
 	flush                    js.Value
 	getBufferSubData         js.Value
 	getExtension             js.Value
@@ -111,6 +112,7 @@ func newGL(v js.Value) *gl {
 		enable:                   v.Get("enable").Call("bind", v),
 		enableVertexAttribArray:  v.Get("enableVertexAttribArray").Call("bind", v),
 		framebufferTexture2D:     v.Get("framebufferTexture2D").Call("bind", v),
+		finish:                   v.Get("finish").Call("bind", v),
 		flush:                    v.Get("flush").Call("bind", v),
 		getParameter:             v.Get("getParameter").Call("bind", v),
 		getProgramInfoLog:        v.Get("getProgramInfoLog").Call("bind", v),
@@ -138,6 +138,10 @@ func (DefaultContext) EnableVertexAttribArray(index uint32) {
 	C.glEnableVertexAttribArray(C.GLuint(index))
 }
 
+func (DefaultContext) Finish() {
+	C.glFinish()
+}
+
 func (DefaultContext) Flush() {
 	C.glFlush()
 }
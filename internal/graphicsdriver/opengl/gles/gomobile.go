@@ -137,6 +137,10 @@ func (g *GomobileContext) EnableVertexAttribArray(index uint32) {
 	g.ctx.EnableVertexAttribArray(gl.Attrib{Value: uint(index)})
 }
 
+func (g *GomobileContext) Finish() {
+	g.ctx.Finish()
+}
+
 func (g *GomobileContext) Flush() {
 	g.ctx.Flush()
 }
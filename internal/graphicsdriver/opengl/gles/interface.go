@@ -40,6 +40,7 @@ type Context interface {
 	DrawElements(mode uint32, count int32, xtype uint32, offset int)
 	Enable(cap uint32)
 	EnableVertexAttribArray(index uint32)
+	Finish()
 	Flush()
 	FramebufferTexture2D(target uint32, attachment uint32, textarget uint32, texture uint32, level int32)
 	GenBuffers(n int32) []uint32
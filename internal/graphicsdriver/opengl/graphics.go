@@ -16,6 +16,7 @@ package opengl
 
 import (
 	"fmt"
+	"sync/atomic"
 
 	"github.com/hajimehoshi/ebiten/v2/internal/affine"
 	"github.com/hajimehoshi/ebiten/v2/internal/driver"
@@ -41,6 +42,9 @@ type Graphics struct {
 
 	// drawCalled is true just after Draw is called. This holds true until ReplacePixels is called.
 	drawCalled bool
+
+	// screenBufferCount is the number of the screen buffers. This must be accessed atomically.
+	screenBufferCount int32
 }
 
 func (g *Graphics) Begin() {
@@ -50,6 +54,13 @@ func (g *Graphics) Begin() {
 func (g *Graphics) End() {
 	// Call glFlush to prevent black flicking (especially on Android (#226) and iOS).
 	// TODO: examples/sprites worked without this. Is this really needed?
+	//
+	// The number of the swap chain's buffers cannot be controlled with OpenGL. For double buffering, wait for
+	// the GPU instead so that the driver doesn't queue more frames.
+	if atomic.LoadInt32(&g.screenBufferCount) == 2 {
+		g.context.finish()
+		return
+	}
 	g.context.flush()
 }
 
@@ -140,6 +151,24 @@ func (g *Graphics) Reset() error {
 	return g.state.reset(&g.context)
 }
 
+func (g *Graphics) BeginExternal() {
+	// Let the driver execute Ebiten's commands before the external commands.
+	g.context.flush()
+}
+
+func (g *Graphics) EndExternal() {
+	g.context.restoreState()
+
+	// Rebind the buffers here since SetVertices assumes the buffers are bound.
+	g.state.lastProgram = zeroProgram
+	g.state.lastActiveTexture = 0
+	for k := range g.state.lastUniforms {
+		delete(g.state.lastUniforms, k)
+	}
+	g.context.bindArrayBuffer(g.state.arrayBuffer)
+	g.context.bindElementArrayBuffer(g.state.elementArrayBuffer)
+}
+
 func (g *Graphics) SetVertices(vertices []float32, indices []uint16) {
 	// Note that the vertices passed to BufferSubData is not under GC management
 	// in opengl package due to unsafe-way.
@@ -252,6 +281,10 @@ func (g *Graphics) SetVsyncEnabled(enabled bool) {
 	// Do nothing
 }
 
+func (g *Graphics) SetScreenBufferCount(count int) {
+	atomic.StoreInt32(&g.screenBufferCount, int32(count))
+}
+
 func (g *Graphics) FramebufferYDirection() driver.YDirection {
 	return driver.Upward
 }
@@ -0,0 +1,51 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opengl
+
+import (
+	"syscall/js"
+)
+
+// SetCanvas sets the canvas to render to, e.g. an OffscreenCanvas in a worker.
+//
+// SetCanvas must be called before the graphics context is initialized.
+func (g *Graphics) SetCanvas(canvas js.Value) {
+	g.context.canvas = canvas
+}
+
+// WebGLContext returns the underlying WebGL rendering context.
+//
+// WebGLContext returns undefined before the graphics context is initialized.
+func (g *Graphics) WebGLContext() js.Value {
+	if !g.context.glContext.Truthy() {
+		return js.Undefined()
+	}
+	return g.context.glContext
+}
+
+// SetScreenFramebuffer replaces the framebuffer for the screen, e.g. with a WebXR layer's framebuffer.
+// If f is null, the default framebuffer is used.
+func (g *Graphics) SetScreenFramebuffer(f js.Value) {
+	c := &g.context
+	if c.screenFramebuffer.equal(framebufferNative(f)) {
+		return
+	}
+	c.screenFramebuffer = framebufferNative(f)
+
+	// Bind the new framebuffer and set the viewport at the next rendering.
+	c.lastFramebuffer = framebufferNative(js.Undefined())
+	c.lastViewportWidth = 0
+	c.lastViewportHeight = 0
+}
@@ -42,7 +42,8 @@ func (i *Image) Dispose() {
 	if !i.pbo.equal(*new(buffer)) {
 		i.graphics.context.deleteBuffer(i.pbo)
 	}
-	if i.framebuffer != nil {
+	// The screen framebuffer is owned by the platform.
+	if i.framebuffer != nil && !i.screen {
 		i.framebuffer.delete(&i.graphics.context)
 	}
 	if !i.textureNative.equal(*new(textureNative)) {
@@ -89,6 +90,10 @@ func (i *Image) framebufferSize() (int, int) {
 
 func (i *Image) ensureFramebuffer() error {
 	if i.framebuffer != nil {
+		// The screen framebuffer can be replaced, e.g. by WebXR.
+		if i.screen {
+			i.framebuffer.native = i.graphics.context.getScreenFramebuffer()
+		}
 		return nil
 	}
 
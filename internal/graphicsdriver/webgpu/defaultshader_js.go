@@ -0,0 +1,230 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webgpu
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2/internal/driver"
+	"github.com/hajimehoshi/ebiten/v2/internal/shaderir"
+)
+
+// defaultUniformTypes is the types of the uniform variables of the default shader.
+var defaultUniformTypes = []shaderir.Type{
+	{Main: shaderir.Vec2},  // viewport_size
+	{Main: shaderir.Vec2},  // source_size
+	{Main: shaderir.Mat4},  // color_matrix_body
+	{Main: shaderir.Vec4},  // color_matrix_translation
+	{Main: shaderir.Float}, // scale
+	{Main: shaderir.Vec4},  // source_region
+}
+
+const (
+	defaultVertexEntryPoint   = "VertexShader"
+	defaultFragmentEntryPoint = "FragmentShader"
+)
+
+// defaultShaderSource returns the WGSL source of the default shader for the given parameters.
+//
+// WGSL doesn't have templates unlike Metal. Instead, the parameters are embedded as constants, and the branches
+// by them are resolved at the compilation.
+func defaultShaderSource(useColorM bool, filter driver.Filter, address driver.Address) string {
+	replaces := map[string]string{
+		"{{.FilterNearest}}":      fmt.Sprintf("%d", driver.FilterNearest),
+		"{{.FilterLinear}}":       fmt.Sprintf("%d", driver.FilterLinear),
+		"{{.FilterScreen}}":       fmt.Sprintf("%d", driver.FilterScreen),
+		"{{.AddressClampToZero}}": fmt.Sprintf("%d", driver.AddressClampToZero),
+		"{{.AddressRepeat}}":      fmt.Sprintf("%d", driver.AddressRepeat),
+		"{{.AddressUnsafe}}":      fmt.Sprintf("%d", driver.AddressUnsafe),
+		"{{.UseColorM}}":          fmt.Sprintf("%t", useColorM),
+		"{{.Filter}}":             fmt.Sprintf("%d", filter),
+		"{{.Address}}":            fmt.Sprintf("%d", address),
+	}
+	src := defaultShaderTemplate
+	for k, v := range replaces {
+		src = strings.Replace(src, k, v, -1)
+	}
+	return src
+}
+
+const defaultShaderTemplate = `const FILTER_NEAREST: i32 = {{.FilterNearest}};
+const FILTER_LINEAR: i32 = {{.FilterLinear}};
+const FILTER_SCREEN: i32 = {{.FilterScreen}};
+
+const ADDRESS_CLAMP_TO_ZERO: i32 = {{.AddressClampToZero}};
+const ADDRESS_REPEAT: i32 = {{.AddressRepeat}};
+const ADDRESS_UNSAFE: i32 = {{.AddressUnsafe}};
+
+const use_color_m: bool = {{.UseColorM}};
+const filter_type: i32 = {{.Filter}};
+const address_type: i32 = {{.Address}};
+
+struct Uniforms {
+  viewport_size: vec2<f32>,
+  source_size: vec2<f32>,
+  color_matrix_body: mat4x4<f32>,
+  color_matrix_translation: vec4<f32>,
+  scale: f32,
+  source_region: vec4<f32>,
+}
+
+@group(0) @binding(0) var<storage, read> uniforms: Uniforms;
+@group(0) @binding(1) var texture_sampler: sampler;
+@group(0) @binding(2) var source_texture: texture_2d<f32>;
+
+struct VertexIn {
+  @location(0) position: vec2<f32>,
+  @location(1) tex: vec2<f32>,
+  @location(2) color: vec4<f32>,
+}
+
+struct VertexOut {
+  @builtin(position) position: vec4<f32>,
+  @location(0) tex: vec2<f32>,
+  @location(1) color: vec4<f32>,
+}
+
+@vertex
+fn VertexShader(v: VertexIn) -> VertexOut {
+  // In WebGPU, the NDC's Y direction (upward) and the framebuffer's Y direction (downward) don't match, and a
+  // viewport cannot invert the Y direction. Invert the Y direction in the projection matrix instead.
+  let projection_matrix = mat4x4<f32>(
+    vec4<f32>(2.0 / uniforms.viewport_size.x, 0.0, 0.0, 0.0),
+    vec4<f32>(0.0, -2.0 / uniforms.viewport_size.y, 0.0, 0.0),
+    vec4<f32>(0.0, 0.0, 1.0, 0.0),
+    vec4<f32>(-1.0, 1.0, 0.0, 1.0)
+  );
+
+  var o: VertexOut;
+  o.position = projection_matrix * vec4<f32>(v.position, 0.0, 1.0);
+  o.tex = v.tex;
+  o.color = v.color;
+  return o;
+}
+
+fn floor_mod(x: f32, y: f32) -> f32 {
+  if (x < 0.0) {
+    return y - (-x - y * floor(-x / y));
+  }
+  return x - y * floor(x / y);
+}
+
+fn adjust_texel_by_address(p: vec2<f32>) -> vec2<f32> {
+  if (address_type != ADDRESS_REPEAT) {
+    return p;
+  }
+  let r = uniforms.source_region;
+  let o = vec2<f32>(r[0], r[1]);
+  let size = vec2<f32>(r[2] - r[0], r[3] - r[1]);
+  return vec2<f32>(floor_mod((p.x - o.x), size.x) + o.x, floor_mod((p.y - o.y), size.y) + o.y);
+}
+
+// texel_at samples the texture. textureSample is available only in uniform control flow, then use
+// textureSampleLevel instead.
+fn texel_at(p: vec2<f32>) -> vec4<f32> {
+  return textureSampleLevel(source_texture, texture_sampler, p, 0.0);
+}
+
+fn color_from_texel(v: VertexOut) -> vec4<f32> {
+  let r = uniforms.source_region;
+
+  if (filter_type == FILTER_NEAREST) {
+    if (address_type == ADDRESS_UNSAFE) {
+      return texel_at(v.tex);
+    }
+    let p = adjust_texel_by_address(v.tex);
+    if (r[0] <= p.x &&
+        r[1] <= p.y &&
+        p.x < r[2] &&
+        p.y < r[3]) {
+      return texel_at(p);
+    }
+    return vec4<f32>(0.0);
+  }
+
+  let texel_size = 1.0 / uniforms.source_size;
+
+  if (filter_type == FILTER_LINEAR) {
+    // Shift 1/512 [texel] to avoid the tie-breaking issue.
+    // As all the vertex positions are aligned to 1/16 [pixel], this shiting should work in most cases.
+    var p0 = v.tex - texel_size / 2.0 + (texel_size / 512.0);
+    var p1 = v.tex + texel_size / 2.0 + (texel_size / 512.0);
+    p0 = adjust_texel_by_address(p0);
+    p1 = adjust_texel_by_address(p1);
+
+    var c0 = texel_at(p0);
+    var c1 = texel_at(vec2<f32>(p1.x, p0.y));
+    var c2 = texel_at(vec2<f32>(p0.x, p1.y));
+    var c3 = texel_at(p1);
+
+    if (address_type != ADDRESS_UNSAFE) {
+      if (p0.x < r[0]) {
+        c0 = vec4<f32>(0.0);
+        c2 = vec4<f32>(0.0);
+      }
+      if (p0.y < r[1]) {
+        c0 = vec4<f32>(0.0);
+        c1 = vec4<f32>(0.0);
+      }
+      if (r[2] <= p1.x) {
+        c1 = vec4<f32>(0.0);
+        c3 = vec4<f32>(0.0);
+      }
+      if (r[3] <= p1.y) {
+        c2 = vec4<f32>(0.0);
+        c3 = vec4<f32>(0.0);
+      }
+    }
+
+    let rate = fract(p0 * uniforms.source_size);
+    return mix(mix(c0, c1, rate.x), mix(c2, c3, rate.x), rate.y);
+  }
+
+  // FILTER_SCREEN
+  let scale = uniforms.scale;
+  let p0 = v.tex - texel_size / 2.0 / scale + (texel_size / 512.0);
+  let p1 = v.tex + texel_size / 2.0 / scale + (texel_size / 512.0);
+
+  let c0 = texel_at(p0);
+  let c1 = texel_at(vec2<f32>(p1.x, p0.y));
+  let c2 = texel_at(vec2<f32>(p0.x, p1.y));
+  let c3 = texel_at(p1);
+
+  let rate_center = vec2<f32>(1.0, 1.0) - texel_size / 2.0 / scale;
+  let rate = clamp(((fract(p0 * uniforms.source_size) - rate_center) * scale) + rate_center, vec2<f32>(0.0), vec2<f32>(1.0));
+  return mix(mix(c0, c1, rate.x), mix(c2, c3, rate.x), rate.y);
+}
+
+@fragment
+fn FragmentShader(v: VertexOut) -> @location(0) vec4<f32> {
+  var c = color_from_texel(v);
+  if (filter_type == FILTER_SCREEN) {
+    return c;
+  }
+
+  if (use_color_m) {
+    let rgb = c.rgb / (c.a + (1.0 - sign(c.a)));
+    c = (uniforms.color_matrix_body * vec4<f32>(rgb, c.a)) + uniforms.color_matrix_translation;
+    c = c * v.color;
+    c = vec4<f32>(c.rgb * c.a, c.a);
+  } else {
+    let s = v.color;
+    c = c * vec4<f32>(s.r, s.g, s.b, 1.0) * s.a;
+  }
+  c = min(c, vec4<f32>(c.a));
+  return c;
+}
+`
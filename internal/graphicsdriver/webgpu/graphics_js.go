@@ -0,0 +1,1100 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webgpu
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"syscall/js"
+
+	"github.com/hajimehoshi/ebiten/v2/internal/affine"
+	"github.com/hajimehoshi/ebiten/v2/internal/driver"
+	"github.com/hajimehoshi/ebiten/v2/internal/graphics"
+	"github.com/hajimehoshi/ebiten/v2/internal/jsutil"
+	"github.com/hajimehoshi/ebiten/v2/internal/shaderir"
+	"github.com/hajimehoshi/ebiten/v2/internal/shaderir/wgsl"
+	"github.com/hajimehoshi/ebiten/v2/internal/web"
+)
+
+// The flags of GPUBufferUsage, GPUTextureUsage, GPUMapMode and GPUShaderStage.
+const (
+	bufferUsageMapRead = 0x0001
+	bufferUsageCopySrc = 0x0004
+	bufferUsageCopyDst = 0x0008
+	bufferUsageIndex   = 0x0010
+	bufferUsageVertex  = 0x0020
+	bufferUsageStorage = 0x0080
+
+	textureUsageCopySrc          = 0x01
+	textureUsageCopyDst          = 0x02
+	textureUsageTextureBinding   = 0x04
+	textureUsageRenderAttachment = 0x10
+
+	mapModeRead = 0x0001
+
+	shaderStageVertex   = 0x1
+	shaderStageFragment = 0x2
+)
+
+const (
+	// bytesPerRowAlignment is the alignment of bytesPerRow at copying between a buffer and a texture.
+	bytesPerRowAlignment = 256
+
+	// uniformBufferSize is the size of a buffer to store the uniform variables of draw calls.
+	uniformBufferSize = 1 << 16
+
+	// uniformOffsetAlignment is the alignment of the offset to bind a storage buffer.
+	uniformOffsetAlignment = 256
+
+	textureFormat = "rgba8unorm"
+)
+
+var (
+	gpu        = navigatorGPU()
+	uint8Array = js.Global().Get("Uint8Array")
+)
+
+func navigatorGPU() js.Value {
+	// navigator is undefined on old node.js.
+	n := js.Global().Get("navigator")
+	if !n.Truthy() {
+		return js.Undefined()
+	}
+	return n.Get("gpu")
+}
+
+func roundUp(x, align int) int {
+	return (x + align - 1) / align * align
+}
+
+// IsAvailable reports whether the browser supports WebGPU.
+func IsAvailable() bool {
+	return gpu.Truthy()
+}
+
+type pipelineKey struct {
+	useColorM     bool
+	filter        driver.Filter
+	address       driver.Address
+	compositeMode driver.CompositeMode
+	screen        bool
+}
+
+type defaultModuleKey struct {
+	useColorM bool
+	filter    driver.Filter
+	address   driver.Address
+}
+
+type Graphics struct {
+	device  js.Value
+	queue   js.Value
+	context js.Value
+
+	canvasFormat string
+
+	// deviceLost reports whether the device is lost. This must be accessed atomically.
+	deviceLost        int32
+	deviceLostHandler func()
+
+	sampler         js.Value
+	dummyTexture    js.Value
+	dummyView       js.Value
+	bindGroupLayout js.Value
+	pipelineLayout  js.Value
+
+	defaultModules map[defaultModuleKey]js.Value
+	pipelines      map[pipelineKey]js.Value
+
+	encoder js.Value
+	pass    js.Value
+	passDst *Image
+
+	vb js.Value
+	ib js.Value
+
+	uniformBuffers     []js.Value
+	uniformBufferIndex int
+	uniformOffset      int
+
+	// tmpBuffers and tmpTextures are destroyed after the current commands are submitted.
+	tmpBuffers  []js.Value
+	tmpTextures []js.Value
+
+	images      map[driver.ImageID]*Image
+	nextImageID driver.ImageID
+
+	shaders      map[driver.ShaderID]*Shader
+	nextShaderID driver.ShaderID
+
+	transparent  bool
+	maxImageSize int
+
+	// screenBufferCount is the number of the screen buffers. This must be accessed atomically.
+	screenBufferCount int32
+}
+
+var theGraphics Graphics
+
+func Get() *Graphics {
+	return &theGraphics
+}
+
+// Initialize requests a WebGPU device and prepares the canvas to render to.
+//
+// Initialize must not be called in a callback from JavaScript, as this waits for promises.
+func (g *Graphics) Initialize(canvas js.Value) error {
+	if !IsAvailable() {
+		return errors.New("webgpu: WebGPU is not available")
+	}
+	if err := g.requestDevice(); err != nil {
+		return err
+	}
+
+	// A canvas can have only one type of context. Get the context after the device is available so that the
+	// canvas is still available for WebGL otherwise.
+	ctx := canvas.Call("getContext", "webgpu")
+	if !ctx.Truthy() {
+		g.device.Call("destroy")
+		g.device = js.Undefined()
+		return errors.New("webgpu: getContext failed")
+	}
+	g.context = ctx
+	g.canvasFormat = gpu.Call("getPreferredCanvasFormat").String()
+	return nil
+}
+
+// SetDeviceLostHandler sets the function called when the device is lost.
+// The device is requested again at Reset.
+func (g *Graphics) SetDeviceLostHandler(f func()) {
+	g.deviceLostHandler = f
+}
+
+func (g *Graphics) requestDevice() error {
+	adapter, err := web.AwaitPromise(gpu.Call("requestAdapter"))
+	if err != nil {
+		return fmt.Errorf("webgpu: requestAdapter failed: %v", err)
+	}
+	if !adapter.Truthy() {
+		return errors.New("webgpu: no adapter is available")
+	}
+	device, err := web.AwaitPromise(adapter.Call("requestDevice"))
+	if err != nil {
+		return fmt.Errorf("webgpu: requestDevice failed: %v", err)
+	}
+
+	g.device = device
+	g.queue = device.Get("queue")
+	atomic.StoreInt32(&g.deviceLost, 0)
+
+	var f js.Func
+	f = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		defer f.Release()
+
+		// The device is destroyed explicitly, or replaced with a new device.
+		if r := args[0].Get("reason"); r.Type() == js.TypeString && r.String() == "destroyed" {
+			return nil
+		}
+		if !g.device.Equal(device) {
+			return nil
+		}
+		atomic.StoreInt32(&g.deviceLost, 1)
+		if g.deviceLostHandler != nil {
+			g.deviceLostHandler()
+		}
+		return nil
+	})
+	device.Get("lost").Call("then", f)
+	return nil
+}
+
+func (g *Graphics) Begin() {
+}
+
+func (g *Graphics) End() {
+	// The canvas's texture is presented after the current task finishes.
+	g.flush()
+}
+
+func (g *Graphics) SetTransparent(transparent bool) {
+	// The canvas is always composited with premultiplied alpha as WebGL does.
+	g.transparent = transparent
+}
+
+func (g *Graphics) BeginExternal() {
+	// Submit Ebiten's commands so that the commands are executed before the external commands.
+	g.flush()
+}
+
+func (g *Graphics) EndExternal() {
+	// A new command encoder is created at the next drawing. There is no state to restore.
+}
+
+func (g *Graphics) newBuffer(size int, usage int) js.Value {
+	return g.device.Call("createBuffer", map[string]interface{}{
+		"size":  size,
+		"usage": usage,
+	})
+}
+
+func (g *Graphics) SetVertices(vertices []float32, indices []uint16) {
+	// The buffers might be used by the commands that are not submitted yet.
+	if g.vb.Truthy() {
+		g.tmpBuffers = append(g.tmpBuffers, g.vb)
+	}
+	if g.ib.Truthy() {
+		g.tmpBuffers = append(g.tmpBuffers, g.ib)
+	}
+
+	// The size to write a buffer must be a multiple of 4.
+	vsize := roundUp(4*len(vertices), 4)
+	g.vb = g.newBuffer(vsize, bufferUsageVertex|bufferUsageCopyDst)
+	g.queue.Call("writeBuffer", g.vb, 0, jsutil.TemporaryUint8Array(vsize, vertices), 0, vsize)
+
+	isize := roundUp(2*len(indices), 4)
+	g.ib = g.newBuffer(isize, bufferUsageIndex|bufferUsageCopyDst)
+	g.queue.Call("writeBuffer", g.ib, 0, jsutil.TemporaryUint8Array(isize, indices), 0, isize)
+}
+
+func (g *Graphics) commandEncoder() js.Value {
+	if !g.encoder.Truthy() {
+		g.encoder = g.device.Call("createCommandEncoder")
+	}
+	return g.encoder
+}
+
+// renderPass returns a render pass to render to dst. The current render pass is reused if its destination is dst.
+func (g *Graphics) renderPass(dst *Image) js.Value {
+	if g.pass.Truthy() && g.passDst == dst {
+		return g.pass
+	}
+	g.endRenderPass()
+
+	var view js.Value
+	if dst.screen {
+		view = g.context.Call("getCurrentTexture").Call("createView")
+	} else {
+		view = dst.view()
+	}
+
+	g.pass = g.commandEncoder().Call("beginRenderPass", map[string]interface{}{
+		"colorAttachments": []interface{}{
+			map[string]interface{}{
+				"view": view,
+				// Even though the destination pixels are not used, discarding them is not safe (#1019).
+				"loadOp":  "load",
+				"storeOp": "store",
+			},
+		},
+	})
+	g.passDst = dst
+	return g.pass
+}
+
+func (g *Graphics) endRenderPass() {
+	if !g.pass.Truthy() {
+		return
+	}
+	g.pass.Call("end")
+	g.pass = js.Undefined()
+	g.passDst = nil
+}
+
+func (g *Graphics) flush() {
+	if !g.encoder.Truthy() {
+		return
+	}
+	g.endRenderPass()
+	g.queue.Call("submit", []interface{}{g.encoder.Call("finish")})
+	g.encoder = js.Undefined()
+
+	// Destroying resources after submitting is fine. They are released after the commands are executed.
+	for _, b := range g.tmpBuffers {
+		b.Call("destroy")
+	}
+	g.tmpBuffers = g.tmpBuffers[:0]
+	for _, t := range g.tmpTextures {
+		t.Call("destroy")
+	}
+	g.tmpTextures = g.tmpTextures[:0]
+
+	g.uniformBufferIndex = 0
+	g.uniformOffset = 0
+}
+
+// uniformBuffer returns a buffer and an offset to store the uniform variables of the given size in bytes.
+//
+// The uniform variables are written by GPUQueue.writeBuffer, which is executed before the commands submitted after
+// it. Then, a region of a buffer must not be reused until the current commands are submitted.
+func (g *Graphics) uniformBuffer(size int) (js.Value, int) {
+	if size > uniformBufferSize {
+		b := g.newBuffer(size, bufferUsageStorage|bufferUsageCopyDst)
+		g.tmpBuffers = append(g.tmpBuffers, b)
+		return b, 0
+	}
+
+	if g.uniformOffset+size > uniformBufferSize {
+		g.uniformBufferIndex++
+		g.uniformOffset = 0
+	}
+	if g.uniformBufferIndex == len(g.uniformBuffers) {
+		g.uniformBuffers = append(g.uniformBuffers, g.newBuffer(uniformBufferSize, bufferUsageStorage|bufferUsageCopyDst))
+	}
+	b := g.uniformBuffers[g.uniformBufferIndex]
+	offset := g.uniformOffset
+	g.uniformOffset = roundUp(offset+size, uniformOffsetAlignment)
+	return b, offset
+}
+
+func (g *Graphics) checkSize(width, height int) {
+	if width < 1 {
+		panic(fmt.Sprintf("webgpu: width (%d) must be equal or more than %d", width, 1))
+	}
+	if height < 1 {
+		panic(fmt.Sprintf("webgpu: height (%d) must be equal or more than %d", height, 1))
+	}
+	m := g.MaxImageSize()
+	if width > m {
+		panic(fmt.Sprintf("webgpu: width (%d) must be less than or equal to %d", width, m))
+	}
+	if height > m {
+		panic(fmt.Sprintf("webgpu: height (%d) must be less than or equal to %d", height, m))
+	}
+}
+
+func (g *Graphics) genNextImageID() driver.ImageID {
+	id := g.nextImageID
+	g.nextImageID++
+	return id
+}
+
+func (g *Graphics) InvalidImageID() driver.ImageID {
+	return -1
+}
+
+func (g *Graphics) genNextShaderID() driver.ShaderID {
+	id := g.nextShaderID
+	g.nextShaderID++
+	return id
+}
+
+func (g *Graphics) NewImage(width, height int) (driver.Image, error) {
+	g.checkSize(width, height)
+	t := g.device.Call("createTexture", map[string]interface{}{
+		"size": map[string]interface{}{
+			"width":  graphics.InternalImageSize(width),
+			"height": graphics.InternalImageSize(height),
+		},
+		"format": textureFormat,
+		"usage":  textureUsageTextureBinding | textureUsageRenderAttachment | textureUsageCopySrc | textureUsageCopyDst,
+	})
+	i := &Image{
+		id:       g.genNextImageID(),
+		graphics: g,
+		width:    width,
+		height:   height,
+		texture:  t,
+	}
+	g.addImage(i)
+	return i, nil
+}
+
+func (g *Graphics) NewScreenFramebufferImage(width, height int) (driver.Image, error) {
+	// The canvas's size is set by the UI driver.
+	i := &Image{
+		id:       g.genNextImageID(),
+		graphics: g,
+		width:    width,
+		height:   height,
+		screen:   true,
+	}
+	g.addImage(i)
+	return i, nil
+}
+
+func (g *Graphics) addImage(img *Image) {
+	if g.images == nil {
+		g.images = map[driver.ImageID]*Image{}
+	}
+	if _, ok := g.images[img.id]; ok {
+		panic(fmt.Sprintf("webgpu: image ID %d was already registered", img.id))
+	}
+	g.images[img.id] = img
+}
+
+func (g *Graphics) removeImage(img *Image) {
+	delete(g.images, img.id)
+}
+
+func operationToBlendFactor(c driver.Operation) string {
+	switch c {
+	case driver.Zero:
+		return "zero"
+	case driver.One:
+		return "one"
+	case driver.SrcAlpha:
+		return "src-alpha"
+	case driver.DstAlpha:
+		return "dst-alpha"
+	case driver.OneMinusSrcAlpha:
+		return "one-minus-src-alpha"
+	case driver.OneMinusDstAlpha:
+		return "one-minus-dst-alpha"
+	case driver.DstColor:
+		return "dst"
+	default:
+		panic(fmt.Sprintf("webgpu: invalid operation: %d", c))
+	}
+}
+
+func (g *Graphics) Reset() error {
+	if atomic.LoadInt32(&g.deviceLost) != 0 {
+		if err := g.requestDevice(); err != nil {
+			// The device might be available later, e.g. after the GPU process restarts.
+			return driver.GraphicsNotReady
+		}
+	}
+	if !g.device.Truthy() {
+		return errors.New("webgpu: the device is not initialized")
+	}
+
+	g.encoder = js.Undefined()
+	g.pass = js.Undefined()
+	g.passDst = nil
+	g.vb = js.Undefined()
+	g.ib = js.Undefined()
+	g.uniformBuffers = nil
+	g.uniformBufferIndex = 0
+	g.uniformOffset = 0
+	g.tmpBuffers = nil
+	g.tmpTextures = nil
+	g.maxImageSize = 0
+
+	g.context.Call("configure", map[string]interface{}{
+		"device": g.device,
+		"format": g.canvasFormat,
+		// Use premultiplied alpha as WebGL does.
+		"alphaMode": "premultiplied",
+	})
+
+	g.sampler = g.device.Call("createSampler", map[string]interface{}{
+		"magFilter":    "nearest",
+		"minFilter":    "nearest",
+		"addressModeU": "clamp-to-edge",
+		"addressModeV": "clamp-to-edge",
+	})
+
+	// A texture must be bound even when a shader doesn't use a source image.
+	g.dummyTexture = g.device.Call("createTexture", map[string]interface{}{
+		"size": map[string]interface{}{
+			"width":  1,
+			"height": 1,
+		},
+		"format": textureFormat,
+		"usage":  textureUsageTextureBinding,
+	})
+	g.dummyView = g.dummyTexture.Call("createView")
+
+	entries := []interface{}{
+		map[string]interface{}{
+			"binding":    wgsl.UniformsBinding,
+			"visibility": shaderStageVertex | shaderStageFragment,
+			"buffer": map[string]interface{}{
+				"type": "read-only-storage",
+			},
+		},
+		map[string]interface{}{
+			"binding":    wgsl.SamplerBinding,
+			"visibility": shaderStageVertex | shaderStageFragment,
+			"sampler": map[string]interface{}{
+				"type": "filtering",
+			},
+		},
+	}
+	for i := 0; i < graphics.ShaderImageNum; i++ {
+		entries = append(entries, map[string]interface{}{
+			"binding":    wgsl.TextureBindingOffset + i,
+			"visibility": shaderStageVertex | shaderStageFragment,
+			"texture": map[string]interface{}{
+				"sampleType": "float",
+			},
+		})
+	}
+	g.bindGroupLayout = g.device.Call("createBindGroupLayout", map[string]interface{}{
+		"entries": entries,
+	})
+	g.pipelineLayout = g.device.Call("createPipelineLayout", map[string]interface{}{
+		"bindGroupLayouts": []interface{}{g.bindGroupLayout},
+	})
+
+	// Pipelines are created lazily as there are many combinations.
+	g.defaultModules = map[defaultModuleKey]js.Value{}
+	g.pipelines = map[pipelineKey]js.Value{}
+
+	return nil
+}
+
+// targetFormat returns the texture format to render to.
+func (g *Graphics) targetFormat(screen bool) string {
+	if screen {
+		return g.canvasFormat
+	}
+	return textureFormat
+}
+
+func (g *Graphics) newPipeline(module js.Value, vertexEntryPoint, fragmentEntryPoint string, attributes []shaderir.Type, mode driver.CompositeMode, screen bool) js.Value {
+	var attrs []interface{}
+	var offset int
+	for i, a := range attributes {
+		format := "float32"
+		if n := a.FloatNum(); n > 1 {
+			format = fmt.Sprintf("float32x%d", n)
+		}
+		attrs = append(attrs, map[string]interface{}{
+			"shaderLocation": i,
+			"offset":         offset,
+			"format":         format,
+		})
+		offset += 4 * a.FloatNum()
+	}
+
+	src, dst := mode.Operations()
+	blend := map[string]interface{}{
+		"srcFactor": operationToBlendFactor(src),
+		"dstFactor": operationToBlendFactor(dst),
+		"operation": "add",
+	}
+	return g.device.Call("createRenderPipeline", map[string]interface{}{
+		"layout": g.pipelineLayout,
+		"vertex": map[string]interface{}{
+			"module":     module,
+			"entryPoint": vertexEntryPoint,
+			"buffers": []interface{}{
+				map[string]interface{}{
+					"arrayStride": 4 * graphics.VertexFloatNum,
+					"attributes":  attrs,
+				},
+			},
+		},
+		"fragment": map[string]interface{}{
+			"module":     module,
+			"entryPoint": fragmentEntryPoint,
+			"targets": []interface{}{
+				map[string]interface{}{
+					"format": g.targetFormat(screen),
+					"blend": map[string]interface{}{
+						"color": blend,
+						"alpha": blend,
+					},
+				},
+			},
+		},
+		"primitive": map[string]interface{}{
+			"topology": "triangle-list",
+		},
+	})
+}
+
+var defaultAttributes = []shaderir.Type{
+	{Main: shaderir.Vec2}, // position
+	{Main: shaderir.Vec2}, // texture coordinates
+	{Main: shaderir.Vec4}, // color
+}
+
+func (g *Graphics) defaultPipeline(key pipelineKey) js.Value {
+	if p, ok := g.pipelines[key]; ok {
+		return p
+	}
+
+	mkey := defaultModuleKey{
+		useColorM: key.useColorM,
+		filter:    key.filter,
+		address:   key.address,
+	}
+	m, ok := g.defaultModules[mkey]
+	if !ok {
+		m = g.device.Call("createShaderModule", map[string]interface{}{
+			"code": defaultShaderSource(key.useColorM, key.filter, key.address),
+		})
+		g.defaultModules[mkey] = m
+	}
+
+	p := g.newPipeline(m, defaultVertexEntryPoint, defaultFragmentEntryPoint, defaultAttributes, key.compositeMode, key.screen)
+	g.pipelines[key] = p
+	return p
+}
+
+func (g *Graphics) draw(pipeline js.Value, dst *Image, dstRegion driver.Region, srcs [graphics.ShaderImageNum]*Image, indexLen int, indexOffset int, uniforms []float32) error {
+	// Write the uniform variables before starting the render pass, as this doesn't matter with the render pass.
+	size := 4 * len(uniforms)
+	ub, uoffset := g.uniformBuffer(size)
+	g.queue.Call("writeBuffer", ub, uoffset, jsutil.TemporaryFloat32Array(len(uniforms), uniforms), 0, len(uniforms))
+
+	entries := []interface{}{
+		map[string]interface{}{
+			"binding": wgsl.UniformsBinding,
+			"resource": map[string]interface{}{
+				"buffer": ub,
+				"offset": uoffset,
+				"size":   size,
+			},
+		},
+		map[string]interface{}{
+			"binding":  wgsl.SamplerBinding,
+			"resource": g.sampler,
+		},
+	}
+	for i, src := range srcs {
+		v := g.dummyView
+		if src != nil {
+			v = src.view()
+		}
+		entries = append(entries, map[string]interface{}{
+			"binding":  wgsl.TextureBindingOffset + i,
+			"resource": v,
+		})
+	}
+	bg := g.device.Call("createBindGroup", map[string]interface{}{
+		"layout":  g.bindGroupLayout,
+		"entries": entries,
+	})
+
+	pass := g.renderPass(dst)
+	pass.Call("setPipeline", pipeline)
+
+	w, h := dst.internalSize()
+	pass.Call("setViewport", 0, 0, w, h, 0, 1)
+
+	// The scissor rect must be in the render target.
+	x0 := clamp(int(dstRegion.X), 0, w)
+	y0 := clamp(int(dstRegion.Y), 0, h)
+	x1 := clamp(int(dstRegion.X+dstRegion.Width), 0, w)
+	y1 := clamp(int(dstRegion.Y+dstRegion.Height), 0, h)
+	pass.Call("setScissorRect", x0, y0, x1-x0, y1-y0)
+
+	pass.Call("setVertexBuffer", 0, g.vb)
+	pass.Call("setIndexBuffer", g.ib, "uint16")
+	pass.Call("setBindGroup", 0, bg)
+	pass.Call("drawIndexed", indexLen, 1, indexOffset)
+	return nil
+}
+
+func clamp(x, min, max int) int {
+	if x < min {
+		return min
+	}
+	if x > max {
+		return max
+	}
+	return x
+}
+
+func (g *Graphics) Draw(dstID, srcID driver.ImageID, indexLen int, indexOffset int, mode driver.CompositeMode, colorM *affine.ColorM, filter driver.Filter, address driver.Address, dstRegion, srcRegion driver.Region) error {
+	dst := g.images[dstID]
+
+	srcs := [graphics.ShaderImageNum]*Image{g.images[srcID]}
+
+	var key pipelineKey
+	if dst.screen && filter == driver.FilterScreen {
+		// The screen filter overwrites the destination pixels.
+		key = pipelineKey{
+			filter:        driver.FilterScreen,
+			address:       driver.AddressUnsafe,
+			compositeMode: driver.CompositeModeCopy,
+			screen:        true,
+		}
+	} else {
+		key = pipelineKey{
+			useColorM:     colorM != nil,
+			filter:        filter,
+			address:       address,
+			compositeMode: mode,
+			screen:        dst.screen,
+		}
+	}
+	pipeline := g.defaultPipeline(key)
+
+	w, h := dst.internalSize()
+	sourceSize := []float32{0, 0}
+	if filter != driver.FilterNearest {
+		w, h := srcs[0].internalSize()
+		sourceSize[0] = float32(w)
+		sourceSize[1] = float32(h)
+	}
+	esBody, esTranslate := colorM.UnsafeElements()
+	scale := float32(0)
+	if filter == driver.FilterScreen {
+		scale = float32(dst.width) / float32(srcs[0].width)
+	}
+	uniforms := []interface{}{
+		[]float32{float32(w), float32(h)},
+		sourceSize,
+		esBody,
+		esTranslate,
+		scale,
+		[]float32{
+			srcRegion.X,
+			srcRegion.Y,
+			srcRegion.X + srcRegion.Width,
+			srcRegion.Y + srcRegion.Height,
+		},
+	}
+	us, err := packUniforms(defaultUniformTypes, uniforms)
+	if err != nil {
+		return err
+	}
+	if err := g.draw(pipeline, dst, dstRegion, srcs, indexLen, indexOffset, us); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (g *Graphics) SetVsyncEnabled(enabled bool) {
+	// Do nothing. The frames are synchronized with requestAnimationFrame by the UI driver.
+}
+
+func (g *Graphics) SetScreenBufferCount(count int) {
+	// The canvas manages its textures by itself. The value is just kept.
+	atomic.StoreInt32(&g.screenBufferCount, int32(count))
+}
+
+func (g *Graphics) FramebufferYDirection() driver.YDirection {
+	return driver.Downward
+}
+
+func (g *Graphics) NeedsRestoring() bool {
+	return false
+}
+
+func (g *Graphics) IsGL() bool {
+	return false
+}
+
+func (g *Graphics) HasHighPrecisionFloat() bool {
+	return true
+}
+
+func (g *Graphics) MaxImageSize() int {
+	if g.maxImageSize == 0 {
+		g.maxImageSize = g.device.Get("limits").Get("maxTextureDimension2D").Int()
+	}
+	return g.maxImageSize
+}
+
+func (g *Graphics) NewShader(program *shaderir.Program) (driver.Shader, error) {
+	s, err := newShader(g, g.genNextShaderID(), program)
+	if err != nil {
+		return nil, err
+	}
+	g.addShader(s)
+	return s, nil
+}
+
+func (g *Graphics) addShader(shader *Shader) {
+	if g.shaders == nil {
+		g.shaders = map[driver.ShaderID]*Shader{}
+	}
+	if _, ok := g.shaders[shader.id]; ok {
+		panic(fmt.Sprintf("webgpu: shader ID %d was already registered", shader.id))
+	}
+	g.shaders[shader.id] = shader
+}
+
+func (g *Graphics) removeShader(shader *Shader) {
+	delete(g.shaders, shader.id)
+}
+
+func (g *Graphics) DrawShader(dstID driver.ImageID, srcIDs [graphics.ShaderImageNum]driver.ImageID, offsets [graphics.ShaderImageNum - 1][2]float32, shader driver.ShaderID, indexLen int, indexOffset int, dstRegion, srcRegion driver.Region, mode driver.CompositeMode, uniforms []interface{}) error {
+	dst := g.images[dstID]
+
+	var srcs [graphics.ShaderImageNum]*Image
+	for i, srcID := range srcIDs {
+		srcs[i] = g.images[srcID]
+	}
+
+	s := g.shaders[shader]
+	pipeline := s.pipeline(mode, dst.screen)
+
+	us := make([]interface{}, graphics.PreservedUniformVariablesNum+len(uniforms))
+
+	// Set the destination texture size.
+	dw, dh := dst.internalSize()
+	us[graphics.DestinationTextureSizeUniformVariableIndex] = []float32{float32(dw), float32(dh)}
+
+	// Set the source texture sizes.
+	usizes := make([]float32, 2*len(srcs))
+	for i, src := range srcs {
+		if src != nil {
+			w, h := src.internalSize()
+			usizes[2*i] = float32(w)
+			usizes[2*i+1] = float32(h)
+		}
+	}
+	us[graphics.TextureSizesUniformVariableIndex] = usizes
+
+	// Set the destination region's origin.
+	udorigin := []float32{float32(dstRegion.X) / float32(dw), float32(dstRegion.Y) / float32(dh)}
+	us[graphics.TextureDestinationRegionOriginUniformVariableIndex] = udorigin
+
+	// Set the destination region's size.
+	udsize := []float32{float32(dstRegion.Width) / float32(dw), float32(dstRegion.Height) / float32(dh)}
+	us[graphics.TextureDestinationRegionSizeUniformVariableIndex] = udsize
+
+	// Set the source offsets.
+	uoffsets := make([]float32, 2*len(offsets))
+	for i, offset := range offsets {
+		uoffsets[2*i] = offset[0]
+		uoffsets[2*i+1] = offset[1]
+	}
+	us[graphics.TextureSourceOffsetsUniformVariableIndex] = uoffsets
+
+	// Set the source region's origin of texture0.
+	usorigin := []float32{float32(srcRegion.X), float32(srcRegion.Y)}
+	us[graphics.TextureSourceRegionOriginUniformVariableIndex] = usorigin
+
+	// Set the source region's size of texture0.
+	ussize := []float32{float32(srcRegion.Width), float32(srcRegion.Height)}
+	us[graphics.TextureSourceRegionSizeUniformVariableIndex] = ussize
+
+	// Set the additional uniform variables.
+	for i, v := range uniforms {
+		const offset = graphics.PreservedUniformVariablesNum
+		us[offset+i] = v
+	}
+
+	packed, err := packUniforms(s.ir.Uniforms, us)
+	if err != nil {
+		return err
+	}
+	if err := g.draw(pipeline, dst, dstRegion, srcs, indexLen, indexOffset, packed); err != nil {
+		return err
+	}
+	return nil
+}
+
+// uniformTypeLayout returns the alignment and the size of a type in float units, in the layout of a storage buffer.
+func uniformTypeLayout(t *shaderir.Type) (int, int) {
+	switch t.Main {
+	case shaderir.Float:
+		return 1, 1
+	case shaderir.Vec2:
+		return 2, 2
+	case shaderir.Vec3:
+		return 4, 3
+	case shaderir.Vec4:
+		return 4, 4
+	case shaderir.Mat2:
+		return 2, 4
+	case shaderir.Mat3:
+		// Each column of mat3x3 is aligned as vec3.
+		return 4, 12
+	case shaderir.Mat4:
+		return 4, 16
+	case shaderir.Array:
+		align, size := uniformTypeLayout(&t.Sub[0])
+		return align, roundUp(size, align) * t.Length
+	default:
+		panic(fmt.Sprintf("webgpu: unexpected uniform type: %s", t.String()))
+	}
+}
+
+// packUniforms packs the uniform variables values into a float32 slice in the layout of a WGSL struct in a storage
+// buffer.
+func packUniforms(types []shaderir.Type, values []interface{}) ([]float32, error) {
+	var us []float32
+	maxAlign := 1
+	for i, t := range types {
+		align, size := uniformTypeLayout(&t)
+		if maxAlign < align {
+			maxAlign = align
+		}
+		offset := roundUp(len(us), align)
+		us = append(us, make([]float32, offset+size-len(us))...)
+
+		switch v := values[i].(type) {
+		case float32:
+			us[offset] = v
+		case []float32:
+			switch {
+			case t.Main == shaderir.Mat3:
+				for j := 0; j < 3; j++ {
+					copy(us[offset+4*j:offset+4*j+3], v[3*j:3*j+3])
+				}
+			case t.Main == shaderir.Array:
+				salign, ssize := uniformTypeLayout(&t.Sub[0])
+				stride := roundUp(ssize, salign)
+				n := t.Sub[0].FloatNum()
+				for j := 0; j < t.Length && n*j < len(v); j++ {
+					dst := us[offset+stride*j : offset+stride*(j+1)]
+					src := v[n*j:]
+					if t.Sub[0].Main == shaderir.Mat3 {
+						for k := 0; k < 3; k++ {
+							copy(dst[4*k:4*k+3], src[3*k:3*k+3])
+						}
+						continue
+					}
+					copy(dst[:n], src)
+				}
+			default:
+				copy(us[offset:offset+size], v)
+			}
+		default:
+			return nil, fmt.Errorf("webgpu: unexpected uniform value: %[1]v (type: %[1]T)", values[i])
+		}
+	}
+	us = append(us, make([]float32, roundUp(len(us), maxAlign)-len(us))...)
+	return us, nil
+}
+
+type Image struct {
+	id       driver.ImageID
+	graphics *Graphics
+	width    int
+	height   int
+	screen   bool
+	texture  js.Value
+
+	textureView js.Value
+}
+
+func (i *Image) ID() driver.ImageID {
+	return i.id
+}
+
+func (i *Image) internalSize() (int, int) {
+	if i.screen {
+		return i.width, i.height
+	}
+	return graphics.InternalImageSize(i.width), graphics.InternalImageSize(i.height)
+}
+
+func (i *Image) view() js.Value {
+	if !i.textureView.Truthy() {
+		i.textureView = i.texture.Call("createView")
+	}
+	return i.textureView
+}
+
+func (i *Image) Dispose() {
+	if i.texture.Truthy() {
+		// The texture might be used by the commands that are not submitted yet.
+		i.graphics.tmpTextures = append(i.graphics.tmpTextures, i.texture)
+		i.texture = js.Undefined()
+		i.textureView = js.Undefined()
+	}
+	i.graphics.removeImage(i)
+}
+
+func (i *Image) IsInvalidated() bool {
+	// A device lost is detected explicitly by GPUDevice.lost.
+	return false
+}
+
+func (i *Image) Pixels() ([]byte, error) {
+	if i.screen {
+		return nil, errors.New("webgpu: Pixels cannot be called for the screen")
+	}
+
+	g := i.graphics
+	g.endRenderPass()
+
+	bytesPerRow := roundUp(4*i.width, bytesPerRowAlignment)
+	size := bytesPerRow * i.height
+	buf := g.newBuffer(size, bufferUsageMapRead|bufferUsageCopyDst)
+	g.commandEncoder().Call("copyTextureToBuffer", map[string]interface{}{
+		"texture": i.texture,
+	}, map[string]interface{}{
+		"buffer":      buf,
+		"bytesPerRow": bytesPerRow,
+	}, map[string]interface{}{
+		"width":  i.width,
+		"height": i.height,
+	})
+	g.flush()
+
+	if _, err := web.AwaitPromise(buf.Call("mapAsync", mapModeRead)); err != nil {
+		buf.Call("destroy")
+		return nil, fmt.Errorf("webgpu: mapAsync failed: %v", err)
+	}
+	bs := make([]byte, size)
+	js.CopyBytesToGo(bs, uint8Array.New(buf.Call("getMappedRange")))
+	buf.Call("unmap")
+	buf.Call("destroy")
+
+	if bytesPerRow == 4*i.width {
+		return bs, nil
+	}
+	pix := make([]byte, 4*i.width*i.height)
+	for j := 0; j < i.height; j++ {
+		copy(pix[4*i.width*j:4*i.width*(j+1)], bs[bytesPerRow*j:])
+	}
+	return pix, nil
+}
+
+func (i *Image) ReplacePixels(args []*driver.ReplacePixelsArgs) {
+	if i.screen {
+		panic("webgpu: ReplacePixels cannot be called for the screen")
+	}
+
+	g := i.graphics
+
+	// Use one staging buffer for all the arguments. The pixels are copied after the current render pass, as
+	// writing the texture directly by GPUQueue.writeTexture would be executed before the unsubmitted commands.
+	offsets := make([]int, len(args))
+	var size int
+	for j, a := range args {
+		offsets[j] = size
+		size = roundUp(size+roundUp(4*a.Width, bytesPerRowAlignment)*a.Height, 4)
+	}
+	buf := g.device.Call("createBuffer", map[string]interface{}{
+		"size":             size,
+		"usage":            bufferUsageCopySrc,
+		"mappedAtCreation": true,
+	})
+	mapped := uint8Array.New(buf.Call("getMappedRange"))
+	for j, a := range args {
+		bytesPerRow := roundUp(4*a.Width, bytesPerRowAlignment)
+		pix := a.Pixels
+		if bytesPerRow != 4*a.Width {
+			pix = make([]byte, bytesPerRow*a.Height)
+			for k := 0; k < a.Height; k++ {
+				copy(pix[bytesPerRow*k:], a.Pixels[4*a.Width*k:4*a.Width*(k+1)])
+			}
+		}
+		js.CopyBytesToJS(mapped.Call("subarray", offsets[j], offsets[j]+len(pix)), pix)
+	}
+	buf.Call("unmap")
+
+	g.endRenderPass()
+	e := g.commandEncoder()
+	for j, a := range args {
+		e.Call("copyBufferToTexture", map[string]interface{}{
+			"buffer":      buf,
+			"offset":      offsets[j],
+			"bytesPerRow": roundUp(4*a.Width, bytesPerRowAlignment),
+		}, map[string]interface{}{
+			"texture": i.texture,
+			"origin": map[string]interface{}{
+				"x": a.X,
+				"y": a.Y,
+			},
+		}, map[string]interface{}{
+			"width":  a.Width,
+			"height": a.Height,
+		})
+	}
+	g.tmpBuffers = append(g.tmpBuffers, buf)
+}
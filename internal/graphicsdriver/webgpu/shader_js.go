@@ -0,0 +1,110 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webgpu
+
+import (
+	"fmt"
+	"strings"
+	"syscall/js"
+
+	"github.com/hajimehoshi/ebiten/v2/internal/driver"
+	"github.com/hajimehoshi/ebiten/v2/internal/shaderir"
+	"github.com/hajimehoshi/ebiten/v2/internal/shaderir/wgsl"
+	"github.com/hajimehoshi/ebiten/v2/internal/web"
+)
+
+type shaderPipelineKey struct {
+	compositeMode driver.CompositeMode
+	screen        bool
+}
+
+type Shader struct {
+	id       driver.ShaderID
+	graphics *Graphics
+
+	ir        *shaderir.Program
+	module    js.Value
+	pipelines map[shaderPipelineKey]js.Value
+}
+
+func newShader(graphics *Graphics, id driver.ShaderID, program *shaderir.Program) (*Shader, error) {
+	s := &Shader{
+		id:        id,
+		graphics:  graphics,
+		ir:        program,
+		pipelines: map[shaderPipelineKey]js.Value{},
+	}
+	if err := s.init(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Shader) ID() driver.ShaderID {
+	return s.id
+}
+
+func (s *Shader) Dispose() {
+	// Shader modules and pipelines don't have a method to destroy. They are garbage-collected.
+	s.module = js.Undefined()
+	s.pipelines = nil
+	s.graphics.removeShader(s)
+}
+
+const (
+	vertexEntryPoint   = "Vertex"
+	fragmentEntryPoint = "Fragment"
+)
+
+func (s *Shader) init() error {
+	src := wgsl.Compile(s.ir, vertexEntryPoint, fragmentEntryPoint)
+	m := s.graphics.device.Call("createShaderModule", map[string]interface{}{
+		"code": src,
+	})
+
+	// A shader module is always created even with errors. Check the compilation messages explicitly.
+	info, err := web.AwaitPromise(m.Call("getCompilationInfo"))
+	if err != nil {
+		return fmt.Errorf("webgpu: getCompilationInfo failed: %v", err)
+	}
+	var errs []string
+	msgs := info.Get("messages")
+	for i := 0; i < msgs.Length(); i++ {
+		msg := msgs.Index(i)
+		if msg.Get("type").String() != "error" {
+			continue
+		}
+		errs = append(errs, fmt.Sprintf("%d:%d: %s", msg.Get("lineNum").Int(), msg.Get("linePos").Int(), msg.Get("message").String()))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("webgpu: createShaderModule failed: %s, source: %s", strings.Join(errs, "\n"), src)
+	}
+
+	s.module = m
+	return nil
+}
+
+func (s *Shader) pipeline(mode driver.CompositeMode, screen bool) js.Value {
+	key := shaderPipelineKey{
+		compositeMode: mode,
+		screen:        screen,
+	}
+	if p, ok := s.pipelines[key]; ok {
+		return p
+	}
+	p := s.graphics.newPipeline(s.module, vertexEntryPoint, fragmentEntryPoint, s.ir.Attributes, mode, screen)
+	s.pipelines[key] = p
+	return p
+}
@@ -15,6 +15,7 @@
 package hooks
 
 import (
+	"image"
 	"sync"
 )
 
@@ -42,6 +43,100 @@ func RunBeforeUpdateHooks() error {
 	return nil
 }
 
+var onScreenHooks = []func(readScreen func() (image.Image, error)){}
+
+// AppendHookOnScreen appends a hook function that is run after the game's Draw every frame.
+// readScreen reads the pixels of the game screen. As reading pixels is slow, a hook function should call
+// readScreen only when necessary.
+func AppendHookOnScreen(f func(readScreen func() (image.Image, error))) {
+	m.Lock()
+	onScreenHooks = append(onScreenHooks, f)
+	m.Unlock()
+}
+
+func RunScreenHooks(readScreen func() (image.Image, error)) {
+	m.Lock()
+	defer m.Unlock()
+
+	for _, f := range onScreenHooks {
+		f(readScreen)
+	}
+}
+
+// HasScreenHooks reports whether any screen hook functions exist.
+func HasScreenHooks() bool {
+	m.Lock()
+	defer m.Unlock()
+	return len(onScreenHooks) > 0
+}
+
+var onLowMemoryHooks = []func() error{}
+
+// AppendHookOnLowMemory appends a hook function that is run when the system is low on memory.
+// A hook function should release caches that can be recreated later.
+func AppendHookOnLowMemory(f func() error) {
+	m.Lock()
+	onLowMemoryHooks = append(onLowMemoryHooks, f)
+	m.Unlock()
+}
+
+func RunLowMemoryHooks() error {
+	m.Lock()
+	defer m.Unlock()
+
+	for _, f := range onLowMemoryHooks {
+		if err := f(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var onResetHooks = []func() error{}
+
+// AppendHookOnReset appends a hook function that is run when the game is restarted by ebiten.RestartGame.
+// A hook function should release the resources and the caches related to the previous game.
+func AppendHookOnReset(f func() error) {
+	m.Lock()
+	onResetHooks = append(onResetHooks, f)
+	m.Unlock()
+}
+
+func RunResetHooks() error {
+	m.Lock()
+	defer m.Unlock()
+
+	for _, f := range onResetHooks {
+		if err := f(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var onDeviceLostHooks = []func() error{}
+
+// AppendHookOnDeviceLost appends a hook function that is run when the graphics device was lost and the contents
+// of the images were not restored.
+// A hook function should recreate the images it manages.
+func AppendHookOnDeviceLost(f func() error) {
+	m.Lock()
+	onDeviceLostHooks = append(onDeviceLostHooks, f)
+	m.Unlock()
+}
+
+func RunDeviceLostHooks() error {
+	m.Lock()
+	defer m.Unlock()
+
+	for _, f := range onDeviceLostHooks {
+		if err := f(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 var (
 	audioSuspended bool
 	onSuspendAudio func() error
@@ -0,0 +1,29 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package hotswap provides functions to replace the contents of the existing handles of the ebiten package, for
+// packages that can't access the ebiten package's internals.
+package hotswap
+
+// Image replaces the content of dst with src's. Both dst and src must be *ebiten.Image.
+// src is no longer available after Image is called.
+//
+// Image is set by the ebiten package.
+var Image func(dst, src interface{})
+
+// Shader replaces the content of dst with src's. Both dst and src must be *ebiten.Shader.
+// src is no longer available after Shader is called.
+//
+// Shader is set by the ebiten package.
+var Shader func(dst, src interface{})
@@ -35,15 +35,24 @@ func EndFrame() error {
 
 // Mipmap is a set of buffered.Image sorted by the order of mipmap level.
 // The level 0 image is a regular image and higher-level images are used for mipmap.
+//
+// An image larger than the maximum texture size is split into tiles. Such an image doesn't have mipmaps.
 type Mipmap struct {
 	width    int
 	height   int
 	volatile bool
 	orig     *buffered.Image
 	imgs     map[int]*buffered.Image
+
+	// tiles is the images split from a large image. If tiles is not nil, orig is nil.
+	tiles    []*buffered.Image
+	tileSize int
 }
 
 func New(width, height int) *Mipmap {
+	if s, ok := tileSize(width, height); ok {
+		return newTiled(width, height, s)
+	}
 	return &Mipmap{
 		width:  width,
 		height: height,
@@ -66,14 +75,88 @@ func (m *Mipmap) SetVolatile(volatile bool) {
 	if m.volatile {
 		m.disposeMipmaps()
 	}
+	if m.isTiled() {
+		for _, t := range m.tiles {
+			t.SetVolatile(volatile)
+		}
+		return
+	}
 	m.orig.SetVolatile(volatile)
 }
 
+func (m *Mipmap) SetAtlasGroup(group int) error {
+	if m.isTiled() {
+		// Tiles are too big to be on an atlas.
+		return nil
+	}
+	return m.orig.SetAtlasGroup(group)
+}
+
+// SetCold sets whether the image is cold. See buffered.Image.SetCold.
+func (m *Mipmap) SetCold(cold bool) error {
+	if cold {
+		m.disposeMipmaps()
+	}
+	for _, img := range m.images() {
+		if err := img.SetCold(cold); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Evict releases the GPU textures of the image. The textures are recreated when the image is used next time.
+func (m *Mipmap) Evict() error {
+	m.disposeMipmaps()
+	for _, img := range m.images() {
+		if err := img.Evict(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Prefetch recreates the GPU textures of the image if the image is evicted.
+func (m *Mipmap) Prefetch() {
+	for _, img := range m.images() {
+		img.Prefetch()
+	}
+}
+
+// RecoverContents restores the pixels of the image after the contents of the textures are lost.
+// See buffered.Image.RecoverContents.
+func (m *Mipmap) RecoverContents() bool {
+	// The mipmap images are recreated from the level-0 images when needed.
+	m.disposeMipmaps()
+
+	recovered := true
+	for _, img := range m.images() {
+		if !img.RecoverContents() {
+			recovered = false
+		}
+	}
+	return recovered
+}
+
+// images returns the level-0 images, i.e. the original image or the tiles.
+func (m *Mipmap) images() []*buffered.Image {
+	if m.isTiled() {
+		return m.tiles
+	}
+	return []*buffered.Image{m.orig}
+}
+
 func (m *Mipmap) Dump(name string, blackbg bool) error {
+	if m.isTiled() {
+		return m.dumpTiles(name, blackbg)
+	}
 	return m.orig.Dump(name, blackbg)
 }
 
 func (m *Mipmap) ReplacePixels(pix []byte, x, y, width, height int) error {
+	if m.isTiled() {
+		return m.replacePixelsOnTiles(pix, x, y, width, height)
+	}
 	if err := m.orig.ReplacePixels(pix, x, y, width, height); err != nil {
 		return err
 	}
@@ -82,6 +165,9 @@ func (m *Mipmap) ReplacePixels(pix []byte, x, y, width, height int) error {
 }
 
 func (m *Mipmap) Pixels(x, y, width, height int) ([]byte, error) {
+	if m.isTiled() {
+		return m.pixelsOnTiles(x, y, width, height)
+	}
 	return m.orig.Pixels(x, y, width, height)
 }
 
@@ -90,6 +176,16 @@ func (m *Mipmap) DrawTriangles(srcs [graphics.ShaderImageNum]*Mipmap, vertices [
 		return
 	}
 
+	if m.isTiled() || hasTiledImage(srcs) {
+		var s *buffered.Shader
+		if shader != nil {
+			s = shader.shader
+		}
+		m.drawTrianglesTiled(srcs, vertices, indices, colorm, mode, filter, address, dstRegion, srcRegion, subimageOffsets, s, uniforms)
+		m.disposeMipmaps()
+		return
+	}
+
 	level := 0
 	// TODO: Do we need to check all the sources' states of being volatile?
 	if !canSkipMipmap && srcs[0] != nil && !srcs[0].volatile && filter != driver.FilterScreen {
@@ -244,6 +340,13 @@ func sizeForLevel(x int, level int) int {
 
 func (m *Mipmap) MarkDisposed() {
 	m.disposeMipmaps()
+	if m.isTiled() {
+		for _, t := range m.tiles {
+			t.MarkDisposed()
+		}
+		m.tiles = nil
+		return
+	}
 	m.orig.MarkDisposed()
 	m.orig = nil
 }
@@ -0,0 +1,399 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mipmap
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2/internal/affine"
+	"github.com/hajimehoshi/ebiten/v2/internal/buffered"
+	"github.com/hajimehoshi/ebiten/v2/internal/driver"
+	"github.com/hajimehoshi/ebiten/v2/internal/graphics"
+)
+
+// tileSize returns the size of tiles, and reports whether an image with the given size needs to be split into
+// tiles.
+func tileSize(width, height int) (int, bool) {
+	if s, ok := buffered.MaxImageSize(); ok {
+		return s, width > s || height > s
+	}
+
+	// The graphics driver is not initialized yet and the precise maximum size is unknown.
+	// 4096 should be a safe size in most environments (#1399). An image up to 4096 is not split as it might work
+	// with the actual graphics driver. Leave room for the paddings of the internal images in a tile.
+	const safeSize = 4096
+	return safeSize - 16, width > safeSize || height > safeSize
+}
+
+func newTiled(width, height, tileSize int) *Mipmap {
+	m := &Mipmap{
+		width:    width,
+		height:   height,
+		imgs:     map[int]*buffered.Image{},
+		tileSize: tileSize,
+	}
+	for j := 0; j < m.tileYNum(); j++ {
+		for i := 0; i < m.tileXNum(); i++ {
+			_, _, w, h := m.tileRect(j*m.tileXNum() + i)
+			m.tiles = append(m.tiles, buffered.NewImage(w, h))
+		}
+	}
+	return m
+}
+
+func (m *Mipmap) isTiled() bool {
+	return m.tiles != nil
+}
+
+func hasTiledImage(imgs [graphics.ShaderImageNum]*Mipmap) bool {
+	for _, img := range imgs {
+		if img != nil && img.isTiled() {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Mipmap) tileXNum() int {
+	return (m.width + m.tileSize - 1) / m.tileSize
+}
+
+func (m *Mipmap) tileYNum() int {
+	return (m.height + m.tileSize - 1) / m.tileSize
+}
+
+// tileRect returns the region of the tile at the given index on the whole image.
+func (m *Mipmap) tileRect(index int) (x, y, width, height int) {
+	x = (index % m.tileXNum()) * m.tileSize
+	y = (index / m.tileXNum()) * m.tileSize
+	width = m.tileSize
+	if x+width > m.width {
+		width = m.width - x
+	}
+	height = m.tileSize
+	if y+height > m.height {
+		height = m.height - y
+	}
+	return
+}
+
+func (m *Mipmap) dumpTiles(name string, blackbg bool) error {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i, t := range m.tiles {
+		if err := t.Dump(fmt.Sprintf("%s_%d_%d%s", base, i%m.tileXNum(), i/m.tileXNum(), ext), blackbg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Mipmap) replacePixelsOnTiles(pix []byte, x, y, width, height int) error {
+	for i, t := range m.tiles {
+		tx, ty, tw, th := m.tileRect(i)
+		x0, y0, x1, y1, ok := intersect(x, y, x+width, y+height, tx, ty, tx+tw, ty+th)
+		if !ok {
+			continue
+		}
+		w, h := x1-x0, y1-y0
+		p := make([]byte, 4*w*h)
+		for j := 0; j < h; j++ {
+			copy(p[4*j*w:4*(j+1)*w], pix[4*((y0-y+j)*width+(x0-x)):])
+		}
+		if err := t.ReplacePixels(p, x0-tx, y0-ty, w, h); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Mipmap) pixelsOnTiles(x, y, width, height int) ([]byte, error) {
+	pix := make([]byte, 4*width*height)
+	for i, t := range m.tiles {
+		tx, ty, tw, th := m.tileRect(i)
+		x0, y0, x1, y1, ok := intersect(x, y, x+width, y+height, tx, ty, tx+tw, ty+th)
+		if !ok {
+			continue
+		}
+		w, h := x1-x0, y1-y0
+		p, err := t.Pixels(x0-tx, y0-ty, w, h)
+		if err != nil {
+			return nil, err
+		}
+		for j := 0; j < h; j++ {
+			copy(pix[4*((y0-y+j)*width+(x0-x)):], p[4*j*w:4*(j+1)*w])
+		}
+	}
+	return pix, nil
+}
+
+func intersect(ax0, ay0, ax1, ay1, bx0, by0, bx1, by1 int) (x0, y0, x1, y1 int, ok bool) {
+	x0, y0, x1, y1 = ax0, ay0, ax1, ay1
+	if x0 < bx0 {
+		x0 = bx0
+	}
+	if y0 < by0 {
+		y0 = by0
+	}
+	if x1 > bx1 {
+		x1 = bx1
+	}
+	if y1 > by1 {
+		y1 = by1
+	}
+	return x0, y0, x1, y1, x0 < x1 && y0 < y1
+}
+
+// intersectRegion returns the intersection of the region and the rectangle, translated by (-x0, -y0).
+func intersectRegion(r driver.Region, x0, y0, x1, y1 float32) (driver.Region, bool) {
+	rx0, ry0, rx1, ry1 := r.X, r.Y, r.X+r.Width, r.Y+r.Height
+	if rx0 < x0 {
+		rx0 = x0
+	}
+	if ry0 < y0 {
+		ry0 = y0
+	}
+	if rx1 > x1 {
+		rx1 = x1
+	}
+	if ry1 > y1 {
+		ry1 = y1
+	}
+	if rx0 >= rx1 || ry0 >= ry1 {
+		return driver.Region{}, false
+	}
+	return driver.Region{
+		X:      rx0 - x0,
+		Y:      ry0 - y0,
+		Width:  rx1 - rx0,
+		Height: ry1 - ry0,
+	}, true
+}
+
+// drawTrianglesTiled draws triangles when the destination or one of the sources is split into tiles.
+//
+// The triangles are clipped by each tile of the first source image, and then drawn on each tile of the
+// destination.
+func (m *Mipmap) drawTrianglesTiled(srcs [graphics.ShaderImageNum]*Mipmap, vertices []float32, indices []uint16, colorm *affine.ColorM, mode driver.CompositeMode, filter driver.Filter, address driver.Address, dstRegion, srcRegion driver.Region, subimageOffsets [graphics.ShaderImageNum - 1][2]float32, shader *buffered.Shader, uniforms []interface{}) {
+	src0 := srcs[0]
+	if src0 == nil || !src0.isTiled() {
+		var imgs [graphics.ShaderImageNum]*buffered.Image
+		for i, src := range srcs {
+			if src == nil {
+				continue
+			}
+			if src.isTiled() {
+				panic("mipmap: only the first source image can be an image split into tiles")
+			}
+			imgs[i] = src.orig
+		}
+		m.drawTrianglesOnTiles(imgs, vertices, indices, colorm, mode, filter, address, dstRegion, srcRegion, subimageOffsets, shader, uniforms)
+		return
+	}
+
+	if address == driver.AddressRepeat {
+		panic("mipmap: AddressRepeat is not available with an image split into tiles as a source")
+	}
+
+	for idx, tile := range src0.tiles {
+		tx, ty, tw, th := src0.tileRect(idx)
+		x0, y0, x1, y1 := float32(tx), float32(ty), float32(tx+tw), float32(ty+th)
+
+		sr := srcRegion
+		if sr.Width > 0 && sr.Height > 0 {
+			var ok bool
+			sr, ok = intersectRegion(sr, x0, y0, x1, y1)
+			if !ok {
+				continue
+			}
+		}
+
+		var imgs [graphics.ShaderImageNum]*buffered.Image
+		imgs[0] = tile
+		offsets := subimageOffsets
+		for i, src := range srcs[1:] {
+			if src == nil {
+				continue
+			}
+			if src.isTiled() {
+				if src.width != src0.width || src.height != src0.height || src.tileSize != src0.tileSize || offsets[i] != [2]float32{} {
+					panic("mipmap: the source images split into tiles must have the same size and the same position")
+				}
+				imgs[i+1] = src.tiles[idx]
+				continue
+			}
+			imgs[i+1] = src.orig
+			offsets[i][0] += x0
+			offsets[i][1] += y0
+		}
+
+		clipTriangles(vertices, indices, x0, y0, x1, y1, func(vs []float32, is []uint16) {
+			// Convert the source coordinates to the tile's.
+			const n = graphics.VertexFloatNum
+			for i := 0; i < len(vs)/n; i++ {
+				vs[i*n+2] -= x0
+				vs[i*n+3] -= y0
+			}
+			m.drawTrianglesOnTiles(imgs, vs, is, colorm, mode, filter, address, dstRegion, sr, offsets, shader, uniforms)
+		})
+	}
+}
+
+// drawTrianglesOnTiles draws triangles on the destination, which might be split into tiles.
+func (m *Mipmap) drawTrianglesOnTiles(srcs [graphics.ShaderImageNum]*buffered.Image, vertices []float32, indices []uint16, colorm *affine.ColorM, mode driver.CompositeMode, filter driver.Filter, address driver.Address, dstRegion, srcRegion driver.Region, subimageOffsets [graphics.ShaderImageNum - 1][2]float32, shader *buffered.Shader, uniforms []interface{}) {
+	if !m.isTiled() {
+		m.orig.DrawTriangles(srcs, vertices, indices, colorm, mode, filter, address, dstRegion, srcRegion, subimageOffsets, shader, uniforms)
+		return
+	}
+
+	const n = graphics.VertexFloatNum
+	minX, minY, maxX, maxY := vertices[n*indices[0]], vertices[n*indices[0]+1], vertices[n*indices[0]], vertices[n*indices[0]+1]
+	for _, idx := range indices {
+		x, y := vertices[n*idx], vertices[n*idx+1]
+		if minX > x {
+			minX = x
+		}
+		if minY > y {
+			minY = y
+		}
+		if maxX < x {
+			maxX = x
+		}
+		if maxY < y {
+			maxY = y
+		}
+	}
+
+	for idx, tile := range m.tiles {
+		tx, ty, tw, th := m.tileRect(idx)
+		x0, y0, x1, y1 := float32(tx), float32(ty), float32(tx+tw), float32(ty+th)
+		if maxX <= x0 || x1 <= minX || maxY <= y0 || y1 <= minY {
+			continue
+		}
+		dr, ok := intersectRegion(dstRegion, x0, y0, x1, y1)
+		if !ok {
+			continue
+		}
+
+		// The vertices can be modified by the callee. Copy them for each tile.
+		vs := graphics.Vertices(len(vertices) / n)
+		copy(vs, vertices)
+		for i := 0; i < len(vs)/n; i++ {
+			vs[i*n] -= x0
+			vs[i*n+1] -= y0
+		}
+		tile.DrawTriangles(srcs, vs, indices, colorm, mode, filter, address, dr, srcRegion, subimageOffsets, shader, uniforms)
+	}
+}
+
+type vertex [graphics.VertexFloatNum]float32
+
+// clipTriangles clips the triangles by the rectangle in the source coordinates, and calls f with the clipped
+// triangles. f can be called multiple times not to exceed the limit of the number of indices.
+func clipTriangles(vertices []float32, indices []uint16, x0, y0, x1, y1 float32, f func(vertices []float32, indices []uint16)) {
+	const n = graphics.VertexFloatNum
+
+	var vs []float32
+	var is []uint16
+	flush := func() {
+		if len(is) > 0 {
+			f(vs, is)
+		}
+		vs = nil
+		is = nil
+	}
+
+	var poly, tmp []vertex
+	for t := 0; t < len(indices)/3; t++ {
+		poly = poly[:0]
+		minX, minY := float32(0), float32(0)
+		maxX, maxY := float32(0), float32(0)
+		for k := 0; k < 3; k++ {
+			var v vertex
+			copy(v[:], vertices[n*int(indices[3*t+k]):n*int(indices[3*t+k]+1)])
+			poly = append(poly, v)
+			if k == 0 || minX > v[2] {
+				minX = v[2]
+			}
+			if k == 0 || minY > v[3] {
+				minY = v[3]
+			}
+			if k == 0 || maxX < v[2] {
+				maxX = v[2]
+			}
+			if k == 0 || maxY < v[3] {
+				maxY = v[3]
+			}
+		}
+
+		if maxX <= x0 || x1 <= minX || maxY <= y0 || y1 <= minY {
+			continue
+		}
+		if !(x0 <= minX && maxX <= x1 && y0 <= minY && maxY <= y1) {
+			poly, tmp = clipPolygon(poly, tmp, 2, x0, true), poly
+			poly, tmp = clipPolygon(poly, tmp, 2, x1, false), poly
+			poly, tmp = clipPolygon(poly, tmp, 3, y0, true), poly
+			poly, tmp = clipPolygon(poly, tmp, 3, y1, false), poly
+			if len(poly) < 3 {
+				continue
+			}
+		}
+
+		// A clipped polygon has 7 vertices at most.
+		if len(vs)/n+len(poly) > 1<<16 || len(is)+3*(len(poly)-2) > graphics.IndicesNum {
+			flush()
+		}
+		base := uint16(len(vs) / n)
+		for _, v := range poly {
+			vs = append(vs, v[:]...)
+		}
+		for k := 1; k < len(poly)-1; k++ {
+			is = append(is, base, base+uint16(k), base+uint16(k)+1)
+		}
+	}
+	flush()
+}
+
+// clipPolygon clips the convex polygon by the line where the value of the component comp is bound.
+// If greater is true, the part where the value is greater than or equal to bound is kept.
+func clipPolygon(poly []vertex, out []vertex, comp int, bound float32, greater bool) []vertex {
+	inside := func(v *vertex) bool {
+		if greater {
+			return v[comp] >= bound
+		}
+		return v[comp] <= bound
+	}
+
+	out = out[:0]
+	for i := range poly {
+		cur := &poly[i]
+		prev := &poly[(i+len(poly)-1)%len(poly)]
+		curIn, prevIn := inside(cur), inside(prev)
+		if curIn != prevIn {
+			var v vertex
+			t := (bound - prev[comp]) / (cur[comp] - prev[comp])
+			for k := range v {
+				v[k] = prev[k] + (cur[k]-prev[k])*t
+			}
+			out = append(out, v)
+		}
+		if curIn {
+			out = append(out, *cur)
+		}
+	}
+	return out
+}
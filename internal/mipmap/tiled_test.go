@@ -0,0 +1,84 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mipmap
+
+import (
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2/internal/graphics"
+)
+
+func TestClipTriangles(t *testing.T) {
+	// A quad from (0, 0) to (10, 10) both in the destination and the source coordinates.
+	vs := graphics.QuadVertices(0, 0, 10, 10, 1, 0, 0, 1, 0, 0, 1, 1, 1, 1)
+	is := graphics.QuadIndices()
+
+	cases := []struct {
+		Name           string
+		X0, Y0, X1, Y1 float32
+		Area           float32
+	}{
+		{
+			Name: "inside",
+			X0:   -1, Y0: -1, X1: 11, Y1: 11,
+			Area: 100,
+		},
+		{
+			Name: "outside",
+			X0:   10, Y0: 0, X1: 20, Y1: 10,
+			Area: 0,
+		},
+		{
+			Name: "half",
+			X0:   0, Y0: 0, X1: 5, Y1: 10,
+			Area: 50,
+		},
+		{
+			Name: "quarter",
+			X0:   5, Y0: 5, X1: 20, Y1: 20,
+			Area: 25,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			var area float32
+			clipTriangles(vs, is, c.X0, c.Y0, c.X1, c.Y1, func(vs []float32, is []uint16) {
+				const n = graphics.VertexFloatNum
+				for i := 0; i < len(is)/3; i++ {
+					v0 := vs[n*int(is[3*i]):]
+					v1 := vs[n*int(is[3*i+1]):]
+					v2 := vs[n*int(is[3*i+2]):]
+					for _, v := range [][]float32{v0, v1, v2} {
+						// The destination and the source coordinates must be interpolated in the same way.
+						if v[0] != v[2] || v[1] != v[3] {
+							t.Errorf("vertex: got: %v, want: the same destination and source positions", v[:4])
+						}
+						if v[2] < c.X0 || c.X1 < v[2] || v[3] < c.Y0 || c.Y1 < v[3] {
+							t.Errorf("vertex: %v is out of the rectangle", v[:4])
+						}
+					}
+					a := ((v1[2]-v0[2])*(v2[3]-v0[3]) - (v2[2]-v0[2])*(v1[3]-v0[3])) / 2
+					if a < 0 {
+						a = -a
+					}
+					area += a
+				}
+			})
+			if got, want := area, c.Area; got != want {
+				t.Errorf("area: got: %f, want: %f", got, want)
+			}
+		})
+	}
+}
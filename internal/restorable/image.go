@@ -623,6 +623,28 @@ func (i *Image) restore() error {
 	return nil
 }
 
+// recreate recreates the texture of the image without restoring its content.
+//
+// The recreated image is cleared, except for emptyImage.
+func (i *Image) recreate() {
+	w, h := i.width, i.height
+	if i.screen {
+		i.image = graphicscommand.NewScreenFramebufferImage(w, h)
+		return
+	}
+
+	i.image = graphicscommand.NewImage(w, h)
+	if i == emptyImage {
+		pix := make([]byte, 4*w*h)
+		for j := range pix {
+			pix[j] = 0xff
+		}
+		i.image.ReplacePixels(pix, 0, 0, w, h)
+		return
+	}
+	clearImage(i.image)
+}
+
 // Dispose disposes the image.
 //
 // After disposing, calling the function of the image causes unexpected results.
@@ -43,6 +43,11 @@ type images struct {
 	shaders     map[*Shader]struct{}
 	lastTarget  *Image
 	contextLost bool
+
+	// deviceLost and contentsLost are the states of the last recovery from the context lost.
+	// See TakeDeviceLost.
+	deviceLost   bool
+	contentsLost bool
 }
 
 // theImages represents the images for the current process.
@@ -70,6 +75,21 @@ func ResolveStaleImages() error {
 // Restoring means to make all *graphicscommand.Image objects have their textures and framebuffers.
 func RestoreIfNeeded() error {
 	if !NeedsRestoring() {
+		// Even when restoring is disabled, the textures must be recreated after the context lost, or drawing
+		// to them causes undefined behaviors. Their contents are lost.
+		if !canDetectContextLostExplicitly || !theImages.contextLost {
+			return nil
+		}
+		err := graphicscommand.ResetGraphicsDriverState()
+		if err == driver.GraphicsNotReady {
+			return nil
+		}
+		if err != nil {
+			return &driver.DeviceLostError{Err: err}
+		}
+		theImages.recreate()
+		theImages.deviceLost = true
+		theImages.contentsLost = true
 		return nil
 	}
 
@@ -105,9 +125,24 @@ func RestoreIfNeeded() error {
 		return nil
 	}
 	if err != nil {
-		return err
+		return &driver.DeviceLostError{Err: err}
+	}
+	if err := theImages.restore(); err != nil {
+		return &driver.DeviceLostError{Err: err}
+	}
+	if !forceRestoring {
+		theImages.deviceLost = true
 	}
-	return theImages.restore()
+	return nil
+}
+
+// TakeDeviceLost reports whether the textures were recreated due to the context lost after the last call.
+// contentsLost reports whether the contents of the images were not restored.
+func TakeDeviceLost() (lost bool, contentsLost bool) {
+	lost, contentsLost = theImages.deviceLost, theImages.contentsLost
+	theImages.deviceLost = false
+	theImages.contentsLost = false
+	return lost, contentsLost
 }
 
 // DumpImages dumps all the current images to the specified directory.
@@ -270,6 +305,39 @@ func (i *images) restore() error {
 	return nil
 }
 
+// recreate recreates the textures of all the images without their contents.
+//
+// recreate is called when the context is lost and restoring is disabled.
+func (i *images) recreate() {
+	for s := range i.shaders {
+		if needsDisposingWhenRestoring {
+			s.shader.Dispose()
+		}
+		s.shader = nil
+	}
+	for s := range i.shaders {
+		s.restore()
+	}
+
+	for img := range i.images {
+		if needsDisposingWhenRestoring {
+			img.image.Dispose()
+		}
+		img.image = nil
+	}
+
+	// As clearImage uses emptyImage, emptyImage must be recreated first.
+	emptyImage.recreate()
+	for img := range i.images {
+		if img == emptyImage {
+			continue
+		}
+		img.recreate()
+	}
+
+	i.contextLost = false
+}
+
 // InitializeGraphicsDriverState initializes the graphics driver state.
 func InitializeGraphicsDriverState() error {
 	return graphicscommand.ResetGraphicsDriverState()
@@ -27,6 +27,7 @@ import (
 	. "github.com/hajimehoshi/ebiten/v2/internal/shader"
 	"github.com/hajimehoshi/ebiten/v2/internal/shaderir/glsl"
 	"github.com/hajimehoshi/ebiten/v2/internal/shaderir/metal"
+	"github.com/hajimehoshi/ebiten/v2/internal/shaderir/wgsl"
 )
 
 func glslNormalize(str string) string {
@@ -44,6 +45,13 @@ func metalNormalize(str string) string {
 	return strings.TrimSpace(str)
 }
 
+func wgslNormalize(str string) string {
+	if strings.HasPrefix(str, wgsl.Prelude) {
+		str = str[len(wgsl.Prelude):]
+	}
+	return strings.TrimSpace(str)
+}
+
 func compare(t *testing.T, title, got, want string) {
 	var msg string
 	gotlines := strings.Split(got, "\n")
@@ -81,6 +89,7 @@ func TestCompile(t *testing.T) {
 		VS    []byte
 		FS    []byte
 		Metal []byte
+		WGSL  []byte
 	}
 
 	fnames := map[string]struct{}{}
@@ -139,6 +148,15 @@ func TestCompile(t *testing.T) {
 			tc.Metal = metal
 		}
 
+		wgsln := name + ".expected.wgsl"
+		if _, ok := fnames[wgsln]; ok {
+			wgsl, err := ioutil.ReadFile(filepath.Join("testdata", wgsln))
+			if err != nil {
+				t.Fatal(err)
+			}
+			tc.WGSL = wgsl
+		}
+
 		tests = append(tests, tc)
 	}
 
@@ -174,9 +192,17 @@ func TestCompile(t *testing.T) {
 				}
 			}
 
+			if tc.WGSL != nil {
+				w := wgsl.Compile(s, "Vertex", "Fragment")
+				if got, want := wgslNormalize(w), wgslNormalize(string(tc.WGSL)); got != want {
+					compare(t, "WGSL", got, want)
+				}
+			}
+
 			// Just check that Compile doesn't cause panic.
 			// TODO: Should the results be tested?
 			metal.Compile(s, "Vertex", "Fragmentp")
+			wgsl.Compile(s, "Vertex", "Fragment")
 		})
 	}
 }
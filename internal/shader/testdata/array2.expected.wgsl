@@ -0,0 +1,12 @@
+@group(0) @binding(1) var texture_sampler: sampler;
+
+fn F0(l0: ptr<function, array<vec2<f32>, 3>>) {
+	var l1: array<vec2<f32>, 2>;
+	var l2: array<vec2<f32>, 3>;
+	{
+		var l2: array<vec2<f32>, 2>;
+		l2 = l1;
+	}
+	(*l0) = l2;
+	return;
+}
@@ -0,0 +1,10 @@
+@group(0) @binding(1) var texture_sampler: sampler;
+
+fn F0(l0: f32, l1: f32, l2: ptr<function, bool>) {
+	var l3: f32;
+	var l4: f32;
+	l3 = atan((l1) / (l0));
+	l4 = atan2(l1, l0);
+	(*l2) = (l3) == (l4);
+	return;
+}
@@ -0,0 +1,75 @@
+@group(0) @binding(1) var texture_sampler: sampler;
+
+struct Uniforms {
+	U0: f32,
+	U1: f32,
+	U2: f32,
+}
+
+@group(0) @binding(0) var<storage, read> uniforms: Uniforms;
+
+struct Attributes {
+	@location(0) M0: vec2<f32>,
+}
+
+struct Varyings {
+	@builtin(position) Position: vec4<f32>,
+}
+
+fn F0(l0: i32, l1: ptr<function, i32>) {
+	(*l1) = l0;
+	return;
+}
+
+@vertex
+fn Vertex(attributes: Attributes) -> Varyings {
+	var varyings: Varyings;
+	var l0: i32;
+	var l2: i32;
+	l0 = 0;
+	for (var l1: i32 = 0; l1 < 10; l1++) {
+		var l2: i32;
+		var l3: i32;
+		F0(l1, &l2);
+		l3 = l2;
+		l0 = (l0) + (l3);
+		for (var l4: i32 = 0; l4 < 10; l4++) {
+			var l5: i32;
+			var l6: i32;
+			F0(l4, &l5);
+			l6 = l5;
+			l0 = (l0) + (l6);
+		}
+	}
+	l2 = 0;
+	l0 = (l0) + (l2);
+	varyings.Position = vec4<f32>(f32(l0));
+	varyings.Position.y = -varyings.Position.y;
+	return varyings;
+}
+
+@fragment
+fn Fragment(varyings: Varyings) -> @location(0) vec4<f32> {
+	var outColor: vec4<f32>;
+	var l0: i32;
+	var l2: i32;
+	l0 = 0;
+	for (var l1: i32 = 0; l1 < 10; l1++) {
+		var l2: i32;
+		var l3: i32;
+		F0(l1, &l2);
+		l3 = l2;
+		l0 = (l0) + (l3);
+		for (var l4: i32 = 0; l4 < 10; l4++) {
+			var l5: i32;
+			var l6: i32;
+			F0(l4, &l5);
+			l6 = l5;
+			l0 = (l0) + (l6);
+		}
+	}
+	l2 = 0;
+	l0 = (l0) + (l2);
+	outColor = vec4<f32>(f32(l0));
+	return outColor;
+}
@@ -0,0 +1,32 @@
+@group(0) @binding(1) var texture_sampler: sampler;
+
+struct Attributes {
+	@location(0) M0: vec2<f32>,
+}
+
+struct Varyings {
+	@builtin(position) Position: vec4<f32>,
+}
+
+@vertex
+fn Vertex(attributes: Attributes) -> Varyings {
+	var varyings: Varyings;
+	var l0: vec4<f32>;
+	for (var l1: f32 = 0.0; l1 < 4.0; l1 += 1.0) {
+		(l0).x = ((l0).x) + ((l1) * (1.0000000000e-02));
+	}
+	varyings.Position = l0;
+	varyings.Position.y = -varyings.Position.y;
+	return varyings;
+}
+
+@fragment
+fn Fragment(varyings: Varyings) -> @location(0) vec4<f32> {
+	var outColor: vec4<f32>;
+	var l0: vec4<f32>;
+	for (var l1: f32 = 0.0; l1 < 4.0; l1 += 1.0) {
+		(l0).x = ((l0).x) + ((l1) * (1.0000000000e-02));
+	}
+	outColor = l0;
+	return outColor;
+}
@@ -0,0 +1,38 @@
+@group(0) @binding(1) var texture_sampler: sampler;
+
+struct Uniforms {
+	U0: vec2<f32>,
+}
+
+@group(0) @binding(0) var<storage, read> uniforms: Uniforms;
+
+struct Attributes {
+	@location(0) M0: vec2<f32>,
+	@location(1) M1: vec2<f32>,
+	@location(2) M2: vec4<f32>,
+}
+
+struct Varyings {
+	@builtin(position) Position: vec4<f32>,
+	@location(0) M0: vec2<f32>,
+	@location(1) M1: vec4<f32>,
+}
+
+@vertex
+fn Vertex(attributes: Attributes) -> Varyings {
+	var varyings: Varyings;
+	var l0: mat4x4<f32>;
+	l0 = mat4x4<f32>((2.0) / ((uniforms.U0).x), 0.0, 0.0, 0.0, 0.0, (2.0) / ((uniforms.U0).y), 0.0, 0.0, 0.0, 0.0, 1.0, 0.0, -1.0, -1.0, 0.0, 1.0);
+	varyings.Position = (l0) * (vec4<f32>(attributes.M0, 0.0, 1.0));
+	varyings.M0 = attributes.M1;
+	varyings.M1 = attributes.M2;
+	varyings.Position.y = -varyings.Position.y;
+	return varyings;
+}
+
+@fragment
+fn Fragment(varyings: Varyings) -> @location(0) vec4<f32> {
+	var outColor: vec4<f32>;
+	outColor = vec4<f32>((varyings.Position).x, (varyings.M0).y, (varyings.M1).z, 1.0);
+	return outColor;
+}
@@ -21,6 +21,7 @@ import (
 	. "github.com/hajimehoshi/ebiten/v2/internal/shaderir"
 	"github.com/hajimehoshi/ebiten/v2/internal/shaderir/glsl"
 	"github.com/hajimehoshi/ebiten/v2/internal/shaderir/metal"
+	"github.com/hajimehoshi/ebiten/v2/internal/shaderir/wgsl"
 )
 
 func block(localVars []Type, offset int, stmts ...Stmt) *Block {
@@ -1042,6 +1043,9 @@ void main(void) {
 					t.Errorf("%s metal: got: %s, want: %s", tc.Name, got, want)
 				}
 			}
+
+			// Just check that Compile doesn't cause panic.
+			wgsl.Compile(&tc.Program, "Vertex", "Fragment")
 		})
 	}
 }
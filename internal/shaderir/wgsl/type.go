@@ -0,0 +1,153 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wgsl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2/internal/shaderir"
+)
+
+func typeString(t *shaderir.Type) string {
+	switch t.Main {
+	case shaderir.Array:
+		st := typeString(&t.Sub[0])
+		return fmt.Sprintf("array<%s, %d>", st, t.Length)
+	case shaderir.Struct:
+		panic("wgsl: a struct is not implemented")
+	default:
+		return basicTypeString(t.Main)
+	}
+}
+
+func basicTypeString(t shaderir.BasicType) string {
+	switch t {
+	case shaderir.None:
+		return "?(none)"
+	case shaderir.Bool:
+		return "bool"
+	case shaderir.Int:
+		return "i32"
+	case shaderir.Float:
+		return "f32"
+	case shaderir.Vec2:
+		return "vec2<f32>"
+	case shaderir.Vec3:
+		return "vec3<f32>"
+	case shaderir.Vec4:
+		return "vec4<f32>"
+	case shaderir.Mat2:
+		return "mat2x2<f32>"
+	case shaderir.Mat3:
+		return "mat3x3<f32>"
+	case shaderir.Mat4:
+		return "mat4x4<f32>"
+	case shaderir.Array:
+		return "?(array)"
+	case shaderir.Struct:
+		return "?(struct)"
+	default:
+		return fmt.Sprintf("?(unknown type: %d)", t)
+	}
+}
+
+func builtinFuncString(f shaderir.BuiltinFunc) string {
+	switch f {
+	case shaderir.BoolF:
+		return "bool"
+	case shaderir.IntF:
+		return "i32"
+	case shaderir.FloatF:
+		return "f32"
+	case shaderir.Vec2F:
+		return "vec2<f32>"
+	case shaderir.Vec3F:
+		return "vec3<f32>"
+	case shaderir.Vec4F:
+		return "vec4<f32>"
+	case shaderir.Mat2F:
+		return "mat2x2<f32>"
+	case shaderir.Mat3F:
+		return "mat3x3<f32>"
+	case shaderir.Mat4F:
+		return "mat4x4<f32>"
+	case shaderir.Inversesqrt:
+		return "inverseSqrt"
+	case shaderir.Faceforward:
+		return "faceForward"
+	case shaderir.Dfdx:
+		return "dpdx"
+	case shaderir.Dfdy:
+		return "dpdy"
+	case shaderir.Mod:
+		return "?(mod)"
+	case shaderir.Texture2DF:
+		return "?(texture2D)"
+	}
+	return string(f)
+}
+
+// vectorSize returns the number of the components of a vector type, or 0 if t is not a vector type.
+func vectorSize(t shaderir.BasicType) int {
+	switch t {
+	case shaderir.Vec2:
+		return 2
+	case shaderir.Vec3:
+		return 3
+	case shaderir.Vec4:
+		return 4
+	}
+	return 0
+}
+
+// matrixSize returns the number of the columns of a matrix type, or 0 if t is not a matrix type.
+func matrixSize(t shaderir.BasicType) int {
+	switch t {
+	case shaderir.Mat2:
+		return 2
+	case shaderir.Mat3:
+		return 3
+	case shaderir.Mat4:
+		return 4
+	}
+	return 0
+}
+
+func isScalar(t shaderir.BasicType) bool {
+	return t == shaderir.Bool || t == shaderir.Int || t == shaderir.Float
+}
+
+func vectorType(n int) shaderir.BasicType {
+	switch n {
+	case 1:
+		return shaderir.Float
+	case 2:
+		return shaderir.Vec2
+	case 3:
+		return shaderir.Vec3
+	case 4:
+		return shaderir.Vec4
+	}
+	return shaderir.None
+}
+
+// swizzlingString converts a swizzling to WGSL. WGSL doesn't have the strq set.
+func swizzlingString(s string) string {
+	if !strings.ContainsAny(s, "stq") {
+		return s
+	}
+	return strings.NewReplacer("s", "x", "t", "y", "r", "z", "q", "w").Replace(s)
+}
@@ -0,0 +1,859 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wgsl
+
+import (
+	"fmt"
+	"go/constant"
+	"go/token"
+	"regexp"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2/internal/shaderir"
+)
+
+const (
+	vertexIn    = "attributes"
+	vertexOut   = "varyings"
+	fragmentIn  = "varyings"
+	fragmentOut = "outColor"
+	uniforms    = "uniforms"
+	sampler     = "texture_sampler"
+)
+
+// The bindings of the group 0 in a compiled shader.
+//
+// The uniform variables are the members of a read-only storage buffer at UniformsBinding.
+// Prelude declares the sampler at SamplerBinding.
+// The i-th texture is at TextureBindingOffset + i.
+const (
+	UniformsBinding      = 0
+	SamplerBinding       = 1
+	TextureBindingOffset = 2
+)
+
+const Prelude = `@group(0) @binding(1) var texture_sampler: sampler;`
+
+type compileContext struct {
+	structNames map[string]string
+	structTypes []shaderir.Type
+
+	funcs      map[int]*shaderir.Func
+	blockFuncs map[*shaderir.Block]*shaderir.Func
+
+	// forVarTypes is the types of the for-loop counters, which are not in the local variables of the blocks.
+	forVarTypes map[int]shaderir.Type
+}
+
+func (c *compileContext) structName(p *shaderir.Program, t *shaderir.Type) string {
+	if t.Main != shaderir.Struct {
+		panic("wgsl: the given type at structName must be a struct")
+	}
+	s := t.String()
+	if n, ok := c.structNames[s]; ok {
+		return n
+	}
+	n := fmt.Sprintf("S%d", len(c.structNames))
+	c.structNames[s] = n
+	c.structTypes = append(c.structTypes, *t)
+	return n
+}
+
+// Compile compiles the program to WGSL.
+//
+// A viewport with a negative height is not available in WebGPU unlike Metal. Instead, the vertex entry point
+// inverts the Y direction of the position so that the framebuffer's Y direction is downward.
+func Compile(p *shaderir.Program, vertex, fragment string) (shader string) {
+	c := &compileContext{
+		structNames: map[string]string{},
+		funcs:       map[int]*shaderir.Func{},
+		blockFuncs:  map[*shaderir.Block]*shaderir.Func{},
+		forVarTypes: map[int]shaderir.Type{},
+	}
+	for i := range p.Funcs {
+		f := &p.Funcs[i]
+		c.funcs[f.Index] = f
+		c.blockFuncs[f.Block] = f
+	}
+
+	var lines []string
+	lines = append(lines, strings.Split(Prelude, "\n")...)
+	lines = append(lines, "", "{{.Structs}}")
+
+	if len(p.Uniforms) > 0 {
+		lines = append(lines, "")
+		lines = append(lines, "struct Uniforms {")
+		for i, u := range p.Uniforms {
+			lines = append(lines, fmt.Sprintf("\tU%d: %s,", i, c.wgslType(p, &u)))
+		}
+		lines = append(lines, "}")
+		lines = append(lines, "")
+		lines = append(lines, fmt.Sprintf("@group(0) @binding(%d) var<storage, read> %s: Uniforms;", UniformsBinding, uniforms))
+	}
+
+	if p.TextureNum > 0 {
+		lines = append(lines, "")
+		for i := 0; i < p.TextureNum; i++ {
+			lines = append(lines, fmt.Sprintf("@group(0) @binding(%d) var T%d: texture_2d<f32>;", TextureBindingOffset+i, i))
+		}
+	}
+
+	hasVertex := p.VertexFunc.Block != nil && len(p.VertexFunc.Block.Stmts) > 0
+	hasFragment := p.FragmentFunc.Block != nil && len(p.FragmentFunc.Block.Stmts) > 0
+
+	if len(p.Attributes) > 0 {
+		lines = append(lines, "")
+		lines = append(lines, "struct Attributes {")
+		for i, a := range p.Attributes {
+			lines = append(lines, fmt.Sprintf("\t@location(%d) M%d: %s,", i, i, c.wgslType(p, &a)))
+		}
+		lines = append(lines, "}")
+	}
+
+	if len(p.Varyings) > 0 || hasVertex || hasFragment {
+		lines = append(lines, "")
+		lines = append(lines, "struct Varyings {")
+		lines = append(lines, "\t@builtin(position) Position: vec4<f32>,")
+		for i, v := range p.Varyings {
+			interpolate := ""
+			if v.Main == shaderir.Int {
+				interpolate = "@interpolate(flat) "
+			}
+			lines = append(lines, fmt.Sprintf("\t@location(%d) %sM%d: %s,", i, interpolate, i, c.wgslType(p, &v)))
+		}
+		lines = append(lines, "}")
+	}
+
+	for _, f := range p.Funcs {
+		lines = append(lines, "")
+		lines = append(lines, c.wgslFunc(p, &f)...)
+	}
+
+	if hasVertex {
+		lines = append(lines, "")
+		lines = append(lines, "@vertex")
+		var in string
+		var copied bool
+		if len(p.Attributes) > 0 {
+			in = fmt.Sprintf("%s: Attributes", vertexIn)
+			for idx := range c.assignedLocalVariables(p.VertexFunc.Block) {
+				if idx < len(p.Attributes) {
+					copied = true
+					break
+				}
+			}
+			if copied {
+				// Parameters are immutable in WGSL. Copy them to modify.
+				in = fmt.Sprintf("%s_in: Attributes", vertexIn)
+			}
+		}
+		lines = append(lines, fmt.Sprintf("fn %s(%s) -> Varyings {", vertex, in))
+		if copied {
+			lines = append(lines, fmt.Sprintf("\tvar %[1]s: Attributes = %[1]s_in;", vertexIn))
+		}
+		lines = append(lines, fmt.Sprintf("\tvar %s: Varyings;", vertexOut))
+		lines = append(lines, c.wgslBlock(p, p.VertexFunc.Block, p.VertexFunc.Block, 0)...)
+		if last := fmt.Sprintf("\treturn %s;", vertexOut); lines[len(lines)-1] != last {
+			lines = append(lines, vertexReturn("\t")...)
+		}
+		lines = append(lines, "}")
+	}
+
+	if hasFragment {
+		lines = append(lines, "")
+		lines = append(lines, "@fragment")
+		in := fmt.Sprintf("%s: Varyings", fragmentIn)
+		var copied bool
+		for idx := range c.assignedLocalVariables(p.FragmentFunc.Block) {
+			if idx < len(p.Varyings)+1 {
+				copied = true
+				break
+			}
+		}
+		if copied {
+			// Parameters are immutable in WGSL. Copy them to modify.
+			in = fmt.Sprintf("%s_in: Varyings", fragmentIn)
+		}
+		lines = append(lines, fmt.Sprintf("fn %s(%s) -> @location(0) vec4<f32> {", fragment, in))
+		if copied {
+			lines = append(lines, fmt.Sprintf("\tvar %[1]s: Varyings = %[1]s_in;", fragmentIn))
+		}
+		lines = append(lines, fmt.Sprintf("\tvar %s: vec4<f32>;", fragmentOut))
+		lines = append(lines, c.wgslBlock(p, p.FragmentFunc.Block, p.FragmentFunc.Block, 0)...)
+		if last := fmt.Sprintf("\treturn %s;", fragmentOut); lines[len(lines)-1] != last {
+			lines = append(lines, last)
+		}
+		lines = append(lines, "}")
+	}
+
+	ls := strings.Join(lines, "\n")
+
+	// Struct types are determined after converting the program.
+	if len(c.structTypes) > 0 {
+		var stlines []string
+		for i, t := range c.structTypes {
+			stlines = append(stlines, fmt.Sprintf("struct S%d {", i))
+			for j, st := range t.Sub {
+				stlines = append(stlines, fmt.Sprintf("\tM%d: %s,", j, c.wgslType(p, &st)))
+			}
+			stlines = append(stlines, "}")
+		}
+		ls = strings.ReplaceAll(ls, "{{.Structs}}", strings.Join(stlines, "\n"))
+	} else {
+		ls = strings.ReplaceAll(ls, "{{.Structs}}", "")
+	}
+
+	nls := regexp.MustCompile(`\n\n+`)
+	ls = nls.ReplaceAllString(ls, "\n\n")
+	ls = strings.TrimSpace(ls) + "\n"
+
+	return ls
+}
+
+// vertexReturn returns the lines to return from the vertex entry point.
+func vertexReturn(idt string) []string {
+	return []string{
+		fmt.Sprintf("%s%[2]s.Position.y = -%[2]s.Position.y;", idt, vertexOut),
+		fmt.Sprintf("%sreturn %s;", idt, vertexOut),
+	}
+}
+
+func (c *compileContext) wgslType(p *shaderir.Program, t *shaderir.Type) string {
+	switch t.Main {
+	case shaderir.None:
+		return "?(none)"
+	case shaderir.Struct:
+		return c.structName(p, t)
+	default:
+		return typeString(t)
+	}
+}
+
+func (c *compileContext) wgslFunc(p *shaderir.Program, f *shaderir.Func) []string {
+	assigned := c.assignedLocalVariables(f.Block)
+
+	var args []string
+	var copies []string
+	var idx int
+	for _, t := range f.InParams {
+		name := fmt.Sprintf("l%d", idx)
+		if _, ok := assigned[idx]; ok {
+			// Parameters are immutable in WGSL. Copy them to modify.
+			args = append(args, fmt.Sprintf("p%d: %s", idx, c.wgslType(p, &t)))
+			copies = append(copies, fmt.Sprintf("\tvar %s: %s = p%d;", name, c.wgslType(p, &t), idx))
+		} else {
+			args = append(args, fmt.Sprintf("%s: %s", name, c.wgslType(p, &t)))
+		}
+		idx++
+	}
+	for _, t := range f.OutParams {
+		args = append(args, fmt.Sprintf("l%d: ptr<function, %s>", idx, c.wgslType(p, &t)))
+		idx++
+	}
+
+	sig := fmt.Sprintf("fn F%d(%s)", f.Index, strings.Join(args, ", "))
+	if f.Return.Main != shaderir.None {
+		sig += " -> " + c.wgslType(p, &f.Return)
+	}
+
+	var lines []string
+	lines = append(lines, fmt.Sprintf("%s {", sig))
+	lines = append(lines, copies...)
+	lines = append(lines, c.wgslBlock(p, f.Block, f.Block, 0)...)
+	lines = append(lines, "}")
+
+	return lines
+}
+
+// assignedLocalVariables returns the indices of the local variables that are assigned or passed as out-params in the
+// block and its descendants.
+func (c *compileContext) assignedLocalVariables(block *shaderir.Block) map[int]struct{} {
+	r := map[int]struct{}{}
+
+	var root func(e *shaderir.Expr) int
+	root = func(e *shaderir.Expr) int {
+		switch e.Type {
+		case shaderir.LocalVariable:
+			return e.Index
+		case shaderir.FieldSelector, shaderir.Index:
+			return root(&e.Exprs[0])
+		}
+		return -1
+	}
+
+	var walkExpr func(e *shaderir.Expr)
+	walkExpr = func(e *shaderir.Expr) {
+		if e.Type == shaderir.Call && e.Exprs[0].Type == shaderir.FunctionExpr {
+			if f, ok := c.funcs[e.Exprs[0].Index]; ok {
+				for i := 1 + len(f.InParams); i < len(e.Exprs); i++ {
+					if idx := root(&e.Exprs[i]); idx >= 0 {
+						r[idx] = struct{}{}
+					}
+				}
+			}
+		}
+		for i := range e.Exprs {
+			walkExpr(&e.Exprs[i])
+		}
+	}
+
+	var walkBlock func(b *shaderir.Block)
+	walkBlock = func(b *shaderir.Block) {
+		if b == nil {
+			return
+		}
+		for _, s := range b.Stmts {
+			if s.Type == shaderir.Assign {
+				if idx := root(&s.Exprs[0]); idx >= 0 {
+					r[idx] = struct{}{}
+				}
+			}
+			for i := range s.Exprs {
+				walkExpr(&s.Exprs[i])
+			}
+			for _, b := range s.Blocks {
+				walkBlock(b)
+			}
+		}
+	}
+	walkBlock(block)
+
+	return r
+}
+
+func constantToNumberLiteral(t shaderir.ConstType, v constant.Value) string {
+	switch t {
+	case shaderir.ConstTypeNone, shaderir.ConstTypeBool:
+		if v.Kind() == constant.Bool {
+			if constant.BoolVal(v) {
+				return "true"
+			}
+			return "false"
+		}
+		if t == shaderir.ConstTypeBool {
+			break
+		}
+		fallthrough
+	case shaderir.ConstTypeFloat:
+		if i := constant.ToInt(v); i.Kind() == constant.Int {
+			x, _ := constant.Int64Val(i)
+			return fmt.Sprintf("%d.0", x)
+		}
+		if i := constant.ToFloat(v); i.Kind() == constant.Float {
+			x, _ := constant.Float64Val(i)
+			return fmt.Sprintf("%.10e", x)
+		}
+	case shaderir.ConstTypeInt:
+		if i := constant.ToInt(v); i.Kind() == constant.Int {
+			x, _ := constant.Int64Val(i)
+			return fmt.Sprintf("%d", x)
+		}
+	}
+	return fmt.Sprintf("?(unexpected literal: %s)", v)
+}
+
+func (c *compileContext) isOutParam(topBlock *shaderir.Block, idx int) bool {
+	f, ok := c.blockFuncs[topBlock]
+	if !ok {
+		return false
+	}
+	return len(f.InParams) <= idx && idx < len(f.InParams)+len(f.OutParams)
+}
+
+func (c *compileContext) localVariableName(p *shaderir.Program, topBlock *shaderir.Block, idx int) string {
+	switch topBlock {
+	case p.VertexFunc.Block:
+		na := len(p.Attributes)
+		nv := len(p.Varyings)
+		switch {
+		case idx < na:
+			return fmt.Sprintf("%s.M%d", vertexIn, idx)
+		case idx == na:
+			return fmt.Sprintf("%s.Position", vertexOut)
+		case idx < na+nv+1:
+			return fmt.Sprintf("%s.M%d", vertexOut, idx-na-1)
+		default:
+			return fmt.Sprintf("l%d", idx-(na+nv+1))
+		}
+	case p.FragmentFunc.Block:
+		nv := len(p.Varyings)
+		switch {
+		case idx == 0:
+			return fmt.Sprintf("%s.Position", fragmentIn)
+		case idx < nv+1:
+			return fmt.Sprintf("%s.M%d", fragmentIn, idx-1)
+		case idx == nv+1:
+			return fragmentOut
+		default:
+			return fmt.Sprintf("l%d", idx-(nv+2))
+		}
+	default:
+		if c.isOutParam(topBlock, idx) {
+			return fmt.Sprintf("(*l%d)", idx)
+		}
+		return fmt.Sprintf("l%d", idx)
+	}
+}
+
+func (c *compileContext) localVariableType(p *shaderir.Program, topBlock, block *shaderir.Block, idx int) shaderir.Type {
+	t := p.LocalVariableType(topBlock, block, idx)
+	if t.Main == shaderir.None {
+		if t, ok := c.forVarTypes[idx]; ok {
+			return t
+		}
+	}
+	return t
+}
+
+func (c *compileContext) wgslBlock(p *shaderir.Program, topBlock, block *shaderir.Block, level int) []string {
+	if block == nil {
+		return nil
+	}
+
+	idt := strings.Repeat("\t", level+1)
+
+	var lines []string
+	for i, t := range block.LocalVars {
+		// The type is None e.g., when the variable is a for-loop counter.
+		if t.Main != shaderir.None {
+			name := c.localVariableName(p, topBlock, block.LocalVarIndexOffset+i)
+			lines = append(lines, fmt.Sprintf("%svar %s: %s;", idt, name, c.wgslType(p, &t)))
+		}
+	}
+
+	// exprType returns the type of the expression. WGSL is stricter than GLSL about types, and some expressions
+	// need to be converted based on their types.
+	var exprType func(e *shaderir.Expr) shaderir.Type
+	exprType = func(e *shaderir.Expr) shaderir.Type {
+		switch e.Type {
+		case shaderir.NumberExpr:
+			switch e.ConstType {
+			case shaderir.ConstTypeBool:
+				return shaderir.Type{Main: shaderir.Bool}
+			case shaderir.ConstTypeInt:
+				return shaderir.Type{Main: shaderir.Int}
+			case shaderir.ConstTypeFloat:
+				return shaderir.Type{Main: shaderir.Float}
+			}
+			if e.Const.Kind() == constant.Bool {
+				return shaderir.Type{Main: shaderir.Bool}
+			}
+			return shaderir.Type{Main: shaderir.Float}
+		case shaderir.UniformVariable:
+			return p.Uniforms[e.Index]
+		case shaderir.LocalVariable:
+			return c.localVariableType(p, topBlock, block, e.Index)
+		case shaderir.Unary:
+			if e.Op == shaderir.NotOp {
+				return shaderir.Type{Main: shaderir.Bool}
+			}
+			return exprType(&e.Exprs[0])
+		case shaderir.Binary:
+			switch e.Op {
+			case shaderir.LessThanOp, shaderir.LessThanEqualOp, shaderir.GreaterThanOp, shaderir.GreaterThanEqualOp, shaderir.EqualOp, shaderir.NotEqualOp, shaderir.AndAnd, shaderir.OrOr:
+				return shaderir.Type{Main: shaderir.Bool}
+			}
+			t0, t1 := exprType(&e.Exprs[0]), exprType(&e.Exprs[1])
+			if e.Op == shaderir.Mul && matrixSize(t0.Main) > 0 && vectorSize(t1.Main) > 0 {
+				return t1
+			}
+			if isScalar(t0.Main) && !isScalar(t1.Main) {
+				return t1
+			}
+			return t0
+		case shaderir.Selection:
+			return exprType(&e.Exprs[1])
+		case shaderir.Call:
+			callee := e.Exprs[0]
+			switch callee.Type {
+			case shaderir.FunctionExpr:
+				if f, ok := c.funcs[callee.Index]; ok {
+					return f.Return
+				}
+			case shaderir.BuiltinFuncExpr:
+				switch callee.BuiltinFunc {
+				case shaderir.BoolF:
+					return shaderir.Type{Main: shaderir.Bool}
+				case shaderir.IntF:
+					return shaderir.Type{Main: shaderir.Int}
+				case shaderir.FloatF, shaderir.Length, shaderir.Distance, shaderir.Dot:
+					return shaderir.Type{Main: shaderir.Float}
+				case shaderir.Vec2F:
+					return shaderir.Type{Main: shaderir.Vec2}
+				case shaderir.Vec3F, shaderir.Cross:
+					return shaderir.Type{Main: shaderir.Vec3}
+				case shaderir.Vec4F, shaderir.Texture2DF:
+					return shaderir.Type{Main: shaderir.Vec4}
+				case shaderir.Mat2F:
+					return shaderir.Type{Main: shaderir.Mat2}
+				case shaderir.Mat3F:
+					return shaderir.Type{Main: shaderir.Mat3}
+				case shaderir.Mat4F:
+					return shaderir.Type{Main: shaderir.Mat4}
+				case shaderir.Step:
+					return exprType(&e.Exprs[2])
+				case shaderir.Smoothstep:
+					return exprType(&e.Exprs[3])
+				}
+				if len(e.Exprs) > 1 {
+					return exprType(&e.Exprs[1])
+				}
+			}
+		case shaderir.FieldSelector:
+			switch e.Exprs[1].Type {
+			case shaderir.SwizzlingExpr:
+				return shaderir.Type{Main: vectorType(len(e.Exprs[1].Swizzling))}
+			case shaderir.StructMember:
+				if t := exprType(&e.Exprs[0]); t.Main == shaderir.Struct {
+					return t.Sub[e.Exprs[1].Index]
+				}
+			}
+		case shaderir.Index:
+			t := exprType(&e.Exprs[0])
+			switch {
+			case t.Main == shaderir.Array:
+				return t.Sub[0]
+			case vectorSize(t.Main) > 0:
+				return shaderir.Type{Main: shaderir.Float}
+			case matrixSize(t.Main) > 0:
+				return shaderir.Type{Main: vectorType(matrixSize(t.Main))}
+			}
+		}
+		return shaderir.Type{}
+	}
+
+	var wgslExpr func(e *shaderir.Expr) string
+	wgslExpr = func(e *shaderir.Expr) string {
+		switch e.Type {
+		case shaderir.NumberExpr:
+			return constantToNumberLiteral(e.ConstType, e.Const)
+		case shaderir.UniformVariable:
+			return fmt.Sprintf("%s.U%d", uniforms, e.Index)
+		case shaderir.TextureVariable:
+			return fmt.Sprintf("T%d", e.Index)
+		case shaderir.LocalVariable:
+			return c.localVariableName(p, topBlock, e.Index)
+		case shaderir.StructMember:
+			return fmt.Sprintf("M%d", e.Index)
+		case shaderir.BuiltinFuncExpr:
+			return builtinFuncString(e.BuiltinFunc)
+		case shaderir.SwizzlingExpr:
+			if !shaderir.IsValidSwizzling(e.Swizzling) {
+				return fmt.Sprintf("?(unexpected swizzling: %s)", e.Swizzling)
+			}
+			return swizzlingString(e.Swizzling)
+		case shaderir.FunctionExpr:
+			return fmt.Sprintf("F%d", e.Index)
+		case shaderir.Unary:
+			var op string
+			switch e.Op {
+			case shaderir.Add:
+				// WGSL doesn't have the unary plus operator.
+				op = ""
+			case shaderir.Sub, shaderir.NotOp:
+				op = string(e.Op)
+			default:
+				op = fmt.Sprintf("?(unexpected op: %s)", string(e.Op))
+			}
+			return fmt.Sprintf("%s(%s)", op, wgslExpr(&e.Exprs[0]))
+		case shaderir.Binary:
+			lhs, rhs := wgslExpr(&e.Exprs[0]), wgslExpr(&e.Exprs[1])
+			switch e.Op {
+			case shaderir.EqualOp, shaderir.NotEqualOp:
+				// In WGSL, comparing vectors results in a boolean vector.
+				if vectorSize(exprType(&e.Exprs[0]).Main) > 0 {
+					f := "all"
+					if e.Op == shaderir.NotEqualOp {
+						f = "any"
+					}
+					return fmt.Sprintf("%s((%s) %s (%s))", f, lhs, e.Op, rhs)
+				}
+			case shaderir.LeftShift, shaderir.RightShift:
+				// In WGSL, the right operand of a shift must be an unsigned integer.
+				return fmt.Sprintf("(%s) %s u32(%s)", lhs, e.Op, rhs)
+			}
+			return fmt.Sprintf("(%s) %s (%s)", lhs, e.Op, rhs)
+		case shaderir.Selection:
+			return fmt.Sprintf("select((%s), (%s), (%s))", wgslExpr(&e.Exprs[2]), wgslExpr(&e.Exprs[1]), wgslExpr(&e.Exprs[0]))
+		case shaderir.Call:
+			callee := e.Exprs[0]
+			if callee.Type == shaderir.FunctionExpr {
+				f := c.funcs[callee.Index]
+				var args []string
+				for i, exp := range e.Exprs[1:] {
+					if f == nil || i < len(f.InParams) {
+						args = append(args, wgslExpr(&exp))
+						continue
+					}
+					// Out-params are pointers.
+					if exp.Type == shaderir.LocalVariable && c.isOutParam(topBlock, exp.Index) {
+						args = append(args, fmt.Sprintf("l%d", exp.Index))
+						continue
+					}
+					args = append(args, "&"+wgslExpr(&exp))
+				}
+				return fmt.Sprintf("%s(%s)", wgslExpr(&callee), strings.Join(args, ", "))
+			}
+
+			var args []string
+			var types []shaderir.Type
+			for _, exp := range e.Exprs[1:] {
+				args = append(args, wgslExpr(&exp))
+				types = append(types, exprType(&exp))
+			}
+			if callee.Type == shaderir.BuiltinFuncExpr {
+				if s, ok := c.builtinCallString(callee.BuiltinFunc, args, types); ok {
+					return s
+				}
+			}
+			return fmt.Sprintf("%s(%s)", wgslExpr(&callee), strings.Join(args, ", "))
+		case shaderir.FieldSelector:
+			return fmt.Sprintf("(%s).%s", wgslExpr(&e.Exprs[0]), wgslExpr(&e.Exprs[1]))
+		case shaderir.Index:
+			return fmt.Sprintf("(%s)[%s]", wgslExpr(&e.Exprs[0]), wgslExpr(&e.Exprs[1]))
+		default:
+			return fmt.Sprintf("?(unexpected expr: %d)", e.Type)
+		}
+	}
+
+	for _, s := range block.Stmts {
+		switch s.Type {
+		case shaderir.ExprStmt:
+			lines = append(lines, fmt.Sprintf("%s%s;", idt, wgslExpr(&s.Exprs[0])))
+		case shaderir.BlockStmt:
+			lines = append(lines, idt+"{")
+			lines = append(lines, c.wgslBlock(p, topBlock, s.Blocks[0], level+1)...)
+			lines = append(lines, idt+"}")
+		case shaderir.Assign:
+			lhs := &s.Exprs[0]
+			if lhs.Type == shaderir.FieldSelector && lhs.Exprs[1].Type == shaderir.SwizzlingExpr && len(lhs.Exprs[1].Swizzling) > 1 {
+				// WGSL cannot assign a value to a swizzling with multiple components. Assign each component instead.
+				lines = append(lines, idt+"{")
+				lines = append(lines, fmt.Sprintf("%s\tlet v = %s;", idt, wgslExpr(&s.Exprs[1])))
+				for i, comp := range swizzlingString(lhs.Exprs[1].Swizzling) {
+					lines = append(lines, fmt.Sprintf("%s\t(%s).%c = v.%c;", idt, wgslExpr(&lhs.Exprs[0]), comp, "xyzw"[i]))
+				}
+				lines = append(lines, idt+"}")
+				break
+			}
+			lines = append(lines, fmt.Sprintf("%s%s = %s;", idt, wgslExpr(lhs), wgslExpr(&s.Exprs[1])))
+		case shaderir.Init:
+			init := true
+			if topBlock == p.VertexFunc.Block {
+				// In the vertex function, varying values are the output parameters.
+				// These values are represented as a struct and not needed to be initialized.
+				na := len(p.Attributes)
+				nv := len(p.Varyings)
+				if s.InitIndex < na+nv+1 {
+					init = false
+				}
+			}
+			if init {
+				name := c.localVariableName(p, topBlock, s.InitIndex)
+				t := c.localVariableType(p, topBlock, block, s.InitIndex)
+				lines = append(lines, fmt.Sprintf("%s%s = %s();", idt, name, c.wgslType(p, &t)))
+			}
+		case shaderir.If:
+			lines = append(lines, fmt.Sprintf("%sif (%s) {", idt, wgslExpr(&s.Exprs[0])))
+			lines = append(lines, c.wgslBlock(p, topBlock, s.Blocks[0], level+1)...)
+			if len(s.Blocks) > 1 {
+				lines = append(lines, fmt.Sprintf("%s} else {", idt))
+				lines = append(lines, c.wgslBlock(p, topBlock, s.Blocks[1], level+1)...)
+			}
+			lines = append(lines, fmt.Sprintf("%s}", idt))
+		case shaderir.For:
+			var ct shaderir.ConstType
+			switch s.ForVarType.Main {
+			case shaderir.Int:
+				ct = shaderir.ConstTypeInt
+			case shaderir.Float:
+				ct = shaderir.ConstTypeFloat
+			}
+			c.forVarTypes[s.ForVarIndex] = s.ForVarType
+
+			v := c.localVariableName(p, topBlock, s.ForVarIndex)
+			var delta string
+			switch val, _ := constant.Float64Val(s.ForDelta); {
+			case val == 0:
+				delta = fmt.Sprintf("?(unexpected delta: %v)", s.ForDelta)
+			case val == 1 && ct == shaderir.ConstTypeInt:
+				delta = fmt.Sprintf("%s++", v)
+			case val == -1 && ct == shaderir.ConstTypeInt:
+				// The increment and decrement statements are available only for integers.
+				delta = fmt.Sprintf("%s--", v)
+			default:
+				d := s.ForDelta
+				if val > 0 {
+					delta = fmt.Sprintf("%s += %s", v, constantToNumberLiteral(ct, d))
+				} else {
+					d = constant.UnaryOp(token.SUB, d, 0)
+					delta = fmt.Sprintf("%s -= %s", v, constantToNumberLiteral(ct, d))
+				}
+			}
+			var op string
+			switch s.ForOp {
+			case shaderir.LessThanOp, shaderir.LessThanEqualOp, shaderir.GreaterThanOp, shaderir.GreaterThanEqualOp, shaderir.EqualOp, shaderir.NotEqualOp:
+				op = string(s.ForOp)
+			default:
+				op = fmt.Sprintf("?(unexpected op: %s)", string(s.ForOp))
+			}
+
+			t := s.ForVarType
+			init := constantToNumberLiteral(ct, s.ForInit)
+			end := constantToNumberLiteral(ct, s.ForEnd)
+			ts := typeString(&t)
+			lines = append(lines, fmt.Sprintf("%sfor (var %s: %s = %s; %s %s %s; %s) {", idt, v, ts, init, v, op, end, delta))
+			lines = append(lines, c.wgslBlock(p, topBlock, s.Blocks[0], level+1)...)
+			lines = append(lines, fmt.Sprintf("%s}", idt))
+		case shaderir.Continue:
+			lines = append(lines, idt+"continue;")
+		case shaderir.Break:
+			lines = append(lines, idt+"break;")
+		case shaderir.Return:
+			switch {
+			case topBlock == p.VertexFunc.Block:
+				lines = append(lines, vertexReturn(idt)...)
+			case topBlock == p.FragmentFunc.Block:
+				lines = append(lines, fmt.Sprintf("%sreturn %s;", idt, fragmentOut))
+			case len(s.Exprs) == 0:
+				lines = append(lines, idt+"return;")
+			default:
+				lines = append(lines, fmt.Sprintf("%sreturn %s;", idt, wgslExpr(&s.Exprs[0])))
+			}
+		case shaderir.Discard:
+			lines = append(lines, idt+"discard;")
+		default:
+			lines = append(lines, fmt.Sprintf("%s?(unexpected stmt: %d)", idt, s.Type))
+		}
+	}
+
+	return lines
+}
+
+// builtinCallString returns a call of a built-in function that needs a conversion from GLSL's semantics.
+// builtinCallString returns false if no conversion is needed.
+func (c *compileContext) builtinCallString(f shaderir.BuiltinFunc, args []string, types []shaderir.Type) (string, bool) {
+	toFloat := func(arg string, t shaderir.Type) string {
+		if t.Main == shaderir.Int || t.Main == shaderir.Bool {
+			return fmt.Sprintf("f32(%s)", arg)
+		}
+		return arg
+	}
+	splat := func(arg string, t shaderir.Type, to shaderir.Type) string {
+		if vectorSize(to.Main) > 0 && isScalar(t.Main) {
+			return fmt.Sprintf("%s(%s)", basicTypeString(to.Main), arg)
+		}
+		return arg
+	}
+
+	switch f {
+	case shaderir.Texture2DF:
+		if len(args) != 2 {
+			return "", false
+		}
+		// textureSample is available only in uniform control flow. Use textureSampleLevel instead.
+		return fmt.Sprintf("textureSampleLevel(%s, %s, %s, 0.0)", args[0], sampler, args[1]), true
+	case shaderir.Mod:
+		if len(args) != 2 {
+			return "", false
+		}
+		// WGSL's % is different from GLSL's mod for negative values.
+		return fmt.Sprintf("((%[1]s) - (%[2]s) * floor((%[1]s) / (%[2]s)))", args[0], args[1]), true
+	case shaderir.Atan:
+		if len(args) == 2 {
+			return fmt.Sprintf("atan2(%s, %s)", args[0], args[1]), true
+		}
+	case shaderir.Vec2F, shaderir.Vec3F, shaderir.Vec4F:
+		n := int(f[3] - '0')
+		if len(args) == 1 {
+			// GLSL can make a smaller vector from a bigger vector.
+			if m := vectorSize(types[0].Main); m > n {
+				return fmt.Sprintf("(%s).%s", args[0], "xyzw"[:n]), true
+			}
+		}
+		var strs []string
+		for i, arg := range args {
+			strs = append(strs, toFloat(arg, types[i]))
+		}
+		return fmt.Sprintf("%s(%s)", builtinFuncString(f), strings.Join(strs, ", ")), true
+	case shaderir.Mat2F, shaderir.Mat3F, shaderir.Mat4F:
+		n := int(f[3] - '0')
+		if len(args) == 1 {
+			if isScalar(types[0].Main) {
+				// In GLSL, a matrix from a scalar is a diagonal matrix.
+				x := toFloat(args[0], types[0])
+				var elems []string
+				for i := 0; i < n; i++ {
+					for j := 0; j < n; j++ {
+						if i == j {
+							elems = append(elems, x)
+						} else {
+							elems = append(elems, "0.0")
+						}
+					}
+				}
+				return fmt.Sprintf("%s(%s)", builtinFuncString(f), strings.Join(elems, ", ")), true
+			}
+			if m := matrixSize(types[0].Main); m > 0 && m != n {
+				// In GLSL, a matrix from a matrix takes the upper-left part, and the rest is filled with the
+				// identity matrix.
+				var cols []string
+				for i := 0; i < n; i++ {
+					var elems []string
+					for j := 0; j < n; j++ {
+						switch {
+						case i < m && j < m:
+							elems = append(elems, fmt.Sprintf("(%s)[%d][%d]", args[0], i, j))
+						case i == j:
+							elems = append(elems, "1.0")
+						default:
+							elems = append(elems, "0.0")
+						}
+					}
+					cols = append(cols, strings.Join(elems, ", "))
+				}
+				return fmt.Sprintf("%s(%s)", builtinFuncString(f), strings.Join(cols, ", ")), true
+			}
+		}
+		var strs []string
+		for i, arg := range args {
+			strs = append(strs, toFloat(arg, types[i]))
+		}
+		return fmt.Sprintf("%s(%s)", builtinFuncString(f), strings.Join(strs, ", ")), true
+	case shaderir.Min, shaderir.Max, shaderir.Clamp:
+		// In WGSL, all the arguments must have the same type.
+		if len(args) == 0 {
+			return "", false
+		}
+		strs := []string{args[0]}
+		for i, arg := range args[1:] {
+			strs = append(strs, splat(arg, types[i+1], types[0]))
+		}
+		return fmt.Sprintf("%s(%s)", builtinFuncString(f), strings.Join(strs, ", ")), true
+	case shaderir.Step, shaderir.Smoothstep:
+		// In WGSL, all the arguments must have the same type.
+		if len(args) == 0 {
+			return "", false
+		}
+		last := len(args) - 1
+		var strs []string
+		for i, arg := range args[:last] {
+			strs = append(strs, splat(arg, types[i], types[last]))
+		}
+		strs = append(strs, args[last])
+		return fmt.Sprintf("%s(%s)", builtinFuncString(f), strings.Join(strs, ", ")), true
+	}
+	return "", false
+}
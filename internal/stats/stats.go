@@ -0,0 +1,188 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package stats records the statistics of the engine for diagnostics like the performance overlay.
+package stats
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// FrameTimeHistorySize is the number of the frame times recorded.
+const FrameTimeHistorySize = 240
+
+// Phase represents a phase of a frame.
+type Phase int
+
+const (
+	PhaseUpdate Phase = iota
+	PhaseDraw
+	PhaseGPU
+	PhasePresent
+
+	PhaseNum
+)
+
+var (
+	drawCalls     int64
+	lastDrawCalls int64
+
+	textureCount   int64
+	textureBytes   int64
+	audioUnderruns int64
+
+	frameTimes     [FrameTimeHistorySize]time.Duration
+	frameTimeIndex int
+	frameTimeCount int
+	lastFrameEnd   time.Time
+
+	phaseTimes     [PhaseNum]time.Duration
+	lastPhaseTimes [PhaseNum]time.Duration
+
+	drawCallLogEnabled int32
+	drawCallLog        []string
+	lastDrawCallLog    []string
+
+	m sync.Mutex
+)
+
+// AddDrawCall records a draw call to the graphics driver.
+func AddDrawCall() {
+	atomic.AddInt64(&drawCalls, 1)
+}
+
+// SetDrawCallLogEnabled sets whether the descriptions of the draw calls are recorded by LogDrawCall.
+func SetDrawCallLogEnabled(enabled bool) {
+	v := int32(0)
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&drawCallLogEnabled, v)
+}
+
+// IsDrawCallLogEnabled reports whether the descriptions of the draw calls are recorded.
+// As making a description is not cheap, the caller should check this before LogDrawCall.
+func IsDrawCallLogEnabled() bool {
+	return atomic.LoadInt32(&drawCallLogEnabled) != 0
+}
+
+// LogDrawCall records the description of a draw call.
+func LogDrawCall(description string) {
+	m.Lock()
+	defer m.Unlock()
+	drawCallLog = append(drawCallLog, description)
+}
+
+// LastFrameDrawCallLog returns the descriptions of the draw calls in the last frame.
+func LastFrameDrawCallLog() []string {
+	m.Lock()
+	defer m.Unlock()
+	return append([]string(nil), lastDrawCallLog...)
+}
+
+// AddTexture records a texture created with the given size in bytes.
+func AddTexture(bytes int64) {
+	atomic.AddInt64(&textureCount, 1)
+	atomic.AddInt64(&textureBytes, bytes)
+}
+
+// RemoveTexture records a texture disposed with the given size in bytes.
+func RemoveTexture(bytes int64) {
+	atomic.AddInt64(&textureCount, -1)
+	atomic.AddInt64(&textureBytes, -bytes)
+}
+
+// AddAudioUnderrun records an underrun of an audio player, i.e. the player could not provide enough data to
+// the audio device in time.
+func AddAudioUnderrun() {
+	atomic.AddInt64(&audioUnderruns, 1)
+}
+
+// AddPhaseTime records the time spent in the given phase in the current frame.
+func AddPhaseTime(phase Phase, d time.Duration) {
+	m.Lock()
+	defer m.Unlock()
+	phaseTimes[phase] += d
+}
+
+// BeginFrame records the beginning of a frame.
+//
+// The phase times recorded so far are regarded as the last frame's. As presenting the screen happens after the
+// end of the frame on some platforms, the phase times are not finalized at EndFrame.
+func BeginFrame() {
+	m.Lock()
+	defer m.Unlock()
+	lastPhaseTimes = phaseTimes
+	phaseTimes = [PhaseNum]time.Duration{}
+}
+
+// PhaseTimes returns the times spent in the phases in the last frame.
+func PhaseTimes() [PhaseNum]time.Duration {
+	m.Lock()
+	defer m.Unlock()
+	return lastPhaseTimes
+}
+
+// EndFrame records the end of a frame at t.
+func EndFrame(t time.Time) {
+	atomic.StoreInt64(&lastDrawCalls, atomic.SwapInt64(&drawCalls, 0))
+
+	m.Lock()
+	defer m.Unlock()
+
+	lastDrawCallLog = drawCallLog
+	drawCallLog = nil
+
+	if !lastFrameEnd.IsZero() {
+		frameTimes[frameTimeIndex] = t.Sub(lastFrameEnd)
+		frameTimeIndex = (frameTimeIndex + 1) % FrameTimeHistorySize
+		if frameTimeCount < FrameTimeHistorySize {
+			frameTimeCount++
+		}
+	}
+	lastFrameEnd = t
+}
+
+// DrawCalls returns the number of the draw calls in the last frame.
+func DrawCalls() int {
+	return int(atomic.LoadInt64(&lastDrawCalls))
+}
+
+// Textures returns the number of the live textures and their total size in bytes.
+func Textures() (int, int64) {
+	return int(atomic.LoadInt64(&textureCount)), atomic.LoadInt64(&textureBytes)
+}
+
+// AudioUnderruns returns the total number of the audio underruns.
+func AudioUnderruns() int {
+	return int(atomic.LoadInt64(&audioUnderruns))
+}
+
+// AppendFrameTimes appends the recent frame times to dst from the oldest one, and returns the extended slice.
+// A frame time is the interval between the ends of two adjacent frames.
+func AppendFrameTimes(dst []time.Duration) []time.Duration {
+	m.Lock()
+	defer m.Unlock()
+
+	start := frameTimeIndex - frameTimeCount
+	if start < 0 {
+		start += FrameTimeHistorySize
+	}
+	for i := 0; i < frameTimeCount; i++ {
+		dst = append(dst, frameTimes[(start+i)%FrameTimeHistorySize])
+	}
+	return dst
+}
@@ -0,0 +1,82 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stats_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2/internal/stats"
+)
+
+func TestFrameTimes(t *testing.T) {
+	t0 := time.Unix(0, 0)
+	stats.EndFrame(t0)
+	for i := 1; i <= stats.FrameTimeHistorySize+2; i++ {
+		stats.AddDrawCall()
+		stats.AddDrawCall()
+		stats.EndFrame(t0.Add(time.Duration(i*(i+1)/2) * time.Millisecond))
+	}
+
+	if got, want := stats.DrawCalls(), 2; got != want {
+		t.Errorf("stats.DrawCalls(): got: %d, want: %d", got, want)
+	}
+
+	ts := stats.AppendFrameTimes(nil)
+	if got, want := len(ts), stats.FrameTimeHistorySize; got != want {
+		t.Fatalf("len(ts): got: %d, want: %d", got, want)
+	}
+	// The i-th frame took i milliseconds. The oldest two frames are dropped.
+	for i, d := range ts {
+		if want := time.Duration(i+3) * time.Millisecond; d != want {
+			t.Errorf("ts[%d]: got: %v, want: %v", i, d, want)
+		}
+	}
+}
+
+func TestTextures(t *testing.T) {
+	n0, b0 := stats.Textures()
+	stats.AddTexture(16)
+	stats.AddTexture(32)
+	stats.RemoveTexture(16)
+	n1, b1 := stats.Textures()
+	if got, want := n1-n0, 1; got != want {
+		t.Errorf("count: got: %d, want: %d", got, want)
+	}
+	if got, want := b1-b0, int64(32); got != want {
+		t.Errorf("bytes: got: %d, want: %d", got, want)
+	}
+}
+
+func TestPhaseTimes(t *testing.T) {
+	stats.BeginFrame()
+	stats.AddPhaseTime(stats.PhaseUpdate, 1*time.Millisecond)
+	stats.AddPhaseTime(stats.PhaseUpdate, 2*time.Millisecond)
+	stats.AddPhaseTime(stats.PhaseDraw, 4*time.Millisecond)
+
+	// The phase times are not finalized until the next frame begins.
+	if got, want := stats.PhaseTimes()[stats.PhaseUpdate], time.Duration(0); got != want {
+		t.Errorf("update: got: %v, want: %v", got, want)
+	}
+
+	stats.BeginFrame()
+	ts := stats.PhaseTimes()
+	if got, want := ts[stats.PhaseUpdate], 3*time.Millisecond; got != want {
+		t.Errorf("update: got: %v, want: %v", got, want)
+	}
+	if got, want := ts[stats.PhaseDraw], 4*time.Millisecond; got != want {
+		t.Errorf("draw: got: %v, want: %v", got, want)
+	}
+}
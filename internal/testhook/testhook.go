@@ -0,0 +1,82 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package testhook provides hooks for the ebitentest package to drive the ebiten package.
+package testhook
+
+import (
+	"image"
+	"sync"
+
+	"github.com/hajimehoshi/ebiten/v2/internal/driver"
+)
+
+var (
+	input driver.Input
+	m     sync.Mutex
+)
+
+// SetInput overrides the input of the UI driver. If i is nil, the input of the UI driver is used.
+func SetInput(i driver.Input) {
+	m.Lock()
+	defer m.Unlock()
+	input = i
+}
+
+// Input returns the overriding input, or nil if the input is not overridden.
+func Input() driver.Input {
+	m.Lock()
+	defer m.Unlock()
+	return input
+}
+
+// ResetInputForFrame resets the overriding input for a new tick, if the input has ResetForFrame.
+func ResetInputForFrame() {
+	i := Input()
+	if r, ok := i.(interface{ ResetForFrame() }); ok {
+		r.ResetForFrame()
+	}
+}
+
+// Runner drives a game in the same way as the main loop of the ebiten package, but with a manual clock and
+// without a screen framebuffer.
+type Runner interface {
+	// Advance updates the game by one tick, and then draws the game.
+	Advance() error
+
+	// SetOutsideSize sets the outside size passed to the game's Layout.
+	SetOutsideSize(width, height float64)
+
+	// Screen returns the image that the game drew at the last Advance, which is *ebiten.Image.
+	// Screen returns nil if Advance is not called yet.
+	Screen() interface{}
+
+	// Dispose disposes the resources of the runner.
+	Dispose()
+}
+
+// NewRunner creates a new Runner for the game, which must be ebiten.Game.
+//
+// NewRunner is set by the ebiten package.
+var NewRunner func(game interface{}) Runner
+
+// GraphicsBackend returns the name of the current graphics backend, e.g. "opengl" or "metal".
+//
+// GraphicsBackend is set by the ebiten package.
+var GraphicsBackend func() string
+
+// ReadPixels reads the pixels of img, which must be *ebiten.Image.
+//
+// ReadPixels is set by the ebiten package.
+var ReadPixels func(img interface{}) (*image.RGBA, error)
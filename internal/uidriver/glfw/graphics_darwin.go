@@ -34,6 +34,7 @@ package glfw
 import "C"
 
 import (
+	"github.com/hajimehoshi/ebiten/v2/internal/diag"
 	"github.com/hajimehoshi/ebiten/v2/internal/driver"
 	"github.com/hajimehoshi/ebiten/v2/internal/graphicsdriver/metal"
 	"github.com/hajimehoshi/ebiten/v2/internal/graphicsdriver/metal/mtl"
@@ -59,9 +60,11 @@ func supportsMetal() bool {
 func init() {
 	if supportsMetal() {
 		graphics = metal.Get()
+		diag.Log(diag.CategoryGraphics, "Metal is chosen as the graphics backend")
 		return
 	}
 	graphics = opengl.Get()
+	diag.Log(diag.CategoryGraphics, "OpenGL is chosen as the graphics backend as Metal is not available")
 }
 
 func (*UserInterface) Graphics() driver.Graphics {
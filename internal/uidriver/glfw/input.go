@@ -22,8 +22,10 @@ package glfw
 import (
 	"math"
 	"sync"
+	"time"
 	"unicode"
 
+	"github.com/hajimehoshi/ebiten/v2/internal/diag"
 	"github.com/hajimehoshi/ebiten/v2/internal/driver"
 	"github.com/hajimehoshi/ebiten/v2/internal/glfw"
 )
@@ -50,6 +52,9 @@ type Input struct {
 	touches            map[driver.TouchID]pos // TODO: Implement this (#417)
 	runeBuffer         []rune
 	ui                 *UserInterface
+
+	// ignoredGamepads is a set of the GUIDs of the ignored gamepads, to log them only once.
+	ignoredGamepads map[string]struct{}
 }
 
 type pos struct {
@@ -327,6 +332,14 @@ func (i *Input) update(window *glfw.Window, context driver.UIContext) {
 		// recognized as gamepads by GLFW. In this case, the number of the 'buttons' can exceeds the
 		// maximum. Skip such devices as a tentative solution (#1173).
 		if len(buttons) > driver.GamepadButtonNum {
+			guid := id.GetGUID()
+			if _, ok := i.ignoredGamepads[guid]; !ok {
+				if i.ignoredGamepads == nil {
+					i.ignoredGamepads = map[string]struct{}{}
+				}
+				i.ignoredGamepads[guid] = struct{}{}
+				diag.Log(diag.CategoryInput, "gamepad ignored as it has too many buttons", "name", id.GetName(), "guid", guid, "buttons", len(buttons))
+			}
 			continue
 		}
 
@@ -356,3 +369,7 @@ func (i *Input) update(window *glfw.Window, context driver.UIContext) {
 		i.gamepads[id].name = id.GetName()
 	}
 }
+
+func (i *Input) VibrateGamepad(id driver.GamepadID, duration time.Duration, strongMagnitude float64, weakMagnitude float64) {
+	// GLFW doesn't support vibration.
+}
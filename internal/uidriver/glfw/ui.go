@@ -32,6 +32,7 @@ import (
 	"github.com/hajimehoshi/ebiten/v2/internal/driver"
 	"github.com/hajimehoshi/ebiten/v2/internal/glfw"
 	"github.com/hajimehoshi/ebiten/v2/internal/hooks"
+	"github.com/hajimehoshi/ebiten/v2/internal/stats"
 	"github.com/hajimehoshi/ebiten/v2/internal/thread"
 )
 
@@ -999,9 +1000,8 @@ func (u *UserInterface) loop() error {
 
 		var t1, t2 time.Time
 
-		if unfocused {
-			t1 = time.Now()
-		}
+		// The screen might not be presented if it is not updated. Measure the time to sleep in this case.
+		t1 = time.Now()
 
 		var outsideWidth, outsideHeight float64
 		var outsideSizeChanged bool
@@ -1010,6 +1010,12 @@ func (u *UserInterface) loop() error {
 			outsideWidth, outsideHeight, outsideSizeChanged, err = u.update()
 			return err
 		}); err != nil {
+			if err == driver.RegularTermination {
+				// Let the game finish its work before the window is destroyed.
+				if err := u.context.Shutdown(); err != nil {
+					return err
+				}
+			}
 			return err
 		}
 		if outsideSizeChanged {
@@ -1059,20 +1065,19 @@ func (u *UserInterface) loop() error {
 		// swapBuffers also checks IsGL, so this condition is redundant.
 		// However, (*thread).Call is not good for performance due to channels.
 		// Let's avoid this whenever possible (#1367).
-		if u.Graphics().IsGL() {
+		presented := u.context.IsScreenUpdated()
+		if u.Graphics().IsGL() && presented {
 			_ = u.t.Call(func() error {
 				u.swapBuffers()
 				return nil
 			})
 		}
 
-		if unfocused {
-			t2 = time.Now()
-		}
-
 		// When a window is not focused, SwapBuffers might return immediately and CPU might be busy.
 		// Mitigate this by sleeping (#982).
-		if unfocused {
+		// The same applies when the screen is not presented, as nothing waits for vsync.
+		if unfocused || !presented {
+			t2 = time.Now()
 			d := t2.Sub(t1)
 			const wait = time.Second / 60
 			if d < wait {
@@ -1085,7 +1090,9 @@ func (u *UserInterface) loop() error {
 // swapBuffers must be called from the main thread.
 func (u *UserInterface) swapBuffers() {
 	if u.Graphics().IsGL() {
+		t := time.Now()
 		u.window.SwapBuffers()
+		stats.AddPhaseTime(stats.PhasePresent, time.Since(t))
 	}
 }
 
@@ -1376,6 +1383,33 @@ func (u *UserInterface) IsScreenTransparent() bool {
 	return val
 }
 
+func (u *UserInterface) UpdateInput() {
+	if !u.isRunning() {
+		return
+	}
+	_ = u.t.Call(func() error {
+		// UpdateInput can be called in the middle of a frame. Disable the callback of SetSize, as calling
+		// ForceUpdate inside Update is illegal (#1505).
+		if u.setSizeCallbackEnabled {
+			u.setSizeCallbackEnabled = false
+			defer func() {
+				u.setSizeCallbackEnabled = true
+			}()
+		}
+		glfw.PollEvents()
+
+		// Record the window size explicitly since the callback is disabled now. The new size is applied to the
+		// rendering at the next frame.
+		if !u.isFullscreen() && u.window.GetAttrib(glfw.Resizable) == glfw.True && u.window.GetAttrib(glfw.Iconified) != glfw.True {
+			w, h := u.window.GetSize()
+			u.setWindowSize(w, h, false)
+		}
+
+		u.input.update(u.window, u.context)
+		return nil
+	})
+}
+
 func (u *UserInterface) ResetForFrame() {
 	// The offscreens must be updated every frame (#490).
 	var w, h float64
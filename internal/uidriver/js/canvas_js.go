@@ -0,0 +1,130 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package js
+
+import (
+	"fmt"
+	"syscall/js"
+
+	"github.com/hajimehoshi/ebiten/v2/internal/devicescale"
+)
+
+var (
+	// canvasParent is the element that contains the canvas. The canvas fills canvasParent, and the size of
+	// canvasParent is used as the outside size. canvasParent is the document's body by default.
+	canvasParent js.Value
+
+	resizeObserver js.Value
+)
+
+// canvasParentSize returns the size of the canvas's parent element in CSS pixels.
+func canvasParentSize() (float64, float64) {
+	// In fullscreen, the canvas fills the screen regardless of its parent.
+	if theUI.IsFullscreen() {
+		return window.Get("innerWidth").Float(), window.Get("innerHeight").Float()
+	}
+	return canvasParent.Get("clientWidth").Float(), canvasParent.Get("clientHeight").Float()
+}
+
+func onCanvasParentResize() {
+	theUI.updateScreenSize()
+	// The main loop has not started yet.
+	if theUI.context == nil {
+		return
+	}
+	// WebGPU waits for promises, which cannot be done in a callback from JavaScript. The screen is updated at
+	// the next frame.
+	if !theUI.Graphics().IsGL() {
+		return
+	}
+	if err := theUI.updateImpl(true); err != nil {
+		panic(err)
+	}
+}
+
+// observeCanvasParent starts tracking the size of the canvas's parent element.
+//
+// The window's resize event is not enough since the parent element can be resized without resizing the window,
+// e.g. by a CSS layout.
+func observeCanvasParent() {
+	class := js.Global().Get("ResizeObserver")
+	if !class.Truthy() {
+		// The window's resize event is used instead.
+		return
+	}
+	resizeObserver = class.New(js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		onCanvasParentResize()
+		return nil
+	}))
+	resizeObserver.Call("observe", canvasParent)
+}
+
+// watchDevicePixelRatio starts tracking the changes of the device pixel ratio, e.g. by zooming the page or
+// moving the window to another monitor.
+func watchDevicePixelRatio() {
+	if !window.Get("matchMedia").Truthy() {
+		return
+	}
+
+	// A media query matches only with the current ratio. Register a new query whenever the ratio changes.
+	var f js.Func
+	var mql js.Value
+	f = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		mql.Call("removeEventListener", "change", f)
+		f.Release()
+
+		devicescale.Invalidate()
+		watchDevicePixelRatio()
+		onCanvasParentResize()
+		return nil
+	})
+	mql = window.Call("matchMedia", fmt.Sprintf("(resolution: %gdppx)", window.Get("devicePixelRatio").Float()))
+	mql.Call("addEventListener", "change", f)
+}
+
+// SetCanvasContainerID moves the canvas into the element specified by the ID, and makes the canvas track the
+// element's size.
+//
+// If id is an empty string, the canvas is moved back to the document's body.
+func (u *UserInterface) SetCanvasContainerID(id string) error {
+	if !document.Truthy() {
+		return nil
+	}
+
+	var parent js.Value
+	if id == "" {
+		parent = document.Get("body")
+	} else {
+		parent = document.Call("getElementById", id)
+		if !parent.Truthy() {
+			return fmt.Errorf("js: the element %q was not found", id)
+		}
+	}
+	if parent.Equal(canvasParent) {
+		return nil
+	}
+
+	if resizeObserver.Truthy() {
+		resizeObserver.Call("unobserve", canvasParent)
+	}
+	canvasParent = parent
+	canvasParent.Call("appendChild", canvas)
+	if resizeObserver.Truthy() {
+		resizeObserver.Call("observe", canvasParent)
+	}
+
+	u.updateScreenSize()
+	return nil
+}
@@ -0,0 +1,114 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package js
+
+import (
+	"syscall/js"
+
+	"github.com/hajimehoshi/ebiten/v2/internal/web"
+)
+
+func clipboard() js.Value {
+	n := window.Get("navigator")
+	if !n.Truthy() {
+		return js.Undefined()
+	}
+	// navigator.clipboard is available only in secure contexts.
+	return n.Get("clipboard")
+}
+
+// SetClipboardText writes text to the clipboard.
+//
+// Browsers require a user gesture to write to the clipboard. If the current timing is not in or soon after a user
+// gesture, writing is deferred until the next user gesture on the canvas.
+func (u *UserInterface) SetClipboardText(text string) {
+	u.pendingClipboardText = &text
+	if !isUserActivationActive() {
+		return
+	}
+	u.flushClipboardText()
+}
+
+// flushClipboardText is called in a user gesture's event handler.
+func (u *UserInterface) flushClipboardText() {
+	if u.pendingClipboardText == nil {
+		return
+	}
+	text := *u.pendingClipboardText
+	u.pendingClipboardText = nil
+
+	if c := clipboard(); c.Truthy() && c.Get("writeText").Truthy() {
+		var reject js.Func
+		reject = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			defer reject.Release()
+			// The request was rejected, probably due to the lack of a user gesture or the focus.
+			// Retry at the next user gesture unless a newer text is already pending.
+			if u.pendingClipboardText == nil {
+				u.pendingClipboardText = &text
+			}
+			return nil
+		})
+		c.Call("writeText", text).Call("catch", reject)
+		return
+	}
+
+	// The async Clipboard API is not available e.g. in an insecure context. Use the legacy copy command.
+	var f js.Func
+	f = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		e := args[0]
+		e.Get("clipboardData").Call("setData", "text/plain", text)
+		e.Call("preventDefault")
+		return nil
+	})
+	document.Call("addEventListener", "copy", f)
+	document.Call("execCommand", "copy")
+	document.Call("removeEventListener", "copy", f)
+	f.Release()
+}
+
+// RequestClipboardText requests to read text from the clipboard. The text is appended to the pasted text later
+// when the read succeeds.
+//
+// The browser might ask the user for permission. RequestClipboardText does nothing if the async Clipboard API
+// is not available.
+func (u *UserInterface) RequestClipboardText() {
+	c := clipboard()
+	if !c.Truthy() || !c.Get("readText").Truthy() {
+		return
+	}
+	p := c.Call("readText")
+	go func() {
+		v, err := web.AwaitPromise(p)
+		if err != nil {
+			// The permission was denied, or the document was not focused.
+			return
+		}
+		u.input.pastedText += v.String()
+	}()
+}
+
+// PastedText returns the text pasted in the current tick.
+func (u *UserInterface) PastedText() string {
+	return u.input.pastedText
+}
+
+func onPaste(e js.Value) {
+	d := e.Get("clipboardData")
+	if !d.Truthy() {
+		return
+	}
+	e.Call("preventDefault")
+	theUI.input.pastedText += d.Call("getData", "text").String()
+}
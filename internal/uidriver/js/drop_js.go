@@ -0,0 +1,82 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package js
+
+import (
+	"syscall/js"
+
+	"github.com/hajimehoshi/ebiten/v2/internal/web"
+)
+
+// DroppedFile is a file dropped onto the canvas.
+type DroppedFile struct {
+	Name string
+	Type string
+	Data []byte
+}
+
+// DroppedFiles returns the files dropped in the current tick.
+func (u *UserInterface) DroppedFiles() []DroppedFile {
+	fs := make([]DroppedFile, len(u.input.droppedFiles))
+	copy(fs, u.input.droppedFiles)
+	return fs
+}
+
+// readDroppedFile reads the content of a File object and appends it to the dropped files.
+// readDroppedFile must be called in a goroutine as this blocks.
+func (i *Input) readDroppedFile(file js.Value) {
+	buf, err := web.AwaitPromise(file.Call("arrayBuffer"))
+	if err != nil {
+		js.Global().Get("console").Call("error", "reading a dropped file failed: "+err.Error())
+		return
+	}
+	a := js.Global().Get("Uint8Array").New(buf)
+	data := make([]byte, a.Length())
+	js.CopyBytesToGo(data, a)
+	i.droppedFiles = append(i.droppedFiles, DroppedFile{
+		Name: file.Get("name").String(),
+		Type: file.Get("type").String(),
+		Data: data,
+	})
+}
+
+func onDrop(e js.Value) {
+	e.Call("preventDefault")
+	t := e.Get("dataTransfer")
+	if !t.Truthy() {
+		return
+	}
+	files := t.Get("files")
+	for idx := 0; idx < files.Length(); idx++ {
+		// Reading a file is asynchronous. Read it in a goroutine as the event handler must not block.
+		go theUI.input.readDroppedFile(files.Index(idx))
+	}
+}
+
+func setDropEventHandlers(v js.Value) {
+	// Cancel dragover to accept drops.
+	v.Call("addEventListener", "dragover", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		e := args[0]
+		e.Call("preventDefault")
+		if t := e.Get("dataTransfer"); t.Truthy() {
+			t.Set("dropEffect", "copy")
+		}
+		return nil
+	}))
+	v.Call("addEventListener", "drop", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		onDrop(args[0])
+		return nil
+	}))
+}
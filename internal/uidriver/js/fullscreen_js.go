@@ -0,0 +1,152 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package js
+
+import (
+	"syscall/js"
+)
+
+// OrientationLock is the orientation to lock the screen to while the canvas is in fullscreen.
+//
+// The values must be synced with ebiten.OrientationLockType.
+type OrientationLock int
+
+const (
+	OrientationLockNone OrientationLock = iota
+	OrientationLockPortrait
+	OrientationLockLandscape
+)
+
+func (o OrientationLock) orientationType() string {
+	switch o {
+	case OrientationLockPortrait:
+		return "portrait"
+	case OrientationLockLandscape:
+		return "landscape"
+	}
+	return ""
+}
+
+func (u *UserInterface) FullscreenOrientationLock() OrientationLock {
+	return u.orientationLock
+}
+
+// SetFullscreenOrientationLock sets the orientation to lock the screen to while the canvas is in fullscreen.
+//
+// Browsers allow to lock the orientation only in fullscreen. The lock is released when the fullscreen exits.
+func (u *UserInterface) SetFullscreenOrientationLock(lock OrientationLock) {
+	if u.orientationLock == lock {
+		return
+	}
+	u.orientationLock = lock
+	if u.IsFullscreen() {
+		lockOrientation(lock)
+	}
+}
+
+func screenOrientation() js.Value {
+	screen := window.Get("screen")
+	if !screen.Truthy() {
+		return js.Undefined()
+	}
+	return screen.Get("orientation")
+}
+
+func lockOrientation(lock OrientationLock) {
+	o := screenOrientation()
+	if !o.Truthy() || !o.Get("lock").Truthy() {
+		return
+	}
+	if lock == OrientationLockNone {
+		o.Call("unlock")
+		return
+	}
+	// Desktop browsers don't support the orientation lock and reject the promise. Ignore the error.
+	o.Call("lock", lock.orientationType()).Call("catch", ignoreErrorFunc)
+}
+
+var ignoreErrorFunc = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+	return nil
+})
+
+// isUserActivationActive reports whether the current timing is in or soon after a user gesture.
+// Browsers require a user gesture to enter fullscreen.
+func isUserActivationActive() bool {
+	a := window.Get("navigator").Get("userActivation")
+	if !a.Truthy() {
+		// The state is unknown. Try to enter fullscreen anyway.
+		return true
+	}
+	return a.Get("isActive").Bool()
+}
+
+var rejectFullscreenFunc = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+	// The request was rejected, probably due to the lack of a user gesture. Retry at the next user gesture.
+	theUI.fullscreenPending = true
+	return nil
+})
+
+// requestFullscreen requests to make the canvas fullscreen.
+// If a user gesture is required, the request is deferred until the next user gesture on the canvas.
+func (u *UserInterface) requestFullscreen() {
+	if !isUserActivationActive() {
+		u.fullscreenPending = true
+		return
+	}
+	u.fullscreenPending = false
+
+	f := canvas.Get("requestFullscreen")
+	if !f.Truthy() {
+		f = canvas.Get("webkitRequestFullscreen")
+	}
+	if !f.Truthy() {
+		return
+	}
+	// The old API returns undefined instead of a promise.
+	if p := f.Call("bind", canvas).Invoke(); p.Truthy() && p.Get("catch").Truthy() {
+		p.Call("catch", rejectFullscreenFunc)
+	}
+}
+
+// flushFullscreenRequest is called in a user gesture's event handler.
+func (u *UserInterface) flushFullscreenRequest() {
+	if !u.fullscreenPending {
+		return
+	}
+	u.requestFullscreen()
+}
+
+func onFullscreenChange() {
+	if theUI.IsFullscreen() {
+		lockOrientation(theUI.orientationLock)
+	} else {
+		// The fullscreen can be exited by the user e.g. pressing the ESC key.
+		theUI.fullscreenPending = false
+		lockOrientation(OrientationLockNone)
+	}
+	// The canvas's size in fullscreen is different from its parent's size.
+	onCanvasParentResize()
+}
+
+func setFullscreenEventHandlers(v js.Value) {
+	v.Call("addEventListener", "fullscreenchange", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		onFullscreenChange()
+		return nil
+	}))
+	v.Call("addEventListener", "webkitfullscreenchange", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		onFullscreenChange()
+		return nil
+	}))
+}
@@ -0,0 +1,59 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !ebitengl && !ebitenwebgl1
+// +build !ebitengl,!ebitenwebgl1
+
+package js
+
+import (
+	"sync"
+
+	"github.com/hajimehoshi/ebiten/v2/internal/diag"
+	"github.com/hajimehoshi/ebiten/v2/internal/driver"
+	"github.com/hajimehoshi/ebiten/v2/internal/graphicsdriver/opengl"
+	"github.com/hajimehoshi/ebiten/v2/internal/graphicsdriver/webgpu"
+	"github.com/hajimehoshi/ebiten/v2/internal/restorable"
+)
+
+var (
+	graphics     driver.Graphics
+	graphicsOnce sync.Once
+)
+
+// initGraphics chooses WebGPU if available, or WebGL otherwise.
+//
+// initGraphics waits for promises to request a WebGPU device. Then, this must not be called in a callback from
+// JavaScript.
+func initGraphics() {
+	if !canvas.Truthy() || !webgpu.IsAvailable() {
+		graphics = opengl.Get()
+		diag.Log(diag.CategoryGraphics, "WebGL is chosen as the graphics backend as WebGPU is not available")
+		return
+	}
+	g := webgpu.Get()
+	if err := g.Initialize(canvas); err != nil {
+		graphics = opengl.Get()
+		diag.Log(diag.CategoryGraphics, "WebGL is chosen as the graphics backend as WebGPU cannot be initialized", "error", err)
+		return
+	}
+	g.SetDeviceLostHandler(restorable.OnContextLost)
+	graphics = g
+	diag.Log(diag.CategoryGraphics, "WebGPU is chosen as the graphics backend")
+}
+
+func (*UserInterface) Graphics() driver.Graphics {
+	graphicsOnce.Do(initGraphics)
+	return graphics
+}
@@ -17,8 +17,10 @@ package js
 import (
 	"encoding/hex"
 	"syscall/js"
+	"time"
 	"unicode"
 
+	"github.com/hajimehoshi/ebiten/v2/internal/diag"
 	"github.com/hajimehoshi/ebiten/v2/internal/driver"
 )
 
@@ -60,6 +62,7 @@ type pos struct {
 }
 
 type gamepad struct {
+	value         js.Value
 	name          string
 	axisNum       int
 	axes          [16]float64
@@ -80,7 +83,13 @@ type Input struct {
 	gamepads           map[driver.GamepadID]gamepad
 	touches            map[driver.TouchID]pos
 	runeBuffer         []rune
+	pastedText         string
+	droppedFiles       []DroppedFile
 	ui                 *UserInterface
+
+	// nonStandardGamepads is a set of the names of the gamepads without the standard mapping, to log them only
+	// once.
+	nonStandardGamepads map[string]struct{}
 }
 
 func (i *Input) CursorPosition() (x, y int) {
@@ -195,6 +204,8 @@ func (i *Input) RuneBuffer() []rune {
 
 func (i *Input) resetForFrame() {
 	i.runeBuffer = nil
+	i.pastedText = ""
+	i.droppedFiles = nil
 	i.wheelX = 0
 	i.wheelY = 0
 }
@@ -286,6 +297,10 @@ func (i *Input) mouseUp(code int) {
 }
 
 func (i *Input) updateGamepads() {
+	if !gamepadEnabled {
+		return
+	}
+
 	nav := js.Global().Get("navigator")
 	if !nav.Truthy() {
 		return
@@ -308,34 +323,60 @@ func (i *Input) updateGamepads() {
 		}
 
 		id := driver.GamepadID(gp.Get("index").Int())
-		g := gamepad{}
+		g := newGamepadFromJS(gp)
 		g.name = gp.Get("id").String()
 
-		axes := gp.Get("axes")
-		axesNum := axes.Get("length").Int()
-		g.axisNum = axesNum
-		for a := 0; a < len(g.axes); a++ {
-			if axesNum <= a {
-				break
+		if m := gp.Get("mapping").String(); m != "standard" {
+			if _, ok := i.nonStandardGamepads[g.name]; !ok {
+				if i.nonStandardGamepads == nil {
+					i.nonStandardGamepads = map[string]struct{}{}
+				}
+				i.nonStandardGamepads[g.name] = struct{}{}
+				diag.Log(diag.CategoryInput, "gamepad without the standard mapping", "name", g.name, "mapping", m)
 			}
-			g.axes[a] = axes.Index(a).Float()
 		}
 
-		buttons := gp.Get("buttons")
-		buttonsNum := buttons.Get("length").Int()
-		g.buttonNum = buttonsNum
-		for b := 0; b < len(g.buttonPressed); b++ {
-			if buttonsNum <= b {
-				break
-			}
-			g.buttonPressed[b] = buttons.Index(b).Get("pressed").Bool()
+		if i.gamepads == nil {
+			i.gamepads = map[driver.GamepadID]gamepad{}
 		}
+		i.gamepads[id] = g
+	}
 
+	// The gamepads of WebXR input sources are not listed in navigator.getGamepads().
+	for idx, gp := range i.ui.xr.gamepads {
+		g := newGamepadFromJS(gp.gamepad)
+		g.name = gp.name
 		if i.gamepads == nil {
 			i.gamepads = map[driver.GamepadID]gamepad{}
 		}
-		i.gamepads[id] = g
+		i.gamepads[driver.GamepadID(xrGamepadIDOffset+idx)] = g
+	}
+}
+
+func newGamepadFromJS(gp js.Value) gamepad {
+	g := gamepad{
+		value: gp,
+	}
+	axes := gp.Get("axes")
+	axesNum := axes.Get("length").Int()
+	g.axisNum = axesNum
+	for a := 0; a < len(g.axes); a++ {
+		if axesNum <= a {
+			break
+		}
+		g.axes[a] = axes.Index(a).Float()
+	}
+
+	buttons := gp.Get("buttons")
+	buttonsNum := buttons.Get("length").Int()
+	g.buttonNum = buttonsNum
+	for b := 0; b < len(g.buttonPressed); b++ {
+		if buttonsNum <= b {
+			break
+		}
+		g.buttonPressed[b] = buttons.Index(b).Get("pressed").Bool()
 	}
+	return g
 }
 
 func (i *Input) updateFromEvent(e js.Value) {
@@ -422,7 +463,8 @@ func (in *Input) updateTouchesFromEvent(e js.Value) {
 		delete(in.touches, k)
 	}
 	for i := 0; i < j.Length(); i++ {
-		jj := j.Call("item", i)
+		// Use Index instead of item so that an array of touches also works, e.g. in a worker.
+		jj := j.Index(i)
 		id := driver.TouchID(jj.Get("identifier").Int())
 		if in.touches == nil {
 			in.touches = map[driver.TouchID]pos{}
@@ -456,6 +498,14 @@ func (i *Input) updateForGo2Cpp() {
 		}
 	}
 
+	i.updateGamepadsForGo2Cpp()
+}
+
+func (i *Input) updateGamepadsForGo2Cpp() {
+	if !gamepadEnabled {
+		return
+	}
+
 	for k := range i.gamepads {
 		delete(i.gamepads, k)
 	}
@@ -492,3 +542,33 @@ func (i *Input) updateForGo2Cpp() {
 		i.gamepads[id] = g
 	}
 }
+
+func (i *Input) VibrateGamepad(id driver.GamepadID, duration time.Duration, strongMagnitude float64, weakMagnitude float64) {
+	if !gamepadEnabled {
+		return
+	}
+
+	g, ok := i.gamepads[id]
+	if !ok {
+		return
+	}
+
+	// vibrationActuator is the standard. hapticActuators is an old API supported only by Firefox.
+	if a := g.value.Get("vibrationActuator"); a.Truthy() {
+		// Desktop browsers might not support vibration and reject the promise. Ignore the error.
+		a.Call("playEffect", "dual-rumble", map[string]interface{}{
+			"startDelay":      0,
+			"duration":        float64(duration) / float64(time.Millisecond),
+			"strongMagnitude": strongMagnitude,
+			"weakMagnitude":   weakMagnitude,
+		}).Call("catch", ignoreErrorFunc)
+		return
+	}
+	if as := g.value.Get("hapticActuators"); as.Truthy() && as.Length() > 0 {
+		v := strongMagnitude
+		if v < weakMagnitude {
+			v = weakMagnitude
+		}
+		as.Index(0).Call("pulse", v, float64(duration)/float64(time.Millisecond)).Call("catch", ignoreErrorFunc)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package js
+
+import (
+	"syscall/js"
+)
+
+// SetOnSuspend sets a function called when the page gets hidden or is being unloaded.
+func (u *UserInterface) SetOnSuspend(f func()) {
+	u.onSuspend = f
+}
+
+// SetOnResume sets a function called when the page gets visible again.
+func (u *UserInterface) SetOnResume(f func()) {
+	u.onResume = f
+}
+
+func (u *UserInterface) IsAudioAliveInBackground() bool {
+	return u.audioAliveInBackground
+}
+
+// SetAudioAliveInBackground sets whether the audio keeps playing while the page is hidden.
+func (u *UserInterface) SetAudioAliveInBackground(alive bool) {
+	u.audioAliveInBackground = alive
+}
+
+// isAudioSuspendedInBackground reports whether the audio should be suspended as the page is hidden.
+func (u *UserInterface) isAudioSuspendedInBackground() bool {
+	return u.pageHidden && !u.audioAliveInBackground
+}
+
+// setPageHidden updates the page's visibility and calls the suspend or resume function.
+//
+// setPageHidden is called in the event handlers synchronously, as the page might be frozen or discarded soon after
+// the page gets hidden.
+func (u *UserInterface) setPageHidden(hidden bool) {
+	if u.pageHidden == hidden {
+		return
+	}
+	u.pageHidden = hidden
+	if hidden {
+		if u.onSuspend != nil {
+			u.onSuspend()
+		}
+		return
+	}
+	if u.onResume != nil {
+		u.onResume()
+	}
+}
+
+// updatePageVisibility checks the page's visibility.
+// This is called regularly, as visibilitychange events are not reliable (#961).
+func (u *UserInterface) updatePageVisibility() {
+	if !document.Truthy() {
+		return
+	}
+	u.setPageHidden(document.Get("hidden").Bool())
+}
+
+func setPageEventHandlers() {
+	document.Call("addEventListener", "visibilitychange", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		theUI.updatePageVisibility()
+		return nil
+	}))
+	// pagehide is fired when the page is being unloaded or stored in the back/forward cache, where
+	// visibilitychange might not be fired.
+	window.Call("addEventListener", "pagehide", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		theUI.setPageHidden(true)
+		return nil
+	}))
+	window.Call("addEventListener", "pageshow", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		theUI.updatePageVisibility()
+		return nil
+	}))
+}
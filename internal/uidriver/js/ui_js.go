@@ -20,7 +20,6 @@ import (
 
 	"github.com/hajimehoshi/ebiten/v2/internal/devicescale"
 	"github.com/hajimehoshi/ebiten/v2/internal/driver"
-	"github.com/hajimehoshi/ebiten/v2/internal/graphicsdriver/opengl"
 	"github.com/hajimehoshi/ebiten/v2/internal/hooks"
 	"github.com/hajimehoshi/ebiten/v2/internal/restorable"
 )
@@ -56,12 +55,23 @@ type UserInterface struct {
 	cursorMode          driver.CursorMode
 	cursorPrevMode      driver.CursorMode
 	cursorShape         driver.CursorShape
+	fullscreenPending   bool
+	orientationLock     OrientationLock
+
+	pendingClipboardText *string
 
 	sizeChanged bool
 	contextLost bool
 
 	lastDeviceScaleFactor float64
 
+	xr xrState
+
+	pageHidden             bool
+	onSuspend              func()
+	onResume               func()
+	audioAliveInBackground bool
+
 	context driver.UIContext
 	input   Input
 }
@@ -91,6 +101,9 @@ var (
 )
 
 func (u *UserInterface) ScreenSizeInFullscreen() (int, int) {
+	if isWorker {
+		return int(theWorkerState.width), int(theWorkerState.height)
+	}
 	return window.Get("innerWidth").Int(), window.Get("innerHeight").Int()
 }
 
@@ -102,11 +115,14 @@ func (u *UserInterface) SetFullscreen(fullscreen bool) {
 		return
 	}
 	if fullscreen {
-		f := canvas.Get("requestFullscreen")
-		if !f.Truthy() {
-			f = canvas.Get("webkitRequestFullscreen")
+		if u.IsFullscreen() {
+			return
 		}
-		f.Call("bind", canvas).Invoke()
+		u.requestFullscreen()
+		return
+	}
+	u.fullscreenPending = false
+	if !u.IsFullscreen() {
 		return
 	}
 	f := document.Get("exitFullscreen")
@@ -154,7 +170,8 @@ func (u *UserInterface) CursorMode() driver.CursorMode {
 }
 
 func (u *UserInterface) SetCursorMode(mode driver.CursorMode) {
-	if !canvas.Truthy() {
+	// The cursor cannot be changed from a worker.
+	if !canvas.Truthy() || isWorker {
 		return
 	}
 	if u.cursorMode == mode {
@@ -191,7 +208,8 @@ func (u *UserInterface) CursorShape() driver.CursorShape {
 }
 
 func (u *UserInterface) SetCursorShape(shape driver.CursorShape) {
-	if !canvas.Truthy() {
+	// The cursor cannot be changed from a worker.
+	if !canvas.Truthy() || isWorker {
 		return
 	}
 	if u.cursorShape == shape {
@@ -205,6 +223,13 @@ func (u *UserInterface) SetCursorShape(shape driver.CursorShape) {
 }
 
 func (u *UserInterface) DeviceScaleFactor() float64 {
+	// In a WebXR session, the screen is rendered to the layer's framebuffer in its pixels.
+	if u.xr.session.Truthy() {
+		return 1
+	}
+	if isWorker {
+		return theWorkerState.devicePixelRatio
+	}
 	return devicescale.GetAt(0, 0)
 }
 
@@ -218,11 +243,12 @@ func (u *UserInterface) updateSize() {
 	if u.sizeChanged {
 		u.sizeChanged = false
 		switch {
+		case u.xr.session.Truthy():
+			u.context.Layout(float64(u.xr.framebufferWidth), float64(u.xr.framebufferHeight))
 		case document.Truthy():
-			body := document.Get("body")
-			bw := body.Get("clientWidth").Float()
-			bh := body.Get("clientHeight").Float()
-			u.context.Layout(bw, bh)
+			u.context.Layout(canvasParentSize())
+		case isWorker:
+			u.context.Layout(theWorkerState.width, theWorkerState.height)
 		case go2cpp.Truthy():
 			w := go2cpp.Get("screenWidth").Float()
 			h := go2cpp.Get("screenHeight").Float()
@@ -245,6 +271,13 @@ func (u *UserInterface) isFocused() bool {
 	if go2cpp.Truthy() {
 		return true
 	}
+	if isWorker {
+		return theWorkerState.focused
+	}
+	// The page might lose the focus while a WebXR session is presenting.
+	if u.xr.session.Truthy() {
+		return true
+	}
 
 	if !document.Call("hasFocus").Bool() {
 		return false
@@ -259,6 +292,14 @@ func (u *UserInterface) update() error {
 	if u.suspended() {
 		return hooks.SuspendAudio()
 	}
+	// While the page is hidden, the game doesn't proceed, like requestAnimationFrame isn't fired.
+	// This matters when vsync is disabled and setTimeout is used.
+	if u.pageHidden {
+		if u.isAudioSuspendedInBackground() {
+			return hooks.SuspendAudio()
+		}
+		return nil
+	}
 	if err := hooks.ResumeAudio(); err != nil {
 		return err
 	}
@@ -295,14 +336,26 @@ func (u *UserInterface) loop(context driver.UIContext) <-chan error {
 			return
 		}
 
-		if err := u.update(); err != nil {
+		// While a WebXR session is active, the game is updated in the session's animation frames instead.
+		if u.xr.session.Truthy() {
+			requestAnimationFrame.Invoke(cf)
+			return
+		}
+
+		// An error in a WebXR session's animation frame is reported after the session ends.
+		err := u.xr.err
+		if err == nil {
+			err = u.update()
+		}
+		if err != nil {
 			close(reqStopAudioCh)
 			<-resStopAudioCh
 
 			errCh <- err
 			return
 		}
-		if u.vsync {
+		// requestAnimationFrame might not be available in a worker.
+		if u.vsync && requestAnimationFrame.Truthy() {
 			requestAnimationFrame.Invoke(cf)
 		} else {
 			setTimeout.Invoke(cf, 0)
@@ -342,7 +395,8 @@ func (u *UserInterface) loop(context driver.UIContext) <-chan error {
 		for {
 			select {
 			case <-t.C:
-				if u.suspended() {
+				u.updatePageVisibility()
+				if u.suspended() || u.isAudioSuspendedInBackground() {
 					if err := hooks.SuspendAudio(); err != nil {
 						errCh <- err
 						return
@@ -363,6 +417,11 @@ func (u *UserInterface) loop(context driver.UIContext) <-chan error {
 }
 
 func init() {
+	if isWorker {
+		initWorker()
+		return
+	}
+
 	// docuemnt is undefined on node.js
 	if !document.Truthy() {
 		return
@@ -390,7 +449,8 @@ func init() {
 	canvas.Set("width", 16)
 	canvas.Set("height", 16)
 
-	document.Get("body").Call("appendChild", canvas)
+	canvasParent = document.Get("body")
+	canvasParent.Call("appendChild", canvas)
 
 	htmlStyle := document.Get("documentElement").Get("style")
 	htmlStyle.Set("height", "100%")
@@ -414,6 +474,12 @@ func init() {
 	canvas.Get("style").Set("outline", "none")
 
 	setCanvasEventHandlers(canvas)
+	setDropEventHandlers(canvas)
+	setFullscreenEventHandlers(document)
+	setPageEventHandlers()
+	initXR()
+	observeCanvasParent()
+	watchDevicePixelRatio()
 
 	// Pointer Lock
 	document.Call("addEventListener", "pointerlockchange", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
@@ -445,10 +511,7 @@ func init() {
 
 func setWindowEventHandlers(v js.Value) {
 	v.Call("addEventListener", "resize", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-		theUI.updateScreenSize()
-		if err := theUI.updateImpl(true); err != nil {
-			panic(err)
-		}
+		onCanvasParentResize()
 		return nil
 	}))
 
@@ -479,6 +542,8 @@ func setCanvasEventHandlers(v js.Value) {
 		e := args[0]
 		e.Call("preventDefault")
 		theUI.input.updateFromEvent(e)
+		theUI.flushFullscreenRequest()
+		theUI.flushClipboardText()
 		return nil
 	}))
 
@@ -496,6 +561,8 @@ func setCanvasEventHandlers(v js.Value) {
 		e := args[0]
 		e.Call("preventDefault")
 		theUI.input.updateFromEvent(e)
+		theUI.flushFullscreenRequest()
+		theUI.flushClipboardText()
 		return nil
 	}))
 	v.Call("addEventListener", "mousemove", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
@@ -525,6 +592,8 @@ func setCanvasEventHandlers(v js.Value) {
 		e := args[0]
 		e.Call("preventDefault")
 		theUI.input.updateFromEvent(e)
+		theUI.flushFullscreenRequest()
+		theUI.flushClipboardText()
 		return nil
 	}))
 	v.Call("addEventListener", "touchmove", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
@@ -534,6 +603,12 @@ func setCanvasEventHandlers(v js.Value) {
 		return nil
 	}))
 
+	// Clipboard
+	v.Call("addEventListener", "paste", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		onPaste(args[0])
+		return nil
+	}))
+
 	// Context menu
 	v.Call("addEventListener", "contextmenu", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
 		e := args[0]
@@ -575,11 +650,14 @@ func (u *UserInterface) RunWithoutMainLoop(context driver.UIContext) {
 func (u *UserInterface) updateScreenSize() {
 	switch {
 	case document.Truthy():
-		body := document.Get("body")
-		bw := int(body.Get("clientWidth").Float() * u.DeviceScaleFactor())
-		bh := int(body.Get("clientHeight").Float() * u.DeviceScaleFactor())
+		w, h := canvasParentSize()
+		bw := int(w * u.DeviceScaleFactor())
+		bh := int(h * u.DeviceScaleFactor())
 		canvas.Set("width", bw)
 		canvas.Set("height", bh)
+	case isWorker:
+		canvas.Set("width", int(theWorkerState.width*u.DeviceScaleFactor()))
+		canvas.Set("height", int(theWorkerState.height*u.DeviceScaleFactor()))
 	case go2cpp.Truthy():
 		// TODO: Implement this
 	}
@@ -590,6 +668,10 @@ func (u *UserInterface) SetScreenTransparent(transparent bool) {
 	if u.running {
 		panic("js: SetScreenTransparent can't be called after the main loop starts")
 	}
+	// In a worker, the page's style determines the background.
+	if !document.Truthy() {
+		return
+	}
 
 	bodyStyle := document.Get("body").Get("style")
 	if transparent {
@@ -600,10 +682,20 @@ func (u *UserInterface) SetScreenTransparent(transparent bool) {
 }
 
 func (u *UserInterface) IsScreenTransparent() bool {
+	if !document.Truthy() {
+		return false
+	}
 	bodyStyle := document.Get("body").Get("style")
 	return bodyStyle.Get("backgroundColor").Equal(stringTransparent)
 }
 
+func (u *UserInterface) UpdateInput() {
+	// Keyboard, mouse and touch events are dispatched asynchronously and cannot be sampled here.
+	// Only gamepads are polled.
+	u.input.updateGamepads()
+	u.input.updateForGo2Cpp()
+}
+
 func (u *UserInterface) ResetForFrame() {
 	u.updateSize()
 	u.input.resetForFrame()
@@ -623,7 +715,3 @@ func (u *UserInterface) Input() driver.Input {
 func (u *UserInterface) Window() driver.Window {
 	return nil
 }
-
-func (*UserInterface) Graphics() driver.Graphics {
-	return opengl.Get()
-}
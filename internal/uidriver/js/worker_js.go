@@ -0,0 +1,106 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package js
+
+import (
+	"syscall/js"
+
+	"github.com/hajimehoshi/ebiten/v2/internal/graphicsdriver/opengl"
+)
+
+// isWorker reports whether the game runs in a dedicated worker with an OffscreenCanvas.
+//
+// In a worker, the page sends messages to the worker instead of the DOM events. The messages are:
+//
+//     {type: 'ebiten:init', canvas, width, height, devicePixelRatio, focused}
+//     {type: 'ebiten:resize', width, height, devicePixelRatio}
+//     {type: 'ebiten:focus', focused}
+//     {type: 'ebiten:event', event}
+//
+// canvas is an OffscreenCanvas transferred from the page. width and height are the canvas's size in CSS pixels.
+// event is a copy of a DOM event's properties that Ebiten uses. targetTouches must be an array.
+var isWorker = !document.Truthy() && js.Global().Get("WorkerGlobalScope").Truthy()
+
+type workerState struct {
+	width            float64
+	height           float64
+	devicePixelRatio float64
+	focused          bool
+}
+
+var theWorkerState = workerState{
+	devicePixelRatio: 1,
+}
+
+func (w *workerState) update(data js.Value) {
+	if v := data.Get("width"); v.Type() == js.TypeNumber {
+		w.width = v.Float()
+	}
+	if v := data.Get("height"); v.Type() == js.TypeNumber {
+		w.height = v.Float()
+	}
+	if v := data.Get("devicePixelRatio"); v.Type() == js.TypeNumber && v.Float() > 0 {
+		w.devicePixelRatio = v.Float()
+	}
+	if v := data.Get("focused"); v.Type() == js.TypeBoolean {
+		w.focused = v.Bool()
+	}
+}
+
+func initWorker() {
+	ch := make(chan struct{})
+	// The page does preventDefault for the original events. Calling preventDefault on the copied events does nothing.
+	preventDefault := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		return nil
+	})
+	js.Global().Call("addEventListener", "message", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		data := args[0].Get("data")
+		if !data.Truthy() || data.Get("type").Type() != js.TypeString {
+			return nil
+		}
+		switch data.Get("type").String() {
+		case "ebiten:init":
+			if canvas.Truthy() {
+				return nil
+			}
+			canvas = data.Get("canvas")
+			opengl.Get().SetCanvas(canvas)
+			theWorkerState.update(data)
+			close(ch)
+		case "ebiten:resize":
+			theWorkerState.update(data)
+			theUI.updateScreenSize()
+			if !theUI.running {
+				return nil
+			}
+			// WebGPU waits for promises, which cannot be done in a callback from JavaScript. The screen is
+			// updated at the next frame.
+			if !theUI.Graphics().IsGL() {
+				return nil
+			}
+			if err := theUI.updateImpl(true); err != nil {
+				panic(err)
+			}
+		case "ebiten:focus":
+			theWorkerState.update(data)
+		case "ebiten:event":
+			e := data.Get("event")
+			e.Set("preventDefault", preventDefault)
+			theUI.input.updateFromEvent(e)
+		}
+		return nil
+	}))
+	<-ch
+}
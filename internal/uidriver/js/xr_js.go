@@ -0,0 +1,339 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package js
+
+import (
+	"errors"
+	"fmt"
+	"syscall/js"
+
+	"github.com/hajimehoshi/ebiten/v2/internal/driver"
+	"github.com/hajimehoshi/ebiten/v2/internal/graphicsdriver/opengl"
+	"github.com/hajimehoshi/ebiten/v2/internal/web"
+)
+
+// XRSessionMode is a mode of a WebXR session.
+type XRSessionMode int
+
+const (
+	XRSessionModeImmersiveVR XRSessionMode = iota
+	XRSessionModeImmersiveAR
+)
+
+func (m XRSessionMode) String() string {
+	switch m {
+	case XRSessionModeImmersiveVR:
+		return "immersive-vr"
+	case XRSessionModeImmersiveAR:
+		return "immersive-ar"
+	}
+	panic(fmt.Sprintf("js: invalid XRSessionMode: %d", m))
+}
+
+// XREye and XRHandedness values must be synced with the xr package.
+const (
+	xrNone = iota
+	xrLeft
+	xrRight
+)
+
+func xrSideFromString(s string) int {
+	switch s {
+	case "left":
+		return xrLeft
+	case "right":
+		return xrRight
+	}
+	return xrNone
+}
+
+// XRPose is a position and an orientation (a quaternion) in the reference space.
+type XRPose struct {
+	Position    [3]float64
+	Orientation [4]float64
+}
+
+func xrPoseFromTransform(t js.Value) XRPose {
+	var p XRPose
+	pos := t.Get("position")
+	p.Position = [3]float64{pos.Get("x").Float(), pos.Get("y").Float(), pos.Get("z").Float()}
+	o := t.Get("orientation")
+	p.Orientation = [4]float64{o.Get("x").Float(), o.Get("y").Float(), o.Get("z").Float(), o.Get("w").Float()}
+	return p
+}
+
+// XRView is a view for one eye.
+//
+// The viewport is in the screen framebuffer's pixels, and its origin is the upper-left corner.
+type XRView struct {
+	Eye              int
+	X                int
+	Y                int
+	Width            int
+	Height           int
+	ProjectionMatrix [16]float64
+	Transform        XRPose
+}
+
+// XRInputSource is an input source like a controller.
+type XRInputSource struct {
+	Handedness    int
+	TargetRayPose XRPose
+	GripPose      XRPose
+	HasGripPose   bool
+	GamepadID     driver.GamepadID
+	HasGamepad    bool
+}
+
+// xrGamepadIDOffset is the offset of the gamepad IDs for the input sources.
+// This must not conflict with the indices of navigator.getGamepads().
+const xrGamepadIDOffset = 0x100
+
+type xrGamepad struct {
+	gamepad js.Value
+	name    string
+}
+
+type xrState struct {
+	supported [2]bool
+
+	session           js.Value
+	refSpace          js.Value
+	frameFunc         js.Func
+	framebufferWidth  int
+	framebufferHeight int
+	views             []XRView
+	inputSources      []XRInputSource
+	gamepads          []xrGamepad
+
+	// err is an error in a session's animation frame. err is reported in the main loop.
+	err error
+}
+
+func xrSystem() js.Value {
+	nav := js.Global().Get("navigator")
+	if !nav.Truthy() {
+		return js.Undefined()
+	}
+	return nav.Get("xr")
+}
+
+func initXR() {
+	xr := xrSystem()
+	if !xr.Truthy() {
+		return
+	}
+	for _, mode := range []XRSessionMode{XRSessionModeImmersiveVR, XRSessionModeImmersiveAR} {
+		mode := mode
+		var f js.Func
+		f = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			theUI.xr.supported[mode] = args[0].Bool()
+			f.Release()
+			return nil
+		})
+		xr.Call("isSessionSupported", mode.String()).Call("then", f)
+	}
+	theUI.xr.frameFunc = js.FuncOf(theUI.onXRFrame)
+}
+
+// IsXRSessionSupported reports whether the session mode is supported.
+//
+// IsXRSessionSupported returns false until the browser answers the query.
+func (u *UserInterface) IsXRSessionSupported(mode XRSessionMode) bool {
+	return u.xr.supported[mode]
+}
+
+func (u *UserInterface) IsXRSessionActive() bool {
+	return u.xr.session.Truthy()
+}
+
+// RequestXRSession starts a WebXR session. RequestXRSession blocks until the session starts.
+//
+// While the session is active, the game is updated and rendered in the session's animation frames, and the screen
+// is rendered to the session's layer instead of the canvas.
+//
+// Browsers require a user gesture to start a session.
+func (u *UserInterface) RequestXRSession(mode XRSessionMode) error {
+	xr := xrSystem()
+	if !xr.Truthy() {
+		return errors.New("js: WebXR is not available")
+	}
+	if u.xr.session.Truthy() {
+		return errors.New("js: a WebXR session is already active")
+	}
+	if !u.Graphics().IsGL() {
+		return errors.New("js: WebXR requires WebGL; use the ebitengl build tag")
+	}
+	gl := opengl.Get().WebGLContext()
+	if !gl.Truthy() {
+		return errors.New("js: the graphics context is not initialized yet")
+	}
+
+	options := map[string]interface{}{
+		"optionalFeatures": []interface{}{"local-floor"},
+	}
+	session, err := web.AwaitPromise(xr.Call("requestSession", mode.String(), options))
+	if err != nil {
+		return fmt.Errorf("js: requestSession failed: %v", err)
+	}
+
+	if gl.Get("makeXRCompatible").Truthy() {
+		if _, err := web.AwaitPromise(gl.Call("makeXRCompatible")); err != nil {
+			session.Call("end")
+			return fmt.Errorf("js: makeXRCompatible failed: %v", err)
+		}
+	}
+	layer := js.Global().Get("XRWebGLLayer").New(session, gl)
+	session.Call("updateRenderState", map[string]interface{}{
+		"baseLayer": layer,
+	})
+
+	refSpace, err := web.AwaitPromise(session.Call("requestReferenceSpace", "local-floor"))
+	if err != nil {
+		refSpace, err = web.AwaitPromise(session.Call("requestReferenceSpace", "local"))
+		if err != nil {
+			session.Call("end")
+			return fmt.Errorf("js: requestReferenceSpace failed: %v", err)
+		}
+	}
+
+	var onEnd js.Func
+	onEnd = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		u.onXRSessionEnd()
+		onEnd.Release()
+		return nil
+	})
+	session.Call("addEventListener", "end", onEnd)
+
+	u.xr.session = session
+	u.xr.refSpace = refSpace
+	u.xr.framebufferWidth = 0
+	u.xr.framebufferHeight = 0
+	session.Call("requestAnimationFrame", u.xr.frameFunc)
+	return nil
+}
+
+// EndXRSession ends the current WebXR session if exists.
+func (u *UserInterface) EndXRSession() {
+	if !u.xr.session.Truthy() {
+		return
+	}
+	u.xr.session.Call("end")
+}
+
+func (u *UserInterface) onXRSessionEnd() {
+	u.xr.session = js.Undefined()
+	u.xr.refSpace = js.Undefined()
+	u.xr.views = nil
+	u.xr.inputSources = nil
+	u.xr.gamepads = nil
+	opengl.Get().SetScreenFramebuffer(js.Null())
+	u.updateScreenSize()
+}
+
+func (u *UserInterface) onXRFrame(this js.Value, args []js.Value) interface{} {
+	frame := args[1]
+	session := frame.Get("session")
+	if !session.Equal(u.xr.session) {
+		return nil
+	}
+	session.Call("requestAnimationFrame", u.xr.frameFunc)
+	if u.xr.err != nil {
+		return nil
+	}
+
+	layer := session.Get("renderState").Get("baseLayer")
+	w := layer.Get("framebufferWidth").Int()
+	h := layer.Get("framebufferHeight").Int()
+	if u.xr.framebufferWidth != w || u.xr.framebufferHeight != h {
+		u.xr.framebufferWidth = w
+		u.xr.framebufferHeight = h
+		u.sizeChanged = true
+	}
+
+	u.xr.views = u.xr.views[:0]
+	if pose := frame.Call("getViewerPose", u.xr.refSpace); pose.Truthy() {
+		views := pose.Get("views")
+		for i := 0; i < views.Length(); i++ {
+			v := views.Index(i)
+			vp := layer.Call("getViewport", v)
+			xv := XRView{
+				Eye:       xrSideFromString(v.Get("eye").String()),
+				X:         vp.Get("x").Int(),
+				Width:     vp.Get("width").Int(),
+				Height:    vp.Get("height").Int(),
+				Transform: xrPoseFromTransform(v.Get("transform")),
+			}
+			// The origin of a WebGL viewport is the lower-left corner.
+			xv.Y = h - vp.Get("y").Int() - xv.Height
+			m := v.Get("projectionMatrix")
+			for j := range xv.ProjectionMatrix {
+				xv.ProjectionMatrix[j] = m.Index(j).Float()
+			}
+			u.xr.views = append(u.xr.views, xv)
+		}
+	}
+
+	u.xr.inputSources = u.xr.inputSources[:0]
+	u.xr.gamepads = u.xr.gamepads[:0]
+	sources := session.Get("inputSources")
+	for i := 0; i < sources.Length(); i++ {
+		s := sources.Index(i)
+		handedness := s.Get("handedness").String()
+		src := XRInputSource{
+			Handedness: xrSideFromString(handedness),
+		}
+		if p := frame.Call("getPose", s.Get("targetRaySpace"), u.xr.refSpace); p.Truthy() {
+			src.TargetRayPose = xrPoseFromTransform(p.Get("transform"))
+		}
+		if gs := s.Get("gripSpace"); gs.Truthy() {
+			if p := frame.Call("getPose", gs, u.xr.refSpace); p.Truthy() {
+				src.GripPose = xrPoseFromTransform(p.Get("transform"))
+				src.HasGripPose = true
+			}
+		}
+		if gp := s.Get("gamepad"); gp.Truthy() {
+			name := "WebXR " + handedness
+			if profiles := s.Get("profiles"); profiles.Length() > 0 {
+				name = profiles.Index(0).String()
+			}
+			src.GamepadID = driver.GamepadID(xrGamepadIDOffset + len(u.xr.gamepads))
+			src.HasGamepad = true
+			u.xr.gamepads = append(u.xr.gamepads, xrGamepad{
+				gamepad: gp,
+				name:    name,
+			})
+		}
+		u.xr.inputSources = append(u.xr.inputSources, src)
+	}
+
+	// The layer's framebuffer is valid only in this callback. Render the game synchronously.
+	opengl.Get().SetScreenFramebuffer(layer.Get("framebuffer"))
+	if err := u.updateImpl(false); err != nil {
+		u.xr.err = err
+		session.Call("end")
+	}
+	return nil
+}
+
+// XRViews returns the views of the current WebXR frame.
+func (u *UserInterface) XRViews() []XRView {
+	return append([]XRView(nil), u.xr.views...)
+}
+
+// XRInputSources returns the input sources of the current WebXR frame.
+func (u *UserInterface) XRInputSources() []XRInputSource {
+	return append([]XRInputSource(nil), u.xr.inputSources...)
+}
@@ -0,0 +1,59 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build android || ios
+// +build android ios
+
+package mobile
+
+// SetExternalDisplaySize is called from mobile/ebitenmobileview when an external display is connected, disconnected
+// or changed. The unit is pixels. (0, 0) means no external display is connected.
+//
+// SetExternalDisplaySize is concurrent safe.
+func (u *UserInterface) SetExternalDisplaySize(width, height int) {
+	u.m.Lock()
+	defer u.m.Unlock()
+	if u.externalDisplayWidth == width && u.externalDisplayHeight == height {
+		return
+	}
+	u.externalDisplayWidth = width
+	u.externalDisplayHeight = height
+	u.externalDisplayChanged = true
+}
+
+// ExternalDisplaySize is concurrent safe.
+func (u *UserInterface) ExternalDisplaySize() (width, height int) {
+	u.m.RLock()
+	defer u.m.RUnlock()
+	return u.externalDisplayWidth, u.externalDisplayHeight
+}
+
+// SetOnExternalDisplayChange is concurrent safe.
+func (u *UserInterface) SetOnExternalDisplayChange(f func()) {
+	u.m.Lock()
+	defer u.m.Unlock()
+	u.onExternalDisplayChange = f
+}
+
+func (u *UserInterface) handleExternalDisplayChangeIfNeeded() {
+	u.m.Lock()
+	changed := u.externalDisplayChanged
+	u.externalDisplayChanged = false
+	f := u.onExternalDisplayChange
+	u.m.Unlock()
+
+	if changed && f != nil {
+		f()
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build android || ios
+// +build android ios
+
+package mobile
+
+// SetFilesDir is called from mobile/ebitenmobileview with the directory for the application's private files.
+//
+// SetFilesDir is concurrent safe.
+func (u *UserInterface) SetFilesDir(dir string) {
+	u.m.Lock()
+	defer u.m.Unlock()
+	u.filesDir = dir
+}
+
+// FilesDir returns the directory for the application's private files.
+//
+// FilesDir returns an empty string if the platform has not reported the directory yet. On iOS, the directory is
+// never reported.
+//
+// FilesDir is concurrent safe.
+func (u *UserInterface) FilesDir() string {
+	u.m.RLock()
+	defer u.m.RUnlock()
+	return u.filesDir
+}
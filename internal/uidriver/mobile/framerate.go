@@ -0,0 +1,77 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build android || ios
+// +build android ios
+
+package mobile
+
+// FrameRateController is implemented by the platform side to change the frame rate of the rendering loop.
+type FrameRateController interface {
+	SetPreferredFrameRate(fps int)
+}
+
+// SetFrameRateController is called from mobile/ebitenmobileview.
+//
+// SetFrameRateController is concurrent safe.
+func (u *UserInterface) SetFrameRateController(controller FrameRateController) {
+	u.m.Lock()
+	u.frameRateController = controller
+	fps := u.preferredFrameRate
+	u.m.Unlock()
+
+	// Apply the frame rate that was specified before the controller was set.
+	if controller != nil && fps != 0 {
+		controller.SetPreferredFrameRate(fps)
+	}
+}
+
+// PreferredFrameRate is concurrent safe.
+func (u *UserInterface) PreferredFrameRate() int {
+	u.m.RLock()
+	defer u.m.RUnlock()
+	return u.preferredFrameRate
+}
+
+// SetPreferredFrameRate is concurrent safe.
+func (u *UserInterface) SetPreferredFrameRate(fps int) {
+	u.m.Lock()
+	if u.preferredFrameRate == fps {
+		u.m.Unlock()
+		return
+	}
+	u.preferredFrameRate = fps
+	c := u.frameRateController
+	u.m.Unlock()
+
+	if c != nil {
+		c.SetPreferredFrameRate(fps)
+	}
+}
+
+// SetDisplayRefreshRate is called from mobile/ebitenmobileview when the refresh rate of the display changes.
+//
+// SetDisplayRefreshRate is concurrent safe.
+func (u *UserInterface) SetDisplayRefreshRate(hz float64) {
+	u.m.Lock()
+	u.displayRefreshRate = hz
+	u.m.Unlock()
+}
+
+// DisplayRefreshRate is concurrent safe.
+func (u *UserInterface) DisplayRefreshRate() float64 {
+	u.m.RLock()
+	defer u.m.RUnlock()
+	return u.displayRefreshRate
+}
@@ -18,6 +18,8 @@
 package mobile
 
 import (
+	"time"
+
 	"github.com/hajimehoshi/ebiten/v2/internal/driver"
 )
 
@@ -212,3 +214,7 @@ func (i *Input) update(keys map[driver.Key]struct{}, runes []rune, touches []*To
 func (i *Input) resetForFrame() {
 	i.runes = nil
 }
+
+func (i *Input) VibrateGamepad(id driver.GamepadID, duration time.Duration, strongMagnitude float64, weakMagnitude float64) {
+	// Not implemented yet.
+}
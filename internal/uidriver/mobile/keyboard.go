@@ -0,0 +1,105 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build android || ios
+// +build android ios
+
+package mobile
+
+// SoftKeyboardController is implemented by the platform side to show and hide the on-screen keyboard for
+// an invisible text field.
+type SoftKeyboardController interface {
+	ShowSoftKeyboard(text string)
+	HideSoftKeyboard()
+}
+
+type softKeyboardText struct {
+	text             string
+	compositionStart int
+	compositionEnd   int
+}
+
+// SetSoftKeyboardController is called from mobile/ebitenmobileview.
+//
+// SetSoftKeyboardController is concurrent safe.
+func (u *UserInterface) SetSoftKeyboardController(controller SoftKeyboardController) {
+	u.m.Lock()
+	defer u.m.Unlock()
+	u.softKeyboardController = controller
+}
+
+// ShowSoftKeyboard is concurrent safe.
+func (u *UserInterface) ShowSoftKeyboard(text string) {
+	u.m.Lock()
+	u.softKeyboardText = softKeyboardText{
+		text:             text,
+		compositionStart: len(text),
+		compositionEnd:   len(text),
+	}
+	c := u.softKeyboardController
+	u.m.Unlock()
+
+	if c != nil {
+		c.ShowSoftKeyboard(text)
+	}
+}
+
+// HideSoftKeyboard is concurrent safe.
+func (u *UserInterface) HideSoftKeyboard() {
+	u.m.RLock()
+	c := u.softKeyboardController
+	u.m.RUnlock()
+
+	if c != nil {
+		c.HideSoftKeyboard()
+	}
+}
+
+// SetSoftKeyboardVisible is called from mobile/ebitenmobileview when the on-screen keyboard is shown or hidden.
+//
+// SetSoftKeyboardVisible is concurrent safe.
+func (u *UserInterface) SetSoftKeyboardVisible(visible bool) {
+	u.m.Lock()
+	defer u.m.Unlock()
+	u.softKeyboardVisible = visible
+}
+
+// IsSoftKeyboardVisible is concurrent safe.
+func (u *UserInterface) IsSoftKeyboardVisible() bool {
+	u.m.RLock()
+	defer u.m.RUnlock()
+	return u.softKeyboardVisible
+}
+
+// SetSoftKeyboardText is called from mobile/ebitenmobileview when the text of the invisible text field changes.
+// compositionStart and compositionEnd are byte offsets in text.
+//
+// SetSoftKeyboardText is concurrent safe.
+func (u *UserInterface) SetSoftKeyboardText(text string, compositionStart, compositionEnd int) {
+	u.m.Lock()
+	defer u.m.Unlock()
+	u.softKeyboardText = softKeyboardText{
+		text:             text,
+		compositionStart: compositionStart,
+		compositionEnd:   compositionEnd,
+	}
+}
+
+// SoftKeyboardText is concurrent safe.
+func (u *UserInterface) SoftKeyboardText() (text string, compositionStart, compositionEnd int) {
+	u.m.RLock()
+	defer u.m.RUnlock()
+	t := u.softKeyboardText
+	return t.text, t.compositionStart, t.compositionEnd
+}
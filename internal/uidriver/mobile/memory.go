@@ -0,0 +1,82 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build android || ios
+// +build android ios
+
+package mobile
+
+import (
+	"runtime/debug"
+
+	"github.com/hajimehoshi/ebiten/v2/internal/hooks"
+)
+
+// NotifyLowMemory is called from mobile/ebitenmobileview when the OS warns that the memory is low.
+//
+// The notification is handled at the next update on the game's goroutine.
+//
+// NotifyLowMemory is concurrent safe.
+func (u *UserInterface) NotifyLowMemory() {
+	u.m.Lock()
+	defer u.m.Unlock()
+	u.lowMemory = true
+}
+
+// SetOnLowMemory is concurrent safe.
+func (u *UserInterface) SetOnLowMemory(f func()) {
+	u.m.Lock()
+	defer u.m.Unlock()
+	u.onLowMemory = f
+}
+
+// IsCacheEvictionOnLowMemoryDisabled is concurrent safe.
+func (u *UserInterface) IsCacheEvictionOnLowMemoryDisabled() bool {
+	u.m.RLock()
+	defer u.m.RUnlock()
+	return u.cacheEvictionOnLowMemoryDisabled
+}
+
+// SetCacheEvictionOnLowMemoryDisabled is concurrent safe.
+func (u *UserInterface) SetCacheEvictionOnLowMemoryDisabled(disabled bool) {
+	u.m.Lock()
+	defer u.m.Unlock()
+	u.cacheEvictionOnLowMemoryDisabled = disabled
+}
+
+func (u *UserInterface) handleLowMemoryIfNeeded() error {
+	u.m.Lock()
+	lowMemory := u.lowMemory
+	u.lowMemory = false
+	evict := !u.cacheEvictionOnLowMemoryDisabled
+	f := u.onLowMemory
+	u.m.Unlock()
+
+	if !lowMemory {
+		return nil
+	}
+
+	if evict {
+		if err := hooks.RunLowMemoryHooks(); err != nil {
+			return err
+		}
+	}
+	if f != nil {
+		f()
+	}
+
+	// Return the released memory to the OS as soon as possible.
+	debug.FreeOSMemory()
+	return nil
+}
@@ -0,0 +1,127 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build android || ios
+// +build android ios
+
+package mobile
+
+// Orientation is a bit set of interface orientations.
+//
+// The values must be synced with mobile.Orientation and the generated Java/Objective-C code in cmd/ebitenmobile.
+type Orientation int
+
+const (
+	OrientationPortrait Orientation = 1 << iota
+	OrientationPortraitUpsideDown
+	OrientationLandscapeLeft
+	OrientationLandscapeRight
+)
+
+const (
+	orientationPortraits  = OrientationPortrait | OrientationPortraitUpsideDown
+	orientationLandscapes = OrientationLandscapeLeft | OrientationLandscapeRight
+)
+
+// maxOrientationWaitCount is the maximum number of layout attempts to wait for the outside size consistent with
+// a new orientation.
+const maxOrientationWaitCount = 30
+
+// OrientationController is implemented by the platform side (e.g. a view controller or an Android view).
+type OrientationController interface {
+	SetAllowedOrientations(orientations int)
+}
+
+// SetOrientationController is called from mobile/ebitenmobileview.
+//
+// SetOrientationController is concurrent safe.
+func (u *UserInterface) SetOrientationController(controller OrientationController) {
+	u.m.Lock()
+	u.orientationController = controller
+	allowed := u.allowedOrientations
+	u.m.Unlock()
+
+	// Apply the orientations that were specified before the controller was set.
+	if controller != nil && allowed != 0 {
+		controller.SetAllowedOrientations(int(allowed))
+	}
+}
+
+// SetOrientation is called from mobile/ebitenmobileview when the platform's interface orientation changes.
+//
+// The new orientation is not adopted until the outside size that matches with the orientation is given.
+// This prevents a game from observing an intermediate state in a rotation transition.
+//
+// SetOrientation is concurrent safe.
+func (u *UserInterface) SetOrientation(orientation Orientation) {
+	u.m.Lock()
+	defer u.m.Unlock()
+	if u.nextOrientation == orientation {
+		return
+	}
+	u.nextOrientation = orientation
+	u.sizeChanged = true
+}
+
+// Orientation returns the current interface orientation.
+//
+// Orientation returns 0 if the platform has not reported the orientation yet.
+//
+// Orientation is concurrent safe.
+func (u *UserInterface) Orientation() Orientation {
+	u.m.RLock()
+	defer u.m.RUnlock()
+	return u.orientation
+}
+
+// AllowedOrientations returns the orientations that the view is allowed to rotate to.
+// 0 means the platform's default.
+//
+// AllowedOrientations is concurrent safe.
+func (u *UserInterface) AllowedOrientations() Orientation {
+	u.m.RLock()
+	defer u.m.RUnlock()
+	return u.allowedOrientations
+}
+
+// SetAllowedOrientations sets the orientations that the view is allowed to rotate to.
+//
+// SetAllowedOrientations is concurrent safe.
+func (u *UserInterface) SetAllowedOrientations(orientations Orientation) {
+	u.m.Lock()
+	if u.allowedOrientations == orientations {
+		u.m.Unlock()
+		return
+	}
+	u.allowedOrientations = orientations
+	c := u.orientationController
+	u.m.Unlock()
+
+	if c != nil {
+		c.SetAllowedOrientations(int(orientations))
+	}
+}
+
+// isOrientationConsistentWithSize reports whether the given size can be the one after rotating to the given
+// orientation.
+func isOrientationConsistentWithSize(orientation Orientation, width, height float64) bool {
+	switch {
+	case orientation&orientationPortraits != 0:
+		return width <= height
+	case orientation&orientationLandscapes != 0:
+		return width >= height
+	}
+	// The orientation is unknown.
+	return true
+}
@@ -0,0 +1,115 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build android || ios
+// +build android ios
+
+package mobile
+
+// ThermalState represents the thermal state of the device.
+//
+// The values must be synced with mobile.ThermalState and the generated Java/Objective-C code in cmd/ebitenmobile.
+type ThermalState int
+
+const (
+	ThermalStateUnknown ThermalState = iota
+	ThermalStateNominal
+	ThermalStateFair
+	ThermalStateSerious
+	ThermalStateCritical
+)
+
+// GameMode represents the game mode that the user selects on Android.
+//
+// The values must be synced with mobile.GameMode.
+type GameMode int
+
+// SetThermalState is called from mobile/ebitenmobileview when the thermal state changes.
+//
+// SetThermalState is concurrent safe.
+func (u *UserInterface) SetThermalState(state ThermalState) {
+	u.m.Lock()
+	defer u.m.Unlock()
+	if u.thermalState == state {
+		return
+	}
+	u.thermalState = state
+	u.powerStateChanged = true
+}
+
+// ThermalState is concurrent safe.
+func (u *UserInterface) ThermalState() ThermalState {
+	u.m.RLock()
+	defer u.m.RUnlock()
+	return u.thermalState
+}
+
+// SetLowPowerMode is called from mobile/ebitenmobileview when the low power mode is switched.
+//
+// SetLowPowerMode is concurrent safe.
+func (u *UserInterface) SetLowPowerMode(lowPowerMode bool) {
+	u.m.Lock()
+	defer u.m.Unlock()
+	if u.lowPowerMode == lowPowerMode {
+		return
+	}
+	u.lowPowerMode = lowPowerMode
+	u.powerStateChanged = true
+}
+
+// IsLowPowerMode is concurrent safe.
+func (u *UserInterface) IsLowPowerMode() bool {
+	u.m.RLock()
+	defer u.m.RUnlock()
+	return u.lowPowerMode
+}
+
+// SetGameMode is called from mobile/ebitenmobileview when the game mode is known or changed.
+//
+// SetGameMode is concurrent safe.
+func (u *UserInterface) SetGameMode(mode GameMode) {
+	u.m.Lock()
+	defer u.m.Unlock()
+	if u.gameMode == mode {
+		return
+	}
+	u.gameMode = mode
+	u.powerStateChanged = true
+}
+
+// GameMode is concurrent safe.
+func (u *UserInterface) GameMode() GameMode {
+	u.m.RLock()
+	defer u.m.RUnlock()
+	return u.gameMode
+}
+
+// SetOnPowerStateChange is concurrent safe.
+func (u *UserInterface) SetOnPowerStateChange(f func()) {
+	u.m.Lock()
+	defer u.m.Unlock()
+	u.onPowerStateChange = f
+}
+
+func (u *UserInterface) handlePowerStateChangeIfNeeded() {
+	u.m.Lock()
+	changed := u.powerStateChanged
+	u.powerStateChanged = false
+	f := u.onPowerStateChange
+	u.m.Unlock()
+
+	if changed && f != nil {
+		f()
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build android || ios
+// +build android ios
+
+package mobile
+
+import (
+	"fmt"
+)
+
+// ShareController is implemented by the platform side to show the share sheet.
+type ShareController interface {
+	Share(data []byte, name string, mimeType string)
+}
+
+// SetShareController is called from mobile/ebitenmobileview.
+//
+// SetShareController is concurrent safe.
+func (u *UserInterface) SetShareController(controller ShareController) {
+	u.m.Lock()
+	defer u.m.Unlock()
+	u.shareController = controller
+}
+
+// Share is concurrent safe.
+func (u *UserInterface) Share(data []byte, name string, mimeType string) error {
+	u.m.RLock()
+	c := u.shareController
+	u.m.RUnlock()
+
+	if c == nil {
+		return fmt.Errorf("mobile: the view is not available to share %s", name)
+	}
+	c.Share(data, name, mimeType)
+	return nil
+}
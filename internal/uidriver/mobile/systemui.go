@@ -0,0 +1,128 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build android || ios
+// +build android ios
+
+package mobile
+
+// SystemUIController is implemented by the platform side to control system bars.
+type SystemUIController interface {
+	SetImmersiveMode(immersive bool)
+	SetEdgeToEdge(edgeToEdge bool)
+}
+
+type insets struct {
+	left   float64
+	top    float64
+	right  float64
+	bottom float64
+}
+
+// SetSystemUIController is called from mobile/ebitenmobileview.
+//
+// SetSystemUIController is concurrent safe.
+func (u *UserInterface) SetSystemUIController(controller SystemUIController) {
+	u.m.Lock()
+	u.systemUIController = controller
+	immersive := u.immersive
+	edgeToEdge := u.edgeToEdge
+	u.m.Unlock()
+
+	// Apply the states that were specified before the controller was set.
+	if controller != nil {
+		if immersive {
+			controller.SetImmersiveMode(true)
+		}
+		if edgeToEdge {
+			controller.SetEdgeToEdge(true)
+		}
+	}
+}
+
+// IsImmersiveMode is concurrent safe.
+func (u *UserInterface) IsImmersiveMode() bool {
+	u.m.RLock()
+	defer u.m.RUnlock()
+	return u.immersive
+}
+
+// SetImmersiveMode is concurrent safe.
+func (u *UserInterface) SetImmersiveMode(immersive bool) {
+	u.m.Lock()
+	if u.immersive == immersive {
+		u.m.Unlock()
+		return
+	}
+	u.immersive = immersive
+	c := u.systemUIController
+	u.m.Unlock()
+
+	if c != nil {
+		c.SetImmersiveMode(immersive)
+	}
+}
+
+// IsEdgeToEdge is concurrent safe.
+func (u *UserInterface) IsEdgeToEdge() bool {
+	u.m.RLock()
+	defer u.m.RUnlock()
+	return u.edgeToEdge
+}
+
+// SetEdgeToEdge is concurrent safe.
+func (u *UserInterface) SetEdgeToEdge(edgeToEdge bool) {
+	u.m.Lock()
+	if u.edgeToEdge == edgeToEdge {
+		u.m.Unlock()
+		return
+	}
+	u.edgeToEdge = edgeToEdge
+	c := u.systemUIController
+	u.m.Unlock()
+
+	if c != nil {
+		c.SetEdgeToEdge(edgeToEdge)
+	}
+}
+
+// SetSafeAreaInsets is called from mobile/ebitenmobileview when the insets of the system bars or display cutouts
+// change. The unit is device-independent pixels.
+//
+// Like the orientation, the new insets are adopted at the same time as the next layout.
+//
+// SetSafeAreaInsets is concurrent safe.
+func (u *UserInterface) SetSafeAreaInsets(left, top, right, bottom float64) {
+	u.m.Lock()
+	defer u.m.Unlock()
+	i := insets{
+		left:   left,
+		top:    top,
+		right:  right,
+		bottom: bottom,
+	}
+	if u.nextSafeAreaInsets == i {
+		return
+	}
+	u.nextSafeAreaInsets = i
+	u.sizeChanged = true
+}
+
+// SafeAreaInsets is concurrent safe.
+func (u *UserInterface) SafeAreaInsets() (left, top, right, bottom float64) {
+	u.m.RLock()
+	defer u.m.RUnlock()
+	i := u.safeAreaInsets
+	return i.left, i.top, i.right, i.bottom
+}
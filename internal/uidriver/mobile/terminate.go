@@ -0,0 +1,60 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build android || ios
+// +build android ios
+
+package mobile
+
+import (
+	"sync/atomic"
+)
+
+// Terminate is called from mobile/ebitenmobileview when the application is being terminated.
+//
+// Terminate runs the game loop once to call the game's Shutdown even when the view is in background, and returns
+// the error that Shutdown returns. After Terminate, the game is no longer updated.
+//
+// Terminate must be called on the same thread as Update's.
+func (u *UserInterface) Terminate() error {
+	if atomic.LoadInt32(&u.terminationRequested) != 0 {
+		return nil
+	}
+	atomic.StoreInt32(&u.terminationRequested, 1)
+	u.runOnce()
+
+	u.m.RLock()
+	defer u.m.RUnlock()
+	return u.shutdownErr
+}
+
+// shutdownIfNeeded calls the game's Shutdown if termination is requested, and reports whether the game is
+// terminated.
+//
+// shutdownIfNeeded must be called on the same goroutine as update().
+func (u *UserInterface) shutdownIfNeeded() bool {
+	if atomic.LoadInt32(&u.terminationRequested) == 0 {
+		return false
+	}
+	if u.terminated {
+		return true
+	}
+	u.terminated = true
+
+	err := u.context.Shutdown()
+	u.m.Lock()
+	u.shutdownErr = err
+	u.m.Unlock()
+	return true
+}
@@ -83,6 +83,12 @@ func (u *UserInterface) Update() error {
 		return nil
 	}
 
+	u.runOnce()
+	return nil
+}
+
+// runOnce runs the game loop for one frame.
+func (u *UserInterface) runOnce() {
 	renderCh <- struct{}{}
 	go func() {
 		<-renderEndCh
@@ -91,7 +97,6 @@ func (u *UserInterface) Update() error {
 		})
 	}()
 	u.t.Loop()
-	return nil
 }
 
 type UserInterface struct {
@@ -102,6 +107,49 @@ type UserInterface struct {
 	foreground  int32
 	errCh       chan error
 
+	orientation           Orientation
+	nextOrientation       Orientation
+	allowedOrientations   Orientation
+	orientationController OrientationController
+	orientationWaitCount  int
+
+	immersive          bool
+	edgeToEdge         bool
+	safeAreaInsets     insets
+	nextSafeAreaInsets insets
+	systemUIController SystemUIController
+
+	preferredFrameRate  int
+	displayRefreshRate  float64
+	frameRateController FrameRateController
+
+	lowMemory                        bool
+	onLowMemory                      func()
+	cacheEvictionOnLowMemoryDisabled bool
+
+	thermalState       ThermalState
+	lowPowerMode       bool
+	gameMode           GameMode
+	powerStateChanged  bool
+	onPowerStateChange func()
+
+	softKeyboardVisible    bool
+	softKeyboardText       softKeyboardText
+	softKeyboardController SoftKeyboardController
+
+	externalDisplayWidth    int
+	externalDisplayHeight   int
+	externalDisplayChanged  bool
+	onExternalDisplayChange func()
+
+	shareController ShareController
+
+	terminationRequested int32
+	terminated           bool
+	shutdownErr          error
+
+	filesDir string
+
 	// Used for gomobile-build
 	gbuildWidthPx   int
 	gbuildHeightPx  int
@@ -158,6 +206,12 @@ func (u *UserInterface) appMain(a app.App) {
 				glctx = nil
 			}
 		case size.Event:
+			switch e.Orientation {
+			case size.OrientationPortrait:
+				u.SetOrientation(OrientationPortrait)
+			case size.OrientationLandscape:
+				u.SetOrientation(OrientationLandscapeLeft)
+			}
 			u.setGBuildSize(e.WidthPx, e.HeightPx)
 			sizeInited = true
 		case paint.Event:
@@ -300,7 +354,7 @@ func (u *UserInterface) run(context driver.UIContext, mainloop bool) (err error)
 func (u *UserInterface) layoutIfNeeded() {
 	var outsideWidth, outsideHeight float64
 
-	u.m.RLock()
+	u.m.Lock()
 	sizeChanged := u.sizeChanged
 	if sizeChanged {
 		if u.gbuildWidthPx == 0 || u.gbuildHeightPx == 0 {
@@ -312,9 +366,22 @@ func (u *UserInterface) layoutIfNeeded() {
 			outsideWidth = float64(u.gbuildWidthPx) / d
 			outsideHeight = float64(u.gbuildHeightPx) / d
 		}
+
+		// In a rotation transition, the new orientation and the new size might not be notified at the same
+		// time. Wait for the size consistent with the orientation so that Layout doesn't get an
+		// intermediate size. A view embedded in a part of the screen might never have such a size, then
+		// give up waiting after a while.
+		if isOrientationConsistentWithSize(u.nextOrientation, outsideWidth, outsideHeight) || u.orientationWaitCount >= maxOrientationWaitCount {
+			u.orientation = u.nextOrientation
+			u.safeAreaInsets = u.nextSafeAreaInsets
+			u.orientationWaitCount = 0
+			u.sizeChanged = false
+		} else {
+			u.orientationWaitCount++
+			sizeChanged = false
+		}
 	}
-	u.sizeChanged = false
-	u.m.RUnlock()
+	u.m.Unlock()
 
 	if sizeChanged {
 		u.context.Layout(outsideWidth, outsideHeight)
@@ -327,6 +394,15 @@ func (u *UserInterface) update() error {
 		renderEndCh <- struct{}{}
 	}()
 
+	if u.shutdownIfNeeded() {
+		return nil
+	}
+
+	if err := u.handleLowMemoryIfNeeded(); err != nil {
+		return err
+	}
+	u.handlePowerStateChangeIfNeeded()
+	u.handleExternalDisplayChangeIfNeeded()
 	if err := u.context.Update(); err != nil {
 		return err
 	}
@@ -425,6 +501,10 @@ func (u *UserInterface) IsScreenTransparent() bool {
 	return false
 }
 
+func (u *UserInterface) UpdateInput() {
+	// Do nothing. The input state is updated by the platform whenever the input events happen.
+}
+
 func (u *UserInterface) ResetForFrame() {
 	u.layoutIfNeeded()
 	u.input.resetForFrame()
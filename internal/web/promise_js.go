@@ -0,0 +1,47 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"errors"
+	"syscall/js"
+)
+
+// AwaitPromise waits for the promise to be settled, and returns the fulfilled value or the rejected reason as an
+// error.
+//
+// AwaitPromise must not be called in a callback from JavaScript, or this blocks forever.
+func AwaitPromise(p js.Value) (js.Value, error) {
+	ch := make(chan struct{})
+	var v js.Value
+	var err error
+	resolve := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if len(args) > 0 {
+			v = args[0]
+		}
+		close(ch)
+		return nil
+	})
+	defer resolve.Release()
+	reject := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		err = errors.New(args[0].Call("toString").String())
+		close(ch)
+		return nil
+	})
+	defer reject.Release()
+	p.Call("then", resolve, reject)
+	<-ch
+	return v, err
+}
@@ -0,0 +1,50 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten
+
+import (
+	"math"
+)
+
+// LayoutFGame is an optional interface for a Game to use a non-integer logical screen size.
+type LayoutFGame interface {
+	// LayoutF is the float version of Game's Layout. If a game implements LayoutFGame, LayoutF is called instead
+	// of Layout.
+	//
+	// LayoutF accepts a native outside size in device-independent pixels without rounding, and returns the
+	// game's logical screen size. For example, on a display with a 125% device scale factor, a game can return
+	// outsideWidth * 1.25 and outsideHeight * 1.25 to render the screen in the exact physical pixels, without
+	// one-pixel seams or blurry scaling.
+	//
+	// The screen image given to Draw has the size of the returned values rounded up, i.e. math.Ceil(screenWidth)
+	// and math.Ceil(screenHeight). The fractional part of the last column and row might not be visible.
+	//
+	// If LayoutF returns non-positive numbers, the caller can panic.
+	LayoutF(outsideWidth, outsideHeight float64) (screenWidth, screenHeight float64)
+}
+
+// layoutGame calls the game's LayoutF if the game implements LayoutFGame, or Layout otherwise.
+func layoutGame(game Game, outsideWidth, outsideHeight float64) (float64, float64) {
+	if l, ok := game.(LayoutFGame); ok {
+		return l.LayoutF(outsideWidth, outsideHeight)
+	}
+	sw, sh := game.Layout(int(outsideWidth), int(outsideHeight))
+	return float64(sw), float64(sh)
+}
+
+// offscreenSize returns the size of an image to render the given screen size.
+func offscreenSize(screenWidth, screenHeight float64) (int, int) {
+	return int(math.Ceil(screenWidth)), int(math.Ceil(screenHeight))
+}
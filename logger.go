@@ -0,0 +1,113 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2/internal/diag"
+)
+
+// LogCategory represents a category of a log entry.
+type LogCategory int
+
+const (
+	// LogCategoryGraphics is for events of the graphics driver, e.g. the graphics backend chosen.
+	LogCategoryGraphics LogCategory = LogCategory(diag.CategoryGraphics)
+
+	// LogCategoryAtlas is for events of the texture atlases, e.g. an atlas is extended or created.
+	LogCategoryAtlas LogCategory = LogCategory(diag.CategoryAtlas)
+
+	// LogCategoryAudio is for events of the audio driver, e.g. an underrun.
+	LogCategoryAudio LogCategory = LogCategory(diag.CategoryAudio)
+
+	// LogCategoryInput is for events of the input devices, e.g. a gamepad without a standard mapping.
+	LogCategoryInput LogCategory = LogCategory(diag.CategoryInput)
+)
+
+// String returns a string representing the category.
+func (c LogCategory) String() string {
+	switch c {
+	case LogCategoryGraphics:
+		return "graphics"
+	case LogCategoryAtlas:
+		return "atlas"
+	case LogCategoryAudio:
+		return "audio"
+	case LogCategoryInput:
+		return "input"
+	}
+	return fmt.Sprintf("LogCategory(%d)", int(c))
+}
+
+// LogEntry represents a noteworthy internal event of the engine.
+type LogEntry struct {
+	// Time is the time when the event happened.
+	Time time.Time
+
+	// Category is the category of the event.
+	Category LogCategory
+
+	// Message is the description of the event.
+	Message string
+
+	// Fields is the additional data of the event, e.g. the size of an atlas. Fields can be nil.
+	Fields map[string]interface{}
+}
+
+// Logger is the interface to receive the internal events of the engine.
+type Logger interface {
+	// Log is called when an event happens.
+	//
+	// Log can be called from any goroutine, including the audio goroutines. Log must be concurrent-safe, and
+	// should return quickly.
+	Log(entry *LogEntry)
+}
+
+// LoggerFunc is an adapter to use an ordinary function as a Logger.
+type LoggerFunc func(entry *LogEntry)
+
+// Log calls f(entry).
+func (f LoggerFunc) Log(entry *LogEntry) {
+	f(entry)
+}
+
+// SetLogger sets the logger to receive the noteworthy internal events of the engine, so that a game can surface
+// them in its own logs instead of silent behavior changes. The events include:
+//
+//     * The graphics backend chosen, including a fallback
+//     * An extension or a creation of a texture atlas
+//     * An audio underrun (only on Linux and other Unix-like systems so far)
+//     * A gamepad ignored or without a standard mapping
+//
+// The events happened before the first SetLogger call, e.g. at the initialization, are delivered when the logger
+// is set. If logger is nil, the logger is unset.
+//
+// SetLogger is concurrent-safe.
+func SetLogger(logger Logger) {
+	if logger == nil {
+		diag.SetLogger(nil)
+		return
+	}
+	diag.SetLogger(func(e *diag.Entry) {
+		logger.Log(&LogEntry{
+			Time:     e.Time,
+			Category: LogCategory(e.Category),
+			Message:  e.Message,
+			Fields:   e.Fields,
+		})
+	})
+}
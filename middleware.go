@@ -0,0 +1,137 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten
+
+// Middleware wraps the Update and the Draw of a game.
+//
+// Middlewares are useful for tools that work with any games, like profilers, screen transitions, input recorders
+// and debug overlays. Use WrapGame to apply middlewares to a game.
+//
+// If a middleware implements Shutdowner, its Shutdown is called after the game's Shutdown.
+type Middleware interface {
+	// Update is called instead of the game's Update.
+	//
+	// next calls the Update of the next middleware or the game. Update can do something before and after next,
+	// or skip next e.g. to pause the game. If next returns an error, Update should return the error.
+	//
+	// When pipelined updating is enabled, Update is called on the same goroutine as the game's Update.
+	Update(next func() error) error
+
+	// Draw is called instead of the game's Draw, or the game's DrawSnapshot when pipelined updating is enabled.
+	//
+	// next calls the Draw of the next middleware or the game. Draw can draw something before and after next.
+	// next can be called with another image than screen, e.g. an offscreen image for a screen transition.
+	Draw(screen *Image, next func(screen *Image))
+}
+
+// WrapGame returns a game that runs the given middlewares around the Update and the Draw of the given game.
+//
+// The first middleware is the outermost one. For example, with WrapGame(game, m0, m1), Update calls m0's Update,
+// m0's next calls m1's Update, and m1's next calls game's Update. Draw is processed in the same order.
+//
+// The returned game implements the optional interfaces of the given game like Shutdowner, DeviceLostHandler,
+// PipelinedGame and LayoutFGame. Layout of the returned game calls game's Layout.
+func WrapGame(game Game, middlewares ...Middleware) Game {
+	ms := make([]Middleware, len(middlewares))
+	copy(ms, middlewares)
+	return &wrappedGame{
+		game:        game,
+		middlewares: ms,
+	}
+}
+
+type wrappedGame struct {
+	game        Game
+	middlewares []Middleware
+}
+
+func (w *wrappedGame) Update() error {
+	return w.update(0)
+}
+
+func (w *wrappedGame) update(index int) error {
+	if index == len(w.middlewares) {
+		return updateGame(w.game)
+	}
+	return w.middlewares[index].Update(func() error {
+		return w.update(index + 1)
+	})
+}
+
+func (w *wrappedGame) Draw(screen *Image) {
+	w.draw(0, screen, w.game.Draw)
+}
+
+func (w *wrappedGame) draw(index int, screen *Image, drawGame func(screen *Image)) {
+	if index == len(w.middlewares) {
+		drawGame(screen)
+		return
+	}
+	w.middlewares[index].Draw(screen, func(screen *Image) {
+		w.draw(index+1, screen, drawGame)
+	})
+}
+
+func (w *wrappedGame) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return w.game.Layout(outsideWidth, outsideHeight)
+}
+
+func (w *wrappedGame) LayoutF(outsideWidth, outsideHeight float64) (float64, float64) {
+	return layoutGame(w.game, outsideWidth, outsideHeight)
+}
+
+// Snapshot is called only when the game implements PipelinedGame. See isPipelinedGame.
+func (w *wrappedGame) Snapshot() interface{} {
+	return w.game.(PipelinedGame).Snapshot()
+}
+
+// DrawSnapshot is called only when the game implements PipelinedGame. See isPipelinedGame.
+func (w *wrappedGame) DrawSnapshot(screen *Image, snapshot interface{}) {
+	w.draw(0, screen, func(screen *Image) {
+		w.game.(PipelinedGame).DrawSnapshot(screen, snapshot)
+	})
+}
+
+func (w *wrappedGame) Shutdown() error {
+	if s, ok := w.game.(Shutdowner); ok {
+		if err := s.Shutdown(); err != nil {
+			return err
+		}
+	}
+	for _, m := range w.middlewares {
+		if s, ok := m.(Shutdowner); ok {
+			if err := s.Shutdown(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (w *wrappedGame) HandleDeviceLost(lostImages []*Image) error {
+	if h, ok := w.game.(DeviceLostHandler); ok {
+		return h.HandleDeviceLost(lostImages)
+	}
+	return nil
+}
+
+// isPipelinedGame reports whether the game implements PipelinedGame.
+func isPipelinedGame(game Game) bool {
+	if w, ok := game.(*wrappedGame); ok {
+		return isPipelinedGame(w.game)
+	}
+	_, ok := game.(PipelinedGame)
+	return ok
+}
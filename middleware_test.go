@@ -0,0 +1,91 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/hajimehoshi/ebiten/v2"
+)
+
+type recordingGame struct {
+	log *[]string
+}
+
+func (g *recordingGame) Update() error {
+	*g.log = append(*g.log, "game update")
+	return nil
+}
+
+func (g *recordingGame) Draw(screen *Image) {
+	*g.log = append(*g.log, "game draw")
+}
+
+func (g *recordingGame) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return outsideWidth, outsideHeight
+}
+
+type recordingMiddleware struct {
+	name string
+	log  *[]string
+}
+
+func (m *recordingMiddleware) Update(next func() error) error {
+	*m.log = append(*m.log, m.name+" before update")
+	if err := next(); err != nil {
+		return err
+	}
+	*m.log = append(*m.log, m.name+" after update")
+	return nil
+}
+
+func (m *recordingMiddleware) Draw(screen *Image, next func(screen *Image)) {
+	*m.log = append(*m.log, m.name+" before draw")
+	next(screen)
+	*m.log = append(*m.log, m.name+" after draw")
+}
+
+func TestWrapGame(t *testing.T) {
+	var log []string
+	g := WrapGame(&recordingGame{log: &log},
+		&recordingMiddleware{name: "m0", log: &log},
+		&recordingMiddleware{name: "m1", log: &log})
+
+	if err := g.Update(); err != nil {
+		t.Fatal(err)
+	}
+	g.Draw(nil)
+
+	want := []string{
+		"m0 before update",
+		"m1 before update",
+		"game update",
+		"m1 after update",
+		"m0 after update",
+		"m0 before draw",
+		"m1 before draw",
+		"game draw",
+		"m1 after draw",
+		"m0 after draw",
+	}
+	if !reflect.DeepEqual(log, want) {
+		t.Errorf("got: %v, want: %v", log, want)
+	}
+
+	if _, ok := g.(Shutdowner); !ok {
+		t.Errorf("the wrapped game must implement Shutdowner")
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build android ios
+
+package ebitenmobileview
+
+import (
+	"github.com/hajimehoshi/ebiten/v2/internal/uidriver/mobile"
+)
+
+// FrameRateController is implemented by the view on the platform side to change the frame rate.
+//
+// SetPreferredFrameRate is called with 0 when the platform's default frame rate should be used.
+type FrameRateController interface {
+	SetPreferredFrameRate(fps int)
+}
+
+func SetFrameRateController(controller FrameRateController) {
+	mobile.Get().SetFrameRateController(controller)
+}
+
+// SetDisplayRefreshRate notifies the actual refresh rate of the display in Hz.
+func SetDisplayRefreshRate(hz float64) {
+	mobile.Get().SetDisplayRefreshRate(hz)
+}
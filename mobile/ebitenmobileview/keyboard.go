@@ -0,0 +1,63 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build android ios
+
+package ebitenmobileview
+
+import (
+	"github.com/hajimehoshi/ebiten/v2/internal/uidriver/mobile"
+)
+
+// SoftKeyboardController is implemented by the view on the platform side.
+//
+// ShowSoftKeyboard focuses an invisible text field with the given text and shows the on-screen keyboard.
+// HideSoftKeyboard unfocuses the text field and hides the keyboard.
+type SoftKeyboardController interface {
+	ShowSoftKeyboard(text string)
+	HideSoftKeyboard()
+}
+
+func SetSoftKeyboardController(controller SoftKeyboardController) {
+	mobile.Get().SetSoftKeyboardController(controller)
+}
+
+// SetSoftKeyboardVisible notifies whether the on-screen keyboard is visible.
+func SetSoftKeyboardVisible(visible bool) {
+	mobile.Get().SetSoftKeyboardVisible(visible)
+}
+
+// SetSoftKeyboardText notifies the text of the invisible text field.
+//
+// compositionStart and compositionEnd represent the range of the text being composed by an IME, in UTF-16 code units.
+// If there is no composition, compositionStart and compositionEnd should be the same.
+func SetSoftKeyboardText(text string, compositionStart, compositionEnd int) {
+	mobile.Get().SetSoftKeyboardText(text, utf16OffsetToByteOffset(text, compositionStart), utf16OffsetToByteOffset(text, compositionEnd))
+}
+
+func utf16OffsetToByteOffset(str string, offset int) int {
+	var n int
+	for i, r := range str {
+		if n >= offset {
+			return i
+		}
+		if r >= 0x10000 {
+			// This is a surrogate pair in UTF-16.
+			n += 2
+		} else {
+			n++
+		}
+	}
+	return len(str)
+}
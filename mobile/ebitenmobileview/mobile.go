@@ -90,3 +90,17 @@ func OnContextLost() {
 func DeviceScale() float64 {
 	return devicescale.GetAt(0, 0)
 }
+
+// Terminate must be called when the application is being terminated, e.g. applicationWillTerminate on iOS or
+// onDestroy with isFinishing on Android.
+//
+// Terminate must be called on the same thread as Update.
+func Terminate() error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if !theState.isRunning() {
+		return nil
+	}
+	return mobile.Get().Terminate()
+}
@@ -0,0 +1,40 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build android ios
+
+package ebitenmobileview
+
+import (
+	"github.com/hajimehoshi/ebiten/v2/internal/uidriver/mobile"
+)
+
+// OrientationController is implemented by the view on the platform side.
+//
+// SetAllowedOrientations is called with a bit set of allowed orientations.
+// 0 means the platform's default.
+type OrientationController interface {
+	SetAllowedOrientations(orientations int)
+}
+
+func SetOrientationController(controller OrientationController) {
+	mobile.Get().SetOrientationController(controller)
+}
+
+// SetOrientation notifies the current interface orientation.
+//
+// SetOrientation should be called before Layout is called with the new view size.
+func SetOrientation(orientation int) {
+	mobile.Get().SetOrientation(mobile.Orientation(orientation))
+}
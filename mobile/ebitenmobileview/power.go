@@ -0,0 +1,44 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build android ios
+
+package ebitenmobileview
+
+import (
+	"github.com/hajimehoshi/ebiten/v2/internal/uidriver/mobile"
+)
+
+// SetThermalState notifies the thermal state of the device.
+//
+// state is one of these values:
+//
+//     0: Unknown
+//     1: Nominal
+//     2: Fair
+//     3: Serious
+//     4: Critical
+func SetThermalState(state int) {
+	mobile.Get().SetThermalState(mobile.ThermalState(state))
+}
+
+// SetGameMode notifies the game mode, which is the value of GameManager's getGameMode on Android.
+func SetGameMode(mode int) {
+	mobile.Get().SetGameMode(mobile.GameMode(mode))
+}
+
+// SetLowPowerMode notifies whether the low power mode (or the battery saver on Android) is enabled.
+func SetLowPowerMode(lowPowerMode bool) {
+	mobile.Get().SetLowPowerMode(lowPowerMode)
+}
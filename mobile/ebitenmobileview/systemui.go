@@ -0,0 +1,38 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build android ios
+
+package ebitenmobileview
+
+import (
+	"github.com/hajimehoshi/ebiten/v2/internal/uidriver/mobile"
+)
+
+// SystemUIController is implemented by the view on the platform side to control the system bars.
+type SystemUIController interface {
+	SetImmersiveMode(immersive bool)
+	SetEdgeToEdge(edgeToEdge bool)
+}
+
+func SetSystemUIController(controller SystemUIController) {
+	mobile.Get().SetSystemUIController(controller)
+}
+
+// SetSafeAreaInsets notifies the insets of the system bars and display cutouts in device-independent pixels.
+//
+// SetSafeAreaInsets should be called before Layout is called.
+func SetSafeAreaInsets(left, top, right, bottom float64) {
+	mobile.Get().SetSafeAreaInsets(left, top, right, bottom)
+}
@@ -0,0 +1,45 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mobile
+
+// ExternalDisplaySize returns the size in pixels of the connected external display, e.g. a TV connected via
+// AirPlay, HDMI or USB-C. If multiple external displays are connected, the first one is used.
+//
+// By default, the OS mirrors the device's screen to the external display. Ebiten has only one screen for the game,
+// so the external display cannot be a second render target. If you want to show the game on the external display
+// at its native resolution, put the generated view in a UIWindow for the external UIScreen on iOS, or in a
+// Presentation on Android. The most recently shown view renders the game (see SetGame). Then the device's screen
+// can show your own native UI, e.g. as a controller.
+//
+// ExternalDisplaySize returns (0, 0) if no external display is connected, or on non-mobile platforms.
+//
+// ExternalDisplaySize is concurrent-safe.
+func ExternalDisplaySize() (width, height int) {
+	return externalDisplaySize()
+}
+
+// SetOnExternalDisplayChange sets a function that is called when an external display is connected, disconnected
+// or changed.
+//
+// The function is called on the game's goroutine before the next Update.
+//
+// If f is nil, the function is unset.
+//
+// SetOnExternalDisplayChange does nothing on non-mobile platforms.
+//
+// SetOnExternalDisplayChange is concurrent-safe.
+func SetOnExternalDisplayChange(f func()) {
+	setOnExternalDisplayChange(f)
+}
@@ -0,0 +1,57 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mobile
+
+// PreferredFrameRate returns the frame rate specified by SetPreferredFrameRate.
+//
+// PreferredFrameRate is concurrent-safe.
+func PreferredFrameRate() int {
+	return preferredFrameRate()
+}
+
+// SetPreferredFrameRate requests the platform to render frames at the given rate in FPS.
+// 0 means the platform's default, which is usually 60.
+//
+// On iOS, CADisplayLink's preferredFrameRateRange (iOS 15 or later) or preferredFramesPerSecond is used.
+// Rates higher than 60 on ProMotion displays of iPhones also require CADisableMinimumFrameDurationOnPhone
+// to be true in Info.plist.
+//
+// On Android, Surface's setFrameRate (Android 11 or later) is used. On older versions, a display mode with
+// the closest refresh rate is requested.
+//
+// The platform might not adopt the given frame rate e.g. in the low power mode.
+// Use DisplayRefreshRate to know the actual refresh rate.
+//
+// Note that the frame rate is not related to TPS. If you want to update the game more often, use
+// ebiten.SetMaxTPS too.
+//
+// SetPreferredFrameRate does nothing on non-mobile platforms.
+//
+// SetPreferredFrameRate is concurrent-safe.
+func SetPreferredFrameRate(fps int) {
+	if fps < 0 {
+		panic("mobile: fps must be >= 0")
+	}
+	setPreferredFrameRate(fps)
+}
+
+// DisplayRefreshRate returns the refresh rate in Hz that the display actually adopts.
+//
+// DisplayRefreshRate returns 0 if the refresh rate is not known yet, or on non-mobile platforms.
+//
+// DisplayRefreshRate is concurrent-safe.
+func DisplayRefreshRate() float64 {
+	return displayRefreshRate()
+}
@@ -19,9 +19,110 @@ package mobile
 
 import (
 	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/internal/uidriver/mobile"
 	"github.com/hajimehoshi/ebiten/v2/mobile/ebitenmobileview"
 )
 
 func setGame(game ebiten.Game) {
 	ebitenmobileview.SetGame(game)
 }
+
+func currentOrientation() Orientation {
+	return Orientation(mobile.Get().Orientation())
+}
+
+func allowedOrientations() Orientation {
+	return Orientation(mobile.Get().AllowedOrientations())
+}
+
+func setAllowedOrientations(orientations Orientation) {
+	mobile.Get().SetAllowedOrientations(mobile.Orientation(orientations))
+}
+
+func isImmersiveMode() bool {
+	return mobile.Get().IsImmersiveMode()
+}
+
+func setImmersiveMode(immersive bool) {
+	mobile.Get().SetImmersiveMode(immersive)
+}
+
+func isEdgeToEdge() bool {
+	return mobile.Get().IsEdgeToEdge()
+}
+
+func setEdgeToEdge(edgeToEdge bool) {
+	mobile.Get().SetEdgeToEdge(edgeToEdge)
+}
+
+func safeAreaInsets() (left, top, right, bottom float64) {
+	return mobile.Get().SafeAreaInsets()
+}
+
+func preferredFrameRate() int {
+	return mobile.Get().PreferredFrameRate()
+}
+
+func setPreferredFrameRate(fps int) {
+	mobile.Get().SetPreferredFrameRate(fps)
+}
+
+func displayRefreshRate() float64 {
+	return mobile.Get().DisplayRefreshRate()
+}
+
+func setOnLowMemory(f func()) {
+	mobile.Get().SetOnLowMemory(f)
+}
+
+func isCacheEvictionOnLowMemoryDisabled() bool {
+	return mobile.Get().IsCacheEvictionOnLowMemoryDisabled()
+}
+
+func setCacheEvictionOnLowMemoryDisabled(disabled bool) {
+	mobile.Get().SetCacheEvictionOnLowMemoryDisabled(disabled)
+}
+
+func currentThermalState() ThermalState {
+	return ThermalState(mobile.Get().ThermalState())
+}
+
+func isLowPowerMode() bool {
+	return mobile.Get().IsLowPowerMode()
+}
+
+func setOnPowerStateChange(f func()) {
+	mobile.Get().SetOnPowerStateChange(f)
+}
+
+func showSoftKeyboard(text string) {
+	mobile.Get().ShowSoftKeyboard(text)
+}
+
+func hideSoftKeyboard() {
+	mobile.Get().HideSoftKeyboard()
+}
+
+func isSoftKeyboardVisible() bool {
+	return mobile.Get().IsSoftKeyboardVisible()
+}
+
+func softKeyboardText() (text string, compositionStart, compositionEnd int) {
+	return mobile.Get().SoftKeyboardText()
+}
+
+func currentGameMode() GameMode {
+	return GameMode(mobile.Get().GameMode())
+}
+
+func externalDisplaySize() (width, height int) {
+	return mobile.Get().ExternalDisplaySize()
+}
+
+func setOnExternalDisplayChange(f func()) {
+	mobile.Get().SetOnExternalDisplayChange(f)
+}
+
+func share(data []byte, name string, mimeType string) error {
+	return mobile.Get().Share(data, name, mimeType)
+}
@@ -18,9 +18,101 @@
 package mobile
 
 import (
+	"fmt"
+
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
 func setGame(game ebiten.Game) {
 	panic("mobile: setGame is not implemented in this environment")
 }
+
+func currentOrientation() Orientation {
+	return 0
+}
+
+func allowedOrientations() Orientation {
+	return 0
+}
+
+func setAllowedOrientations(orientations Orientation) {
+}
+
+func isImmersiveMode() bool {
+	return false
+}
+
+func setImmersiveMode(immersive bool) {
+}
+
+func isEdgeToEdge() bool {
+	return false
+}
+
+func setEdgeToEdge(edgeToEdge bool) {
+}
+
+func safeAreaInsets() (left, top, right, bottom float64) {
+	return 0, 0, 0, 0
+}
+
+func preferredFrameRate() int {
+	return 0
+}
+
+func setPreferredFrameRate(fps int) {
+}
+
+func displayRefreshRate() float64 {
+	return 0
+}
+
+func setOnLowMemory(f func()) {
+}
+
+func isCacheEvictionOnLowMemoryDisabled() bool {
+	return false
+}
+
+func setCacheEvictionOnLowMemoryDisabled(disabled bool) {
+}
+
+func currentThermalState() ThermalState {
+	return ThermalStateUnknown
+}
+
+func isLowPowerMode() bool {
+	return false
+}
+
+func setOnPowerStateChange(f func()) {
+}
+
+func showSoftKeyboard(text string) {
+}
+
+func hideSoftKeyboard() {
+}
+
+func isSoftKeyboardVisible() bool {
+	return false
+}
+
+func softKeyboardText() (text string, compositionStart, compositionEnd int) {
+	return "", 0, 0
+}
+
+func currentGameMode() GameMode {
+	return GameModeUnsupported
+}
+
+func externalDisplaySize() (width, height int) {
+	return 0, 0
+}
+
+func setOnExternalDisplayChange(f func()) {
+}
+
+func share(data []byte, name string, mimeType string) error {
+	return fmt.Errorf("mobile: Share is not supported on this platform")
+}
@@ -0,0 +1,62 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mobile
+
+// ShowSoftKeyboard shows the on-screen keyboard to edit the given text.
+//
+// The text is edited in an invisible text field managed by the platform, so the keyboard and IMEs work as usual.
+// Use SoftKeyboardText to get the text being edited, and render it by yourself.
+//
+// ShowSoftKeyboard does nothing on non-mobile platforms.
+//
+// ShowSoftKeyboard is concurrent-safe.
+func ShowSoftKeyboard(text string) {
+	showSoftKeyboard(text)
+}
+
+// HideSoftKeyboard hides the on-screen keyboard.
+//
+// The keyboard is also hidden when the user presses the return key or dismisses the keyboard.
+//
+// HideSoftKeyboard does nothing on non-mobile platforms.
+//
+// HideSoftKeyboard is concurrent-safe.
+func HideSoftKeyboard() {
+	hideSoftKeyboard()
+}
+
+// IsSoftKeyboardVisible reports whether the on-screen keyboard is visible.
+//
+// IsSoftKeyboardVisible returns false on non-mobile platforms.
+//
+// IsSoftKeyboardVisible is concurrent-safe.
+func IsSoftKeyboardVisible() bool {
+	return isSoftKeyboardVisible()
+}
+
+// SoftKeyboardText returns the text being edited by the on-screen keyboard.
+//
+// compositionStart and compositionEnd are the byte offsets of the range in text that is being composed by an IME
+// and not committed yet. For example, you can underline the range. If there is no composition,
+// compositionStart and compositionEnd are the same.
+//
+// The text is kept after the keyboard is hidden until ShowSoftKeyboard is called again.
+//
+// SoftKeyboardText returns an empty string on non-mobile platforms.
+//
+// SoftKeyboardText is concurrent-safe.
+func SoftKeyboardText() (text string, compositionStart, compositionEnd int) {
+	return softKeyboardText()
+}
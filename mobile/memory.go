@@ -0,0 +1,51 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mobile
+
+// SetOnLowMemory sets a function that is called when the OS warns that the memory is low.
+// The function should release resources that the game can recreate later, e.g. images for scenes that are not
+// visible. Otherwise, the OS might kill the application.
+//
+// The function is called on the game's goroutine before the next Update. If the application is in the
+// background, the function is called after the application is resumed.
+//
+// If f is nil, the function is unset.
+//
+// SetOnLowMemory does nothing on non-mobile platforms.
+//
+// SetOnLowMemory is concurrent-safe.
+func SetOnLowMemory(f func()) {
+	setOnLowMemory(f)
+}
+
+// IsCacheEvictionOnLowMemoryDisabled reports whether Ebiten's internal caches are kept on low memory.
+//
+// IsCacheEvictionOnLowMemoryDisabled is concurrent-safe.
+func IsCacheEvictionOnLowMemoryDisabled() bool {
+	return isCacheEvictionOnLowMemoryDisabled()
+}
+
+// SetCacheEvictionOnLowMemoryDisabled sets whether Ebiten's internal caches are kept on low memory.
+//
+// By default, when the OS warns that the memory is low, Ebiten releases its internal caches that can be
+// recreated later, like glyph images of the text package, before calling the function set by SetOnLowMemory.
+// Recreating the caches might cause a hiccup. If this is not acceptable, disable the eviction.
+//
+// SetCacheEvictionOnLowMemoryDisabled does nothing on non-mobile platforms.
+//
+// SetCacheEvictionOnLowMemoryDisabled is concurrent-safe.
+func SetCacheEvictionOnLowMemoryDisabled(disabled bool) {
+	setCacheEvictionOnLowMemoryDisabled(disabled)
+}
@@ -28,6 +28,10 @@ import (
 // SetGame is expected to be called only once.
 //
 // SetGame can be called anytime. Until SetGame is called, the game does not start.
+//
+// There is only one game in a process, but the game can be shown in multiple views one after another.
+// For example, a view can be destroyed and recreated with an activity or a view controller.
+// The most recently shown view renders the game and receives the requests like SetAllowedOrientations.
 func SetGame(game ebiten.Game) {
 	setGame(game)
 }
@@ -0,0 +1,69 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mobile
+
+// Orientation represents a bit set of interface orientations.
+//
+// The names follow UIInterfaceOrientation on iOS: OrientationLandscapeLeft is the landscape orientation where
+// the bottom of the device (e.g. the home button) is on the left side.
+type Orientation int
+
+const (
+	OrientationPortrait Orientation = 1 << iota
+	OrientationPortraitUpsideDown
+	OrientationLandscapeLeft
+	OrientationLandscapeRight
+
+	OrientationLandscape = OrientationLandscapeLeft | OrientationLandscapeRight
+	OrientationAll       = OrientationPortrait | OrientationPortraitUpsideDown | OrientationLandscape
+)
+
+// CurrentOrientation returns the current interface orientation.
+//
+// The returned value is updated at the same time as the outside size given to the game's Layout.
+// Then, Layout can use CurrentOrientation to decide the screen size without observing an intermediate state
+// in a rotation transition.
+//
+// CurrentOrientation returns 0 if the orientation is not known yet, or on non-mobile platforms.
+//
+// CurrentOrientation is concurrent-safe.
+func CurrentOrientation() Orientation {
+	return currentOrientation()
+}
+
+// AllowedOrientations returns the orientations that the view is allowed to rotate to.
+//
+// AllowedOrientations returns 0 if SetAllowedOrientations is not called yet.
+//
+// AllowedOrientations is concurrent-safe.
+func AllowedOrientations() Orientation {
+	return allowedOrientations()
+}
+
+// SetAllowedOrientations locks the view's rotation to the given orientations at runtime.
+// For example, you can lock the orientation to OrientationLandscape during gameplay and
+// allow OrientationAll in menus.
+//
+// If orientations is 0, the platform's default (e.g. Info.plist or AndroidManifest.xml) is used.
+//
+// The allowed orientations are also restricted by the application's settings. For example, on iOS, orientations
+// that are not listed in Info.plist are never adopted.
+//
+// SetAllowedOrientations does nothing on non-mobile platforms.
+//
+// SetAllowedOrientations is concurrent-safe.
+func SetAllowedOrientations(orientations Orientation) {
+	setAllowedOrientations(orientations)
+}
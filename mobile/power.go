@@ -0,0 +1,110 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mobile
+
+// ThermalState represents the thermal state of the device.
+//
+// The names follow ProcessInfo.ThermalState on iOS.
+type ThermalState int
+
+const (
+	// ThermalStateUnknown means the thermal state is not available, e.g. on old OS versions.
+	ThermalStateUnknown ThermalState = iota
+
+	// ThermalStateNominal means the thermal state is within normal limits.
+	ThermalStateNominal
+
+	// ThermalStateFair means the thermal state is slightly elevated.
+	ThermalStateFair
+
+	// ThermalStateSerious means the thermal state is high and the OS starts to throttle the performance.
+	ThermalStateSerious
+
+	// ThermalStateCritical means the thermal state is significantly impacting the performance and the device
+	// needs to cool down.
+	ThermalStateCritical
+)
+
+// GameMode represents the game mode that the user selects for the game on Android 12 or later.
+//
+// The values are the same as GameManager's game modes.
+type GameMode int
+
+const (
+	// GameModeUnsupported means the game mode is not available, e.g. on iOS or old Android versions,
+	// or the game doesn't support the game mode.
+	GameModeUnsupported GameMode = iota
+
+	// GameModeStandard means the user doesn't specify a preference.
+	GameModeStandard
+
+	// GameModePerformance means the user prefers the performance like higher frame rates.
+	GameModePerformance
+
+	// GameModeBattery means the user prefers longer battery life. The game should lower its load,
+	// e.g. by ebiten.SetMaxTPS or SetPreferredFrameRate.
+	GameModeBattery
+
+	// GameModeCustom means the user customizes the interventions by the OS.
+	GameModeCustom
+)
+
+// CurrentThermalState returns the current thermal state of the device.
+//
+// On iOS, ProcessInfo's thermalState is used. On Android, PowerManager's thermal status (Android 10 or later) is used.
+//
+// CurrentThermalState returns ThermalStateUnknown on non-mobile platforms.
+//
+// CurrentThermalState is concurrent-safe.
+func CurrentThermalState() ThermalState {
+	return currentThermalState()
+}
+
+// IsLowPowerMode reports whether the low power mode (or the battery saver on Android) is enabled.
+//
+// IsLowPowerMode returns false on non-mobile platforms.
+//
+// IsLowPowerMode is concurrent-safe.
+func IsLowPowerMode() bool {
+	return isLowPowerMode()
+}
+
+// CurrentGameMode returns the game mode that the user selects.
+//
+// To enable game modes other than GameModeStandard, declare them in game_mode_config.xml of your application.
+// See https://developer.android.com/games/gamemode for details.
+//
+// CurrentGameMode returns GameModeUnsupported on iOS and non-mobile platforms.
+//
+// CurrentGameMode is concurrent-safe.
+func CurrentGameMode() GameMode {
+	return currentGameMode()
+}
+
+// SetOnPowerStateChange sets a function that is called when the thermal state, the low power mode or the game mode
+// changes.
+// The function can lower the game's load, e.g. by ebiten.SetMaxTPS, SetPreferredFrameRate or the quality of
+// effects, before the OS throttles the device.
+//
+// The function is called on the game's goroutine before the next Update.
+//
+// If f is nil, the function is unset.
+//
+// SetOnPowerStateChange does nothing on non-mobile platforms.
+//
+// SetOnPowerStateChange is concurrent-safe.
+func SetOnPowerStateChange(f func()) {
+	setOnPowerStateChange(f)
+}
@@ -0,0 +1,66 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mobile
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"strings"
+)
+
+// Share exports the given data as a file through the platform's share sheet, e.g. to send a screenshot or
+// a replay to other applications or to save it as a file.
+//
+// name is the file name without directories, like "score.png". mimeType is the MIME type of the data, like
+// "image/png". On iOS, the type is determined by the extension of name instead.
+//
+// Share returns immediately. The share sheet is shown asynchronously.
+//
+// On Android, the file is provided to other applications by EbitenFileProvider that ebitenmobile generates.
+// Declare the provider in AndroidManifest.xml of your application:
+//
+//     <provider
+//         android:name="<java package>.<prefix><package name>.EbitenFileProvider"
+//         android:authorities="${applicationId}.ebitenfileprovider"
+//         android:exported="false"
+//         android:grantUriPermissions="true" />
+//
+// Share returns an error on non-mobile platforms.
+//
+// Share is concurrent-safe.
+func Share(name string, data []byte, mimeType string) error {
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("mobile: invalid file name: %q", name)
+	}
+	return share(data, name, mimeType)
+}
+
+// ShareImage exports the given image as a PNG file through the platform's share sheet.
+//
+// For example, you can share a screenshot by passing an *ebiten.Image.
+// Note that reading pixels from an *ebiten.Image is not fast. Don't call this every frame.
+//
+// See Share for the details.
+//
+// ShareImage is concurrent-safe.
+func ShareImage(name string, img image.Image) error {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+	return Share(name, buf.Bytes(), "image/png")
+}
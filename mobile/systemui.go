@@ -0,0 +1,66 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mobile
+
+// IsImmersiveMode reports whether the immersive mode is enabled.
+//
+// IsImmersiveMode is concurrent-safe.
+func IsImmersiveMode() bool {
+	return isImmersiveMode()
+}
+
+// SetImmersiveMode enters or leaves the immersive mode.
+//
+// On Android, the immersive mode is the 'sticky immersive' mode: the status bar and the navigation bar are hidden,
+// and they appear temporarily when the user swipes from the edge of the screen.
+// On iOS, the status bar is hidden and the home indicator is hidden automatically.
+//
+// SetImmersiveMode does nothing on non-mobile platforms.
+//
+// SetImmersiveMode is concurrent-safe.
+func SetImmersiveMode(immersive bool) {
+	setImmersiveMode(immersive)
+}
+
+// IsEdgeToEdge reports whether the view is laid out behind the system bars.
+//
+// IsEdgeToEdge is concurrent-safe.
+func IsEdgeToEdge() bool {
+	return isEdgeToEdge()
+}
+
+// SetEdgeToEdge sets whether the view is laid out behind the system bars and display cutouts.
+//
+// When the view is edge-to-edge, use SafeAreaInsets to avoid placing important contents under the system bars.
+//
+// On iOS, the view is always laid out edge-to-edge and SetEdgeToEdge does nothing.
+// SetEdgeToEdge does nothing on non-mobile platforms.
+//
+// SetEdgeToEdge is concurrent-safe.
+func SetEdgeToEdge(edgeToEdge bool) {
+	setEdgeToEdge(edgeToEdge)
+}
+
+// SafeAreaInsets returns the insets of the area covered by the system bars or display cutouts.
+//
+// The unit is device-independent pixels, which is the same as the outside size given to the game's Layout.
+// The returned value is updated at the same time as the outside size given to the game's Layout.
+//
+// SafeAreaInsets returns zeros on non-mobile platforms.
+//
+// SafeAreaInsets is concurrent-safe.
+func SafeAreaInsets() (left, top, right, bottom float64) {
+	return safeAreaInsets()
+}
@@ -0,0 +1,47 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten
+
+// OrientationLockType represents an orientation to lock the screen to in fullscreen on mobile browsers.
+type OrientationLockType int
+
+// OrientationLockTypes
+const (
+	OrientationLockNone OrientationLockType = iota
+	OrientationLockPortrait
+	OrientationLockLandscape
+)
+
+// FullscreenOrientationLock returns the current orientation lock in fullscreen.
+//
+// FullscreenOrientationLock is concurrent-safe.
+func FullscreenOrientationLock() OrientationLockType {
+	return fullscreenOrientationLock()
+}
+
+// SetFullscreenOrientationLock sets the orientation to lock the screen to while the game is in fullscreen on
+// browsers.
+//
+// Browsers allow to lock the orientation only in fullscreen, and the lock is released when the fullscreen exits.
+// The lock works only on mobile browsers that support the Screen Orientation API. Otherwise the lock is ignored.
+//
+// The default value is OrientationLockNone.
+//
+// SetFullscreenOrientationLock does nothing on non-browsers.
+//
+// SetFullscreenOrientationLock is concurrent-safe.
+func SetFullscreenOrientationLock(lock OrientationLockType) {
+	setFullscreenOrientationLock(lock)
+}
@@ -0,0 +1,304 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2/internal/driver"
+	"github.com/hajimehoshi/ebiten/v2/internal/stats"
+)
+
+var isPerformanceOverlayVisible int32
+
+// IsPerformanceOverlayVisible reports whether the performance overlay is visible.
+//
+// IsPerformanceOverlayVisible is concurrent-safe.
+func IsPerformanceOverlayVisible() bool {
+	return atomic.LoadInt32(&isPerformanceOverlayVisible) != 0
+}
+
+// SetPerformanceOverlayVisible sets whether the performance overlay is visible.
+//
+// The performance overlay is drawn at the upper-left corner of the window over the game screen. The overlay is not
+// drawn on the image passed to Draw, so the game's rendering is not affected. The overlay shows:
+//
+//     * The current FPS and TPS, and their graphs
+//     * The 50th, 95th and 99th percentiles of the recent frame times, and the graph of the frame times
+//     * The number of the draw calls in the last frame, including the draw calls for the overlay itself
+//     * The number and the approximate size of the textures, including the internal texture atlases
+//     * The total number of the audio underruns (only on Linux and other Unix-like systems so far)
+//
+// As the overlay is included in screenshots of the window, the overlay is useful to capture the performance
+// context of a bug report. The overlay can be toggled by a key, for example:
+//
+//     if inpututil.IsKeyJustPressed(ebiten.KeyF3) {
+//         ebiten.SetPerformanceOverlayVisible(!ebiten.IsPerformanceOverlayVisible())
+//     }
+//
+// The default value is false.
+//
+// SetPerformanceOverlayVisible is concurrent-safe.
+func SetPerformanceOverlayVisible(visible bool) {
+	v := int32(0)
+	if visible {
+		v = 1
+	}
+	atomic.StoreInt32(&isPerformanceOverlayVisible, v)
+}
+
+const perfOverlayGlyphRunes = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ.:-/%"
+
+// perfOverlayGlyphs are the 3x5 pixel patterns of perfOverlayGlyphRunes.
+var perfOverlayGlyphs = [...]string{
+	"####.##.##.####", // 0
+	".#.##..#..#.###", // 1
+	"###..#####..###", // 2
+	"###..####..####", // 3
+	"#.##.####..#..#", // 4
+	"####..###..####", // 5
+	"####..####.####", // 6
+	"###..#..#..#..#", // 7
+	"####.#####.####", // 8
+	"####.####..####", // 9
+	".#.#.#####.##.#", // A
+	"##.#.###.#.###.", // B
+	".###..#..#...##", // C
+	"##.#.##.##.###.", // D
+	"####..##.#..###", // E
+	"####..##.#..#..", // F
+	".###..#.##.#.##", // G
+	"#.##.#####.##.#", // H
+	"###.#..#..#.###", // I
+	"..#..#..##.#.#.", // J
+	"#.##.###.#.##.#", // K
+	"#..#..#..#..###", // L
+	"#.########.##.#", // M
+	"##.#.##.##.##.#", // N
+	".#.#.##.##.#.#.", // O
+	"##.#.###.#..#..", // P
+	".#.#.##.###..##", // Q
+	"##.#.###.#.##.#", // R
+	".###...#...###.", // S
+	"###.#..#..#..#.", // T
+	"#.##.##.##.####", // U
+	"#.##.##.##.#.#.", // V
+	"#.##.########.#", // W
+	"#.##.#.#.#.##.#", // X
+	"#.##.#.#..#..#.", // Y
+	"###..#.#.#..###", // Z
+	".............#.", // .
+	"....#.....#....", // :
+	"......###......", // -
+	"..#..#.#.#..#..", // /
+	"#.#..#.#.#..#.#", // %
+}
+
+const (
+	perfOverlayGlyphWidth  = 3
+	perfOverlayGlyphHeight = 5
+	perfOverlayLineHeight  = perfOverlayGlyphHeight + 2
+
+	perfOverlayWidth       = 160
+	perfOverlayHeight      = 92
+	perfOverlayMargin      = 2
+	perfOverlayGraphWidth  = perfOverlayWidth - 2*perfOverlayMargin
+	perfOverlayGraphHeight = 24
+
+	// perfOverlayScale is the scale of the overlay in device-independent pixels.
+	perfOverlayScale = 2
+)
+
+type perfOverlay struct {
+	image  *Image
+	glyphs map[rune]*Image
+
+	fpsHistory []float64
+	tpsHistory []float64
+	frameTimes []time.Duration
+	sorted     []time.Duration
+}
+
+var thePerfOverlay perfOverlay
+
+func (p *perfOverlay) ensureImages() {
+	if p.image == nil {
+		p.image = newImage(perfOverlayWidth, perfOverlayHeight)
+	}
+	if p.glyphs != nil {
+		return
+	}
+
+	rgba := image.NewRGBA(image.Rect(0, 0, len(perfOverlayGlyphs)*perfOverlayGlyphWidth, perfOverlayGlyphHeight))
+	for i, g := range perfOverlayGlyphs {
+		for j, c := range g {
+			if c != '#' {
+				continue
+			}
+			rgba.Set(i*perfOverlayGlyphWidth+j%perfOverlayGlyphWidth, j/perfOverlayGlyphWidth, color.White)
+		}
+	}
+	img := newImageFromImage(rgba)
+	p.glyphs = map[rune]*Image{}
+	for i, r := range perfOverlayGlyphRunes {
+		x := i * perfOverlayGlyphWidth
+		p.glyphs[r] = img.SubImage(image.Rect(x, 0, x+perfOverlayGlyphWidth, perfOverlayGlyphHeight)).(*Image)
+	}
+}
+
+// update records the current statistics. update is called every frame while the overlay is visible.
+func (p *perfOverlay) update() {
+	p.fpsHistory = appendHistory(p.fpsHistory, CurrentFPS())
+	p.tpsHistory = appendHistory(p.tpsHistory, CurrentTPS())
+	p.frameTimes = stats.AppendFrameTimes(p.frameTimes[:0])
+}
+
+func appendHistory(history []float64, value float64) []float64 {
+	if len(history) == perfOverlayGraphWidth {
+		copy(history, history[1:])
+		history = history[:len(history)-1]
+	}
+	return append(history, value)
+}
+
+// percentile returns the frame time at the given percentile in milliseconds.
+func (p *perfOverlay) percentile(percent int) float64 {
+	if len(p.sorted) == 0 {
+		return 0
+	}
+	d := p.sorted[(len(p.sorted)-1)*percent/100]
+	return float64(d) / float64(time.Millisecond)
+}
+
+func (p *perfOverlay) draw(screen *Image, deviceScaleFactor float64, yDirection driver.YDirection) {
+	p.ensureImages()
+	p.image.Clear()
+
+	p.sorted = append(p.sorted[:0], p.frameTimes...)
+	sort.Slice(p.sorted, func(i, j int) bool {
+		return p.sorted[i] < p.sorted[j]
+	})
+
+	var fps, tps float64
+	if n := len(p.fpsHistory); n > 0 {
+		fps, tps = p.fpsHistory[n-1], p.tpsHistory[n-1]
+	}
+	textureCount, textureBytes := stats.Textures()
+
+	p.fillRect(0, 0, perfOverlayWidth, perfOverlayHeight, color.RGBA{0, 0, 0, 0xc0})
+
+	white := color.White
+	x, y := perfOverlayMargin, perfOverlayMargin
+	p.drawText(x, y, fmt.Sprintf("FPS %.1f  TPS %.1f", fps, tps), white)
+	y += perfOverlayLineHeight
+	p.drawText(x, y, fmt.Sprintf("FRAME P50 %.1f P95 %.1f P99 %.1f MS", p.percentile(50), p.percentile(95), p.percentile(99)), white)
+	y += perfOverlayLineHeight
+	p.drawText(x, y, fmt.Sprintf("DRAW CALLS %d", stats.DrawCalls()), white)
+	y += perfOverlayLineHeight
+	p.drawText(x, y, fmt.Sprintf("TEXTURES %d  %.1f MB", textureCount, float64(textureBytes)/(1<<20)), white)
+	y += perfOverlayLineHeight
+	p.drawText(x, y, fmt.Sprintf("AUDIO UNDERRUNS %d", stats.AudioUnderruns()), white)
+	y += perfOverlayLineHeight + 1
+
+	// The FPS and TPS graph.
+	maxRate := 60.0
+	for i := range p.fpsHistory {
+		if maxRate < p.fpsHistory[i] {
+			maxRate = p.fpsHistory[i]
+		}
+		if maxRate < p.tpsHistory[i] {
+			maxRate = p.tpsHistory[i]
+		}
+	}
+	p.fillRect(x, y, perfOverlayGraphWidth, perfOverlayGraphHeight, color.RGBA{0x40, 0x40, 0x40, 0xc0})
+	for i := range p.fpsHistory {
+		ty := y + perfOverlayGraphHeight - 1 - int(p.tpsHistory[i]/maxRate*(perfOverlayGraphHeight-1))
+		p.fillRect(x+i, ty, 1, 1, color.RGBA{0xff, 0xc0, 0x40, 0xff})
+		fy := y + perfOverlayGraphHeight - 1 - int(p.fpsHistory[i]/maxRate*(perfOverlayGraphHeight-1))
+		p.fillRect(x+i, fy, 1, 1, color.RGBA{0x40, 0xff, 0x40, 0xff})
+	}
+	y += perfOverlayGraphHeight + 2
+
+	// The frame time graph. The top of the graph is 2 frames at 60 FPS.
+	const maxFrameTime = time.Second / 30
+	p.fillRect(x, y, perfOverlayGraphWidth, perfOverlayGraphHeight, color.RGBA{0x40, 0x40, 0x40, 0xc0})
+	fts := p.frameTimes
+	if len(fts) > perfOverlayGraphWidth {
+		fts = fts[len(fts)-perfOverlayGraphWidth:]
+	}
+	for i, d := range fts {
+		if d > maxFrameTime {
+			d = maxFrameTime
+		}
+		h := int(int64(d) * perfOverlayGraphHeight / int64(maxFrameTime))
+		clr := color.RGBA{0x40, 0xc0, 0xff, 0xff}
+		if d > time.Second/50 {
+			clr = color.RGBA{0xff, 0x40, 0x40, 0xff}
+		}
+		p.fillRect(x+i, y+perfOverlayGraphHeight-h, 1, h, clr)
+	}
+
+	s := perfOverlayScale * deviceScaleFactor
+	op := &DrawImageOptions{}
+	switch yDirection {
+	case driver.Upward:
+		_, h := screen.Size()
+		op.GeoM.Scale(s, -s)
+		op.GeoM.Translate(0, float64(h))
+	case driver.Downward:
+		op.GeoM.Scale(s, s)
+	default:
+		panic(fmt.Sprintf("ebiten: invalid v-direction: %d", yDirection))
+	}
+	screen.DrawImage(p.image, op)
+}
+
+func (p *perfOverlay) drawText(x, y int, str string, clr color.Color) {
+	op := &DrawImageOptions{}
+	op.ColorM.Scale(colorToScale(clr))
+	for _, r := range strings.ToUpper(str) {
+		if g, ok := p.glyphs[r]; ok {
+			op.GeoM.Reset()
+			op.GeoM.Translate(float64(x), float64(y))
+			p.image.DrawImage(g, op)
+		}
+		x += perfOverlayGlyphWidth + 1
+	}
+}
+
+func (p *perfOverlay) fillRect(x, y, width, height int, clr color.Color) {
+	if width <= 0 || height <= 0 {
+		return
+	}
+	op := &DrawImageOptions{}
+	op.GeoM.Scale(float64(width), float64(height))
+	op.GeoM.Translate(float64(x), float64(y))
+	op.ColorM.Scale(colorToScale(clr))
+	p.image.DrawImage(emptySubImage, op)
+}
+
+func colorToScale(clr color.Color) (float64, float64, float64, float64) {
+	r, g, b, a := clr.RGBA()
+	if a == 0 {
+		return 0, 0, 0, 0
+	}
+	return float64(r) / float64(a), float64(g) / float64(a), float64(b) / float64(a), float64(a) / 0xffff
+}
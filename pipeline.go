@@ -0,0 +1,192 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten
+
+import (
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2/internal/stats"
+)
+
+// PipelinedGame is a Game whose drawing can run in parallel with the next frame's Update.
+//
+// When pipelined updating is enabled by SetPipelinedUpdateEnabled, the frame N's DrawSnapshot runs while the
+// frame N+1's Update runs on another goroutine. The data handoff between them is done only via Snapshot:
+//
+//     1. Update is called zero or more times as usual.
+//     2. Snapshot is called right after the last Update of the frame.
+//     3. In the next frame, DrawSnapshot is called with the snapshot, in parallel with the first Update call of
+//        the frame. The other Update calls of the frame, if any, are called after DrawSnapshot.
+//
+// Then, the rendered frames are one frame behind the game state.
+//
+// Update and Snapshot are never called in parallel with each other. The input and the tick information are
+// updated on the main loop before and after each Update call, so functions like IsKeyPressed work in Update as
+// usual.
+//
+// The value returned by Snapshot must not be modified by later Update calls, and DrawSnapshot must not read
+// the game state other than the snapshot. Update and DrawSnapshot can use images in parallel as long as they
+// don't use the same image. For example, Update must not draw onto images that DrawSnapshot reads.
+//
+// Game's Draw is not called while pipelined updating is enabled.
+type PipelinedGame interface {
+	Game
+
+	// Snapshot returns the immutable state to draw.
+	Snapshot() interface{}
+
+	// DrawSnapshot draws the game screen from the given snapshot.
+	DrawSnapshot(screen *Image, snapshot interface{})
+}
+
+var isPipelinedUpdateEnabled = int32(0)
+
+// SetPipelinedUpdateEnabled enables or disables pipelined updating.
+//
+// Pipelined updating works only when the game implements PipelinedGame. Otherwise, this is ignored.
+// See PipelinedGame for the contract of the handoff between Update and DrawSnapshot.
+//
+// Pipelined updating can raise the throughput of a CPU-bound game on a multicore machine, at the cost of one
+// frame latency.
+//
+// Pipelined updating is disabled by default.
+//
+// SetPipelinedUpdateEnabled is concurrent-safe.
+func SetPipelinedUpdateEnabled(enabled bool) {
+	v := int32(0)
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&isPipelinedUpdateEnabled, v)
+}
+
+// IsPipelinedUpdateEnabled reports whether pipelined updating is enabled.
+//
+// IsPipelinedUpdateEnabled is concurrent-safe.
+func IsPipelinedUpdateEnabled() bool {
+	return atomic.LoadInt32(&isPipelinedUpdateEnabled) != 0
+}
+
+type pipelinedUpdateResult struct {
+	err        error
+	duration   time.Duration
+	panicked   bool
+	panicValue interface{}
+	panicStack []byte
+}
+
+func (c *uiContext) pipelinedGame() (PipelinedGame, bool) {
+	if !IsPipelinedUpdateEnabled() {
+		return nil, false
+	}
+	g := c.game
+	if d, ok := g.(*imageDumperGame); ok {
+		g = d.game
+	}
+	if !isPipelinedGame(g) {
+		return nil, false
+	}
+	return c.game.(PipelinedGame), true
+}
+
+// updatePipelined runs the first Update call on another goroutine while drawing the last snapshot.
+//
+// Only Update runs on the other goroutine. The input and the tick information are processed on the caller
+// goroutine, as the UI driver is not concurrent-safe.
+func (c *uiContext) updatePipelined(g PipelinedGame, updateCount int) error {
+	if !c.hasSnapshot {
+		// There is no snapshot to draw yet e.g. in the first frame. Take a snapshot synchronously.
+		if err := c.runUpdates(updateCount); err != nil {
+			return err
+		}
+		c.snapshot = g.Snapshot()
+		c.hasSnapshot = true
+		updateCount = 0
+	}
+
+	var ch chan pipelinedUpdateResult
+	if updateCount > 0 {
+		if err := c.beginTick(false); err != nil {
+			return err
+		}
+		ch = make(chan pipelinedUpdateResult, 1)
+		go func() {
+			ch <- runPipelinedUpdate(g)
+		}()
+	}
+
+	t := time.Now()
+	if isOffscreenClearedEveryFrame() {
+		c.offscreen.Clear()
+	}
+	g.DrawSnapshot(c.offscreen, c.snapshot)
+	stats.AddPhaseTime(stats.PhaseDraw, time.Since(t))
+
+	if ch != nil {
+		r := <-ch
+		if r.panicked {
+			theCrashReporter.repanic(r.panicValue, r.panicStack)
+		}
+		if r.err != nil {
+			return r.err
+		}
+		c.endTick()
+		stats.AddPhaseTime(stats.PhaseUpdate, r.duration)
+
+		// There is nothing to draw in parallel with the rest of the Update calls.
+		t := time.Now()
+		for i := 1; i < updateCount; i++ {
+			if err := c.beginTick(true); err != nil {
+				return err
+			}
+			if err := updateGame(g); err != nil {
+				return err
+			}
+			c.endTick()
+		}
+		stats.AddPhaseTime(stats.PhaseUpdate, time.Since(t))
+
+		c.snapshot = g.Snapshot()
+	}
+
+	// Process the drawn screen after Update finishes so that the hooks don't run in parallel with Update.
+	t = time.Now()
+	if d, ok := g.(*imageDumperGame); ok {
+		d.afterDraw(c.offscreen)
+	}
+	c.drawScreen()
+	stats.AddPhaseTime(stats.PhaseDraw, time.Since(t))
+	return nil
+}
+
+// runPipelinedUpdate calls the game's Update. runPipelinedUpdate is called on another goroutine than the main
+// loop.
+func runPipelinedUpdate(g PipelinedGame) (r pipelinedUpdateResult) {
+	// Forward a panic to the caller goroutine so that it is handled in the same way as a regular frame.
+	defer func() {
+		if v := recover(); v != nil {
+			r.panicked = true
+			r.panicValue = v
+			r.panicStack = debug.Stack()
+		}
+	}()
+
+	t := time.Now()
+	r.err = updateGame(g)
+	r.duration = time.Since(t)
+	return
+}
@@ -0,0 +1,149 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sync/atomic"
+
+	"github.com/hajimehoshi/ebiten/v2/internal/driver"
+	"github.com/hajimehoshi/ebiten/v2/internal/graphics"
+)
+
+var currentRenderScale = math.Float64bits(1)
+
+// SetRenderScale sets the scale of the resolution to render the screen, relative to the logical screen size
+// returned by Layout.
+//
+// For example, with the scale 2, the screen is rendered at the doubled resolution and then downsampled to the
+// outside, which works as supersampling antialiasing. With the scale 0.75, the screen is rendered at the lower
+// resolution and then upscaled, which reduces the load of GPU. A game can offer a quality slider with this.
+//
+// The render scale doesn't change the coordinate system of the game. The screen image given to Draw still has
+// the logical size, and the drawing operations onto the screen and its sub-images are scaled automatically.
+// There are some limitations with a render scale other than 1:
+//
+//   - Using the screen image as a source is supported only by DrawImage, DrawSubImage, DrawImageAt,
+//     DrawImageParts and DrawList.DrawImage.
+//   - Positions in shaders are in the pixels of the render resolution.
+//   - At returns the color of the corresponding pixel at the render resolution, and Set and ReplacePixels
+//     are slower.
+//   - Screenshots are taken at the render resolution.
+//
+// The new scale is adopted at the next frame.
+//
+// The default render scale is 1.
+//
+// SetRenderScale panics if scale is not positive.
+//
+// SetRenderScale is concurrent-safe.
+func SetRenderScale(scale float64) {
+	if scale <= 0 || math.IsNaN(scale) || math.IsInf(scale, 0) {
+		panic("ebiten: scale must be positive at SetRenderScale")
+	}
+	atomic.StoreUint64(&currentRenderScale, math.Float64bits(scale))
+}
+
+// RenderScale returns the current render scale.
+//
+// RenderScale is concurrent-safe.
+func RenderScale() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&currentRenderScale))
+}
+
+// newImageWithRenderScale creates an image that has the given logical size and is rendered at the given scale.
+func newImageWithRenderScale(width, height int, scale float64) *Image {
+	if scale == 1 {
+		return newImage(width, height)
+	}
+	b := image.Rect(0, 0, width, height)
+	p := scaleRect(b, scale)
+	i := newImage(p.Dx(), p.Dy())
+	i.bounds = b
+	i.renderScale = scale
+	return i
+}
+
+// textureScale returns the scale of the underlying texture relative to the bounds of the image.
+func (i *Image) textureScale() float64 {
+	if i.isSubImage() {
+		i = i.original
+	}
+	if i.renderScale == 0 {
+		return 1
+	}
+	return i.renderScale
+}
+
+// physicalBounds returns the bounds of the image in the pixels of the underlying texture.
+func (i *Image) physicalBounds() image.Rectangle {
+	s := i.textureScale()
+	if s == 1 {
+		return i.bounds
+	}
+	return scaleRect(i.bounds, s)
+}
+
+// applyRenderScale scales the destination positions of the vertices and the destination region by the render
+// scale of the image i, and returns the scaled region.
+func (i *Image) applyRenderScale(vertices []float32, dstRegion driver.Region) driver.Region {
+	s := i.textureScale()
+	if s == 1 {
+		return dstRegion
+	}
+	s32 := float32(s)
+	for j := 0; j < len(vertices); j += graphics.VertexFloatNum {
+		vertices[j] *= s32
+		vertices[j+1] *= s32
+	}
+	return driver.Region{
+		X:      dstRegion.X * s32,
+		Y:      dstRegion.Y * s32,
+		Width:  dstRegion.Width * s32,
+		Height: dstRegion.Height * s32,
+	}
+}
+
+// setWithRenderScale sets the color at (x, y) of the image that has a render scale.
+func (i *Image) setWithRenderScale(x, y int, clr color.Color) {
+	op := &DrawImageOptions{}
+	op.GeoM.Translate(float64(x), float64(y))
+	op.ColorM.Scale(colorToScale(clr))
+	op.CompositeMode = CompositeModeCopy
+	i.DrawImage(emptySubImage, op)
+}
+
+// replacePixelsWithRenderScale replaces the pixels of the image that has a render scale.
+func (i *Image) replacePixelsWithRenderScale(pixels []byte) {
+	r := i.Bounds()
+	img := newImage(r.Dx(), r.Dy())
+	defer img.Dispose()
+	img.ReplacePixels(pixels)
+
+	op := &DrawImageOptions{}
+	op.GeoM.Translate(float64(r.Min.X), float64(r.Min.Y))
+	op.CompositeMode = CompositeModeCopy
+	i.DrawImage(img, op)
+}
+
+func scaleRect(r image.Rectangle, scale float64) image.Rectangle {
+	return image.Rect(
+		int(math.Floor(float64(r.Min.X)*scale)),
+		int(math.Floor(float64(r.Min.Y)*scale)),
+		int(math.Ceil(float64(r.Max.X)*scale)),
+		int(math.Ceil(float64(r.Max.Y)*scale)))
+}
@@ -0,0 +1,83 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten
+
+import (
+	"github.com/hajimehoshi/ebiten/v2/internal/atlas"
+	"github.com/hajimehoshi/ebiten/v2/internal/graphics"
+	"github.com/hajimehoshi/ebiten/v2/internal/graphicscommand"
+)
+
+// ResourceStats represents the statistics of the graphics resources.
+//
+// The numbers include the resources that Ebiten uses internally, e.g. the screen and mipmaps.
+type ResourceStats struct {
+	// TextureMemory is the estimated GPU memory for the textures in bytes.
+	//
+	// The actual usage depends on the graphics driver. Ebiten might also keep copies of pixels on CPU to
+	// restore the textures, which are not included.
+	TextureMemory int64
+
+	// AtlasNum is the number of the texture atlases.
+	AtlasNum int
+
+	// AtlasOccupancy is the ratio of the area allocated for images to the total area of the texture atlases,
+	// from 0 to 1. AtlasOccupancy is 0 when there is no atlas.
+	AtlasOccupancy float64
+
+	// ImageNum is the number of the live images that have textures.
+	// Images that are created but not used yet might not have textures.
+	ImageNum int
+
+	// ShaderNum is the number of the live shaders.
+	ShaderNum int
+}
+
+// ReadResourceStats returns the current statistics of the graphics resources.
+//
+// ReadResourceStats is useful to implement budgets for the resources, or to detect leaks of images and shaders
+// in long play sessions.
+//
+// When ReadResourceStats is called outside of Update and Draw, e.g. on another goroutine, ReadResourceStats
+// blocks until the next frame begins.
+//
+// ReadResourceStats is concurrent-safe.
+func ReadResourceStats() ResourceStats {
+	atlases, images := atlas.Infos()
+
+	var s ResourceStats
+	s.AtlasNum = len(atlases)
+	s.ImageNum = len(images)
+	s.ShaderNum = len(graphicscommand.Shaders())
+
+	var total, used int64
+	for _, a := range atlases {
+		area := int64(a.Size) * int64(a.Size)
+		total += area
+		used += int64(a.UsedArea)
+	}
+	s.TextureMemory = total * 4
+	if total > 0 {
+		s.AtlasOccupancy = float64(used) / float64(total)
+	}
+
+	for _, i := range images {
+		if i.Atlas >= 0 {
+			continue
+		}
+		s.TextureMemory += int64(graphics.InternalImageSize(i.Width)) * int64(graphics.InternalImageSize(i.Height)) * 4
+	}
+	return s
+}
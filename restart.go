@@ -0,0 +1,193 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten
+
+import (
+	"sync"
+
+	"github.com/hajimehoshi/ebiten/v2/internal/hooks"
+)
+
+// resources is a set of the images and the shaders created by users.
+//
+// An image or a shader is removed from resources when it is disposed. In other words, the images and the shaders
+// that are not disposed are kept alive until RestartGame disposes them.
+type resources struct {
+	images  map[*Image]struct{}
+	shaders map[*Shader]struct{}
+
+	m sync.Mutex
+}
+
+var theResources = &resources{
+	images:  map[*Image]struct{}{},
+	shaders: map[*Shader]struct{}{},
+}
+
+func (r *resources) addImage(img *Image) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.images[img] = struct{}{}
+}
+
+func (r *resources) removeImage(img *Image) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	delete(r.images, img)
+}
+
+func (r *resources) addShader(shader *Shader) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.shaders[shader] = struct{}{}
+}
+
+func (r *resources) removeShader(shader *Shader) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	delete(r.shaders, shader)
+}
+
+// allImages returns all the images that are not disposed yet.
+func (r *resources) allImages() []*Image {
+	r.m.Lock()
+	defer r.m.Unlock()
+	imgs := make([]*Image, 0, len(r.images))
+	for img := range r.images {
+		imgs = append(imgs, img)
+	}
+	return imgs
+}
+
+// allShaders returns all the shaders that are not disposed yet.
+func (r *resources) allShaders() []*Shader {
+	r.m.Lock()
+	defer r.m.Unlock()
+	shaders := make([]*Shader, 0, len(r.shaders))
+	for s := range r.shaders {
+		shaders = append(shaders, s)
+	}
+	return shaders
+}
+
+// disposeAll disposes all the images and the shaders.
+func (r *resources) disposeAll() {
+	imgs := r.allImages()
+	shaders := r.allShaders()
+
+	// Dispose removes the resources from r.
+	for _, img := range imgs {
+		img.Dispose()
+	}
+	for _, s := range shaders {
+		s.Dispose()
+	}
+}
+
+type restart struct {
+	game Game
+
+	m sync.Mutex
+}
+
+var theRestart = &restart{}
+
+func (r *restart) request(game Game) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.game = game
+}
+
+func (r *restart) take() (Game, bool) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	g := r.game
+	r.game = nil
+	return g, g != nil
+}
+
+// RestartGame ends the current game and restarts the main loop with the given game from a clean state.
+//
+// RestartGame is useful for flows like "return to the title and reload mods", where the resources of the current
+// game should be released before loading new ones.
+//
+// The restart happens at the beginning of the next frame, in this order:
+//
+//   - Shutdown of the current game is called if the current game implements Shutdowner.
+//   - All the images and the shaders created by NewImage, NewImageFromImage and NewShader are disposed,
+//     including the ones held by other packages like text and ebitenutil. The packages in Ebiten recreate their
+//     internal resources automatically.
+//   - All the playing audio players are closed. The audio context is kept, and the new game can get it by
+//     audio.CurrentContext.
+//   - The game is replaced with the given game. As well as the first frame, Layout and Update of the new game
+//     are called before Draw.
+//
+// The current game must not use any images, shaders or audio players after RestartGame is called, as they are
+// disposed.
+//
+// If RestartGame is called multiple times in one frame, the last game is adopted.
+//
+// RestartGame panics if game is nil.
+//
+// RestartGame is concurrent-safe.
+func RestartGame(game Game) {
+	if game == nil {
+		panic("ebiten: game must not be nil at RestartGame")
+	}
+	theRestart.request(game)
+}
+
+// restartIfNeeded restarts the game if RestartGame is called.
+//
+// restartIfNeeded must be called in a frame.
+func (c *uiContext) restartIfNeeded() error {
+	game, ok := theRestart.take()
+	if !ok {
+		return nil
+	}
+
+	c.m.Lock()
+	old := c.game
+	c.m.Unlock()
+	if d, ok := old.(*imageDumperGame); ok {
+		old = d.game
+	}
+	if s, ok := old.(Shutdowner); ok {
+		if err := s.Shutdown(); err != nil {
+			return err
+		}
+	}
+
+	theResources.disposeAll()
+
+	// Let the packages recreate their internal resources. The recreated resources can be disposed again at the
+	// next restart.
+	if err := hooks.RunResetHooks(); err != nil {
+		return err
+	}
+
+	c.set(&imageDumperGame{
+		game: game,
+	})
+	c.updateCalled = false
+	c.tick.reset()
+	c.snapshot = nil
+	c.hasSnapshot = false
+	if c.offscreen != nil {
+		c.offscreen.Clear()
+	}
+	theDrawSkip.invalidate()
+	return nil
+}
@@ -15,10 +15,12 @@
 package ebiten
 
 import (
+	"image"
 	"sync/atomic"
 
 	"github.com/hajimehoshi/ebiten/v2/internal/clock"
 	"github.com/hajimehoshi/ebiten/v2/internal/driver"
+	"github.com/hajimehoshi/ebiten/v2/internal/hooks"
 )
 
 // Game defines necessary functions for a game.
@@ -32,6 +34,8 @@ type Game interface {
 	//
 	// After the first frame, Update might not be called or might be called once
 	// or more for one frame. The frequency is determined by the current TPS (tick-per-second).
+	//
+	// If the game implements TickUpdater, UpdateWithTick is called instead of Update.
 	Update() error
 
 	// Draw draws the game screen by one frame.
@@ -56,6 +60,8 @@ type Game interface {
 	//
 	// You can return a fixed screen size if you don't care, or you can also return a calculated screen size
 	// adjusted with the given outside size.
+	//
+	// If the game implements LayoutFGame, LayoutF is called instead of Layout.
 	Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeight int)
 }
 
@@ -121,9 +127,46 @@ func (i *imageDumperGame) Draw(screen *Image) {
 	}
 
 	i.game.Draw(screen)
+	i.afterDraw(screen)
+}
+
+// DrawSnapshot draws the snapshot with the game's DrawSnapshot.
+//
+// Unlike Draw, DrawSnapshot does not process the drawn screen. afterDraw must be called after DrawSnapshot
+// when the game's Update is not running.
+func (i *imageDumperGame) DrawSnapshot(screen *Image, snapshot interface{}) {
+	if i.err != nil {
+		return
+	}
+	i.game.(PipelinedGame).DrawSnapshot(screen, snapshot)
+}
+
+// Snapshot returns the game's snapshot.
+func (i *imageDumperGame) Snapshot() interface{} {
+	return i.game.(PipelinedGame).Snapshot()
+}
+
+// afterDraw processes the drawn screen e.g. for screenshots.
+func (i *imageDumperGame) afterDraw(screen *Image) {
+	if i.err != nil {
+		return
+	}
+	if err := theCaptureHotkeys.capture(screen); err != nil {
+		i.err = err
+		return
+	}
+	if hooks.HasScreenHooks() {
+		hooks.RunScreenHooks(func() (image.Image, error) {
+			return screenToRGBA(screen)
+		})
+	}
 	i.err = i.d.dump(screen)
 }
 
+func (i *imageDumperGame) LayoutF(outsideWidth, outsideHeight float64) (screenWidth, screenHeight float64) {
+	return layoutGame(i.game, outsideWidth, outsideHeight)
+}
+
 func (i *imageDumperGame) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeight int) {
 	return i.game.Layout(outsideWidth, outsideHeight)
 }
@@ -161,6 +204,9 @@ func (i *imageDumperGame) Layout(outsideWidth, outsideHeight int) (screenWidth,
 //
 // The size unit is device-independent pixel.
 //
+// If game implements Shutdowner, Shutdown is called when the game ends regularly, e.g. the window is closed.
+// See also RunGameWithContext.
+//
 // Don't call RunGame twice or more in one process.
 func RunGame(game Game) error {
 	defer atomic.StoreInt32(&isRunGameEnded_, 1)
@@ -268,8 +314,11 @@ func IsFullscreen() bool {
 // On desktops, Ebiten uses 'windowed' fullscreen mode, which doesn't change
 // your monitor's resolution.
 //
-// On browsers, triggering fullscreen requires a user gesture otherwise SetFullscreen does nothing but leave an error message in console.
+// On browsers, triggering fullscreen requires a user gesture. If SetFullscreen(true) is called without a user gesture,
+// the request is deferred until the next user gesture on the canvas, like releasing a key or a mouse button.
 // This behaviour varies across browser implementations, your mileage may vary.
+// The user can exit fullscreen anytime, e.g. by pressing the ESC key. IsFullscreen reflects the actual state.
+// See also SetFullscreenOrientationLock.
 //
 // SetFullscreen does nothing on mobiles.
 //
@@ -304,8 +353,9 @@ func IsRunnableOnUnfocused() bool {
 // If the given value is true, the game runs even in background e.g. when losing focus.
 // The initial state is true.
 //
-// Known issue: On browsers, even if the state is on, the game doesn't run in background tabs.
+// On browsers, even if the state is on, the game doesn't run in background tabs.
 // This is because browsers throttles background tabs not to often update.
+// See SetOnSuspend and SetAudioAliveInBackground for background tabs.
 //
 // SetRunnableOnUnfocused does nothing on mobiles so far.
 //
@@ -409,6 +459,24 @@ func SetScreenTransparent(transparent bool) {
 	uiDriver().SetScreenTransparent(transparent)
 }
 
+// SetCanvasContainerID moves the canvas into the HTML element specified by the ID on browsers.
+//
+// The canvas fills the element, and the element's size is used as the outside size given to the game's Layout.
+// The size and the device pixel ratio are tracked, so the game adapts to the page's layout without any JavaScript
+// code. By default, the canvas is in the document's body. If id is an empty string, the canvas is moved back to
+// the body.
+//
+// The element must have a size, e.g. by CSS. Otherwise the outside size is zero.
+//
+// SetCanvasContainerID returns an error if the element is not found.
+//
+// SetCanvasContainerID does nothing on non-browsers, or in a Web Worker.
+//
+// SetCanvasContainerID is concurrent-safe.
+func SetCanvasContainerID(id string) error {
+	return setCanvasContainerID(id)
+}
+
 // SetInitFocused sets whether the application is focused on show.
 // The default value is true, i.e., the application is focused.
 // Note that the application does not proceed if this is not focused by default.
@@ -0,0 +1,53 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+var screenBufferCount = int32(0)
+
+// SetScreenBufferCount sets the number of the screen buffers, which determines how many frames can be in flight.
+//
+// 2 means double buffering, 3 means triple buffering, and 0 means the default of the graphics driver.
+// Double buffering reduces the latency from input to display by one frame at the cost of the throughput,
+// which is good for twitch games. Triple buffering keeps the throughput when the rendering time is unstable.
+//
+// The behavior depends on the graphics driver:
+//
+//     Metal:  The count is the maximum number of the drawables. The default is 3.
+//     OpenGL: The number of the buffers cannot be controlled directly. With the count 2, Ebiten waits for the GPU
+//             to finish rendering at the end of each frame, so that the driver doesn't queue frames.
+//             The count 3 is the same as the default.
+//
+// SetScreenBufferCount panics if count is not 0, 2 or 3.
+//
+// SetScreenBufferCount is concurrent-safe.
+func SetScreenBufferCount(count int) {
+	if count != 0 && count != 2 && count != 3 {
+		panic(fmt.Sprintf("ebiten: count must be 0, 2 or 3 but %d", count))
+	}
+	atomic.StoreInt32(&screenBufferCount, int32(count))
+	uiDriver().Graphics().SetScreenBufferCount(count)
+}
+
+// ScreenBufferCount returns the number of the screen buffers set by SetScreenBufferCount.
+//
+// ScreenBufferCount is concurrent-safe.
+func ScreenBufferCount() int {
+	return int(atomic.LoadInt32(&screenBufferCount))
+}
@@ -0,0 +1,145 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/hajimehoshi/ebiten/v2/internal/buffered"
+)
+
+// Shutdowner is an optional interface for a Game to be notified when the game ends.
+type Shutdowner interface {
+	// Shutdown is called once when the game ends regularly, after the last Draw and before the window and the
+	// graphics resources are torn down. Shutdown is useful to save the game state or to close network
+	// sessions.
+	//
+	// Shutdown is called when
+	//
+	//   * the window is closed on desktops,
+	//   * the context given to RunGameWithContext is cancelled,
+	//   * an interrupt signal is received with RunGameOptions.TerminateOnInterrupt, or
+	//   * TerminateGame is called in the view on mobiles.
+	//
+	// Shutdown is not called when the game ends with an error, e.g. when Update returns an error.
+	//
+	// Images can be used in Shutdown.
+	//
+	// If Shutdown returns an error, RunGame returns the error.
+	Shutdown() error
+}
+
+// RunGameOptions represents options for RunGameWithContext.
+type RunGameOptions struct {
+	// TerminateOnInterrupt specifies whether the game ends regularly when the process receives an interrupt
+	// signal (SIGINT or SIGTERM). Shutdown of the game is called in this case.
+	//
+	// TerminateOnInterrupt is available only on desktops.
+	//
+	// The default (zero) value is false, which means the default behavior of the signals, i.e. terminating the
+	// process immediately.
+	TerminateOnInterrupt bool
+}
+
+// RunGameWithContext starts the main loop and runs the game in the same way as RunGame, and ends the game
+// regularly when ctx is cancelled.
+//
+// When ctx is cancelled, the main loop ends after the current frame, and Shutdown of the game is called if the
+// game implements Shutdowner. Then, RunGameWithContext returns nil.
+//
+// options can be nil. In this case, the default options are used.
+//
+// RunGameWithContext must be called on the main thread.
+//
+// Don't call RunGameWithContext or RunGame twice or more in one process.
+func RunGameWithContext(ctx context.Context, game Game, options *RunGameOptions) error {
+	if options == nil {
+		options = &RunGameOptions{}
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+
+	var sig chan os.Signal
+	if options.TerminateOnInterrupt {
+		sig = make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(sig)
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-sig:
+		case <-done:
+			return
+		}
+		theUIContext.requestTermination()
+	}()
+
+	return RunGame(game)
+}
+
+func (c *uiContext) requestTermination() {
+	atomic.StoreInt32(&c.terminationRequested, 1)
+}
+
+func (c *uiContext) isTerminationRequested() bool {
+	return atomic.LoadInt32(&c.terminationRequested) != 0
+}
+
+// Shutdown calls the game's Shutdown in a frame. Shutdown calls the game's Shutdown at most once.
+//
+// Shutdown must be called on the same goroutine as Update.
+func (c *uiContext) Shutdown() error {
+	c.m.Lock()
+	if c.shutdownCalled {
+		c.m.Unlock()
+		return nil
+	}
+	c.shutdownCalled = true
+	g := c.game
+	c.m.Unlock()
+
+	if d, ok := g.(*imageDumperGame); ok {
+		g = d.game
+	}
+	s, ok := g.(Shutdowner)
+	if !ok {
+		return nil
+	}
+
+	if err, ok := c.err.Load().(error); ok && err != nil {
+		return err
+	}
+
+	// Images are available only in a frame.
+	if err := buffered.BeginFrame(); err != nil {
+		return err
+	}
+	if err := c.shutdown(s); err != nil {
+		return err
+	}
+	return buffered.EndFrame()
+}
+
+func (c *uiContext) shutdown(s Shutdowner) error {
+	defer theCrashReporter.handlePanic()
+	return s.Shutdown()
+}
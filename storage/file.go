@@ -0,0 +1,135 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !js
+// +build !js
+
+package storage
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type fileBackend struct {
+	dir string
+}
+
+func openBackend(name string) (backend, error) {
+	root, err := rootDir()
+	if err != nil {
+		return nil, fmt.Errorf("storage: the directory is not available: %v", err)
+	}
+	dir := filepath.Join(root, name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &fileBackend{dir: dir}, nil
+}
+
+// encodeKey converts a key to a file name.
+//
+// All the characters except for lower-case letters, digits, '-' and '_' are percent-encoded. Upper-case letters
+// are encoded too, as file systems can be case-insensitive. As '.' is encoded, a file name never starts with '.'.
+func encodeKey(key string) string {
+	var sb strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' {
+			sb.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&sb, "%%%02X", c)
+	}
+	return sb.String()
+}
+
+func (f *fileBackend) path(key string) string {
+	return filepath.Join(f.dir, encodeKey(key))
+}
+
+func (f *fileBackend) load(key string) ([]byte, error) {
+	bs, err := ioutil.ReadFile(f.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrNotExist
+	}
+	return bs, err
+}
+
+func (f *fileBackend) save(key string, data []byte) error {
+	// Write a temporary file and rename it so that the old data is not broken even if writing fails.
+	// The temporary file's name starts with '.', which is never used for keys.
+	tmp, err := ioutil.TempFile(f.dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), f.path(key)); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+func (f *fileBackend) delete(key string) error {
+	if err := os.Remove(f.path(key)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (f *fileBackend) keys() ([]string, error) {
+	infos, err := ioutil.ReadDir(f.dir)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		key, err := url.PathUnescape(name)
+		if err != nil {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func estimate() (int64, int64, error) {
+	return -1, -1, nil
+}
+
+func persist() (bool, error) {
+	return true, nil
+}
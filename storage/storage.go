@@ -0,0 +1,150 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package storage provides a persistent key-value storage for save data and settings.
+//
+// On desktops and mobiles, each value is stored as a file in the user's configuration directory (e.g.
+// %AppData% on Windows, ~/Library/Application Support on macOS and iOS, and ~/.config on Linux), or in the
+// application's files directory on Android.
+//
+// On browsers, the values are stored in IndexedDB. If IndexedDB is not available, localStorage is used instead.
+// The browser can evict the data under storage pressure unless Persist succeeds.
+//
+// On browsers, the functions block until the browser finishes the operation. Don't call them in JavaScript
+// callbacks.
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+var (
+	// ErrNotExist is returned when the key doesn't exist.
+	ErrNotExist = os.ErrNotExist
+
+	// ErrQuotaExceeded is returned when the storage's quota is exceeded.
+	ErrQuotaExceeded = errors.New("storage: quota exceeded")
+)
+
+type backend interface {
+	load(key string) ([]byte, error)
+	save(key string, data []byte) error
+	delete(key string) error
+	keys() ([]string, error)
+}
+
+// Storage is a persistent key-value storage.
+type Storage struct {
+	backend backend
+	m       sync.Mutex
+}
+
+// Open opens the storage for the application.
+//
+// name identifies the application, e.g. "com.example.mygame". name is used as a directory name on desktops and
+// mobiles, and must not contain path separators.
+//
+// On Android, Open fails until the game's view is created.
+func Open(name string) (*Storage, error) {
+	if name == "" || name == "." || name == ".." {
+		return nil, fmt.Errorf("storage: invalid name: %q", name)
+	}
+	for _, r := range name {
+		if r == '/' || r == '\\' || r == 0 {
+			return nil, fmt.Errorf("storage: invalid name: %q", name)
+		}
+	}
+	b, err := openBackend(name)
+	if err != nil {
+		return nil, err
+	}
+	return &Storage{
+		backend: b,
+	}, nil
+}
+
+func checkKey(key string) error {
+	if key == "" {
+		return errors.New("storage: key must not be empty")
+	}
+	return nil
+}
+
+// Load returns the value for the key.
+//
+// Load returns ErrNotExist if the key doesn't exist.
+//
+// Load is concurrent-safe.
+func (s *Storage) Load(key string) ([]byte, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.backend.load(key)
+}
+
+// Save stores the value for the key. The value is replaced atomically: the old value is kept if Save fails.
+//
+// Save returns ErrQuotaExceeded if there is not enough space.
+//
+// Save is concurrent-safe.
+func (s *Storage) Save(key string, data []byte) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.backend.save(key, data)
+}
+
+// Delete removes the key. Delete does nothing if the key doesn't exist.
+//
+// Delete is concurrent-safe.
+func (s *Storage) Delete(key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.backend.delete(key)
+}
+
+// Keys returns all the keys in the storage. The order is undefined.
+//
+// Keys is concurrent-safe.
+func (s *Storage) Keys() ([]string, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.backend.keys()
+}
+
+// Estimate returns the usage and the quota of the storage in bytes.
+//
+// On browsers, the values are the estimations for the whole origin. On the other platforms, Estimate returns -1
+// for both as the values are unknown.
+func Estimate() (usage, quota int64, err error) {
+	return estimate()
+}
+
+// Persist requests the browser not to evict the data under storage pressure, and reports whether the request is
+// granted. The browser might ask the user for the permission.
+//
+// Persist always returns true on non-browsers.
+func Persist() (bool, error) {
+	return persist()
+}
@@ -0,0 +1,238 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"syscall/js"
+
+	"github.com/hajimehoshi/ebiten/v2/internal/web"
+)
+
+const objectStoreName = "values"
+
+func openBackend(name string) (backend, error) {
+	dbName := "ebiten-storage:" + name
+	if idb := js.Global().Get("indexedDB"); idb.Truthy() {
+		req := idb.Call("open", dbName, 1)
+		var f js.Func
+		f = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			req.Get("result").Call("createObjectStore", objectStoreName)
+			return nil
+		})
+		req.Set("onupgradeneeded", f)
+		db, err := awaitRequest(req)
+		req.Set("onupgradeneeded", nil)
+		f.Release()
+		if err == nil {
+			return &indexedDBBackend{db: db}, nil
+		}
+		// IndexedDB can be unavailable e.g. in a private mode of some browsers. Try localStorage.
+	}
+	if ls := js.Global().Get("localStorage"); ls.Truthy() {
+		return &localStorageBackend{
+			storage: ls,
+			prefix:  dbName + "/",
+		}, nil
+	}
+	return nil, errors.New("storage: neither IndexedDB nor localStorage is available")
+}
+
+// toError converts a DOMException to an error.
+func toError(e js.Value) error {
+	if !e.Truthy() {
+		return errors.New("storage: unknown error")
+	}
+	if e.Get("name").String() == "QuotaExceededError" {
+		return ErrQuotaExceeded
+	}
+	return fmt.Errorf("storage: %s", e.Call("toString").String())
+}
+
+// awaitRequest waits for an IDBRequest to finish.
+func awaitRequest(req js.Value) (js.Value, error) {
+	ch := make(chan struct{})
+	var result js.Value
+	var err error
+	success := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		result = req.Get("result")
+		close(ch)
+		return nil
+	})
+	defer success.Release()
+	failure := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		err = toError(req.Get("error"))
+		close(ch)
+		return nil
+	})
+	defer failure.Release()
+	req.Set("onsuccess", success)
+	req.Set("onerror", failure)
+	<-ch
+	return result, err
+}
+
+// awaitTransaction waits for an IDBTransaction to be committed.
+func awaitTransaction(tx js.Value) error {
+	ch := make(chan struct{})
+	var err error
+	complete := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		close(ch)
+		return nil
+	})
+	defer complete.Release()
+	abort := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		err = toError(tx.Get("error"))
+		close(ch)
+		return nil
+	})
+	defer abort.Release()
+	tx.Set("oncomplete", complete)
+	tx.Set("onabort", abort)
+	<-ch
+	return err
+}
+
+type indexedDBBackend struct {
+	db js.Value
+}
+
+func (i *indexedDBBackend) objectStore(mode string) (js.Value, js.Value) {
+	tx := i.db.Call("transaction", objectStoreName, mode)
+	return tx, tx.Call("objectStore", objectStoreName)
+}
+
+func (i *indexedDBBackend) load(key string) ([]byte, error) {
+	_, s := i.objectStore("readonly")
+	v, err := awaitRequest(s.Call("get", key))
+	if err != nil {
+		return nil, err
+	}
+	if v.IsUndefined() {
+		return nil, ErrNotExist
+	}
+	bs := make([]byte, v.Get("byteLength").Int())
+	js.CopyBytesToGo(bs, v)
+	return bs, nil
+}
+
+func (i *indexedDBBackend) save(key string, data []byte) error {
+	a := js.Global().Get("Uint8Array").New(len(data))
+	js.CopyBytesToJS(a, data)
+
+	tx, s := i.objectStore("readwrite")
+	s.Call("put", a, key)
+	// The data is stored when the transaction is committed.
+	return awaitTransaction(tx)
+}
+
+func (i *indexedDBBackend) delete(key string) error {
+	tx, s := i.objectStore("readwrite")
+	s.Call("delete", key)
+	return awaitTransaction(tx)
+}
+
+func (i *indexedDBBackend) keys() ([]string, error) {
+	_, s := i.objectStore("readonly")
+	v, err := awaitRequest(s.Call("getAllKeys"))
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, v.Length())
+	for j := 0; j < v.Length(); j++ {
+		keys = append(keys, v.Index(j).String())
+	}
+	return keys, nil
+}
+
+// localStorageBackend stores values as base64 strings, as localStorage can store only strings.
+type localStorageBackend struct {
+	storage js.Value
+	prefix  string
+}
+
+func (l *localStorageBackend) load(key string) ([]byte, error) {
+	v := l.storage.Call("getItem", l.prefix+key)
+	if v.IsNull() {
+		return nil, ErrNotExist
+	}
+	return base64.StdEncoding.DecodeString(v.String())
+}
+
+func (l *localStorageBackend) save(key string, data []byte) (err error) {
+	// setItem throws an exception when the quota is exceeded.
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(js.Error)
+			if !ok {
+				panic(r)
+			}
+			err = toError(e.Value)
+		}
+	}()
+	l.storage.Call("setItem", l.prefix+key, base64.StdEncoding.EncodeToString(data))
+	return nil
+}
+
+func (l *localStorageBackend) delete(key string) error {
+	l.storage.Call("removeItem", l.prefix+key)
+	return nil
+}
+
+func (l *localStorageBackend) keys() ([]string, error) {
+	var keys []string
+	n := l.storage.Get("length").Int()
+	for i := 0; i < n; i++ {
+		k := l.storage.Call("key", i).String()
+		if len(k) > len(l.prefix) && k[:len(l.prefix)] == l.prefix {
+			keys = append(keys, k[len(l.prefix):])
+		}
+	}
+	return keys, nil
+}
+
+func storageManager() js.Value {
+	nav := js.Global().Get("navigator")
+	if !nav.Truthy() {
+		return js.Undefined()
+	}
+	return nav.Get("storage")
+}
+
+func estimate() (int64, int64, error) {
+	m := storageManager()
+	if !m.Truthy() || !m.Get("estimate").Truthy() {
+		return -1, -1, nil
+	}
+	v, err := web.AwaitPromise(m.Call("estimate"))
+	if err != nil {
+		return 0, 0, fmt.Errorf("storage: estimate failed: %v", err)
+	}
+	return int64(v.Get("usage").Float()), int64(v.Get("quota").Float()), nil
+}
+
+func persist() (bool, error) {
+	m := storageManager()
+	if !m.Truthy() || !m.Get("persist").Truthy() {
+		return false, nil
+	}
+	v, err := web.AwaitPromise(m.Call("persist"))
+	if err != nil {
+		return false, fmt.Errorf("storage: persist failed: %v", err)
+	}
+	return v.Bool(), nil
+}
@@ -0,0 +1,114 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !js
+// +build !js
+
+package storage_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"sort"
+	"testing"
+
+	. "github.com/hajimehoshi/ebiten/v2/storage"
+)
+
+func TestSaveLoad(t *testing.T) {
+	s := NewStorageInDirForTesting(t.TempDir())
+
+	if _, err := s.Load("foo"); err != ErrNotExist {
+		t.Errorf("got: %v, want: %v", err, ErrNotExist)
+	}
+
+	keys := []string{"foo", "Foo", "save/1.dat", ".hidden", "セーブ"}
+	for i, k := range keys {
+		if err := s.Save(k, []byte{byte(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, k := range keys {
+		got, err := s.Load(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []byte{byte(i)}; !bytes.Equal(got, want) {
+			t.Errorf("Load(%q): got: %v, want: %v", k, got, want)
+		}
+	}
+
+	got, err := s.Keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(got)
+	want := append([]string{}, keys...)
+	sort.Strings(want)
+	if len(got) != len(want) {
+		t.Fatalf("Keys(): got: %q, want: %q", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("Keys(): got: %q, want: %q", got, want)
+		}
+	}
+}
+
+func TestSaveOverwrite(t *testing.T) {
+	dir := t.TempDir()
+	s := NewStorageInDirForTesting(dir)
+
+	if err := s.Save("foo", []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save("foo", []byte("bye")); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.Load("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte("bye"); !bytes.Equal(got, want) {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+
+	// No temporary files should remain.
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 {
+		t.Errorf("the number of files: got: %d, want: 1", len(infos))
+	}
+}
+
+func TestDelete(t *testing.T) {
+	s := NewStorageInDirForTesting(t.TempDir())
+
+	if err := s.Save("foo", []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete("foo"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Load("foo"); !os.IsNotExist(err) {
+		t.Errorf("got: %v, want: not exist", err)
+	}
+	// Deleting a non-existing key is not an error.
+	if err := s.Delete("foo"); err != nil {
+		t.Error(err)
+	}
+}
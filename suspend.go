@@ -0,0 +1,66 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten
+
+// SetOnSuspend sets a function called when the game is suspended on browsers.
+//
+// The game is suspended when the page gets hidden, e.g. by switching tabs or minimizing the window, or when the
+// page is being unloaded. While the game is suspended, Update and Draw are not called. f is a good place to pause
+// the game's simulation and save its state.
+//
+// f is called synchronously from the browser's event handler, as the page might be frozen or discarded soon.
+// f must not block.
+//
+// If f is nil, no function is called.
+//
+// SetOnSuspend does nothing on non-browsers.
+//
+// SetOnSuspend is concurrent-safe.
+func SetOnSuspend(f func()) {
+	setOnSuspend(f)
+}
+
+// SetOnResume sets a function called when the game is resumed on browsers, i.e. when the page gets visible again.
+//
+// f is called synchronously from the browser's event handler. f must not block.
+//
+// If f is nil, no function is called.
+//
+// SetOnResume does nothing on non-browsers.
+//
+// SetOnResume is concurrent-safe.
+func SetOnResume(f func()) {
+	setOnResume(f)
+}
+
+// IsAudioAliveInBackground reports whether the audio keeps playing while the game is suspended on browsers.
+//
+// IsAudioAliveInBackground is concurrent-safe.
+func IsAudioAliveInBackground() bool {
+	return isAudioAliveInBackground()
+}
+
+// SetAudioAliveInBackground sets whether the audio keeps playing while the game is suspended on browsers.
+//
+// The default value is false, i.e., the audio is suspended while the page is hidden.
+//
+// If IsRunnableOnUnfocused is false, the audio is suspended while the page is unfocused regardless of this setting.
+//
+// SetAudioAliveInBackground does nothing on non-browsers.
+//
+// SetAudioAliveInBackground is concurrent-safe.
+func SetAudioAliveInBackground(alive bool) {
+	setAudioAliveInBackground(alive)
+}
@@ -0,0 +1,84 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten
+
+import (
+	"image"
+	"path"
+	"reflect"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2/internal/testhook"
+)
+
+func init() {
+	testhook.ReadPixels = func(img interface{}) (*image.RGBA, error) {
+		return readPixels(img.(*Image))
+	}
+	testhook.GraphicsBackend = func() string {
+		// The package name of the graphics driver is the backend name, e.g. opengl or metal.
+		t := reflect.TypeOf(uiDriver().Graphics())
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		return path.Base(t.PkgPath())
+	}
+	testhook.NewRunner = func(game interface{}) testhook.Runner {
+		return newTestRunner(game.(Game))
+	}
+}
+
+// testRunner is a headless uiContext for the ebitentest package.
+type testRunner struct {
+	c *uiContext
+}
+
+func newTestRunner(game Game) *testRunner {
+	tps := MaxTPS()
+	if tps <= 0 {
+		tps = DefaultTPS
+	}
+	return &testRunner{
+		c: &uiContext{
+			game: game,
+			tick: &tickState{
+				step: time.Second / time.Duration(tps),
+			},
+			headless: true,
+		},
+	}
+}
+
+func (r *testRunner) Advance() error {
+	return r.c.updateHeadless()
+}
+
+func (r *testRunner) SetOutsideSize(width, height float64) {
+	r.c.Layout(width, height)
+}
+
+func (r *testRunner) Screen() interface{} {
+	if r.c.offscreen == nil {
+		return nil
+	}
+	return r.c.offscreen
+}
+
+func (r *testRunner) Dispose() {
+	if r.c.offscreen != nil {
+		r.c.offscreen.Dispose()
+		r.c.offscreen = nil
+	}
+}
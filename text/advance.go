@@ -31,6 +31,9 @@ func glyphAdvance(face font.Face, r rune) fixed.Int26_6 {
 	a, ok := m[r]
 	if !ok {
 		a, _ = face.GlyphAdvance(r)
+		if getFaceOptions(face).Hinting == font.HintingFull {
+			a = fixed.I(a.Round())
+		}
 		m[r] = a
 	}
 
@@ -0,0 +1,211 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"golang.org/x/image/font"
+)
+
+// arabicForms is the presentation forms of an Arabic letter: isolated, final, initial and medial.
+// A letter without initial and medial forms joins only to the previous letter.
+type arabicForms [4]rune
+
+func (a arabicForms) isDualJoining() bool {
+	return a[2] != 0
+}
+
+const (
+	arabicIsolated = iota
+	arabicFinal
+	arabicInitial
+	arabicMedial
+)
+
+var arabicLetters = map[rune]arabicForms{
+	0x0621: {0xFE80, 0, 0, 0},
+	0x0622: {0xFE81, 0xFE82, 0, 0},
+	0x0623: {0xFE83, 0xFE84, 0, 0},
+	0x0624: {0xFE85, 0xFE86, 0, 0},
+	0x0625: {0xFE87, 0xFE88, 0, 0},
+	0x0626: {0xFE89, 0xFE8A, 0xFE8B, 0xFE8C},
+	0x0627: {0xFE8D, 0xFE8E, 0, 0},
+	0x0628: {0xFE8F, 0xFE90, 0xFE91, 0xFE92},
+	0x0629: {0xFE93, 0xFE94, 0, 0},
+	0x062A: {0xFE95, 0xFE96, 0xFE97, 0xFE98},
+	0x062B: {0xFE99, 0xFE9A, 0xFE9B, 0xFE9C},
+	0x062C: {0xFE9D, 0xFE9E, 0xFE9F, 0xFEA0},
+	0x062D: {0xFEA1, 0xFEA2, 0xFEA3, 0xFEA4},
+	0x062E: {0xFEA5, 0xFEA6, 0xFEA7, 0xFEA8},
+	0x062F: {0xFEA9, 0xFEAA, 0, 0},
+	0x0630: {0xFEAB, 0xFEAC, 0, 0},
+	0x0631: {0xFEAD, 0xFEAE, 0, 0},
+	0x0632: {0xFEAF, 0xFEB0, 0, 0},
+	0x0633: {0xFEB1, 0xFEB2, 0xFEB3, 0xFEB4},
+	0x0634: {0xFEB5, 0xFEB6, 0xFEB7, 0xFEB8},
+	0x0635: {0xFEB9, 0xFEBA, 0xFEBB, 0xFEBC},
+	0x0636: {0xFEBD, 0xFEBE, 0xFEBF, 0xFEC0},
+	0x0637: {0xFEC1, 0xFEC2, 0xFEC3, 0xFEC4},
+	0x0638: {0xFEC5, 0xFEC6, 0xFEC7, 0xFEC8},
+	0x0639: {0xFEC9, 0xFECA, 0xFECB, 0xFECC},
+	0x063A: {0xFECD, 0xFECE, 0xFECF, 0xFED0},
+	0x0641: {0xFED1, 0xFED2, 0xFED3, 0xFED4},
+	0x0642: {0xFED5, 0xFED6, 0xFED7, 0xFED8},
+	0x0643: {0xFED9, 0xFEDA, 0xFEDB, 0xFEDC},
+	0x0644: {0xFEDD, 0xFEDE, 0xFEDF, 0xFEE0},
+	0x0645: {0xFEE1, 0xFEE2, 0xFEE3, 0xFEE4},
+	0x0646: {0xFEE5, 0xFEE6, 0xFEE7, 0xFEE8},
+	0x0647: {0xFEE9, 0xFEEA, 0xFEEB, 0xFEEC},
+	0x0648: {0xFEED, 0xFEEE, 0, 0},
+	0x0649: {0xFEEF, 0xFEF0, 0, 0},
+	0x064A: {0xFEF1, 0xFEF2, 0xFEF3, 0xFEF4},
+
+	// Letters for Persian and Urdu.
+	0x0671: {0xFB50, 0xFB51, 0, 0},
+	0x0679: {0xFB66, 0xFB67, 0xFB68, 0xFB69},
+	0x067E: {0xFB56, 0xFB57, 0xFB58, 0xFB59},
+	0x0686: {0xFB7A, 0xFB7B, 0xFB7C, 0xFB7D},
+	0x0688: {0xFB88, 0xFB89, 0, 0},
+	0x0691: {0xFB8C, 0xFB8D, 0, 0},
+	0x0698: {0xFB8A, 0xFB8B, 0, 0},
+	0x06A9: {0xFB8E, 0xFB8F, 0xFB90, 0xFB91},
+	0x06AF: {0xFB92, 0xFB93, 0xFB94, 0xFB95},
+	0x06BE: {0xFBAA, 0xFBAB, 0xFBAC, 0xFBAD},
+	0x06C1: {0xFBA6, 0xFBA7, 0xFBA8, 0xFBA9},
+	0x06CC: {0xFBFC, 0xFBFD, 0xFBFE, 0xFBFF},
+	0x06D2: {0xFBAE, 0xFBAF, 0, 0},
+}
+
+// lamAlefLigatures is the isolated and final forms of the ligatures of Lam and Alef variants.
+var lamAlefLigatures = map[rune][2]rune{
+	0x0622: {0xFEF5, 0xFEF6},
+	0x0623: {0xFEF7, 0xFEF8},
+	0x0625: {0xFEF9, 0xFEFA},
+	0x0627: {0xFEFB, 0xFEFC},
+}
+
+const (
+	arabicLam     = 0x0644
+	arabicTatweel = 0x0640
+	zeroWidthJoin = 0x200D
+)
+
+// isArabicTransparent reports whether r is skipped to determine the joining of the adjacent letters,
+// e.g. a diacritic mark.
+func isArabicTransparent(r rune) bool {
+	return (0x064B <= r && r <= 0x065F) || r == 0x0670 || (0x06D6 <= r && r <= 0x06ED && r != 0x06DD && r != 0x06DE)
+}
+
+// joinsToNext reports whether r can join to the next letter.
+func joinsToNext(r rune) bool {
+	if r == arabicTatweel || r == zeroWidthJoin {
+		return true
+	}
+	f, ok := arabicLetters[r]
+	return ok && f.isDualJoining()
+}
+
+// joinsToPrev reports whether r can join to the previous letter.
+func joinsToPrev(r rune) bool {
+	if r == arabicTatweel || r == zeroWidthJoin {
+		return true
+	}
+	f, ok := arabicLetters[r]
+	return ok && f[arabicFinal] != 0
+}
+
+// shapeArabic replaces Arabic letters with their contextual presentation forms in the logical order.
+//
+// A presentation form is used only when the face has its glyph.
+func shapeArabic(face font.Face, rs []rune) []rune {
+	out, _ := shapeArabicWithIndices(face, rs)
+	return out
+}
+
+// shapeArabicWithIndices is like shapeArabic, but also returns the index in rs of the first rune for each
+// returned rune. A ligature is made from multiple runes.
+func shapeArabicWithIndices(face font.Face, rs []rune) ([]rune, []int) {
+	has := func(r rune) bool {
+		return hasGlyph(face, r)
+	}
+
+	// prevIndex and nextIndex skip transparent characters.
+	prevIndex := func(i int) int {
+		for i--; i >= 0; i-- {
+			if !isArabicTransparent(rs[i]) {
+				return i
+			}
+		}
+		return -1
+	}
+	nextIndex := func(i int) int {
+		for i++; i < len(rs); i++ {
+			if !isArabicTransparent(rs[i]) {
+				return i
+			}
+		}
+		return -1
+	}
+
+	out := make([]rune, 0, len(rs))
+	indices := make([]int, 0, len(rs))
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+		indices = append(indices, i)
+		forms, ok := arabicLetters[r]
+		if !ok {
+			out = append(out, r)
+			continue
+		}
+
+		p := prevIndex(i)
+		joinPrev := p >= 0 && joinsToNext(rs[p]) && joinsToPrev(r)
+
+		// Lam followed by Alef forms a mandatory ligature.
+		if r == arabicLam && i+1 < len(rs) {
+			if lig, ok := lamAlefLigatures[rs[i+1]]; ok {
+				l := lig[0]
+				if joinPrev {
+					l = lig[1]
+				}
+				if has(l) {
+					out = append(out, l)
+					i++
+					continue
+				}
+			}
+		}
+
+		n := nextIndex(i)
+		joinNext := n >= 0 && joinsToNext(r) && joinsToPrev(rs[n])
+
+		var form int
+		switch {
+		case joinPrev && joinNext:
+			form = arabicMedial
+		case joinPrev:
+			form = arabicFinal
+		case joinNext:
+			form = arabicInitial
+		default:
+			form = arabicIsolated
+		}
+		if f := forms[form]; f != 0 && has(f) {
+			out = append(out, f)
+			continue
+		}
+		out = append(out, r)
+	}
+	return out, indices
+}
@@ -0,0 +1,192 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text_test
+
+import (
+	"image"
+	"testing"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+// runeFace is a face that has glyphs only for the given runes.
+// If runes is nil, the face has glyphs for all the runes.
+type runeFace struct {
+	runes map[rune]struct{}
+}
+
+func (f *runeFace) has(r rune) bool {
+	if f.runes == nil {
+		return true
+	}
+	_, ok := f.runes[r]
+	return ok
+}
+
+func (f *runeFace) Close() error {
+	return nil
+}
+
+func (f *runeFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	return image.Rectangle{}, nil, image.Point{}, 0, false
+}
+
+func (f *runeFace) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
+	if !f.has(r) {
+		return fixed.Rectangle26_6{}, 0, false
+	}
+	return fixed.R(0, -10, 10, 0), fixed.I(10), true
+}
+
+func (f *runeFace) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
+	if !f.has(r) {
+		return 0, false
+	}
+	return fixed.I(10), true
+}
+
+func (f *runeFace) Kern(r0, r1 rune) fixed.Int26_6 {
+	return 0
+}
+
+func (f *runeFace) Metrics() font.Metrics {
+	return font.Metrics{
+		Height:  fixed.I(12),
+		Ascent:  fixed.I(10),
+		Descent: fixed.I(2),
+	}
+}
+
+func TestShapeArabic(t *testing.T) {
+	const (
+		beh       = 'ب'
+		dal       = 'د'
+		lam       = 'ل'
+		alef      = 'ا'
+		hamza     = 'ء'
+		fatha     = 'َ'
+		tatweel   = 'ـ'
+		zwj       = '‍'
+		behIsol   = 'ﺏ'
+		behFina   = 'ﺐ'
+		behInit   = 'ﺑ'
+		behMedi   = 'ﺒ'
+		dalIsol   = 'ﺩ'
+		dalFina   = 'ﺪ'
+		lamAlef   = 'ﻻ'
+		lamAlefF  = 'ﻼ'
+		lamInit   = 'ﻟ'
+		hamzaIsol = 'ﺀ'
+	)
+
+	cases := []struct {
+		Name  string
+		In    []rune
+		Out   []rune
+		Runes []rune
+	}{
+		{
+			Name: "isolated",
+			In:   []rune{beh},
+			Out:  []rune{behIsol},
+		},
+		{
+			Name: "initial and final",
+			In:   []rune{beh, beh},
+			Out:  []rune{behInit, behFina},
+		},
+		{
+			Name: "medial",
+			In:   []rune{beh, beh, beh},
+			Out:  []rune{behInit, behMedi, behFina},
+		},
+		{
+			Name: "right-joining letter",
+			In:   []rune{beh, dal, beh},
+			Out:  []rune{behInit, dalFina, behIsol},
+		},
+		{
+			Name: "non-joining letter",
+			In:   []rune{beh, hamza, beh},
+			Out:  []rune{behIsol, hamzaIsol, behIsol},
+		},
+		{
+			Name: "isolated right-joining letter",
+			In:   []rune{dal},
+			Out:  []rune{dalIsol},
+		},
+		{
+			Name: "transparent mark",
+			In:   []rune{beh, fatha, beh},
+			Out:  []rune{behInit, fatha, behFina},
+		},
+		{
+			Name: "tatweel",
+			In:   []rune{beh, tatweel},
+			Out:  []rune{behInit, tatweel},
+		},
+		{
+			Name: "zero width joiner",
+			In:   []rune{zwj, beh},
+			Out:  []rune{zwj, behFina},
+		},
+		{
+			Name: "lam alef ligature",
+			In:   []rune{lam, alef},
+			Out:  []rune{lamAlef},
+		},
+		{
+			Name: "final lam alef ligature",
+			In:   []rune{beh, lam, alef},
+			Out:  []rune{behInit, lamAlefF},
+		},
+		{
+			Name: "non-Arabic",
+			In:   []rune("a" + string(beh) + "b"),
+			Out:  []rune{'a', behIsol, 'b'},
+		},
+		{
+			Name:  "missing presentation form",
+			In:    []rune{beh, beh},
+			Out:   []rune{behInit, beh},
+			Runes: []rune{beh, behInit},
+		},
+		{
+			Name:  "missing ligature",
+			In:    []rune{lam, alef},
+			Out:   []rune{lamInit, alef},
+			Runes: []rune{lam, alef, lamInit},
+		},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			f := &runeFace{}
+			if c.Runes != nil {
+				f.runes = map[rune]struct{}{}
+				for _, r := range c.Runes {
+					f.runes[r] = struct{}{}
+				}
+			}
+			got := text.ShapeArabic(f, c.In)
+			if string(got) != string(c.Out) {
+				t.Errorf("got: %U, want: %U", got, c.Out)
+			}
+		})
+	}
+}
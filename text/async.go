@@ -0,0 +1,206 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"image"
+	"sync"
+
+	"golang.org/x/image/font"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// GlyphRasterizationModeType represents how glyphs that are not in the cache are rasterized.
+type GlyphRasterizationModeType int
+
+const (
+	// GlyphRasterizationModeSync rasterizes a new glyph immediately when the glyph is drawn.
+	// Drawing a text with many new glyphs can take a long time in the frame.
+	//
+	// GlyphRasterizationModeSync is the default mode.
+	GlyphRasterizationModeSync GlyphRasterizationModeType = iota
+
+	// GlyphRasterizationModeAsync rasterizes a new glyph on a background worker.
+	// A glyph is not drawn until its rasterization finishes, which usually takes one frame.
+	GlyphRasterizationModeAsync
+)
+
+var glyphRasterizationMode = GlyphRasterizationModeSync
+
+// GlyphRasterizationMode returns the current glyph rasterization mode.
+//
+// GlyphRasterizationMode is concurrent-safe.
+func GlyphRasterizationMode() GlyphRasterizationModeType {
+	textM.Lock()
+	defer textM.Unlock()
+	return glyphRasterizationMode
+}
+
+// SetGlyphRasterizationMode sets the glyph rasterization mode.
+//
+// With GlyphRasterizationModeAsync, a text revealing many new glyphs at once, like scrolling credits or a chat log,
+// doesn't cause a spike of the frame time. Instead, the new glyphs appear a little later than the other glyphs.
+// CacheGlyphs and CacheGlyphRange also request the glyphs to the background worker without waiting for them.
+//
+// The face methods are called on the background worker, but never at the same time as the functions of this package.
+// Effects like outlines, shadows and distance fields are applied on the background worker in parallel.
+//
+// SetGlyphRasterizationMode is concurrent-safe.
+func SetGlyphRasterizationMode(mode GlyphRasterizationModeType) {
+	textM.Lock()
+	defer textM.Unlock()
+	glyphRasterizationMode = mode
+}
+
+type glyphJob struct {
+	face font.Face
+	key  glyphImageKey
+}
+
+type rasterizedGlyph struct {
+	face font.Face
+	key  glyphImageKey
+
+	// image is nil if the glyph is empty.
+	image image.Image
+}
+
+var (
+	// glyphWorkerM protects glyphJobs, rasterizedGlyphs and glyphWorkerStarted.
+	// glyphWorkerM can be locked while textM is locked, but not vice versa.
+	glyphWorkerM       sync.Mutex
+	glyphWorkerCond    = sync.NewCond(&glyphWorkerM)
+	glyphJobs          []glyphJob
+	rasterizedGlyphs   []rasterizedGlyph
+	glyphWorkerStarted bool
+
+	// pendingGlyphs is the set of the glyphs requested to the worker. pendingGlyphs is protected by textM.
+	pendingGlyphs = map[font.Face]map[glyphImageKey]struct{}{}
+)
+
+// requestGlyph requests the glyph to the background worker.
+func requestGlyph(face font.Face, key glyphImageKey) {
+	if _, ok := pendingGlyphs[face][key]; ok {
+		return
+	}
+	if _, ok := pendingGlyphs[face]; !ok {
+		pendingGlyphs[face] = map[glyphImageKey]struct{}{}
+	}
+	pendingGlyphs[face][key] = struct{}{}
+
+	glyphWorkerM.Lock()
+	defer glyphWorkerM.Unlock()
+
+	glyphJobs = append(glyphJobs, glyphJob{
+		face: face,
+		key:  key,
+	})
+	if !glyphWorkerStarted {
+		go glyphWorker()
+		glyphWorkerStarted = true
+	}
+	glyphWorkerCond.Signal()
+}
+
+func glyphWorker() {
+	for {
+		glyphWorkerM.Lock()
+		for len(glyphJobs) == 0 {
+			glyphWorkerCond.Wait()
+		}
+		j := glyphJobs[0]
+		glyphJobs[0] = glyphJob{}
+		glyphJobs = glyphJobs[1:]
+		glyphWorkerM.Unlock()
+
+		// A face is not concurrent-safe in general. Use the face only while textM is locked.
+		textM.Lock()
+		if _, ok := pendingGlyphs[j.face][j.key]; !ok {
+			// The request was canceled.
+			textM.Unlock()
+			continue
+		}
+		rgba := rasterizeGlyph(j.face, j.key.rune, j.key.subpixel)
+		textM.Unlock()
+
+		var img image.Image
+		if rgba != nil {
+			img = j.key.variant.apply(rgba)
+		}
+
+		glyphWorkerM.Lock()
+		rasterizedGlyphs = append(rasterizedGlyphs, rasterizedGlyph{
+			face:  j.face,
+			key:   j.key,
+			image: img,
+		})
+		glyphWorkerM.Unlock()
+	}
+}
+
+// flushRasterizedGlyphs puts the glyphs rasterized by the worker into the cache.
+func flushRasterizedGlyphs() {
+	glyphWorkerM.Lock()
+	gs := rasterizedGlyphs
+	rasterizedGlyphs = nil
+	glyphWorkerM.Unlock()
+
+	for _, g := range gs {
+		// The glyph might be cleared after the request.
+		if _, ok := pendingGlyphs[g.face][g.key]; !ok {
+			continue
+		}
+		delete(pendingGlyphs[g.face], g.key)
+		if len(pendingGlyphs[g.face]) == 0 {
+			delete(pendingGlyphs, g.face)
+		}
+
+		if _, ok := glyphImageCache[g.face]; !ok {
+			glyphImageCache[g.face] = map[glyphImageKey]*glyphImageCacheEntry{}
+		}
+		if _, ok := glyphImageCache[g.face][g.key]; ok {
+			continue
+		}
+		var img *ebiten.Image
+		if g.image != nil {
+			img = ebiten.NewImageFromImage(g.image)
+		}
+		glyphImageCache[g.face][g.key] = &glyphImageCacheEntry{
+			image: img,
+			atime: now(),
+		}
+	}
+}
+
+// cancelGlyphRequests cancels the requests of the face's glyphs that are not rasterized yet.
+func cancelGlyphRequests(face font.Face) {
+	delete(pendingGlyphs, face)
+
+	glyphWorkerM.Lock()
+	defer glyphWorkerM.Unlock()
+
+	js := glyphJobs[:0]
+	for _, j := range glyphJobs {
+		if j.face == face {
+			continue
+		}
+		js = append(js, j)
+	}
+	for i := len(js); i < len(glyphJobs); i++ {
+		glyphJobs[i] = glyphJob{}
+	}
+	glyphJobs = js
+}
@@ -0,0 +1,325 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"golang.org/x/text/unicode/bidi"
+)
+
+// needsShaping reports whether the text might need bidirectional reordering or Arabic shaping.
+func needsShaping(text string) bool {
+	for _, r := range text {
+		// Hebrew, Arabic and other right-to-left scripts start at U+0590.
+		// Directional formatting characters like U+200F are also in this range.
+		if r >= 0x0590 {
+			return true
+		}
+	}
+	return false
+}
+
+// bidiReorder returns the runes of a line in the visual order by a simplified version of the Unicode Bidirectional
+// Algorithm (UAX #9).
+//
+// Explicit embeddings, overrides and isolates are ignored. The paragraph direction is determined by the first strong
+// character. Characters with an odd level are mirrored if they have mirrored pairs.
+func bidiReorder(rs []rune) []rune {
+	out, _, _ := bidiReorderWithIndices(rs)
+	return out
+}
+
+// bidiReorderWithIndices is like bidiReorder, but also returns the index in rs for each returned rune, and whether
+// each returned rune is in a right-to-left run.
+func bidiReorderWithIndices(rs []rune) ([]rune, []int, []bool) {
+	n := len(rs)
+	indices := make([]int, n)
+	rtl := make([]bool, n)
+	for i := range indices {
+		indices[i] = i
+	}
+	if n == 0 {
+		return rs, indices, rtl
+	}
+
+	classes := make([]bidi.Class, n)
+	orig := make([]bidi.Class, n)
+	for i, r := range rs {
+		p, _ := bidi.LookupRune(r)
+		c := p.Class()
+		// Explicit formatting characters are not supported. Treat them as boundary neutrals.
+		if c >= bidi.Control {
+			c = bidi.BN
+		}
+		classes[i] = c
+		orig[i] = c
+	}
+
+	// P2, P3: Determine the paragraph level by the first strong character.
+	var paraLevel int
+	for _, c := range classes {
+		if c == bidi.L {
+			break
+		}
+		if c == bidi.R || c == bidi.AL {
+			paraLevel = 1
+			break
+		}
+	}
+	sos := bidi.L
+	if paraLevel == 1 {
+		sos = bidi.R
+	}
+
+	// X9: Boundary neutrals are treated as the previous character's type.
+	for i, c := range classes {
+		if c != bidi.BN {
+			continue
+		}
+		if i == 0 {
+			classes[i] = sos
+		} else {
+			classes[i] = classes[i-1]
+		}
+	}
+
+	// W1: Nonspacing marks get the type of the previous character.
+	for i, c := range classes {
+		if c != bidi.NSM {
+			continue
+		}
+		if i == 0 {
+			classes[i] = sos
+		} else {
+			classes[i] = classes[i-1]
+		}
+	}
+
+	// W2: European numbers after Arabic letters become Arabic numbers.
+	// W3: Arabic letters become R.
+	lastStrong := sos
+	for i, c := range classes {
+		switch c {
+		case bidi.L, bidi.R, bidi.AL:
+			lastStrong = c
+		case bidi.EN:
+			if lastStrong == bidi.AL {
+				classes[i] = bidi.AN
+			}
+		}
+	}
+	for i, c := range classes {
+		if c == bidi.AL {
+			classes[i] = bidi.R
+		}
+	}
+
+	// W4: A single separator between two numbers of the same type gets the number type.
+	for i := 1; i < n-1; i++ {
+		prev, next := classes[i-1], classes[i+1]
+		switch classes[i] {
+		case bidi.ES:
+			if prev == bidi.EN && next == bidi.EN {
+				classes[i] = bidi.EN
+			}
+		case bidi.CS:
+			if prev == next && (prev == bidi.EN || prev == bidi.AN) {
+				classes[i] = prev
+			}
+		}
+	}
+
+	// W5: European terminators adjacent to European numbers become European numbers.
+	for i := 0; i < n; i++ {
+		if classes[i] != bidi.ET {
+			continue
+		}
+		end := i
+		for end < n && classes[end] == bidi.ET {
+			end++
+		}
+		if (i > 0 && classes[i-1] == bidi.EN) || (end < n && classes[end] == bidi.EN) {
+			for j := i; j < end; j++ {
+				classes[j] = bidi.EN
+			}
+		}
+		i = end - 1
+	}
+
+	// W6: Other separators and terminators become neutrals.
+	for i, c := range classes {
+		if c == bidi.ES || c == bidi.ET || c == bidi.CS {
+			classes[i] = bidi.ON
+		}
+	}
+
+	// W7: European numbers after L become L.
+	lastStrong = sos
+	for i, c := range classes {
+		switch c {
+		case bidi.L, bidi.R:
+			lastStrong = c
+		case bidi.EN:
+			if lastStrong == bidi.L {
+				classes[i] = bidi.L
+			}
+		}
+	}
+
+	// N1, N2: Neutrals between the same directions get the direction. Otherwise, they get the paragraph direction.
+	isNeutral := func(c bidi.Class) bool {
+		return c == bidi.B || c == bidi.S || c == bidi.WS || c == bidi.ON
+	}
+	strongDir := func(c bidi.Class) bidi.Class {
+		// Numbers are treated as R.
+		if c == bidi.L {
+			return bidi.L
+		}
+		return bidi.R
+	}
+	for i := 0; i < n; i++ {
+		if !isNeutral(classes[i]) {
+			continue
+		}
+		end := i
+		for end < n && isNeutral(classes[end]) {
+			end++
+		}
+		before := sos
+		if i > 0 {
+			before = strongDir(classes[i-1])
+		}
+		after := sos
+		if end < n {
+			after = strongDir(classes[end])
+		}
+		dir := sos
+		if before == after {
+			dir = before
+		}
+		for j := i; j < end; j++ {
+			classes[j] = dir
+		}
+		i = end - 1
+	}
+
+	// I1, I2: Resolve the implicit levels.
+	levels := make([]int, n)
+	for i, c := range classes {
+		l := paraLevel
+		if paraLevel%2 == 0 {
+			switch c {
+			case bidi.R:
+				l++
+			case bidi.AN, bidi.EN:
+				l += 2
+			}
+		} else {
+			switch c {
+			case bidi.L, bidi.EN, bidi.AN:
+				l++
+			}
+		}
+		levels[i] = l
+	}
+
+	// L1: Separators and trailing whitespaces are reset to the paragraph level.
+	trailing := true
+	for i := n - 1; i >= 0; i-- {
+		switch orig[i] {
+		case bidi.S, bidi.B:
+			levels[i] = paraLevel
+			trailing = true
+		case bidi.WS, bidi.BN:
+			if trailing {
+				levels[i] = paraLevel
+			}
+		default:
+			trailing = false
+		}
+	}
+
+	// L4: Mirror characters with odd levels.
+	out := make([]rune, n)
+	copy(out, rs)
+	for i, l := range levels {
+		if l%2 == 1 {
+			rtl[i] = true
+			if m, ok := mirroredRunes[out[i]]; ok {
+				out[i] = m
+			}
+		}
+	}
+
+	// L2: Reverse any contiguous sequence at the level or higher, from the highest level to the lowest odd level.
+	maxLevel, minOddLevel := 0, -1
+	for _, l := range levels {
+		if maxLevel < l {
+			maxLevel = l
+		}
+		if l%2 == 1 && (minOddLevel < 0 || l < minOddLevel) {
+			minOddLevel = l
+		}
+	}
+	if minOddLevel < 0 {
+		return out, indices, rtl
+	}
+	for level := maxLevel; level >= minOddLevel; level-- {
+		for i := 0; i < n; i++ {
+			if levels[i] < level {
+				continue
+			}
+			end := i
+			for end < n && levels[end] >= level {
+				end++
+			}
+			for a, b := i, end-1; a < b; a, b = a+1, b-1 {
+				out[a], out[b] = out[b], out[a]
+				levels[a], levels[b] = levels[b], levels[a]
+				indices[a], indices[b] = indices[b], indices[a]
+				rtl[a], rtl[b] = rtl[b], rtl[a]
+			}
+			i = end
+		}
+	}
+	return out, indices, rtl
+}
+
+// mirroredRunes is a subset of the Bidi_Mirroring_Glyph property for commonly used characters.
+var mirroredRunes = map[rune]rune{
+	'(': ')',
+	')': '(',
+	'<': '>',
+	'>': '<',
+	'[': ']',
+	']': '[',
+	'{': '}',
+	'}': '{',
+	'«': '»',
+	'»': '«',
+	'‹': '›',
+	'›': '‹',
+	'≤': '≥',
+	'≥': '≤',
+	'〈': '〉',
+	'〉': '〈',
+	'《': '》',
+	'》': '《',
+	'「': '」',
+	'」': '「',
+	'『': '』',
+	'』': '『',
+	'【': '】',
+	'】': '【',
+}
@@ -0,0 +1,99 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text_test
+
+import (
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+func TestBidiReorder(t *testing.T) {
+	cases := []struct {
+		Name string
+		In   string
+		Out  string
+	}{
+		{
+			Name: "empty",
+			In:   "",
+			Out:  "",
+		},
+		{
+			Name: "left-to-right only",
+			In:   "abc def",
+			Out:  "abc def",
+		},
+		{
+			Name: "right-to-left only",
+			In:   "אבג דהו",
+			Out:  "והד גבא",
+		},
+		{
+			Name: "right-to-left in a left-to-right paragraph",
+			In:   "ab אבג cd",
+			Out:  "ab גבא cd",
+		},
+		{
+			Name: "left-to-right in a right-to-left paragraph",
+			In:   "אבג abc דהו",
+			Out:  "והד abc גבא",
+		},
+		{
+			Name: "neutrals between different directions",
+			In:   "אבג, abc",
+			Out:  "abc ,גבא",
+		},
+		{
+			Name: "European numbers in a right-to-left paragraph",
+			In:   "אב 123",
+			Out:  "123 בא",
+		},
+		{
+			Name: "number with separators",
+			In:   "אב 1,234.5",
+			Out:  "1,234.5 בא",
+		},
+		{
+			Name: "European numbers after Arabic letters",
+			In:   "بت 12",
+			Out:  "12 تب",
+		},
+		{
+			Name: "mirrored brackets",
+			In:   "א(ב)",
+			Out:  "(ב)א",
+		},
+		{
+			Name: "brackets in a left-to-right paragraph",
+			In:   "a (אב)",
+			Out:  "a (בא)",
+		},
+		{
+			Name: "nonspacing mark",
+			In:   "بَت",
+			Out:  "تَب",
+		},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			got := string(text.BidiReorder([]rune(c.In)))
+			if got != c.Out {
+				t.Errorf("got: %q, want: %q", got, c.Out)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"golang.org/x/image/font"
+)
+
+// DefaultGlyphCacheSize is the default soft limit of the number of cached glyphs per face.
+const DefaultGlyphCacheSize = 512
+
+// glyphCacheSize indicates the soft limit of the number of glyphs in the cache per face.
+// If the number of glyphs exceeds this soft limits, old glyphs are removed.
+// Even after clearning up the cache, the number of glyphs might still exceeds the soft limit, but
+// this is fine.
+var glyphCacheSize = DefaultGlyphCacheSize
+
+// glyphCacheEvictionTicks is the number of ticks after which an unused glyph can be evicted.
+// 60 is an arbitrary number.
+const glyphCacheEvictionTicks = 60
+
+// GlyphCacheSize returns the soft limit of the number of cached glyphs per face.
+//
+// GlyphCacheSize is concurrent-safe.
+func GlyphCacheSize() int {
+	textM.Lock()
+	defer textM.Unlock()
+	return glyphCacheSize
+}
+
+// SetGlyphCacheSize sets the soft limit of the number of cached glyphs per face.
+//
+// When the number of the cached glyphs of a face exceeds the limit, the glyphs that have not been used for a while
+// are evicted. The glyphs used recently are never evicted, so the number of the cached glyphs can exceed the limit.
+//
+// For a game using many glyphs like CJK characters, a bigger size avoids creating the same glyphs again and again.
+//
+// If size is 0 or negative, DefaultGlyphCacheSize is used.
+//
+// SetGlyphCacheSize is concurrent-safe.
+func SetGlyphCacheSize(size int) {
+	textM.Lock()
+	defer textM.Unlock()
+	if size <= 0 {
+		size = DefaultGlyphCacheSize
+	}
+	glyphCacheSize = size
+}
+
+// ClearGlyphCache evicts all the cached glyphs and measurements of the given face.
+//
+// If face is nil, all the cached glyphs of all the faces are evicted.
+//
+// After ClearGlyphCache, the face is no longer held by this package until the face is used again. This is useful
+// to release a face that is no longer used.
+//
+// ClearGlyphCache is concurrent-safe.
+func ClearGlyphCache(face font.Face) {
+	textM.Lock()
+	defer textM.Unlock()
+
+	if face == nil {
+		for f := range glyphImageCache {
+			clearGlyphCache(f)
+		}
+		for f := range glyphBoundsCache {
+			clearGlyphCache(f)
+		}
+		for f := range glyphAdvanceCache {
+			clearGlyphCache(f)
+		}
+		for f := range pendingGlyphs {
+			clearGlyphCache(f)
+		}
+		return
+	}
+	clearGlyphCache(face)
+}
+
+func clearGlyphCache(face font.Face) {
+	for _, e := range glyphImageCache[face] {
+		if e.image != nil {
+			e.image.Dispose()
+		}
+	}
+	delete(glyphImageCache, face)
+	cancelGlyphRequests(face)
+	delete(glyphBoundsCache, face)
+	delete(glyphAdvanceCache, face)
+	for k := range shapedTextCache {
+		if k.face == face {
+			delete(shapedTextCache, k)
+		}
+	}
+}
+
+// CacheGlyphRange precaches the glyphs for the runes in the range [from, to] into the cache.
+//
+// CacheGlyphRange is useful to create glyphs at a loading time so that the first frame drawing new glyphs doesn't
+// hitch. For example, CacheGlyphRange(face, 0x3041, 0x3096) caches all the Hiragana characters.
+//
+// Runes that the face doesn't have are skipped. See NewMultiFace for how to determine whether the face has the glyph.
+//
+// Like CacheGlyphs, the glyphs cached by CacheGlyphRange can be evicted. To keep the glyphs in the cache, set a cache
+// size bigger than the number of the glyphs by SetGlyphCacheSize.
+//
+// CacheGlyphRange is concurrent-safe.
+func CacheGlyphRange(face font.Face, from, to rune) {
+	textM.Lock()
+	defer textM.Unlock()
+
+	if from > to {
+		return
+	}
+	for r := from; ; r++ {
+		if hasGlyph(face, r) {
+			getGlyphImage(face, r, glyphVariant{}, 0)
+		}
+		// Avoid the overflow when to is the maximum value.
+		if r == to {
+			break
+		}
+	}
+}
+
+// cleanUpGlyphCache evicts old glyphs of the face if the number of the glyphs exceeds the soft limit.
+func cleanUpGlyphCache(face font.Face) {
+	if len(glyphImageCache[face]) <= glyphCacheSize {
+		return
+	}
+	for k, e := range glyphImageCache[face] {
+		if e.atime < now()-glyphCacheEvictionTicks {
+			if e.image != nil {
+				e.image.Dispose()
+			}
+			delete(glyphImageCache[face], k)
+		}
+	}
+}
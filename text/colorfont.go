@@ -0,0 +1,618 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"sort"
+	"sync"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// colorGlyphFace is implemented by faces that have color glyphs.
+type colorGlyphFace interface {
+	// colorGlyph returns the color image of the glyph and the bounds relative to the dot.
+	// The bounds are aligned with whole pixels. colorGlyph returns false if r doesn't have a color glyph.
+	colorGlyph(r rune) (*image.RGBA, fixed.Rectangle26_6, bool)
+}
+
+// isColorGlyph reports whether r has a color glyph in face.
+func isColorGlyph(face font.Face, r rune) bool {
+	cf, ok := face.(colorGlyphFace)
+	if !ok {
+		return false
+	}
+	_, _, ok = cf.colorGlyph(r)
+	return ok
+}
+
+// NewColorFace creates a new face from an OpenType font that has color glyphs, like color emoji fonts.
+//
+// The color glyphs in the sbix table (Apple), the CBDT/CBLC tables (Google) and the COLR/CPAL tables (Microsoft)
+// are supported. The layers of COLR with the foreground color are rendered in white. The first palette of CPAL
+// is used. Glyphs without color are rendered like a face created by opentype.NewFace.
+//
+// Color glyphs are drawn with their own colors. The color given to Draw affects only the alpha.
+// Outlines and shadows are drawn with the silhouettes of the color glyphs.
+func NewColorFace(src []byte, options *opentype.FaceOptions) (font.Face, error) {
+	f, err := opentype.Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	face, err := opentype.NewFace(f, options)
+	if err != nil {
+		return nil, err
+	}
+
+	tables, err := parseTableDirectory(src)
+	if err != nil {
+		return nil, err
+	}
+
+	size, dpi := 12.0, 72.0
+	var hinting font.Hinting
+	if options != nil {
+		if options.Size > 0 {
+			size = options.Size
+		}
+		if options.DPI > 0 {
+			dpi = options.DPI
+		}
+		hinting = options.Hinting
+	}
+
+	return &colorFace{
+		Face:    face,
+		font:    f,
+		sbix:    tables["sbix"],
+		cblc:    tables["CBLC"],
+		cbdt:    tables["CBDT"],
+		colr:    tables["COLR"],
+		cpal:    tables["CPAL"],
+		ppem:    size * dpi / 72,
+		hinting: hinting,
+		glyphs:  map[rune]*colorGlyph{},
+	}, nil
+}
+
+type colorGlyph struct {
+	image  *image.RGBA
+	bounds fixed.Rectangle26_6
+}
+
+type colorFace struct {
+	font.Face
+
+	font    *sfnt.Font
+	sbix    []byte
+	cblc    []byte
+	cbdt    []byte
+	colr    []byte
+	cpal    []byte
+	ppem    float64
+	hinting font.Hinting
+
+	buf    sfnt.Buffer
+	glyphs map[rune]*colorGlyph
+	m      sync.Mutex
+}
+
+func (f *colorFace) colorGlyph(r rune) (*image.RGBA, fixed.Rectangle26_6, bool) {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	g, ok := f.glyphs[r]
+	if !ok {
+		g = f.loadColorGlyph(r)
+		f.glyphs[r] = g
+	}
+	if g == nil {
+		return nil, fixed.Rectangle26_6{}, false
+	}
+	return g.image, g.bounds, true
+}
+
+func (f *colorFace) loadColorGlyph(r rune) *colorGlyph {
+	gid, err := f.font.GlyphIndex(&f.buf, r)
+	if err != nil || gid == 0 {
+		return nil
+	}
+	if f.sbix != nil {
+		if g := f.loadSbixGlyph(gid); g != nil {
+			return g
+		}
+	}
+	if f.cblc != nil && f.cbdt != nil {
+		if g := f.loadCBDTGlyph(gid); g != nil {
+			return g
+		}
+	}
+	if f.colr != nil && f.cpal != nil {
+		if g := f.loadCOLRGlyph(gid); g != nil {
+			return g
+		}
+	}
+	return nil
+}
+
+func (f *colorFace) hasGlyph(r rune) bool {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	gid, err := f.font.GlyphIndex(&f.buf, r)
+	return err == nil && gid != 0
+}
+
+// GlyphBounds implements font.Face.
+func (f *colorFace) GlyphBounds(r rune) (bounds fixed.Rectangle26_6, advance fixed.Int26_6, ok bool) {
+	if _, b, ok := f.colorGlyph(r); ok {
+		a, _ := f.Face.GlyphAdvance(r)
+		return b, a, true
+	}
+	return f.Face.GlyphBounds(r)
+}
+
+// Glyph implements font.Face. For a color glyph, the mask is the silhouette of the glyph.
+func (f *colorFace) Glyph(dot fixed.Point26_6, r rune) (dr image.Rectangle, mask image.Image, maskp image.Point, advance fixed.Int26_6, ok bool) {
+	if img, b, ok := f.colorGlyph(r); ok {
+		a, _ := f.Face.GlyphAdvance(r)
+		x, y := dot.X.Round(), dot.Y.Round()
+		dr = image.Rect(x+b.Min.X.Round(), y+b.Min.Y.Round(), x+b.Max.X.Round(), y+b.Max.Y.Round())
+		return dr, img, image.Point{}, a, true
+	}
+	return f.Face.Glyph(dot, r)
+}
+
+// scaleBitmap scales the bitmap image in a strike of strikePPEM to the face's size.
+// (x, y) is the upper-left position of the bitmap relative to the dot in the strike's pixels.
+func (f *colorFace) scaleBitmap(src image.Image, x, y float64, strikePPEM float64) *colorGlyph {
+	scale := f.ppem / strikePPEM
+	sb := src.Bounds()
+	x0 := int(math.Floor(x * scale))
+	y0 := int(math.Floor(y * scale))
+	x1 := int(math.Ceil((x + float64(sb.Dx())) * scale))
+	y1 := int(math.Ceil((y + float64(sb.Dy())) * scale))
+	if x1 <= x0 || y1 <= y0 {
+		return nil
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, x1-x0, y1-y0))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, sb, draw.Src, nil)
+	return &colorGlyph{
+		image:  dst,
+		bounds: fixed.R(x0, y0, x1, y1),
+	}
+}
+
+func (f *colorFace) loadSbixGlyph(gid sfnt.GlyphIndex) *colorGlyph {
+	t := f.sbix
+	if len(t) < 8 {
+		return nil
+	}
+	numStrikes := int(be32(t[4:]))
+	if len(t) < 8+4*numStrikes {
+		return nil
+	}
+
+	// Choose the smallest strike that is bigger than the face's size, or the biggest strike.
+	var strike []byte
+	var strikePPEM float64
+	for i := 0; i < numStrikes; i++ {
+		offset := int(be32(t[8+4*i:]))
+		if offset+4 > len(t) {
+			continue
+		}
+		s := t[offset:]
+		ppem := float64(be16(s))
+		if strike == nil ||
+			(strikePPEM < f.ppem && ppem > strikePPEM) ||
+			(ppem >= f.ppem && ppem < strikePPEM) {
+			strike = s
+			strikePPEM = ppem
+		}
+	}
+	if strike == nil || strikePPEM == 0 {
+		return nil
+	}
+
+	// A 'dupe' glyph refers to another glyph. Follow the reference only once to avoid an infinite loop.
+	for i := 0; i < 2; i++ {
+		idx := 4 + 4*int(gid)
+		if idx+8 > len(strike) {
+			return nil
+		}
+		start, end := int(be32(strike[idx:])), int(be32(strike[idx+4:]))
+		if end-start < 8 || end > len(strike) {
+			return nil
+		}
+		data := strike[start:end]
+		originX := float64(int16(be16(data)))
+		originY := float64(int16(be16(data[2:])))
+		graphicType := string(data[4:8])
+		data = data[8:]
+
+		switch graphicType {
+		case "dupe":
+			if len(data) < 2 {
+				return nil
+			}
+			gid = sfnt.GlyphIndex(be16(data))
+			continue
+		case "png ", "jpg ":
+			img, _, err := image.Decode(bytes.NewReader(data))
+			if err != nil {
+				return nil
+			}
+			// The origin is the offset of the bottom-left corner from the dot, and the Y axis increases up.
+			h := float64(img.Bounds().Dy())
+			return f.scaleBitmap(img, originX, -(originY + h), strikePPEM)
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+func (f *colorFace) loadCBDTGlyph(gid sfnt.GlyphIndex) *colorGlyph {
+	t := f.cblc
+	if len(t) < 8 {
+		return nil
+	}
+	numSizes := int(be32(t[4:]))
+	const bitmapSizeLen = 48
+
+	// Choose the smallest strike that is bigger than the face's size, or the biggest strike.
+	var size []byte
+	var strikePPEM float64
+	for i := 0; i < numSizes; i++ {
+		offset := 8 + bitmapSizeLen*i
+		if offset+bitmapSizeLen > len(t) {
+			break
+		}
+		s := t[offset : offset+bitmapSizeLen]
+		start, end := sfnt.GlyphIndex(be16(s[40:])), sfnt.GlyphIndex(be16(s[42:]))
+		if gid < start || end < gid {
+			continue
+		}
+		ppem := float64(s[45])
+		if size == nil ||
+			(strikePPEM < f.ppem && ppem > strikePPEM) ||
+			(ppem >= f.ppem && ppem < strikePPEM) {
+			size = s
+			strikePPEM = ppem
+		}
+	}
+	if size == nil || strikePPEM == 0 {
+		return nil
+	}
+
+	arrayOffset := int(be32(size))
+	numSubtables := int(be32(size[8:]))
+	for i := 0; i < numSubtables; i++ {
+		e := arrayOffset + 8*i
+		if e+8 > len(t) {
+			return nil
+		}
+		first, last := sfnt.GlyphIndex(be16(t[e:])), sfnt.GlyphIndex(be16(t[e+2:]))
+		if gid < first || last < gid {
+			continue
+		}
+		sub := arrayOffset + int(be32(t[e+4:]))
+		if sub+8 > len(t) {
+			return nil
+		}
+		return f.loadCBDTGlyphInSubtable(t[sub:], gid, first, strikePPEM)
+	}
+	return nil
+}
+
+func (f *colorFace) loadCBDTGlyphInSubtable(sub []byte, gid, first sfnt.GlyphIndex, strikePPEM float64) *colorGlyph {
+	indexFormat := be16(sub)
+	imageFormat := be16(sub[2:])
+	imageDataOffset := int(be32(sub[4:]))
+	body := sub[8:]
+	n := int(gid - first)
+
+	// bigMetrics is available for the image format 19.
+	var bigMetrics []byte
+	var offset int
+	switch indexFormat {
+	case 1:
+		if 4*n+4 > len(body) {
+			return nil
+		}
+		offset = imageDataOffset + int(be32(body[4*n:]))
+	case 2:
+		if len(body) < 12 {
+			return nil
+		}
+		offset = imageDataOffset + int(be32(body))*n
+		bigMetrics = body[4:12]
+	case 3:
+		if 2*n+2 > len(body) {
+			return nil
+		}
+		offset = imageDataOffset + int(be16(body[2*n:]))
+	case 4:
+		if len(body) < 4 {
+			return nil
+		}
+		num := int(be32(body))
+		found := false
+		for i := 0; i < num; i++ {
+			e := 4 + 4*i
+			if e+4 > len(body) {
+				return nil
+			}
+			if sfnt.GlyphIndex(be16(body[e:])) == gid {
+				offset = imageDataOffset + int(be16(body[e+2:]))
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil
+		}
+	case 5:
+		if len(body) < 16 {
+			return nil
+		}
+		imageSize := int(be32(body))
+		bigMetrics = body[4:12]
+		num := int(be32(body[12:]))
+		ids := body[16:]
+		if len(ids) < 2*num {
+			return nil
+		}
+		i := sort.Search(num, func(i int) bool {
+			return sfnt.GlyphIndex(be16(ids[2*i:])) >= gid
+		})
+		if i == num || sfnt.GlyphIndex(be16(ids[2*i:])) != gid {
+			return nil
+		}
+		offset = imageDataOffset + imageSize*i
+	default:
+		return nil
+	}
+
+	d := f.cbdt
+	var bearingX, bearingY float64
+	var data []byte
+	switch imageFormat {
+	case 17:
+		// smallGlyphMetrics: height, width, bearingX, bearingY, advance
+		if offset+9 > len(d) {
+			return nil
+		}
+		bearingX, bearingY = float64(int8(d[offset+2])), float64(int8(d[offset+3]))
+		l := int(be32(d[offset+5:]))
+		data = sliceAt(d, offset+9, l)
+	case 18:
+		// bigGlyphMetrics: height, width, horiBearingX, horiBearingY, horiAdvance, vertBearingX, vertBearingY,
+		// vertAdvance
+		if offset+12 > len(d) {
+			return nil
+		}
+		bearingX, bearingY = float64(int8(d[offset+2])), float64(int8(d[offset+3]))
+		l := int(be32(d[offset+8:]))
+		data = sliceAt(d, offset+12, l)
+	case 19:
+		if bigMetrics == nil || offset+4 > len(d) {
+			return nil
+		}
+		bearingX, bearingY = float64(int8(bigMetrics[2])), float64(int8(bigMetrics[3]))
+		l := int(be32(d[offset:]))
+		data = sliceAt(d, offset+4, l)
+	default:
+		return nil
+	}
+	if data == nil {
+		return nil
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+	// bearingY is the distance from the baseline to the top of the bitmap, and the Y axis increases up.
+	return f.scaleBitmap(img, bearingX, -bearingY, strikePPEM)
+}
+
+func (f *colorFace) loadCOLRGlyph(gid sfnt.GlyphIndex) *colorGlyph {
+	t := f.colr
+	if len(t) < 14 {
+		return nil
+	}
+	numBase := int(be16(t[2:]))
+	baseOffset := int(be32(t[4:]))
+	layerOffset := int(be32(t[8:]))
+	numLayers := int(be16(t[12:]))
+	if baseOffset+6*numBase > len(t) || layerOffset+4*numLayers > len(t) {
+		return nil
+	}
+
+	i := sort.Search(numBase, func(i int) bool {
+		return sfnt.GlyphIndex(be16(t[baseOffset+6*i:])) >= gid
+	})
+	if i == numBase || sfnt.GlyphIndex(be16(t[baseOffset+6*i:])) != gid {
+		return nil
+	}
+	rec := t[baseOffset+6*i:]
+	firstLayer, layerCount := int(be16(rec[2:])), int(be16(rec[4:]))
+	if firstLayer+layerCount > numLayers {
+		return nil
+	}
+
+	type layer struct {
+		segments sfnt.Segments
+		color    color.Color
+	}
+	ppem := fixed.Int26_6(math.Round(f.ppem * (1 << 6)))
+	var layers []layer
+	var bounds fixed.Rectangle26_6
+	for j := 0; j < layerCount; j++ {
+		l := t[layerOffset+4*(firstLayer+j):]
+		lgid := sfnt.GlyphIndex(be16(l))
+		clr, ok := f.paletteColor(be16(l[2:]))
+		if !ok {
+			return nil
+		}
+		segs, err := f.font.LoadGlyph(&f.buf, lgid, ppem, nil)
+		if err != nil {
+			return nil
+		}
+		b, _, err := f.font.GlyphBounds(&f.buf, lgid, ppem, f.hinting)
+		if err != nil {
+			return nil
+		}
+		bounds = bounds.Union(b)
+		// LoadGlyph reuses the buffer. Copy the segments.
+		layers = append(layers, layer{
+			segments: append(sfnt.Segments(nil), segs...),
+			color:    clr,
+		})
+	}
+
+	x0, y0 := bounds.Min.X.Floor(), bounds.Min.Y.Floor()
+	x1, y1 := bounds.Max.X.Ceil(), bounds.Max.Y.Ceil()
+	if x1 <= x0 || y1 <= y0 {
+		return nil
+	}
+	w, h := x1-x0, y1-y0
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	mask := image.NewAlpha(image.Rect(0, 0, w, h))
+	for _, l := range layers {
+		for i := range mask.Pix {
+			mask.Pix[i] = 0
+		}
+		rasterizeSegments(mask, l.segments, float32(-x0), float32(-y0))
+		draw.DrawMask(dst, dst.Bounds(), image.NewUniform(l.color), image.Point{}, mask, image.Point{}, draw.Over)
+	}
+	return &colorGlyph{
+		image:  dst,
+		bounds: fixed.R(x0, y0, x1, y1),
+	}
+}
+
+// paletteColor returns the color of the index in the first palette.
+func (f *colorFace) paletteColor(index uint16) (color.Color, bool) {
+	// 0xffff means the foreground color.
+	if index == 0xffff {
+		return color.White, true
+	}
+	t := f.cpal
+	if len(t) < 14 {
+		return nil, false
+	}
+	numEntries := be16(t[2:])
+	if index >= numEntries {
+		return nil, false
+	}
+	recordsOffset := int(be32(t[8:]))
+	first := int(be16(t[12:]))
+	o := recordsOffset + 4*(first+int(index))
+	if o+4 > len(t) {
+		return nil, false
+	}
+	// The color record is in the BGRA order and is not premultiplied.
+	return color.NRGBA{
+		R: t[o+2],
+		G: t[o+1],
+		B: t[o],
+		A: t[o+3],
+	}, true
+}
+
+func rasterizeSegments(dst *image.Alpha, segs sfnt.Segments, dx, dy float32) {
+	b := dst.Bounds()
+	r := vector.NewRasterizer(b.Dx(), b.Dy())
+	r.DrawOp = draw.Src
+	p := func(v fixed.Point26_6) (float32, float32) {
+		return float32(v.X)/(1<<6) + dx, float32(v.Y)/(1<<6) + dy
+	}
+	for _, s := range segs {
+		switch s.Op {
+		case sfnt.SegmentOpMoveTo:
+			r.MoveTo(p(s.Args[0]))
+		case sfnt.SegmentOpLineTo:
+			r.LineTo(p(s.Args[0]))
+		case sfnt.SegmentOpQuadTo:
+			x0, y0 := p(s.Args[0])
+			x1, y1 := p(s.Args[1])
+			r.QuadTo(x0, y0, x1, y1)
+		case sfnt.SegmentOpCubeTo:
+			x0, y0 := p(s.Args[0])
+			x1, y1 := p(s.Args[1])
+			x2, y2 := p(s.Args[2])
+			r.CubeTo(x0, y0, x1, y1, x2, y2)
+		}
+	}
+	r.ClosePath()
+	r.Draw(dst, b, image.Opaque, image.Point{})
+}
+
+// parseTableDirectory returns the tables of an OpenType font.
+func parseTableDirectory(src []byte) (map[string][]byte, error) {
+	if len(src) < 12 {
+		return nil, errors.New("text: invalid font: too short")
+	}
+	switch string(src[:4]) {
+	case "\x00\x01\x00\x00", "OTTO", "true":
+	case "ttcf":
+		return nil, errors.New("text: font collections are not supported")
+	default:
+		return nil, fmt.Errorf("text: invalid font: unknown version %q", src[:4])
+	}
+	numTables := int(be16(src[4:]))
+	tables := map[string][]byte{}
+	for i := 0; i < numTables; i++ {
+		r := 12 + 16*i
+		if r+16 > len(src) {
+			return nil, errors.New("text: invalid font: broken table directory")
+		}
+		offset, length := int(be32(src[r+8:])), int(be32(src[r+12:]))
+		if t := sliceAt(src, offset, length); t != nil {
+			tables[string(src[r:r+4])] = t
+		}
+	}
+	return tables, nil
+}
+
+func sliceAt(b []byte, offset, length int) []byte {
+	if offset < 0 || length < 0 || offset+length > len(b) {
+		return nil
+	}
+	return b[offset : offset+length]
+}
+
+func be16(b []byte) uint16 {
+	return binary.BigEndian.Uint16(b)
+}
+
+func be32(b []byte) uint32 {
+	return binary.BigEndian.Uint32(b)
+}
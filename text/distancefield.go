@@ -0,0 +1,161 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"image/color"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// DefaultDistanceFieldSpread is the default range of distance fields in pixels.
+const DefaultDistanceFieldSpread = 8
+
+const distanceFieldShaderSrc = `package main
+
+var Color vec4
+
+func Fragment(position vec4, texCoord vec2, color vec4) vec4 {
+	// Interpolate the distance linearly. The source image is sampled with the nearest filter.
+	size := imageSrcTextureSize()
+	p := texCoord*size - 0.5
+	f := fract(p)
+	base := floor(p) + 0.5
+	d00 := imageSrc0At(base / size).a
+	d10 := imageSrc0At((base + vec2(1, 0)) / size).a
+	d01 := imageSrc0At((base + vec2(0, 1)) / size).a
+	d11 := imageSrc0At((base + vec2(1, 1)) / size).a
+	d := mix(mix(d00, d10, f.x), mix(d01, d11, f.x), f.y)
+
+	// Anti-alias the edge with the width of one pixel on the destination.
+	w := max(fwidth(d), 1.0/1024.0)
+	a := clamp((d-0.5)/w+0.5, 0, 1)
+	return Color * a
+}
+`
+
+var (
+	distanceFieldShader    *ebiten.Shader
+	distanceFieldShaderErr error
+)
+
+func getDistanceFieldShader() (*ebiten.Shader, error) {
+	if distanceFieldShader == nil && distanceFieldShaderErr == nil {
+		distanceFieldShader, distanceFieldShaderErr = ebiten.NewShader([]byte(distanceFieldShaderSrc))
+	}
+	return distanceFieldShader, distanceFieldShaderErr
+}
+
+// DistanceFieldOptions represents options for DrawDistanceField.
+type DistanceFieldOptions struct {
+	// GeoM is a geometry matrix applied to the text.
+	// The origin of the text's coordinate is the dot position of the first line.
+	// The default (zero) value is identity, which draws the text at (0, 0).
+	GeoM ebiten.GeoM
+
+	// Spread is the range of the distance field in pixels of the face.
+	// A bigger Spread keeps the edges more accurate when the text is scaled down a lot, but needs a bigger atlas.
+	// If Spread is 0 or negative, DefaultDistanceFieldSpread is used.
+	Spread int
+}
+
+// DrawDistanceField draws a given text on a given destination image dst in the distance field mode.
+//
+// In the distance field mode, each glyph is cached as a signed distance field instead of a bitmap, and is
+// rendered with a built-in shader. The edges of the glyphs stay crisp under arbitrary scaling and rotation by
+// options.GeoM, so a face with a moderate size (e.g. 48px) can be used for both small and huge texts.
+// Sharp corners are slightly rounded when the text is scaled up a lot.
+//
+// clr is the color for text rendering.
+//
+// Color glyphs of a face created by NewColorFace are drawn as bitmaps with the linear filter.
+// If the shader is not available (e.g. the ebitennokage build tag is specified), all the glyphs are drawn as
+// bitmaps with the linear filter.
+//
+// Be careful that the passed font face is held by this package and is never released.
+// This is a known issue (#498).
+//
+// DrawDistanceField is concurrent-safe.
+func DrawDistanceField(dst *ebiten.Image, text string, face font.Face, clr color.Color, options *DistanceFieldOptions) {
+	textM.Lock()
+	defer textM.Unlock()
+
+	if options == nil {
+		options = &DistanceFieldOptions{}
+	}
+
+	cr, cg, cb, ca := clr.RGBA()
+	if ca == 0 {
+		return
+	}
+
+	spread := options.Spread
+	if spread <= 0 {
+		spread = DefaultDistanceFieldSpread
+	}
+	variant := glyphVariant{
+		distanceFieldSpread: spread,
+	}
+
+	// Fall back to the bitmaps when the shader is not available.
+	shader, err := getDistanceFieldShader()
+
+	var colorm ebiten.ColorM
+	colorm.Scale(float64(cr)/float64(ca), float64(cg)/float64(ca), float64(cb)/float64(ca), float64(ca)/0xffff)
+	var colorGlyphColorM ebiten.ColorM
+	colorGlyphColorM.Scale(1, 1, 1, float64(ca)/0xffff)
+
+	uniforms := map[string]interface{}{
+		"Color": []float32{float32(cr) / 0xffff, float32(cg) / 0xffff, float32(cb) / 0xffff, float32(ca) / 0xffff},
+	}
+
+	forEachGlyph(face, text, 0, 0, spacing{}, func(r rune, x, y fixed.Int26_6) {
+		b := getGlyphBounds(face, r)
+
+		if err != nil || isColorGlyph(face, r) {
+			img := getGlyphImage(face, r, glyphVariant{}, 0)
+			if img == nil {
+				return
+			}
+			op := &ebiten.DrawImageOptions{}
+			op.GeoM.Translate(float64((x+b.Min.X)>>6), float64((y+b.Min.Y)>>6))
+			op.GeoM.Concat(options.GeoM)
+			op.ColorM = colorm
+			if isColorGlyph(face, r) {
+				op.ColorM = colorGlyphColorM
+			}
+			op.Filter = ebiten.FilterLinear
+			dst.DrawImage(img, op)
+			return
+		}
+
+		img := getGlyphImage(face, r, variant, 0)
+		if img == nil {
+			return
+		}
+		p := variant.padding()
+		w, h := img.Size()
+		op := &ebiten.DrawRectShaderOptions{}
+		op.GeoM.Translate(float64((x+b.Min.X)>>6)-float64(p), float64((y+b.Min.Y)>>6)-float64(p))
+		op.GeoM.Concat(options.GeoM)
+		op.Uniforms = uniforms
+		op.Images[0] = img
+		dst.DrawRectShader(w, h, shader, op)
+	})
+	cleanUpGlyphCache(face)
+}
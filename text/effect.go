@@ -0,0 +1,271 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"image"
+	"math"
+)
+
+// alphaFromRGBA returns an alpha image with the alpha channel of src.
+func alphaFromRGBA(src *image.RGBA) *image.Alpha {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dst := image.NewAlpha(image.Rect(0, 0, w, h))
+	for j := 0; j < h; j++ {
+		for i := 0; i < w; i++ {
+			dst.Pix[j*dst.Stride+i] = src.Pix[j*src.Stride+4*i+3]
+		}
+	}
+	return dst
+}
+
+// dilate returns an alpha image of the glyph src expanded by radius pixels in all the directions.
+//
+// The result has ceil(radius) pixels of padding on each side. The edge of the expanded shape is anti-aliased by the
+// distance from the glyph.
+func dilate(src *image.Alpha, radius float64) *image.Alpha {
+	p := int(math.Ceil(radius))
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	dst := image.NewAlpha(image.Rect(0, 0, sw+2*p, sh+2*p))
+
+	// kernel[(dy+p)*(2p+1)+(dx+p)] is the coverage of the disk of the radius at the offset (dx, dy).
+	kw := 2*p + 1
+	kernel := make([]float64, kw*kw)
+	for dy := -p; dy <= p; dy++ {
+		for dx := -p; dx <= p; dx++ {
+			// d is the distance between the pixel centers. The distance from the source pixel's edge to the
+			// destination pixel's center is about d-0.5, and the destination pixel's coverage is about
+			// radius-(d-0.5)+0.5.
+			d := math.Sqrt(float64(dx*dx + dy*dy))
+			c := radius + 1 - d
+			if c > 1 {
+				c = 1
+			}
+			if c < 0 {
+				c = 0
+			}
+			kernel[(dy+p)*kw+(dx+p)] = c
+		}
+	}
+
+	for j := 0; j < sh; j++ {
+		for i := 0; i < sw; i++ {
+			a := float64(src.Pix[j*src.Stride+i])
+			if a == 0 {
+				continue
+			}
+			// Spread the source pixel to the destination pixels within the radius.
+			for dy := -p; dy <= p; dy++ {
+				y := j + p + dy
+				for dx := -p; dx <= p; dx++ {
+					k := kernel[(dy+p)*kw+(dx+p)]
+					if k == 0 {
+						continue
+					}
+					x := i + p + dx
+					v := uint8(a*k + 0.5)
+					if idx := y*dst.Stride + x; dst.Pix[idx] < v {
+						dst.Pix[idx] = v
+					}
+				}
+			}
+		}
+	}
+	return dst
+}
+
+// blur returns an alpha image of src blurred with a Gaussian filter.
+//
+// radius is the radius of the filter, and the standard deviation is the half of radius.
+// The result has ceil(radius) pixels of padding on each side.
+func blur(src *image.Alpha, radius float64) *image.Alpha {
+	p := int(math.Ceil(radius))
+	sigma := radius / 2
+
+	kernel := make([]float64, 2*p+1)
+	var sum float64
+	for i := -p; i <= p; i++ {
+		v := math.Exp(-float64(i*i) / (2 * sigma * sigma))
+		kernel[i+p] = v
+		sum += v
+	}
+	for i := range kernel {
+		kernel[i] /= sum
+	}
+
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	w, h := sw+2*p, sh+2*p
+
+	// The Gaussian filter is separable. Apply the filter horizontally and then vertically.
+	tmp := make([]float64, w*sh)
+	for j := 0; j < sh; j++ {
+		for i := 0; i < sw; i++ {
+			a := float64(src.Pix[j*src.Stride+i])
+			if a == 0 {
+				continue
+			}
+			for k, v := range kernel {
+				tmp[j*w+i+k] += a * v
+			}
+		}
+	}
+
+	acc := make([]float64, w*h)
+	for j := 0; j < sh; j++ {
+		for i := 0; i < w; i++ {
+			a := tmp[j*w+i]
+			if a == 0 {
+				continue
+			}
+			for k, v := range kernel {
+				acc[(j+k)*w+i] += a * v
+			}
+		}
+	}
+
+	dst := image.NewAlpha(image.Rect(0, 0, w, h))
+	for i, a := range acc {
+		if a > 0xff {
+			a = 0xff
+		}
+		dst.Pix[i] = uint8(a + 0.5)
+	}
+	return dst
+}
+
+// distanceField returns an alpha image of the signed distance field of the glyph src.
+//
+// The value 0x80 is the edge of the glyph, and the value increases inside the glyph. The range of the distance is
+// spread pixels on each side of the edge. The result has spread pixels of padding on each side.
+func distanceField(src *image.Alpha, spread int) *image.Alpha {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	w, h := sw+2*spread, sh+2*spread
+
+	inside := make([]bool, w*h)
+	for j := 0; j < sh; j++ {
+		for i := 0; i < sw; i++ {
+			inside[(j+spread)*w+i+spread] = src.Pix[j*src.Stride+i] >= 0x80
+		}
+	}
+
+	// toInside is the squared distance to the nearest inside pixel, and toOutside is the squared distance to the
+	// nearest outside pixel.
+	toInside := squaredDistanceTransform(inside, w, h, true)
+	toOutside := squaredDistanceTransform(inside, w, h, false)
+
+	dst := image.NewAlpha(image.Rect(0, 0, w, h))
+	for j := 0; j < h; j++ {
+		for i := 0; i < w; i++ {
+			idx := j*w + i
+			var d float64
+			if inside[idx] {
+				d = math.Sqrt(toOutside[idx]) - 0.5
+			} else {
+				d = 0.5 - math.Sqrt(toInside[idx])
+			}
+			// Use the coverage for the pixels on the edge for the sub-pixel accuracy.
+			if x, y := i-spread, j-spread; 0 <= x && x < sw && 0 <= y && y < sh {
+				if a := src.Pix[y*src.Stride+x]; 0 < a && a < 0xff {
+					d = float64(a)/0xff - 0.5
+				}
+			}
+			v := 0x80 + d*0x80/float64(spread)
+			if v < 0 {
+				v = 0
+			}
+			if v > 0xff {
+				v = 0xff
+			}
+			dst.Pix[j*dst.Stride+i] = uint8(v + 0.5)
+		}
+	}
+	return dst
+}
+
+// squaredDistanceTransform returns the squared Euclidean distances from each pixel to the nearest pixel where
+// inside is target.
+func squaredDistanceTransform(inside []bool, w, h int, target bool) []float64 {
+	const inf = 1e20
+
+	d := make([]float64, w*h)
+	for i, in := range inside {
+		if in == target {
+			continue
+		}
+		d[i] = inf
+	}
+
+	// The transform is separable. Apply the one-dimensional transform to the columns and then the rows.
+	n := w
+	if n < h {
+		n = h
+	}
+	f := make([]float64, n)
+	z := make([]float64, n+1)
+	v := make([]int, n)
+	out := make([]float64, n)
+
+	for i := 0; i < w; i++ {
+		for j := 0; j < h; j++ {
+			f[j] = d[j*w+i]
+		}
+		distanceTransform1D(f[:h], out[:h], v, z)
+		for j := 0; j < h; j++ {
+			d[j*w+i] = out[j]
+		}
+	}
+	for j := 0; j < h; j++ {
+		copy(f[:w], d[j*w:(j+1)*w])
+		distanceTransform1D(f[:w], out[:w], v, z)
+		copy(d[j*w:(j+1)*w], out[:w])
+	}
+	return d
+}
+
+// distanceTransform1D computes the one-dimensional squared distance transform of f into dst by the algorithm of
+// Felzenszwalb and Huttenlocher. v and z are work buffers.
+func distanceTransform1D(f, dst []float64, v []int, z []float64) {
+	n := len(f)
+	if n == 0 {
+		return
+	}
+	k := 0
+	v[0] = 0
+	z[0] = math.Inf(-1)
+	z[1] = math.Inf(1)
+	intersection := func(q, p int) float64 {
+		return ((f[q] + float64(q*q)) - (f[p] + float64(p*p))) / float64(2*q-2*p)
+	}
+	for q := 1; q < n; q++ {
+		s := intersection(q, v[k])
+		// z[0] is -Inf and this loop always ends.
+		for s <= z[k] {
+			k--
+			s = intersection(q, v[k])
+		}
+		k++
+		v[k] = q
+		z[k] = s
+		z[k+1] = math.Inf(1)
+	}
+	k = 0
+	for q := 0; q < n; q++ {
+		for z[k+1] < float64(q) {
+			k++
+		}
+		p := v[k]
+		dst[q] = float64((q-p)*(q-p)) + f[p]
+	}
+}
@@ -0,0 +1,31 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"golang.org/x/image/font"
+)
+
+var (
+	BidiReorder = bidiReorder
+	HasGlyph    = hasGlyph
+	ShapeArabic = shapeArabic
+)
+
+func CachedGlyphCount(face font.Face) int {
+	textM.Lock()
+	defer textM.Unlock()
+	return len(glyphImageCache[face])
+}
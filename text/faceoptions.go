@@ -0,0 +1,123 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// maxSubpixelPositions is the maximum number of FaceOptions.SubpixelPositions.
+const maxSubpixelPositions = 16
+
+// FaceOptions represents options to render a face in this package.
+type FaceOptions struct {
+	// Hinting is the hinting mode of the positions of glyphs.
+	//
+	// This package doesn't hint the outlines of glyphs, but snaps the metrics to whole pixels.
+	// font.HintingVertical rounds the line height, and font.HintingFull rounds the advances and the kerning too.
+	//
+	// The default (zero) value is font.HintingNone, which uses the metrics of the face as they are.
+	Hinting font.Hinting
+
+	// SubpixelPositions is the number of the horizontal positions in a pixel where glyphs can be placed.
+	//
+	// If SubpixelPositions is 1, glyphs are snapped to whole pixels. Small texts look crisp, but the spaces
+	// between glyphs are uneven and moving texts look shimmery. A bigger value like 4 places glyphs more
+	// accurately, but the glyph images are cached for each position.
+	//
+	// SubpixelPositions is clamped into [1, 16]. The default (zero) value is treated as 1.
+	SubpixelPositions int
+}
+
+var faceOptions = map[font.Face]FaceOptions{}
+
+// SetFaceOptions sets the options to render the given face.
+// If options is nil, the default options are used.
+//
+// SetFaceOptions discards the cached glyphs of the face.
+//
+// Be careful that the passed font face is held by this package and is never released.
+// This is a known issue (#498).
+//
+// SetFaceOptions is concurrent-safe.
+func SetFaceOptions(face font.Face, options *FaceOptions) {
+	textM.Lock()
+	defer textM.Unlock()
+
+	var o FaceOptions
+	if options != nil {
+		o = *options
+	}
+	if o.SubpixelPositions < 1 {
+		o.SubpixelPositions = 1
+	}
+	if o.SubpixelPositions > maxSubpixelPositions {
+		o.SubpixelPositions = maxSubpixelPositions
+	}
+
+	if getFaceOptions(face) == o {
+		return
+	}
+	if o == (FaceOptions{SubpixelPositions: 1}) {
+		delete(faceOptions, face)
+	} else {
+		faceOptions[face] = o
+	}
+	clearGlyphCache(face)
+}
+
+func getFaceOptions(face font.Face) FaceOptions {
+	if o, ok := faceOptions[face]; ok {
+		return o
+	}
+	return FaceOptions{
+		SubpixelPositions: 1,
+	}
+}
+
+// kern returns the kerning between r0 and r1 with the face's options applied.
+func kern(face font.Face, r0, r1 rune) fixed.Int26_6 {
+	k := face.Kern(r0, r1)
+	if getFaceOptions(face).Hinting == font.HintingFull {
+		k = fixed.I(k.Round())
+	}
+	return k
+}
+
+// lineHeight returns the height of a line with the face's options applied.
+func lineHeight(face font.Face) fixed.Int26_6 {
+	h := face.Metrics().Height
+	if getFaceOptions(face).Hinting != font.HintingNone {
+		h = fixed.I(h.Round())
+	}
+	return h
+}
+
+// subpixelPosition returns the position where a glyph at x is drawn, and the index of the subpixel position.
+func subpixelPosition(face font.Face, x fixed.Int26_6) (fixed.Int26_6, int) {
+	n := getFaceOptions(face).SubpixelPositions
+	if n <= 1 {
+		return x, 0
+	}
+	q := (int(x)*n + (1 << 5)) >> 6
+	i := q / n
+	s := q % n
+	if s < 0 {
+		i--
+		s += n
+	}
+	return fixed.I(i), s
+}
@@ -0,0 +1,196 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"math"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// GlyphLayout represents the layout of a glyph returned by LayoutGlyphs.
+type GlyphLayout struct {
+	// Rune is the rune of the glyph.
+	// Rune can be different from the rune in the text, like a contextual form of an Arabic letter or a mirrored
+	// bracket in a right-to-left run.
+	Rune rune
+
+	// StartIndex and EndIndex are the range of the cluster in the text in bytes.
+	// The cluster is text[StartIndex:EndIndex], and usually consists of one rune. A ligature like Lam-Alef
+	// consists of multiple runes.
+	StartIndex int
+	EndIndex   int
+
+	// X and Y are the dot position of the glyph relative to the dot position of the text.
+	X float64
+	Y float64
+
+	// Advance is the advance width of the glyph.
+	Advance float64
+
+	// Line is the index of the line of the glyph.
+	Line int
+
+	// RightToLeft reports whether the glyph is in a right-to-left run, like Hebrew and Arabic.
+	// In a right-to-left run, the cluster's start is at the right side of the glyph.
+	RightToLeft bool
+}
+
+// LayoutGlyphs returns the layout of the glyphs of a given text in the visual order.
+//
+// The glyphs are laid out in the same way as Draw and BoundString: the dot position of the text is the origin,
+// and the '\n' newline character puts the following text on the next line. The newline characters are not
+// included in the result. The spacing options of DrawOptions are not considered.
+//
+// LayoutGlyphs is useful for text cursors and selection highlighting. For example, the caret before the cluster
+// of a GlyphLayout g is at g.X for a left-to-right run, or g.X+g.Advance for a right-to-left run.
+//
+// LayoutGlyphs is concurrent-safe.
+func LayoutGlyphs(face font.Face, text string) []GlyphLayout {
+	textM.Lock()
+	defer textM.Unlock()
+
+	return layoutGlyphs(face, text)
+}
+
+// IndexAt returns the index of the caret position in a given text in bytes that is the nearest to (x, y).
+// (x, y) is relative to the dot position of the text, as LayoutGlyphs.
+//
+// IndexAt is useful to move a text cursor to a clicked position.
+//
+// IndexAt is concurrent-safe.
+func IndexAt(face font.Face, text string, x, y float64) int {
+	textM.Lock()
+	defer textM.Unlock()
+
+	// Choose the line. A line occupies the range from the ascent above the dot to the next line.
+	lines := strings.Count(text, "\n") + 1
+	h := fixed26_6ToFloat64(lineHeight(face))
+	line := 0
+	if h > 0 {
+		line = int(math.Floor((y + fixed26_6ToFloat64(face.Metrics().Ascent)) / h))
+	}
+	if line < 0 {
+		line = 0
+	}
+	if line >= lines {
+		line = lines - 1
+	}
+
+	lineStart := 0
+	for i := 0; i < line; i++ {
+		lineStart += strings.IndexByte(text[lineStart:], '\n') + 1
+	}
+
+	var last *GlyphLayout
+	for _, g := range layoutGlyphs(face, text) {
+		if g.Line != line {
+			continue
+		}
+		if x < g.X+g.Advance/2 {
+			if g.RightToLeft {
+				return g.EndIndex
+			}
+			return g.StartIndex
+		}
+		g := g
+		last = &g
+	}
+	if last == nil {
+		return lineStart
+	}
+	if last.RightToLeft {
+		return last.StartIndex
+	}
+	return last.EndIndex
+}
+
+func layoutGlyphs(face font.Face, text string) []GlyphLayout {
+	var glyphs []GlyphLayout
+	h := lineHeight(face)
+	lineStart := 0
+	for line := 0; ; line++ {
+		n := strings.IndexByte(text[lineStart:], '\n')
+		lineEnd := len(text)
+		if n >= 0 {
+			lineEnd = lineStart + n
+		}
+		glyphs = appendLineGlyphs(glyphs, face, text[lineStart:lineEnd], lineStart, line, h*fixed.Int26_6(line))
+		if n < 0 {
+			break
+		}
+		lineStart = lineEnd + 1
+	}
+	return glyphs
+}
+
+func appendLineGlyphs(glyphs []GlyphLayout, face font.Face, line string, offset int, lineIndex int, y fixed.Int26_6) []GlyphLayout {
+	// byteIndices[i] is the index of the i-th rune in bytes. The last item is the end of the line.
+	var rs []rune
+	var byteIndices []int
+	for i, r := range line {
+		rs = append(rs, r)
+		byteIndices = append(byteIndices, offset+i)
+	}
+	byteIndices = append(byteIndices, offset+len(line))
+
+	// visual is the runes in the visual order, and starts and ends are the ranges of the clusters in runes.
+	visual := rs
+	starts := make([]int, len(rs))
+	ends := make([]int, len(rs))
+	rtl := make([]bool, len(rs))
+	for i := range rs {
+		starts[i] = i
+		ends[i] = i + 1
+	}
+
+	// Shape the line in the same way as shapeText.
+	if needsShaping(line) {
+		shaped, shapedIndices := shapeArabicWithIndices(face, rs)
+		var visualIndices []int
+		visual, visualIndices, rtl = bidiReorderWithIndices(shaped)
+		starts = starts[:len(visual)]
+		ends = ends[:len(visual)]
+		for j, k := range visualIndices {
+			starts[j] = shapedIndices[k]
+			ends[j] = len(rs)
+			if k+1 < len(shapedIndices) {
+				ends[j] = shapedIndices[k+1]
+			}
+		}
+	}
+
+	var x fixed.Int26_6
+	for i, r := range visual {
+		if i > 0 {
+			x += kern(face, visual[i-1], r)
+		}
+		a := glyphAdvance(face, r)
+		glyphs = append(glyphs, GlyphLayout{
+			Rune:        r,
+			StartIndex:  byteIndices[starts[i]],
+			EndIndex:    byteIndices[ends[i]],
+			X:           fixed26_6ToFloat64(x),
+			Y:           fixed26_6ToFloat64(y),
+			Advance:     fixed26_6ToFloat64(a),
+			Line:        lineIndex,
+			RightToLeft: rtl[i],
+		})
+		x += a
+	}
+	return glyphs
+}
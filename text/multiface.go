@@ -0,0 +1,240 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"image"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// NewFace creates a new face from the font in the same way as opentype.NewFace.
+//
+// A face created by opentype.NewFace returns the .notdef glyph (tofu) for runes that are not in the font.
+// NewMultiFace tells whether such a face has the glyph by comparing the glyph with the .notdef glyph. The face
+// returned by NewFace tells it by the glyph index in the font, which is faster and exact.
+func NewFace(f *opentype.Font, options *opentype.FaceOptions) (font.Face, error) {
+	face, err := opentype.NewFace(f, options)
+	if err != nil {
+		return nil, err
+	}
+	return &openTypeFace{
+		Face: face,
+		font: f,
+	}, nil
+}
+
+type openTypeFace struct {
+	font.Face
+
+	font *sfnt.Font
+	buf  sfnt.Buffer
+	m    sync.Mutex
+}
+
+func (f *openTypeFace) hasGlyph(r rune) bool {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	gid, err := f.font.GlyphIndex(&f.buf, r)
+	return err == nil && gid != 0
+}
+
+// NewMultiFace creates a new face that composes the given faces into a fallback chain.
+//
+// For each rune, the first face that has the glyph is used. For example, with a Latin face, a CJK face and an
+// emoji face in this order, Latin letters are rendered with the Latin face, and kanji are rendered with the CJK
+// face. If none of the faces has the glyph, the first face is used.
+//
+// A face created by NewFace, NewColorFace or NewVariableFace is regarded as not having the glyph if the font doesn't
+// map the rune to a glyph. A face created by opentype.NewFace is regarded as not having the glyph if the glyph is
+// the same as the .notdef glyph (tofu). Other faces are regarded as not having the glyph if the face's GlyphAdvance
+// returns false.
+//
+// The metrics of the returned face are the maximum of the metrics of the faces, so that the lines have enough
+// space for all the faces. Kerning is applied only between two runes rendered with the same face.
+//
+// Close of the returned face doesn't close the given faces.
+//
+// NewMultiFace panics if faces is empty.
+func NewMultiFace(faces ...font.Face) font.Face {
+	if len(faces) == 0 {
+		panic("text: faces must not be empty at NewMultiFace")
+	}
+	return &multiFace{
+		faces:   append([]font.Face(nil), faces...),
+		indices: map[rune]int{},
+	}
+}
+
+type multiFace struct {
+	faces   []font.Face
+	indices map[rune]int
+	m       sync.Mutex
+}
+
+// faceFor returns the face used for r.
+func (f *multiFace) faceFor(r rune) font.Face {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	if i, ok := f.indices[r]; ok {
+		return f.faces[i]
+	}
+	idx := 0
+	for i, face := range f.faces {
+		if hasGlyph(face, r) {
+			idx = i
+			break
+		}
+	}
+	f.indices[r] = idx
+	return f.faces[idx]
+}
+
+// glyphChecker is implemented by faces that know whether they have a glyph.
+type glyphChecker interface {
+	hasGlyph(r rune) bool
+}
+
+// hasGlyph reports whether face has the glyph for r.
+func hasGlyph(face font.Face, r rune) bool {
+	if c, ok := face.(glyphChecker); ok {
+		return c.hasGlyph(r)
+	}
+
+	if _, ok := face.GlyphAdvance(r); !ok {
+		return false
+	}
+	if f, ok := face.(*opentype.Face); ok {
+		return !isNotdefGlyph(f, r)
+	}
+	return true
+}
+
+// notdefRune is a noncharacter that fonts don't map to glyphs.
+const notdefRune = '\uffff'
+
+// isNotdefGlyph reports whether the glyph for r is the same as the .notdef glyph.
+//
+// opentype.Face doesn't expose the glyph index, so the glyph is compared with the glyph for notdefRune, which is
+// always .notdef. A glyph that looks the same as .notdef, e.g. an empty glyph when .notdef is empty, is regarded as
+// .notdef.
+func isNotdefGlyph(face *opentype.Face, r rune) bool {
+	if r == notdefRune {
+		return true
+	}
+
+	nb, na, ok := face.GlyphBounds(notdefRune)
+	if !ok {
+		return false
+	}
+	b, a, ok := face.GlyphBounds(r)
+	if !ok {
+		return false
+	}
+	if nb != b || na != a {
+		return false
+	}
+
+	// The mask returned by Glyph is valid only until the next Glyph call. Copy the pixels.
+	ndr, nmask, nmaskp, _, ok := face.Glyph(fixed.Point26_6{}, notdefRune)
+	if !ok {
+		return false
+	}
+	npix := make([]uint8, 0, ndr.Dx()*ndr.Dy())
+	for j := 0; j < ndr.Dy(); j++ {
+		for i := 0; i < ndr.Dx(); i++ {
+			_, _, _, a := nmask.At(nmaskp.X+i, nmaskp.Y+j).RGBA()
+			npix = append(npix, uint8(a>>8))
+		}
+	}
+
+	dr, mask, maskp, _, ok := face.Glyph(fixed.Point26_6{}, r)
+	if !ok {
+		return false
+	}
+	if dr != ndr {
+		return false
+	}
+	for j := 0; j < dr.Dy(); j++ {
+		for i := 0; i < dr.Dx(); i++ {
+			_, _, _, a := mask.At(maskp.X+i, maskp.Y+j).RGBA()
+			if uint8(a>>8) != npix[j*dr.Dx()+i] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Close implements font.Face.
+func (f *multiFace) Close() error {
+	return nil
+}
+
+// Glyph implements font.Face.
+func (f *multiFace) Glyph(dot fixed.Point26_6, r rune) (dr image.Rectangle, mask image.Image, maskp image.Point, advance fixed.Int26_6, ok bool) {
+	return f.faceFor(r).Glyph(dot, r)
+}
+
+// GlyphBounds implements font.Face.
+func (f *multiFace) GlyphBounds(r rune) (bounds fixed.Rectangle26_6, advance fixed.Int26_6, ok bool) {
+	return f.faceFor(r).GlyphBounds(r)
+}
+
+// GlyphAdvance implements font.Face.
+func (f *multiFace) GlyphAdvance(r rune) (advance fixed.Int26_6, ok bool) {
+	return f.faceFor(r).GlyphAdvance(r)
+}
+
+// Kern implements font.Face.
+func (f *multiFace) Kern(r0, r1 rune) fixed.Int26_6 {
+	face := f.faceFor(r0)
+	if face != f.faceFor(r1) {
+		return 0
+	}
+	return face.Kern(r0, r1)
+}
+
+// Metrics implements font.Face.
+func (f *multiFace) Metrics() font.Metrics {
+	m := f.faces[0].Metrics()
+	for _, face := range f.faces[1:] {
+		mm := face.Metrics()
+		if m.Height < mm.Height {
+			m.Height = mm.Height
+		}
+		if m.Ascent < mm.Ascent {
+			m.Ascent = mm.Ascent
+		}
+		if m.Descent < mm.Descent {
+			m.Descent = mm.Descent
+		}
+	}
+	return m
+}
+
+func (f *multiFace) colorGlyph(r rune) (*image.RGBA, fixed.Rectangle26_6, bool) {
+	cf, ok := f.faceFor(r).(colorGlyphFace)
+	if !ok {
+		return nil, fixed.Rectangle26_6{}, false
+	}
+	return cf.colorGlyph(r)
+}
@@ -0,0 +1,124 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text_test
+
+import (
+	"testing"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+func newGoRegularFace(t *testing.T) font.Face {
+	f, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	face, err := text.NewFace(f, &opentype.FaceOptions{
+		Size: 12,
+		DPI:  72,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return face
+}
+
+func TestNewFaceHasGlyph(t *testing.T) {
+	face := newGoRegularFace(t)
+
+	// The Go fonts don't have Hiragana. The face returns the .notdef glyph as opentype.NewFace does.
+	const hiragana = 'あ'
+	if _, ok := face.GlyphAdvance(hiragana); !ok {
+		t.Errorf("GlyphAdvance(%q): got: false, want: true", hiragana)
+	}
+
+	cases := []struct {
+		Rune rune
+		Want bool
+	}{
+		{'a', true},
+		{'é', true},
+		{hiragana, false},
+		{0xffff, false},
+	}
+	for _, c := range cases {
+		if got := text.HasGlyph(face, c.Rune); got != c.Want {
+			t.Errorf("HasGlyph(%q): got: %v, want: %v", c.Rune, got, c.Want)
+		}
+	}
+}
+
+func TestMultiFaceFallback(t *testing.T) {
+	// runeFace has the square glyphs with the width 10.
+	fallback := &runeFace{}
+	f := text.NewMultiFace(newGoRegularFace(t), fallback)
+
+	if got, want := text.HasGlyph(fallback, 'あ'), true; got != want {
+		t.Errorf("HasGlyph(fallback, 'あ'): got: %v, want: %v", got, want)
+	}
+
+	want := fixed.R(0, -10, 10, 0)
+	if b, _, _ := f.GlyphBounds('あ'); b != want {
+		t.Errorf("GlyphBounds('あ'): got: %v, want: %v (the fallback face's)", b, want)
+	}
+	if b, _, _ := f.GlyphBounds('a'); b == want {
+		t.Errorf("GlyphBounds('a'): got: %v, want: the first face's", b)
+	}
+}
+
+func TestMultiFaceFallbackOpenType(t *testing.T) {
+	f, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A face created by opentype.NewFace returns the .notdef glyph for runes that are not in the font.
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{
+		Size: 12,
+		DPI:  72,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		Rune rune
+		Want bool
+	}{
+		{'a', true},
+		{'é', true},
+		{'あ', false},
+		{0xffff, false},
+	}
+	for _, c := range cases {
+		if got := text.HasGlyph(face, c.Rune); got != c.Want {
+			t.Errorf("HasGlyph(%q): got: %v, want: %v", c.Rune, got, c.Want)
+		}
+	}
+
+	fallback := &runeFace{}
+	mf := text.NewMultiFace(face, fallback)
+	want := fixed.R(0, -10, 10, 0)
+	if b, _, _ := mf.GlyphBounds('あ'); b != want {
+		t.Errorf("GlyphBounds('あ'): got: %v, want: %v (the fallback face's)", b, want)
+	}
+	if b, _, _ := mf.GlyphBounds('a'); b == want {
+		t.Errorf("GlyphBounds('a'): got: %v, want: the first face's", b)
+	}
+}
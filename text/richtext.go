@@ -0,0 +1,403 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// RichText is a parsed rich text.
+//
+// The markup of rich text consists of text and tags like BBCode:
+//
+//     [color=#rrggbb]...[/color]  changes the color. #rgb, #rrggbb and #rrggbbaa are accepted.
+//     [size=24]...[/size]         changes the size. The face for the size is given by RichTextOptions.Face.
+//     [b]...[/b]                  makes the text bold. The bold face is given by RichTextOptions.Face.
+//     [i]...[/i]                  makes the text italic. The italic face is given by RichTextOptions.Face.
+//     [img=name]                  puts an inline image given by RichTextOptions.Images.
+//     [[                          is a literal '['.
+//
+// Tags must be nested correctly. '\n' starts a new line.
+type RichText struct {
+	spans []richTextSpan
+}
+
+type richTextSpan struct {
+	text  string
+	image string
+	style RichTextStyle
+	color color.Color
+}
+
+// RichTextStyle represents a style of a text in a rich text.
+type RichTextStyle struct {
+	// Size is the size specified by the size tag. Size is 0 if no size is specified.
+	Size float64
+
+	// Bold reports whether the text is in the b tag.
+	Bold bool
+
+	// Italic reports whether the text is in the i tag.
+	Italic bool
+}
+
+type richTextTag struct {
+	name  string
+	style RichTextStyle
+	color color.Color
+}
+
+// ParseRichText parses the given markup.
+//
+// ParseRichText returns an error if the markup is invalid.
+func ParseRichText(markup string) (*RichText, error) {
+	t := &RichText{}
+
+	var stack []richTextTag
+	current := func() (RichTextStyle, color.Color) {
+		if len(stack) == 0 {
+			return RichTextStyle{}, nil
+		}
+		tag := stack[len(stack)-1]
+		return tag.style, tag.color
+	}
+
+	var buf strings.Builder
+	flush := func() {
+		if buf.Len() == 0 {
+			return
+		}
+		style, clr := current()
+		t.spans = append(t.spans, richTextSpan{
+			text:  buf.String(),
+			style: style,
+			color: clr,
+		})
+		buf.Reset()
+	}
+
+	for len(markup) > 0 {
+		idx := strings.IndexByte(markup, '[')
+		if idx < 0 {
+			buf.WriteString(markup)
+			break
+		}
+		buf.WriteString(markup[:idx])
+		markup = markup[idx:]
+
+		if strings.HasPrefix(markup, "[[") {
+			buf.WriteByte('[')
+			markup = markup[2:]
+			continue
+		}
+
+		end := strings.IndexByte(markup, ']')
+		if end < 0 {
+			return nil, fmt.Errorf("text: unclosed tag: %q", markup)
+		}
+		tag := markup[1:end]
+		markup = markup[end+1:]
+
+		flush()
+
+		if strings.HasPrefix(tag, "/") {
+			name := tag[1:]
+			if len(stack) == 0 || stack[len(stack)-1].name != name {
+				return nil, fmt.Errorf("text: unexpected closing tag: [%s]", tag)
+			}
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		name, value := tag, ""
+		if i := strings.IndexByte(tag, '='); i >= 0 {
+			name, value = tag[:i], tag[i+1:]
+		}
+
+		style, clr := current()
+		switch name {
+		case "color":
+			c, err := parseColor(value)
+			if err != nil {
+				return nil, err
+			}
+			clr = c
+		case "size":
+			s, err := strconv.ParseFloat(value, 64)
+			if err != nil || s <= 0 {
+				return nil, fmt.Errorf("text: invalid size: %q", value)
+			}
+			style.Size = s
+		case "b":
+			style.Bold = true
+		case "i":
+			style.Italic = true
+		case "img":
+			if value == "" {
+				return nil, fmt.Errorf("text: image name is missing")
+			}
+			t.spans = append(t.spans, richTextSpan{
+				image: value,
+				style: style,
+				color: clr,
+			})
+			continue
+		default:
+			return nil, fmt.Errorf("text: unknown tag: [%s]", tag)
+		}
+		stack = append(stack, richTextTag{
+			name:  name,
+			style: style,
+			color: clr,
+		})
+	}
+	flush()
+
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("text: tag is not closed: [%s]", stack[len(stack)-1].name)
+	}
+	return t, nil
+}
+
+func parseColor(str string) (color.Color, error) {
+	if !strings.HasPrefix(str, "#") {
+		return nil, fmt.Errorf("text: invalid color: %q", str)
+	}
+	s := str[1:]
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	if len(s) == 6 {
+		s += "ff"
+	}
+	if len(s) != 8 {
+		return nil, fmt.Errorf("text: invalid color: %q", str)
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("text: invalid color: %q", str)
+	}
+	// The color in the markup is not premultiplied.
+	return color.NRGBA{
+		R: uint8(v >> 24),
+		G: uint8(v >> 16),
+		B: uint8(v >> 8),
+		A: uint8(v),
+	}, nil
+}
+
+// RichTextOptions represents options to lay out a rich text.
+type RichTextOptions struct {
+	// Face returns the face for the given style. Face must not be nil, and must not return nil.
+	Face func(style RichTextStyle) font.Face
+
+	// Images are the inline images referred by the img tags. An image that is not in Images is skipped.
+	Images map[string]*ebiten.Image
+
+	// Color is the color of the text without a color tag. If Color is nil, white is used.
+	Color color.Color
+}
+
+// RichTextCommand is a command to draw a part of a rich text.
+type RichTextCommand struct {
+	// Text is the text to draw. Text is empty for an image.
+	Text string
+
+	// Face is the face for Text.
+	Face font.Face
+
+	// Color is the color for Text.
+	Color color.Color
+
+	// Image is the inline image to draw. Image is nil for a text.
+	Image *ebiten.Image
+
+	// X and Y are the position relative to the dot position of the first line.
+	// For a text, (X, Y) is the dot position. For an image, (X, Y) is the upper-left position.
+	X int
+	Y int
+}
+
+// Layout lays out the rich text and returns the draw commands and the bounds.
+//
+// The bounds' origin is the dot position of the first line like BoundString. Inline images are put on the baseline.
+//
+// Layout is concurrent-safe.
+func (t *RichText) Layout(options *RichTextOptions) ([]RichTextCommand, image.Rectangle) {
+	textM.Lock()
+	defer textM.Unlock()
+
+	defaultColor := options.Color
+	if defaultColor == nil {
+		defaultColor = color.White
+	}
+
+	type item struct {
+		cmd     RichTextCommand
+		advance fixed.Int26_6
+		ascent  fixed.Int26_6
+		descent fixed.Int26_6
+		gap     fixed.Int26_6
+	}
+
+	// Split the spans into lines.
+	var lines [][]item
+	var line []item
+	for _, s := range t.spans {
+		if s.image != "" {
+			img, ok := options.Images[s.image]
+			if !ok {
+				continue
+			}
+			w, h := img.Size()
+			line = append(line, item{
+				cmd: RichTextCommand{
+					Image: img,
+				},
+				advance: fixed.I(w),
+				ascent:  fixed.I(h),
+			})
+			continue
+		}
+
+		face := options.Face(s.style)
+		clr := s.color
+		if clr == nil {
+			clr = defaultColor
+		}
+		m := face.Metrics()
+		gap := m.Height - m.Ascent - m.Descent
+		if gap < 0 {
+			gap = 0
+		}
+		for i, str := range strings.Split(s.text, "\n") {
+			if i > 0 {
+				lines = append(lines, line)
+				line = nil
+			}
+			if str == "" && i > 0 {
+				// An empty line still has the height of the face.
+				line = append(line, item{
+					cmd: RichTextCommand{
+						Face:  face,
+						Color: clr,
+					},
+					ascent:  m.Ascent,
+					descent: m.Descent,
+					gap:     gap,
+				})
+				continue
+			}
+			if str == "" {
+				continue
+			}
+			line = append(line, item{
+				cmd: RichTextCommand{
+					Text:  str,
+					Face:  face,
+					Color: clr,
+				},
+				advance: stringAdvance(face, str, spacing{}),
+				ascent:  m.Ascent,
+				descent: m.Descent,
+				gap:     gap,
+			})
+		}
+	}
+	lines = append(lines, line)
+
+	var cmds []RichTextCommand
+	var bounds image.Rectangle
+	var baseline, prevDescent, prevGap fixed.Int26_6
+	for i, l := range lines {
+		var ascent, descent, gap fixed.Int26_6
+		for _, it := range l {
+			if ascent < it.ascent {
+				ascent = it.ascent
+			}
+			if descent < it.descent {
+				descent = it.descent
+			}
+			if gap < it.gap {
+				gap = it.gap
+			}
+		}
+		if i > 0 {
+			baseline += prevDescent + prevGap + ascent
+		}
+
+		var x fixed.Int26_6
+		for _, it := range l {
+			c := it.cmd
+			if c.Image != nil {
+				c.X = x.Round()
+				c.Y = (baseline - it.ascent).Round()
+				w, h := c.Image.Size()
+				bounds = bounds.Union(image.Rect(c.X, c.Y, c.X+w, c.Y+h))
+				cmds = append(cmds, c)
+			} else if c.Text != "" {
+				c.X = x.Round()
+				c.Y = baseline.Round()
+				bounds = bounds.Union(boundString(c.Face, c.Text, spacing{}).Add(image.Pt(c.X, c.Y)))
+				cmds = append(cmds, c)
+			}
+			x += it.advance
+		}
+		prevDescent = descent
+		prevGap = gap
+	}
+	return cmds, bounds
+}
+
+// stringAdvance returns the advance of the string in the same way as Draw.
+func stringAdvance(face font.Face, text string, sp spacing) fixed.Int26_6 {
+	text = shapeText(face, text)
+
+	var x fixed.Int26_6
+	prevR := rune(-1)
+	for _, r := range text {
+		if prevR >= 0 {
+			x += sp.glyphGap(face, prevR, r)
+		}
+		x += glyphAdvance(face, r)
+		prevR = r
+	}
+	return x
+}
+
+// DrawRichText draws the commands returned by (*RichText).Layout on dst.
+// (x, y) is the dot position of the first line.
+//
+// DrawRichText is concurrent-safe.
+func DrawRichText(dst *ebiten.Image, commands []RichTextCommand, x, y int) {
+	for _, c := range commands {
+		if c.Image != nil {
+			op := &ebiten.DrawImageOptions{}
+			op.GeoM.Translate(float64(x+c.X), float64(y+c.Y))
+			dst.DrawImage(c.Image, op)
+			continue
+		}
+		Draw(dst, c.Text, c.Face, x+c.X, y+c.Y, c.Color)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"strings"
+
+	"golang.org/x/image/font"
+)
+
+type shapedTextKey struct {
+	face font.Face
+	text string
+}
+
+type shapedTextEntry struct {
+	text  string
+	atime int64
+}
+
+// shapedTextCacheSize is the soft limit of the number of the cached shaped texts.
+const shapedTextCacheSize = 256
+
+var shapedTextCache = map[shapedTextKey]*shapedTextEntry{}
+
+// shapeText returns the text in the visual order with Arabic letters in their contextual forms.
+// Each line is treated as a paragraph.
+//
+// The result is cached as the same text is usually drawn every frame.
+func shapeText(face font.Face, text string) string {
+	if !needsShaping(text) {
+		return text
+	}
+
+	key := shapedTextKey{
+		face: face,
+		text: text,
+	}
+	if e, ok := shapedTextCache[key]; ok {
+		e.atime = now()
+		return e.text
+	}
+
+	lines := strings.Split(text, "\n")
+	for i, l := range lines {
+		if !needsShaping(l) {
+			continue
+		}
+		rs := shapeArabic(face, []rune(l))
+		lines[i] = string(bidiReorder(rs))
+	}
+	shaped := strings.Join(lines, "\n")
+
+	if len(shapedTextCache) >= shapedTextCacheSize {
+		for k, e := range shapedTextCache {
+			if e.atime < now()-glyphCacheEvictionTicks {
+				delete(shapedTextCache, k)
+			}
+		}
+	}
+	shapedTextCache[key] = &shapedTextEntry{
+		text:  shaped,
+		atime: now(),
+	}
+	return shaped
+}
@@ -0,0 +1,55 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// spacing represents the spacing between glyphs and lines.
+// The zero value is the default spacing of the face.
+type spacing struct {
+	// letter is added to the advance of each glyph except for the last glyph of a line.
+	letter fixed.Int26_6
+
+	// line is the distance between the dots of adjacent lines.
+	// If line is 0, the face's line height is used.
+	line fixed.Int26_6
+
+	// paragraph is added to the distance between lines separated by a line break.
+	paragraph fixed.Int26_6
+}
+
+func newSpacing(letterSpacing, lineHeight, paragraphSpacing float64) spacing {
+	return spacing{
+		letter:    floatToFixed26_6(letterSpacing),
+		line:      floatToFixed26_6(lineHeight),
+		paragraph: floatToFixed26_6(paragraphSpacing),
+	}
+}
+
+// lineHeight returns the distance between the dots of adjacent lines.
+func (s spacing) lineHeight(face font.Face) fixed.Int26_6 {
+	if s.line > 0 {
+		return s.line
+	}
+	return lineHeight(face)
+}
+
+// glyphGap returns the distance added between the glyphs of r0 and r1 in addition to r0's advance.
+func (s spacing) glyphGap(face font.Face, r0, r1 rune) fixed.Int26_6 {
+	return kern(face, r0, r1) + s.letter
+}
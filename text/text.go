@@ -14,6 +14,27 @@
 
 // Package text offers functions to draw texts on an Ebiten's image.
 //
+// Texts are given in the logical order. Lines including right-to-left scripts like Hebrew and Arabic are reordered
+// into the visual order by a simplified version of the Unicode Bidirectional Algorithm, and Arabic letters are
+// replaced with their contextual forms if the face has the glyphs of the Arabic presentation forms.
+// Scripts that require an OpenType shaping engine, like Devanagari, are not rendered correctly yet.
+//
+// Color fonts like color emoji fonts can be used with NewColorFace.
+// NewVariableFace creates a face of a variable font whose axes like the weight can be changed at runtime.
+// NewMultiFace composes faces into a fallback chain, e.g. a Latin face, a CJK face and an emoji face. NewFace creates
+// a face that tells NewMultiFace which runes the font has.
+//
+// SetFaceOptions controls the hinting and the subpixel positioning of glyphs for each face.
+// SetGlyphRasterizationMode moves the rasterization of new glyphs to a background worker.
+//
+// DrawWrapped draws texts wrapped in a width and aligned to the left, the center, the right or the both sides.
+// DrawOptions and WrapOptions can specify the letter spacing, the line height and the paragraph spacing.
+//
+// LayoutGlyphs returns the position of each glyph for text cursors and selections, and IndexAt returns the position
+// in a text for a clicked point.
+//
+// DrawDistanceField renders texts with distance fields, which keep the glyphs crisp under any scaling and rotation.
+//
 // For the example using a TTF font, see font package in the examples.
 package text
 
@@ -43,20 +64,50 @@ func init() {
 		monotonicClock++
 		return nil
 	})
+	hooks.AppendHookOnLowMemory(func() error {
+		textM.Lock()
+		defer textM.Unlock()
+
+		// Glyph images can be recreated anytime.
+		for face, m := range glyphImageCache {
+			for _, e := range m {
+				if e.image != nil {
+					e.image.Dispose()
+				}
+			}
+			delete(glyphImageCache, face)
+		}
+		return nil
+	})
+	hooks.AppendHookOnReset(func() error {
+		// The glyph images and the shader are disposed by ebiten.RestartGame.
+		// Forget them so that they are recreated.
+		ClearGlyphCache(nil)
+		distanceFieldShader = nil
+		distanceFieldShaderErr = nil
+		return nil
+	})
+	hooks.AppendHookOnDeviceLost(func() error {
+		// The glyph images might lose their contents. Let them be recreated.
+		ClearGlyphCache(nil)
+		return nil
+	})
 }
 
 func fixed26_6ToFloat64(x fixed.Int26_6) float64 {
 	return float64(x>>6) + float64(x&((1<<6)-1))/float64(1<<6)
 }
 
-func drawGlyph(dst *ebiten.Image, face font.Face, r rune, img *ebiten.Image, x, y fixed.Int26_6, clr ebiten.ColorM) {
+func drawGlyph(dst *ebiten.Image, face font.Face, r rune, img *ebiten.Image, x, y fixed.Int26_6, offsetX, offsetY float64, clr ebiten.ColorM, variant glyphVariant) {
 	if img == nil {
 		return
 	}
 
 	b := getGlyphBounds(face, r)
+	p := variant.padding()
 	op := &ebiten.DrawImageOptions{}
-	op.GeoM.Translate(float64((x+b.Min.X)>>6), float64((y+b.Min.Y)>>6))
+	op.GeoM.Translate(float64((x+b.Min.X)>>6)-float64(p), float64((y+b.Min.Y)>>6)-float64(p))
+	op.GeoM.Translate(offsetX, offsetY)
 	op.ColorM = clr
 	dst.DrawImage(img, op)
 }
@@ -77,32 +128,111 @@ func getGlyphBounds(face font.Face, r rune) fixed.Rectangle26_6 {
 	return b
 }
 
+// glyphVariant represents an effect applied to a glyph image.
+type glyphVariant struct {
+	// outline is the width of the outline. If outline is 0, the glyph image is the glyph itself.
+	outline fixed.Int26_6
+
+	// blur is the radius of the blur applied after the outline. If blur is 0, the glyph image is not blurred.
+	blur fixed.Int26_6
+
+	// distanceFieldSpread is the range of the distance field in pixels. If distanceFieldSpread is 0, the glyph
+	// image is not a distance field.
+	distanceFieldSpread int
+}
+
+// padding returns the number of pixels added to each side of the glyph image by the effect.
+func (v glyphVariant) padding() int {
+	return v.outline.Ceil() + v.blur.Ceil() + v.distanceFieldSpread
+}
+
+// apply applies the effect to the glyph image.
+func (v glyphVariant) apply(rgba *image.RGBA) image.Image {
+	if v.outline == 0 && v.blur == 0 && v.distanceFieldSpread == 0 {
+		return rgba
+	}
+	a := alphaFromRGBA(rgba)
+	if v.outline > 0 {
+		a = dilate(a, fixed26_6ToFloat64(v.outline))
+	}
+	if v.blur > 0 {
+		a = blur(a, fixed26_6ToFloat64(v.blur))
+	}
+	if v.distanceFieldSpread > 0 {
+		a = distanceField(a, v.distanceFieldSpread)
+	}
+	return a
+}
+
+func floatToFixed26_6(x float64) fixed.Int26_6 {
+	return fixed.Int26_6(math.Round(x * (1 << 6)))
+}
+
+type glyphImageKey struct {
+	rune    rune
+	variant glyphVariant
+
+	// subpixel is the index of the horizontal subpixel position.
+	subpixel int
+}
+
 type glyphImageCacheEntry struct {
 	image *ebiten.Image
 	atime int64
 }
 
 var (
-	glyphImageCache = map[font.Face]map[rune]*glyphImageCacheEntry{}
+	glyphImageCache = map[font.Face]map[glyphImageKey]*glyphImageCacheEntry{}
 )
 
-func getGlyphImage(face font.Face, r rune) *ebiten.Image {
+func getGlyphImage(face font.Face, r rune, variant glyphVariant, subpixel int) *ebiten.Image {
 	if _, ok := glyphImageCache[face]; !ok {
-		glyphImageCache[face] = map[rune]*glyphImageCacheEntry{}
+		glyphImageCache[face] = map[glyphImageKey]*glyphImageCacheEntry{}
 	}
 
-	if e, ok := glyphImageCache[face][r]; ok {
+	key := glyphImageKey{
+		rune:     r,
+		variant:  variant,
+		subpixel: subpixel,
+	}
+	if e, ok := glyphImageCache[face][key]; ok {
+		e.atime = now()
+		return e.image
+	}
+
+	// The glyph might have been rasterized by the background worker.
+	flushRasterizedGlyphs()
+	if e, ok := glyphImageCache[face][key]; ok {
 		e.atime = now()
 		return e.image
 	}
+	if glyphRasterizationMode == GlyphRasterizationModeAsync {
+		requestGlyph(face, key)
+		return nil
+	}
+
+	var img *ebiten.Image
+	if rgba := rasterizeGlyph(face, r, subpixel); rgba != nil {
+		img = ebiten.NewImageFromImage(variant.apply(rgba))
+	}
+	glyphImageCache[face][key] = &glyphImageCacheEntry{
+		image: img,
+		atime: now(),
+	}
+	return img
+}
+
+// rasterizeGlyph renders the glyph for r at the subpixel position. rasterizeGlyph returns nil if the glyph is empty.
+func rasterizeGlyph(face font.Face, r rune, subpixel int) *image.RGBA {
+	if cf, ok := face.(colorGlyphFace); ok {
+		if img, _, ok := cf.colorGlyph(r); ok {
+			return img
+		}
+	}
 
 	b := getGlyphBounds(face, r)
 	w, h := (b.Max.X - b.Min.X).Ceil(), (b.Max.Y - b.Min.Y).Ceil()
 	if w == 0 || h == 0 {
-		glyphImageCache[face][r] = &glyphImageCacheEntry{
-			image: nil,
-			atime: now(),
-		}
 		return nil
 	}
 
@@ -112,6 +242,10 @@ func getGlyphImage(face font.Face, r rune) *ebiten.Image {
 	if b.Min.Y&((1<<6)-1) != 0 {
 		h++
 	}
+	// The glyph at a subpixel position can overflow by one pixel.
+	if subpixel > 0 {
+		w++
+	}
 	rgba := image.NewRGBA(image.Rect(0, 0, w, h))
 
 	d := font.Drawer{
@@ -121,18 +255,10 @@ func getGlyphImage(face font.Face, r rune) *ebiten.Image {
 	}
 	x, y := -b.Min.X, -b.Min.Y
 	x, y = fixed.I(x.Ceil()), fixed.I(y.Ceil())
+	x += fixed.Int26_6(subpixel * (1 << 6) / getFaceOptions(face).SubpixelPositions)
 	d.Dot = fixed.Point26_6{X: x, Y: y}
 	d.DrawString(string(r))
-
-	img := ebiten.NewImageFromImage(rgba)
-	if _, ok := glyphImageCache[face][r]; !ok {
-		glyphImageCache[face][r] = &glyphImageCacheEntry{
-			image: img,
-			atime: now(),
-		}
-	}
-
-	return img
+	return rgba
 }
 
 var textM sync.Mutex
@@ -176,6 +302,109 @@ func Draw(dst *ebiten.Image, text string, face font.Face, x, y int, clr color.Co
 	textM.Lock()
 	defer textM.Unlock()
 
+	drawGlyphs(dst, text, face, x, y, 0, 0, clr, glyphVariant{}, spacing{})
+	cleanUpGlyphCache(face)
+}
+
+// DrawOptions represents options for DrawWithOptions.
+type DrawOptions struct {
+	// OutlineWidth is the width of the outline in pixels.
+	// If OutlineWidth is 0 or negative, no outline is drawn.
+	OutlineWidth float64
+
+	// OutlineColor is the color of the outline.
+	// If OutlineColor is nil, black is used.
+	OutlineColor color.Color
+
+	// ShadowOffsetX and ShadowOffsetY are the offset of the drop shadow in pixels.
+	// If both are 0 and ShadowBlur is 0 or negative, no shadow is drawn.
+	ShadowOffsetX float64
+	ShadowOffsetY float64
+
+	// ShadowBlur is the radius of the blur of the drop shadow in pixels.
+	// If ShadowBlur is 0 or negative, the shadow is not blurred.
+	ShadowBlur float64
+
+	// ShadowColor is the color of the drop shadow.
+	// If ShadowColor is nil, translucent black is used.
+	ShadowColor color.Color
+
+	// LetterSpacing is the space added between glyphs in pixels, also known as tracking.
+	// LetterSpacing can be negative to tighten the text.
+	LetterSpacing float64
+
+	// LineHeight is the distance between the dots of adjacent lines in pixels.
+	// If LineHeight is 0 or negative, the face's height is used.
+	LineHeight float64
+
+	// ParagraphSpacing is the space added after each line break ('\n') in pixels.
+	ParagraphSpacing float64
+}
+
+func (o *DrawOptions) spacing() spacing {
+	return newSpacing(o.LetterSpacing, o.LineHeight, o.ParagraphSpacing)
+}
+
+func (o *DrawOptions) hasShadow() bool {
+	return o.ShadowOffsetX != 0 || o.ShadowOffsetY != 0 || o.ShadowBlur > 0
+}
+
+// DrawWithOptions draws a given text on a given destination image dst with the given options.
+//
+// DrawWithOptions works like Draw except for the options. If options is nil, DrawWithOptions is the same as Draw.
+//
+// The outline is rendered as a separate glyph image dilated from the glyph, not by drawing the glyph multiple times
+// with offsets. The outlines of all the glyphs are drawn before the glyphs so that an outline never covers
+// an adjacent glyph.
+//
+// The drop shadow is rendered as a blurred glyph image, including the outline if any, and is drawn before
+// the outlines and the glyphs. As the shadow's glyph images are in the same cache as the other glyph images,
+// the shadow doesn't need an additional offscreen pass.
+//
+// DrawWithOptions is concurrent-safe.
+func DrawWithOptions(dst *ebiten.Image, text string, face font.Face, x, y int, clr color.Color, options *DrawOptions) {
+	textM.Lock()
+	defer textM.Unlock()
+
+	if options == nil {
+		options = &DrawOptions{}
+	}
+	sp := options.spacing()
+
+	var outline fixed.Int26_6
+	if options.OutlineWidth > 0 {
+		outline = floatToFixed26_6(options.OutlineWidth)
+	}
+
+	if options.hasShadow() {
+		sclr := options.ShadowColor
+		if sclr == nil {
+			sclr = color.RGBA{0, 0, 0, 0x80}
+		}
+		var b fixed.Int26_6
+		if options.ShadowBlur > 0 {
+			b = floatToFixed26_6(options.ShadowBlur)
+		}
+		drawGlyphs(dst, text, face, x, y, options.ShadowOffsetX, options.ShadowOffsetY, sclr, glyphVariant{
+			outline: outline,
+			blur:    b,
+		}, sp)
+	}
+	if outline > 0 {
+		oclr := options.OutlineColor
+		if oclr == nil {
+			oclr = color.Black
+		}
+		drawGlyphs(dst, text, face, x, y, 0, 0, oclr, glyphVariant{
+			outline: outline,
+		}, sp)
+	}
+	drawGlyphs(dst, text, face, x, y, 0, 0, clr, glyphVariant{}, sp)
+	cleanUpGlyphCache(face)
+}
+
+// drawGlyphs draws the glyphs of the text. (offsetX, offsetY) is added to each glyph's position.
+func drawGlyphs(dst *ebiten.Image, text string, face font.Face, x, y int, offsetX, offsetY float64, clr color.Color, variant glyphVariant, sp spacing) {
 	cr, cg, cb, ca := clr.RGBA()
 	if ca == 0 {
 		return
@@ -184,15 +413,33 @@ func Draw(dst *ebiten.Image, text string, face font.Face, x, y int, clr color.Co
 	var colorm ebiten.ColorM
 	colorm.Scale(float64(cr)/float64(ca), float64(cg)/float64(ca), float64(cb)/float64(ca), float64(ca)/0xffff)
 
+	// Color glyphs keep their own colors and only the alpha is applied.
+	// Outlines and shadows use the silhouettes, then the given color is applied as it is.
+	var colorGlyphColorM ebiten.ColorM
+	colorGlyphColorM.Scale(1, 1, 1, float64(ca)/0xffff)
+
+	forEachGlyph(face, text, x, y, sp, func(r rune, fx, fy fixed.Int26_6) {
+		fx, s := subpixelPosition(face, fx)
+		img := getGlyphImage(face, r, variant, s)
+		cm := colorm
+		if variant == (glyphVariant{}) && isColorGlyph(face, r) {
+			cm = colorGlyphColorM
+		}
+		drawGlyph(dst, face, r, img, fx, fy, offsetX, offsetY, cm, variant)
+	})
+}
+
+// forEachGlyph calls f with each rune of the shaped text and its dot position.
+// Line breaks are not passed to f.
+func forEachGlyph(face font.Face, text string, x, y int, sp spacing, f func(r rune, x, y fixed.Int26_6)) {
+	text = shapeText(face, text)
+
 	fx, fy := fixed.I(x), fixed.I(y)
 	prevR := rune(-1)
 
-	faceHeight := face.Metrics().Height
+	faceHeight := sp.lineHeight(face) + sp.paragraph
 
 	for _, r := range text {
-		if prevR >= 0 {
-			fx += face.Kern(prevR, r)
-		}
 		if r == '\n' {
 			fx = fixed.I(x)
 			fy += faceHeight
@@ -200,28 +447,14 @@ func Draw(dst *ebiten.Image, text string, face font.Face, x, y int, clr color.Co
 			continue
 		}
 
-		img := getGlyphImage(face, r)
-		drawGlyph(dst, face, r, img, fx, fy, colorm)
+		if prevR >= 0 {
+			fx += sp.glyphGap(face, prevR, r)
+		}
+		f(r, fx, fy)
 		fx += glyphAdvance(face, r)
 
 		prevR = r
 	}
-
-	// cacheSoftLimit indicates the soft limit of the number of glyphs in the cache.
-	// If the number of glyphs exceeds this soft limits, old glyphs are removed.
-	// Even after clearning up the cache, the number of glyphs might still exceeds the soft limit, but
-	// this is fine.
-	const cacheSoftLimit = 512
-
-	// Clean up the cache.
-	if len(glyphImageCache[face]) > cacheSoftLimit {
-		for r, e := range glyphImageCache[face] {
-			// 60 is an arbitrary number.
-			if e.atime < now()-60 {
-				delete(glyphImageCache[face], r)
-			}
-		}
-	}
 }
 
 // BoundString returns the measured size of a given string using a given font.
@@ -243,23 +476,60 @@ func BoundString(face font.Face, text string) image.Rectangle {
 	textM.Lock()
 	defer textM.Unlock()
 
-	m := face.Metrics()
-	faceHeight := m.Height
+	return boundString(face, text, spacing{})
+}
+
+// BoundStringWithOptions returns the measured size of a given string drawn by DrawWithOptions with the given options.
+//
+// BoundStringWithOptions works like BoundString except for the options. The spacing options, the outline and
+// the drop shadow are considered. If options is nil, BoundStringWithOptions is the same as BoundString.
+//
+// BoundStringWithOptions is concurrent-safe.
+func BoundStringWithOptions(face font.Face, text string, options *DrawOptions) image.Rectangle {
+	textM.Lock()
+	defer textM.Unlock()
+
+	if options == nil {
+		return boundString(face, text, spacing{})
+	}
+
+	bounds := boundString(face, text, options.spacing())
+	if bounds.Empty() {
+		return bounds
+	}
+	if options.OutlineWidth > 0 {
+		bounds = bounds.Inset(-int(math.Ceil(options.OutlineWidth)))
+	}
+	if options.hasShadow() {
+		s := bounds
+		if options.ShadowBlur > 0 {
+			s = s.Inset(-int(math.Ceil(options.ShadowBlur)))
+		}
+		s = s.Add(image.Pt(int(math.Round(options.ShadowOffsetX)), int(math.Round(options.ShadowOffsetY))))
+		bounds = bounds.Union(s)
+	}
+	return bounds
+}
+
+func boundString(face font.Face, text string, sp spacing) image.Rectangle {
+	text = shapeText(face, text)
+
+	faceHeight := sp.lineHeight(face) + sp.paragraph
 
 	fx, fy := fixed.I(0), fixed.I(0)
 	prevR := rune(-1)
 
 	var bounds fixed.Rectangle26_6
 	for _, r := range text {
-		if prevR >= 0 {
-			fx += face.Kern(prevR, r)
-		}
 		if r == '\n' {
 			fx = fixed.I(0)
 			fy += faceHeight
 			prevR = rune(-1)
 			continue
 		}
+		if prevR >= 0 {
+			fx += sp.glyphGap(face, prevR, r)
+		}
 
 		b := getGlyphBounds(face, r)
 		b.Min.X += fx
@@ -301,11 +571,15 @@ func BoundString(face font.Face, text string) image.Rectangle {
 // merged into one draw call regardless of the size of the text.
 //
 // If a rune's glyph is already cached, CacheGlyphs does nothing for the rune.
+//
+// To keep a large number of glyphs like CJK characters in the cache, increase the cache size by SetGlyphCacheSize.
+//
+// CacheGlyphs is concurrent-safe.
 func CacheGlyphs(face font.Face, text string) {
 	textM.Lock()
 	defer textM.Unlock()
 
 	for _, r := range text {
-		getGlyphImage(face, r)
+		getGlyphImage(face, r, glyphVariant{}, 0)
 	}
 }
@@ -18,9 +18,12 @@ import (
 	"image"
 	"image/color"
 	"testing"
+	"time"
 
 	"github.com/hajimehoshi/bitmapfont/v2"
 	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
 	"golang.org/x/image/math/fixed"
 
 	"github.com/hajimehoshi/ebiten/v2"
@@ -146,3 +149,492 @@ func TestTextOverlap(t *testing.T) {
 		}
 	}
 }
+
+func TestTextOutline(t *testing.T) {
+	f := &testFace{}
+	dst := ebiten.NewImage(testFaceSize+4, testFaceSize+4)
+
+	clr := color.RGBA{0xff, 0, 0, 0xff}
+	DrawWithOptions(dst, "b", f, 2, 2, color.White, &DrawOptions{
+		OutlineWidth: 1,
+		OutlineColor: clr,
+	})
+
+	for j := 0; j < testFaceSize+4; j++ {
+		for i := 0; i < testFaceSize+4; i++ {
+			got := dst.At(i, j)
+			var want color.RGBA
+			switch {
+			case 2 <= i && i < testFaceSize+2 && 2 <= j && j < testFaceSize+2:
+				want = color.RGBA{0xff, 0xff, 0xff, 0xff}
+			case (i == 1 || i == testFaceSize+2) && 2 <= j && j < testFaceSize+2:
+				want = clr
+			case (j == 1 || j == testFaceSize+2) && 2 <= i && i < testFaceSize+2:
+				want = clr
+			case i == 0 || j == 0 || i == testFaceSize+3 || j == testFaceSize+3:
+				want = color.RGBA{}
+			default:
+				// Corners are anti-aliased.
+				continue
+			}
+			if got != want {
+				t.Errorf("At(%d, %d): got: %v, want: %v", i, j, got, want)
+			}
+		}
+	}
+}
+
+func TestTextShadow(t *testing.T) {
+	f := &testFace{}
+	dst := ebiten.NewImage(testFaceSize*2, testFaceSize)
+
+	clr := color.RGBA{0, 0, 0x80, 0x80}
+	DrawWithOptions(dst, "b", f, 0, 0, color.White, &DrawOptions{
+		ShadowOffsetX: testFaceSize,
+		ShadowColor:   clr,
+	})
+
+	for j := 0; j < testFaceSize; j++ {
+		for i := 0; i < testFaceSize*2; i++ {
+			got := dst.At(i, j)
+			want := color.RGBA{0xff, 0xff, 0xff, 0xff}
+			if i >= testFaceSize {
+				want = clr
+			}
+			if got != want {
+				t.Errorf("At(%d, %d): got: %v, want: %v", i, j, got, want)
+			}
+		}
+	}
+}
+
+func TestParseRichTextError(t *testing.T) {
+	for _, markup := range []string{
+		"[b]a",
+		"a[/b]",
+		"[b][i]a[/b][/i]",
+		"[color=red]a[/color]",
+		"[size=-1]a[/size]",
+		"[unknown]a[/unknown]",
+		"[b",
+	} {
+		if _, err := ParseRichText(markup); err == nil {
+			t.Errorf("ParseRichText(%q) must return an error", markup)
+		}
+	}
+}
+
+func TestRichTextLayout(t *testing.T) {
+	rt, err := ParseRichText("a[[[color=#f00]b[/color]\n[img=icon]a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := &testFace{}
+	icon := ebiten.NewImage(4, 4)
+	cmds, _ := rt.Layout(&RichTextOptions{
+		Face: func(style RichTextStyle) font.Face {
+			return f
+		},
+		Images: map[string]*ebiten.Image{
+			"icon": icon,
+		},
+	})
+
+	want := []RichTextCommand{
+		{Text: "a[", Face: f, Color: color.White, X: 0, Y: 0},
+		{Text: "b", Face: f, Color: color.NRGBA{0xff, 0, 0, 0xff}, X: testFaceSize * 2, Y: 0},
+		{Image: icon, X: 0, Y: testFaceSize - 4},
+		{Text: "a", Face: f, Color: color.White, X: 4, Y: testFaceSize},
+	}
+	if len(cmds) != len(want) {
+		t.Fatalf("len(cmds): got: %d, want: %d", len(cmds), len(want))
+	}
+	for i := range want {
+		if cmds[i] != want[i] {
+			t.Errorf("cmds[%d]: got: %v, want: %v", i, cmds[i], want[i])
+		}
+	}
+}
+
+func TestColorFaceWithoutColorGlyphs(t *testing.T) {
+	options := &opentype.FaceOptions{
+		Size: 16,
+		DPI:  72,
+	}
+	f, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := opentype.NewFace(f, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := NewColorFace(goregular.TTF, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, r := range "Ag." {
+		gb, ga, gok := got.GlyphBounds(r)
+		wb, wa, wok := want.GlyphBounds(r)
+		if gb != wb || ga != wa || gok != wok {
+			t.Errorf("GlyphBounds(%q): got: %v, %v, %v, want: %v, %v, %v", r, gb, ga, gok, wb, wa, wok)
+		}
+	}
+
+	if got, want := BoundString(got, "Ag."), BoundString(want, "Ag."); got != want {
+		t.Errorf("BoundString: got: %v, want: %v", got, want)
+	}
+
+	if _, err := NewColorFace([]byte("ttcf"), options); err == nil {
+		t.Errorf("NewColorFace with an invalid font must return an error")
+	}
+}
+
+func TestDrawDistanceField(t *testing.T) {
+	const scale = 4
+
+	f := &testFace{}
+	dst := ebiten.NewImage((testFaceSize+2)*scale, (testFaceSize+2)*scale)
+
+	op := &DistanceFieldOptions{}
+	op.GeoM.Translate(1, 1)
+	op.GeoM.Scale(scale, scale)
+	DrawDistanceField(dst, "b", f, color.White, op)
+
+	w, h := dst.Size()
+	for j := 0; j < h; j++ {
+		for i := 0; i < w; i++ {
+			got := dst.At(i, j)
+			var want color.RGBA
+			switch {
+			case scale+2 <= i && i < (testFaceSize+1)*scale-2 && scale+2 <= j && j < (testFaceSize+1)*scale-2:
+				want = color.RGBA{0xff, 0xff, 0xff, 0xff}
+			case i < scale-2 || (testFaceSize+1)*scale+2 <= i || j < scale-2 || (testFaceSize+1)*scale+2 <= j:
+				want = color.RGBA{}
+			default:
+				// The edges are anti-aliased.
+				continue
+			}
+			if got != want {
+				t.Errorf("At(%d, %d): got: %v, want: %v", i, j, got, want)
+			}
+		}
+	}
+}
+
+type coverageFace struct {
+	testFace
+	runes string
+}
+
+func (f *coverageFace) GlyphAdvance(r rune) (advance fixed.Int26_6, ok bool) {
+	for _, r2 := range f.runes {
+		if r == r2 {
+			return fixed.I(testFaceSize), true
+		}
+	}
+	return 0, false
+}
+
+func (f *coverageFace) Metrics() font.Metrics {
+	m := f.testFace.Metrics()
+	m.Descent = fixed.I(len(f.runes))
+	return m
+}
+
+func TestMultiFace(t *testing.T) {
+	f0 := &coverageFace{runes: "a"}
+	f1 := &coverageFace{runes: "ab"}
+	f := NewMultiFace(f0, f1)
+
+	if got, want := f.Metrics().Descent, fixed.I(2); got != want {
+		t.Errorf("Descent: got: %v, want: %v", got, want)
+	}
+
+	// testFace kerns before 'b'. The kerning is applied only when the two runes use the same face.
+	if got, want := f.Kern('a', 'b'), fixed.Int26_6(0); got != want {
+		t.Errorf("Kern('a', 'b'): got: %v, want: %v", got, want)
+	}
+	if got, want := f.Kern('b', 'b'), fixed.I(-testFaceSize); got != want {
+		t.Errorf("Kern('b', 'b'): got: %v, want: %v", got, want)
+	}
+
+	// The first face is used when no face has the glyph.
+	if _, ok := f.GlyphAdvance('c'); ok {
+		t.Errorf("GlyphAdvance('c'): got: true, want: false")
+	}
+	if _, ok := f.GlyphAdvance('b'); !ok {
+		t.Errorf("GlyphAdvance('b'): got: false, want: true")
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	f := &testFace{}
+	cases := []struct {
+		Text  string
+		Width int
+		Want  []string
+	}{
+		{
+			Text:  "aa aa aaa",
+			Width: testFaceSize * 3,
+			Want:  []string{"aa", "aa", "aaa"},
+		},
+		{
+			Text:  "aaaaaaa",
+			Width: testFaceSize * 3,
+			Want:  []string{"aaa", "aaa", "a"},
+		},
+		{
+			// '。' cannot start a line.
+			Text:  "あいう。",
+			Width: testFaceSize * 3,
+			Want:  []string{"あい", "う。"},
+		},
+		{
+			// '「' cannot end a line.
+			Text:  "あ「い」",
+			Width: testFaceSize * 3,
+			Want:  []string{"あ", "「い」"},
+		},
+		{
+			Text:  "aa\n\naa aa",
+			Width: 0,
+			Want:  []string{"aa", "", "aa aa"},
+		},
+	}
+	for _, c := range cases {
+		got := WrapText(f, c.Text, c.Width)
+		if len(got) != len(c.Want) {
+			t.Errorf("WrapText(%q, %d): got: %q, want: %q", c.Text, c.Width, got, c.Want)
+			continue
+		}
+		for i := range got {
+			if got[i] != c.Want[i] {
+				t.Errorf("WrapText(%q, %d): got: %q, want: %q", c.Text, c.Width, got, c.Want)
+				break
+			}
+		}
+	}
+}
+
+func TestBoundWrapped(t *testing.T) {
+	f := &testFace{}
+	cases := []struct {
+		Align Align
+		Want  image.Rectangle
+	}{
+		{
+			Align: AlignLeft,
+			Want:  image.Rect(0, 0, testFaceSize*2, testFaceSize*2),
+		},
+		{
+			Align: AlignCenter,
+			Want:  image.Rect(testFaceSize/2, 0, testFaceSize*5/2, testFaceSize*2),
+		},
+		{
+			Align: AlignRight,
+			Want:  image.Rect(testFaceSize, 0, testFaceSize*3, testFaceSize*2),
+		},
+	}
+	for _, c := range cases {
+		got := BoundWrapped(f, "a\naa", &WrapOptions{
+			Width: testFaceSize * 3,
+			Align: c.Align,
+		})
+		if got != c.Want {
+			t.Errorf("BoundWrapped with align %d: got: %v, want: %v", c.Align, got, c.Want)
+		}
+	}
+}
+
+func TestVariableFaceWithoutVariations(t *testing.T) {
+	options := &opentype.FaceOptions{
+		Size: 16,
+		DPI:  72,
+	}
+	f, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := opentype.NewFace(f, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := NewVariableFace(goregular.TTF, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got.Axes()) != 0 {
+		t.Errorf("len(Axes()): got: %d, want: 0", len(got.Axes()))
+	}
+
+	// The bounds of VariableFace are aligned with whole pixels.
+	near := func(a, b fixed.Int26_6) bool {
+		return a-b <= fixed.I(1) && b-a <= fixed.I(1)
+	}
+	for _, r := range "Ag.é" {
+		gb, ga, _ := got.GlyphBounds(r)
+		wb, wa, _ := want.GlyphBounds(r)
+		if ga != wa {
+			t.Errorf("advance of %q: got: %v, want: %v", r, ga, wa)
+		}
+		if !near(gb.Min.X, wb.Min.X) || !near(gb.Min.Y, wb.Min.Y) || !near(gb.Max.X, wb.Max.X) || !near(gb.Max.Y, wb.Max.Y) {
+			t.Errorf("bounds of %q: got: %v, want: %v", r, gb, wb)
+		}
+	}
+}
+
+func TestFaceOptionsHinting(t *testing.T) {
+	f, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{
+		Size: 13,
+		DPI:  72,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, _ := face.GlyphAdvance('a')
+	if a.Round()*64 == int(a) {
+		t.Fatalf("the advance must not be aligned with whole pixels for this test: %v", a)
+	}
+
+	SetFaceOptions(face, &FaceOptions{
+		Hinting: font.HintingFull,
+	})
+	defer SetFaceOptions(face, nil)
+
+	// With font.HintingFull, the glyphs are placed at whole pixels of the rounded advance.
+	b0 := BoundString(face, "a")
+	b1 := BoundString(face, "aaaa")
+	if got, want := b1.Max.X-b0.Max.X, 3*a.Round(); got != want {
+		t.Errorf("got: %d, want: %d", got, want)
+	}
+}
+
+func TestLayoutGlyphs(t *testing.T) {
+	f := &testFace{}
+
+	// 'א' and 'ב' are Hebrew letters and are laid out from right to left.
+	got := LayoutGlyphs(f, "aa\nאב")
+	want := []GlyphLayout{
+		{Rune: 'a', StartIndex: 0, EndIndex: 1, X: 0, Y: 0, Advance: testFaceSize, Line: 0},
+		{Rune: 'a', StartIndex: 1, EndIndex: 2, X: testFaceSize, Y: 0, Advance: testFaceSize, Line: 0},
+		{Rune: 'ב', StartIndex: 5, EndIndex: 7, X: 0, Y: testFaceSize, Advance: testFaceSize, Line: 1, RightToLeft: true},
+		{Rune: 'א', StartIndex: 3, EndIndex: 5, X: testFaceSize, Y: testFaceSize, Advance: testFaceSize, Line: 1, RightToLeft: true},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got: %v, want: %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("glyph %d: got: %v, want: %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestIndexAt(t *testing.T) {
+	f := &testFace{}
+	text := "aa\nאב\n"
+	cases := []struct {
+		X, Y float64
+		Want int
+	}{
+		{X: -1, Y: -1, Want: 0},
+		{X: testFaceSize * 0.4, Y: -1, Want: 0},
+		{X: testFaceSize * 0.6, Y: -1, Want: 1},
+		{X: testFaceSize * 3, Y: -1, Want: 2},
+		{X: -1, Y: testFaceSize - 1, Want: 7},
+		{X: testFaceSize * 0.6, Y: testFaceSize - 1, Want: 5},
+		{X: testFaceSize * 3, Y: testFaceSize - 1, Want: 3},
+		{X: 0, Y: testFaceSize * 10, Want: 8},
+	}
+	for _, c := range cases {
+		if got := IndexAt(f, text, c.X, c.Y); got != c.Want {
+			t.Errorf("IndexAt(%v, %v): got: %d, want: %d", c.X, c.Y, got, c.Want)
+		}
+	}
+}
+
+func TestSpacing(t *testing.T) {
+	f := &testFace{}
+
+	got := BoundStringWithOptions(f, "aa\na", &DrawOptions{
+		LetterSpacing:    2,
+		LineHeight:       10,
+		ParagraphSpacing: 3,
+	})
+	if want := image.Rect(0, 0, testFaceSize*2+2, 13+testFaceSize); got != want {
+		t.Errorf("BoundStringWithOptions: got: %v, want: %v", got, want)
+	}
+
+	// Wrapped lines in a paragraph are not separated by the paragraph spacing.
+	got = BoundWrapped(f, "aaa aaa\na", &WrapOptions{
+		Width:            30,
+		LetterSpacing:    2,
+		LineHeight:       10,
+		ParagraphSpacing: 3,
+	})
+	if want := image.Rect(0, 0, testFaceSize*3+4, 23+testFaceSize); got != want {
+		t.Errorf("BoundWrapped: got: %v, want: %v", got, want)
+	}
+}
+
+func TestAsyncGlyphRasterization(t *testing.T) {
+	SetGlyphRasterizationMode(GlyphRasterizationModeAsync)
+	defer SetGlyphRasterizationMode(GlyphRasterizationModeSync)
+
+	f := &testFace{}
+	ClearGlyphCache(f)
+	defer ClearGlyphCache(f)
+
+	// The glyph is not drawn until it is rasterized.
+	dst := ebiten.NewImage(testFaceSize, testFaceSize)
+	Draw(dst, "a", f, 0, 0, color.White)
+	if got, want := dst.At(0, 0), (color.RGBA{}); got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+
+	want := color.RGBA{0x80, 0x80, 0x80, 0x80}
+	for i := 0; i < 100; i++ {
+		time.Sleep(10 * time.Millisecond)
+		dst.Clear()
+		Draw(dst, "a", f, 0, 0, color.White)
+		if dst.At(0, 0) == want {
+			return
+		}
+	}
+	t.Errorf("got: %v, want: %v", dst.At(0, 0), want)
+}
+
+func TestCacheGlyphRange(t *testing.T) {
+	f, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	face, err := NewFace(f, &opentype.FaceOptions{
+		Size: 12,
+		DPI:  72,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The Go fonts don't have Hiragana. The .notdef glyphs must not be cached.
+	CacheGlyphRange(face, 0x3041, 0x3096)
+	if got, want := CachedGlyphCount(face), 0; got != want {
+		t.Errorf("CachedGlyphCount after caching Hiragana: got: %d, want: %d", got, want)
+	}
+
+	CacheGlyphRange(face, 'a', 'z')
+	if got, want := CachedGlyphCount(face), 26; got != want {
+		t.Errorf("CachedGlyphCount after caching a-z: got: %d, want: %d", got, want)
+	}
+}
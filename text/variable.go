@@ -0,0 +1,1159 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"errors"
+	"image"
+	"math"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// VariationAxis represents an axis of a variable font.
+type VariationAxis struct {
+	// Tag is the four-letter tag of the axis like "wght" (weight), "wdth" (width) and "slnt" (slant).
+	Tag string
+
+	// Min, Default and Max are the range and the default value of the axis.
+	Min     float64
+	Default float64
+	Max     float64
+}
+
+// VariationInstance represents a named instance of a variable font like "Bold" and "Condensed Light".
+type VariationInstance struct {
+	// Name is the subfamily name of the instance.
+	Name string
+
+	// Variations is the values of the axes. The keys are the tags of the axes.
+	Variations map[string]float64
+}
+
+// VariableFace is a face of an OpenType variable font whose axis values can be changed at runtime.
+//
+// Only TrueType outlines (the glyf table) with the gvar table are supported. Fonts with CFF2 outlines are not
+// supported. The metrics and the kerning are the default instance's. A VariableFace can be created from a
+// non-variable TrueType font, and then the face has no axes.
+//
+// Changing the axis values discards the cached glyphs of the face. Animating the axis values at every frame
+// works for a short text, but rasterizes all the glyphs at every frame.
+type VariableFace struct {
+	face    font.Face
+	font    *sfnt.Font
+	buf     sfnt.Buffer
+	scale   float64
+	hinting font.Hinting
+
+	glyf        []byte
+	loca        []byte
+	longLoca    bool
+	hmtx        []byte
+	numHMetrics int
+	numGlyphs   int
+
+	axes         []VariationAxis
+	avar         [][][2]float64
+	instances    []VariationInstance
+	gvar         []byte
+	sharedTuples [][]float64
+
+	values []float64
+	coords []float64
+
+	glyphs map[sfnt.GlyphIndex]*variableGlyph
+	m      sync.Mutex
+}
+
+type variableGlyph struct {
+	// points is the points of the outline in pixels relative to the dot.
+	points []glyphPoint
+	ends   []int
+
+	bounds  fixed.Rectangle26_6
+	advance fixed.Int26_6
+
+	// mask is the cached glyph image at a whole pixel position.
+	mask *image.Alpha
+}
+
+type glyphPoint struct {
+	x  float64
+	y  float64
+	on bool
+}
+
+// NewVariableFace creates a new face from an OpenType variable font.
+func NewVariableFace(src []byte, options *opentype.FaceOptions) (*VariableFace, error) {
+	f, err := opentype.Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	face, err := opentype.NewFace(f, options)
+	if err != nil {
+		return nil, err
+	}
+	tables, err := parseTableDirectory(src)
+	if err != nil {
+		return nil, err
+	}
+
+	size, dpi := 12.0, 72.0
+	var hinting font.Hinting
+	if options != nil {
+		if options.Size > 0 {
+			size = options.Size
+		}
+		if options.DPI > 0 {
+			dpi = options.DPI
+		}
+		hinting = options.Hinting
+	}
+
+	head, maxp, hhea := tables["head"], tables["maxp"], tables["hhea"]
+	if len(head) < 54 || len(maxp) < 6 || len(hhea) < 36 {
+		return nil, errors.New("text: invalid font: broken head, maxp or hhea table")
+	}
+	if tables["glyf"] == nil || tables["loca"] == nil {
+		return nil, errors.New("text: only TrueType outlines are supported for variable fonts")
+	}
+
+	v := &VariableFace{
+		face:        face,
+		font:        f,
+		scale:       size * dpi / 72 / float64(be16(head[18:])),
+		hinting:     hinting,
+		glyf:        tables["glyf"],
+		loca:        tables["loca"],
+		longLoca:    be16(head[50:]) != 0,
+		hmtx:        tables["hmtx"],
+		numHMetrics: int(be16(hhea[34:])),
+		numGlyphs:   int(be16(maxp[4:])),
+		glyphs:      map[sfnt.GlyphIndex]*variableGlyph{},
+	}
+	if err := v.parseVariations(tables["fvar"], tables["avar"], tables["gvar"]); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (f *VariableFace) parseVariations(fvar, avar, gvar []byte) error {
+	if fvar == nil {
+		return nil
+	}
+	if len(fvar) < 16 {
+		return errors.New("text: invalid font: broken fvar table")
+	}
+	axesOffset := int(be16(fvar[4:]))
+	axisCount := int(be16(fvar[8:]))
+	axisSize := int(be16(fvar[10:]))
+	instanceCount := int(be16(fvar[12:]))
+	instanceSize := int(be16(fvar[14:]))
+	if axesOffset+axisCount*axisSize+instanceCount*instanceSize > len(fvar) || axisSize < 20 {
+		return errors.New("text: invalid font: broken fvar table")
+	}
+
+	for i := 0; i < axisCount; i++ {
+		a := fvar[axesOffset+i*axisSize:]
+		f.axes = append(f.axes, VariationAxis{
+			Tag:     string(a[:4]),
+			Min:     fixed16Dot16(a[4:]),
+			Default: fixed16Dot16(a[8:]),
+			Max:     fixed16Dot16(a[12:]),
+		})
+		f.values = append(f.values, fixed16Dot16(a[8:]))
+	}
+	f.coords = make([]float64, axisCount)
+
+	if instanceSize >= 4+4*axisCount {
+		for i := 0; i < instanceCount; i++ {
+			inst := fvar[axesOffset+axisCount*axisSize+i*instanceSize:]
+			name, _ := f.font.Name(&f.buf, sfnt.NameID(be16(inst)))
+			vs := map[string]float64{}
+			for j, a := range f.axes {
+				vs[a.Tag] = fixed16Dot16(inst[4+4*j:])
+			}
+			f.instances = append(f.instances, VariationInstance{
+				Name:       name,
+				Variations: vs,
+			})
+		}
+	}
+
+	// The avar table is optional. Ignore the table if the table is broken.
+	if len(avar) >= 8 && int(be16(avar[6:])) == axisCount {
+		maps := make([][][2]float64, axisCount)
+		p := avar[8:]
+		for i := 0; i < axisCount; i++ {
+			if len(p) < 2 {
+				maps = nil
+				break
+			}
+			n := int(be16(p))
+			if len(p) < 2+4*n {
+				maps = nil
+				break
+			}
+			for j := 0; j < n; j++ {
+				maps[i] = append(maps[i], [2]float64{f2Dot14(p[2+4*j:]), f2Dot14(p[4+4*j:])})
+			}
+			p = p[2+4*n:]
+		}
+		f.avar = maps
+	}
+
+	if len(gvar) >= 20 && int(be16(gvar[4:])) == axisCount {
+		sharedCount := int(be16(gvar[6:]))
+		sharedOffset := int(be32(gvar[8:]))
+		if sharedOffset+2*axisCount*sharedCount > len(gvar) {
+			return errors.New("text: invalid font: broken gvar table")
+		}
+		for i := 0; i < sharedCount; i++ {
+			f.sharedTuples = append(f.sharedTuples, readTuple(gvar[sharedOffset+2*axisCount*i:], axisCount))
+		}
+		f.gvar = gvar
+	}
+	return nil
+}
+
+// Axes returns the axes of the font.
+//
+// Axes is concurrent-safe.
+func (f *VariableFace) Axes() []VariationAxis {
+	return append([]VariationAxis(nil), f.axes...)
+}
+
+// NamedInstances returns the named instances of the font.
+//
+// NamedInstances is concurrent-safe.
+func (f *VariableFace) NamedInstances() []VariationInstance {
+	return append([]VariationInstance(nil), f.instances...)
+}
+
+// Variation returns the current value of the axis.
+// Variation returns 0 if the font doesn't have the axis.
+//
+// Variation is concurrent-safe.
+func (f *VariableFace) Variation(tag string) float64 {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	for i, a := range f.axes {
+		if a.Tag == tag {
+			return f.values[i]
+		}
+	}
+	return 0
+}
+
+// SetVariation sets the value of the axis. The value is clamped into the range of the axis.
+// SetVariation does nothing if the font doesn't have the axis.
+//
+// SetVariation is concurrent-safe.
+func (f *VariableFace) SetVariation(tag string, value float64) {
+	f.SetVariations(map[string]float64{tag: value})
+}
+
+// SetVariations sets the values of the axes at once. The keys are the tags of the axes.
+// For example, SetVariations with the Variations of a VariationInstance selects the named instance.
+//
+// SetVariations is concurrent-safe.
+func (f *VariableFace) SetVariations(variations map[string]float64) {
+	textM.Lock()
+	defer textM.Unlock()
+
+	f.m.Lock()
+	changed := false
+	for i, a := range f.axes {
+		v, ok := variations[a.Tag]
+		if !ok {
+			continue
+		}
+		v = math.Max(a.Min, math.Min(a.Max, v))
+		if f.values[i] == v {
+			continue
+		}
+		f.values[i] = v
+		f.coords[i] = f.normalize(i, v)
+		changed = true
+	}
+	if changed {
+		f.glyphs = map[sfnt.GlyphIndex]*variableGlyph{}
+	}
+	f.m.Unlock()
+
+	if changed {
+		clearGlyphCache(f)
+	}
+}
+
+// normalize returns the normalized coordinate in [-1, 1] of the value of the i-th axis.
+func (f *VariableFace) normalize(i int, v float64) float64 {
+	a := f.axes[i]
+	var c float64
+	switch {
+	case v < a.Default && a.Default > a.Min:
+		c = (v - a.Default) / (a.Default - a.Min)
+	case v > a.Default && a.Max > a.Default:
+		c = (v - a.Default) / (a.Max - a.Default)
+	}
+	if f.avar == nil || len(f.avar[i]) < 2 {
+		return c
+	}
+	m := f.avar[i]
+	for j := 1; j < len(m); j++ {
+		if c > m[j][0] {
+			continue
+		}
+		if m[j][0] == m[j-1][0] {
+			return m[j][1]
+		}
+		return m[j-1][1] + (m[j][1]-m[j-1][1])*(c-m[j-1][0])/(m[j][0]-m[j-1][0])
+	}
+	return c
+}
+
+// Close implements font.Face.
+func (f *VariableFace) Close() error {
+	return nil
+}
+
+// Kern implements font.Face.
+func (f *VariableFace) Kern(r0, r1 rune) fixed.Int26_6 {
+	return f.face.Kern(r0, r1)
+}
+
+// Metrics implements font.Face.
+func (f *VariableFace) Metrics() font.Metrics {
+	return f.face.Metrics()
+}
+
+// Glyph implements font.Face.
+func (f *VariableFace) Glyph(dot fixed.Point26_6, r rune) (dr image.Rectangle, mask image.Image, maskp image.Point, advance fixed.Int26_6, ok bool) {
+	g, ok := f.glyph(r)
+	if !ok {
+		return image.Rectangle{}, nil, image.Point{}, 0, false
+	}
+	if len(g.points) == 0 {
+		return image.Rectangle{}, image.NewAlpha(image.Rectangle{}), image.Point{}, g.advance, true
+	}
+
+	// Place the glyph at the subpixel position horizontally.
+	x, y := dot.X.Floor(), dot.Y.Round()
+	dx := fixed26_6ToFloat64(dot.X - fixed.I(x))
+
+	f.m.Lock()
+	defer f.m.Unlock()
+	var m *image.Alpha
+	if dx == 0 {
+		if g.mask == nil {
+			g.mask = g.rasterize(0)
+		}
+		m = g.mask
+	} else {
+		m = g.rasterize(dx)
+	}
+	dr = m.Bounds().Add(image.Pt(x+g.bounds.Min.X.Round(), y+g.bounds.Min.Y.Round()))
+	return dr, m, image.Point{}, g.advance, true
+}
+
+// GlyphBounds implements font.Face.
+func (f *VariableFace) GlyphBounds(r rune) (bounds fixed.Rectangle26_6, advance fixed.Int26_6, ok bool) {
+	g, ok := f.glyph(r)
+	if !ok {
+		return fixed.Rectangle26_6{}, 0, false
+	}
+	return g.bounds, g.advance, true
+}
+
+// GlyphAdvance implements font.Face.
+func (f *VariableFace) GlyphAdvance(r rune) (advance fixed.Int26_6, ok bool) {
+	g, ok := f.glyph(r)
+	if !ok {
+		return 0, false
+	}
+	return g.advance, true
+}
+
+func (f *VariableFace) hasGlyph(r rune) bool {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	gid, err := f.font.GlyphIndex(&f.buf, r)
+	return err == nil && gid != 0
+}
+
+func (f *VariableFace) glyph(r rune) (*variableGlyph, bool) {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	// As opentype.Face, the .notdef glyph is used for a missing rune.
+	gid, err := f.font.GlyphIndex(&f.buf, r)
+	if err != nil {
+		return nil, false
+	}
+	if g, ok := f.glyphs[gid]; ok {
+		return g, g != nil
+	}
+
+	g := f.loadGlyph(gid)
+	f.glyphs[gid] = g
+	return g, g != nil
+}
+
+func (f *VariableFace) loadGlyph(gid sfnt.GlyphIndex) *variableGlyph {
+	points, ends, advance, ok := f.loadPoints(gid, 0)
+	if !ok {
+		return nil
+	}
+
+	adv := floatToFixed26_6(advance * f.scale)
+	if f.hinting != font.HintingNone {
+		adv = fixed.I(adv.Round())
+	}
+	g := &variableGlyph{
+		advance: adv,
+	}
+	if len(points) == 0 {
+		return g
+	}
+
+	// Convert the points into pixels. The Y axis of fonts increases up.
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for i := range points {
+		points[i].x *= f.scale
+		points[i].y *= -f.scale
+		minX = math.Min(minX, points[i].x)
+		minY = math.Min(minY, points[i].y)
+		maxX = math.Max(maxX, points[i].x)
+		maxY = math.Max(maxY, points[i].y)
+	}
+	x0, y0 := int(math.Floor(minX)), int(math.Floor(minY))
+	x1, y1 := int(math.Ceil(maxX)), int(math.Ceil(maxY))
+	if x1 <= x0 || y1 <= y0 {
+		return g
+	}
+
+	g.points = points
+	g.ends = ends
+	g.bounds = fixed.R(x0, y0, x1, y1)
+	return g
+}
+
+// rasterize renders the glyph shifted by dx pixels horizontally. dx must be in [0, 1).
+// The upper-left corner of the returned image is the upper-left corner of the bounds.
+func (g *variableGlyph) rasterize(dx float64) *image.Alpha {
+	x0, y0 := g.bounds.Min.X.Round(), g.bounds.Min.Y.Round()
+	w, h := g.bounds.Max.X.Round()-x0, g.bounds.Max.Y.Round()-y0
+	if dx > 0 {
+		w++
+	}
+
+	mask := image.NewAlpha(image.Rect(0, 0, w, h))
+	rast := vector.NewRasterizer(w, h)
+	pt := func(p glyphPoint) (float32, float32) {
+		return float32(p.x+dx) - float32(x0), float32(p.y) - float32(y0)
+	}
+	mid := func(p, q glyphPoint) glyphPoint {
+		return glyphPoint{x: (p.x + q.x) / 2, y: (p.y + q.y) / 2, on: true}
+	}
+	start := 0
+	for _, end := range g.ends {
+		c := g.points[start : end+1]
+		start = end + 1
+		if len(c) == 0 {
+			continue
+		}
+
+		// Find the start point on the curve and list the rest of the points ending with the start point.
+		var first glyphPoint
+		var rest []glyphPoint
+		switch {
+		case c[0].on:
+			first = c[0]
+			rest = append(append(rest, c[1:]...), first)
+		case c[len(c)-1].on:
+			first = c[len(c)-1]
+			rest = append(append(rest, c[:len(c)-1]...), first)
+		default:
+			first = mid(c[len(c)-1], c[0])
+			rest = append(append(rest, c...), first)
+		}
+
+		rast.MoveTo(pt(first))
+		var ctrl *glyphPoint
+		for i := range rest {
+			p := rest[i]
+			switch {
+			case p.on && ctrl == nil:
+				rast.LineTo(pt(p))
+			case p.on:
+				cx, cy := pt(*ctrl)
+				px, py := pt(p)
+				rast.QuadTo(cx, cy, px, py)
+				ctrl = nil
+			case ctrl != nil:
+				m := mid(*ctrl, p)
+				cx, cy := pt(*ctrl)
+				mx, my := pt(m)
+				rast.QuadTo(cx, cy, mx, my)
+				ctrl = &rest[i]
+			default:
+				ctrl = &rest[i]
+			}
+		}
+	}
+	rast.Draw(mask, mask.Bounds(), image.Opaque, image.Point{})
+	return mask
+}
+
+// glyphData returns the data of the glyph in the glyf table.
+func (f *VariableFace) glyphData(gid sfnt.GlyphIndex) ([]byte, bool) {
+	if int(gid) >= f.numGlyphs {
+		return nil, false
+	}
+	var start, end int
+	if f.longLoca {
+		if 4*int(gid)+8 > len(f.loca) {
+			return nil, false
+		}
+		start, end = int(be32(f.loca[4*gid:])), int(be32(f.loca[4*gid+4:]))
+	} else {
+		if 2*int(gid)+4 > len(f.loca) {
+			return nil, false
+		}
+		start, end = 2*int(be16(f.loca[2*gid:])), 2*int(be16(f.loca[2*gid+2:]))
+	}
+	if start > end || end > len(f.glyf) {
+		return nil, false
+	}
+	return f.glyf[start:end], true
+}
+
+// horizontalMetrics returns the advance width and the left side bearing of the glyph in font units.
+func (f *VariableFace) horizontalMetrics(gid sfnt.GlyphIndex) (float64, float64) {
+	if f.numHMetrics == 0 {
+		return 0, 0
+	}
+	i := int(gid)
+	if i >= f.numHMetrics {
+		i = f.numHMetrics - 1
+	}
+	if 4*i+4 > len(f.hmtx) {
+		return 0, 0
+	}
+	adv := float64(be16(f.hmtx[4*i:]))
+	lsb := float64(int16(be16(f.hmtx[4*i+2:])))
+	if int(gid) >= f.numHMetrics {
+		o := 4*f.numHMetrics + 2*(int(gid)-f.numHMetrics)
+		if o+2 <= len(f.hmtx) {
+			lsb = float64(int16(be16(f.hmtx[o:])))
+		}
+	}
+	return adv, lsb
+}
+
+// loadPoints returns the points of the glyph with the variations applied in font units.
+func (f *VariableFace) loadPoints(gid sfnt.GlyphIndex, depth int) (points []glyphPoint, ends []int, advance float64, ok bool) {
+	// Composite glyphs can refer to other composite glyphs. Limit the depth to avoid an infinite loop.
+	if depth > 8 {
+		return nil, nil, 0, false
+	}
+
+	data, ok := f.glyphData(gid)
+	if !ok {
+		return nil, nil, 0, false
+	}
+	adv, lsb := f.horizontalMetrics(gid)
+
+	var xMin float64
+	var numContours int
+	if len(data) >= 10 {
+		numContours = int(int16(be16(data)))
+		xMin = float64(int16(be16(data[2:])))
+	}
+	phantom := []glyphPoint{
+		{x: xMin - lsb},
+		{x: xMin - lsb + adv},
+		{},
+		{},
+	}
+
+	if numContours >= 0 {
+		points, ends, ok = parseSimpleGlyph(data, numContours)
+		if !ok {
+			return nil, nil, 0, false
+		}
+		all := append(points, phantom...)
+		f.applyVariations(gid, all, ends)
+		advance = all[len(all)-3].x - all[len(all)-4].x
+		return all[:len(points)], ends, advance, true
+	}
+
+	comps, ok := parseCompositeGlyph(data)
+	if !ok {
+		return nil, nil, 0, false
+	}
+	// The points varied for a composite glyph are the offsets of the components.
+	offsets := make([]glyphPoint, 0, len(comps)+len(phantom))
+	for _, c := range comps {
+		offsets = append(offsets, glyphPoint{x: c.dx, y: c.dy})
+	}
+	offsets = append(offsets, phantom...)
+	f.applyVariations(gid, offsets, nil)
+
+	for i, c := range comps {
+		ps, es, _, ok := f.loadPoints(c.gid, depth+1)
+		if !ok {
+			return nil, nil, 0, false
+		}
+		n := len(points)
+		for _, p := range ps {
+			x := c.xx*p.x + c.yx*p.y + offsets[i].x
+			y := c.xy*p.x + c.yy*p.y + offsets[i].y
+			points = append(points, glyphPoint{x: x, y: y, on: p.on})
+		}
+		for _, e := range es {
+			ends = append(ends, n+e)
+		}
+	}
+	advance = offsets[len(offsets)-3].x - offsets[len(offsets)-4].x
+	return points, ends, advance, true
+}
+
+func parseSimpleGlyph(data []byte, numContours int) ([]glyphPoint, []int, bool) {
+	if numContours == 0 || len(data) < 10 {
+		return nil, nil, true
+	}
+	p := data[10:]
+	if len(p) < 2*numContours+2 {
+		return nil, nil, false
+	}
+	ends := make([]int, numContours)
+	for i := range ends {
+		ends[i] = int(be16(p[2*i:]))
+		if i > 0 && ends[i] < ends[i-1] {
+			return nil, nil, false
+		}
+	}
+	n := ends[numContours-1] + 1
+	p = p[2*numContours:]
+	instLen := int(be16(p))
+	if len(p) < 2+instLen {
+		return nil, nil, false
+	}
+	p = p[2+instLen:]
+
+	const (
+		flagOnCurve     = 0x01
+		flagXShort      = 0x02
+		flagYShort      = 0x04
+		flagRepeat      = 0x08
+		flagXSameOrPlus = 0x10
+		flagYSameOrPlus = 0x20
+	)
+
+	flags := make([]byte, 0, n)
+	for len(flags) < n {
+		if len(p) == 0 {
+			return nil, nil, false
+		}
+		fl := p[0]
+		p = p[1:]
+		flags = append(flags, fl)
+		if fl&flagRepeat != 0 {
+			if len(p) == 0 {
+				return nil, nil, false
+			}
+			for i := 0; i < int(p[0]) && len(flags) < n; i++ {
+				flags = append(flags, fl)
+			}
+			p = p[1:]
+		}
+	}
+
+	points := make([]glyphPoint, n)
+	readCoords := func(short, sameOrPlus byte, set func(i int, v float64)) bool {
+		var v float64
+		for i, fl := range flags {
+			switch {
+			case fl&short != 0:
+				if len(p) < 1 {
+					return false
+				}
+				d := float64(p[0])
+				p = p[1:]
+				if fl&sameOrPlus == 0 {
+					d = -d
+				}
+				v += d
+			case fl&sameOrPlus == 0:
+				if len(p) < 2 {
+					return false
+				}
+				v += float64(int16(be16(p)))
+				p = p[2:]
+			}
+			set(i, v)
+		}
+		return true
+	}
+	if !readCoords(flagXShort, flagXSameOrPlus, func(i int, v float64) { points[i].x = v }) {
+		return nil, nil, false
+	}
+	if !readCoords(flagYShort, flagYSameOrPlus, func(i int, v float64) { points[i].y = v }) {
+		return nil, nil, false
+	}
+	for i, fl := range flags {
+		points[i].on = fl&flagOnCurve != 0
+	}
+	return points, ends, true
+}
+
+type glyphComponent struct {
+	gid            sfnt.GlyphIndex
+	dx, dy         float64
+	xx, xy, yx, yy float64
+}
+
+func parseCompositeGlyph(data []byte) ([]glyphComponent, bool) {
+	const (
+		argsAreWords   = 0x0001
+		argsAreXY      = 0x0002
+		hasScale       = 0x0008
+		moreComponents = 0x0020
+		hasXYScale     = 0x0040
+		hasTwoByTwo    = 0x0080
+	)
+
+	var comps []glyphComponent
+	p := data[10:]
+	for {
+		if len(p) < 4 {
+			return nil, false
+		}
+		flags := be16(p)
+		c := glyphComponent{
+			gid: sfnt.GlyphIndex(be16(p[2:])),
+			xx:  1,
+			yy:  1,
+		}
+		p = p[4:]
+
+		var arg0, arg1 float64
+		if flags&argsAreWords != 0 {
+			if len(p) < 4 {
+				return nil, false
+			}
+			arg0, arg1 = float64(int16(be16(p))), float64(int16(be16(p[2:])))
+			p = p[4:]
+		} else {
+			if len(p) < 2 {
+				return nil, false
+			}
+			arg0, arg1 = float64(int8(p[0])), float64(int8(p[1]))
+			p = p[2:]
+		}
+		// Matching points are not supported. The component is placed at the origin.
+		if flags&argsAreXY != 0 {
+			c.dx, c.dy = arg0, arg1
+		}
+
+		switch {
+		case flags&hasScale != 0:
+			if len(p) < 2 {
+				return nil, false
+			}
+			c.xx = f2Dot14(p)
+			c.yy = c.xx
+			p = p[2:]
+		case flags&hasXYScale != 0:
+			if len(p) < 4 {
+				return nil, false
+			}
+			c.xx, c.yy = f2Dot14(p), f2Dot14(p[2:])
+			p = p[4:]
+		case flags&hasTwoByTwo != 0:
+			if len(p) < 8 {
+				return nil, false
+			}
+			c.xx, c.xy, c.yx, c.yy = f2Dot14(p), f2Dot14(p[2:]), f2Dot14(p[4:]), f2Dot14(p[6:])
+			p = p[8:]
+		}
+
+		comps = append(comps, c)
+		if flags&moreComponents == 0 {
+			break
+		}
+	}
+	return comps, true
+}
+
+// applyVariations adds the deltas of the gvar table to the points of the glyph.
+// ends is the end indices of the contours for a simple glyph, or nil for a composite glyph.
+func (f *VariableFace) applyVariations(gid sfnt.GlyphIndex, points []glyphPoint, ends []int) {
+	if f.gvar == nil {
+		return
+	}
+	zero := true
+	for _, c := range f.coords {
+		if c != 0 {
+			zero = false
+			break
+		}
+	}
+	if zero {
+		return
+	}
+
+	data, ok := f.glyphVariationData(gid)
+	if !ok || len(data) < 4 {
+		return
+	}
+
+	axisCount := len(f.axes)
+	tupleCount := int(be16(data) & 0x0fff)
+	sharedPointNumbers := be16(data)&0x8000 != 0
+	dataOffset := int(be16(data[2:]))
+	if dataOffset > len(data) {
+		return
+	}
+	headers := data[4:]
+	serialized := data[dataOffset:]
+
+	var sharedPoints []int
+	if sharedPointNumbers {
+		ps, n, ok := readPointNumbers(serialized)
+		if !ok {
+			return
+		}
+		sharedPoints = ps
+		serialized = serialized[n:]
+	}
+
+	original := append([]glyphPoint(nil), points...)
+	deltas := make([]glyphPoint, len(points))
+	touched := make([]bool, len(points))
+
+	for i := 0; i < tupleCount; i++ {
+		if len(headers) < 4 {
+			return
+		}
+		size := int(be16(headers))
+		index := be16(headers[2:])
+		headers = headers[4:]
+
+		var peak []float64
+		if index&0x8000 != 0 {
+			if len(headers) < 2*axisCount {
+				return
+			}
+			peak = readTuple(headers, axisCount)
+			headers = headers[2*axisCount:]
+		} else {
+			idx := int(index & 0x0fff)
+			if idx >= len(f.sharedTuples) {
+				return
+			}
+			peak = f.sharedTuples[idx]
+		}
+		var start, end []float64
+		if index&0x4000 != 0 {
+			if len(headers) < 4*axisCount {
+				return
+			}
+			start = readTuple(headers, axisCount)
+			end = readTuple(headers[2*axisCount:], axisCount)
+			headers = headers[4*axisCount:]
+		}
+
+		if size > len(serialized) {
+			return
+		}
+		tupleData := serialized[:size]
+		serialized = serialized[size:]
+
+		scalar := tupleScalar(f.coords, peak, start, end)
+		if scalar == 0 {
+			continue
+		}
+
+		pointNumbers := sharedPoints
+		if index&0x2000 != 0 {
+			ps, n, ok := readPointNumbers(tupleData)
+			if !ok {
+				return
+			}
+			pointNumbers = ps
+			tupleData = tupleData[n:]
+		}
+
+		count := len(pointNumbers)
+		if pointNumbers == nil {
+			count = len(points)
+		}
+		xs, n, ok := readDeltas(tupleData, count)
+		if !ok {
+			return
+		}
+		ys, _, ok := readDeltas(tupleData[n:], count)
+		if !ok {
+			return
+		}
+
+		if pointNumbers == nil {
+			for j := range points {
+				points[j].x += scalar * xs[j]
+				points[j].y += scalar * ys[j]
+			}
+			continue
+		}
+
+		for j := range deltas {
+			deltas[j] = glyphPoint{}
+			touched[j] = false
+		}
+		for j, p := range pointNumbers {
+			if p >= len(points) {
+				continue
+			}
+			deltas[p].x += xs[j]
+			deltas[p].y += ys[j]
+			touched[p] = true
+		}
+		if ends != nil {
+			interpolateUntouchedPoints(original, deltas, touched, ends)
+		}
+		for j := range points {
+			points[j].x += scalar * deltas[j].x
+			points[j].y += scalar * deltas[j].y
+		}
+	}
+}
+
+func (f *VariableFace) glyphVariationData(gid sfnt.GlyphIndex) ([]byte, bool) {
+	g := f.gvar
+	glyphCount := int(be16(g[12:]))
+	if int(gid) >= glyphCount {
+		return nil, false
+	}
+	longOffsets := be16(g[14:])&1 != 0
+	arrayOffset := int(be32(g[16:]))
+	var start, end int
+	if longOffsets {
+		o := 20 + 4*int(gid)
+		if o+8 > len(g) {
+			return nil, false
+		}
+		start, end = int(be32(g[o:])), int(be32(g[o+4:]))
+	} else {
+		o := 20 + 2*int(gid)
+		if o+4 > len(g) {
+			return nil, false
+		}
+		start, end = 2*int(be16(g[o:])), 2*int(be16(g[o+2:]))
+	}
+	if start >= end || arrayOffset+end > len(g) {
+		return nil, false
+	}
+	return g[arrayOffset+start : arrayOffset+end], true
+}
+
+// tupleScalar returns the scalar of a tuple variation for the normalized coordinates.
+func tupleScalar(coords, peak, start, end []float64) float64 {
+	scalar := 1.0
+	for i, c := range coords {
+		p := peak[i]
+		if p == 0 {
+			continue
+		}
+		s, e := math.Min(p, 0), math.Max(p, 0)
+		if start != nil {
+			s, e = start[i], end[i]
+			if s > p || p > e || (s < 0 && e > 0) {
+				continue
+			}
+		}
+		if c < s || c > e {
+			return 0
+		}
+		if c == p {
+			continue
+		}
+		if c < p {
+			scalar *= (c - s) / (p - s)
+		} else {
+			scalar *= (e - c) / (e - p)
+		}
+	}
+	return scalar
+}
+
+// interpolateUntouchedPoints sets the deltas of the untouched points by the Interpolate Untouched Points (IUP)
+// algorithm.
+func interpolateUntouchedPoints(points, deltas []glyphPoint, touched []bool, ends []int) {
+	iup := func(v, v1, v2, d1, d2 float64) float64 {
+		if v1 == v2 {
+			if d1 == d2 {
+				return d1
+			}
+			return 0
+		}
+		if v1 > v2 {
+			v1, v2 = v2, v1
+			d1, d2 = d2, d1
+		}
+		switch {
+		case v <= v1:
+			return d1
+		case v >= v2:
+			return d2
+		}
+		return d1 + (d2-d1)*(v-v1)/(v2-v1)
+	}
+
+	start := 0
+	for _, end := range ends {
+		if end >= len(points) {
+			return
+		}
+		var ts []int
+		for i := start; i <= end; i++ {
+			if touched[i] {
+				ts = append(ts, i)
+			}
+		}
+		if len(ts) > 0 {
+			for k, t1 := range ts {
+				t2 := ts[(k+1)%len(ts)]
+				// Interpolate the points between t1 and t2 in the contour.
+				for i := t1 + 1; ; i++ {
+					if i > end {
+						i = start
+					}
+					if i == t2 {
+						break
+					}
+					deltas[i].x = iup(points[i].x, points[t1].x, points[t2].x, deltas[t1].x, deltas[t2].x)
+					deltas[i].y = iup(points[i].y, points[t1].y, points[t2].y, deltas[t1].y, deltas[t2].y)
+				}
+			}
+		}
+		start = end + 1
+	}
+}
+
+// readPointNumbers reads packed point numbers. readPointNumbers returns nil for all the points.
+func readPointNumbers(data []byte) ([]int, int, bool) {
+	if len(data) < 1 {
+		return nil, 0, false
+	}
+	count := int(data[0])
+	n := 1
+	if count&0x80 != 0 {
+		if len(data) < 2 {
+			return nil, 0, false
+		}
+		count = (count&0x7f)<<8 | int(data[1])
+		n = 2
+	}
+	if count == 0 {
+		return nil, n, true
+	}
+
+	points := make([]int, 0, count)
+	var p int
+	for len(points) < count {
+		if n >= len(data) {
+			return nil, 0, false
+		}
+		ctrl := data[n]
+		n++
+		runCount := int(ctrl&0x7f) + 1
+		words := ctrl&0x80 != 0
+		for i := 0; i < runCount && len(points) < count; i++ {
+			if words {
+				if n+2 > len(data) {
+					return nil, 0, false
+				}
+				p += int(be16(data[n:]))
+				n += 2
+			} else {
+				if n+1 > len(data) {
+					return nil, 0, false
+				}
+				p += int(data[n])
+				n++
+			}
+			points = append(points, p)
+		}
+	}
+	return points, n, true
+}
+
+// readDeltas reads count packed deltas.
+func readDeltas(data []byte, count int) ([]float64, int, bool) {
+	deltas := make([]float64, 0, count)
+	n := 0
+	for len(deltas) < count {
+		if n >= len(data) {
+			return nil, 0, false
+		}
+		ctrl := data[n]
+		n++
+		runCount := int(ctrl&0x3f) + 1
+		for i := 0; i < runCount && len(deltas) < count; i++ {
+			switch {
+			case ctrl&0x80 != 0:
+				deltas = append(deltas, 0)
+			case ctrl&0x40 != 0:
+				if n+2 > len(data) {
+					return nil, 0, false
+				}
+				deltas = append(deltas, float64(int16(be16(data[n:]))))
+				n += 2
+			default:
+				if n+1 > len(data) {
+					return nil, 0, false
+				}
+				deltas = append(deltas, float64(int8(data[n])))
+				n++
+			}
+		}
+	}
+	return deltas, n, true
+}
+
+func readTuple(data []byte, axisCount int) []float64 {
+	t := make([]float64, axisCount)
+	for i := range t {
+		t[i] = f2Dot14(data[2*i:])
+	}
+	return t
+}
+
+func f2Dot14(b []byte) float64 {
+	return float64(int16(be16(b))) / (1 << 14)
+}
+
+func fixed16Dot16(b []byte) float64 {
+	return float64(int32(be32(b))) / (1 << 16)
+}
@@ -0,0 +1,160 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text_test
+
+import (
+	"image"
+	"io/ioutil"
+	"testing"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+// testdata/variable.ttf is a TrueType variable font with 1000 units per em. The font has a 'wght' axis in
+// [100, 900] whose default is 400, and a named instance "Bold" at 900.
+//
+// The glyph of 'A' is a square from (100, 0) to (600, 500) with the advance 700. At wght=900, the gvar table
+// moves the top-left point by (0, 200), the top-right point by (100, 200) and the advance by 200. The other points
+// are moved by interpolating the untouched points.
+func newTestVariableFace(t *testing.T) *text.VariableFace {
+	src, err := ioutil.ReadFile("testdata/variable.ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := text.NewVariableFace(src, &opentype.FaceOptions{
+		Size:    100,
+		DPI:     72,
+		Hinting: font.HintingNone,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestVariableFaceAxes(t *testing.T) {
+	f := newTestVariableFace(t)
+
+	axes := f.Axes()
+	if len(axes) != 1 {
+		t.Fatalf("len(Axes()): got: %d, want: 1", len(axes))
+	}
+	if got, want := axes[0], (text.VariationAxis{Tag: "wght", Min: 100, Default: 400, Max: 900}); got != want {
+		t.Errorf("Axes()[0]: got: %+v, want: %+v", got, want)
+	}
+
+	insts := f.NamedInstances()
+	if len(insts) != 1 {
+		t.Fatalf("len(NamedInstances()): got: %d, want: 1", len(insts))
+	}
+	if got, want := insts[0].Name, "Bold"; got != want {
+		t.Errorf("NamedInstances()[0].Name: got: %q, want: %q", got, want)
+	}
+	if got, want := insts[0].Variations["wght"], 900.0; got != want {
+		t.Errorf("NamedInstances()[0].Variations[\"wght\"]: got: %v, want: %v", got, want)
+	}
+
+	if got, want := f.Variation("wght"), 400.0; got != want {
+		t.Errorf("Variation(\"wght\"): got: %v, want: %v", got, want)
+	}
+	f.SetVariation("wght", 1000)
+	if got, want := f.Variation("wght"), 900.0; got != want {
+		t.Errorf("Variation(\"wght\") after SetVariation(\"wght\", 1000): got: %v, want: %v", got, want)
+	}
+	f.SetVariation("wdth", 100)
+	if got, want := f.Variation("wdth"), 0.0; got != want {
+		t.Errorf("Variation(\"wdth\"): got: %v, want: %v", got, want)
+	}
+}
+
+func TestVariableFaceVariation(t *testing.T) {
+	cases := []struct {
+		Wght    float64
+		Bounds  fixed.Rectangle26_6
+		Advance fixed.Int26_6
+	}{
+		{
+			Wght:    400,
+			Bounds:  fixed.R(10, -50, 60, 0),
+			Advance: fixed.I(70),
+		},
+		{
+			// The font has no variations for the negative direction.
+			Wght:    100,
+			Bounds:  fixed.R(10, -50, 60, 0),
+			Advance: fixed.I(70),
+		},
+		{
+			Wght:    650,
+			Bounds:  fixed.R(10, -60, 65, -10),
+			Advance: fixed.I(80),
+		},
+		{
+			Wght:    900,
+			Bounds:  fixed.R(10, -70, 70, -20),
+			Advance: fixed.I(90),
+		},
+	}
+
+	f := newTestVariableFace(t)
+	for _, c := range cases {
+		f.SetVariation("wght", c.Wght)
+
+		bounds, advance, ok := f.GlyphBounds('A')
+		if !ok {
+			t.Fatalf("wght: %v: GlyphBounds('A') failed", c.Wght)
+		}
+		if bounds != c.Bounds {
+			t.Errorf("wght: %v: bounds: got: %v, want: %v", c.Wght, bounds, c.Bounds)
+		}
+		if advance != c.Advance {
+			t.Errorf("wght: %v: advance: got: %v, want: %v", c.Wght, advance, c.Advance)
+		}
+		if got, _ := f.GlyphAdvance('A'); got != c.Advance {
+			t.Errorf("wght: %v: GlyphAdvance('A'): got: %v, want: %v", c.Wght, got, c.Advance)
+		}
+	}
+}
+
+func TestVariableFaceGlyph(t *testing.T) {
+	f := newTestVariableFace(t)
+
+	alphaAt := func(p image.Point) uint32 {
+		dot := fixed.P(0, 100)
+		dr, mask, maskp, _, ok := f.Glyph(dot, 'A')
+		if !ok {
+			t.Fatal("Glyph('A') failed")
+		}
+		if !p.In(dr) {
+			return 0
+		}
+		_, _, _, a := mask.At(p.X-dr.Min.X+maskp.X, p.Y-dr.Min.Y+maskp.Y).RGBA()
+		return a
+	}
+
+	// (40, 35) is above the glyph at the default, and in the glyph at wght=900.
+	p := image.Pt(40, 35)
+	if got := alphaAt(p); got != 0 {
+		t.Errorf("alpha at %v with the default: got: %d, want: 0", p, got)
+	}
+	f.SetVariation("wght", 900)
+	if got := alphaAt(p); got != 0xffff {
+		t.Errorf("alpha at %v with wght=900: got: %d, want: %d", p, got, 0xffff)
+	}
+}
@@ -0,0 +1,373 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Align represents the horizontal alignment of lines.
+type Align int
+
+const (
+	AlignLeft Align = iota
+	AlignCenter
+	AlignRight
+
+	// AlignJustify aligns the both sides of lines by stretching the spaces between words.
+	// Lines without spaces, like Japanese and Chinese, are stretched between the characters.
+	// The last line of each paragraph is aligned to the left.
+	AlignJustify
+)
+
+// WrapOptions represents options for DrawWrapped and BoundWrapped.
+type WrapOptions struct {
+	// Width is the maximum width of lines in pixels.
+	// If Width is 0 or negative, lines are not wrapped and are aligned in the width of the longest line.
+	Width int
+
+	// Align is the horizontal alignment of lines.
+	// The default (zero) value is AlignLeft.
+	Align Align
+
+	// LetterSpacing is the space added between glyphs in pixels, also known as tracking.
+	// LetterSpacing can be negative to tighten the text.
+	LetterSpacing float64
+
+	// LineHeight is the distance between the dots of adjacent lines in pixels.
+	// If LineHeight is 0 or negative, the face's height is used.
+	LineHeight float64
+
+	// ParagraphSpacing is the space added after each paragraph in pixels.
+	// A paragraph is separated by a line break ('\n'). Lines broken by wrapping are not separated by
+	// ParagraphSpacing.
+	ParagraphSpacing float64
+}
+
+func (o *WrapOptions) spacing() spacing {
+	return newSpacing(o.LetterSpacing, o.LineHeight, o.ParagraphSpacing)
+}
+
+// WrapText breaks a given text into lines that fit in the given width in pixels.
+//
+// A line is broken at a line break ('\n'), after spaces, after hyphens, and between Chinese, Japanese and Korean
+// characters. A line never starts with closing punctuation or small kana like '。', '」' and 'ゃ', and never ends
+// with opening punctuation like '「'. A word wider than width is broken at any character.
+//
+// Spaces at the end of each line are removed.
+//
+// If width is 0 or negative, WrapText breaks the text only at line breaks.
+//
+// WrapText is concurrent-safe.
+func WrapText(face font.Face, text string, width int) []string {
+	textM.Lock()
+	defer textM.Unlock()
+
+	var lines []string
+	for _, l := range wrapLines(face, text, width, spacing{}) {
+		lines = append(lines, l.text)
+	}
+	return lines
+}
+
+// DrawWrapped draws a given text wrapped and aligned by options on a given destination image dst.
+//
+// (x, y) represents the dot position of the first line. With the options' Width, the lines are aligned in the
+// range from x to x+Width. The distance between lines is the face's height, as Draw, unless the options specify
+// LineHeight.
+//
+// Be careful that the passed font face is held by this package and is never released.
+// This is a known issue (#498).
+//
+// DrawWrapped is concurrent-safe.
+func DrawWrapped(dst *ebiten.Image, text string, face font.Face, x, y int, clr color.Color, options *WrapOptions) {
+	textM.Lock()
+	defer textM.Unlock()
+
+	if options == nil {
+		options = &WrapOptions{}
+	}
+	sp := options.spacing()
+	for _, s := range layoutWrapped(face, text, options) {
+		drawGlyphs(dst, s.text, face, x+s.x.Round(), y+s.y.Round(), 0, 0, clr, glyphVariant{}, sp)
+	}
+	cleanUpGlyphCache(face)
+}
+
+// BoundWrapped returns the measured size of a given text drawn by DrawWrapped.
+// The bound's origin point indicates the dot position of the first line.
+//
+// BoundWrapped is concurrent-safe.
+func BoundWrapped(face font.Face, text string, options *WrapOptions) image.Rectangle {
+	textM.Lock()
+	defer textM.Unlock()
+
+	if options == nil {
+		options = &WrapOptions{}
+	}
+	sp := options.spacing()
+	var bounds image.Rectangle
+	for _, s := range layoutWrapped(face, text, options) {
+		b := boundString(face, s.text, sp)
+		if b.Empty() {
+			continue
+		}
+		bounds = bounds.Union(b.Add(image.Pt(s.x.Round(), s.y.Round())))
+	}
+	return bounds
+}
+
+// wrappedLine is a line broken by wrapLines.
+type wrappedLine struct {
+	text string
+
+	// last reports whether the line is the last line of a paragraph.
+	last bool
+}
+
+// wrapLines breaks text into lines that fit in width.
+func wrapLines(face font.Face, text string, width int, sp spacing) []wrappedLine {
+	w := fixed.Int26_6(math.MaxInt32)
+	if width > 0 {
+		w = fixed.I(width)
+	}
+
+	var lines []wrappedLine
+	for _, p := range strings.Split(text, "\n") {
+		ls := wrapParagraph(face, []rune(p), w, sp)
+		for i, l := range ls {
+			lines = append(lines, wrappedLine{
+				text: string(l),
+				last: i == len(ls)-1,
+			})
+		}
+	}
+	return lines
+}
+
+func wrapParagraph(face font.Face, rs []rune, width fixed.Int26_6, sp spacing) [][]rune {
+	if len(rs) == 0 {
+		return [][]rune{nil}
+	}
+
+	var lines [][]rune
+	start := 0
+	for start < len(rs) {
+		end := len(rs)
+		lastBreak := -1
+		var x fixed.Int26_6
+		for i := start; i < len(rs); i++ {
+			r := rs[i]
+			if i > start {
+				if canBreakBetween(rs[i-1], r) {
+					lastBreak = i
+				}
+				x += sp.glyphGap(face, rs[i-1], r)
+			}
+			x += glyphAdvance(face, r)
+
+			// Spaces at the end of a line can overflow.
+			if x > width && !isWrapSpace(r) && i > start {
+				if lastBreak > start {
+					end = lastBreak
+				} else {
+					end = i
+				}
+				break
+			}
+		}
+		lines = append(lines, trimRightSpaces(rs[start:end]))
+		start = end
+	}
+	return lines
+}
+
+// wrappedSegment is a part of a line laid out by layoutWrapped.
+type wrappedSegment struct {
+	text string
+
+	// x and y are the position of the dot relative to the dot of the first line.
+	x fixed.Int26_6
+	y fixed.Int26_6
+}
+
+// layoutWrapped returns the segments of the wrapped and aligned text.
+// A line is split into multiple segments only when the line is justified.
+func layoutWrapped(face font.Face, text string, options *WrapOptions) []wrappedSegment {
+	if options == nil {
+		options = &WrapOptions{}
+	}
+
+	sp := options.spacing()
+	lines := wrapLines(face, text, options.Width, sp)
+	widths := make([]fixed.Int26_6, len(lines))
+	var width fixed.Int26_6
+	for i, l := range lines {
+		widths[i] = stringAdvance(face, l.text, sp)
+		if width < widths[i] {
+			width = widths[i]
+		}
+	}
+	if options.Width > 0 {
+		width = fixed.I(options.Width)
+	}
+
+	var segments []wrappedSegment
+	h := sp.lineHeight(face)
+	var y fixed.Int26_6
+	for i, l := range lines {
+		if i > 0 {
+			y += h
+			if lines[i-1].last {
+				y += sp.paragraph
+			}
+		}
+		extra := width - widths[i]
+
+		switch options.Align {
+		case AlignCenter:
+			segments = append(segments, wrappedSegment{text: l.text, x: extra / 2, y: y})
+			continue
+		case AlignRight:
+			segments = append(segments, wrappedSegment{text: l.text, x: extra, y: y})
+			continue
+		case AlignJustify:
+			if !l.last && extra > 0 && !needsShaping(l.text) {
+				if ss := justifyLine(face, l.text, extra, sp); ss != nil {
+					for _, s := range ss {
+						s.y = y
+						segments = append(segments, s)
+					}
+					continue
+				}
+			}
+		}
+		segments = append(segments, wrappedSegment{text: l.text, y: y})
+	}
+	return segments
+}
+
+// justifyLine splits the line into segments and distributes extra to the gaps between the segments.
+// justifyLine returns nil if the line cannot be justified.
+func justifyLine(face font.Face, line string, extra fixed.Int26_6, sp spacing) []wrappedSegment {
+	rs := []rune(line)
+
+	// Split the line into words if the line has spaces, or into characters otherwise.
+	var starts []int
+	hasSpace := strings.ContainsAny(line, " \t")
+	for i, r := range rs {
+		if isWrapSpace(r) {
+			continue
+		}
+		if i == 0 || !hasSpace || isWrapSpace(rs[i-1]) {
+			starts = append(starts, i)
+		}
+	}
+	if len(starts) < 2 {
+		return nil
+	}
+
+	gaps := fixed.Int26_6(len(starts) - 1)
+	var segments []wrappedSegment
+	var x fixed.Int26_6
+	for i, r := range rs {
+		if i > 0 {
+			x += sp.glyphGap(face, rs[i-1], r)
+		}
+		if n := len(segments); n < len(starts) && starts[n] == i {
+			end := len(rs)
+			if n+1 < len(starts) {
+				end = starts[n+1]
+			}
+			segments = append(segments, wrappedSegment{
+				text: string(trimRightSpaces(rs[i:end])),
+				x:    x + extra*fixed.Int26_6(n)/gaps,
+			})
+		}
+		x += glyphAdvance(face, r)
+	}
+	return segments
+}
+
+func isWrapSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\u3000'
+}
+
+func trimRightSpaces(rs []rune) []rune {
+	for len(rs) > 0 && isWrapSpace(rs[len(rs)-1]) {
+		rs = rs[:len(rs)-1]
+	}
+	return rs
+}
+
+// noBreakBefore is the set of runes that cannot start a line (Kinsoku).
+const noBreakBefore = ",.!?:;)]}%" +
+	"、。，．・：；？！）〕］｝〉》」』】〙〗〟’”｠»" +
+	"ヽヾーァィゥェォッャュョヮヵヶぁぃぅぇぉっゃゅょゎゕゖㇰㇱㇲㇳㇴㇵㇶㇷㇸㇹㇺㇻㇼㇽㇾㇿ々〻" +
+	"‐゠–〜～…‥"
+
+// noBreakAfter is the set of runes that cannot end a line (Kinsoku).
+const noBreakAfter = "([{" +
+	"（〔［｛〈《「『【〘〖〝‘“｟«"
+
+// canBreakBetween reports whether a line can be broken between r0 and r1.
+//
+// This is a simplified version of the Unicode line breaking algorithm (UAX #14).
+func canBreakBetween(r0, r1 rune) bool {
+	if isWrapSpace(r1) {
+		return false
+	}
+	if strings.ContainsRune(noBreakBefore, r1) || strings.ContainsRune(noBreakAfter, r0) {
+		return false
+	}
+	if isWrapSpace(r0) {
+		return true
+	}
+	// Break after hyphens and zero width spaces.
+	if r0 == '-' || r0 == '‐' || r0 == '–' || r0 == '—' || r0 == '\u200b' {
+		return true
+	}
+	return isIdeographic(r0) || isIdeographic(r1)
+}
+
+// isIdeographic reports whether a line can be broken before and after r like Chinese, Japanese and Korean
+// characters.
+func isIdeographic(r rune) bool {
+	switch {
+	case 0x1100 <= r && r <= 0x11ff: // Hangul Jamo
+		return true
+	case 0x2e80 <= r && r <= 0x9fff: // CJK Radicals to CJK Unified Ideographs, including Kana
+		return true
+	case 0xac00 <= r && r <= 0xd7af: // Hangul Syllables
+		return true
+	case 0xf900 <= r && r <= 0xfaff: // CJK Compatibility Ideographs
+		return true
+	case 0xff00 <= r && r <= 0xffef: // Halfwidth and Fullwidth Forms
+		return true
+	case 0x1f300 <= r && r <= 0x1faff: // Emoji
+		return true
+	case 0x20000 <= r && r <= 0x3ffff: // CJK Unified Ideographs Extensions
+		return true
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten
+
+import (
+	"time"
+)
+
+// TickInfo represents the information of a tick, i.e. one Update call.
+type TickInfo struct {
+	// Tick is the number of the tick. The first tick is 0.
+	Tick int64
+
+	// DeltaTime is the real time elapsed since the previous tick started.
+	// DeltaTime is 0 for the first tick.
+	//
+	// Catch-up ticks are called in a row, so their DeltaTime is almost 0.
+	DeltaTime time.Duration
+
+	// CatchUp reports whether the tick is a catch-up tick.
+	//
+	// When a frame takes more time than one tick, Update is called multiple times in the next frame to catch up
+	// with the TPS. The second and later ticks in one frame are catch-up ticks. A game can e.g. skip expensive
+	// processing that is only for visuals at catch-up ticks.
+	CatchUp bool
+}
+
+// TickUpdater is an optional interface for a Game to receive the information of each tick.
+//
+// If a game implements TickUpdater, UpdateWithTick is called instead of Update.
+type TickUpdater interface {
+	// UpdateWithTick updates a game by one tick in the same way as Update, with the information of the tick.
+	UpdateWithTick(info TickInfo) error
+}
+
+type tickState struct {
+	count     int64
+	lastStart time.Time
+
+	// step is the fixed duration of a tick for a manual clock. If step is 0, the real time is used.
+	step time.Duration
+}
+
+// begin starts a new tick and returns its information.
+//
+// begin must be called on the same goroutine as Update.
+func (t *tickState) begin(catchUp bool) TickInfo {
+	var dt time.Duration
+	if t.step > 0 {
+		if t.count > 0 {
+			dt = t.step
+		}
+	} else {
+		now := time.Now()
+		if !t.lastStart.IsZero() {
+			dt = now.Sub(t.lastStart)
+		}
+		t.lastStart = now
+	}
+	info := TickInfo{
+		Tick:      t.count,
+		DeltaTime: dt,
+		CatchUp:   catchUp,
+	}
+	t.count++
+	return info
+}
+
+// reset resets the state for a new game.
+func (t *tickState) reset() {
+	*t = tickState{
+		step: t.step,
+	}
+}
+
+// theTickInfo is the information of the current tick.
+var theTickInfo TickInfo
+
+// updateGame calls the game's UpdateWithTick if the game implements TickUpdater, or Update otherwise.
+func updateGame(game Game) error {
+	if u, ok := game.(TickUpdater); ok {
+		return u.UpdateWithTick(theTickInfo)
+	}
+	return game.Update()
+}
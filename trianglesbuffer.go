@@ -0,0 +1,79 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten
+
+// TrianglesBuffer is a reusable buffer of vertices and indices for DrawTriangles and DrawTrianglesShader.
+//
+// Building vertices and indices into new slices every frame generates a lot of garbage e.g. for particle
+// systems and tile maps. TrianglesBuffer keeps the allocated memory across Reset calls so that the buffer can
+// be reused every frame:
+//
+//     buf.Reset()
+//     for _, p := range particles {
+//         buf.AppendQuad(...)
+//     }
+//     screen.DrawTriangles(buf.Vertices, buf.Indices, img, nil)
+//
+// The zero value is an empty buffer ready to use.
+type TrianglesBuffer struct {
+	// Vertices is the vertices in the buffer.
+	Vertices []Vertex
+
+	// Indices is the indices in the buffer.
+	Indices []uint16
+}
+
+// Reset empties the buffer while keeping the allocated memory.
+func (b *TrianglesBuffer) Reset() {
+	b.Vertices = b.Vertices[:0]
+	b.Indices = b.Indices[:0]
+}
+
+// CanAppend reports whether vertexNum vertices and indexNum indices can be appended.
+//
+// The number of indices must not exceed MaxIndicesNum, and the number of vertices must be representable by
+// uint16 indices. If CanAppend returns false, draw the buffer and call Reset before appending.
+func (b *TrianglesBuffer) CanAppend(vertexNum, indexNum int) bool {
+	return len(b.Vertices)+vertexNum <= 1<<16 && len(b.Indices)+indexNum <= MaxIndicesNum
+}
+
+// AppendTriangles appends the vertices and the indices.
+// The indices are relative to the given vertices, and are offset by the number of vertices already in the
+// buffer.
+//
+// AppendTriangles panics if CanAppend(len(vertices), len(indices)) returns false.
+func (b *TrianglesBuffer) AppendTriangles(vertices []Vertex, indices []uint16) {
+	if !b.CanAppend(len(vertices), len(indices)) {
+		panic("ebiten: too many vertices or indices for TrianglesBuffer")
+	}
+	base := uint16(len(b.Vertices))
+	b.Vertices = append(b.Vertices, vertices...)
+	for _, idx := range indices {
+		b.Indices = append(b.Indices, base+idx)
+	}
+}
+
+// AppendQuad appends a quadrangle with the upper-left, the upper-right, the lower-left and the lower-right
+// vertices.
+//
+// AppendQuad panics if CanAppend(4, 6) returns false.
+func (b *TrianglesBuffer) AppendQuad(upperLeft, upperRight, lowerLeft, lowerRight Vertex) {
+	if !b.CanAppend(4, 6) {
+		panic("ebiten: too many vertices or indices for TrianglesBuffer")
+	}
+	base := uint16(len(b.Vertices))
+	b.Vertices = append(b.Vertices, upperLeft, upperRight, lowerLeft, lowerRight)
+	b.Indices = append(b.Indices, base, base+1, base+2, base+1, base+2, base+3)
+}
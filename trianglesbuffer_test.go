@@ -0,0 +1,54 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebiten_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestTrianglesBuffer(t *testing.T) {
+	var b TrianglesBuffer
+	b.AppendQuad(Vertex{DstX: 0}, Vertex{DstX: 1}, Vertex{DstX: 2}, Vertex{DstX: 3})
+	b.AppendTriangles([]Vertex{{DstX: 4}, {DstX: 5}, {DstX: 6}}, []uint16{0, 2, 1})
+
+	if got, want := len(b.Vertices), 7; got != want {
+		t.Errorf("len(b.Vertices): got: %d, want: %d", got, want)
+	}
+	if got, want := b.Indices, []uint16{0, 1, 2, 1, 2, 3, 4, 6, 5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("b.Indices: got: %v, want: %v", got, want)
+	}
+
+	c := cap(b.Vertices)
+	b.Reset()
+	if len(b.Vertices) != 0 || len(b.Indices) != 0 {
+		t.Errorf("b.Reset must empty the buffer")
+	}
+	if cap(b.Vertices) != c {
+		t.Errorf("b.Reset must keep the allocated memory")
+	}
+
+	if !b.CanAppend(1<<16, 0) {
+		t.Errorf("b.CanAppend(1<<16, 0): got: false, want: true")
+	}
+	if b.CanAppend(1<<16+1, 0) {
+		t.Errorf("b.CanAppend(1<<16+1, 0): got: true, want: false")
+	}
+	if b.CanAppend(0, MaxIndicesNum+1) {
+		t.Errorf("b.CanAppend(0, MaxIndicesNum+1): got: true, want: false")
+	}
+}
@@ -19,12 +19,15 @@ import (
 	"math"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/hajimehoshi/ebiten/v2/internal/buffered"
 	"github.com/hajimehoshi/ebiten/v2/internal/clock"
 	"github.com/hajimehoshi/ebiten/v2/internal/debug"
 	"github.com/hajimehoshi/ebiten/v2/internal/driver"
 	"github.com/hajimehoshi/ebiten/v2/internal/hooks"
+	"github.com/hajimehoshi/ebiten/v2/internal/stats"
+	"github.com/hajimehoshi/ebiten/v2/internal/testhook"
 )
 
 type uiContext struct {
@@ -34,16 +37,39 @@ type uiContext struct {
 
 	updateCalled bool
 
+	// tick is the state of the ticks of the game.
+	tick *tickState
+
+	// headless indicates whether the context is driven without the UI driver, e.g. by the ebitentest package.
+	// A headless context has no screen framebuffer, and the game is drawn only onto the offscreen.
+	headless bool
+
+	// screenUpdated indicates whether the screen framebuffer is updated in the last frame.
+	screenUpdated bool
+
+	// snapshot is the last snapshot of PipelinedGame to draw.
+	snapshot    interface{}
+	hasSnapshot bool
+
 	outsideSizeUpdated bool
 	outsideWidth       float64
 	outsideHeight      float64
 
+	// screenWidth and screenHeight are the logical screen size returned by the game's Layout or LayoutF.
+	screenWidth  float64
+	screenHeight float64
+
 	err atomic.Value
 
+	terminationRequested int32
+	shutdownCalled       bool
+
 	m sync.Mutex
 }
 
-var theUIContext = &uiContext{}
+var theUIContext = &uiContext{
+	tick: &tickState{},
+}
 
 func (c *uiContext) set(game Game) {
 	c.m.Lock()
@@ -67,17 +93,19 @@ func (c *uiContext) Layout(outsideWidth, outsideHeight float64) {
 }
 
 func (c *uiContext) updateOffscreen() {
-	sw, sh := c.game.Layout(int(c.outsideWidth), int(c.outsideHeight))
+	sw, sh := layoutGame(c.game, c.outsideWidth, c.outsideHeight)
 	if sw <= 0 || sh <= 0 {
 		panic("ebiten: Layout must return positive numbers")
 	}
 
-	if c.offscreen != nil && !c.outsideSizeUpdated {
-		if w, h := c.offscreen.Size(); w == sw && h == sh {
-			return
-		}
+	rs := RenderScale()
+	if c.offscreen != nil && !c.outsideSizeUpdated && c.screenWidth == sw && c.screenHeight == sh && c.offscreen.textureScale() == rs {
+		return
 	}
 	c.outsideSizeUpdated = false
+	c.screenWidth = sw
+	c.screenHeight = sh
+	ow, oh := offscreenSize(sw, sh)
 
 	if c.screen != nil {
 		c.screen.Dispose()
@@ -85,14 +113,21 @@ func (c *uiContext) updateOffscreen() {
 	}
 
 	if c.offscreen != nil {
-		if w, h := c.offscreen.Size(); w != sw || h != sh {
+		if w, h := c.offscreen.Size(); w != ow || h != oh || c.offscreen.textureScale() != rs {
 			c.offscreen.Dispose()
 			c.offscreen = nil
 		}
 	}
 	if c.offscreen == nil {
-		c.offscreen = NewImage(sw, sh)
-		c.offscreen.mipmap.SetVolatile(IsScreenClearedEveryFrame())
+		c.offscreen = newImageWithRenderScale(ow, oh, rs)
+		c.offscreen.mipmap.SetVolatile(isOffscreenClearedEveryFrame())
+	}
+
+	// The new offscreen and screen framebuffer must be drawn.
+	theDrawSkip.invalidate()
+
+	if c.headless {
+		return
 	}
 
 	// TODO: This is duplicated with mobile/ebitenmobileview/funcs.go. Refactor this.
@@ -105,7 +140,7 @@ func (c *uiContext) setScreenClearedEveryFrame(cleared bool) {
 	defer c.m.Unlock()
 
 	if c.offscreen != nil {
-		c.offscreen.mipmap.SetVolatile(cleared)
+		c.offscreen.mipmap.SetVolatile(cleared && !IsDrawSkippingEnabled())
 	}
 }
 
@@ -122,9 +157,8 @@ func (c *uiContext) screenScale(deviceScaleFactor float64) float64 {
 	if c.offscreen == nil {
 		return 0
 	}
-	sw, sh := c.offscreen.Size()
-	scaleX := c.outsideWidth / float64(sw) * deviceScaleFactor
-	scaleY := c.outsideHeight / float64(sh) * deviceScaleFactor
+	scaleX := c.outsideWidth / c.screenWidth * deviceScaleFactor
+	scaleY := c.outsideHeight / c.screenHeight * deviceScaleFactor
 	return math.Min(scaleX, scaleY)
 }
 
@@ -132,10 +166,9 @@ func (c *uiContext) offsets(deviceScaleFactor float64) (float64, float64) {
 	if c.offscreen == nil {
 		return 0, 0
 	}
-	sw, sh := c.offscreen.Size()
 	s := c.screenScale(deviceScaleFactor)
-	width := float64(sw) * s
-	height := float64(sh) * s
+	width := c.screenWidth * s
+	height := c.screenHeight * s
 	x := (c.outsideWidth*deviceScaleFactor - width) / 2
 	y := (c.outsideHeight*deviceScaleFactor - height) / 2
 	return x, y
@@ -147,8 +180,15 @@ func (c *uiContext) Update() error {
 	if err, ok := c.err.Load().(error); ok && err != nil {
 		return err
 	}
+	if c.isTerminationRequested() {
+		if err := c.Shutdown(); err != nil {
+			return err
+		}
+		return driver.RegularTermination
+	}
+	stats.BeginFrame()
 	if err := buffered.BeginFrame(); err != nil {
-		return err
+		return fromInternalError(err)
 	}
 	if err := c.update(clock.Update(MaxTPS())); err != nil {
 		return err
@@ -156,6 +196,7 @@ func (c *uiContext) Update() error {
 	if err := buffered.EndFrame(); err != nil {
 		return err
 	}
+	stats.EndFrame(time.Now())
 	return nil
 }
 
@@ -169,8 +210,10 @@ func (c *uiContext) ForceUpdate() error {
 		return err
 	}
 	if err := buffered.BeginFrame(); err != nil {
-		return err
+		return fromInternalError(err)
 	}
+	// ForceUpdate is called e.g. when the window is resized. Always draw and present the screen.
+	theDrawSkip.invalidate()
 	if err := c.update(1); err != nil {
 		return err
 	}
@@ -181,32 +224,140 @@ func (c *uiContext) ForceUpdate() error {
 }
 
 func (c *uiContext) update(updateCount int) error {
+	defer theCrashReporter.handlePanic()
+
+	if err := c.restartIfNeeded(); err != nil {
+		return err
+	}
+	if err := c.handleDeviceLost(); err != nil {
+		return err
+	}
+	return c.updateFrame(updateCount)
+}
+
+// updateHeadless updates the game by one tick and draws the game onto the offscreen in the same way as a frame
+// of the main loop. updateHeadless is used for a headless context.
+func (c *uiContext) updateHeadless() error {
+	return c.updateFrame(1)
+}
+
+// updateFrame runs Update calls and draws the game in a frame, where updateCount is the number of Update calls
+// by the clock.
+func (c *uiContext) updateFrame(updateCount int) error {
 	c.updateOffscreen()
 
+	updateCount = theFrameStep.updateCount(updateCount)
+
 	// Ensure that Update is called once before Draw so that Update can be used for initialization.
 	if !c.updateCalled && updateCount == 0 {
 		updateCount = 1
+	}
+	if updateCount > 0 {
 		c.updateCalled = true
 	}
 	debug.Logf("--\nUpdate count per frame: %d\n", updateCount)
 
+	if !theDrawSkip.needsToDraw() {
+		return c.updateWithoutDraw(updateCount)
+	}
+	return c.updateAndDraw(updateCount)
+}
+
+// updateAndDraw runs Update calls and then draws the game.
+func (c *uiContext) updateAndDraw(updateCount int) error {
+	if g, ok := c.pipelinedGame(); ok {
+		return c.updatePipelined(g, updateCount)
+	}
+	c.snapshot = nil
+	c.hasSnapshot = false
+
+	if err := c.runUpdates(updateCount); err != nil {
+		return err
+	}
+
+	t := time.Now()
+
+	// Even though updateCount == 0, the offscreen is cleared and Draw is called.
+	// Draw should not update the game state and then the screen should not be updated without Update, but
+	// users might want to process something at Draw with the time intervals of FPS.
+	if isOffscreenClearedEveryFrame() {
+		c.offscreen.Clear()
+	}
+	c.game.Draw(c.offscreen)
+	c.drawScreen()
+
+	stats.AddPhaseTime(stats.PhaseDraw, time.Since(t))
+	return nil
+}
+
+// updateWithoutDraw runs Update calls without Draw, and keeps the last frame.
+func (c *uiContext) updateWithoutDraw(updateCount int) error {
+	if err := c.runUpdates(updateCount); err != nil {
+		return err
+	}
+
+	// The snapshot for pipelined updating is stale. Take a new snapshot at the next drawing.
+	c.snapshot = nil
+	c.hasSnapshot = false
+
+	if !canSkipPresent || IsPerformanceOverlayVisible() {
+		c.drawScreen()
+		return nil
+	}
+	c.screenUpdated = false
+	return nil
+}
+
+func (c *uiContext) runUpdates(updateCount int) error {
+	t := time.Now()
 	for i := 0; i < updateCount; i++ {
-		if err := hooks.RunBeforeUpdateHooks(); err != nil {
+		if err := c.beginTick(i > 0); err != nil {
 			return err
 		}
-		if err := c.game.Update(); err != nil {
+		if err := updateGame(c.game); err != nil {
 			return err
 		}
+		c.endTick()
+	}
+	stats.AddPhaseTime(stats.PhaseUpdate, time.Since(t))
+	return nil
+}
+
+// beginTick prepares the input and the tick information for the next Update call.
+//
+// beginTick must be called on the goroutine of the main loop.
+func (c *uiContext) beginTick(catchUp bool) error {
+	if !c.headless && IsLateInputSamplingEnabled() {
+		uiDriver().UpdateInput()
+	}
+	if err := hooks.RunBeforeUpdateHooks(); err != nil {
+		return err
+	}
+	theCrashReporter.recordInput()
+	theTickInfo = c.tick.begin(catchUp)
+	return nil
+}
+
+// endTick resets the input after an Update call.
+//
+// endTick must be called on the goroutine of the main loop.
+func (c *uiContext) endTick() {
+	if c.headless {
+		testhook.ResetInputForFrame()
+	} else {
 		uiDriver().ResetForFrame()
 	}
+}
 
-	// Even though updateCount == 0, the offscreen is cleared and Draw is called.
-	// Draw should not update the game state and then the screen should not be updated without Update, but
-	// users might want to process something at Draw with the time intervals of FPS.
-	if IsScreenClearedEveryFrame() {
-		c.offscreen.Clear()
+// drawScreen draws the offscreen onto the screen framebuffer.
+func (c *uiContext) drawScreen() {
+	if c.headless {
+		return
 	}
-	c.game.Draw(c.offscreen)
+
+	theCrashReporter.keepScreen(c.offscreen)
+
+	c.screenUpdated = true
 
 	// This clear is needed for fullscreen mode or some mobile platforms (#622).
 	c.screen.Clear()
@@ -217,8 +368,9 @@ func (c *uiContext) update(updateCount int) error {
 	switch vd := uiDriver().Graphics().FramebufferYDirection(); vd {
 	case driver.Upward:
 		op.GeoM.Scale(s, -s)
-		_, h := c.offscreen.Size()
-		op.GeoM.Translate(0, float64(h)*s)
+		// Use the logical height instead of the offscreen's height, which is rounded up. The fractional part of
+		// the last row is cropped as well as the downward case, and the positions match with offsets.
+		op.GeoM.Translate(0, c.screenHeight*s)
 	case driver.Downward:
 		op.GeoM.Scale(s, s)
 	default:
@@ -230,13 +382,22 @@ func (c *uiContext) update(updateCount int) error {
 
 	// filterScreen works with >=1 scale, but does not well with <1 scale.
 	// Use regular FilterLinear instead so far (#669).
-	if s >= 1 {
+	// As the offscreen is shrunk to its logical size at DrawImage, take the render scale into account.
+	if s/c.offscreen.textureScale() >= 1 {
 		op.Filter = filterScreen
 	} else {
 		op.Filter = FilterLinear
 	}
 	c.screen.DrawImage(c.offscreen, op)
-	return nil
+
+	if IsPerformanceOverlayVisible() {
+		thePerfOverlay.update()
+		thePerfOverlay.draw(c.screen, uiDriver().DeviceScaleFactor(), uiDriver().Graphics().FramebufferYDirection())
+	}
+}
+
+func (c *uiContext) IsScreenUpdated() bool {
+	return c.screenUpdated
 }
 
 func (c *uiContext) AdjustPosition(x, y float64, deviceScaleFactor float64) (float64, float64) {
@@ -0,0 +1,84 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package video
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"sync"
+)
+
+// Decoder decodes compressed video frames.
+//
+// If a Decoder implements io.Closer, Close is called when the player is closed.
+type Decoder interface {
+	// DecodeFrame decodes a compressed frame.
+	//
+	// DecodeFrame is called for the frames in the stream order, starting with a key frame. DecodeFrame can return
+	// nil image for a frame that is not shown.
+	DecodeFrame(data []byte) (image.Image, error)
+
+	// IsKeyFrame reports whether the compressed frame can be decoded without the preceding frames.
+	IsKeyFrame(data []byte) bool
+}
+
+var (
+	decoders  = map[string]func(width, height int) (Decoder, error){}
+	decodersM sync.Mutex
+)
+
+// RegisterDecoder registers a decoder for the codec specified by fourcc, e.g. "VP90" for VP9 or "AV01" for AV1.
+//
+// newDecoder is called with the frame size in the stream header when a player for the codec is created.
+//
+// The video package bundles only a decoder for Motion JPEG ("MJPG"). To play VP9 or AV1 streams, register a
+// decoder that uses a platform decoder or a codec library, typically in an init function of a package that
+// provides the decoder.
+//
+// RegisterDecoder is concurrent-safe.
+func RegisterDecoder(fourcc string, newDecoder func(width, height int) (Decoder, error)) {
+	decodersM.Lock()
+	defer decodersM.Unlock()
+	decoders[fourcc] = newDecoder
+}
+
+func newDecoder(fourcc string, width, height int) (Decoder, error) {
+	decodersM.Lock()
+	f, ok := decoders[fourcc]
+	decodersM.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("video: no decoder is registered for the codec %q", fourcc)
+	}
+	return f(width, height)
+}
+
+func init() {
+	RegisterDecoder("MJPG", func(width, height int) (Decoder, error) {
+		return mjpegDecoder{}, nil
+	})
+}
+
+// mjpegDecoder is a decoder for Motion JPEG, where all the frames are independent JPEG images.
+type mjpegDecoder struct{}
+
+func (mjpegDecoder) DecodeFrame(data []byte) (image.Image, error) {
+	return jpeg.Decode(bytes.NewReader(data))
+}
+
+func (mjpegDecoder) IsKeyFrame(data []byte) bool {
+	return true
+}
@@ -0,0 +1,147 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package video
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+const (
+	ivfHeaderSize      = 32
+	ivfFrameHeaderSize = 12
+)
+
+type ivfFrame struct {
+	offset int64
+	size   int
+	pts    int64
+}
+
+// ivfReader reads compressed frames from an IVF stream.
+//
+// IVF is a simple container that is used for VP8, VP9 and AV1 streams.
+type ivfReader struct {
+	src io.ReadSeeker
+
+	fourcc string
+	width  int
+	height int
+
+	// The unit of the timestamps is timebaseNum/timebaseDen seconds.
+	timebaseNum int64
+	timebaseDen int64
+
+	frames []ivfFrame
+}
+
+func newIVFReader(src io.ReadSeeker) (*ivfReader, error) {
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	var h [ivfHeaderSize]byte
+	if _, err := io.ReadFull(src, h[:]); err != nil {
+		return nil, fmt.Errorf("video: reading the IVF header failed: %v", err)
+	}
+	if string(h[0:4]) != "DKIF" {
+		return nil, errors.New("video: the stream is not an IVF stream")
+	}
+	headerSize := int64(binary.LittleEndian.Uint16(h[6:8]))
+	if headerSize < ivfHeaderSize {
+		return nil, fmt.Errorf("video: invalid IVF header size: %d", headerSize)
+	}
+
+	r := &ivfReader{
+		src:         src,
+		fourcc:      string(h[8:12]),
+		width:       int(binary.LittleEndian.Uint16(h[12:14])),
+		height:      int(binary.LittleEndian.Uint16(h[14:16])),
+		timebaseDen: int64(binary.LittleEndian.Uint32(h[16:20])),
+		timebaseNum: int64(binary.LittleEndian.Uint32(h[20:24])),
+	}
+	if r.timebaseNum == 0 || r.timebaseDen == 0 {
+		return nil, errors.New("video: invalid IVF time base")
+	}
+
+	// Index all the frames so that seeking is possible.
+	offset := headerSize
+	for {
+		if _, err := src.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		var fh [ivfFrameHeaderSize]byte
+		if _, err := io.ReadFull(src, fh[:]); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				break
+			}
+			return nil, err
+		}
+		size := int(binary.LittleEndian.Uint32(fh[0:4]))
+		r.frames = append(r.frames, ivfFrame{
+			offset: offset + ivfFrameHeaderSize,
+			size:   size,
+			pts:    int64(binary.LittleEndian.Uint64(fh[4:12])),
+		})
+		offset += ivfFrameHeaderSize + int64(size)
+	}
+	if len(r.frames) == 0 {
+		return nil, errors.New("video: the IVF stream has no frames")
+	}
+	return r, nil
+}
+
+// timestamp returns the presentation time of the i-th frame.
+func (r *ivfReader) timestamp(i int) time.Duration {
+	return time.Duration(r.frames[i].pts * r.timebaseNum * int64(time.Second) / r.timebaseDen)
+}
+
+// duration returns the duration of the stream. The last frame is assumed to be shown for the average duration
+// of the frames.
+func (r *ivfReader) duration() time.Duration {
+	n := len(r.frames)
+	last := r.timestamp(n - 1)
+	if n == 1 {
+		return last
+	}
+	return last + (last-r.timestamp(0))/time.Duration(n-1)
+}
+
+// frameAt returns the index of the frame to be shown at t. frameAt returns -1 if t is before the first frame.
+func (r *ivfReader) frameAt(t time.Duration) int {
+	return sort.Search(len(r.frames), func(i int) bool {
+		return r.timestamp(i) > t
+	}) - 1
+}
+
+// readFrame reads the compressed data of the i-th frame into buf.
+func (r *ivfReader) readFrame(i int, buf []byte) ([]byte, error) {
+	f := r.frames[i]
+	if cap(buf) < f.size {
+		buf = make([]byte, f.size)
+	}
+	buf = buf[:f.size]
+	if _, err := r.src.Seek(f.offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(r.src, buf); err != nil {
+		return nil, fmt.Errorf("video: reading the frame %d failed: %v", i, err)
+	}
+	return buf, nil
+}
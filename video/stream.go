@@ -0,0 +1,342 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package video
+
+import (
+	"image"
+	"image/draw"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2/audio"
+)
+
+// streamPlayer plays a video stream decoded by a Decoder.
+//
+// The frames are decoded when they are read, so the cost of decoding is paid on the thread calling Image.
+type streamPlayer struct {
+	src     *ivfReader
+	decoder Decoder
+	audio   *audio.Player
+
+	// The states below are used only without an audio player.
+	playing bool
+	pos     time.Duration
+	start   time.Time
+	vol     float64
+
+	loop bool
+
+	// decoded is the index of the last decoded frame, or -1 if no frame is decoded yet.
+	decoded   int
+	keyFrames []keyFrameState
+	image     image.Image
+	buf       []byte
+	e         error
+
+	m sync.Mutex
+}
+
+type keyFrameState int
+
+const (
+	keyFrameUnknown keyFrameState = iota
+	keyFrameYes
+	keyFrameNo
+)
+
+func newStreamPlayer(src io.ReadSeeker, audioPlayer *audio.Player) (*streamPlayer, error) {
+	r, err := newIVFReader(src)
+	if err != nil {
+		return nil, err
+	}
+	d, err := newDecoder(r.fourcc, r.width, r.height)
+	if err != nil {
+		return nil, err
+	}
+	return &streamPlayer{
+		src:       r,
+		decoder:   d,
+		audio:     audioPlayer,
+		vol:       1,
+		decoded:   -1,
+		keyFrames: make([]keyFrameState, len(r.frames)),
+	}, nil
+}
+
+func (p *streamPlayer) play() {
+	if p.audio != nil {
+		p.audio.Play()
+		return
+	}
+
+	p.m.Lock()
+	defer p.m.Unlock()
+	if p.playing {
+		return
+	}
+	if p.isEndedImpl() {
+		p.pos = 0
+	}
+	p.playing = true
+	p.start = time.Now()
+}
+
+func (p *streamPlayer) pause() {
+	if p.audio != nil {
+		p.audio.Pause()
+		return
+	}
+
+	p.m.Lock()
+	defer p.m.Unlock()
+	if !p.playing {
+		return
+	}
+	p.pos = p.currentImpl()
+	p.playing = false
+}
+
+func (p *streamPlayer) isPlaying() bool {
+	if p.audio != nil {
+		return p.audio.IsPlaying()
+	}
+
+	p.m.Lock()
+	defer p.m.Unlock()
+	return p.playing && !p.isEndedImpl()
+}
+
+func (p *streamPlayer) isEnded() bool {
+	p.m.Lock()
+	defer p.m.Unlock()
+	return p.isEndedImpl()
+}
+
+func (p *streamPlayer) isEndedImpl() bool {
+	if p.loop {
+		return false
+	}
+	return p.currentImpl() >= p.src.duration()
+}
+
+func (p *streamPlayer) seek(offset time.Duration) {
+	if offset < 0 {
+		offset = 0
+	}
+
+	if p.audio != nil {
+		if err := p.audio.Seek(offset); err != nil {
+			p.m.Lock()
+			p.e = err
+			p.m.Unlock()
+		}
+		return
+	}
+
+	p.m.Lock()
+	defer p.m.Unlock()
+	p.pos = offset
+	p.start = time.Now()
+}
+
+func (p *streamPlayer) current() time.Duration {
+	p.m.Lock()
+	defer p.m.Unlock()
+	return p.currentImpl()
+}
+
+func (p *streamPlayer) currentImpl() time.Duration {
+	var pos time.Duration
+	if p.audio != nil {
+		pos = p.audio.Current()
+	} else {
+		pos = p.pos
+		if p.playing {
+			pos += time.Since(p.start)
+		}
+	}
+
+	d := p.src.duration()
+	if d <= 0 {
+		return 0
+	}
+	if p.loop {
+		return pos % d
+	}
+	if pos > d {
+		return d
+	}
+	return pos
+}
+
+func (p *streamPlayer) duration() time.Duration {
+	return p.src.duration()
+}
+
+func (p *streamPlayer) volume() float64 {
+	if p.audio != nil {
+		return p.audio.Volume()
+	}
+
+	p.m.Lock()
+	defer p.m.Unlock()
+	return p.vol
+}
+
+func (p *streamPlayer) setVolume(volume float64) {
+	if p.audio != nil {
+		p.audio.SetVolume(volume)
+		return
+	}
+
+	p.m.Lock()
+	defer p.m.Unlock()
+	p.vol = volume
+}
+
+func (p *streamPlayer) setLoop(loop bool) {
+	p.m.Lock()
+	defer p.m.Unlock()
+	if p.loop == loop {
+		return
+	}
+	if !loop && p.audio == nil {
+		// Keep the current position in the loop.
+		p.pos = p.currentImpl()
+		p.start = time.Now()
+	}
+	p.loop = loop
+}
+
+func (p *streamPlayer) err() error {
+	p.m.Lock()
+	defer p.m.Unlock()
+	return p.e
+}
+
+func (p *streamPlayer) readFrame(pix []byte) ([]byte, int, int, bool) {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if p.e != nil {
+		return pix, 0, 0, false
+	}
+
+	target := p.src.frameAt(p.currentImpl())
+	if target < 0 {
+		return pix, 0, 0, false
+	}
+	if target == p.decoded {
+		w, h := imageSize(p.image)
+		return pix, w, h, false
+	}
+
+	// Continue decoding from the last decoded frame unless there is a key frame on the way.
+	from := p.decoded + 1
+	if p.decoded < 0 || target < p.decoded {
+		from = 0
+	}
+	from = p.keyFrameBefore(target, from)
+	for i := from; i <= target; i++ {
+		data, err := p.src.readFrame(i, p.buf)
+		if err != nil {
+			p.e = err
+			return pix, 0, 0, false
+		}
+		p.buf = data
+
+		img, err := p.decoder.DecodeFrame(data)
+		if err != nil {
+			p.e = err
+			return pix, 0, 0, false
+		}
+		if img != nil {
+			p.image = img
+		}
+		p.decoded = i
+	}
+
+	if p.image == nil {
+		return pix, 0, 0, false
+	}
+	pix, w, h := toRGBA(p.image, pix)
+	return pix, w, h, true
+}
+
+// keyFrameBefore returns the index of the last key frame in [lower, i].
+// If there is no such key frame, keyFrameBefore returns lower.
+func (p *streamPlayer) keyFrameBefore(i int, lower int) int {
+	for ; i > lower; i-- {
+		switch p.keyFrames[i] {
+		case keyFrameYes:
+			return i
+		case keyFrameNo:
+			continue
+		}
+
+		data, err := p.src.readFrame(i, p.buf)
+		if err != nil {
+			// The error will be reported when the frame is decoded.
+			return i
+		}
+		p.buf = data
+		if p.decoder.IsKeyFrame(data) {
+			p.keyFrames[i] = keyFrameYes
+			return i
+		}
+		p.keyFrames[i] = keyFrameNo
+	}
+	return lower
+}
+
+func (p *streamPlayer) close() error {
+	p.m.Lock()
+	defer p.m.Unlock()
+	p.image = nil
+	p.buf = nil
+	if c, ok := p.decoder.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func imageSize(img image.Image) (int, int) {
+	if img == nil {
+		return 0, 0
+	}
+	b := img.Bounds()
+	return b.Dx(), b.Dy()
+}
+
+// toRGBA converts img to RGBA pixels, reusing pix if possible.
+func toRGBA(img image.Image, pix []byte) ([]byte, int, int) {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	n := 4 * w * h
+	if cap(pix) < n {
+		pix = make([]byte, n)
+	}
+	pix = pix[:n]
+
+	dst := &image.RGBA{
+		Pix:    pix,
+		Stride: 4 * w,
+		Rect:   image.Rect(0, 0, w, h),
+	}
+	draw.Draw(dst, dst.Rect, img, b.Min, draw.Src)
+	return pix, w, h
+}
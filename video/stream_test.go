@@ -0,0 +1,108 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package video
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+	"time"
+)
+
+// newMJPEGIVF creates an IVF stream of 30 fps with solid-color Motion JPEG frames.
+func newMJPEGIVF(t *testing.T, colors []color.Gray) []byte {
+	const (
+		w = 16
+		h = 16
+	)
+
+	var buf bytes.Buffer
+	var header [ivfHeaderSize]byte
+	copy(header[0:4], "DKIF")
+	binary.LittleEndian.PutUint16(header[6:8], ivfHeaderSize)
+	copy(header[8:12], "MJPG")
+	binary.LittleEndian.PutUint16(header[12:14], w)
+	binary.LittleEndian.PutUint16(header[14:16], h)
+	binary.LittleEndian.PutUint32(header[16:20], 30)
+	binary.LittleEndian.PutUint32(header[20:24], 1)
+	binary.LittleEndian.PutUint32(header[24:28], uint32(len(colors)))
+	buf.Write(header[:])
+
+	for i, c := range colors {
+		img := image.NewGray(image.Rect(0, 0, w, h))
+		for j := range img.Pix {
+			img.Pix[j] = c.Y
+		}
+		var frame bytes.Buffer
+		if err := jpeg.Encode(&frame, img, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		var fh [ivfFrameHeaderSize]byte
+		binary.LittleEndian.PutUint32(fh[0:4], uint32(frame.Len()))
+		binary.LittleEndian.PutUint64(fh[4:12], uint64(i))
+		buf.Write(fh[:])
+		buf.Write(frame.Bytes())
+	}
+	return buf.Bytes()
+}
+
+func TestStreamPlayerSeek(t *testing.T) {
+	colors := []color.Gray{{0x00}, {0x40}, {0x80}, {0xc0}}
+	p, err := newStreamPlayer(bytes.NewReader(newMJPEGIVF(t, colors)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.close()
+
+	if got, want := p.duration(), 4*time.Second/30; got != want {
+		t.Errorf("duration(): got: %v, want: %v", got, want)
+	}
+
+	var pix []byte
+	for _, i := range []int{2, 0, 3, 3, 1} {
+		p.seek(time.Duration(i) * time.Second / 30)
+		var w, h int
+		var updated bool
+		pix, w, h, updated = p.readFrame(pix)
+		if err := p.err(); err != nil {
+			t.Fatal(err)
+		}
+		if w != 16 || h != 16 {
+			t.Fatalf("readFrame(): size: got: (%d, %d), want: (16, 16)", w, h)
+		}
+		if !updated {
+			// The frame is the same as the previous one.
+			continue
+		}
+		got := pix[4*(8*w+8)]
+		want := colors[i].Y
+		// JPEG is lossy.
+		if diff := int(got) - int(want); diff < -2 || diff > 2 {
+			t.Errorf("frame %d: got: %d, want: %d", i, got, want)
+		}
+	}
+}
+
+func TestStreamPlayerUnknownCodec(t *testing.T) {
+	b := newMJPEGIVF(t, []color.Gray{{0}})
+	copy(b[8:12], "XXXX")
+	if _, err := newStreamPlayer(bytes.NewReader(b), nil); err == nil {
+		t.Errorf("newStreamPlayer() must return an error for an unknown codec")
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package video provides video playback into an ebiten.Image.
+//
+// There are two kinds of players:
+//
+// NewPlayer creates a player that uses the browser's video element. The formats that the browser supports (e.g.
+// MP4/H.264 and WebM/VP9) are available. The audio track is played by the browser, and the frames are delivered
+// in sync with the audio. NewPlayer works only on browsers.
+//
+// NewPlayerFromStream creates a player that decodes an IVF stream with a Decoder registered by RegisterDecoder.
+// The frames are delivered in sync with an audio.Player playing the audio track, if given. NewPlayerFromStream
+// works on all the platforms.
+package video
+
+import (
+	"io"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/audio"
+)
+
+type player interface {
+	play()
+	pause()
+	isPlaying() bool
+	isEnded() bool
+	seek(offset time.Duration)
+	current() time.Duration
+	duration() time.Duration
+	volume() float64
+	setVolume(volume float64)
+	setLoop(loop bool)
+	err() error
+	readFrame(pix []byte) ([]byte, int, int, bool)
+	close() error
+}
+
+// Player is a video player.
+type Player struct {
+	player player
+
+	image  *ebiten.Image
+	pixels []byte
+}
+
+// NewPlayer creates a new video player for the video at the given URL.
+//
+// Loading the video is asynchronous. Until the first frame is loaded, Image returns nil.
+//
+// On browsers, the video must be served from the same origin, or the server must allow the access by CORS,
+// so that the frames can be read.
+//
+// NewPlayer returns an error on non-browsers.
+func NewPlayer(url string) (*Player, error) {
+	p, err := newPlayer(url)
+	if err != nil {
+		return nil, err
+	}
+	return &Player{
+		player: p,
+	}, nil
+}
+
+// PlayerOptions represents options for NewPlayerFromStream.
+type PlayerOptions struct {
+	// Audio is the player of the video's audio track.
+	//
+	// If Audio is not nil, the frames are synchronized with Audio's position, and Play, Pause, Seek, Volume and
+	// SetVolume operate Audio. To loop the video, Audio's stream should loop too, e.g. by audio.InfiniteLoop.
+	//
+	// If Audio is nil, the frames are delivered by the wall clock.
+	Audio *audio.Player
+}
+
+// NewPlayerFromStream creates a new video player for the IVF stream src.
+//
+// IVF is a simple container for VP8, VP9 and AV1 streams. For example, ffmpeg can output IVF with "-f ivf".
+// The codec of the stream must have a decoder registered by RegisterDecoder.
+//
+// The frames are decoded in Image. A Player doesn't close src nor options.Audio.
+//
+// NewPlayerFromStream works on all the platforms.
+func NewPlayerFromStream(src io.ReadSeeker, options *PlayerOptions) (*Player, error) {
+	var a *audio.Player
+	if options != nil {
+		a = options.Audio
+	}
+	p, err := newStreamPlayer(src, a)
+	if err != nil {
+		return nil, err
+	}
+	return &Player{
+		player: p,
+	}, nil
+}
+
+// Play starts or resumes playing the video.
+//
+// Browsers allow to play a video with sound only after the user interacts with the page, e.g. clicks the screen.
+// If playing is not allowed, Play does nothing and IsPlaying returns false. Play can be called again after a user
+// interaction.
+func (p *Player) Play() {
+	p.player.play()
+}
+
+// Pause pauses playing the video.
+func (p *Player) Pause() {
+	p.player.pause()
+}
+
+// IsPlaying reports whether the video is playing.
+func (p *Player) IsPlaying() bool {
+	return p.player.isPlaying()
+}
+
+// IsEnded reports whether the video reached its end.
+func (p *Player) IsEnded() bool {
+	return p.player.isEnded()
+}
+
+// Seek seeks the position to the given offset.
+//
+// For a player created by NewPlayer, seeking is asynchronous. The frame at the new position is delivered to Image
+// later.
+func (p *Player) Seek(offset time.Duration) {
+	p.player.seek(offset)
+}
+
+// Current returns the current position.
+func (p *Player) Current() time.Duration {
+	return p.player.current()
+}
+
+// Duration returns the duration of the video. Duration returns 0 if the duration is not known yet.
+func (p *Player) Duration() time.Duration {
+	return p.player.duration()
+}
+
+// Volume returns the current volume of the video's audio track in the range of [0, 1].
+func (p *Player) Volume() float64 {
+	return p.player.volume()
+}
+
+// SetVolume sets the volume of the video's audio track in the range of [0, 1].
+func (p *Player) SetVolume(volume float64) {
+	if volume < 0 {
+		volume = 0
+	}
+	if volume > 1 {
+		volume = 1
+	}
+	p.player.setVolume(volume)
+}
+
+// SetLoop sets whether the video is played in a loop.
+func (p *Player) SetLoop(loop bool) {
+	p.player.setLoop(loop)
+}
+
+// Err returns an error if loading or decoding the video fails.
+func (p *Player) Err() error {
+	return p.player.err()
+}
+
+// Image returns the current frame of the video.
+//
+// Image returns nil until the first frame is loaded. The returned image is reused for later frames. The image's
+// content is updated when Image is called and a new frame is available.
+//
+// Image must be called from the main thread e.g. in Update or Draw.
+func (p *Player) Image() *ebiten.Image {
+	pix, w, h, updated := p.player.readFrame(p.pixels)
+	if w == 0 || h == 0 {
+		return p.image
+	}
+	p.pixels = pix
+	if p.image != nil {
+		if iw, ih := p.image.Size(); iw != w || ih != h {
+			p.image.Dispose()
+			p.image = nil
+		}
+	}
+	if p.image == nil {
+		p.image = ebiten.NewImage(w, h)
+		updated = true
+	}
+	if updated {
+		p.image.ReplacePixels(p.pixels)
+	}
+	return p.image
+}
+
+// Close stops the video and releases the resources.
+func (p *Player) Close() error {
+	if p.image != nil {
+		p.image.Dispose()
+		p.image = nil
+	}
+	p.pixels = nil
+	return p.player.close()
+}
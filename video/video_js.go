@@ -0,0 +1,218 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package video
+
+import (
+	"errors"
+	"fmt"
+	"syscall/js"
+	"time"
+)
+
+type elementPlayer struct {
+	video  js.Value
+	canvas js.Value
+	ctx    js.Value
+
+	loaded     bool
+	frameDirty bool
+	frameReq   js.Value
+	lastTime   float64
+	e          error
+
+	funcs []js.Func
+}
+
+func newPlayer(url string) (*elementPlayer, error) {
+	doc := js.Global().Get("document")
+	if !doc.Truthy() {
+		return nil, errors.New("video: video playback requires a document")
+	}
+
+	p := &elementPlayer{
+		lastTime: -1,
+	}
+
+	v := doc.Call("createElement", "video")
+	// crossOrigin is required to read the frames of a video from another origin.
+	v.Set("crossOrigin", "anonymous")
+	v.Set("preload", "auto")
+	v.Set("playsInline", true)
+	p.video = v
+
+	p.addEventListener("loadeddata", func(e js.Value) {
+		p.loaded = true
+		p.frameDirty = true
+	})
+	p.addEventListener("seeked", func(e js.Value) {
+		p.frameDirty = true
+	})
+	p.addEventListener("error", func(e js.Value) {
+		msg := "unknown error"
+		if err := v.Get("error"); err.Truthy() {
+			if m := err.Get("message"); m.Truthy() && m.String() != "" {
+				msg = m.String()
+			} else {
+				msg = fmt.Sprintf("code %d", err.Get("code").Int())
+			}
+		}
+		p.e = fmt.Errorf("video: loading the video failed: %s", msg)
+	})
+
+	// requestVideoFrameCallback tells exactly when a new frame is presented. If this is not available, a frame
+	// is regarded as new when the current time changes.
+	if v.Get("requestVideoFrameCallback").Truthy() {
+		var f js.Func
+		f = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			p.frameDirty = true
+			p.frameReq = p.video.Call("requestVideoFrameCallback", f)
+			return nil
+		})
+		p.funcs = append(p.funcs, f)
+		p.frameReq = v.Call("requestVideoFrameCallback", f)
+	}
+
+	v.Set("src", url)
+	v.Call("load")
+	return p, nil
+}
+
+func (p *elementPlayer) addEventListener(name string, f func(e js.Value)) {
+	jf := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		f(args[0])
+		return nil
+	})
+	p.funcs = append(p.funcs, jf)
+	p.video.Call("addEventListener", name, jf)
+}
+
+func (p *elementPlayer) play() {
+	// The promise is rejected when playing is not allowed without a user interaction. Ignore the error as
+	// IsPlaying reports the state.
+	if r := p.video.Call("play"); r.Truthy() && r.Get("catch").Truthy() {
+		r.Call("catch", ignoreErrorFunc)
+	}
+}
+
+var ignoreErrorFunc = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+	return nil
+})
+
+func (p *elementPlayer) pause() {
+	p.video.Call("pause")
+}
+
+func (p *elementPlayer) isPlaying() bool {
+	return !p.video.Get("paused").Bool() && !p.video.Get("ended").Bool()
+}
+
+func (p *elementPlayer) isEnded() bool {
+	return p.video.Get("ended").Bool()
+}
+
+func (p *elementPlayer) seek(offset time.Duration) {
+	p.video.Set("currentTime", offset.Seconds())
+}
+
+func (p *elementPlayer) current() time.Duration {
+	return time.Duration(p.video.Get("currentTime").Float() * float64(time.Second))
+}
+
+func (p *elementPlayer) duration() time.Duration {
+	d := p.video.Get("duration").Float()
+	// duration is NaN before the metadata is loaded, and +Inf for a live stream.
+	if d != d || d > float64(1<<62)/float64(time.Second) {
+		return 0
+	}
+	return time.Duration(d * float64(time.Second))
+}
+
+func (p *elementPlayer) volume() float64 {
+	return p.video.Get("volume").Float()
+}
+
+func (p *elementPlayer) setVolume(volume float64) {
+	p.video.Set("volume", volume)
+}
+
+func (p *elementPlayer) setLoop(loop bool) {
+	p.video.Set("loop", loop)
+}
+
+func (p *elementPlayer) err() error {
+	return p.e
+}
+
+// readFrame reads the current frame into pix if a new frame is available, and returns the pixels and the size.
+func (p *elementPlayer) readFrame(pix []byte) ([]byte, int, int, bool) {
+	if !p.loaded || p.e != nil {
+		return pix, 0, 0, false
+	}
+	w := p.video.Get("videoWidth").Int()
+	h := p.video.Get("videoHeight").Int()
+	if w == 0 || h == 0 {
+		return pix, 0, 0, false
+	}
+
+	if t := p.video.Get("currentTime").Float(); t != p.lastTime {
+		p.lastTime = t
+		p.frameDirty = true
+	}
+	if !p.frameDirty && len(pix) == 4*w*h {
+		return pix, w, h, false
+	}
+	p.frameDirty = false
+
+	if !p.canvas.Truthy() || p.canvas.Get("width").Int() != w || p.canvas.Get("height").Int() != h {
+		if c := js.Global().Get("OffscreenCanvas"); c.Truthy() {
+			p.canvas = c.New(w, h)
+		} else {
+			p.canvas = js.Global().Get("document").Call("createElement", "canvas")
+			p.canvas.Set("width", w)
+			p.canvas.Set("height", h)
+		}
+		p.ctx = p.canvas.Call("getContext", "2d", map[string]interface{}{
+			"willReadFrequently": true,
+		})
+	}
+	p.ctx.Call("drawImage", p.video, 0, 0, w, h)
+	data := p.ctx.Call("getImageData", 0, 0, w, h).Get("data")
+
+	if len(pix) != 4*w*h {
+		pix = make([]byte, 4*w*h)
+	}
+	js.CopyBytesToGo(pix, js.Global().Get("Uint8Array").New(data.Get("buffer"), data.Get("byteOffset"), data.Get("byteLength")))
+	return pix, w, h, true
+}
+
+func (p *elementPlayer) close() error {
+	if !p.video.Truthy() {
+		return nil
+	}
+	if p.frameReq.Truthy() {
+		p.video.Call("cancelVideoFrameCallback", p.frameReq)
+	}
+	p.video.Call("pause")
+	p.video.Call("removeAttribute", "src")
+	p.video.Call("load")
+	p.video = js.Undefined()
+	p.canvas = js.Undefined()
+	p.ctx = js.Undefined()
+	for _, f := range p.funcs {
+		f.Release()
+	}
+	p.funcs = nil
+	return nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xr
+
+import (
+	"image"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/internal/uidriver/js"
+)
+
+func isSessionSupported(mode SessionMode) bool {
+	return js.Get().IsXRSessionSupported(js.XRSessionMode(mode))
+}
+
+func requestSession(mode SessionMode) error {
+	return js.Get().RequestXRSession(js.XRSessionMode(mode))
+}
+
+func endSession() {
+	js.Get().EndXRSession()
+}
+
+func isSessionActive() bool {
+	return js.Get().IsXRSessionActive()
+}
+
+func toPose(p js.XRPose) Pose {
+	return Pose{
+		Position:    p.Position,
+		Orientation: p.Orientation,
+	}
+}
+
+func views() []View {
+	vs := js.Get().XRViews()
+	if len(vs) == 0 {
+		return nil
+	}
+	r := make([]View, 0, len(vs))
+	for _, v := range vs {
+		r = append(r, View{
+			Eye:              Eye(v.Eye),
+			Viewport:         image.Rect(v.X, v.Y, v.X+v.Width, v.Y+v.Height),
+			ProjectionMatrix: v.ProjectionMatrix,
+			Transform:        toPose(v.Transform),
+		})
+	}
+	return r
+}
+
+func inputSources() []InputSource {
+	ss := js.Get().XRInputSources()
+	if len(ss) == 0 {
+		return nil
+	}
+	r := make([]InputSource, 0, len(ss))
+	for _, s := range ss {
+		r = append(r, InputSource{
+			Handedness:    Handedness(s.Handedness),
+			TargetRayPose: toPose(s.TargetRayPose),
+			GripPose:      toPose(s.GripPose),
+			HasGripPose:   s.HasGripPose,
+			GamepadID:     ebiten.GamepadID(s.GamepadID),
+			HasGamepad:    s.HasGamepad,
+		})
+	}
+	return r
+}
@@ -0,0 +1,45 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !js
+// +build !js
+
+package xr
+
+import (
+	"errors"
+)
+
+func isSessionSupported(mode SessionMode) bool {
+	return false
+}
+
+func requestSession(mode SessionMode) error {
+	return errors.New("xr: WebXR is available only on browsers")
+}
+
+func endSession() {
+}
+
+func isSessionActive() bool {
+	return false
+}
+
+func views() []View {
+	return nil
+}
+
+func inputSources() []InputSource {
+	return nil
+}
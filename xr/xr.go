@@ -0,0 +1,147 @@
+// Copyright 2021 The Ebiten Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package xr provides experimental WebXR support on browsers.
+//
+// While a session is active, the game is updated and drawn in the session's animation frames, and the screen is
+// presented on the headset instead of the canvas. The outside size given to the game's Layout is the size of the
+// session's framebuffer, and the device scale factor is 1. If Layout returns the outside size as it is, the screen
+// image's pixels match with the framebuffer's pixels, and the game can render each eye into the viewport in Views.
+//
+// As the game is updated in the session's callbacks, functions that wait for the browser must not be called in
+// Update while a session is active, e.g. decoding audio with the browser's decoder. Call them in another goroutine.
+//
+// The API is experimental and might be changed in the future.
+package xr
+
+import (
+	"image"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// SessionMode represents a mode of a WebXR session.
+type SessionMode int
+
+const (
+	SessionModeImmersiveVR SessionMode = iota
+	SessionModeImmersiveAR
+)
+
+// Eye represents an eye of a view.
+type Eye int
+
+const (
+	EyeNone Eye = iota
+	EyeLeft
+	EyeRight
+)
+
+// Handedness represents which hand an input source is associated with.
+type Handedness int
+
+const (
+	HandednessNone Handedness = iota
+	HandednessLeft
+	HandednessRight
+)
+
+// Pose represents a position and an orientation in the reference space.
+//
+// The unit of the position is meters. The orientation is a quaternion in the order of x, y, z and w.
+// The reference space's origin is on the floor if the platform supports it, or the viewer's position at the
+// session's start otherwise.
+type Pose struct {
+	Position    [3]float64
+	Orientation [4]float64
+}
+
+// View represents a view to render for one eye.
+type View struct {
+	Eye Eye
+
+	// Viewport is the region of the screen to render the view.
+	Viewport image.Rectangle
+
+	// ProjectionMatrix is a projection matrix in column-major order.
+	ProjectionMatrix [16]float64
+
+	// Transform is the pose of the view.
+	Transform Pose
+}
+
+// InputSource represents an input source like a controller.
+type InputSource struct {
+	Handedness Handedness
+
+	// TargetRayPose is the pose of the ray to point at something.
+	TargetRayPose Pose
+
+	// GripPose is the pose of the controller held by the hand.
+	// GripPose is valid only when HasGripPose is true.
+	GripPose    Pose
+	HasGripPose bool
+
+	// GamepadID is the gamepad ID of the input source. The buttons and the axes can be read with the functions
+	// for gamepads like ebiten.IsGamepadButtonPressed.
+	// GamepadID is valid only when HasGamepad is true.
+	GamepadID  ebiten.GamepadID
+	HasGamepad bool
+}
+
+// IsSessionSupported reports whether the session mode is supported.
+//
+// IsSessionSupported returns false until the browser answers, which can take some frames after the game starts.
+//
+// IsSessionSupported returns false on non-browsers.
+func IsSessionSupported(mode SessionMode) bool {
+	return isSessionSupported(mode)
+}
+
+// RequestSession starts a WebXR session.
+//
+// RequestSession blocks until the session starts, and returns an error if the session cannot be started.
+// Browsers require a user gesture to start a session. Call RequestSession in Update just after a user's input.
+//
+// RequestSession returns an error on non-browsers, and when WebGPU is used as the graphics backend. Specify the
+// `ebitengl` build tag to use WebXR.
+func RequestSession(mode SessionMode) error {
+	return requestSession(mode)
+}
+
+// EndSession ends the current session. The session ends asynchronously.
+//
+// The user can also end the session anytime, e.g. by the headset's system menu.
+func EndSession() {
+	endSession()
+}
+
+// IsSessionActive reports whether a session is active.
+func IsSessionActive() bool {
+	return isSessionActive()
+}
+
+// Views returns the views to render in the current frame.
+//
+// Views returns nil if no session is active or the viewer's pose is not known.
+func Views() []View {
+	return views()
+}
+
+// InputSources returns the input sources like controllers in the current frame.
+//
+// InputSources returns nil if no session is active.
+func InputSources() []InputSource {
+	return inputSources()
+}